@@ -213,6 +213,7 @@ func (p *ecsProject) transformTaskDefinition() error {
 		ContainerConfigs:       p.ContainerConfigs(), // TODO Change to pointer on project?
 		ECSParams:              ecsContext.ECSParams,
 		ECSRegistryCreds:       p.ecsRegistryCreds,
+		SavedRoles:             ecsContext.CommandConfig.SavedRoles,
 	}
 
 	taskDefinition, err := composeutils.ConvertToTaskDefinition(convertParams)
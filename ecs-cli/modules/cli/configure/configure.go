@@ -137,6 +137,8 @@ func Cluster(context *cli.Context) error {
 		CFNStackName:             cfnStackName,
 		ComposeServiceNamePrefix: composeServiceNamePrefix,
 		DefaultLaunchType:        launchType,
+		RoleNamePrefix:           context.String(flags.RoleNamePrefixFlag),
+		RoleNameSuffix:           context.String(flags.RoleNameSuffixFlag),
 	}
 
 	rdwr, err := config.NewReadWriter()
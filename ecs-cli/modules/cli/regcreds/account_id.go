@@ -0,0 +1,73 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/pkg/errors"
+)
+
+// AccountIDTemplate is replaced with the account ID in the permissions boundary and the trust policy of a role bundle
+const AccountIDTemplate = "{{.AccountID}}"
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// accountIDTemplateData is the data the permissions boundary and trust policy templates are executed with
+type accountIDTemplateData struct {
+	AccountID string
+}
+
+// validateAccountID checks that the value given with '--account-id' is a 12 digit account number
+func validateAccountID(accountID string) error {
+	if !accountIDPattern.MatchString(accountID) {
+		return fmt.Errorf("invalid value '%s' for '--%s'; expected a 12 digit account ID", accountID, flags.AccountIDFlag)
+	}
+	return nil
+}
+
+// usesAccountIDTemplate returns whether any of the values is a template which needs the account ID
+func usesAccountIDTemplate(values ...string) bool {
+	for _, value := range values {
+		if strings.Contains(value, "{{") {
+			return true
+		}
+	}
+	return false
+}
+
+// expandAccountID returns the value with '{{.AccountID}}' replaced by the account ID. The field names the value in
+// errors. Values which aren't templates are returned unchanged, without needing an account ID.
+func expandAccountID(value, field, accountID string) (string, error) {
+	if !usesAccountIDTemplate(value) {
+		return value, nil
+	}
+	if accountID == "" {
+		return "", fmt.Errorf("the %s uses %s, but no account ID is known; specify '--%s'", field, AccountIDTemplate, flags.AccountIDFlag)
+	}
+	tmpl, err := template.New(field).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid template in the %s", field)
+	}
+	buf := &bytes.Buffer{}
+	if err = tmpl.Execute(buf, accountIDTemplateData{AccountID: accountID}); err != nil {
+		return "", errors.Wrapf(err, "invalid template in the %s; only %s is supported", field, AccountIDTemplate)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAccountID(t *testing.T) {
+	assert.NoError(t, validateAccountID("111111111111"))
+	for _, accountID := range []string{"11111111111", "1111111111111", "11111111111a", "alias", ""} {
+		assert.Error(t, validateAccountID(accountID), "Expected error for account ID '%s'", accountID)
+	}
+}
+
+func TestExpandAccountID(t *testing.T) {
+	expanded, err := expandAccountID("arn:aws:iam::{{.AccountID}}:policy/boundary", "permissions boundary", "111111111111")
+	assert.NoError(t, err, "Unexpected error expanding the account ID")
+	assert.Equal(t, "arn:aws:iam::111111111111:policy/boundary", expanded)
+
+	expanded, err = expandAccountID("arn:aws:iam::222222222222:policy/boundary", "permissions boundary", "")
+	assert.NoError(t, err, "Expected values without a template not to need an account ID")
+	assert.Equal(t, "arn:aws:iam::222222222222:policy/boundary", expanded)
+}
+
+func TestExpandAccountID_Errors(t *testing.T) {
+	_, err := expandAccountID("arn:aws:iam::{{.AccountID}}:policy/boundary", "permissions boundary", "")
+	assert.Error(t, err, "Expected error when no account ID is known")
+
+	_, err = expandAccountID("arn:aws:iam::{{.Account}}:policy/boundary", "permissions boundary", "111111111111")
+	assert.Error(t, err, "Expected error for an unknown template field")
+
+	_, err = expandAccountID("arn:aws:iam::{{.AccountID:policy/boundary", "permissions boundary", "111111111111")
+	assert.Error(t, err, "Expected error for an invalid template")
+}
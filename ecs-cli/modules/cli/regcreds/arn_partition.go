@@ -0,0 +1,70 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/arn"
+)
+
+// givenARN is an ARN from the input or a flag, with a description of where it was given
+type givenARN struct {
+	Source string
+	Value  string
+}
+
+// upGivenARNs returns the ARNs of the registries' secrets and KMS keys and of the policies given with flags, which
+// already encode their partition
+func upGivenARNs(regCreds map[string]regcredio.RegistryCredEntry, additionalPolicyARNs []string, managedPolicyARN string) []givenARN {
+	registryNames := make([]string, 0, len(regCreds))
+	for registryName := range regCreds {
+		registryNames = append(registryNames, registryName)
+	}
+	sort.Strings(registryNames)
+
+	var arns []givenARN
+	for _, registryName := range registryNames {
+		entry := regCreds[registryName]
+		arns = append(arns,
+			givenARN{Source: fmt.Sprintf("'secrets_manager_arn' of registry %s", registryName), Value: entry.SecretManagerARN},
+			givenARN{Source: fmt.Sprintf("'kms_key_id' of registry %s", registryName), Value: entry.KmsKeyID})
+	}
+	for _, policyARN := range additionalPolicyARNs {
+		arns = append(arns, givenARN{Source: fmt.Sprintf("'--%s'", flags.AttachPolicyFlag), Value: policyARN})
+	}
+	arns = append(arns, givenARN{Source: fmt.Sprintf("'--%s'", flags.ManagedPolicyARNFlag), Value: managedPolicyARN})
+	return arns
+}
+
+// partitionMismatchWarnings returns a warning for each ARN in another partition than the region. The ARNs the ECS CLI
+// builds itself, such as that of the AWS managed task execution role policy, are in the partition of the region, so a
+// mismatch usually means that the region or the ARN is wrong. Values which aren't ARNs, such as key aliases, are
+// skipped.
+func partitionMismatchWarnings(region string, arns []givenARN) []string {
+	regionPartition := utils.GetPartition(region)
+	var warnings []string
+	for _, given := range arns {
+		parsedARN, err := arn.Parse(given.Value)
+		if err != nil || parsedARN.Partition == regionPartition {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("ARN %s given with %s is in partition '%s', but region %s is in partition '%s', whose AWS managed task execution role policy is %s; check the ARN or '--%s'", given.Value, given.Source, parsedARN.Partition, region, regionPartition, getExecutionRolePolicyARN(region), flags.RegionFlag))
+	}
+	return warnings
+}
@@ -0,0 +1,43 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPartitionMismatchWarnings(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{
+		"my-registry": {
+			SecretManagerARN: "arn:aws-cn:secretsmanager:cn-north-1:111111111111:secret:my-registry",
+			KmsKeyID:         "alias/my-key",
+		},
+		"other-registry": {
+			SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:other-registry",
+		},
+	}
+	arns := upGivenARNs(regCreds, []string{"arn:aws-cn:iam::aws:policy/AWSXRayDaemonWriteAccess"}, "")
+
+	warnings := partitionMismatchWarnings("us-west-2", arns)
+	assert.Equal(t, 2, len(warnings), "Expected a warning for each ARN in another partition")
+	assert.Contains(t, warnings[0], "registry my-registry")
+	assert.Contains(t, warnings[0], "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy")
+	assert.Contains(t, warnings[1], "'--attach-policy'")
+
+	assert.Equal(t, 1, len(partitionMismatchWarnings("cn-north-1", arns)), "Expected a warning for the ARN in the aws partition")
+	assert.Empty(t, partitionMismatchWarnings("us-west-2", upGivenARNs(nil, nil, "arn:aws:iam::aws:policy/Custom")))
+}
@@ -0,0 +1,96 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"sync"
+	"time"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+)
+
+// attachRetryInterval is the delay between attempts to attach a policy which was denied shortly after creation
+const attachRetryInterval = 2 * time.Second
+
+// attachRetryClient wraps an IAM client so that attaching a policy is retried if access is denied within a short
+// window after the client created a role or policy. IAM is eventually consistent, so a new role or policy may not be
+// visible to authorization yet. Access denied errors outside of the window are returned as is, since they most likely
+// mean that the caller is not allowed to attach the policy. It is safe for concurrent use.
+type attachRetryClient struct {
+	iamClient.Client
+	window      time.Duration
+	mu          sync.Mutex
+	lastCreated time.Time
+	now         func() time.Time
+	sleep       func(time.Duration)
+}
+
+func newAttachRetryClient(client iamClient.Client, window time.Duration) iamClient.Client {
+	return &attachRetryClient{Client: client, window: window, now: time.Now, sleep: time.Sleep}
+}
+
+func (c *attachRetryClient) CreateOrFindRole(input iam.CreateRoleInput) (string, error) {
+	roleARN, err := c.Client.CreateOrFindRole(input)
+	if err == nil && roleARN != "" {
+		c.setLastCreated()
+	}
+	return roleARN, err
+}
+
+func (c *attachRetryClient) CreatePolicy(input iam.CreatePolicyInput) (*iam.CreatePolicyOutput, error) {
+	output, err := c.Client.CreatePolicy(input)
+	if err == nil {
+		c.setLastCreated()
+	}
+	return output, err
+}
+
+func (c *attachRetryClient) setLastCreated() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastCreated = c.now()
+}
+
+func (c *attachRetryClient) getLastCreated() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastCreated
+}
+
+func (c *attachRetryClient) AttachRolePolicy(policyARN, roleName string) (*iam.AttachRolePolicyOutput, error) {
+	retried := false
+	for {
+		output, err := c.Client.AttachRolePolicy(policyARN, roleName)
+		lastCreated := c.getLastCreated()
+		if err == nil || !isAccessDenied(err) || lastCreated.IsZero() {
+			return output, err
+		}
+		remaining := c.window - c.now().Sub(lastCreated)
+		if remaining <= 0 {
+			if retried {
+				return output, errors.Wrapf(err, "access to attach policy %s to role %s was still denied %s after creation; check that you are allowed to call iam:AttachRolePolicy", policyARN, roleName, c.window)
+			}
+			return output, err
+		}
+		delay := attachRetryInterval
+		if remaining < delay {
+			delay = remaining
+		}
+		retryNotice().Warnf("Access denied attaching policy %s to role %s shortly after creation; retrying in %s...", policyARN, roleName, delay)
+		c.sleep(delay)
+		retried = true
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testAttachPolicyARN = "arn:aws:iam::111111111111:policy/myRole-policy"
+	testAttachRoleName  = "myRole"
+)
+
+// newTestAttachRetryClient returns a client whose clock only advances when it sleeps
+func newTestAttachRetryClient(mocks testClients, window time.Duration) (*attachRetryClient, *[]time.Duration) {
+	clock := time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	client := newAttachRetryClient(mocks.MockIAM, window).(*attachRetryClient)
+	client.now = func() time.Time { return clock }
+	client.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		clock = clock.Add(d)
+	}
+	return client, &sleeps
+}
+
+func TestAttachRetryClient_RetriesAccessDeniedAfterCreate(t *testing.T) {
+	accessDenied := awserr.New("AccessDenied", "not authorized to perform iam:AttachRolePolicy", nil)
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testAttachPolicyARN)}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testAttachPolicyARN, testAttachRoleName).Return(nil, accessDenied),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testAttachPolicyARN, testAttachRoleName).Return(nil, accessDenied),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testAttachPolicyARN, testAttachRoleName).Return(&iam.AttachRolePolicyOutput{}, nil),
+	)
+
+	client, sleeps := newTestAttachRetryClient(mocks, 10*time.Second)
+	_, err := client.CreatePolicy(iam.CreatePolicyInput{})
+	assert.NoError(t, err)
+
+	_, err = client.AttachRolePolicy(testAttachPolicyARN, testAttachRoleName)
+	assert.NoError(t, err, "Expected attachment to succeed once the new policy is visible")
+	assert.Equal(t, []time.Duration{attachRetryInterval, attachRetryInterval}, *sleeps)
+}
+
+func TestAttachRetryClient_ErrorOnAccessDeniedAfterWindow(t *testing.T) {
+	accessDenied := awserr.New("AccessDenied", "not authorized to perform iam:AttachRolePolicy", nil)
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::111111111111:role/"+testAttachRoleName, nil)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(testAttachPolicyARN, testAttachRoleName).Return(nil, accessDenied).Times(3)
+
+	client, sleeps := newTestAttachRetryClient(mocks, 3*time.Second)
+	_, err := client.CreateOrFindRole(iam.CreateRoleInput{})
+	assert.NoError(t, err)
+
+	_, err = client.AttachRolePolicy(testAttachPolicyARN, testAttachRoleName)
+	assert.Error(t, err, "Expected persistent access denied error to be returned")
+	assert.Contains(t, err.Error(), "still denied")
+	assert.Equal(t, []time.Duration{attachRetryInterval, time.Second}, *sleeps, "Expected the last delay to be capped by the window")
+}
+
+func TestAttachRetryClient_NoRetryWithoutCreate(t *testing.T) {
+	accessDenied := awserr.New("AccessDenied", "not authorized to perform iam:AttachRolePolicy", nil)
+
+	mocks := setupTestController(t)
+	// an existing role is not a new resource
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(testAttachPolicyARN, testAttachRoleName).Return(nil, accessDenied).Times(1)
+
+	client, sleeps := newTestAttachRetryClient(mocks, 10*time.Second)
+	_, err := client.CreateOrFindRole(iam.CreateRoleInput{})
+	assert.NoError(t, err)
+
+	_, err = client.AttachRolePolicy(testAttachPolicyARN, testAttachRoleName)
+	assert.Equal(t, accessDenied, err, "Expected access denied error to be returned without retrying")
+	assert.Empty(t, *sleeps)
+}
+
+func TestAttachRetryClient_NoRetryOnOtherErrors(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testAttachPolicyARN)}}, nil)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(testAttachPolicyARN, testAttachRoleName).Return(nil, errors.New("something went wrong")).Times(1)
+
+	client, sleeps := newTestAttachRetryClient(mocks, 10*time.Second)
+	_, err := client.CreatePolicy(iam.CreatePolicyInput{})
+	assert.NoError(t, err)
+
+	_, err = client.AttachRolePolicy(testAttachPolicyARN, testAttachRoleName)
+	assert.Error(t, err)
+	assert.Empty(t, *sleeps)
+}
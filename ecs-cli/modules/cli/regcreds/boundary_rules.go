@@ -0,0 +1,64 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+)
+
+// validateIAMPath checks that a value of '--iam-path' begins and ends with '/', as IAM requires
+func validateIAMPath(path string) error {
+	if !strings.HasPrefix(path, "/") || !strings.HasSuffix(path, "/") {
+		return fmt.Errorf("invalid value '%s' for '--%s'; the path must begin and end with '/'", path, flags.IAMPathFlag)
+	}
+	return nil
+}
+
+// validateBoundaryRules checks that new roles at the path get the permissions boundary which the boundary rules of the
+// ECS CLI config require. The rule with the longest path prefix matching the path applies; roles at paths without a
+// rule may have any boundary, or none.
+func validateBoundaryRules(rules map[string]string, path, permissionsBoundary string) error {
+	if path == "" {
+		path = "/"
+	}
+	prefix := ""
+	for rulePrefix := range rules {
+		if !strings.HasPrefix(rulePrefix, "/") || !strings.HasSuffix(rulePrefix, "/") {
+			return fmt.Errorf("invalid boundary rule '%s' in the ECS CLI config; path prefixes must begin and end with '/'", rulePrefix)
+		}
+		if err := validatePolicyARN(rules[rulePrefix]); err != nil {
+			return fmt.Errorf("invalid permissions boundary for boundary rule '%s' in the ECS CLI config: %v", rulePrefix, err)
+		}
+		if strings.HasPrefix(path, rulePrefix) && len(rulePrefix) > len(prefix) {
+			prefix = rulePrefix
+		}
+	}
+	if prefix == "" {
+		return nil
+	}
+
+	required := rules[prefix]
+	if permissionsBoundary == required {
+		return nil
+	}
+	given := "none"
+	if permissionsBoundary != "" {
+		given = permissionsBoundary
+	}
+	return fmt.Errorf("roles at path '%s' must have the permissions boundary %s (boundary rule '%s' in the ECS CLI config), but the boundary is %s; set it with '--%s'",
+		path, required, prefix, given, flags.PermissionsBoundaryFlag)
+}
@@ -0,0 +1,76 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testPaymentsBoundaryARN = "arn:aws:iam::111111111111:policy/payments-boundary"
+	testTeamsBoundaryARN    = "arn:aws:iam::111111111111:policy/teams-boundary"
+)
+
+func TestValidateBoundaryRules(t *testing.T) {
+	rules := map[string]string{
+		"/teams/":          testTeamsBoundaryARN,
+		"/teams/payments/": testPaymentsBoundaryARN,
+	}
+	testCases := []struct {
+		description string
+		path        string
+		boundary    string
+		expectedErr string
+	}{
+		{"Rule satisfied", "/teams/web/", testTeamsBoundaryARN, ""},
+		{"Longest prefix applies", "/teams/payments/api/", testPaymentsBoundaryARN, ""},
+		{"Shorter prefix's boundary", "/teams/payments/", testTeamsBoundaryARN, "must have the permissions boundary " + testPaymentsBoundaryARN + " (boundary rule '/teams/payments/'"},
+		{"Missing boundary", "/teams/web/", "", "but the boundary is none"},
+		{"No rule for path", "/ci/", "", ""},
+		{"Default path", "", "", ""},
+	}
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			err := validateBoundaryRules(rules, test.path, test.boundary)
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateBoundaryRules_RootRule(t *testing.T) {
+	err := validateBoundaryRules(map[string]string{"/": testTeamsBoundaryARN}, "", "")
+	assert.Error(t, err, "Expected the rule for '/' to apply to the default path")
+}
+
+func TestValidateBoundaryRules_InvalidRule(t *testing.T) {
+	err := validateBoundaryRules(map[string]string{"teams": testTeamsBoundaryARN}, "/teams/", testTeamsBoundaryARN)
+	assert.Error(t, err, "Expected error for a prefix without slashes")
+
+	err = validateBoundaryRules(map[string]string{"/teams/": "payments-boundary"}, "/teams/", "payments-boundary")
+	assert.Error(t, err, "Expected error for a boundary which isn't a policy ARN")
+}
+
+func TestValidateIAMPath(t *testing.T) {
+	assert.NoError(t, validateIAMPath("/teams/payments/"))
+	assert.NoError(t, validateIAMPath("/"))
+	assert.Error(t, validateIAMPath("teams/payments"))
+	assert.Error(t, validateIAMPath("/teams"))
+}
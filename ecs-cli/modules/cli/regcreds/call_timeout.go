@@ -0,0 +1,39 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/urfave/cli"
+)
+
+// DefaultTimeoutPerCallSeconds is the default limit of a single AWS API call, including its retries
+const DefaultTimeoutPerCallSeconds = 30
+
+// applyCallTimeout limits each API call made by the clients created from the command's session to --timeout-per-call
+func applyCallTimeout(c *cli.Context, commandConfig *config.CommandConfig) error {
+	seconds := c.Int(flags.TimeoutPerCallFlag)
+	if seconds < 0 {
+		return fmt.Errorf("'--%s' must not be negative", flags.TimeoutPerCallFlag)
+	}
+	if seconds > 0 {
+		clients.AddCallTimeout(&commandConfig.Session.Handlers, time.Duration(seconds)*time.Second)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	cfnTypeRole            = "AWS::IAM::Role"
+	cfnTypeManagedPolicy   = "AWS::IAM::ManagedPolicy"
+	cfnTypeInstanceProfile = "AWS::IAM::InstanceProfile"
+)
+
+// CloudFormation logical IDs may only contain letters and digits
+var invalidLogicalIDChars = regexp.MustCompile(`[^A-Za-z0-9]`)
+
+// writeCloudFormationTemplate writes a template of the roles, instance profiles and policy created by the run, with
+// the list of resources to import next to it, so that they can be imported into a stack
+func writeCloudFormationTemplate(store regcredio.Store, filename string, params ExecutionRoleParams, roleResults []*ExecutionRoleResult, createTime *time.Time) error {
+	createdAt := time.Now().UTC()
+	if createTime != nil && !createTime.IsZero() {
+		createdAt = *createTime
+	}
+	template, resources, err := buildCloudFormationTemplate(params, roleResults, createdAt)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		log.Warnf("No roles or policy were created, so no CloudFormation template is written to %s.", filename)
+		return nil
+	}
+	if err = regcredio.WriteCloudFormationTemplateTo(store, filename, template, resources); err != nil {
+		return errors.Wrapf(err, "failed to write CloudFormation template to %s", filename)
+	}
+	log.Infof("Wrote CloudFormation template to %s and the resources to import to %s", filename, regcredio.CloudFormationImportFileName(filename))
+	return nil
+}
+
+// buildCloudFormationTemplate returns the template and resources to import for the resources created by the run.
+// Existing roles and policies are left out, since the run didn't set all of their properties. The properties are
+// those the resources were created with, so that the import doesn't change them.
+func buildCloudFormationTemplate(params ExecutionRoleParams, roleResults []*ExecutionRoleResult, createdAt time.Time) (regcredio.CloudFormationTemplate, []regcredio.CloudFormationResourceToImport, error) {
+	template := regcredio.CloudFormationTemplate{
+		AWSTemplateFormatVersion: regcredio.CloudFormationTemplateVersion,
+		Description:              "Resources created by 'ecs-cli registry-creds up' on " + createdAt.UTC().Format(time.RFC3339),
+		Resources:                make(map[string]regcredio.CloudFormationResource),
+	}
+	var resources []regcredio.CloudFormationResourceToImport
+	add := func(resourceType, name string, identifier map[string]string, properties map[string]interface{}) string {
+		logicalID := uniqueLogicalID(name, template.Resources)
+		template.Resources[logicalID] = regcredio.CloudFormationResource{
+			Type:           resourceType,
+			DeletionPolicy: regcredio.CloudFormationDeletionPolicyRetain,
+			Properties:     properties,
+		}
+		resources = append(resources, regcredio.CloudFormationResourceToImport{
+			ResourceType:       resourceType,
+			LogicalResourceID:  logicalID,
+			ResourceIdentifier: identifier,
+		})
+		return logicalID
+	}
+
+	policyLogicalID := ""
+	if len(roleResults) > 0 && roleResults[0].PolicyARN != "" {
+		first := roleResults[0]
+		if first.PolicyRefreshed || first.PolicyReused {
+			log.Infof("Policy %s already existed, so it is not added to the CloudFormation template.", first.PolicyARN)
+		} else {
+			policyLogicalID = add(cfnTypeManagedPolicy, first.PolicyName+"Policy", map[string]string{"PolicyArn": first.PolicyARN}, map[string]interface{}{
+				"ManagedPolicyName": first.PolicyName,
+				"Description":       policyDescription(first.RoleName),
+				"PolicyDocument":    PolicyDocument{Version: rolePolicyVersion, Statement: first.PolicyStatements},
+			})
+		}
+	}
+
+	// the trust policy was validated when the roles were created
+	trustPolicy := json.RawMessage(params.trustPolicy())
+	if !json.Valid(trustPolicy) {
+		return template, nil, errors.New("the trust policy of the new roles is not valid JSON")
+	}
+	for _, result := range roleResults {
+		if !result.RoleCreated {
+			log.Infof("Role %s already existed, so it is not added to the CloudFormation template.", result.RoleName)
+			continue
+		}
+		properties := map[string]interface{}{
+			"RoleName":                 result.RoleName,
+			"Description":              roleDescriptionString,
+			"AssumeRolePolicyDocument": trustPolicy,
+		}
+		if params.Path != "" {
+			properties["Path"] = params.Path
+		}
+		if params.PermissionsBoundary != "" {
+			properties["PermissionsBoundary"] = params.PermissionsBoundary
+		}
+		if len(result.Tags) > 0 {
+			tags := make([]map[string]string, 0, len(result.Tags))
+			for _, tag := range result.Tags {
+				tags = append(tags, map[string]string{"Key": aws.StringValue(tag.Key), "Value": aws.StringValue(tag.Value)})
+			}
+			properties["Tags"] = tags
+		}
+		if len(result.AttachedPolicyARNs) > 0 {
+			policyARNs := make([]interface{}, 0, len(result.AttachedPolicyARNs))
+			for _, policyARN := range result.AttachedPolicyARNs {
+				if policyARN == result.PolicyARN && policyLogicalID != "" {
+					// the ARN of the new policy is referenced, so that the stack tracks the attachment
+					policyARNs = append(policyARNs, map[string]string{"Ref": policyLogicalID})
+				} else {
+					policyARNs = append(policyARNs, policyARN)
+				}
+			}
+			properties["ManagedPolicyArns"] = policyARNs
+		}
+		roleLogicalID := add(cfnTypeRole, result.RoleName+"Role", map[string]string{"RoleName": result.RoleName}, properties)
+
+		if result.InstanceProfileCreated {
+			// the instance profile has the name and path of the role
+			profileProperties := map[string]interface{}{
+				"InstanceProfileName": result.RoleName,
+				"Roles":               []interface{}{map[string]string{"Ref": roleLogicalID}},
+			}
+			if params.Path != "" {
+				profileProperties["Path"] = params.Path
+			}
+			add(cfnTypeInstanceProfile, result.RoleName+"InstanceProfile", map[string]string{"InstanceProfileName": result.RoleName}, profileProperties)
+		}
+	}
+	return template, resources, nil
+}
+
+// uniqueLogicalID returns the name without the characters which are not valid in a logical ID, with a number appended
+// if another resource already has it
+func uniqueLogicalID(name string, resources map[string]regcredio.CloudFormationResource) string {
+	name = invalidLogicalIDChars.ReplaceAllString(name, "")
+	candidate := name
+	for i := 2; ; i++ {
+		if _, ok := resources[candidate]; !ok {
+			return candidate
+		}
+		candidate = name + strconv.Itoa(i)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCloudFormationTemplate(t *testing.T) {
+	policyARN := "arn:aws:iam::111111111111:policy/web-policy-20190601T000000Z"
+	managedARN := getExecutionRolePolicyARN("us-west-2")
+	createTime := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	roleResults := []*ExecutionRoleResult{
+		{
+			RoleName:               "web",
+			RoleCreated:            true,
+			Tags:                   []*iam.Tag{{Key: aws.String("ManagedBy"), Value: aws.String("ecs-cli")}},
+			PolicyARN:              policyARN,
+			PolicyName:             "web-policy-20190601T000000Z",
+			PolicyStatements:       []StatementEntry{{Sid: "Web", Effect: "Allow", Action: []string{"secretsmanager:GetSecretValue"}, Resource: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:web"}}},
+			AttachedPolicyARNs:     []string{managedARN, policyARN},
+			InstanceProfileARN:     "arn:aws:iam::111111111111:instance-profile/teams/web",
+			InstanceProfileCreated: true,
+		},
+		{
+			RoleName:           "existing",
+			PolicyARN:          policyARN,
+			AttachedPolicyARNs: []string{managedARN, policyARN},
+		},
+	}
+	params := ExecutionRoleParams{Path: "/teams/", PermissionsBoundary: "arn:aws:iam::111111111111:policy/boundary"}
+	store := regcredio.NewMemoryStore(nil)
+
+	err := writeCloudFormationTemplate(store, "stack.json", params, roleResults, &createTime)
+	require.NoError(t, err, "Unexpected error writing CloudFormation template")
+
+	templateBytes, err := store.ReadFile("stack.json")
+	require.NoError(t, err)
+	template := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(templateBytes, &template))
+	assert.Equal(t, "Resources created by 'ecs-cli registry-creds up' on 2019-06-01T00:00:00Z", template["Description"])
+
+	resources := template["Resources"].(map[string]interface{})
+	assert.Len(t, resources, 3, "Expected the existing role to be left out")
+	policy := resources["webpolicy20190601T000000ZPolicy"].(map[string]interface{})
+	assert.Equal(t, "AWS::IAM::ManagedPolicy", policy["Type"])
+	assert.Equal(t, "Retain", policy["DeletionPolicy"])
+	policyProperties := policy["Properties"].(map[string]interface{})
+	assert.Equal(t, "web-policy-20190601T000000Z", policyProperties["ManagedPolicyName"])
+	assert.Equal(t, "Policy generated by the ecs-cli for role: web", policyProperties["Description"])
+
+	role := resources["webRole"].(map[string]interface{})
+	roleProperties := role["Properties"].(map[string]interface{})
+	assert.Equal(t, "web", roleProperties["RoleName"])
+	assert.Equal(t, "/teams/", roleProperties["Path"])
+	assert.Equal(t, "arn:aws:iam::111111111111:policy/boundary", roleProperties["PermissionsBoundary"])
+	assert.Equal(t, []interface{}{managedARN, map[string]interface{}{"Ref": "webpolicy20190601T000000ZPolicy"}}, roleProperties["ManagedPolicyArns"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"Key": "ManagedBy", "Value": "ecs-cli"}}, roleProperties["Tags"])
+	assert.Equal(t, "2008-10-17", roleProperties["AssumeRolePolicyDocument"].(map[string]interface{})["Version"])
+
+	profileProperties := resources["webInstanceProfile"].(map[string]interface{})["Properties"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"Ref": "webRole"}}, profileProperties["Roles"])
+
+	importBytes, err := store.ReadFile("stack-resources-to-import.json")
+	require.NoError(t, err)
+	var toImport []regcredio.CloudFormationResourceToImport
+	require.NoError(t, json.Unmarshal(importBytes, &toImport))
+	assert.Equal(t, []regcredio.CloudFormationResourceToImport{
+		{ResourceType: "AWS::IAM::ManagedPolicy", LogicalResourceID: "webpolicy20190601T000000ZPolicy", ResourceIdentifier: map[string]string{"PolicyArn": policyARN}},
+		{ResourceType: "AWS::IAM::Role", LogicalResourceID: "webRole", ResourceIdentifier: map[string]string{"RoleName": "web"}},
+		{ResourceType: "AWS::IAM::InstanceProfile", LogicalResourceID: "webInstanceProfile", ResourceIdentifier: map[string]string{"InstanceProfileName": "web"}},
+	}, toImport)
+}
+
+func TestWriteCloudFormationTemplate_NothingCreated(t *testing.T) {
+	roleResults := []*ExecutionRoleResult{{RoleName: "existing", PolicyARN: "arn:aws:iam::111111111111:policy/shared", PolicyReused: true}}
+	store := regcredio.NewMemoryStore(nil)
+
+	err := writeCloudFormationTemplate(store, "stack.json", ExecutionRoleParams{}, roleResults, nil)
+	assert.NoError(t, err)
+	_, err = store.ReadFile("stack.json")
+	assert.Error(t, err, "Expected no template to be written")
+}
+
+func TestUniqueLogicalID(t *testing.T) {
+	resources := map[string]regcredio.CloudFormationResource{"webRole": {}}
+	assert.Equal(t, "webRole2", uniqueLogicalID("web.Role", resources))
+	assert.Equal(t, "teamsapiRole", uniqueLogicalID("teams-api_Role", resources))
+}
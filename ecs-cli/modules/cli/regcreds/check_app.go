@@ -0,0 +1,262 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	driftResourceRole            = "role"
+	driftResourcePolicy          = "policy"
+	driftResourceAttachment      = "attached policy"
+	driftResourceTag             = "tag"
+	driftResourceInstanceProfile = "instance profile"
+
+	driftChangeMissing = "missing"
+	driftChangeChanged = "changed"
+)
+
+// driftEntry is a difference between a resource listed in a manifest and the resource in the account
+type driftEntry struct {
+	Resource string `json:"resource"`
+	// RoleName is the role the resource belongs to, or which the policy was created for
+	RoleName string `json:"roleName"`
+	Change   string `json:"change"`
+	Details  string `json:"details"`
+}
+
+// Check compares the resources listed in a manifest written by 'registry-creds up' with the resources in the account,
+// and exits with an error if any of them were changed or removed. No changes are made.
+func Check(c *cli.Context) {
+	manifestFile := c.String(flags.ManifestFlag)
+	if manifestFile == "" {
+		log.Fatalf("Error executing 'check': no value specified for '--%s'", flags.ManifestFlag)
+	}
+	format := c.String(flags.FormatFlag)
+	if format != "" && format != TableOutputFormat && format != JSONOutputFormat {
+		log.Fatalf("Error executing 'check': invalid value '%s' for '--%s'; valid values are %s and %s", format, flags.FormatFlag, TableOutputFormat, JSONOutputFormat)
+	}
+
+	manifest, err := readCommandManifest(c, manifestFile)
+	if err != nil {
+		log.Fatal("Error executing 'check': ", err)
+	}
+
+	commandConfig := getNewCommandConfig(c, manifest.Region, regionSourceManifest)
+	drift, err := findManifestDrift(*manifest, iam.NewIAMClient(commandConfig))
+	if err != nil {
+		log.Fatal("Error executing 'check': ", err)
+	}
+
+	if format == JSONOutputFormat {
+		err = printDriftJSON(drift, os.Stdout)
+	} else {
+		err = printDriftTable(drift, os.Stdout)
+	}
+	if err != nil {
+		log.Fatal("Error executing 'check': ", err)
+	}
+
+	if len(drift) > 0 {
+		log.Fatalf("Error executing 'check': found %d change(s) to the resources listed in %s", len(drift), manifestFile)
+	}
+}
+
+// findManifestDrift returns the roles, policies, attachments, tags and instance profiles of the manifest which were
+// changed or removed, in the order of the manifest. The rest of a removed role isn't checked. Policies and tags which
+// were added to a role since the run are not reported, since other tools may manage them.
+func findManifestDrift(manifest regcredio.ECSRegCredsManifest, client iam.Client) ([]driftEntry, error) {
+	drift := []driftEntry{}
+	checkedPolicies := make(map[string]bool)
+
+	for _, role := range manifest.Roles {
+		if _, err := client.GetRole(role.RoleName); err != nil {
+			if utils.EntityNotFound(err) {
+				drift = append(drift, driftEntry{Resource: driftResourceRole, RoleName: role.RoleName, Change: driftChangeMissing, Details: "the role no longer exists"})
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get role %s", role.RoleName)
+		}
+
+		if role.PolicyARN != "" && !checkedPolicies[role.PolicyARN] {
+			checkedPolicies[role.PolicyARN] = true
+			policyDrift, err := findPolicyDrift(role, client)
+			if err != nil {
+				return nil, err
+			}
+			drift = append(drift, policyDrift...)
+		}
+
+		attachedPolicies, err := client.ListAttachedRolePolicies(role.RoleName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list policies attached to role %s", role.RoleName)
+		}
+		attached := make(map[string]bool, len(attachedPolicies))
+		for _, policy := range attachedPolicies {
+			attached[aws.StringValue(policy.PolicyArn)] = true
+		}
+		for _, policyARN := range role.AttachedPolicyARNs {
+			if !attached[policyARN] {
+				drift = append(drift, driftEntry{Resource: driftResourceAttachment, RoleName: role.RoleName, Change: driftChangeMissing, Details: policyARN + " is no longer attached"})
+			}
+		}
+
+		if len(role.Tags) > 0 {
+			tagDrift, err := findTagDrift(role, client)
+			if err != nil {
+				return nil, err
+			}
+			drift = append(drift, tagDrift...)
+		}
+
+		if role.InstanceProfile != nil {
+			profileDrift, err := findInstanceProfileDrift(role, client)
+			if err != nil {
+				return nil, err
+			}
+			drift = append(drift, profileDrift...)
+		}
+	}
+	return drift, nil
+}
+
+// findPolicyDrift checks that the policy of the role still exists, and that its default version has the document
+// recorded in the manifest
+func findPolicyDrift(role regcredio.ManifestRole, client iam.Client) ([]driftEntry, error) {
+	document, err := client.GetPolicyDocument(role.PolicyARN)
+	if err != nil {
+		if utils.EntityNotFound(err) {
+			return []driftEntry{{Resource: driftResourcePolicy, RoleName: role.RoleName, Change: driftChangeMissing, Details: role.PolicyARN + " no longer exists"}}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read policy %s", role.PolicyARN)
+	}
+	if role.PolicyDocumentSHA256 == "" {
+		log.Warnf("The manifest doesn't record the document of policy %s, so only its existence is checked", role.PolicyARN)
+		return nil, nil
+	}
+	digest, err := policyDocumentDigest(document)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse policy %s", role.PolicyARN)
+	}
+	if digest != role.PolicyDocumentSHA256 {
+		return []driftEntry{{Resource: driftResourcePolicy, RoleName: role.RoleName, Change: driftChangeChanged, Details: "the document of " + role.PolicyARN + " was changed"}}, nil
+	}
+	return nil, nil
+}
+
+// findTagDrift returns the tags recorded in the manifest which were removed from the role or given another value
+func findTagDrift(role regcredio.ManifestRole, client iam.Client) ([]driftEntry, error) {
+	tags, err := client.ListRoleTags(role.RoleName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list tags of role %s", role.RoleName)
+	}
+	current := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		current[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	keys := make([]string, 0, len(role.Tags))
+	for key := range role.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var drift []driftEntry
+	for _, key := range keys {
+		value, ok := current[key]
+		switch {
+		case !ok:
+			drift = append(drift, driftEntry{Resource: driftResourceTag, RoleName: role.RoleName, Change: driftChangeMissing, Details: fmt.Sprintf("%s=%s was removed", key, role.Tags[key])})
+		case value != role.Tags[key]:
+			drift = append(drift, driftEntry{Resource: driftResourceTag, RoleName: role.RoleName, Change: driftChangeChanged, Details: fmt.Sprintf("%s=%s -> %s=%s", key, role.Tags[key], key, value)})
+		}
+	}
+	return drift, nil
+}
+
+// findInstanceProfileDrift checks that the instance profile still exists and, if the run added the role to it, that
+// it still contains the role
+func findInstanceProfileDrift(role regcredio.ManifestRole, client iam.Client) ([]driftEntry, error) {
+	profile, err := client.GetInstanceProfile(role.InstanceProfile.Name)
+	if err != nil {
+		if utils.EntityNotFound(err) {
+			return []driftEntry{{Resource: driftResourceInstanceProfile, RoleName: role.RoleName, Change: driftChangeMissing, Details: role.InstanceProfile.Name + " no longer exists"}}, nil
+		}
+		return nil, errors.Wrapf(err, "failed to get instance profile %s", role.InstanceProfile.Name)
+	}
+	if !role.InstanceProfile.RoleAdded {
+		return nil, nil
+	}
+	for _, profileRole := range profile.Roles {
+		if aws.StringValue(profileRole.RoleName) == role.RoleName {
+			return nil, nil
+		}
+	}
+	return []driftEntry{{Resource: driftResourceInstanceProfile, RoleName: role.RoleName, Change: driftChangeChanged, Details: "the role was removed from " + role.InstanceProfile.Name}}, nil
+}
+
+// policyDocumentDigest returns the SHA-256 of the policy document with its keys in a stable order and without
+// whitespace, so that only changes to its contents change the digest
+func policyDocumentDigest(document string) (string, error) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(document), &parsed); err != nil {
+		return "", err
+	}
+	normalized, err := json.Marshal(parsed)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(normalized)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+func printDriftJSON(drift []driftEntry, w io.Writer) error {
+	data, err := json.MarshalIndent(drift, jsonPrefix, jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal drift to JSON")
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func printDriftTable(drift []driftEntry, w io.Writer) error {
+	if len(drift) == 0 {
+		_, err := fmt.Fprintln(w, "No drift found.")
+		return err
+	}
+	tw := new(tabwriter.Writer)
+	tw.Init(w, cellWidthInSpaces, widthBetweenCellsInSpaces, cellPaddingInSpaces, paddingCharacter, noFormatting)
+	fmt.Fprintln(tw, "RESOURCE\tROLE\tCHANGE\tDETAILS")
+	for _, entry := range drift {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Resource, entry.RoleName, entry.Change, entry.Details)
+	}
+	return tw.Flush()
+}
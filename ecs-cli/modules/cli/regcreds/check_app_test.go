@@ -0,0 +1,136 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCheckPolicyDocument = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["secretsmanager:GetSecretValue"],"Resource":["arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-myreg.test.io"]}]}`
+
+func checkManifest(t *testing.T) regcredio.ECSRegCredsManifest {
+	digest, err := policyDocumentDigest(testCheckPolicyDocument)
+	require.NoError(t, err)
+	return regcredio.ECSRegCredsManifest{
+		Region: "us-west-2",
+		Roles: []regcredio.ManifestRole{{
+			RoleName:             testManifestRoleName,
+			Created:              true,
+			PolicyARN:            testManifestPolicyARN,
+			AttachedPolicyARNs:   []string{getExecutionRolePolicyARN("us-west-2"), testManifestPolicyARN},
+			PolicyDocumentSHA256: digest,
+			Tags:                 map[string]string{"Team": "payments", DefaultManagementTagKey: DefaultManagementTagValue},
+			InstanceProfile:      &regcredio.ManifestInstanceProfile{Name: testManifestRoleName, Created: true, RoleAdded: true},
+		}},
+	}
+}
+
+func attachedPolicies(policyARNs ...string) []*iam.AttachedPolicy {
+	var policies []*iam.AttachedPolicy
+	for _, policyARN := range policyARNs {
+		policies = append(policies, &iam.AttachedPolicy{PolicyArn: aws.String(policyARN)})
+	}
+	return policies
+}
+
+func TestFindManifestDrift_NoDrift(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole(testManifestRoleName).Return(&iam.Role{RoleName: aws.String(testManifestRoleName)}, nil)
+	// the document is compared regardless of whitespace and key order
+	mocks.MockIAM.EXPECT().GetPolicyDocument(testManifestPolicyARN).Return(`{"Statement": [{"Resource": ["arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-myreg.test.io"], "Effect": "Allow", "Action": ["secretsmanager:GetSecretValue"]}], "Version": "2012-10-17"}`, nil)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testManifestRoleName).Return(attachedPolicies(testManifestPolicyARN, "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess", getExecutionRolePolicyARN("us-west-2")), nil)
+	mocks.MockIAM.EXPECT().ListRoleTags(testManifestRoleName).Return([]*iam.Tag{
+		{Key: aws.String(DefaultManagementTagKey), Value: aws.String(DefaultManagementTagValue)},
+		{Key: aws.String("Team"), Value: aws.String("payments")},
+		{Key: aws.String("Owner"), Value: aws.String("platform")},
+	}, nil)
+	mocks.MockIAM.EXPECT().GetInstanceProfile(testManifestRoleName).Return(&iam.InstanceProfile{Roles: []*iam.Role{{RoleName: aws.String(testManifestRoleName)}}}, nil)
+
+	drift, err := findManifestDrift(checkManifest(t), mocks.MockIAM)
+	require.NoError(t, err, "Unexpected error checking manifest")
+	assert.Empty(t, drift, "Expected no drift, since policies and tags added to the role are ignored")
+}
+
+func TestFindManifestDrift_Changed(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole(testManifestRoleName).Return(&iam.Role{RoleName: aws.String(testManifestRoleName)}, nil)
+	mocks.MockIAM.EXPECT().GetPolicyDocument(testManifestPolicyARN).Return(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["secretsmanager:*"],"Resource":["*"]}]}`, nil)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testManifestRoleName).Return(attachedPolicies(testManifestPolicyARN), nil)
+	mocks.MockIAM.EXPECT().ListRoleTags(testManifestRoleName).Return([]*iam.Tag{
+		{Key: aws.String("Team"), Value: aws.String("checkout")},
+	}, nil)
+	mocks.MockIAM.EXPECT().GetInstanceProfile(testManifestRoleName).Return(&iam.InstanceProfile{}, nil)
+
+	drift, err := findManifestDrift(checkManifest(t), mocks.MockIAM)
+	require.NoError(t, err, "Unexpected error checking manifest")
+	assert.Equal(t, []driftEntry{
+		{Resource: driftResourcePolicy, RoleName: testManifestRoleName, Change: driftChangeChanged, Details: "the document of " + testManifestPolicyARN + " was changed"},
+		{Resource: driftResourceAttachment, RoleName: testManifestRoleName, Change: driftChangeMissing, Details: getExecutionRolePolicyARN("us-west-2") + " is no longer attached"},
+		{Resource: driftResourceTag, RoleName: testManifestRoleName, Change: driftChangeMissing, Details: "ManagedBy=ecs-cli was removed"},
+		{Resource: driftResourceTag, RoleName: testManifestRoleName, Change: driftChangeChanged, Details: "Team=payments -> Team=checkout"},
+		{Resource: driftResourceInstanceProfile, RoleName: testManifestRoleName, Change: driftChangeChanged, Details: "the role was removed from " + testManifestRoleName},
+	}, drift)
+}
+
+func TestFindManifestDrift_Removed(t *testing.T) {
+	manifest := checkManifest(t)
+	manifest.Roles = append(manifest.Roles, regcredio.ManifestRole{
+		RoleName:           "myOtherRole",
+		PolicyARN:          testManifestPolicyARN,
+		AttachedPolicyARNs: []string{testManifestPolicyARN},
+	})
+	notFound := awserr.New("NoSuchEntity", "not found", nil)
+
+	mocks := setupTestController(t)
+	// the rest of a removed role is not checked, and the shared policy is only checked once
+	mocks.MockIAM.EXPECT().GetRole(testManifestRoleName).Return(nil, notFound)
+	mocks.MockIAM.EXPECT().GetRole("myOtherRole").Return(&iam.Role{RoleName: aws.String("myOtherRole")}, nil)
+	mocks.MockIAM.EXPECT().GetPolicyDocument(testManifestPolicyARN).Return("", notFound)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myOtherRole").Return(nil, nil)
+
+	drift, err := findManifestDrift(manifest, mocks.MockIAM)
+	require.NoError(t, err, "Unexpected error checking manifest")
+	assert.Equal(t, []driftEntry{
+		{Resource: driftResourceRole, RoleName: testManifestRoleName, Change: driftChangeMissing, Details: "the role no longer exists"},
+		{Resource: driftResourcePolicy, RoleName: "myOtherRole", Change: driftChangeMissing, Details: testManifestPolicyARN + " no longer exists"},
+		{Resource: driftResourceAttachment, RoleName: "myOtherRole", Change: driftChangeMissing, Details: testManifestPolicyARN + " is no longer attached"},
+	}, drift)
+}
+
+func TestFindManifestDrift_Error(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole(testManifestRoleName).Return(nil, awserr.New("AccessDenied", "not allowed", nil))
+
+	_, err := findManifestDrift(checkManifest(t), mocks.MockIAM)
+	assert.Error(t, err, "Expected errors other than a missing resource to fail the check")
+}
+
+func TestPrintDriftTable(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, printDriftTable([]driftEntry{}, buf))
+	assert.Equal(t, "No drift found.\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, printDriftTable([]driftEntry{{Resource: driftResourceRole, RoleName: testManifestRoleName, Change: driftChangeMissing, Details: "the role no longer exists"}}, buf))
+	assert.Contains(t, buf.String(), "RESOURCE")
+	assert.Contains(t, buf.String(), "the role no longer exists")
+}
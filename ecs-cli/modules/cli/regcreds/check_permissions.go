@@ -0,0 +1,210 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	stsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/sts"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	kmsDescribeKeyAction = "kms:DescribeKey"
+
+	iamCreateRoleAction       = "iam:CreateRole"
+	iamTagRoleAction          = "iam:TagRole"
+	iamCreatePolicyAction     = "iam:CreatePolicy"
+	iamAttachRolePolicyAction = "iam:AttachRolePolicy"
+)
+
+// requiredPermission is an action a run of 'up' needs on a set of resources
+type requiredPermission struct {
+	Action    string
+	Resources []string
+}
+
+// checkCallerPermissions checks with the IAM policy simulator that the caller is allowed the actions 'up' needs, before
+// any resources are created, and returns an error listing the missing permissions
+func checkCallerPermissions(regCreds map[string]regcredio.RegistryCredEntry, params ExecutionRoleParams, callerClient stsClient.Client, client iamClient.Client) error {
+	callerARN, err := callerClient.GetCallerARN()
+	if err != nil {
+		return errors.Wrap(err, "unable to get the caller of the credentials")
+	}
+	principalARN, err := simulationPrincipalARN(callerARN, client)
+	if err != nil {
+		return err
+	}
+	principal, _ := arn.Parse(principalARN)
+
+	permissions, err := requiredPermissions(regCreds, params, principal.AccountID, client)
+	if err != nil {
+		return err
+	}
+	missing, err := missingPermissions(principalARN, permissions, client)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s is missing %d permission(s) needed by this run; no resources were created or changed:\n  %s", principalARN, len(missing), strings.Join(missing, "\n  "))
+	}
+	log.Infof("%s has the IAM and KMS permissions needed by this run", principalARN)
+	return nil
+}
+
+// createsPolicy returns whether the role setup generates a policy for the given number of registries
+func (params ExecutionRoleParams) createsPolicy(registryCount int) bool {
+	return !params.RoleOnly && (registryCount > 0 || params.MinimalManaged || (params.IncludeECR && len(params.ECRRepositories) > 0))
+}
+
+// requiredPermissions returns the IAM and KMS actions 'up' needs on the resources it would create or change: new roles
+// are created and tagged, the new policy is created and attached with the other policies to each role, and the KMS
+// keys of the registries are described to generate the policy. Secrets Manager permissions are not included.
+func requiredPermissions(regCreds map[string]regcredio.RegistryCredEntry, params ExecutionRoleParams, accountID string, client iamClient.Client) ([]requiredPermission, error) {
+	partition := utils.GetPartition(params.Region)
+	roleNames := params.roleNames()
+	path := params.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var newRoleARNs, roleARNs []string
+	for _, roleName := range roleNames {
+		roleARN := fmt.Sprintf("arn:%s:iam::%s:role%s%s", partition, accountID, path, roleName)
+		role, err := client.GetRole(roleName)
+		if err != nil && !isNoSuchEntity(err) {
+			return nil, errors.Wrapf(err, "failed to get role %s", roleName)
+		}
+		if role != nil {
+			roleARN = aws.StringValue(role.Arn)
+		} else {
+			newRoleARNs = append(newRoleARNs, roleARN)
+		}
+		roleARNs = append(roleARNs, roleARN)
+	}
+
+	var permissions []requiredPermission
+	if len(newRoleARNs) > 0 {
+		permissions = append(permissions,
+			requiredPermission{Action: iamCreateRoleAction, Resources: newRoleARNs},
+			requiredPermission{Action: iamTagRoleAction, Resources: newRoleARNs},
+		)
+	}
+	if params.createsPolicy(len(regCreds)) {
+		policyARN := fmt.Sprintf("arn:%s:iam::%s:policy/%s", partition, accountID, requiredPermissionPolicyName(roleNames[0], params))
+		permissions = append(permissions, requiredPermission{Action: iamCreatePolicyAction, Resources: []string{policyARN}})
+	}
+	permissions = append(permissions, requiredPermission{Action: iamAttachRolePolicyAction, Resources: roleARNs})
+
+	if keyARNs := registryKeyARNs(regCreds, partition, params.Region, accountID); len(keyARNs) > 0 && params.createsPolicy(len(regCreds)) {
+		permissions = append(permissions, requiredPermission{Action: kmsDescribeKeyAction, Resources: keyARNs})
+	}
+	return permissions, nil
+}
+
+// requiredPermissionPolicyName returns the name of the new policy, with a wildcard for the parts which are only known
+// once it is created
+func requiredPermissionPolicyName(roleName string, params ExecutionRoleParams) string {
+	switch {
+	case params.PolicyName != "":
+		return params.PolicyName
+	case params.PolicyNameFromHash:
+		return aws.StringValue(generateECSResourceName(contentPolicyNameMarker + "*"))
+	default:
+		return aws.StringValue(generateECSResourceName(roleName + "-policy-*"))
+	}
+}
+
+// registryKeyARNs returns the ARNs of the KMS keys given for the registries, sorted. Key IDs and aliases are expanded to
+// ARNs in the account and region of the run.
+func registryKeyARNs(regCreds map[string]regcredio.RegistryCredEntry, partition, region, accountID string) []string {
+	keys := make(map[string]bool)
+	for _, entry := range regCreds {
+		keyID := entry.KmsKeyID
+		switch {
+		case keyID == "":
+			continue
+		case isARN(keyID):
+			keys[keyID] = true
+		case strings.HasPrefix(keyID, "alias/"):
+			keys[fmt.Sprintf("arn:%s:kms:%s:%s:%s", partition, region, accountID, keyID)] = true
+		default:
+			keys[fmt.Sprintf("arn:%s:kms:%s:%s:key/%s", partition, region, accountID, keyID)] = true
+		}
+	}
+	keyARNs := make([]string, 0, len(keys))
+	for keyARN := range keys {
+		keyARNs = append(keyARNs, keyARN)
+	}
+	sort.Strings(keyARNs)
+	return keyARNs
+}
+
+// simulationPrincipalARN returns the ARN the policy simulator evaluates the caller's permissions for. The session ARN
+// of an assumed role is resolved to the ARN of the role, which includes its path; IAM users are used as they are.
+func simulationPrincipalARN(callerARN string, client iamClient.Client) (string, error) {
+	parsedARN, err := arn.Parse(callerARN)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid caller ARN %s", callerARN)
+	}
+	switch {
+	case parsedARN.Service == "iam" && strings.HasPrefix(parsedARN.Resource, "user/"):
+		return callerARN, nil
+	case parsedARN.Service == "sts" && strings.HasPrefix(parsedARN.Resource, "assumed-role/"):
+		roleName := strings.Split(parsedARN.Resource, "/")[1]
+		role, err := client.GetRole(roleName)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get role %s of the caller", roleName)
+		}
+		return aws.StringValue(role.Arn), nil
+	default:
+		return "", fmt.Errorf("the permissions of %s can't be checked; only IAM users and roles are supported", callerARN)
+	}
+}
+
+// missingPermissions simulates each required action as the principal and returns the actions which would be denied,
+// with the resource and what denied them, if the simulator reports it
+func missingPermissions(principalARN string, permissions []requiredPermission, client iamClient.Client) ([]string, error) {
+	var missing []string
+	for _, permission := range permissions {
+		evaluations, err := client.SimulatePrincipalPolicy(iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(principalARN),
+			ActionNames:     aws.StringSlice([]string{permission.Action}),
+			ResourceArns:    aws.StringSlice(permission.Resources),
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to simulate %s for %s", permission.Action, principalARN)
+		}
+		for _, evaluation := range evaluations {
+			if aws.StringValue(evaluation.EvalDecision) == iam.PolicyEvaluationDecisionTypeAllowed {
+				continue
+			}
+			entry := fmt.Sprintf("%s on %s", aws.StringValue(evaluation.EvalActionName), aws.StringValue(evaluation.EvalResourceName))
+			if detail := simulationDenialDetail(evaluation); detail != "" {
+				entry += fmt.Sprintf(" (denied by %s)", detail)
+			}
+			missing = append(missing, entry)
+		}
+	}
+	return missing, nil
+}
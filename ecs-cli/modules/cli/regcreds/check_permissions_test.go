@@ -0,0 +1,133 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/sts/mock"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCallerRoleARN = "arn:aws:iam::111111111111:role/ci/deploy"
+
+func TestRequiredPermissions(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{
+		"example.com":       {Username: "user", Password: "pass", KmsKeyID: "1234"},
+		"other.example.com": {Username: "user", Password: "pass", KmsKeyID: "arn:aws:kms:us-west-2:111111111111:key/5678"},
+		"alias.example.com": {Username: "user", Password: "pass", KmsKeyID: "alias/registry"},
+	}
+	params := ExecutionRoleParams{RoleNames: []string{"newRole", "existingRole"}, Region: "us-west-2", Path: "/ecs/"}
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("newRole").Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil))
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(&iam.Role{Arn: aws.String("arn:aws:iam::111111111111:role/existingRole")}, nil)
+
+	permissions, err := requiredPermissions(regCreds, params, "111111111111", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error getting required permissions")
+	assert.Equal(t, []requiredPermission{
+		{Action: "iam:CreateRole", Resources: []string{"arn:aws:iam::111111111111:role/ecs/newRole"}},
+		{Action: "iam:TagRole", Resources: []string{"arn:aws:iam::111111111111:role/ecs/newRole"}},
+		{Action: "iam:CreatePolicy", Resources: []string{"arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-newRole-policy-*"}},
+		{Action: "iam:AttachRolePolicy", Resources: []string{"arn:aws:iam::111111111111:role/ecs/newRole", "arn:aws:iam::111111111111:role/existingRole"}},
+		{Action: "kms:DescribeKey", Resources: []string{
+			"arn:aws:kms:us-west-2:111111111111:alias/registry",
+			"arn:aws:kms:us-west-2:111111111111:key/1234",
+			"arn:aws:kms:us-west-2:111111111111:key/5678",
+		}},
+	}, permissions)
+}
+
+func TestRequiredPermissions_RoleOnly(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{"example.com": {Username: "user", Password: "pass", KmsKeyID: "1234"}}
+	params := ExecutionRoleParams{RoleNames: []string{"existingRole"}, Region: "us-west-2", RoleOnly: true}
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(&iam.Role{Arn: aws.String("arn:aws:iam::111111111111:role/existingRole")}, nil)
+
+	permissions, err := requiredPermissions(regCreds, params, "111111111111", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error getting required permissions")
+	assert.Equal(t, []requiredPermission{
+		{Action: "iam:AttachRolePolicy", Resources: []string{"arn:aws:iam::111111111111:role/existingRole"}},
+	}, permissions, "Expected no policy or KMS permissions without a new policy")
+}
+
+func TestSimulationPrincipalARN(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("deploy").Return(&iam.Role{Arn: aws.String(testCallerRoleARN)}, nil)
+
+	principalARN, err := simulationPrincipalARN("arn:aws:sts::111111111111:assumed-role/deploy/session", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error resolving assumed role")
+	assert.Equal(t, testCallerRoleARN, principalARN, "Expected the ARN of the role with its path")
+
+	principalARN, err = simulationPrincipalARN("arn:aws:iam::111111111111:user/alice", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error resolving user")
+	assert.Equal(t, "arn:aws:iam::111111111111:user/alice", principalARN)
+
+	_, err = simulationPrincipalARN("arn:aws:iam::111111111111:root", mocks.MockIAM)
+	assert.Error(t, err, "Expected error for the root user")
+}
+
+func TestCheckCallerPermissions_Missing(t *testing.T) {
+	mocks := setupTestController(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSTS := mock_sts.NewMockClient(ctrl)
+	mockSTS.EXPECT().GetCallerARN().Return("arn:aws:sts::111111111111:assumed-role/deploy/session", nil)
+
+	roleARN := "arn:aws:iam::111111111111:role/existingRole"
+	mocks.MockIAM.EXPECT().GetRole("deploy").Return(&iam.Role{Arn: aws.String(testCallerRoleARN)}, nil)
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(&iam.Role{Arn: aws.String(roleARN)}, nil)
+	mocks.MockIAM.EXPECT().SimulatePrincipalPolicy(gomock.Any()).Do(func(x interface{}) {
+		input := x.(iam.SimulatePrincipalPolicyInput)
+		assert.Equal(t, testCallerRoleARN, aws.StringValue(input.PolicySourceArn))
+	}).Return([]*iam.EvaluationResult{{
+		EvalActionName:   aws.String("iam:AttachRolePolicy"),
+		EvalResourceName: aws.String(roleARN),
+		EvalDecision:     aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny),
+	}}, nil)
+
+	params := ExecutionRoleParams{RoleNames: []string{"existingRole"}, Region: "us-west-2", RoleOnly: true}
+	err := checkCallerPermissions(nil, params, mockSTS, mocks.MockIAM)
+	assert.Error(t, err, "Expected error for missing permissions")
+	assert.Contains(t, err.Error(), "missing 1 permission(s)")
+	assert.Contains(t, err.Error(), "iam:AttachRolePolicy on "+roleARN)
+}
+
+func TestCheckCallerPermissions_Allowed(t *testing.T) {
+	mocks := setupTestController(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSTS := mock_sts.NewMockClient(ctrl)
+	mockSTS.EXPECT().GetCallerARN().Return("arn:aws:iam::111111111111:user/alice", nil)
+
+	mocks.MockIAM.EXPECT().GetRole("newRole").Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil))
+	mocks.MockIAM.EXPECT().SimulatePrincipalPolicy(gomock.Any()).DoAndReturn(func(input iam.SimulatePrincipalPolicyInput) ([]*iam.EvaluationResult, error) {
+		return []*iam.EvaluationResult{{
+			EvalActionName:   input.ActionNames[0],
+			EvalResourceName: input.ResourceArns[0],
+			EvalDecision:     aws.String(iam.PolicyEvaluationDecisionTypeAllowed),
+		}}, nil
+	}).Times(4)
+
+	params := ExecutionRoleParams{RoleNames: []string{"newRole"}, Region: "us-west-2"}
+	regCreds := map[string]regcredio.RegistryCredEntry{"example.com": {Username: "user", Password: "pass"}}
+	err := checkCallerPermissions(regCreds, params, mockSTS, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when all permissions are allowed")
+}
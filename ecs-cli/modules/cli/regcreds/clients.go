@@ -0,0 +1,54 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Clients are the IAM and KMS clients which set up task execution roles. Build them once with NewClients and pass them
+// to any number of CreateTaskExecutionRole and CreateTaskExecutionRoles calls, rather than building a session for
+// each call, so that credentials are only resolved once.
+//
+// The clients are safe for concurrent use, e.g. by calls from several goroutines or with ExecutionRoleParams.Concurrency
+// above 1: the AWS SDK's service clients are, and the ECS CLI clients wrapping them keep no state between requests.
+// The session must not be changed while the clients are in use.
+type Clients struct {
+	IAM iamClient.Client
+	KMS kmsClient.Client
+}
+
+// NewClients returns the IAM and KMS clients for the session. They share the session's credentials, which are resolved
+// on the first request and cached until they expire, so a new session is only needed for other credentials or another
+// region.
+func NewClients(sess *session.Session) Clients {
+	commandConfig := &config.CommandConfig{Session: sess}
+	return Clients{
+		IAM: iamClient.NewIAMClient(commandConfig),
+		KMS: kmsClient.NewKMSClient(commandConfig),
+	}
+}
+
+// CreateTaskExecutionRole calls CreateTaskExecutionRole with the clients
+func (clients Clients) CreateTaskExecutionRole(params ExecutionRoleParams) (*ExecutionRoleResult, error) {
+	return CreateTaskExecutionRole(params, clients.IAM, clients.KMS)
+}
+
+// CreateTaskExecutionRoles calls CreateTaskExecutionRoles with the clients
+func (clients Clients) CreateTaskExecutionRoles(params ExecutionRoleParams) ([]*ExecutionRoleResult, error) {
+	return CreateTaskExecutionRoles(params, clients.IAM, clients.KMS)
+}
@@ -0,0 +1,76 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingProvider is a credentials.Provider which counts how often credentials are resolved
+type countingProvider struct {
+	mu        sync.Mutex
+	retrieved int
+}
+
+func (p *countingProvider) Retrieve() (credentials.Value, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.retrieved++
+	return credentials.Value{AccessKeyID: "AKID", SecretAccessKey: "SKID", ProviderName: "counting"}, nil
+}
+
+func (p *countingProvider) IsExpired() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.retrieved == 0
+}
+
+func TestNewClients_SharesCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	provider := &countingProvider{}
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(server.URL),
+		DisableSSL:  aws.Bool(true),
+		MaxRetries:  aws.Int(0),
+		Credentials: credentials.NewCredentials(provider),
+	})
+	assert.NoError(t, err, "Unexpected error creating session")
+
+	clients := NewClients(sess)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clients.IAM.GetRole("myRole")
+			clients.KMS.GetValidKeyARN("arn:aws:kms:us-west-2:111111111111:key/1234")
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, provider.retrieved, "Expected the credentials to be resolved once for all requests")
+}
@@ -0,0 +1,135 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"time"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// complianceInventorySource identifies the ECS CLI command in the compliance inventory
+const complianceInventorySource = "ecs-cli registry-creds up"
+
+// buildComplianceInventory returns the inventory of the roles, registry credentials policies and secrets of the run.
+// The roleARNs are in the order of the roleResults. Secrets which the run didn't create are reported as reused, and
+// every secret has the secretTags, which the run applies to all of them.
+func buildComplianceInventory(roleResults []*ExecutionRoleResult, roleARNs []string, creds map[string]regcredio.CredsOutputEntry, createdSecrets []regcredio.ManifestSecret, secretTags map[string]*string, region string, generatedAt time.Time) regcredio.ECSComplianceInventory {
+	var resources []regcredio.ComplianceResource
+	policies := make(map[string]bool)
+	for i, result := range roleResults {
+		change := regcredio.ComplianceChangeReused
+		if result.RoleCreated {
+			change = regcredio.ComplianceChangeCreated
+		}
+		tags := make(map[string]string, len(result.Tags))
+		for _, tag := range result.Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		resources = append(resources, regcredio.ComplianceResource{
+			ResourceType:     regcredio.ComplianceResourceTypeRole,
+			ResourceARN:      roleARNs[i],
+			ResourceName:     result.RoleName,
+			Change:           change,
+			Tags:             tags,
+			AttachedPolicies: result.AttachedPolicyARNs,
+		})
+
+		// the registry credentials policy is shared by the roles of the run
+		if result.PolicyARN == "" || policies[result.PolicyARN] {
+			continue
+		}
+		policies[result.PolicyARN] = true
+		resources = append(resources, regcredio.ComplianceResource{
+			ResourceType: regcredio.ComplianceResourceTypePolicy,
+			ResourceARN:  result.PolicyARN,
+			ResourceName: result.PolicyName,
+			Change:       compliancePolicyChange(result),
+			Grants:       complianceGrants(result.PolicyStatements),
+		})
+	}
+
+	created := make(map[string]bool, len(createdSecrets))
+	for _, secret := range createdSecrets {
+		created[secret.SecretARN] = true
+	}
+	for registryName, entry := range creds {
+		change := regcredio.ComplianceChangeReused
+		if created[entry.CredentialARN] {
+			change = regcredio.ComplianceChangeCreated
+		}
+		resources = append(resources, regcredio.ComplianceResource{
+			ResourceType: regcredio.ComplianceResourceTypeSecret,
+			ResourceARN:  entry.CredentialARN,
+			ResourceName: registryName,
+			Change:       change,
+			Tags:         aws.StringValueMap(secretTags),
+		})
+	}
+
+	return regcredio.ECSComplianceInventory{
+		GeneratedAt: generatedAt,
+		Source:      complianceInventorySource,
+		Region:      region,
+		Resources:   resources,
+	}
+}
+
+func compliancePolicyChange(result *ExecutionRoleResult) string {
+	switch {
+	case result.PolicyRefreshed:
+		return regcredio.ComplianceChangeUpdated
+	case result.PolicyReused:
+		return regcredio.ComplianceChangeReused
+	default:
+		return regcredio.ComplianceChangeCreated
+	}
+}
+
+func complianceGrants(statements []StatementEntry) []regcredio.ComplianceGrant {
+	grants := make([]regcredio.ComplianceGrant, 0, len(statements))
+	for _, statement := range statements {
+		grants = append(grants, regcredio.ComplianceGrant{
+			Sid:       statement.Sid,
+			Effect:    statement.Effect,
+			Actions:   statement.Action,
+			Resources: statement.Resource,
+			Condition: statement.Condition,
+		})
+	}
+	return grants
+}
+
+// writeComplianceInventory writes the compliance inventory of the run to the given file. The roles are looked up for
+// their ARNs if they were not created by the run.
+func writeComplianceInventory(store regcredio.Store, filename string, roleResults []*ExecutionRoleResult, creds map[string]regcredio.CredsOutputEntry, createdSecrets []regcredio.ManifestSecret, secretTags map[string]*string, region string, createTime *time.Time, client iamClient.Client) error {
+	roleARNs, err := getRoleARNs(roleResults, client)
+	if err != nil {
+		return err
+	}
+	generatedAt := time.Now().UTC()
+	if createTime != nil {
+		generatedAt = *createTime
+	}
+	inventory := buildComplianceInventory(roleResults, roleARNs, creds, createdSecrets, secretTags, region, generatedAt)
+	if err = regcredio.WriteComplianceInventoryTo(store, inventory, filename); err != nil {
+		return errors.Wrapf(err, "failed to write compliance inventory to %s", filename)
+	}
+	log.Infof("Wrote compliance inventory to %s", filename)
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildComplianceInventory(t *testing.T) {
+	policyARN := "arn:aws:iam::111111111111:policy/myPolicy"
+	statements := []StatementEntry{{
+		Sid:      "Registry0",
+		Effect:   "Allow",
+		Action:   []string{"secretsmanager:GetSecretValue"},
+		Resource: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:new"},
+	}}
+	roleResults := []*ExecutionRoleResult{
+		{
+			RoleName:           "newRole",
+			RoleCreated:        true,
+			Tags:               []*iam.Tag{{Key: aws.String("Team"), Value: aws.String("payments")}},
+			PolicyARN:          policyARN,
+			PolicyName:         "myPolicy",
+			PolicyStatements:   statements,
+			AttachedPolicyARNs: []string{getExecutionRolePolicyARN("us-west-2"), policyARN},
+		},
+		{
+			RoleName:           "existingRole",
+			PolicyARN:          policyARN,
+			PolicyName:         "myPolicy",
+			PolicyStatements:   statements,
+			AttachedPolicyARNs: []string{policyARN},
+		},
+	}
+	roleARNs := []string{"arn:aws:iam::111111111111:role/newRole", "arn:aws:iam::111111111111:role/existingRole"}
+	creds := map[string]regcredio.CredsOutputEntry{
+		"new.example.com":      {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:new"},
+		"existing.example.com": {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:existing"},
+	}
+	createdSecrets := []regcredio.ManifestSecret{{RegistryName: "new.example.com", SecretARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:new"}}
+	generatedAt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	inventory := buildComplianceInventory(roleResults, roleARNs, creds, createdSecrets, map[string]*string{"Env": aws.String("prod")}, "us-west-2", generatedAt)
+	data, err := regcredio.FormatComplianceInventory(inventory)
+	assert.NoError(t, err)
+	var written regcredio.ECSComplianceInventory
+	assert.NoError(t, json.Unmarshal(data, &written))
+
+	assert.Equal(t, "ecs-cli registry-creds up", written.Source)
+	assert.Equal(t, []regcredio.ComplianceResource{
+		{
+			ResourceType: regcredio.ComplianceResourceTypePolicy,
+			ResourceARN:  policyARN,
+			ResourceName: "myPolicy",
+			Change:       regcredio.ComplianceChangeCreated,
+			Tags:         map[string]string{},
+			Grants: []regcredio.ComplianceGrant{{
+				Sid:       "Registry0",
+				Effect:    "Allow",
+				Actions:   []string{"secretsmanager:GetSecretValue"},
+				Resources: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:new"},
+			}},
+		},
+		{
+			ResourceType:     regcredio.ComplianceResourceTypeRole,
+			ResourceARN:      "arn:aws:iam::111111111111:role/existingRole",
+			ResourceName:     "existingRole",
+			Change:           regcredio.ComplianceChangeReused,
+			Tags:             map[string]string{},
+			AttachedPolicies: []string{policyARN},
+		},
+		{
+			ResourceType:     regcredio.ComplianceResourceTypeRole,
+			ResourceARN:      "arn:aws:iam::111111111111:role/newRole",
+			ResourceName:     "newRole",
+			Change:           regcredio.ComplianceChangeCreated,
+			Tags:             map[string]string{"Team": "payments"},
+			AttachedPolicies: []string{getExecutionRolePolicyARN("us-west-2"), policyARN},
+		},
+		{
+			ResourceType: regcredio.ComplianceResourceTypeSecret,
+			ResourceARN:  "arn:aws:secretsmanager:us-west-2:111111111111:secret:existing",
+			ResourceName: "existing.example.com",
+			Change:       regcredio.ComplianceChangeReused,
+			Tags:         map[string]string{"Env": "prod"},
+		},
+		{
+			ResourceType: regcredio.ComplianceResourceTypeSecret,
+			ResourceARN:  "arn:aws:secretsmanager:us-west-2:111111111111:secret:new",
+			ResourceName: "new.example.com",
+			Change:       regcredio.ComplianceChangeCreated,
+			Tags:         map[string]string{"Env": "prod"},
+		},
+	}, written.Resources, "Expected the shared policy once, and resources sorted by type and ARN")
+}
+
+func TestCompliancePolicyChange(t *testing.T) {
+	assert.Equal(t, regcredio.ComplianceChangeUpdated, compliancePolicyChange(&ExecutionRoleResult{PolicyRefreshed: true}))
+	assert.Equal(t, regcredio.ComplianceChangeReused, compliancePolicyChange(&ExecutionRoleResult{PolicyReused: true}))
+	assert.Equal(t, regcredio.ComplianceChangeCreated, compliancePolicyChange(&ExecutionRoleResult{}))
+}
@@ -0,0 +1,90 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+)
+
+// DefaultConcurrency processes the roles and registries of a run one at a time
+const DefaultConcurrency = 1
+
+// validateConcurrency checks that at least one item is processed at a time
+func validateConcurrency(concurrency int) error {
+	if concurrency < 1 {
+		return fmt.Errorf("'--%s' must be at least 1", flags.ConcurrencyFlag)
+	}
+	return nil
+}
+
+// runItems calls process for the item at each index of keys, running up to concurrency calls at once. Items with the
+// same key are processed one at a time in the order given, so that two items changing the same resource never
+// overlap. process returns false if the item failed; if stopOnFailure is set, items which have not been started by
+// then are passed to skip instead. Callers store each item's result at its index, so results are in the order of keys
+// whatever order the items finish in.
+func runItems(keys []string, concurrency int, stopOnFailure bool, process func(i int) bool, skip func(i int)) {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+
+	// group the indexes by key, keeping the order of the first occurrence of each key
+	var groups [][]int
+	groupOfKey := make(map[string]int, len(keys))
+	for i, key := range keys {
+		if group, ok := groupOfKey[key]; ok {
+			groups[group] = append(groups[group], i)
+			continue
+		}
+		groupOfKey[key] = len(groups)
+		groups = append(groups, []int{i})
+	}
+	if concurrency > len(groups) {
+		concurrency = len(groups)
+	}
+
+	var mu sync.Mutex
+	failed := false
+	pending := make(chan []int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for group := range pending {
+				for _, i := range group {
+					mu.Lock()
+					stopped := stopOnFailure && failed
+					mu.Unlock()
+					if stopped {
+						skip(i)
+						continue
+					}
+					if !process(i) {
+						mu.Lock()
+						failed = true
+						mu.Unlock()
+					}
+				}
+			}
+		}()
+	}
+	for _, group := range groups {
+		pending <- group
+	}
+	close(pending)
+	wg.Wait()
+}
@@ -0,0 +1,170 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunItems_BoundedConcurrency(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	results := make([]string, len(keys))
+
+	runItems(keys, 2, false, func(i int) bool {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		results[i] = "done " + keys[i]
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return true
+	}, nil)
+
+	assert.Equal(t, 2, maxRunning, "Expected at most 2 items at once")
+	assert.Equal(t, []string{"done a", "done b", "done c", "done d", "done e", "done f"}, results)
+}
+
+func TestRunItems_SameKeyIsSerialized(t *testing.T) {
+	keys := []string{"role", "other", "role", "role"}
+	var mu sync.Mutex
+	var order []int
+	active := 0
+
+	runItems(keys, 4, false, func(i int) bool {
+		if keys[i] == "role" {
+			mu.Lock()
+			active++
+			assert.Equal(t, 1, active, "Expected items with the same key not to overlap")
+			order = append(order, i)
+			mu.Unlock()
+			time.Sleep(2 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}
+		return true
+	}, nil)
+
+	assert.Equal(t, []int{0, 2, 3}, order, "Expected items with the same key to keep their order")
+}
+
+func TestRunItems_StopOnFailure(t *testing.T) {
+	keys := []string{"a", "b", "c"}
+	var processed, skipped []string
+
+	runItems(keys, 1, true, func(i int) bool {
+		processed = append(processed, keys[i])
+		return keys[i] != "b"
+	}, func(i int) {
+		skipped = append(skipped, keys[i])
+	})
+
+	assert.Equal(t, []string{"a", "b"}, processed)
+	assert.Equal(t, []string{"c"}, skipped)
+}
+
+func TestValidateConcurrency(t *testing.T) {
+	assert.NoError(t, validateConcurrency(1))
+	assert.NoError(t, validateConcurrency(8))
+	assert.Error(t, validateConcurrency(0))
+	assert.Error(t, validateConcurrency(-2))
+}
+
+func TestCreateTaskExecutionRoles_Concurrent(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:some-test-arn", "", []string{"test"}),
+	}
+	var testRoleNames []string
+	for i := 0; i < 6; i++ {
+		testRoleNames = append(testRoleNames, fmt.Sprintf("myRole%d", i))
+	}
+	testPolicyArn := "arn:aws:iam::111111111111:policy/myRole0-policy"
+
+	mocks := setupTestController(t)
+	for _, roleName := range testRoleNames {
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(roleName, defaultManagementTags())).Return("", nil)
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), roleName).Return(nil, nil)
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testPolicyArn, roleName).Return(nil, nil)
+	}
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testPolicyArn)}}, nil)
+
+	roleResults, err := CreateTaskExecutionRoles(ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleNames:   testRoleNames,
+		Region:      "us-west-2",
+		Concurrency: 3,
+	}, newAttachRetryClient(mocks.MockIAM, time.Second), mocks.MockKMS)
+	require.NoError(t, err, "Unexpected error when creating task execution roles")
+	require.Len(t, roleResults, len(testRoleNames))
+	for i, roleResult := range roleResults {
+		assert.Equal(t, testRoleNames[i], roleResult.RoleName, "Expected results in the order of the roles")
+		assert.Equal(t, []string{getExecutionRolePolicyARN("us-west-2"), testPolicyArn}, roleResult.AttachedPolicyARNs)
+	}
+}
+
+func TestGetOrCreateRegistryCredentials_Concurrent(t *testing.T) {
+	testRegistryCreds := regcredio.RegistryCreds{}
+	var expectedSecrets []regcredio.ManifestSecret
+	mocks := setupTestController(t)
+	for i := 0; i < 5; i++ {
+		registryName := fmt.Sprintf("registry%d.example.com", i)
+		secretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:" + registryName
+		testRegistryCreds[registryName] = regcredio.RegistryCredEntry{Username: "user", Password: "pass"}
+		expectedSecrets = append(expectedSecrets, regcredio.ManifestSecret{RegistryName: registryName, SecretARN: secretARN})
+
+		mocks.MockSM.EXPECT().DescribeSecret(*generateECSResourceName(registryName)).Return(nil, nil)
+		mocks.MockSM.EXPECT().CreateSecret(secretsmanager.CreateSecretInput{
+			Name:         generateECSResourceName(registryName),
+			SecretString: generateSecretString("user", "pass"),
+			Description:  generateSecretDescription(registryName),
+		}).Return(&secretsmanager.CreateSecretOutput{ARN: aws.String(secretARN)}, nil)
+	}
+
+	credsOutput, createdSecrets, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false, 3)
+	require.NoError(t, err, "Unexpected error when creating secrets")
+	assert.Len(t, credsOutput, 5)
+	assert.Equal(t, expectedSecrets, createdSecrets, "Expected the new secrets in the order of the registry names")
+}
+
+func TestGetOrCreateRegistryCredentials_StopsAfterFailure(t *testing.T) {
+	testRegistryCreds := regcredio.RegistryCreds{
+		"a.example.com": regcredio.RegistryCredEntry{Username: "user", Password: "pass"},
+		"b.example.com": regcredio.RegistryCredEntry{Username: "user", Password: "pass"},
+	}
+	mocks := setupTestController(t)
+	mocks.MockSM.EXPECT().DescribeSecret(*generateECSResourceName("a.example.com")).Return(nil, nil)
+	mocks.MockSM.EXPECT().CreateSecret(gomock.Any()).Return(nil, errors.New("limit exceeded"))
+
+	_, _, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false, 1)
+	assert.EqualError(t, err, "limit exceeded")
+}
@@ -0,0 +1,109 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	log "github.com/sirupsen/logrus"
+)
+
+// consolidatedSid is the Sid of a statement combining the statements of several registries
+const consolidatedSid = "RegistryCredentials"
+
+// consolidateStatements combines the statements with the same effect, actions and conditions into a single statement
+// listing all of their resources, in the position of the first one. Since their actions and conditions are the same,
+// the combined statement grants exactly the access of the statements it replaces. Statements are only combined if
+// all of their resources are in the same account and region; otherwise they are returned unchanged. A combined
+// statement belongs to no single registry.
+func consolidateStatements(statements []StatementEntry) []StatementEntry {
+	if !sameAccountAndRegion(statements) {
+		log.Debug("The registry credentials span several accounts or regions, so their policy statements are not combined.")
+		return statements
+	}
+
+	var groups [][]StatementEntry
+	for _, statement := range statements {
+		found := false
+		for i, group := range groups {
+			if canCombineStatements(group[0], statement) {
+				groups[i] = append(group, statement)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, []StatementEntry{statement})
+		}
+	}
+
+	// the Sids of single statements are kept, so the combined statements must not reuse them
+	usedSids := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		if len(group) == 1 {
+			usedSids[group[0].Sid] = true
+		}
+	}
+	consolidated := make([]StatementEntry, 0, len(groups))
+	for _, group := range groups {
+		if len(group) == 1 {
+			consolidated = append(consolidated, group[0])
+			continue
+		}
+		combined := StatementEntry{
+			Sid:       uniqueSid(consolidatedSid, usedSids),
+			Effect:    group[0].Effect,
+			Action:    group[0].Action,
+			Condition: group[0].Condition,
+		}
+		seenResources := make(map[string]bool)
+		for _, statement := range group {
+			for _, resource := range statement.Resource {
+				if !seenResources[resource] {
+					seenResources[resource] = true
+					combined.Resource = append(combined.Resource, resource)
+				}
+			}
+		}
+		consolidated = append(consolidated, combined)
+	}
+	return consolidated
+}
+
+// canCombineStatements returns whether the statements grant the same actions under the same conditions
+func canCombineStatements(a, b StatementEntry) bool {
+	return a.Effect == b.Effect && reflect.DeepEqual(a.Action, b.Action) && reflect.DeepEqual(a.Condition, b.Condition)
+}
+
+// sameAccountAndRegion returns whether every resource of the statements is an ARN in the same account and region
+func sameAccountAndRegion(statements []StatementEntry) bool {
+	account, region := "", ""
+	first := true
+	for _, statement := range statements {
+		for _, resource := range statement.Resource {
+			parsedARN, err := arn.Parse(resource)
+			if err != nil {
+				return false
+			}
+			if first {
+				account, region = parsedARN.AccountID, parsedARN.Region
+				first = false
+			} else if parsedARN.AccountID != account || parsedARN.Region != region {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsolidateStatements(t *testing.T) {
+	mocks := setupTestController(t)
+	keyARN := "arn:aws:kms:us-west-2:111111111111:key/1234"
+	mocks.MockKMS.EXPECT().GetValidKeyARN(keyARN).Return(keyARN, nil).Times(2)
+
+	credEntries := map[string]regcredio.CredsOutputEntry{
+		"first":  {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:first"},
+		"second": {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:second"},
+		"third":  {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:third", KMSKeyID: keyARN},
+		"fourth": {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:fourth", KMSKeyID: keyARN},
+	}
+	statements, err := generateSecretsStatements(credEntries, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating statements")
+	assert.Equal(t, 5, len(statements))
+
+	consolidated := consolidateStatements(statements)
+	assert.Equal(t, 2, len(consolidated), "Expected the secret statements to be combined")
+	assert.Equal(t, consolidatedSid, consolidated[0].Sid)
+	assert.Equal(t, []string{secretsGetValueAction}, consolidated[0].Action)
+	assert.Equal(t, []string{
+		"arn:aws:secretsmanager:us-west-2:111111111111:secret:first",
+		"arn:aws:secretsmanager:us-west-2:111111111111:secret:fourth",
+		"arn:aws:secretsmanager:us-west-2:111111111111:secret:second",
+		"arn:aws:secretsmanager:us-west-2:111111111111:secret:third",
+	}, consolidated[0].Resource)
+	assert.Empty(t, consolidated[0].registryName, "Expected the combined statement to belong to no registry")
+	assert.Equal(t, sharedKeySid, consolidated[1].Sid, "Expected a single statement to keep its Sid")
+	assert.Equal(t, []string{keyARN}, consolidated[1].Resource)
+}
+
+func TestConsolidateStatements_DifferentConditions(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(gomock.Any()).Times(0)
+
+	credEntries := map[string]regcredio.CredsOutputEntry{
+		"first": {
+			CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:first",
+			SecretScope:   &regcredio.SecretScope{ResourceTags: map[string]string{"team": "web"}},
+		},
+		"second": {
+			CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:second",
+			SecretScope:   &regcredio.SecretScope{ResourceTags: map[string]string{"team": "data"}},
+		},
+		"third": {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:third"},
+	}
+	statements, err := generateSecretsStatements(credEntries, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating statements")
+	assert.Equal(t, statements, consolidateStatements(statements), "Expected statements with different conditions to be kept")
+}
+
+func TestConsolidateStatements_SeveralRegions(t *testing.T) {
+	statements := []StatementEntry{
+		{Sid: "First", Effect: "Allow", Action: []string{secretsGetValueAction}, Resource: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:first"}},
+		{Sid: "Second", Effect: "Allow", Action: []string{secretsGetValueAction}, Resource: []string{"arn:aws:secretsmanager:us-east-1:111111111111:secret:second"}},
+	}
+	assert.Equal(t, statements, consolidateStatements(statements), "Expected statements in several regions to be kept")
+
+	statements[1].Resource = []string{"arn:aws:secretsmanager:us-west-2:222222222222:secret:second"}
+	assert.Equal(t, statements, consolidateStatements(statements), "Expected statements in several accounts to be kept")
+}
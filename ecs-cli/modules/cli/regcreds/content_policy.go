@@ -0,0 +1,82 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// content addressed policy names don't contain the generatedPolicyNameMarker, so that a shared policy is never
+// refreshed or pruned as the stale policy of a single role
+const contentPolicyNameMarker = "sha256-"
+
+// the number of hex digits of the document hash used in the policy name
+const contentPolicyHashLength = 32
+
+// contentPolicyName returns the name of the policy with the given document, derived from the hash of the document so
+// that identical policies generated by different runs have the same name
+func contentPolicyName(policyDoc string) string {
+	sum := sha256.Sum256([]byte(policyDoc))
+	return aws.StringValue(generateECSResourceName(contentPolicyNameMarker + hex.EncodeToString(sum[:])[:contentPolicyHashLength]))
+}
+
+func isContentPolicyName(policyName string) bool {
+	return strings.HasPrefix(policyName, utils.ECSCLIResourcePrefix+contentPolicyNameMarker)
+}
+
+// isSharedContentPolicy returns whether the error deleting the policy is because it is a content addressed policy which
+// is still attached to the roles of other runs
+func isSharedContentPolicy(policyARN string, err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == iam.ErrCodeDeleteConflictException && isContentPolicyName(policyNameFromARN(policyARN))
+}
+
+// createOrReuseContentPolicy creates the policy named after the hash of its document, or returns the existing policy
+// with that name, which is returned as reused. An existing policy whose document no longer matches its name (i.e. it
+// was given another version outside of the ECS CLI) is an error rather than being reused.
+func createOrReuseContentPolicy(roleName, policyDoc string, client iamClient.Client) (string, bool, error) {
+	policyName := contentPolicyName(policyDoc)
+	newPolicy, err := createRegistryCredentialsPolicy(aws.String(policyName), roleName, policyDoc, client)
+	if err == nil {
+		return aws.StringValue(newPolicy.Arn), false, nil
+	}
+	if !utils.EntityAlreadyExists(err) {
+		return "", false, err
+	}
+
+	policyARN, err := findLocalPolicyARN(policyName, client)
+	if err != nil {
+		return "", false, err
+	}
+	existingDoc, err := client.GetPolicyDocument(policyARN)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "failed to get the document of policy %s", policyARN)
+	}
+	if existingDoc != policyDoc {
+		return "", false, fmt.Errorf("policy %s has the name of the new policy document but a different document; it may have been changed outside of the ECS CLI", policyARN)
+	}
+	log.Infof("Reusing policy %s, which has the same document", policyARN)
+	return policyARN, true, nil
+}
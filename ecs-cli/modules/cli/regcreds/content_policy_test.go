@@ -0,0 +1,202 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testContentPolicyDoc = `{"Version":"2012-10-17","Statement":[]}`
+
+func TestContentPolicyName(t *testing.T) {
+	policyName := contentPolicyName(testContentPolicyDoc)
+	assert.True(t, strings.HasPrefix(policyName, "amazon-ecs-cli-setup-sha256-"), "Unexpected policy name %s", policyName)
+	assert.Len(t, policyName, len("amazon-ecs-cli-setup-sha256-")+contentPolicyHashLength)
+	assert.NoError(t, validatePolicyName(policyName))
+	assert.True(t, isContentPolicyName(policyName))
+	assert.False(t, isGeneratedPolicyName(policyName), "Expected content addressed policies not to be treated as stale")
+	assert.NotEqual(t, policyName, contentPolicyName(`{"Version":"2012-10-17","Statement":[{}]}`))
+}
+
+func TestContentPolicyName_StableAcrossRuns(t *testing.T) {
+	credEntries := map[string]regcredio.CredsOutputEntry{
+		"a.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:a", "", []string{"web"}),
+		"b.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:b", "", []string{"web"}),
+		"c.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:c", "", []string{"web"}),
+	}
+
+	mocks := setupTestController(t)
+	names := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		policyDoc, err := generateSecretsPolicy(credEntries, "AWSCURRENT", mocks.MockKMS)
+		assert.NoError(t, err, "Unexpected error generating policy")
+		names[contentPolicyName(policyDoc)] = true
+	}
+	assert.Len(t, names, 1, "Expected the same registries to always give the same policy name")
+}
+
+func TestCreateOrReuseContentPolicy_Created(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Do(func(x interface{}) {
+		input := x.(iam.CreatePolicyInput)
+		assert.Equal(t, contentPolicyName(testContentPolicyDoc), aws.StringValue(input.PolicyName))
+	}).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testFixedPolicyARN)}}, nil)
+
+	policyARN, reused, err := createOrReuseContentPolicy("myRole", testContentPolicyDoc, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error creating content addressed policy")
+	assert.Equal(t, testFixedPolicyARN, policyARN)
+	assert.False(t, reused, "Expected new policy not to be reported as reused")
+}
+
+func TestCreateOrReuseContentPolicy_Reused(t *testing.T) {
+	policyName := contentPolicyName(testContentPolicyDoc)
+	policyARN := "arn:aws:iam::111111111111:policy/" + policyName
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, "policy exists", nil)),
+		mocks.MockIAM.EXPECT().ListPoliciesPages(gomock.Any()).Do(func(x interface{}) {
+			fn := x.(func(*iam.ListPoliciesOutput, bool) bool)
+			fn(&iam.ListPoliciesOutput{Policies: []*iam.Policy{{PolicyName: aws.String(policyName), Arn: aws.String(policyARN)}}}, true)
+		}).Return(nil),
+		mocks.MockIAM.EXPECT().GetPolicyDocument(policyARN).Return(testContentPolicyDoc, nil),
+	)
+	mocks.MockIAM.EXPECT().CreatePolicyVersion(gomock.Any(), gomock.Any()).Times(0)
+
+	reusedARN, reused, err := createOrReuseContentPolicy("myRole", testContentPolicyDoc, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error reusing content addressed policy")
+	assert.Equal(t, policyARN, reusedARN)
+	assert.True(t, reused, "Expected existing policy to be reported as reused")
+}
+
+func TestCreateOrReuseContentPolicy_ErrorOnChangedDocument(t *testing.T) {
+	policyName := contentPolicyName(testContentPolicyDoc)
+	policyARN := "arn:aws:iam::111111111111:policy/" + policyName
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, "policy exists", nil)),
+		mocks.MockIAM.EXPECT().ListPoliciesPages(gomock.Any()).Do(func(x interface{}) {
+			fn := x.(func(*iam.ListPoliciesOutput, bool) bool)
+			fn(&iam.ListPoliciesOutput{Policies: []*iam.Policy{{PolicyName: aws.String(policyName), Arn: aws.String(policyARN)}}}, true)
+		}).Return(nil),
+		mocks.MockIAM.EXPECT().GetPolicyDocument(policyARN).Return(`{"Version":"2012-10-17","Statement":[{}]}`, nil),
+	)
+
+	_, _, err := createOrReuseContentPolicy("myRole", testContentPolicyDoc, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the existing policy has another document")
+}
+
+func TestCreateTaskExecutionRoles_InvalidPolicyNameFromHash(t *testing.T) {
+	testCases := map[string]ExecutionRoleParams{
+		"with policy name": {PolicyNameFromHash: true, PolicyName: testFixedPolicyName},
+		"with refresh":     {PolicyNameFromHash: true, RefreshExistingPolicy: true},
+	}
+	for name, testParams := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mocks := setupTestController(t)
+			mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+
+			testParams.CredEntries = map[string]regcredio.CredsOutputEntry{
+				"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+			}
+			testParams.RoleName = testLimitRoleName
+			testParams.Region = "us-west-2"
+
+			_, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+			assert.Error(t, err, "Expected error for invalid policy options")
+		})
+	}
+}
+
+func TestCreateTaskExecutionRoles_ReusedContentPolicyAttachments(t *testing.T) {
+	policyDoc := ""
+	policyARN := func() string {
+		return "arn:aws:iam::111111111111:policy/" + contentPolicyName(policyDoc)
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).DoAndReturn(func(input iam.CreatePolicyInput) (*iam.CreatePolicyOutput, error) {
+			policyDoc = aws.StringValue(input.PolicyDocument)
+			return nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, "policy exists", nil)
+		}),
+		mocks.MockIAM.EXPECT().ListPoliciesPages(gomock.Any()).Do(func(x interface{}) {
+			fn := x.(func(*iam.ListPoliciesOutput, bool) bool)
+			fn(&iam.ListPoliciesOutput{Policies: []*iam.Policy{{PolicyName: aws.String(contentPolicyName(policyDoc)), Arn: aws.String(policyARN())}}}, true)
+		}).Return(nil),
+		mocks.MockIAM.EXPECT().GetPolicyDocument(gomock.Any()).DoAndReturn(func(string) (string, error) {
+			return policyDoc, nil
+		}),
+		// the first role already has the shared policy attached
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myRole").DoAndReturn(func(string) ([]*iam.AttachedPolicy, error) {
+			return []*iam.AttachedPolicy{{PolicyName: aws.String(contentPolicyName(policyDoc)), PolicyArn: aws.String(policyARN())}}, nil
+		}),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), "myRole").Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(gomock.Any(), "myRole").Return(nil, nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myOtherRole").Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), "myOtherRole").Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(gomock.Any(), "myOtherRole").Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+		},
+		RoleNames:          []string{"myRole", "myOtherRole"},
+		Region:             "us-west-2",
+		PolicyNameFromHash: true,
+	}
+
+	roleResults, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when reusing the content addressed policy")
+	assert.True(t, roleResults[0].PolicyReused, "Expected the policy to be reported as reused")
+
+	// only the attachment made by this run is removed by 'down'
+	manifest := buildManifest(roleResults, nil, "us-west-2", time.Now().UTC())
+	assert.Equal(t, 2, len(manifest.Roles))
+	assert.Empty(t, manifest.Roles[0].AttachedPolicyARNs, "Expected the existing attachment not to be listed")
+	assert.Equal(t, []string{policyARN()}, manifest.Roles[1].AttachedPolicyARNs)
+}
+
+func TestRemoveManifestResources_KeepsSharedContentPolicy(t *testing.T) {
+	policyARN := "arn:aws:iam::111111111111:policy/" + contentPolicyName(testContentPolicyDoc)
+	manifest := regcredio.ECSRegCredsManifest{
+		Roles: []regcredio.ManifestRole{{
+			RoleName:           testManifestRoleName,
+			PolicyARN:          policyARN,
+			AttachedPolicyARNs: []string{policyARN},
+		}},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().DetachRolePolicy(policyARN, testManifestRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().DeletePolicy(policyARN).Return(awserr.New(iam.ErrCodeDeleteConflictException, "policy is attached", nil)),
+	)
+
+	err := removeManifestResources(manifest, mocks.MockIAM, mocks.MockSM)
+	assert.NoError(t, err, "Expected a content addressed policy attached to other roles to be kept")
+}
@@ -15,71 +15,658 @@ package regcreds
 
 import (
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
 	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 const (
 	assumeRolePolicyDocString = `{"Version":"2008-10-17","Statement":[{"Sid":"","Effect":"Allow","Principal":{"Service":"ecs-tasks.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
 	roleDescriptionString     = "Role generated by the ecs-cli"
+
+	// DefaultManagementTagKey is the key of the tag used to identify roles created by the ecs-cli
+	DefaultManagementTagKey = "ManagedBy"
+	// DefaultManagementTagValue is the value of the tag used to identify roles created by the ecs-cli
+	DefaultManagementTagValue = "ecs-cli"
+
+	// AttachOrderManagedFirst attaches the AWS managed task execution role policy before the generated policy
+	AttachOrderManagedFirst = "managed-first"
+	// AttachOrderGeneratedFirst attaches the generated policy before the AWS managed task execution role policy
+	AttachOrderGeneratedFirst = "generated-first"
 )
 
-type executionRoleParams struct {
-	CredEntries map[string]regcredio.CredsOutputEntry
-	RoleName    string
-	Region      string
-	Tags        map[string]*string
+// errSkippedAfterFailure is set on the roles which were not set up because FailFast stopped at an earlier failure
+var errSkippedAfterFailure = errors.New("skipped after an earlier role failed")
+
+// ExecutionRoleParams contains the values used to create or update a task execution role for registry credentials
+type ExecutionRoleParams struct {
+	CredEntries  map[string]regcredio.CredsOutputEntry
+	RoleName     string
+	Region       string
+	Tags         map[string]*string
+	VersionStage string
+	// RoleNames, if given, replace RoleName so that the new policy is shared by several roles; only used by
+	// CreateTaskExecutionRoles
+	RoleNames []string
+	// ManagementTagKey and ManagementTagValue identify the role as created by the ecs-cli; if unset, the defaults are used
+	ManagementTagKey   string
+	ManagementTagValue string
+	// PermissionsBoundary is the ARN of the policy set as the permissions boundary of a new role; optional
+	PermissionsBoundary string
+	// TrustPolicy is the assume role policy document of a new role; if unset, ECS tasks are allowed to assume the role
+	TrustPolicy string
+	// RequiredSessionTags, if set, are the session tags a new role's default trust policy requires, by key; an empty
+	// value only requires the tag to be present. They can't be combined with a TrustPolicy.
+	RequiredSessionTags map[string]string
+	// Path is the path of a new role; optional
+	Path string
+	// CreateInstanceProfile indicates whether an instance profile with the same name should be created for the role, for
+	// use with the EC2 launch type
+	CreateInstanceProfile bool
+	// MaxPoliciesPerRole is the number of managed policies which may be attached to an existing role; if unset, the
+	// number of attached policies is not checked
+	MaxPoliciesPerRole int
+	// PruneStalePolicies allows policies previously generated by the ecs-cli to be detached from an existing role to
+	// stay within MaxPoliciesPerRole
+	PruneStalePolicies bool
+	// AdditionalPolicyARNs are existing managed policies attached to each role after the new policy; each must exist
+	AdditionalPolicyARNs []string
+	// ExpectedAccountID, if set, is the account of the caller's credentials; an existing role is only reused if its ARN
+	// is in this account
+	ExpectedAccountID string
+	// AttachOrder is AttachOrderManagedFirst or AttachOrderGeneratedFirst; if unset, the managed policy is attached first
+	AttachOrder string
+	// AllowEmpty allows CredEntries to be empty, in which case no policy is generated (unless IncludeECR is set) and
+	// only the managed task execution role policy (and any AdditionalPolicyARNs) are attached
+	AllowEmpty bool
+	// RoleOnly creates or finds the roles and attaches only the managed task execution role policy (and any
+	// AdditionalPolicyARNs), without generating a policy for the CredEntries, which may then be empty. It can't be
+	// combined with the options of the new policy.
+	RoleOnly bool
+	// ConsolidateStatements combines the statements of registries with the same actions and conditions into one, if all
+	// of the registries' secrets and KMS keys are in the same account and region
+	ConsolidateStatements bool
+	// MultiRegionKeys is MultiRegionKeysPrimaryOnly or MultiRegionKeysAllReplicas; if unset, a multi-Region key is only
+	// granted in the region of its ARN
+	MultiRegionKeys string
+	// IncludeECR adds ECR pull access to the new policy, for the ECRRepositories or, if none are given, all repositories
+	IncludeECR      bool
+	ECRRepositories []string
+	// MinimalManaged grants the MinimalManagedActions (or, if none are given, the DefaultMinimalManagedActions) in the
+	// new policy instead of attaching the AWS managed task execution role policy
+	MinimalManaged        bool
+	MinimalManagedActions []string
+	// ManagedPolicyARN, if set, replaces the AWS managed task execution role policy of the region, e.g. if it was
+	// renamed; it can't be combined with MinimalManaged
+	ManagedPolicyARN string
+	// SkipManagedIfPresent doesn't attach the managed policy to an existing role which already has it, or its
+	// equivalent, attached; see managedPolicyPresent
+	SkipManagedIfPresent bool
+	// RefreshExistingPolicy replaces the content of the policy previously generated by the ecs-cli for the existing roles
+	// with a new policy version, instead of creating and attaching another policy; it can't be combined with
+	// PruneStalePolicies
+	RefreshExistingPolicy bool
+	// PolicyName, if set, is the exact name of the new policy, replacing the generated '<role>-policy-<timestamp>' name.
+	// If a policy with the name exists, it is given a new version if UpdateExistingPolicy is set, and is an error
+	// otherwise.
+	PolicyName           string
+	UpdateExistingPolicy bool
+	// PolicyNameFromHash names the new policy after the hash of its document, and reuses an existing policy with
+	// that name instead of creating an identical one; it can't be combined with PolicyName or RefreshExistingPolicy
+	PolicyNameFromHash bool
+	// ReconcileTags is ReconcileTagsAdditive or ReconcileTagsExact; if unset, the tags of existing roles are unchanged
+	ReconcileTags string
+	// TagFailureMode is TagFailureWarn to only warn if the tags of an existing role can't be reconciled; otherwise the
+	// role fails
+	TagFailureMode string
+	// FailFast stops at the first role which can't be set up, so that no further roles are changed (and no policy is
+	// created if the role fails before it); otherwise the other roles are still set up. With a Concurrency above 1, the
+	// roles already being set up when a role fails are completed.
+	FailFast bool
+	// Concurrency is the number of roles which are set up at once; if unset, roles are set up one at a time. The results
+	// are in the order of the roles either way.
+	Concurrency int
+	// TagCondition, if set, adds a statement to the new policy denying its access unless the principal or request is
+	// tagged; for a principal tag, each role must have the tag
+	TagCondition *PolicyTagCondition
+	// LintRules, if set, are checked against the statements of the new policy before any IAM changes are made
+	LintRules *regcredio.LintRules
+	// Metrics receives counts of created resources and failures; if unset, nothing is recorded
+	Metrics MetricsRecorder
+	// DebugOutput, if set, receives the resolved role config and policy document as JSON before any IAM changes are made
+	DebugOutput io.Writer
 }
 
-// returns the time of IAM policy creation so that other resources (i.e., output file) can be dated to match
-func createTaskExecutionRole(params executionRoleParams, iamClient iamClient.Client, kmsClient kmsClient.Client) (*time.Time, error) {
-	log.Infof("Creating resources for task execution role %s...", params.RoleName)
+// ExecutionRoleResult describes the task execution role and policy used by CreateTaskExecutionRole
+type ExecutionRoleResult struct {
+	RoleName    string
+	RoleCreated bool
+	// RoleARN is only set if the role was created
+	RoleARN string
+	// Tags are the tags the role was created with; existing roles are only tagged with ReconcileTagsExact, so this is
+	// empty for them otherwise
+	Tags []*iam.Tag
+	// PolicyARN is empty if no policy was generated because there were no registry credentials
+	PolicyARN string
+	// PolicyName is the name of the policy at PolicyARN
+	PolicyName string
+	// PolicyStatements are the statements of the policy document at PolicyARN
+	PolicyStatements []StatementEntry
+	// AttachedPolicyARNs are the policies attached to the role, in the order they were attached
+	AttachedPolicyARNs []string
+	// InstanceProfileARN is only set if an instance profile was requested
+	InstanceProfileARN string
+	// InstanceProfileCreated and RoleAddedToInstanceProfile indicate which instance profile changes were made
+	InstanceProfileCreated     bool
+	RoleAddedToInstanceProfile bool
+	// AdditionalPolicyARNs are the policies from ExecutionRoleParams.AdditionalPolicyARNs attached to the role
+	AdditionalPolicyARNs []string
+	// ECRRepositories are the repositories the new policy grants pull access to, if ECR access was included
+	ECRRepositories []string
+	// PolicyRefreshed indicates that PolicyARN is an existing policy which was given a new version instead of created
+	PolicyRefreshed bool
+	// PolicyAttachedBefore indicates that the existing (i.e. refreshed or reused) policy at PolicyARN was already
+	// attached to the role before the run, so attaching it didn't change the role
+	PolicyAttachedBefore bool
+	// PolicyReused indicates that PolicyARN is an existing content addressed policy with the same document
+	PolicyReused bool
+	// PrunedPolicyARNs are the stale policies detached from the role to stay within the policy limit. They are deleted
+	// once the new policies are attached to every role, unless they are still attached to other entities.
+	PrunedPolicyARNs []string
+	// RoleOnly indicates that no policy granting access to the registry credentials was created, since only the role
+	// was requested
+	RoleOnly bool
+	// PolicyCreateTime is the time of IAM policy creation so that other resources (i.e., output file) can be dated to match
+	PolicyCreateTime time.Time
+	// Err is set by CreateTaskExecutionRoles if this role could not be set up
+	Err error
+}
 
-	// create role
-	roleName, err := createOrFindRole(params.RoleName, iamClient, convertToIAMTags(params.Tags))
+// CreateTaskExecutionRole creates or finds the named task execution role and attaches a new policy granting access to
+// the given registry credentials. Any implementation of the IAM and KMS clients may be supplied, e.g. ones that route
+// requests through a proxy. To make many calls with the same credentials, build the clients once with NewClients.
+func CreateTaskExecutionRole(params ExecutionRoleParams, iamClient iamClient.Client, kmsClient kmsClient.Client) (*ExecutionRoleResult, error) {
+	params.RoleNames = nil
+	results, err := CreateTaskExecutionRoles(params, iamClient, kmsClient)
 	if err != nil {
 		return nil, err
 	}
+	return results[0], nil
+}
+
+// CreateTaskExecutionRoles creates or finds each role in params.RoleNames (or params.RoleName, if no role names are
+// given) and attaches a single new policy granting access to the given registry credentials to all of them. A failure
+// for one role does not stop the others from being set up: the result of every role is returned, along with an error
+// naming the roles which failed. The results are nil if no resources were changed.
+func CreateTaskExecutionRoles(params ExecutionRoleParams, iamClient iamClient.Client, kmsClient kmsClient.Client) ([]*ExecutionRoleResult, error) {
+	roleNames := params.roleNames()
+	if len(params.CredEntries) == 0 && !params.AllowEmpty && !params.RoleOnly {
+		return nil, errors.New("no registry credentials were given, so the new policy would grant access to no secrets; specify at least one registry, or allow empty credentials to create only the task execution role")
+	}
+	if err := validateAttachOrder(params.AttachOrder); err != nil {
+		return nil, err
+	}
+	if err := validateReconcileTags(params.ReconcileTags); err != nil {
+		return nil, err
+	}
+	if err := validateMultiRegionKeys(params.MultiRegionKeys); err != nil {
+		return nil, err
+	}
+	if params.RefreshExistingPolicy && params.PruneStalePolicies {
+		return nil, fmt.Errorf("'--%s' can't be used with '--%s', since the policy to refresh could be pruned", flags.RefreshExistingPolicyFlag, flags.PruneStaleFlag)
+	}
+	if len(params.RequiredSessionTags) > 0 && params.TrustPolicy != "" {
+		return nil, fmt.Errorf("'--%s' can't be used with a custom trust policy; add the session tag conditions to the trust policy instead", flags.RequireSessionTagsFlag)
+	}
+	if params.PolicyName != "" {
+		if err := validatePolicyName(params.PolicyName); err != nil {
+			return nil, err
+		}
+		if params.RefreshExistingPolicy {
+			return nil, fmt.Errorf("'--%s' can't be used with '--%s', since the refreshed policy keeps its name", flags.PolicyNameFlag, flags.RefreshExistingPolicyFlag)
+		}
+	} else if params.UpdateExistingPolicy {
+		return nil, fmt.Errorf("'--%s' requires '--%s'", flags.UpdateExistingFlag, flags.PolicyNameFlag)
+	}
+	if params.PolicyNameFromHash && (params.PolicyName != "" || params.RefreshExistingPolicy) {
+		return nil, fmt.Errorf("'--%s' can't be used with '--%s' or '--%s', since the policy is named after its document", flags.PolicyNameFromHashFlag, flags.PolicyNameFlag, flags.RefreshExistingPolicyFlag)
+	}
+	if params.RoleOnly && (params.IncludeECR || params.MinimalManaged || params.TagCondition != nil || params.PolicyName != "" || params.PolicyNameFromHash || params.RefreshExistingPolicy) {
+		return nil, fmt.Errorf("'--%s' can't be used with the options of the new policy, since no policy is created", flags.RoleOnlyFlag)
+	}
+	log.Infof("Creating resources for task execution role %s...", strings.Join(roleNames, ", "))
+
+	metrics := params.metrics()
 
 	// generate policy document
-	policyDoc, err := generateSecretsPolicy(params.CredEntries, kmsClient)
+	policyStatements, err := params.policyStatements(kmsClient)
 	if err != nil {
+		recordFailure(metrics, FailureCategoryPolicyDocument)
 		return nil, err
 	}
+	policyDoc := ""
+	if len(policyStatements) > 0 {
+		doc, err := marshalPolicyDocument(policyStatements)
+		if err != nil {
+			recordFailure(metrics, FailureCategoryPolicyDocument)
+			return nil, err
+		}
+		policyDoc = doc
+	}
+	if params.LintRules != nil {
+		if err := lintPolicy(policyStatements, *params.LintRules); err != nil {
+			recordFailure(metrics, FailureCategoryPolicyDocument)
+			return nil, err
+		}
+	}
+
+	if err := validateAdditionalPolicies(params.AdditionalPolicyARNs, iamClient); err != nil {
+		recordFailure(metrics, FailureCategoryAttachment)
+		return nil, err
+	}
+	if err := validateManagedPolicyARN(params.ManagedPolicyARN, params.MinimalManaged, iamClient); err != nil {
+		recordFailure(metrics, FailureCategoryAttachment)
+		return nil, err
+	}
+
+	managementTagKey, managementTagValue := params.managementTag()
+	allTags := addManagementTag(params.Tags, managementTagKey, managementTagValue)
+	if err := validateTagLimits(allTags, params.reservedTagCount()); err != nil {
+		recordFailure(metrics, FailureCategoryRole)
+		return nil, err
+	}
+	roleTags := convertToIAMTags(allTags)
+	if params.ReconcileTags == ReconcileTagsExact {
+		keysTag, err := managedTagKeysTag(roleTags)
+		if err != nil {
+			return nil, err
+		}
+		roleTags = append(roleTags, keysTag)
+	}
+	if params.DebugOutput != nil {
+		if err := writeDebugConfig(params.DebugOutput, roleNames, params, roleTags, policyDoc); err != nil {
+			return nil, err
+		}
+	}
+
+	// create roles
+	results := make([]*ExecutionRoleResult, len(roleNames))
+	runItems(roleNames, params.Concurrency, params.FailFast, func(i int) bool {
+		results[i] = createRoleResources(roleNames[i], params, iamClient, roleTags)
+		return results[i].Err == nil
+	}, func(i int) {
+		results[i] = &ExecutionRoleResult{RoleName: roleNames[i], Err: errSkippedAfterFailure}
+	})
+
+	// existing roles are tagged to converge on the tags of new roles
+	if params.ReconcileTags == ReconcileTagsExact {
+		runItems(roleNames, params.Concurrency, false, func(i int) bool {
+			result := results[i]
+			if result.Err != nil || result.RoleCreated {
+				return true
+			}
+			_, err := reconcileRoleTags(result.RoleName, roleTags, iamClient)
+			if err == nil {
+				result.Tags = roleTags
+				return true
+			}
+			if result.Err = handleTagFailure(params.TagFailureMode, err, "role "+result.RoleName, iamTagKeys(roleTags)); result.Err != nil {
+				recordFailure(metrics, FailureCategoryRole)
+				return false
+			}
+			return true
+		}, nil)
+	}
+
+	// a role without the tag required by the policy would be denied the access it grants
+	if params.TagCondition != nil && policyDoc != "" {
+		runItems(roleNames, params.Concurrency, false, func(i int) bool {
+			result := results[i]
+			if result.Err != nil {
+				return true
+			}
+			if result.Err = checkPrincipalTag(result, *params.TagCondition, roleTags, iamClient); result.Err != nil {
+				recordFailure(metrics, FailureCategoryRole)
+				return false
+			}
+			return true
+		}, nil)
+	}
+
+	// find the policy generated for the existing roles by a previous run, which is refreshed instead of creating another
+	refreshPolicyARN := ""
+	if params.RefreshExistingPolicy && policyDoc != "" {
+		policyARN, err := findRefreshablePolicy(results, iamClient)
+		if err != nil {
+			recordFailure(metrics, FailureCategoryPolicy)
+			return changedRoles(results), err
+		}
+		if policyARN == "" {
+			log.Info("No existing role has a policy generated by the ECS CLI; a new policy will be created.")
+		}
+		refreshPolicyARN = policyARN
+	}
+
+	// check that existing roles have room for the new policies before any policy is created. Stale policies which make
+	// room are only detached right before the new policies are attached, so that a failure until then leaves the roles
+	// unchanged.
+	stalePolicyARNs := make([][]string, len(results))
+	if params.MaxPoliciesPerRole > 0 {
+		var existingPolicyARNs []string
+		if !params.MinimalManaged {
+			existingPolicyARNs = append(existingPolicyARNs, params.managedPolicyARN())
+		}
+		existingPolicyARNs = append(existingPolicyARNs, params.AdditionalPolicyARNs...)
+		if refreshPolicyARN != "" {
+			existingPolicyARNs = append(existingPolicyARNs, refreshPolicyARN)
+		}
+		for i, result := range results {
+			if result.Err != nil || result.RoleCreated {
+				continue
+			}
+			stalePolicyARNs[i], result.Err = checkPolicyLimit(result.RoleName, existingPolicyARNs, refreshPolicyARN == "", params.MaxPoliciesPerRole, params.PruneStalePolicies, iamClient)
+			if result.Err != nil {
+				recordFailure(metrics, FailureCategoryPolicyLimit)
+			}
+		}
+	}
+	if failed := len(failedRoles(results)); failed == len(results) || (failed > 0 && params.FailFast) {
+		// no role can be used, or the run stops at the first failure, so no policy is created
+		return failedRolesResults(results)
+	}
 
 	// create datetime for policy & output
 	createTime := time.Now().UTC()
 
-	// create the new policy
-	newPolicy, err := createRegistryCredentialsPolicy(params.RoleName, policyDoc, createTime, iamClient)
-	if err != nil {
-		return nil, err
+	// create the new policy, named after the first role
+	policyARN := ""
+	policyRefreshed := refreshPolicyARN != ""
+	policyReused := false
+	if refreshPolicyARN != "" {
+		if err := refreshPolicy(refreshPolicyARN, policyDoc, iamClient); err != nil {
+			recordFailure(metrics, FailureCategoryPolicy)
+			return changedRoles(results), err
+		}
+		policyARN = refreshPolicyARN
+	} else if policyDoc != "" && params.PolicyName != "" {
+		namedPolicyARN, updated, err := createOrUpdateNamedPolicy(params.PolicyName, roleNames[0], policyDoc, params.UpdateExistingPolicy, iamClient)
+		if err != nil {
+			recordFailure(metrics, FailureCategoryPolicy)
+			return changedRoles(results), err
+		}
+		policyARN = namedPolicyARN
+		policyRefreshed = updated
+		if !updated {
+			log.Infof("Created new task execution role policy %s", policyARN)
+			metrics.AddCounter(MetricPoliciesCreated, nil, 1)
+		}
+	} else if policyDoc != "" && params.PolicyNameFromHash {
+		contentPolicyARN, reused, err := createOrReuseContentPolicy(roleNames[0], policyDoc, iamClient)
+		if err != nil {
+			recordFailure(metrics, FailureCategoryPolicy)
+			return changedRoles(results), err
+		}
+		policyARN = contentPolicyARN
+		policyReused = reused
+		if !reused {
+			log.Infof("Created new task execution role policy %s", policyARN)
+			metrics.AddCounter(MetricPoliciesCreated, nil, 1)
+		}
+	} else if policyDoc != "" {
+		newPolicy, err := createRegistryCredentialsPolicy(generatedPolicyName(roleNames[0], createTime), roleNames[0], policyDoc, iamClient)
+		if err != nil {
+			recordFailure(metrics, FailureCategoryPolicy)
+			return changedRoles(results), err
+		}
+		policyARN = aws.StringValue(newPolicy.Arn)
+		log.Infof("Created new task execution role policy %s", policyARN)
+		metrics.AddCounter(MetricPoliciesCreated, nil, 1)
+	} else if params.RoleOnly {
+		log.Info("Only the task execution role was requested; skipping creation of the registry credentials policy.")
+	} else {
+		log.Info("No registry credentials were given; skipping creation of the task execution role policy.")
+	}
+
+	// attach managed execution role policy & new credentials policy to each role
+	for _, result := range results {
+		result.PolicyARN = policyARN
+		result.PolicyName = policyNameFromARN(policyARN)
+		if policyARN != "" {
+			result.PolicyStatements = policyStatements
+		}
+		result.PolicyRefreshed = policyRefreshed
+		result.PolicyReused = policyReused
+		result.RoleOnly = params.RoleOnly
+		result.PolicyCreateTime = createTime
+		if params.IncludeECR {
+			result.ECRRepositories = ecrPullRepositories(params.ECRRepositories)
+		}
 	}
-	log.Infof("Created new task execution role policy %s", aws.StringValue(newPolicy.Arn))
+	runItems(roleNames, params.Concurrency, params.FailFast, func(i int) bool {
+		result := results[i]
+		if result.Err != nil {
+			// only attachment failures stop the remaining attachments
+			return true
+		}
+		if (policyRefreshed || policyReused) && !result.RoleCreated {
+			if result.PolicyAttachedBefore, result.Err = policyAttached(result.RoleName, policyARN, iamClient); result.Err != nil {
+				recordFailure(metrics, FailureCategoryAttachment)
+				return false
+			}
+		}
+		if result.PrunedPolicyARNs, result.Err = detachStalePolicies(result.RoleName, stalePolicyARNs[i], policyARN, iamClient); result.Err != nil {
+			recordFailure(metrics, FailureCategoryPolicyLimit)
+			return false
+		}
+		result.AttachedPolicyARNs, result.Err = attachRolePolicies(policyARN, result.RoleName, params.managedPolicyARN(), params.AttachOrder, params.ManagedPolicyARN != "", params.SkipManagedIfPresent && !result.RoleCreated, params.AdditionalPolicyARNs, iamClient)
+		metrics.AddCounter(MetricPolicyAttachments, nil, float64(len(result.AttachedPolicyARNs)))
+		if result.Err != nil {
+			recordFailure(metrics, FailureCategoryAttachment)
+			// the role keeps the access the stale policies granted
+			result.PrunedPolicyARNs = restoreStalePolicies(result.RoleName, result.PrunedPolicyARNs, iamClient)
+			return false
+		}
+		result.AdditionalPolicyARNs = params.AdditionalPolicyARNs
+		return true
+	}, func(i int) {
+		if results[i].Err == nil {
+			results[i].Err = errSkippedAfterFailure
+		}
+	})
+	deleteStalePolicies(results, iamClient)
 
-	// attach managed execution role policy & new credentials policy to role
-	err = attachRolePolicies(*newPolicy.Arn, roleName, params.Region, iamClient)
+	return failedRolesResults(results)
+}
+
+// createRoleResources creates or finds the role and, if requested, its instance profile. Any error is set on the
+// result so that the remaining roles can still be set up.
+func createRoleResources(roleName string, params ExecutionRoleParams, iamClient iamClient.Client, tags []*iam.Tag) *ExecutionRoleResult {
+	metrics := params.metrics()
+	result := &ExecutionRoleResult{RoleName: roleName}
+
+	roleARN, err := createOrFindRole(roleName, params, iamClient, tags)
 	if err != nil {
-		return nil, err
+		recordFailure(metrics, FailureCategoryRole)
+		result.Err = err
+		return result
+	}
+	if roleARN != "" {
+		metrics.AddCounter(MetricRolesCreated, nil, 1)
+	}
+	result.RoleCreated = roleARN != ""
+	result.RoleARN = roleARN
+	if result.RoleCreated {
+		result.Tags = tags
+	}
+
+	if params.CreateInstanceProfile {
+		instanceProfile, err := createOrFindInstanceProfile(roleName, params.Path, iamClient)
+		if err != nil {
+			recordFailure(metrics, FailureCategoryInstanceProfile)
+			result.Err = err
+			return result
+		}
+		result.InstanceProfileARN = instanceProfile.ARN
+		result.InstanceProfileCreated = instanceProfile.Created
+		result.RoleAddedToInstanceProfile = instanceProfile.RoleAdded
+	}
+
+	return result
+}
+
+// failedRolesResults logs the outcome for each role if there are several, and returns an error if any role failed
+func failedRolesResults(results []*ExecutionRoleResult) ([]*ExecutionRoleResult, error) {
+	failed := failedRoles(results)
+	if len(results) > 1 {
+		for _, result := range results {
+			if result.Err != nil {
+				log.Errorf("Role %s: failed: %v", result.RoleName, result.Err)
+			} else {
+				log.Infof("Role %s: policy %s attached", result.RoleName, result.PolicyARN)
+			}
+		}
+	}
+	if len(failed) == 0 {
+		return results, nil
+	}
+	if len(results) == 1 {
+		return changedRoles(results), results[0].Err
+	}
+	return changedRoles(results), fmt.Errorf("failed to set up %d of %d task execution roles: %s", len(failed), len(results), strings.Join(failed, ", "))
+}
+
+func failedRoles(results []*ExecutionRoleResult) []string {
+	var failed []string
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result.RoleName)
+		}
+	}
+	return failed
+}
+
+// changedRoles returns nil if no role has been changed, so that callers can tell whether there is anything to clean up
+func changedRoles(results []*ExecutionRoleResult) []*ExecutionRoleResult {
+	for _, result := range results {
+		if result.changed() {
+			return results
+		}
+	}
+	return nil
+}
+
+func (result *ExecutionRoleResult) changed() bool {
+	return result.RoleCreated || result.InstanceProfileCreated || result.RoleAddedToInstanceProfile || len(result.AttachedPolicyARNs) > 0
+}
+
+func (params ExecutionRoleParams) roleNames() []string {
+	if len(params.RoleNames) == 0 {
+		return []string{params.RoleName}
 	}
+	return params.RoleNames
+}
+
+func (params ExecutionRoleParams) metrics() MetricsRecorder {
+	if params.Metrics == nil {
+		return noopMetrics{}
+	}
+	return params.Metrics
+}
 
-	return &createTime, nil
+func (params ExecutionRoleParams) managementTag() (string, string) {
+	if params.ManagementTagKey == "" {
+		return DefaultManagementTagKey, DefaultManagementTagValue
+	}
+	return params.ManagementTagKey, params.ManagementTagValue
 }
 
-func createRegistryCredentialsPolicy(roleName, policyDoc string, createTime time.Time, client iamClient.Client) (*iam.Policy, error) {
-	newPolicyName := generateECSResourceName(roleName + "-policy-" + createTime.Format(regcredio.ECSCredFileTimeFmt))
-	policyDescriptionFmtString := "Policy generated by the ecs-cli for role: %s"
+// managedPolicyARN returns the ARN of the managed task execution role policy attached to each role, or an empty string if
+// none is attached
+func (params ExecutionRoleParams) managedPolicyARN() string {
+	if params.MinimalManaged {
+		return ""
+	}
+	if params.ManagedPolicyARN != "" {
+		return params.ManagedPolicyARN
+	}
+	return getExecutionRolePolicyARN(params.Region)
+}
 
+// policyStatements returns the statements of the new policy, which is empty if there is nothing to grant
+func (params ExecutionRoleParams) policyStatements(kmsClient kmsClient.Client) ([]StatementEntry, error) {
+	if params.RoleOnly {
+		return nil, nil
+	}
+	var policyStatements []StatementEntry
+	if len(params.CredEntries) > 0 {
+		statements, err := generateSecretsStatements(params.CredEntries, params.VersionStage, kmsClient)
+		if err != nil {
+			return nil, err
+		}
+		if params.ConsolidateStatements {
+			statements = consolidateStatements(statements)
+		}
+		statements = applyMultiRegionKeys(statements, params.MultiRegionKeys)
+		policyStatements = statements
+	}
+	if params.IncludeECR {
+		if err := validateECRRepositories(params.ECRRepositories); err != nil {
+			return nil, err
+		}
+		policyStatements = appendECRPullStatements(policyStatements, params.ECRRepositories)
+	}
+	if params.TagCondition != nil && len(policyStatements) > 0 {
+		policyStatements = appendTagConditionStatement(policyStatements, *params.TagCondition)
+	}
+	if params.MinimalManaged {
+		// added after the tag condition, since the managed policy they replace isn't restricted by it
+		if err := validateMinimalManagedActions(params.MinimalManagedActions); err != nil {
+			return nil, err
+		}
+		policyStatements = appendMinimalManagedStatements(policyStatements, params.MinimalManagedActions, params.Region, params.IncludeECR, params.ECRRepositories)
+	}
+	return policyStatements, nil
+}
+
+// reservedTagCount returns the number of tags added to the roles besides params.Tags and the management tag
+func (params ExecutionRoleParams) reservedTagCount() int {
+	if params.ReconcileTags == ReconcileTagsExact {
+		// the managed tag keys tag
+		return 1
+	}
+	return 0
+}
+
+func (params ExecutionRoleParams) trustPolicy() string {
+	if params.TrustPolicy == "" {
+		if len(params.RequiredSessionTags) > 0 {
+			return sessionTagTrustPolicy(params.RequiredSessionTags)
+		}
+		return assumeRolePolicyDocString
+	}
+	return params.TrustPolicy
+}
+
+// generatedPolicyName returns the name of a new policy generated for the role, dated with its creation time
+func generatedPolicyName(roleName string, createTime time.Time) *string {
+	return generateECSResourceName(roleName + "-policy-" + createTime.Format(regcredio.ECSCredFileTimeFmt))
+}
+
+func createRegistryCredentialsPolicy(newPolicyName *string, roleName, policyDoc string, client iamClient.Client) (*iam.Policy, error) {
 	createPolicyRequest := iam.CreatePolicyInput{
 		PolicyName:     newPolicyName,
 		PolicyDocument: aws.String(policyDoc),
-		Description:    aws.String(fmt.Sprintf(policyDescriptionFmtString, roleName)),
+		Description:    aws.String(policyDescription(roleName)),
 	}
 
 	policyResult, err := client.CreatePolicy(createPolicyRequest)
@@ -89,38 +676,198 @@ func createRegistryCredentialsPolicy(roleName, policyDoc string, createTime time
 	return policyResult.Policy, nil
 }
 
-func createOrFindRole(roleName string, client iamClient.Client, tags []*iam.Tag) (string, error) {
-	roleResult, err := client.CreateOrFindRole(roleName, roleDescriptionString, assumeRolePolicyDocString, tags)
+// policyDescription returns the description of a policy generated for the role
+func policyDescription(roleName string) string {
+	return fmt.Sprintf("Policy generated by the ecs-cli for role: %s", roleName)
+}
+
+// returns the ARN of the new role, or an empty string if the role already exists
+func createOrFindRole(roleName string, params ExecutionRoleParams, client iamClient.Client, tags []*iam.Tag) (string, error) {
+	permissionsBoundary := params.PermissionsBoundary
+
+	createRoleRequest := iam.CreateRoleInput{
+		AssumeRolePolicyDocument: aws.String(params.trustPolicy()),
+		Description:              aws.String(roleDescriptionString),
+		RoleName:                 aws.String(roleName),
+	}
+	if params.Path != "" {
+		createRoleRequest.Path = aws.String(params.Path)
+	}
+	if permissionsBoundary != "" {
+		createRoleRequest.PermissionsBoundary = aws.String(permissionsBoundary)
+	}
+	if len(tags) > 0 {
+		createRoleRequest.Tags = tags
+	}
+
+	roleResult, err := client.CreateOrFindRole(createRoleRequest)
 	if err != nil {
 		return "", err
 	}
 
 	if roleResult != "" {
 		log.Infof("Created new task execution role %s", roleResult)
-	} else {
-		log.Infof("Using existing role %s", roleName)
+		return roleResult, nil
+	}
+	if params.ExpectedAccountID != "" {
+		if err = verifyRoleAccount(roleName, params.ExpectedAccountID, client); err != nil {
+			return "", err
+		}
+	}
+	log.Infof("Using existing role %s", roleName)
+	if permissionsBoundary != "" {
+		log.Warnf("Permissions boundary %s is not applied to existing role %s", permissionsBoundary, roleName)
+	}
+	if len(params.RequiredSessionTags) > 0 {
+		log.Warnf("The session tag requirements are not applied to the trust policy of existing role %s", roleName)
 	}
 
-	return roleName, nil
+	return "", nil
 }
 
-func attachRolePolicies(secretPolicyARN, roleName, region string, client iamClient.Client) error {
-	managedPolicyARN := getExecutionRolePolicyARN(region)
-	_, err := client.AttachRolePolicy(managedPolicyARN, roleName)
+// attachRolePolicies attaches the managed execution role policy (if any) and the new policy (if any), in the given
+// order, and then any additional policies. It returns the ARNs of the attached policies; if an attachment fails, the
+// policies attached before it are returned. managedOverridden indicates that the managed policy was given with
+// '--managed-policy-arn', and so was already checked to exist. If skipManagedIfPresent is set, the managed policy is
+// not attached if the role already has it, or its equivalent, attached; the other policies are always attached.
+func attachRolePolicies(secretPolicyARN, roleName, managedPolicyARN, attachOrder string, managedOverridden, skipManagedIfPresent bool, additionalPolicyARNs []string, client iamClient.Client) ([]string, error) {
+	type attachment struct {
+		policyARN   string
+		description string
+	}
+	var attachments []attachment
+	if managedPolicyARN != "" {
+		present := ""
+		if skipManagedIfPresent {
+			var err error
+			if present, err = managedPolicyPresent(roleName, managedPolicyARN, client); err != nil {
+				return nil, err
+			}
+		}
+		if present != "" {
+			log.Infof("Skipped attaching AWS managed policy %s to role %s, which already has %s attached", managedPolicyARN, roleName, present)
+		} else {
+			attachments = append(attachments, attachment{managedPolicyARN, "AWS managed policy"})
+		}
+	}
+	if secretPolicyARN != "" {
+		newPolicy := attachment{secretPolicyARN, "new policy"}
+		if attachOrder == AttachOrderGeneratedFirst {
+			attachments = append([]attachment{newPolicy}, attachments...)
+		} else {
+			attachments = append(attachments, newPolicy)
+		}
+	}
+	for _, policyARN := range additionalPolicyARNs {
+		attachments = append(attachments, attachment{policyARN, "policy"})
+	}
+
+	var attached []string
+	for _, a := range attachments {
+		if _, err := client.AttachRolePolicy(a.policyARN, roleName); err != nil {
+			if a.policyARN == managedPolicyARN && !managedOverridden && isNoSuchEntity(err) {
+				return attached, errors.Wrapf(err, "the AWS managed task execution role policy %s was not found; it may have been retired or renamed in this partition. Give the current policy with '--%s', or use '--%s' to grant its actions in the new policy instead", managedPolicyARN, flags.ManagedPolicyARNFlag, flags.MinimalManagedFlag)
+			}
+			return attached, err
+		}
+		log.Infof("Attached %s %s to role %s", a.description, a.policyARN, roleName)
+		attached = append(attached, a.policyARN)
+	}
+
+	return attached, nil
+}
+
+// managedPolicyPresent returns the ARN of the policy attached to the role which is the given managed policy or its
+// equivalent, or "" if there is none. The AWS managed task execution role policy of any partition is equivalent to
+// the managed policy, since a policy given with '--managed-policy-arn' replaces it.
+func managedPolicyPresent(roleName, managedPolicyARN string, client iamClient.Client) (string, error) {
+	attachedPolicies, err := client.ListAttachedRolePolicies(roleName)
 	if err != nil {
-		return err
+		return "", errors.Wrapf(err, "failed to list the policies attached to role %s", roleName)
+	}
+	for _, policy := range attachedPolicies {
+		policyARN := aws.StringValue(policy.PolicyArn)
+		if policyARN == managedPolicyARN {
+			return policyARN, nil
+		}
+		if parsedARN, err := arn.Parse(policyARN); err == nil && parsedARN.AccountID == "aws" && parsedARN.Resource == ecsTaskExecutionPolicyResource {
+			return policyARN, nil
+		}
 	}
-	log.Infof("Attached AWS managed policy %s to role %s", managedPolicyARN, roleName)
+	return "", nil
+}
 
-	_, err = client.AttachRolePolicy(secretPolicyARN, roleName)
+// policyAttached returns whether the policy is attached to the role
+func policyAttached(roleName, policyARN string, client iamClient.Client) (bool, error) {
+	attachedPolicies, err := client.ListAttachedRolePolicies(roleName)
 	if err != nil {
-		return err
+		return false, errors.Wrapf(err, "failed to list the policies attached to role %s", roleName)
+	}
+	for _, policy := range attachedPolicies {
+		if aws.StringValue(policy.PolicyArn) == policyARN {
+			return true, nil
+		}
 	}
-	log.Infof("Attached new policy %s to role %s", secretPolicyARN, roleName)
+	return false, nil
+}
 
+// validateAttachOrder checks that the attach order is one of the supported values; empty means the default order
+func validateAttachOrder(attachOrder string) error {
+	switch attachOrder {
+	case "", AttachOrderManagedFirst, AttachOrderGeneratedFirst:
+		return nil
+	}
+	return fmt.Errorf("invalid value '%s' for '--%s'; valid values are %s and %s", attachOrder, flags.AttachOrderFlag, AttachOrderManagedFirst, AttachOrderGeneratedFirst)
+}
+
+// validateManagedPolicyARN checks that the policy given with '--managed-policy-arn' is an IAM policy ARN which exists,
+// before any resources are created
+func validateManagedPolicyARN(policyARN string, minimalManaged bool, client iamClient.Client) error {
+	if policyARN == "" {
+		return nil
+	}
+	if minimalManaged {
+		return fmt.Errorf("'--%s' can't be used with '--%s', which doesn't attach a managed policy", flags.ManagedPolicyARNFlag, flags.MinimalManagedFlag)
+	}
+	parsedARN, err := arn.Parse(policyARN)
+	if err != nil || parsedARN.Service != "iam" || !strings.HasPrefix(parsedARN.Resource, "policy/") {
+		return fmt.Errorf("invalid value '%s' for '--%s'; expected the ARN of an IAM policy", policyARN, flags.ManagedPolicyARNFlag)
+	}
+	if _, err = client.GetPolicy(policyARN); err != nil {
+		return errors.Wrapf(err, "failed to find policy %s given with '--%s'", policyARN, flags.ManagedPolicyARNFlag)
+	}
 	return nil
 }
 
+// validateAdditionalPolicies checks that each policy exists before any resources are created
+func validateAdditionalPolicies(policyARNs []string, client iamClient.Client) error {
+	seen := make(map[string]bool, len(policyARNs))
+	for _, policyARN := range policyARNs {
+		if seen[policyARN] {
+			return fmt.Errorf("policy %s is specified more than once with '--%s'", policyARN, flags.AttachPolicyFlag)
+		}
+		seen[policyARN] = true
+		if _, err := client.GetPolicy(policyARN); err != nil {
+			return errors.Wrapf(err, "failed to find policy %s given with '--%s'", policyARN, flags.AttachPolicyFlag)
+		}
+	}
+	return nil
+}
+
+// returns a copy of the given tags with the management tag added; a user-provided value for the same key is replaced
+func addManagementTag(tags map[string]*string, key, value string) map[string]*string {
+	allTags := make(map[string]*string, len(tags)+1)
+	for k, v := range tags {
+		allTags[k] = v
+	}
+	if userValue, ok := allTags[key]; ok && aws.StringValue(userValue) != value {
+		log.Warnf("Tag '%s=%s' is replaced with '%s=%s', which identifies resources created by the ecs-cli. To use a different tag for this, use '--%s'.", key, aws.StringValue(userValue), key, value, flags.ManagementTagFlag)
+	}
+	allTags[key] = aws.String(value)
+
+	return allTags
+}
+
 func convertToIAMTags(tags map[string]*string) []*iam.Tag {
 	var iamTags []*iam.Tag
 	for key, value := range tags {
@@ -42,7 +42,7 @@ func TestCreateTaskExecutionRole(t *testing.T) {
 
 	mocks := setupTestController(t)
 	gomock.InOrder(
-		mocks.MockIAM.EXPECT().CreateOrFindRole(testRoleName, roleDescriptionString, assumeRolePolicyDocString, nil).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleName, defaultManagementTags())).Return(*testRoleArn, nil),
 		mocks.MockIAM.EXPECT().CreateRole(gomock.Any()).Return(&iam.CreateRoleOutput{Role: &iam.Role{Arn: testRoleArn}}, nil),
 	)
 	gomock.InOrder(
@@ -53,15 +53,21 @@ func TestCreateTaskExecutionRole(t *testing.T) {
 		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
 	)
 
-	testParams := executionRoleParams{
+	testParams := ExecutionRoleParams{
 		CredEntries: testCreds,
 		RoleName:    testRoleName,
 		Region:      "us-west-2",
 	}
 
-	policyCreateTime, err := createTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
 	assert.NoError(t, err, "Unexpected error when creating task execution role")
-	assert.NotNil(t, policyCreateTime, "Expected policy create time to be non-nil")
+	assert.NotNil(t, roleResult, "Expected role result to be non-nil")
+	assert.Equal(t, testRoleName, roleResult.RoleName)
+	assert.True(t, roleResult.RoleCreated, "Expected role to be reported as created")
+	assert.Equal(t, *testPolicyArn, roleResult.PolicyARN)
+	assert.Equal(t, *testRoleArn, roleResult.RoleARN)
+	assert.Equal(t, []string{getExecutionRolePolicyARN("us-west-2"), *testPolicyArn}, roleResult.AttachedPolicyARNs)
+	assert.False(t, roleResult.PolicyCreateTime.IsZero(), "Expected policy create time to be set")
 }
 
 func TestCreateTaskExecutionRole_CnPartition(t *testing.T) {
@@ -84,7 +90,7 @@ func TestCreateTaskExecutionRole_CnPartition(t *testing.T) {
 
 	mocks := setupTestController(t)
 	gomock.InOrder(
-		mocks.MockIAM.EXPECT().CreateOrFindRole(testRoleName, roleDescriptionString, assumeRolePolicyDocString, nil).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleName, defaultManagementTags())).Return(*testRoleArn, nil),
 		mocks.MockIAM.EXPECT().CreateRole(gomock.Any()).Return(&iam.CreateRoleOutput{Role: &iam.Role{Arn: testRoleArn}}, nil),
 	)
 	gomock.InOrder(
@@ -93,15 +99,15 @@ func TestCreateTaskExecutionRole_CnPartition(t *testing.T) {
 		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
 	)
 
-	testParams := executionRoleParams{
+	testParams := ExecutionRoleParams{
 		CredEntries: testCreds,
 		RoleName:    testRoleName,
 		Region:      "cn-north-1",
 	}
 
-	policyCreateTime, err := createTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
 	assert.NoError(t, err, "Unexpected error when creating task execution role")
-	assert.NotNil(t, policyCreateTime, "Expected policy create time to be non-nil")
+	assert.NotNil(t, roleResult, "Expected role result to be non-nil")
 }
 
 func TestCreateTaskExecutionRole_UsGovPartition(t *testing.T) {
@@ -124,7 +130,7 @@ func TestCreateTaskExecutionRole_UsGovPartition(t *testing.T) {
 
 	mocks := setupTestController(t)
 	gomock.InOrder(
-		mocks.MockIAM.EXPECT().CreateOrFindRole(testRoleName, roleDescriptionString, assumeRolePolicyDocString, nil).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleName, defaultManagementTags())).Return(*testRoleArn, nil),
 		mocks.MockIAM.EXPECT().CreateRole(gomock.Any()).Return(&iam.CreateRoleOutput{Role: &iam.Role{Arn: testRoleArn}}, nil),
 	)
 	gomock.InOrder(
@@ -133,15 +139,15 @@ func TestCreateTaskExecutionRole_UsGovPartition(t *testing.T) {
 		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
 	)
 
-	testParams := executionRoleParams{
+	testParams := ExecutionRoleParams{
 		CredEntries: testCreds,
 		RoleName:    testRoleName,
 		Region:      "us-gov-west-1",
 	}
 
-	policyCreateTime, err := createTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
 	assert.NoError(t, err, "Unexpected error when creating task execution role")
-	assert.NotNil(t, policyCreateTime, "Expected policy create time to be non-nil")
+	assert.NotNil(t, roleResult, "Expected role result to be non-nil")
 }
 
 func TestCreateTaskExecutionRole_NoKMSKey(t *testing.T) {
@@ -157,7 +163,7 @@ func TestCreateTaskExecutionRole_NoKMSKey(t *testing.T) {
 
 	mocks := setupTestController(t)
 	gomock.InOrder(
-		mocks.MockIAM.EXPECT().CreateOrFindRole(testRoleName, roleDescriptionString, assumeRolePolicyDocString, nil).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleName, defaultManagementTags())).Return(*testRoleArn, nil),
 		mocks.MockIAM.EXPECT().CreateRole(gomock.Any()).Return(&iam.CreateRoleOutput{Role: &iam.Role{Arn: testRoleArn}}, nil),
 	)
 	gomock.InOrder(
@@ -166,15 +172,15 @@ func TestCreateTaskExecutionRole_NoKMSKey(t *testing.T) {
 		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
 	)
 
-	testParams := executionRoleParams{
+	testParams := ExecutionRoleParams{
 		CredEntries: testCreds,
 		RoleName:    testRoleName,
 		Region:      "us-west-2",
 	}
 
-	policyCreateTime, err := createTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
 	assert.NoError(t, err, "Unexpected error when creating task execution role")
-	assert.NotNil(t, policyCreateTime, "Expected policy create time to be non-nil")
+	assert.NotNil(t, roleResult, "Expected role result to be non-nil")
 }
 
 func TestCreateTaskExecutionRole_RoleExists(t *testing.T) {
@@ -191,7 +197,7 @@ func TestCreateTaskExecutionRole_RoleExists(t *testing.T) {
 	mocks := setupTestController(t)
 	gomock.InOrder(
 		// CreateOrFindRole should return nil if given role already exists
-		mocks.MockIAM.EXPECT().CreateOrFindRole(testRoleName, roleDescriptionString, assumeRolePolicyDocString, nil).Return("", nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleName, defaultManagementTags())).Return("", nil),
 		mocks.MockIAM.EXPECT().CreateRole(gomock.Any()).Return(nil, roleExistsError),
 	)
 	gomock.InOrder(
@@ -200,15 +206,16 @@ func TestCreateTaskExecutionRole_RoleExists(t *testing.T) {
 		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
 	)
 
-	testParams := executionRoleParams{
+	testParams := ExecutionRoleParams{
 		CredEntries: testCreds,
 		RoleName:    testRoleName,
 		Region:      "us-west-2",
 	}
 
-	policyCreateTime, err := createTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
 	assert.NoError(t, err, "Unexpected error when creating task execution role")
-	assert.NotNil(t, policyCreateTime, "Expected policy create time to be non-nil")
+	assert.NotNil(t, roleResult, "Expected role result to be non-nil")
+	assert.False(t, roleResult.RoleCreated, "Expected existing role to be reported as reused")
 }
 
 func TestCreateTaskExecutionRole_ErrorOnCreateRoleFails(t *testing.T) {
@@ -221,17 +228,17 @@ func TestCreateTaskExecutionRole_ErrorOnCreateRoleFails(t *testing.T) {
 
 	mocks := setupTestController(t)
 	gomock.InOrder(
-		mocks.MockIAM.EXPECT().CreateOrFindRole(testRoleName, roleDescriptionString, assumeRolePolicyDocString, nil).Return("", errors.New("something went wrong")),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleName, defaultManagementTags())).Return("", errors.New("something went wrong")),
 		mocks.MockIAM.EXPECT().CreateRole(gomock.Any()).Return(nil, errors.New("something went wrong")),
 	)
 
-	testParams := executionRoleParams{
+	testParams := ExecutionRoleParams{
 		CredEntries: testCreds,
 		RoleName:    testRoleName,
 		Region:      "us-west-2",
 	}
 
-	_, err := createTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
 	assert.Error(t, err, "Expected error when CreateRole fails")
 }
 
@@ -247,23 +254,208 @@ func TestCreateTaskExecutionRole_ErrorOnCreatePolicyFails(t *testing.T) {
 
 	mocks := setupTestController(t)
 	gomock.InOrder(
-		mocks.MockIAM.EXPECT().CreateOrFindRole(testRoleName, roleDescriptionString, assumeRolePolicyDocString, nil).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleName, defaultManagementTags())).Return(*testRoleArn, nil),
 		mocks.MockIAM.EXPECT().CreateRole(gomock.Any()).Return(&iam.CreateRoleOutput{Role: &iam.Role{Arn: testRoleArn}}, nil),
 	)
 	gomock.InOrder(
 		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, errors.New("something went wrong")),
 	)
 
-	testParams := executionRoleParams{
+	testParams := ExecutionRoleParams{
 		CredEntries: testCreds,
 		RoleName:    testRoleName,
 		Region:      "us-west-2",
 	}
 
-	_, err := createTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
 	assert.Error(t, err, "Expected error when CreatePolicy fails")
 }
 
+func TestCreateTaskExecutionRoles(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	testRoleNames := []string{"myNginxProjectRole", "myOtherProjectRole"}
+
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleNames[0] + "-policy")
+	testRoleArn := aws.String("arn:aws:iam::role/" + testRoleNames[0])
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[0], defaultManagementTags())).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[1], defaultManagementTags())).Return("", nil),
+		// a single policy, named after the first role, is created for all roles
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Do(func(input iam.CreatePolicyInput) {
+			assert.Contains(t, aws.StringValue(input.PolicyName), testRoleNames[0])
+		}).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleNames[0]).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleNames[0]).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleNames[1]).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleNames[1]).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleNames:   testRoleNames,
+		Region:      "us-west-2",
+	}
+
+	roleResults, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution roles")
+	assert.Equal(t, 2, len(roleResults))
+	for i, roleResult := range roleResults {
+		assert.Equal(t, testRoleNames[i], roleResult.RoleName)
+		assert.Equal(t, *testPolicyArn, roleResult.PolicyARN)
+		assert.Equal(t, []string{getExecutionRolePolicyARN("us-west-2"), *testPolicyArn}, roleResult.AttachedPolicyARNs)
+		assert.NoError(t, roleResult.Err)
+	}
+	assert.True(t, roleResults[0].RoleCreated, "Expected first role to be reported as created")
+	assert.False(t, roleResults[1].RoleCreated, "Expected second role to be reported as reused")
+}
+
+func TestCreateTaskExecutionRoles_ErrorOnOneRole(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	testRoleNames := []string{"myNginxProjectRole", "myOtherProjectRole", "myThirdProjectRole"}
+
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleNames[0] + "-policy")
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[0], defaultManagementTags())).Return("", nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[1], defaultManagementTags())).Return("", errors.New("something went wrong")),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[2], defaultManagementTags())).Return("", nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleNames[0]).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleNames[0]).Return(nil, nil),
+		// the failed role is skipped while the remaining roles are still set up
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleNames[2]).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleNames[2]).Return(nil, errors.New("something went wrong")),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleNames:   testRoleNames,
+		Region:      "us-west-2",
+	}
+
+	roleResults, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when a role could not be set up")
+	assert.Contains(t, err.Error(), "2 of 3")
+	assert.Contains(t, err.Error(), testRoleNames[1]+", "+testRoleNames[2])
+	assert.Equal(t, 3, len(roleResults), "Expected results for every role so that changes can be cleaned up")
+	assert.NoError(t, roleResults[0].Err)
+	assert.Error(t, roleResults[1].Err)
+	assert.Empty(t, roleResults[1].AttachedPolicyARNs)
+	assert.Error(t, roleResults[2].Err)
+	assert.Equal(t, []string{getExecutionRolePolicyARN("us-west-2")}, roleResults[2].AttachedPolicyARNs)
+}
+
+func TestCreateTaskExecutionRoles_FailFastOnRole(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	testRoleNames := []string{"myNginxProjectRole", "myOtherProjectRole", "myThirdProjectRole"}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[0], defaultManagementTags())).Return("", nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[1], defaultManagementTags())).Return("", errors.New("something went wrong")),
+	)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleNames:   testRoleNames,
+		Region:      "us-west-2",
+		FailFast:    true,
+	}
+
+	_, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when a role could not be set up")
+	assert.Contains(t, err.Error(), "2 of 3")
+	assert.Contains(t, err.Error(), testRoleNames[1]+", "+testRoleNames[2])
+}
+
+func TestCreateTaskExecutionRoles_FailFastOnAttachment(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	testRoleNames := []string{"myNginxProjectRole", "myOtherProjectRole"}
+
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleNames[0] + "-policy")
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[0], defaultManagementTags())).Return("arn:aws:iam::123456789012:role/"+testRoleNames[0], nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleNames[1], defaultManagementTags())).Return("", nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleNames[0]).Return(nil, errors.New("something went wrong")),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleNames:   testRoleNames,
+		Region:      "us-west-2",
+		FailFast:    true,
+	}
+
+	roleResults, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when a policy could not be attached")
+	assert.Equal(t, 2, len(roleResults), "Expected results for every role so that changes can be cleaned up")
+	assert.Equal(t, errSkippedAfterFailure, roleResults[1].Err, "Expected the second role to be skipped")
+	assert.Equal(t, *testPolicyArn, roleResults[1].PolicyARN)
+}
+
+func TestCreateTaskExecutionRoles_ErrorOnAllRoles(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	testRoleNames := []string{"myNginxProjectRole", "myOtherProjectRole"}
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", errors.New("something went wrong")).Times(2)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleNames:   testRoleNames,
+		Region:      "us-west-2",
+	}
+
+	roleResults, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when no role could be set up")
+	assert.Nil(t, roleResults, "Expected no results when nothing was changed")
+}
+
+func TestCreateTaskExecutionRole_ErrorOnPolicyLimit(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	testRoleName := "myNginxProjectRole"
+	attached := testAttachedPolicies(9)
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testRoleName, defaultManagementTags())).Return("", nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testRoleName).Return(attached, nil),
+	)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+	// no policy is created if it can't be attached
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:        testCreds,
+		RoleName:           testRoleName,
+		Region:             "us-west-2",
+		MaxPoliciesPerRole: DefaultMaxPoliciesPerRole,
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when the role has no room for the new policies")
+}
+
 func TestCreateTaskExecutionRoleWithTags(t *testing.T) {
 	testRegistry := "myreg.test.io"
 	testRegCredARN := "arn:aws:secret/some-test-arn"
@@ -278,7 +470,7 @@ func TestCreateTaskExecutionRoleWithTags(t *testing.T) {
 	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
 	testRoleArn := aws.String("arn:aws:iam::role/" + testRoleName)
 
-	testParams := executionRoleParams{
+	testParams := ExecutionRoleParams{
 		CredEntries: testCreds,
 		RoleName:    testRoleName,
 		Region:      "us-west-2",
@@ -307,12 +499,16 @@ func TestCreateTaskExecutionRoleWithTags(t *testing.T) {
 			Key:   aws.String("Abbey"),
 			Value: aws.String("Road"),
 		},
+		&iam.Tag{
+			Key:   aws.String(DefaultManagementTagKey),
+			Value: aws.String(DefaultManagementTagValue),
+		},
 	}
 
 	mocks := setupTestController(t)
 	gomock.InOrder(
-		mocks.MockIAM.EXPECT().CreateOrFindRole(testRoleName, roleDescriptionString, assumeRolePolicyDocString, gomock.Any()).Do(func(w, x, y, z interface{}) {
-			tags := z.([]*iam.Tag)
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Do(func(x interface{}) {
+			tags := x.(iam.CreateRoleInput).Tags
 			assert.ElementsMatch(t, tags, expectedTags, "Expected Tags to match")
 		}).Return(*testRoleArn, nil),
 		mocks.MockIAM.EXPECT().CreateRole(gomock.Any()).Return(&iam.CreateRoleOutput{Role: &iam.Role{Arn: testRoleArn}}, nil),
@@ -325,7 +521,515 @@ func TestCreateTaskExecutionRoleWithTags(t *testing.T) {
 		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
 	)
 
-	policyCreateTime, err := createTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role")
+	assert.NotNil(t, roleResult, "Expected role result to be non-nil")
+}
+
+func TestCreateTaskExecutionRoleWithCustomManagementTag(t *testing.T) {
+	testRegistry := "myreg.test.io"
+	testRegCredARN := "arn:aws:secret/some-test-arn"
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		testRegistry: regcredio.BuildOutputEntry(testRegCredARN, "", []string{""}),
+	}
+	testRoleName := "myNginxProjectRole"
+
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
+	testRoleArn := aws.String("arn:aws:iam::role/" + testRoleName)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleName:    testRoleName,
+		Region:      "us-west-2",
+		Tags: map[string]*string{
+			"Owner":       aws.String("Platform"),
+			"provisioner": aws.String("manual"),
+		},
+		ManagementTagKey:   "provisioner",
+		ManagementTagValue: "regcreds",
+	}
+
+	expectedTags := []*iam.Tag{
+		&iam.Tag{
+			Key:   aws.String("Owner"),
+			Value: aws.String("Platform"),
+		},
+		&iam.Tag{
+			Key:   aws.String("provisioner"),
+			Value: aws.String("regcreds"),
+		},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Do(func(x interface{}) {
+			tags := x.(iam.CreateRoleInput).Tags
+			assert.ElementsMatch(t, tags, expectedTags, "Expected user tag to be replaced by management tag")
+		}).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
+	)
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role")
+}
+
+func TestCreateTaskExecutionRoleWithPermissionsBoundary(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{""}),
+	}
+	testRoleName := "myNginxProjectRole"
+	testBoundaryARN := "arn:aws:iam::111111111111:policy/boundary"
+
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
+	testRoleArn := aws.String("arn:aws:iam::role/" + testRoleName)
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Do(func(x interface{}) {
+			input := x.(iam.CreateRoleInput)
+			assert.Equal(t, testBoundaryARN, aws.StringValue(input.PermissionsBoundary))
+		}).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:         testCreds,
+		RoleName:            testRoleName,
+		Region:              "us-west-2",
+		PermissionsBoundary: testBoundaryARN,
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role")
+}
+
+func TestCreateTaskExecutionRoleWithInstanceProfile(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{""}),
+	}
+	testRoleName := "myNginxProjectRole"
+	testProfileARN := "arn:aws:iam::111111111111:instance-profile/" + testRoleName
+
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
+	testRoleArn := aws.String("arn:aws:iam::role/" + testRoleName)
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return(*testRoleArn, nil),
+		mocks.MockIAM.EXPECT().CreateInstanceProfile(testRoleName, "").Return(&iam.InstanceProfile{Arn: aws.String(testProfileARN)}, nil),
+		mocks.MockIAM.EXPECT().AddRoleToInstanceProfile(testRoleName, testRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:           testCreds,
+		RoleName:              testRoleName,
+		Region:                "us-west-2",
+		CreateInstanceProfile: true,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
 	assert.NoError(t, err, "Unexpected error when creating task execution role")
-	assert.NotNil(t, policyCreateTime, "Expected policy create time to be non-nil")
+	assert.Equal(t, testProfileARN, roleResult.InstanceProfileARN)
+	assert.True(t, roleResult.InstanceProfileCreated, "Expected instance profile to be reported as created")
+	assert.True(t, roleResult.RoleAddedToInstanceProfile, "Expected role to be reported as added to instance profile")
+}
+
+func TestCreateTaskExecutionRole_NoInstanceProfileByDefault(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{""}),
+	}
+	testRoleName := "myNginxProjectRole"
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(gomock.Any(), testRoleName).Return(nil, nil).Times(2)
+	mocks.MockIAM.EXPECT().CreateInstanceProfile(gomock.Any(), gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleName:    testRoleName,
+		Region:      "us-west-2",
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role")
+	assert.Empty(t, roleResult.InstanceProfileARN)
+}
+
+func TestCreateTaskExecutionRoleWithAdditionalPolicies(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{""}),
+	}
+	testRoleName := "myNginxProjectRole"
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
+	xrayPolicyARN := "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+	ecrPolicyARN := "arn:aws:iam::aws:policy/AmazonEC2ContainerRegistryReadOnly"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().GetPolicy(xrayPolicyARN).Return(&iam.Policy{Arn: aws.String(xrayPolicyARN)}, nil),
+		mocks.MockIAM.EXPECT().GetPolicy(ecrPolicyARN).Return(&iam.Policy{Arn: aws.String(ecrPolicyARN)}, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::role/"+testRoleName, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(xrayPolicyARN, testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(ecrPolicyARN, testRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:          testCreds,
+		RoleName:             testRoleName,
+		Region:               "us-west-2",
+		AdditionalPolicyARNs: []string{xrayPolicyARN, ecrPolicyARN},
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role")
+	assert.Equal(t, []string{getExecutionRolePolicyARN("us-west-2"), *testPolicyArn, xrayPolicyARN, ecrPolicyARN}, roleResult.AttachedPolicyARNs)
+	assert.Equal(t, []string{xrayPolicyARN, ecrPolicyARN}, roleResult.AdditionalPolicyARNs)
+}
+
+func TestCreateTaskExecutionRole_GeneratedPolicyFirst(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{""}),
+	}
+	testRoleName := "myNginxProjectRole"
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
+	xrayPolicyARN := "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().GetPolicy(xrayPolicyARN).Return(&iam.Policy{Arn: aws.String(xrayPolicyARN)}, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::role/"+testRoleName, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(xrayPolicyARN, testRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:          testCreds,
+		RoleName:             testRoleName,
+		Region:               "us-west-2",
+		AdditionalPolicyARNs: []string{xrayPolicyARN},
+		AttachOrder:          AttachOrderGeneratedFirst,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role")
+	assert.Equal(t, []string{*testPolicyArn, getExecutionRolePolicyARN("us-west-2"), xrayPolicyARN}, roleResult.AttachedPolicyARNs)
+}
+
+func TestCreateTaskExecutionRoles_ErrorOnInvalidAttachOrder(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{""}),
+		},
+		RoleName:    "myNginxProjectRole",
+		Region:      "us-west-2",
+		AttachOrder: "alphabetical",
+	}
+
+	results, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error for an invalid attach order")
+	assert.Nil(t, results, "Expected no roles to be changed")
+}
+
+func TestCreateTaskExecutionRole_ErrorOnMissingAdditionalPolicy(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{""}),
+	}
+	missingPolicyARN := "arn:aws:iam::111111111111:policy/doesNotExist"
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetPolicy(missingPolicyARN).Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "policy not found", nil))
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:          testCreds,
+		RoleName:             "myNginxProjectRole",
+		Region:               "us-west-2",
+		AdditionalPolicyARNs: []string{missingPolicyARN},
+	}
+
+	results, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when an additional policy does not exist")
+	assert.Contains(t, err.Error(), missingPolicyARN)
+	assert.Nil(t, results, "Expected no roles to be changed")
+}
+
+func TestAttachRolePolicies_ManagedPolicyNotFound(t *testing.T) {
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	notFound := awserr.New(iam.ErrCodeNoSuchEntityException, "Policy does not exist or is not attachable.", nil)
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(managedPolicyARN, "myRole").Return(nil, notFound)
+
+	attached, err := attachRolePolicies("", "myRole", managedPolicyARN, "", false, false, nil, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the managed policy does not exist")
+	assert.Contains(t, err.Error(), "--managed-policy-arn", "Expected the error to suggest the override")
+	assert.Empty(t, attached)
+
+	// a policy given with '--managed-policy-arn' was already checked, so its errors are returned as is
+	overrideARN := "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicyV2"
+	mocks.MockIAM.EXPECT().AttachRolePolicy(overrideARN, "myRole").Return(nil, notFound)
+	_, err = attachRolePolicies("", "myRole", overrideARN, "", true, false, nil, mocks.MockIAM)
+	assert.Equal(t, notFound, err)
+}
+
+func TestAttachRolePolicies_SkipManagedIfPresent(t *testing.T) {
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	overrideARN := "arn:aws:iam::111111111111:policy/CustomTaskExecutionPolicy"
+	otherPolicyARN := "arn:aws:iam::111111111111:policy/Other"
+	policyARN := "arn:aws:iam::111111111111:policy/myRole-policy"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		// the AWS managed policy is equivalent to the policy given with '--managed-policy-arn'
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myRole").Return([]*iam.AttachedPolicy{
+			{PolicyArn: aws.String(otherPolicyARN)},
+			{PolicyArn: aws.String(managedPolicyARN)},
+		}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(policyARN, "myRole").Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(otherPolicyARN, "myRole").Return(nil, nil),
+	)
+	attached, err := attachRolePolicies(policyARN, "myRole", overrideARN, "", true, true, []string{otherPolicyARN}, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when attaching policies")
+	assert.Equal(t, []string{policyARN, otherPolicyARN}, attached, "Expected the managed policy to be skipped")
+
+	// a customer managed policy with the same name isn't equivalent
+	customCopyARN := "arn:aws:iam::111111111111:" + ecsTaskExecutionPolicyResource
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myRole").Return([]*iam.AttachedPolicy{
+			{PolicyArn: aws.String(customCopyARN)},
+		}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(managedPolicyARN, "myRole").Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(policyARN, "myRole").Return(nil, nil),
+	)
+	attached, err = attachRolePolicies(policyARN, "myRole", managedPolicyARN, "", false, true, nil, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when attaching policies")
+	assert.Equal(t, []string{managedPolicyARN, policyARN}, attached)
+
+	listErr := errors.New("access denied")
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myRole").Return(nil, listErr)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(gomock.Any(), gomock.Any()).Times(0)
+	_, err = attachRolePolicies(policyARN, "myRole", managedPolicyARN, "", false, true, nil, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the attached policies can't be listed")
+}
+
+func TestCreateTaskExecutionRole_ManagedPolicyARN(t *testing.T) {
+	overrideARN := "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicyV2"
+	testRoleName := "myNginxProjectRole"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().GetPolicy(overrideARN).Return(&iam.Policy{Arn: aws.String(overrideARN)}, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::111111111111:role/"+testRoleName, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(overrideARN, testRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		RoleName:         testRoleName,
+		Region:           "us-west-2",
+		AllowEmpty:       true,
+		ManagedPolicyARN: overrideARN,
+	}
+
+	results, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role")
+	assert.Equal(t, []string{overrideARN}, results[0].AttachedPolicyARNs)
+}
+
+func TestValidateManagedPolicyARN(t *testing.T) {
+	mocks := setupTestController(t)
+	assert.NoError(t, validateManagedPolicyARN("", true, mocks.MockIAM))
+	assert.Error(t, validateManagedPolicyARN("arn:aws:iam::aws:policy/Custom", true, mocks.MockIAM), "Expected error with minimal managed")
+	assert.Error(t, validateManagedPolicyARN("AmazonECSTaskExecutionRolePolicy", false, mocks.MockIAM), "Expected error for a policy name")
+	assert.Error(t, validateManagedPolicyARN("arn:aws:iam::111111111111:role/Custom", false, mocks.MockIAM), "Expected error for a role ARN")
+
+	missingARN := "arn:aws:iam::aws:policy/DoesNotExist"
+	mocks.MockIAM.EXPECT().GetPolicy(missingARN).Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "policy not found", nil))
+	assert.Error(t, validateManagedPolicyARN(missingARN, false, mocks.MockIAM), "Expected error for a missing policy")
+}
+
+func TestCreateTaskExecutionRoles_ErrorOnEmptyCredEntries(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{},
+		RoleName:    "myNginxProjectRole",
+		Region:      "us-west-2",
+	}
+
+	results, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when no registry credentials are given")
+	assert.Nil(t, results, "Expected no roles to be changed")
+}
+
+func TestCreateTaskExecutionRole_AllowEmpty(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+	xrayPolicyARN := "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().GetPolicy(xrayPolicyARN).Return(&iam.Policy{Arn: aws.String(xrayPolicyARN)}, nil),
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::role/"+testRoleName, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(xrayPolicyARN, testRoleName).Return(nil, nil),
+	)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		RoleName:             testRoleName,
+		Region:               "us-west-2",
+		AdditionalPolicyARNs: []string{xrayPolicyARN},
+		AllowEmpty:           true,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role without registry credentials")
+	assert.True(t, roleResult.RoleCreated)
+	assert.Empty(t, roleResult.PolicyARN, "Expected no policy to be created")
+	assert.Equal(t, []*iam.Tag{{Key: aws.String(DefaultManagementTagKey), Value: aws.String(DefaultManagementTagValue)}}, roleResult.Tags, "Expected the tags of the new role to be recorded")
+	assert.Equal(t, []string{getExecutionRolePolicyARN("us-west-2"), xrayPolicyARN}, roleResult.AttachedPolicyARNs)
+}
+
+func TestCreateTaskExecutionRole_RoleOnly(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::role/"+testRoleName, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+	)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"my-registry": {
+				CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:my-registry",
+				KMSKeyID:      "arn:aws:kms:us-west-2:111111111111:key/1234",
+			},
+		},
+		RoleName: testRoleName,
+		Region:   "us-west-2",
+		RoleOnly: true,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role only")
+	assert.True(t, roleResult.RoleOnly)
+	assert.Empty(t, roleResult.PolicyARN, "Expected no policy to be created")
+	assert.Empty(t, roleResult.PolicyStatements)
+	assert.Equal(t, []string{getExecutionRolePolicyARN("us-west-2")}, roleResult.AttachedPolicyARNs)
+
+	testParams.IncludeECR = true
+	_, err = CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error for an option of the new policy")
+}
+
+func TestCreateTaskExecutionRole_IncludeECRWithoutCredentials(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+	testRepositoryARN := "arn:aws:ecr:us-west-2:111111111111:repository/nginx"
+	testPolicyARN := "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-" + testRoleName + "-policy"
+
+	mocks := setupTestController(t)
+	var policyDoc string
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::role/"+testRoleName, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Do(func(x interface{}) {
+			policyDoc = aws.StringValue(x.(iam.CreatePolicyInput).PolicyDocument)
+		}).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testPolicyARN)}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testPolicyARN, testRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		RoleName:        testRoleName,
+		Region:          "us-west-2",
+		AllowEmpty:      true,
+		IncludeECR:      true,
+		ECRRepositories: []string{testRepositoryARN},
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role with ECR access")
+	assert.Equal(t, testPolicyARN, roleResult.PolicyARN)
+	assert.Equal(t, []string{testRepositoryARN}, roleResult.ECRRepositories)
+	assert.Contains(t, policyDoc, `"Resource":["arn:aws:ecr:us-west-2:111111111111:repository/nginx"]`)
+	assert.Contains(t, policyDoc, ecrGetAuthorizationTokenAction)
+}
+
+func TestCreateTaskExecutionRole_ErrorOnInvalidECRRepository(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		RoleName:        "myNginxProjectRole",
+		Region:          "us-west-2",
+		AllowEmpty:      true,
+		IncludeECR:      true,
+		ECRRepositories: []string{"nginx"},
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error for a repository which is not an ARN")
+}
+
+func TestValidateAdditionalPolicies_ErrorOnDuplicate(t *testing.T) {
+	policyARN := "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetPolicy(policyARN).Return(&iam.Policy{Arn: aws.String(policyARN)}, nil)
+
+	err := validateAdditionalPolicies([]string{policyARN, policyARN}, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when a policy is given more than once")
+}
+
+func TestAddManagementTag(t *testing.T) {
+	userTags := map[string]*string{
+		"Hey": aws.String("Jude"),
+	}
+
+	actualTags := addManagementTag(userTags, DefaultManagementTagKey, DefaultManagementTagValue)
+	assert.Equal(t, map[string]*string{
+		"Hey":                   aws.String("Jude"),
+		DefaultManagementTagKey: aws.String(DefaultManagementTagValue),
+	}, actualTags)
+	assert.Equal(t, 1, len(userTags), "Expected user tags not to be modified")
+}
+
+func defaultManagementTags() []*iam.Tag {
+	return []*iam.Tag{
+		&iam.Tag{
+			Key:   aws.String(DefaultManagementTagKey),
+			Value: aws.String(DefaultManagementTagValue),
+		},
+	}
+}
+
+func expectedCreateRoleInput(roleName string, tags []*iam.Tag) iam.CreateRoleInput {
+	return iam.CreateRoleInput{
+		AssumeRolePolicyDocument: aws.String(assumeRolePolicyDocString),
+		Description:              aws.String(roleDescriptionString),
+		RoleName:                 aws.String(roleName),
+		Tags:                     tags,
+	}
 }
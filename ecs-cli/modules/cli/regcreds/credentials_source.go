@@ -0,0 +1,91 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	// CredentialsSourceEnv uses only the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables
+	CredentialsSourceEnv = "env"
+	// CredentialsSourceProfile uses only the keys of the AWS profile in the shared credentials file
+	CredentialsSourceProfile = "profile"
+	// CredentialsSourceInstance uses only the role of the EC2 instance, from the instance metadata service
+	CredentialsSourceInstance = "instance"
+	// CredentialsSourceChain resolves credentials as the ECS CLI does by default
+	CredentialsSourceChain = "chain"
+)
+
+// CredentialsSources are the valid values of --credentials-source
+var CredentialsSources = []string{CredentialsSourceEnv, CredentialsSourceProfile, CredentialsSourceInstance, CredentialsSourceChain}
+
+// applyCredentialsSource replaces the credentials of the command's session with ones from only the source given with
+// --credentials-source, so that a missing source fails instead of falling through to the next one in the chain. The
+// provider the credentials came from is logged.
+func applyCredentialsSource(c *cli.Context, commandConfig *config.CommandConfig) error {
+	source := c.String(flags.CredentialsSourceFlag)
+	if source == "" {
+		return nil
+	}
+	if source != CredentialsSourceChain && (c.String(flags.WebIdentityTokenFileFlag) != "" || c.String(flags.WebIdentityRoleARNFlag) != "") {
+		return fmt.Errorf("'--%s %s' can't be used with a web identity; use '--%s %s' instead", flags.CredentialsSourceFlag, source, flags.CredentialsSourceFlag, CredentialsSourceChain)
+	}
+
+	creds, description, err := credentialsFromSource(source, config.RecursiveFlagSearch(c, flags.AWSProfileFlag), commandConfig.Session)
+	if err != nil {
+		return err
+	}
+	if creds != nil {
+		commandConfig.Session = commandConfig.Session.Copy(&aws.Config{Credentials: creds})
+	}
+
+	value, err := commandConfig.Session.Config.Credentials.Get()
+	if err != nil {
+		return errors.Wrapf(err, "no credentials found in %s", description)
+	}
+	log.Infof("Using credentials from %s (provider %s)", description, value.ProviderName)
+	return nil
+}
+
+// credentialsFromSource returns the credentials provider for the source and a description of it for messages. The
+// credentials are nil for CredentialsSourceChain, in which case the session's credentials are kept.
+func credentialsFromSource(source, profile string, sess *session.Session) (*credentials.Credentials, string, error) {
+	switch source {
+	case CredentialsSourceEnv:
+		return credentials.NewEnvCredentials(), "the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables", nil
+	case CredentialsSourceProfile:
+		description := "the default profile of the shared credentials file"
+		if profile != "" {
+			description = fmt.Sprintf("profile '%s' of the shared credentials file", profile)
+		}
+		return credentials.NewSharedCredentials("", profile), description, nil
+	case CredentialsSourceInstance:
+		return ec2rolecreds.NewCredentials(sess), "the EC2 instance metadata service", nil
+	case CredentialsSourceChain:
+		return nil, "the default credential chain", nil
+	}
+	return nil, "", fmt.Errorf("invalid value '%s' for '--%s'; must be one of %s", source, flags.CredentialsSourceFlag, strings.Join(CredentialsSources, ", "))
+}
@@ -0,0 +1,124 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestApplyCredentialsSource_Env(t *testing.T) {
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	os.Setenv("AWS_ACCESS_KEY_ID", "envAKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "envSKID")
+
+	commandConfig := testCredentialsSourceConfig(t)
+	err := applyCredentialsSource(testCredentialsSourceContext(CredentialsSourceEnv, ""), commandConfig)
+	assert.NoError(t, err, "Unexpected error using credentials from the environment")
+
+	creds, err := commandConfig.Session.Config.Credentials.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "envAKID", creds.AccessKeyID)
+	assert.Equal(t, credentials.EnvProviderName, creds.ProviderName)
+}
+
+func TestApplyCredentialsSource_ErrorOnMissingEnv(t *testing.T) {
+	os.Unsetenv("AWS_ACCESS_KEY_ID")
+	os.Unsetenv("AWS_ACCESS_KEY")
+	os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	os.Unsetenv("AWS_SECRET_KEY")
+
+	// the session's own credentials are not used as a fallback
+	err := applyCredentialsSource(testCredentialsSourceContext(CredentialsSourceEnv, ""), testCredentialsSourceConfig(t))
+	assert.Error(t, err, "Expected error when the environment has no credentials")
+	assert.Contains(t, err.Error(), "environment variables")
+}
+
+func TestApplyCredentialsSource_Profile(t *testing.T) {
+	credsFile, err := ioutil.TempFile("", "credentials")
+	assert.NoError(t, err, "Unexpected error creating credentials file")
+	defer os.Remove(credsFile.Name())
+	_, err = credsFile.WriteString("[ci]\naws_access_key_id = profileAKID\naws_secret_access_key = profileSKID\n")
+	assert.NoError(t, err, "Unexpected error writing credentials file")
+	assert.NoError(t, credsFile.Close())
+	defer os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE")
+	os.Setenv("AWS_SHARED_CREDENTIALS_FILE", credsFile.Name())
+
+	commandConfig := testCredentialsSourceConfig(t)
+	err = applyCredentialsSource(testCredentialsSourceContext(CredentialsSourceProfile, "ci"), commandConfig)
+	assert.NoError(t, err, "Unexpected error using credentials from the profile")
+	creds, err := commandConfig.Session.Config.Credentials.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "profileAKID", creds.AccessKeyID)
+
+	err = applyCredentialsSource(testCredentialsSourceContext(CredentialsSourceProfile, "missing"), testCredentialsSourceConfig(t))
+	assert.Error(t, err, "Expected error when the profile does not exist")
+	assert.Contains(t, err.Error(), "profile 'missing'")
+}
+
+func TestApplyCredentialsSource_Chain(t *testing.T) {
+	commandConfig := testCredentialsSourceConfig(t)
+	sess := commandConfig.Session
+
+	err := applyCredentialsSource(testCredentialsSourceContext(CredentialsSourceChain, ""), commandConfig)
+	assert.NoError(t, err, "Unexpected error using the default credential chain")
+	assert.Equal(t, sess, commandConfig.Session, "Expected session to be unchanged")
+}
+
+func TestApplyCredentialsSource_NoFlag(t *testing.T) {
+	commandConfig := testCredentialsSourceConfig(t)
+	sess := commandConfig.Session
+
+	err := applyCredentialsSource(cli.NewContext(nil, flag.NewFlagSet("ecs-cli", 0), nil), commandConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, sess, commandConfig.Session, "Expected session to be unchanged")
+}
+
+func TestApplyCredentialsSource_Errors(t *testing.T) {
+	err := applyCredentialsSource(testCredentialsSourceContext("vault", ""), testCredentialsSourceConfig(t))
+	assert.Error(t, err, "Expected error on invalid credentials source")
+
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.String(flags.CredentialsSourceFlag, CredentialsSourceInstance, "")
+	flagSet.String(flags.WebIdentityRoleARNFlag, testWebIdentityRoleARN, "")
+	err = applyCredentialsSource(cli.NewContext(nil, flagSet, nil), testCredentialsSourceConfig(t))
+	assert.Error(t, err, "Expected error when combined with a web identity")
+}
+
+func testCredentialsSourceConfig(t *testing.T) *config.CommandConfig {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewStaticCredentials("staticAKID", "staticSKID", ""),
+	})
+	assert.NoError(t, err, "Unexpected error creating session")
+	return &config.CommandConfig{Session: sess}
+}
+
+func testCredentialsSourceContext(source, profile string) *cli.Context {
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.String(flags.CredentialsSourceFlag, source, "")
+	flagSet.String(flags.AWSProfileFlag, profile, "")
+	return cli.NewContext(nil, flagSet, nil)
+}
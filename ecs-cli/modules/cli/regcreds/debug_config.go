@@ -0,0 +1,75 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+)
+
+// debugConfig is everything the ecs-cli computed from its inputs to set up the task execution roles
+type debugConfig struct {
+	Role   debugRoleConfig `json:"role"`
+	Policy interface{}     `json:"policy"`
+}
+
+type debugRoleConfig struct {
+	Names               []string          `json:"names"`
+	TrustPolicy         interface{}       `json:"trustPolicy"`
+	Path                string            `json:"path,omitempty"`
+	PermissionsBoundary string            `json:"permissionsBoundary,omitempty"`
+	Tags                map[string]string `json:"tags"`
+	// AdditionalPolicyARNs are attached after the generated policy
+	AdditionalPolicyARNs []string `json:"additionalPolicyArns,omitempty"`
+}
+
+// writeDebugConfig prints the role config and policy document as indented JSON. Documents which are not valid JSON
+// are printed as strings so that they can still be inspected.
+func writeDebugConfig(w io.Writer, roleNames []string, params ExecutionRoleParams, tags []*iam.Tag, policyDoc string) error {
+	config := debugConfig{
+		Role: debugRoleConfig{
+			Names:               roleNames,
+			TrustPolicy:         debugDocument(params.trustPolicy()),
+			Path:                params.Path,
+			PermissionsBoundary: params.PermissionsBoundary,
+			Tags:                make(map[string]string, len(tags)),
+
+			AdditionalPolicyARNs: params.AdditionalPolicyARNs,
+		},
+		Policy: debugDocument(policyDoc),
+	}
+	for _, tag := range tags {
+		config.Role.Tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	data, err := json.MarshalIndent(config, jsonPrefix, jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal debug config to JSON")
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// debugDocument keeps the keys of a JSON document in their original order
+func debugDocument(doc string) interface{} {
+	if !json.Valid([]byte(doc)) {
+		return doc
+	}
+	return json.RawMessage(doc)
+}
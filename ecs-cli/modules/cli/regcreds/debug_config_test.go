@@ -0,0 +1,113 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDebugConfig(t *testing.T) {
+	params := ExecutionRoleParams{
+		Path:                "/ecs/",
+		PermissionsBoundary: "arn:aws:iam::111111111111:policy/boundary",
+	}
+	tags := []*iam.Tag{{Key: aws.String(DefaultManagementTagKey), Value: aws.String(DefaultManagementTagValue)}}
+	policyDoc := `{"Version":"2012-10-17","Statement":[]}`
+
+	var buf bytes.Buffer
+	err := writeDebugConfig(&buf, []string{"webRole", "workerRole"}, params, tags, policyDoc)
+	assert.NoError(t, err, "Unexpected error writing debug config")
+	assert.Contains(t, buf.String(), "\n  \"role\": {", "Expected debug config to be indented")
+
+	var config struct {
+		Role struct {
+			Names               []string          `json:"names"`
+			TrustPolicy         json.RawMessage   `json:"trustPolicy"`
+			Path                string            `json:"path"`
+			PermissionsBoundary string            `json:"permissionsBoundary"`
+			Tags                map[string]string `json:"tags"`
+		} `json:"role"`
+		Policy json.RawMessage `json:"policy"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &config), "Expected debug config to be valid JSON")
+	assert.Equal(t, []string{"webRole", "workerRole"}, config.Role.Names)
+	assert.JSONEq(t, assumeRolePolicyDocString, string(config.Role.TrustPolicy))
+	assert.Equal(t, "/ecs/", config.Role.Path)
+	assert.Equal(t, "arn:aws:iam::111111111111:policy/boundary", config.Role.PermissionsBoundary)
+	assert.Equal(t, map[string]string{DefaultManagementTagKey: DefaultManagementTagValue}, config.Role.Tags)
+	assert.JSONEq(t, policyDoc, string(config.Policy))
+}
+
+func TestWriteDebugConfig_InvalidTrustPolicy(t *testing.T) {
+	params := ExecutionRoleParams{TrustPolicy: "not json"}
+
+	var buf bytes.Buffer
+	err := writeDebugConfig(&buf, []string{"webRole"}, params, nil, `{}`)
+	assert.NoError(t, err, "Unexpected error writing debug config")
+	assert.Contains(t, buf.String(), `"trustPolicy": "not json"`, "Expected invalid document to be printed as a string")
+}
+
+func TestCreateTaskExecutionRole_DebugOutputBeforeIAMChanges(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+
+	var buf bytes.Buffer
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Do(func(_ iam.CreateRoleInput) {
+		assert.Contains(t, buf.String(), "arn:aws:secret/some-test-arn", "Expected policy document to be printed before the role is created")
+		assert.Contains(t, buf.String(), testRoleName)
+	}).Return("", errors.New("something went wrong"))
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleName:    testRoleName,
+		Region:      "us-west-2",
+		DebugOutput: &buf,
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when CreateOrFindRole fails")
+}
+
+func TestCreateTaskExecutionRole_ErrorOnPolicyDocumentBeforeIAMChanges(t *testing.T) {
+	testRegKMSKey := "arn:aws:kms:key/67yt-756yth"
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", testRegKMSKey, []string{"test"}),
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testRegKMSKey).Return("", errors.New("something went wrong"))
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleName:    "myNginxProjectRole",
+		Region:      "us-west-2",
+	}
+
+	results, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when the policy document can't be generated")
+	assert.Nil(t, results, "Expected no roles to be changed")
+}
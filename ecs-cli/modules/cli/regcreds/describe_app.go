@@ -0,0 +1,174 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	secretResourceType = "secret"
+	keyResourceType    = "kms key"
+	// parameterResourceType is only listed for entries which were granted SSM actions
+	parameterResourceType = "parameter"
+)
+
+// accessEntry is a single secret or KMS key the role can access, with the actions allowed on it
+type accessEntry struct {
+	ResourceType string
+	ARN          string
+	Actions      []string
+	Details      string
+}
+
+// Describe prints the secrets and KMS keys a task execution role created by the ecs-cli can access
+func Describe(c *cli.Context) {
+	roleName := c.Args().First()
+	if roleName == "" {
+		log.Fatal("Error executing 'describe': a role name must be specified")
+	}
+
+	commandConfig := getNewCommandConfig(c, "", "")
+	roleClient := iamClient.NewIAMClient(commandConfig)
+	keyClient := kmsClient.NewKMSClient(commandConfig)
+
+	if err := describeRole(roleName, roleClient, keyClient, os.Stdout); err != nil {
+		log.Fatal("Error executing 'describe': ", err)
+	}
+}
+
+// describeRole writes a table of the resources granted by each generated policy attached to the role
+func describeRole(roleName string, roleClient iamClient.Client, keyClient kmsClient.Client, w io.Writer) error {
+	policies, err := roleClient.ListAttachedRolePolicies(roleName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list policies attached to role %s", roleName)
+	}
+
+	generated, err := generatedPolicies(policies, roleClient)
+	if err != nil {
+		return err
+	}
+	var entries []*accessEntry
+	entriesByARN := make(map[string]*accessEntry)
+	for _, policy := range generated {
+		policyARN := aws.StringValue(policy.Arn)
+		document, err := roleClient.GetPolicyDocument(policyARN)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read policy %s", policyARN)
+		}
+		policyDoc := PolicyDocument{}
+		if err = json.Unmarshal([]byte(document), &policyDoc); err != nil {
+			return errors.Wrapf(err, "failed to parse policy %s", policyARN)
+		}
+
+		for _, statement := range policyDoc.Statement {
+			if statement.Effect != "Allow" {
+				continue
+			}
+			for _, resource := range statement.Resource {
+				entry, ok := entriesByARN[resource]
+				if !ok {
+					entry = &accessEntry{ARN: resource}
+					entriesByARN[resource] = entry
+					entries = append(entries, entry)
+				}
+				entry.Actions = appendMissing(entry.Actions, statement.Action...)
+				if stage := statement.Condition["StringEquals"][versionStageConditionKey]; stage != "" {
+					entry.Details = "version stage " + stage
+				}
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no policy created by the ECS CLI is attached to role %s", roleName)
+	}
+
+	for _, entry := range entries {
+		parsedARN, err := arn.Parse(entry.ARN)
+		if err != nil {
+			continue
+		}
+		switch parsedARN.Service {
+		case "secretsmanager":
+			entry.ResourceType = secretResourceType
+		case "ssm":
+			entry.ResourceType = parameterResourceType
+		case "kms":
+			entry.ResourceType = keyResourceType
+			entry.Details = describeKey(entry.ARN, keyClient)
+		}
+	}
+
+	tw := new(tabwriter.Writer)
+	tw.Init(w, cellWidthInSpaces, widthBetweenCellsInSpaces, cellPaddingInSpaces, paddingCharacter, noFormatting)
+	fmt.Fprintln(tw, "TYPE\tACTIONS\tDETAILS\tARN")
+	for _, entry := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.ResourceType, strings.Join(entry.Actions, ","), entry.Details, entry.ARN)
+	}
+	return tw.Flush()
+}
+
+// describeKey returns the aliases and description of a KMS key. Failures are only logged, since the key ARN is still
+// printed and the caller may not be allowed to read key metadata.
+func describeKey(keyARN string, keyClient kmsClient.Client) string {
+	var details []string
+
+	aliases, err := keyClient.ListAliases(keyARN)
+	if err != nil {
+		log.WithError(err).Warnf("Unable to list aliases of KMS key %s", keyARN)
+	}
+	sort.Strings(aliases)
+	details = append(details, aliases...)
+
+	keyResult, err := keyClient.DescribeKey(keyARN)
+	if err != nil {
+		log.WithError(err).Warnf("Unable to describe KMS key %s", keyARN)
+	} else if keyResult.KeyMetadata != nil {
+		if description := aws.StringValue(keyResult.KeyMetadata.Description); description != "" {
+			details = append(details, fmt.Sprintf("(%s)", description))
+		}
+	}
+
+	return strings.Join(details, " ")
+}
+
+func appendMissing(values []string, additions ...string) []string {
+	for _, addition := range additions {
+		found := false
+		for _, value := range values {
+			if value == addition {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, addition)
+		}
+	}
+	return values
+}
@@ -0,0 +1,137 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeRole(t *testing.T) {
+	testRoleName := "myTestRole"
+	testSecretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret"
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	generatedPolicyARN := "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myTestRole-policy-20190601T000000Z"
+	policyDocument := `{"Version":"2012-10-17","Statement":[
+		{"Sid":"Prod","Effect":"Allow","Action":["secretsmanager:GetSecretValue"],"Resource":["` + testSecretARN + `"],
+		 "Condition":{"StringEquals":{"secretsmanager:VersionStage":"AWSCURRENT"}}},
+		{"Sid":"ProdDecrypt","Effect":"Allow","Action":["kms:Decrypt"],"Resource":["` + testKeyARN + `"]}]}`
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testRoleName).Return([]*iam.AttachedPolicy{
+		{PolicyName: aws.String("AmazonECSTaskExecutionRolePolicy"), PolicyArn: aws.String(getExecutionRolePolicyARN("us-west-2"))},
+		{PolicyName: aws.String("amazon-ecs-cli-setup-myTestRole-policy-20190601T000000Z"), PolicyArn: aws.String(generatedPolicyARN)},
+	}, nil)
+	mocks.MockIAM.EXPECT().GetPolicy(generatedPolicyARN).Return(testDescribedPolicy(generatedPolicyARN, policyDescription(testRoleName)), nil)
+	mocks.MockIAM.EXPECT().GetPolicyDocument(generatedPolicyARN).Return(policyDocument, nil)
+	mocks.MockKMS.EXPECT().ListAliases(testKeyARN).Return([]string{"alias/registry-creds"}, nil)
+	mocks.MockKMS.EXPECT().DescribeKey(testKeyARN).Return(&kms.DescribeKeyOutput{
+		KeyMetadata: &kms.KeyMetadata{Arn: aws.String(testKeyARN), Description: aws.String("Registry credentials key")},
+	}, nil)
+
+	output := &bytes.Buffer{}
+	err := describeRole(testRoleName, mocks.MockIAM, mocks.MockKMS, output)
+	assert.NoError(t, err, "Unexpected error describing role")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, 3, len(lines), "Expected header and one row per resource")
+	assert.Contains(t, lines[0], "TYPE")
+	assert.Contains(t, lines[1], secretResourceType)
+	assert.Contains(t, lines[1], "version stage AWSCURRENT")
+	assert.Contains(t, lines[1], testSecretARN)
+	assert.Contains(t, lines[2], keyResourceType)
+	assert.Contains(t, lines[2], "alias/registry-creds (Registry credentials key)")
+	assert.Contains(t, lines[2], testKeyARN)
+}
+
+func TestDescribeRole_SharedKeyListedOnce(t *testing.T) {
+	testRoleName := "myTestRole"
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	generatedPolicyARN := "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myTestRole-policy-20190601T000000Z"
+	policyDocument := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["kms:Decrypt","secretsmanager:GetSecretValue"],"Resource":["arn:aws:secretsmanager:us-west-2:111111111111:secret:first","` + testKeyARN + `"]},
+		{"Effect":"Allow","Action":["kms:Decrypt","secretsmanager:GetSecretValue"],"Resource":["arn:aws:secretsmanager:us-west-2:111111111111:secret:second","` + testKeyARN + `"]}]}`
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testRoleName).Return([]*iam.AttachedPolicy{
+		{PolicyName: aws.String("amazon-ecs-cli-setup-myTestRole-policy-20190601T000000Z"), PolicyArn: aws.String(generatedPolicyARN)},
+	}, nil)
+	mocks.MockIAM.EXPECT().GetPolicy(generatedPolicyARN).Return(testDescribedPolicy(generatedPolicyARN, policyDescription(testRoleName)), nil)
+	mocks.MockIAM.EXPECT().GetPolicyDocument(generatedPolicyARN).Return(policyDocument, nil)
+	mocks.MockKMS.EXPECT().ListAliases(testKeyARN).Return(nil, errors.New("access denied"))
+	mocks.MockKMS.EXPECT().DescribeKey(testKeyARN).Return(nil, errors.New("access denied"))
+
+	output := &bytes.Buffer{}
+	err := describeRole(testRoleName, mocks.MockIAM, mocks.MockKMS, output)
+	assert.NoError(t, err, "Expected KMS lookup failures not to fail describe")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, 4, len(lines), "Expected header, two secrets and one key")
+	assert.Equal(t, 1, strings.Count(output.String(), testKeyARN))
+}
+
+func TestDescribeRole_NamedPolicy(t *testing.T) {
+	testSecretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret"
+	namedPolicyARN := "arn:aws:iam::111111111111:policy/payments-registry-access"
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myTestRole").Return([]*iam.AttachedPolicy{
+		{PolicyName: aws.String("payments-registry-access"), PolicyArn: aws.String(namedPolicyARN)},
+	}, nil)
+	// a policy named with --policy-name is identified by its description
+	mocks.MockIAM.EXPECT().GetPolicy(namedPolicyARN).Return(testDescribedPolicy(namedPolicyARN, policyDescription("myTestRole")), nil)
+	mocks.MockIAM.EXPECT().GetPolicyDocument(namedPolicyARN).Return(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["secretsmanager:GetSecretValue"],"Resource":["`+testSecretARN+`"]}]}`, nil)
+
+	output := &bytes.Buffer{}
+	err := describeRole("myTestRole", mocks.MockIAM, mocks.MockKMS, output)
+	assert.NoError(t, err, "Unexpected error describing role")
+	assert.Contains(t, output.String(), testSecretARN)
+}
+
+func TestDescribeRole_ErrorNoGeneratedPolicy(t *testing.T) {
+	teamPolicyARN := "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myTestRole-policy-team"
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myTestRole").Return([]*iam.AttachedPolicy{
+		{PolicyName: aws.String("amazon-ecs-cli-setup-myTestRole-policy-team"), PolicyArn: aws.String(teamPolicyARN)},
+	}, nil)
+	// the name looks generated, but the policy wasn't created by the ECS CLI
+	mocks.MockIAM.EXPECT().GetPolicy(teamPolicyARN).Return(testDescribedPolicy(teamPolicyARN, "Managed by the team"), nil)
+
+	err := describeRole("myTestRole", mocks.MockIAM, mocks.MockKMS, &bytes.Buffer{})
+	assert.Error(t, err, "Expected error when no generated policy is attached")
+}
+
+func TestDescribeRole_ErrorOnListPolicies(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myTestRole").Return(nil, errors.New("something went wrong"))
+
+	err := describeRole("myTestRole", mocks.MockIAM, mocks.MockKMS, &bytes.Buffer{})
+	assert.Error(t, err, "Expected error when listing attached policies fails")
+}
+
+func testDescribedPolicy(policyARN, description string) *iam.Policy {
+	return &iam.Policy{
+		Arn:         aws.String(policyARN),
+		PolicyName:  aws.String(policyNameFromARN(policyARN)),
+		Description: aws.String(description),
+	}
+}
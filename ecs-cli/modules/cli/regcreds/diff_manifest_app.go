@@ -0,0 +1,341 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	diffChangeAdded   = "added"
+	diffChangeRemoved = "removed"
+	diffChangeChanged = "changed"
+)
+
+// the KMS key of a secret without a kms_key_id
+const diffDefaultKMSKey = "aws/secretsmanager"
+
+// manifestDiff lists the differences between two manifests, or two output files, in order of role and registry name;
+// both lists are empty if the files describe the same resources
+type manifestDiff struct {
+	Roles   []roleDiff   `json:"roles"`
+	Secrets []secretDiff `json:"secrets"`
+}
+
+// roleDiff describes a task execution role which is only in one of the files, or whose policies differ
+type roleDiff struct {
+	RoleName string `json:"roleName"`
+	Change   string `json:"change"`
+	// OldPolicyARN and NewPolicyARN are the registry credentials policy of the role in each file
+	OldPolicyARN string `json:"oldPolicyArn,omitempty"`
+	NewPolicyARN string `json:"newPolicyArn,omitempty"`
+	// AddedPolicyARNs are only attached to the role in the new file, and RemovedPolicyARNs only in the old file
+	AddedPolicyARNs   []string `json:"addedPolicyArns,omitempty"`
+	RemovedPolicyARNs []string `json:"removedPolicyArns,omitempty"`
+}
+
+// secretDiff describes a registry secret which is only in one of the files, or whose secret, key or containers differ
+type secretDiff struct {
+	RegistryName string `json:"registryName"`
+	Change       string `json:"change"`
+	OldSecretARN string `json:"oldSecretArn,omitempty"`
+	NewSecretARN string `json:"newSecretArn,omitempty"`
+	// KMS keys and containers are only listed in output files
+	OldKMSKeyID       string   `json:"oldKmsKeyId,omitempty"`
+	NewKMSKeyID       string   `json:"newKmsKeyId,omitempty"`
+	AddedContainers   []string `json:"addedContainers,omitempty"`
+	RemovedContainers []string `json:"removedContainers,omitempty"`
+}
+
+// diffSnapshot holds the resources of a manifest or output file which are compared
+type diffSnapshot struct {
+	roles   map[string]diffRole
+	secrets map[string]diffSecret
+}
+
+type diffRole struct {
+	policyARN          string
+	attachedPolicyARNs []string
+}
+
+type diffSecret struct {
+	secretARN      string
+	kmsKeyID       string
+	containerNames []string
+}
+
+// DiffManifest prints the differences between the roles, policies and secrets of two manifests or two output files
+// written by 'registry-creds up', without making any AWS requests
+func DiffManifest(c *cli.Context) {
+	args := c.Args()
+	if len(args) != 2 {
+		log.Fatal("Exactly 2 files are required. Found: ", len(args))
+	}
+	format := c.String(flags.FormatFlag)
+	if format != "" && format != TableOutputFormat && format != JSONOutputFormat {
+		log.Fatalf("Error executing 'diff-manifest': invalid value '%s' for '--%s'; valid values are %s and %s", format, flags.FormatFlag, TableOutputFormat, JSONOutputFormat)
+	}
+
+	diff, err := diffManifestFiles(regcredio.FileStore{}, args[0], args[1])
+	if err != nil {
+		log.Fatal("Error executing 'diff-manifest': ", err)
+	}
+
+	if format == JSONOutputFormat {
+		err = printManifestDiffJSON(diff, os.Stdout)
+	} else {
+		err = printManifestDiffTable(diff, os.Stdout)
+	}
+	if err != nil {
+		log.Fatal("Error executing 'diff-manifest': ", err)
+	}
+}
+
+// diffManifestFiles compares the old file with the new one; both must be manifests, or both output files
+func diffManifestFiles(store regcredio.Store, oldFile, newFile string) (manifestDiff, error) {
+	oldSnapshot, oldIsManifest, err := readDiffSnapshot(store, oldFile)
+	if err != nil {
+		return manifestDiff{}, err
+	}
+	newSnapshot, newIsManifest, err := readDiffSnapshot(store, newFile)
+	if err != nil {
+		return manifestDiff{}, err
+	}
+	if oldIsManifest != newIsManifest {
+		return manifestDiff{}, fmt.Errorf("%s and %s can't be compared, since only one of them is a manifest; compare two manifests or two output files", oldFile, newFile)
+	}
+	return diffSnapshots(oldSnapshot, newSnapshot), nil
+}
+
+// readDiffSnapshot reads the file, and returns whether it is a manifest rather than an output file
+func readDiffSnapshot(store regcredio.Store, filename string) (diffSnapshot, bool, error) {
+	manifest, credsOutput, err := regcredio.ReadManifestOrCredsOutputFrom(store, filename)
+	if err != nil {
+		return diffSnapshot{}, false, err
+	}
+	if manifest != nil {
+		return manifestSnapshot(*manifest), true, nil
+	}
+	return credsOutputSnapshot(*credsOutput), false, nil
+}
+
+// manifestSnapshot returns the roles of the manifest and the secrets created by its run
+func manifestSnapshot(manifest regcredio.ECSRegCredsManifest) diffSnapshot {
+	snapshot := diffSnapshot{roles: make(map[string]diffRole), secrets: make(map[string]diffSecret)}
+	for _, role := range manifest.Roles {
+		snapshot.roles[role.RoleName] = diffRole{policyARN: role.PolicyARN, attachedPolicyARNs: role.AttachedPolicyARNs}
+	}
+	for _, secret := range manifest.Secrets {
+		snapshot.secrets[secret.RegistryName] = diffSecret{secretARN: secret.SecretARN}
+	}
+	return snapshot
+}
+
+// credsOutputSnapshot returns the roles of the output file with their policies, and the secrets granted to them.
+// Output files written before the roles were listed only name the role.
+func credsOutputSnapshot(credsOutput regcredio.ECSRegistryCredsOutput) diffSnapshot {
+	resources := credsOutput.CredentialResources
+	snapshot := diffSnapshot{roles: make(map[string]diffRole), secrets: make(map[string]diffSecret)}
+	for _, role := range resources.TaskExecutionRoles {
+		var attached []string
+		for _, policyARN := range append([]string{role.ManagedPolicyARN, role.PolicyARN}, role.AdditionalPolicyARNs...) {
+			if policyARN != "" {
+				attached = append(attached, policyARN)
+			}
+		}
+		snapshot.roles[role.RoleName] = diffRole{policyARN: role.PolicyARN, attachedPolicyARNs: attached}
+	}
+	if len(resources.TaskExecutionRoles) == 0 && resources.TaskExecutionRole != "" {
+		snapshot.roles[resources.TaskExecutionRole] = diffRole{}
+	}
+	for registryName, entry := range resources.ContainerCredentials {
+		snapshot.secrets[registryName] = diffSecret{secretARN: entry.CredentialARN, kmsKeyID: entry.KMSKeyID, containerNames: entry.ContainerNames}
+	}
+	return snapshot
+}
+
+func diffSnapshots(oldSnapshot, newSnapshot diffSnapshot) manifestDiff {
+	diff := manifestDiff{Roles: []roleDiff{}, Secrets: []secretDiff{}}
+
+	for _, roleName := range unionKeys(roleNamesOf(oldSnapshot.roles), roleNamesOf(newSnapshot.roles)) {
+		oldRole, inOld := oldSnapshot.roles[roleName]
+		newRole, inNew := newSnapshot.roles[roleName]
+		roleChange := roleDiff{
+			RoleName:          roleName,
+			OldPolicyARN:      oldRole.policyARN,
+			NewPolicyARN:      newRole.policyARN,
+			AddedPolicyARNs:   missingFrom(newRole.attachedPolicyARNs, oldRole.attachedPolicyARNs),
+			RemovedPolicyARNs: missingFrom(oldRole.attachedPolicyARNs, newRole.attachedPolicyARNs),
+		}
+		switch {
+		case !inOld:
+			roleChange.Change = diffChangeAdded
+		case !inNew:
+			roleChange.Change = diffChangeRemoved
+		case oldRole.policyARN != newRole.policyARN || len(roleChange.AddedPolicyARNs) > 0 || len(roleChange.RemovedPolicyARNs) > 0:
+			roleChange.Change = diffChangeChanged
+		default:
+			continue
+		}
+		diff.Roles = append(diff.Roles, roleChange)
+	}
+
+	for _, registryName := range unionKeys(registryNamesOf(oldSnapshot.secrets), registryNamesOf(newSnapshot.secrets)) {
+		oldSecret, inOld := oldSnapshot.secrets[registryName]
+		newSecret, inNew := newSnapshot.secrets[registryName]
+		secretChange := secretDiff{
+			RegistryName:      registryName,
+			OldSecretARN:      oldSecret.secretARN,
+			NewSecretARN:      newSecret.secretARN,
+			AddedContainers:   missingFrom(newSecret.containerNames, oldSecret.containerNames),
+			RemovedContainers: missingFrom(oldSecret.containerNames, newSecret.containerNames),
+		}
+		if inOld && inNew && oldSecret.kmsKeyID != newSecret.kmsKeyID {
+			secretChange.OldKMSKeyID = kmsKeyOrDefault(oldSecret.kmsKeyID)
+			secretChange.NewKMSKeyID = kmsKeyOrDefault(newSecret.kmsKeyID)
+		}
+		switch {
+		case !inOld:
+			secretChange.Change = diffChangeAdded
+		case !inNew:
+			secretChange.Change = diffChangeRemoved
+		case oldSecret.secretARN != newSecret.secretARN || secretChange.OldKMSKeyID != "" || len(secretChange.AddedContainers) > 0 || len(secretChange.RemovedContainers) > 0:
+			secretChange.Change = diffChangeChanged
+		default:
+			continue
+		}
+		diff.Secrets = append(diff.Secrets, secretChange)
+	}
+	return diff
+}
+
+func kmsKeyOrDefault(kmsKeyID string) string {
+	if kmsKeyID == "" {
+		return diffDefaultKMSKey
+	}
+	return kmsKeyID
+}
+
+func roleNamesOf(roles map[string]diffRole) []string {
+	names := make([]string, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	return names
+}
+
+func registryNamesOf(secrets map[string]diffSecret) []string {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	return names
+}
+
+// unionKeys returns the names in either list, sorted
+func unionKeys(oldNames, newNames []string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, name := range append(oldNames, newNames...) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// missingFrom returns the values which are not in others, in their original order
+func missingFrom(values, others []string) []string {
+	present := make(map[string]bool, len(others))
+	for _, other := range others {
+		present[other] = true
+	}
+	var missing []string
+	for _, value := range values {
+		if !present[value] {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+func printManifestDiffJSON(diff manifestDiff, w io.Writer) error {
+	data, err := json.MarshalIndent(diff, jsonPrefix, jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal diff to JSON")
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// printManifestDiffTable prints one row per difference; a changed role or secret has a row for each of its changes
+func printManifestDiffTable(diff manifestDiff, w io.Writer) error {
+	if len(diff.Roles) == 0 && len(diff.Secrets) == 0 {
+		_, err := fmt.Fprintln(w, "No differences found.")
+		return err
+	}
+	tw := new(tabwriter.Writer)
+	tw.Init(w, cellWidthInSpaces, widthBetweenCellsInSpaces, cellPaddingInSpaces, paddingCharacter, noFormatting)
+	fmt.Fprintln(tw, "RESOURCE\tNAME\tCHANGE\tDETAILS")
+	for _, role := range diff.Roles {
+		if role.Change != diffChangeChanged {
+			fmt.Fprintf(tw, "role\t%s\t%s\t\n", role.RoleName, role.Change)
+		} else if role.OldPolicyARN != role.NewPolicyARN {
+			fmt.Fprintf(tw, "policy\t%s\t%s\t%s -> %s\n", role.RoleName, diffChangeChanged, role.OldPolicyARN, role.NewPolicyARN)
+		}
+		for _, policyARN := range role.AddedPolicyARNs {
+			fmt.Fprintf(tw, "attached policy\t%s\t%s\t%s\n", role.RoleName, diffChangeAdded, policyARN)
+		}
+		for _, policyARN := range role.RemovedPolicyARNs {
+			fmt.Fprintf(tw, "attached policy\t%s\t%s\t%s\n", role.RoleName, diffChangeRemoved, policyARN)
+		}
+	}
+	for _, secret := range diff.Secrets {
+		switch {
+		case secret.Change == diffChangeAdded:
+			fmt.Fprintf(tw, "secret\t%s\t%s\t%s\n", secret.RegistryName, secret.Change, secret.NewSecretARN)
+		case secret.Change == diffChangeRemoved:
+			fmt.Fprintf(tw, "secret\t%s\t%s\t%s\n", secret.RegistryName, secret.Change, secret.OldSecretARN)
+		case secret.OldSecretARN != secret.NewSecretARN:
+			fmt.Fprintf(tw, "secret\t%s\t%s\t%s -> %s\n", secret.RegistryName, diffChangeChanged, secret.OldSecretARN, secret.NewSecretARN)
+		}
+		if secret.OldKMSKeyID != "" {
+			fmt.Fprintf(tw, "kms key\t%s\t%s\t%s -> %s\n", secret.RegistryName, diffChangeChanged, secret.OldKMSKeyID, secret.NewKMSKeyID)
+		}
+		if secret.Change != diffChangeChanged {
+			// containers of added and removed secrets are not listed separately
+			continue
+		}
+		for _, container := range secret.AddedContainers {
+			fmt.Fprintf(tw, "container\t%s\t%s\t%s\n", secret.RegistryName, diffChangeAdded, container)
+		}
+		for _, container := range secret.RemovedContainers {
+			fmt.Fprintf(tw, "container\t%s\t%s\t%s\n", secret.RegistryName, diffChangeRemoved, container)
+		}
+	}
+	return tw.Flush()
+}
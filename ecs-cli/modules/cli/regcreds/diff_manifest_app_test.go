@@ -0,0 +1,167 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testDiffOldPolicyARN = "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myRole-policy-20190601T000000Z"
+	testDiffNewPolicyARN = "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myRole-policy-20190701T000000Z"
+	testDiffSecretARN    = "arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-a.example.com-AbCdEf"
+)
+
+func TestDiffManifestFiles_Manifests(t *testing.T) {
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	store := regcredio.NewMemoryStore(nil)
+	err := regcredio.WriteManifestTo(store, regcredio.ECSRegCredsManifest{
+		Region: "us-west-2",
+		Roles: []regcredio.ManifestRole{
+			{RoleName: "myRole", PolicyARN: testDiffOldPolicyARN, AttachedPolicyARNs: []string{managedPolicyARN, testDiffOldPolicyARN}},
+			{RoleName: "oldRole", PolicyARN: testDiffOldPolicyARN, AttachedPolicyARNs: []string{testDiffOldPolicyARN}},
+			{RoleName: "sameRole", PolicyARN: testDiffOldPolicyARN},
+		},
+		Secrets: []regcredio.ManifestSecret{{RegistryName: "a.example.com", SecretARN: testDiffSecretARN}},
+	}, "old.json")
+	assert.NoError(t, err, "Unexpected error writing old manifest")
+	err = regcredio.WriteManifestTo(store, regcredio.ECSRegCredsManifest{
+		Region: "us-west-2",
+		Roles: []regcredio.ManifestRole{
+			{RoleName: "myRole", PolicyARN: testDiffNewPolicyARN, AttachedPolicyARNs: []string{managedPolicyARN, testDiffNewPolicyARN}},
+			{RoleName: "newRole", PolicyARN: testDiffNewPolicyARN, AttachedPolicyARNs: []string{testDiffNewPolicyARN}},
+			{RoleName: "sameRole", PolicyARN: testDiffOldPolicyARN},
+		},
+	}, "new.json.gz")
+	assert.NoError(t, err, "Unexpected error writing new manifest")
+
+	diff, err := diffManifestFiles(store, "old.json", "new.json.gz")
+	assert.NoError(t, err, "Unexpected error comparing manifests")
+	assert.Equal(t, []roleDiff{
+		{
+			RoleName:          "myRole",
+			Change:            diffChangeChanged,
+			OldPolicyARN:      testDiffOldPolicyARN,
+			NewPolicyARN:      testDiffNewPolicyARN,
+			AddedPolicyARNs:   []string{testDiffNewPolicyARN},
+			RemovedPolicyARNs: []string{testDiffOldPolicyARN},
+		},
+		{RoleName: "newRole", Change: diffChangeAdded, NewPolicyARN: testDiffNewPolicyARN, AddedPolicyARNs: []string{testDiffNewPolicyARN}},
+		{RoleName: "oldRole", Change: diffChangeRemoved, OldPolicyARN: testDiffOldPolicyARN, RemovedPolicyARNs: []string{testDiffOldPolicyARN}},
+	}, diff.Roles, "Expected roles in order of name, without unchanged roles")
+	assert.Equal(t, []secretDiff{{RegistryName: "a.example.com", Change: diffChangeRemoved, OldSecretARN: testDiffSecretARN}}, diff.Secrets)
+}
+
+func TestDiffManifestFiles_CredsOutput(t *testing.T) {
+	store := regcredio.NewMemoryStore(map[string][]byte{
+		"old.yml": []byte(`version: "1"
+registry_credential_outputs:
+  task_execution_role: myRole
+  task_execution_roles:
+  - role_name: myRole
+    policy_arn: ` + testDiffOldPolicyARN + `
+    managed_policy_arn: arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy
+  container_credentials:
+    a.example.com:
+      credentials_parameter: ` + testDiffSecretARN + `
+      container_names:
+      - web
+      - worker
+`),
+		"new.yml": []byte(`version: "1"
+registry_credential_outputs:
+  task_execution_role: myRole
+  task_execution_roles:
+  - role_name: myRole
+    policy_arn: ` + testDiffOldPolicyARN + `
+    managed_policy_arn: arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy
+  container_credentials:
+    a.example.com:
+      credentials_parameter: ` + testDiffSecretARN + `
+      kms_key_id: arn:aws:kms:us-west-2:111111111111:key/1234
+      container_names:
+      - web
+      - api
+    b.example.com:
+      credentials_parameter: arn:aws:secretsmanager:us-west-2:111111111111:secret:b
+      container_names:
+      - web
+`),
+	})
+
+	diff, err := diffManifestFiles(store, "old.yml", "new.yml")
+	assert.NoError(t, err, "Unexpected error comparing output files")
+	assert.Empty(t, diff.Roles, "Expected no role changes")
+	assert.Equal(t, []secretDiff{
+		{
+			RegistryName:      "a.example.com",
+			Change:            diffChangeChanged,
+			OldSecretARN:      testDiffSecretARN,
+			NewSecretARN:      testDiffSecretARN,
+			OldKMSKeyID:       diffDefaultKMSKey,
+			NewKMSKeyID:       "arn:aws:kms:us-west-2:111111111111:key/1234",
+			AddedContainers:   []string{"api"},
+			RemovedContainers: []string{"worker"},
+		},
+		{RegistryName: "b.example.com", Change: diffChangeAdded, NewSecretARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:b", AddedContainers: []string{"web"}},
+	}, diff.Secrets)
+}
+
+func TestDiffManifestFiles_ErrorOnDifferentKinds(t *testing.T) {
+	store := regcredio.NewMemoryStore(map[string][]byte{
+		"manifest.json": []byte(`{"version":"2","region":"us-west-2","roles":[],"secrets":[]}`),
+		"output.yml":    []byte("version: \"1\"\nregistry_credential_outputs:\n  task_execution_role: myRole\n"),
+	})
+
+	_, err := diffManifestFiles(store, "manifest.json", "output.yml")
+	assert.Error(t, err, "Expected error comparing a manifest with an output file")
+
+	_, err = diffManifestFiles(store, "manifest.json", "missing.json")
+	assert.Error(t, err, "Expected error on missing file")
+}
+
+func TestPrintManifestDiff(t *testing.T) {
+	diff := manifestDiff{
+		Roles: []roleDiff{{
+			RoleName:          "myRole",
+			Change:            diffChangeChanged,
+			OldPolicyARN:      testDiffOldPolicyARN,
+			NewPolicyARN:      testDiffNewPolicyARN,
+			AddedPolicyARNs:   []string{testDiffNewPolicyARN},
+			RemovedPolicyARNs: []string{testDiffOldPolicyARN},
+		}},
+		Secrets: []secretDiff{{RegistryName: "a.example.com", Change: diffChangeAdded, NewSecretARN: testDiffSecretARN}},
+	}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, printManifestDiffTable(diff, out), "Unexpected error printing diff table")
+	assert.Contains(t, out.String(), "RESOURCE")
+	assert.Contains(t, out.String(), testDiffOldPolicyARN+" -> "+testDiffNewPolicyARN)
+	assert.Contains(t, out.String(), testDiffSecretARN)
+
+	out.Reset()
+	assert.NoError(t, printManifestDiffJSON(diff, out), "Unexpected error printing diff JSON")
+	printed := manifestDiff{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &printed), "Expected valid JSON")
+	assert.Equal(t, diff, printed)
+
+	out.Reset()
+	assert.NoError(t, printManifestDiffTable(manifestDiff{}, out))
+	assert.Equal(t, "No differences found.\n", out.String())
+}
@@ -0,0 +1,206 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	secretsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/secretsmanager"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Down removes the resources listed in a manifest written by 'registry-creds up'
+func Down(c *cli.Context) {
+	manifestFile := c.String(flags.ManifestFlag)
+	if manifestFile == "" {
+		log.Fatalf("Error executing 'down': no value specified for '--%s'", flags.ManifestFlag)
+	}
+
+	manifest, err := readCommandManifest(c, manifestFile)
+	if err != nil {
+		log.Fatal("Error executing 'down': ", err)
+	}
+
+	// secrets must be deleted in the region they were created in
+	commandConfig := getNewCommandConfig(c, manifest.Region, regionSourceManifest)
+	iamClient := iam.NewIAMClient(commandConfig)
+	smClient := secretsClient.NewSecretsManagerClient(commandConfig)
+
+	if err = removeManifestResources(*manifest, iamClient, smClient); err != nil {
+		log.Fatal("Error executing 'down': ", err)
+	}
+}
+
+// readCommandManifest reads the manifest, checking its signature first if a signing key is given
+func readCommandManifest(c *cli.Context, manifestFile string) (*regcredio.ECSRegCredsManifest, error) {
+	signingKey := c.String(flags.ManifestSigningKeyFlag)
+	if signingKey == "" {
+		if _, err := os.Stat(regcredio.ManifestSignatureFile(manifestFile)); err == nil {
+			log.Warnf("Manifest %s is signed, but its signature is not checked; use '--%s' to refuse a modified manifest", manifestFile, flags.ManifestSigningKeyFlag)
+		}
+		return regcredio.ReadManifest(manifestFile)
+	}
+	// the signature is checked before the manifest is trusted for anything, including its region
+	client := signingKeyClient(c, signingKey, kms.NewKMSClient(getNewCommandConfig(c, "", "")))
+	return readVerifiedManifest(regcredio.FileStore{}, manifestFile, signingKey, client)
+}
+
+// buildManifest lists the resources created by 'up'. The AWS managed policy is only listed as attached if the role was
+// created, since an existing role may already have had it attached before the run. A policy which was refreshed rather
+// than created is not listed, so that 'down' doesn't delete it. A refreshed or reused policy is only listed as attached
+// to the roles it wasn't already attached to. Roles which were not changed by the run and have no policy to clean up are left out.
+func buildManifest(roleResults []*ExecutionRoleResult, createdSecrets []regcredio.ManifestSecret, region string, createTime time.Time) regcredio.ECSRegCredsManifest {
+	manifest := regcredio.ECSRegCredsManifest{
+		CreatedAt: createTime,
+		Region:    region,
+		Secrets:   createdSecrets,
+	}
+
+	for _, roleResult := range roleResults {
+		policyARN := roleResult.PolicyARN
+		if roleResult.PolicyRefreshed {
+			policyARN = ""
+		}
+		attachedPolicies := []string{}
+		for _, attachedARN := range roleResult.AttachedPolicyARNs {
+			if roleResult.RoleCreated || (attachedARN == roleResult.PolicyARN && !roleResult.PolicyAttachedBefore) {
+				attachedPolicies = append(attachedPolicies, attachedARN)
+			}
+		}
+		if !roleResult.RoleCreated && !roleResult.InstanceProfileCreated && !roleResult.RoleAddedToInstanceProfile && len(attachedPolicies) == 0 && policyARN == "" {
+			continue
+		}
+		role := regcredio.ManifestRole{
+			RoleName:           roleResult.RoleName,
+			RoleARN:            roleResult.RoleARN,
+			Created:            roleResult.RoleCreated,
+			PolicyARN:          policyARN,
+			AttachedPolicyARNs: attachedPolicies,
+			Tags:               tagsMap(roleResult.Tags),
+		}
+		if policyARN != "" && len(roleResult.PolicyStatements) > 0 {
+			role.PolicyDocumentSHA256 = statementsDigest(roleResult.PolicyStatements)
+		}
+		if roleResult.InstanceProfileCreated || roleResult.RoleAddedToInstanceProfile {
+			role.InstanceProfile = &regcredio.ManifestInstanceProfile{
+				Name:      roleResult.RoleName,
+				ARN:       roleResult.InstanceProfileARN,
+				Created:   roleResult.InstanceProfileCreated,
+				RoleAdded: roleResult.RoleAddedToInstanceProfile,
+			}
+		}
+		manifest.Roles = append(manifest.Roles, role)
+	}
+
+	return manifest
+}
+
+// statementsDigest returns the digest of the policy document with the statements, or an empty string if the
+// statements can't be marshalled, in which case 'check' only checks that the policy exists
+func statementsDigest(statements []StatementEntry) string {
+	document, err := marshalPolicyDocument(statements)
+	if err != nil {
+		return ""
+	}
+	digest, err := policyDocumentDigest(document)
+	if err != nil {
+		return ""
+	}
+	return digest
+}
+
+// removeManifestResources reverses the changes listed in the manifest. Resources which no longer exist are skipped.
+// Since roles can share a policy, the policy is only deleted once it has been detached from every role. A policy named
+// after its document may also be shared with the roles of other runs, in which case it is kept.
+func removeManifestResources(manifest regcredio.ECSRegCredsManifest, iamClient iam.Client, smClient secretsClient.SMClient) error {
+	for _, role := range manifest.Roles {
+		for _, policyARN := range role.AttachedPolicyARNs {
+			if err := iamClient.DetachRolePolicy(policyARN, role.RoleName); err != nil && !utils.EntityNotFound(err) {
+				return errors.Wrapf(err, "failed to detach policy %s from role %s", policyARN, role.RoleName)
+			}
+			log.Infof("Detached policy %s from role %s", policyARN, role.RoleName)
+		}
+	}
+
+	deletedPolicies := make(map[string]bool)
+	for _, role := range manifest.Roles {
+		if role.PolicyARN == "" || deletedPolicies[role.PolicyARN] {
+			continue
+		}
+		if err := iamClient.DeletePolicy(role.PolicyARN); err != nil && !utils.EntityNotFound(err) {
+			if isSharedContentPolicy(role.PolicyARN, err) {
+				log.Infof("Keeping policy %s which is attached to other entities", role.PolicyARN)
+				deletedPolicies[role.PolicyARN] = true
+				continue
+			}
+			return errors.Wrapf(err, "failed to delete policy %s", role.PolicyARN)
+		}
+		deletedPolicies[role.PolicyARN] = true
+		log.Infof("Deleted policy %s", role.PolicyARN)
+	}
+
+	for _, role := range manifest.Roles {
+		if profile := role.InstanceProfile; profile != nil {
+			if err := removeManifestInstanceProfile(*profile, role.RoleName, iamClient); err != nil {
+				return err
+			}
+		}
+
+		if role.Created {
+			if err := iamClient.DeleteRole(role.RoleName); err != nil && !utils.EntityNotFound(err) {
+				return errors.Wrapf(err, "failed to delete role %s", role.RoleName)
+			}
+			log.Infof("Deleted role %s", role.RoleName)
+		} else {
+			log.Infof("Keeping role %s, which existed before it was used by 'up'", role.RoleName)
+		}
+	}
+
+	for _, secret := range manifest.Secrets {
+		if err := smClient.DeleteSecret(secret.SecretARN); err != nil && !utils.EntityNotFound(err) {
+			return fmt.Errorf("failed to delete secret %s for registry %s: %v", secret.SecretARN, secret.RegistryName, err)
+		}
+		log.Infof("Scheduled secret %s for registry %s for deletion", secret.SecretARN, secret.RegistryName)
+	}
+
+	return nil
+}
+
+// a role can only be deleted once it has been removed from all instance profiles
+func removeManifestInstanceProfile(profile regcredio.ManifestInstanceProfile, roleName string, iamClient iam.Client) error {
+	if profile.RoleAdded {
+		if err := iamClient.RemoveRoleFromInstanceProfile(profile.Name, roleName); err != nil && !utils.EntityNotFound(err) {
+			return errors.Wrapf(err, "failed to remove role %s from instance profile %s", roleName, profile.Name)
+		}
+		log.Infof("Removed role %s from instance profile %s", roleName, profile.Name)
+	}
+
+	if profile.Created {
+		if err := iamClient.DeleteInstanceProfile(profile.Name); err != nil && !utils.EntityNotFound(err) {
+			return errors.Wrapf(err, "failed to delete instance profile %s", profile.Name)
+		}
+		log.Infof("Deleted instance profile %s", profile.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,323 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testManifestRoleName  = "myTaskExecutionRole"
+	testManifestRoleARN   = "arn:aws:iam::111111111111:role/myTaskExecutionRole"
+	testManifestPolicyARN = "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myTaskExecutionRole-policy"
+	testManifestSecretARN = "arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-myreg.test.io"
+)
+
+func TestBuildManifest_NewRole(t *testing.T) {
+	roleResult := &ExecutionRoleResult{
+		RoleName:           testManifestRoleName,
+		RoleCreated:        true,
+		RoleARN:            testManifestRoleARN,
+		PolicyARN:          testManifestPolicyARN,
+		AttachedPolicyARNs: []string{getExecutionRolePolicyARN("us-west-2"), testManifestPolicyARN},
+	}
+	createdSecrets := []regcredio.ManifestSecret{{RegistryName: "myreg.test.io", SecretARN: testManifestSecretARN}}
+	createTime := time.Now().UTC()
+
+	manifest := buildManifest([]*ExecutionRoleResult{roleResult}, createdSecrets, "us-west-2", createTime)
+	assert.Equal(t, "us-west-2", manifest.Region)
+	assert.Equal(t, createTime, manifest.CreatedAt)
+	assert.Equal(t, createdSecrets, manifest.Secrets)
+	assert.Equal(t, []regcredio.ManifestRole{{
+		RoleName:           testManifestRoleName,
+		RoleARN:            testManifestRoleARN,
+		Created:            true,
+		PolicyARN:          testManifestPolicyARN,
+		AttachedPolicyARNs: []string{getExecutionRolePolicyARN("us-west-2"), testManifestPolicyARN},
+	}}, manifest.Roles)
+}
+
+func TestBuildManifest_ExistingRole(t *testing.T) {
+	roleResult := &ExecutionRoleResult{
+		RoleName:           testManifestRoleName,
+		PolicyARN:          testManifestPolicyARN,
+		AttachedPolicyARNs: []string{getExecutionRolePolicyARN("us-west-2"), testManifestPolicyARN},
+	}
+
+	manifest := buildManifest([]*ExecutionRoleResult{roleResult}, nil, "us-west-2", time.Now().UTC())
+	assert.False(t, manifest.Roles[0].Created, "Expected existing role not to be reported as created")
+	assert.Equal(t, []string{testManifestPolicyARN}, manifest.Roles[0].AttachedPolicyARNs, "Expected only the generated policy to be listed as attached to an existing role")
+}
+
+func TestBuildManifest_RefreshedPolicy(t *testing.T) {
+	roleResults := []*ExecutionRoleResult{
+		{
+			RoleName:             testManifestRoleName,
+			PolicyARN:            testManifestPolicyARN,
+			PolicyStatements:     []StatementEntry{{Effect: "Allow", Action: []string{"secretsmanager:GetSecretValue"}, Resource: []string{testManifestSecretARN}}},
+			PolicyRefreshed:      true,
+			PolicyAttachedBefore: true,
+			AttachedPolicyARNs:   []string{getExecutionRolePolicyARN("us-west-2"), testManifestPolicyARN},
+		},
+		// the refreshed policy is newly attached to this role
+		{
+			RoleName:           "myOtherRole",
+			PolicyARN:          testManifestPolicyARN,
+			PolicyRefreshed:    true,
+			AttachedPolicyARNs: []string{getExecutionRolePolicyARN("us-west-2"), testManifestPolicyARN},
+		},
+	}
+
+	manifest := buildManifest(roleResults, nil, "us-west-2", time.Now().UTC())
+	assert.Equal(t, []regcredio.ManifestRole{{
+		RoleName:           "myOtherRole",
+		AttachedPolicyARNs: []string{testManifestPolicyARN},
+	}}, manifest.Roles, "Expected the refreshed policy not to be deleted, and only its new attachment to be listed")
+
+	// 'down' only detaches the new attachment, and doesn't delete the policy
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().DetachRolePolicy(testManifestPolicyARN, "myOtherRole").Return(nil)
+	mocks.MockIAM.EXPECT().DetachRolePolicy(gomock.Any(), testManifestRoleName).Times(0)
+	mocks.MockIAM.EXPECT().DeletePolicy(gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().DeleteRole(gomock.Any()).Times(0)
+
+	err := removeManifestResources(manifest, mocks.MockIAM, mocks.MockSM)
+	assert.NoError(t, err, "Unexpected error removing manifest resources")
+}
+
+func TestBuildManifest_TagsAndPolicyDigest(t *testing.T) {
+	statements := []StatementEntry{{Effect: "Allow", Action: []string{"secretsmanager:GetSecretValue"}, Resource: []string{testManifestSecretARN}}}
+	roleResult := &ExecutionRoleResult{
+		RoleName:           testManifestRoleName,
+		RoleCreated:        true,
+		Tags:               convertToIAMTags(addManagementTag(map[string]*string{"Team": aws.String("payments")}, DefaultManagementTagKey, DefaultManagementTagValue)),
+		PolicyARN:          testManifestPolicyARN,
+		PolicyStatements:   statements,
+		AttachedPolicyARNs: []string{testManifestPolicyARN},
+	}
+
+	manifest := buildManifest([]*ExecutionRoleResult{roleResult}, nil, "us-west-2", time.Now().UTC())
+	assert.Equal(t, map[string]string{"Team": "payments", DefaultManagementTagKey: DefaultManagementTagValue}, manifest.Roles[0].Tags)
+
+	document, err := marshalPolicyDocument(statements)
+	assert.NoError(t, err)
+	expectedDigest, err := policyDocumentDigest(document)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedDigest, manifest.Roles[0].PolicyDocumentSHA256)
+}
+
+func TestBuildManifest_InstanceProfile(t *testing.T) {
+	testProfileARN := "arn:aws:iam::111111111111:instance-profile/myTaskExecutionRole"
+	roleResult := &ExecutionRoleResult{
+		RoleName:                   testManifestRoleName,
+		RoleCreated:                true,
+		PolicyARN:                  testManifestPolicyARN,
+		InstanceProfileARN:         testProfileARN,
+		InstanceProfileCreated:     true,
+		RoleAddedToInstanceProfile: true,
+	}
+
+	manifest := buildManifest([]*ExecutionRoleResult{roleResult}, nil, "us-west-2", time.Now().UTC())
+	assert.Equal(t, &regcredio.ManifestInstanceProfile{
+		Name:      testManifestRoleName,
+		ARN:       testProfileARN,
+		Created:   true,
+		RoleAdded: true,
+	}, manifest.Roles[0].InstanceProfile)
+
+	// an existing instance profile which already contained the role was not changed
+	roleResult.InstanceProfileCreated = false
+	roleResult.RoleAddedToInstanceProfile = false
+	manifest = buildManifest([]*ExecutionRoleResult{roleResult}, nil, "us-west-2", time.Now().UTC())
+	assert.Nil(t, manifest.Roles[0].InstanceProfile)
+}
+
+func TestBuildManifest_NoRole(t *testing.T) {
+	manifest := buildManifest(nil, nil, "us-west-2", time.Now().UTC())
+	assert.Empty(t, manifest.Roles)
+}
+
+func TestBuildManifest_MultipleRoles(t *testing.T) {
+	roleResults := []*ExecutionRoleResult{
+		{
+			RoleName:           testManifestRoleName,
+			RoleCreated:        true,
+			PolicyARN:          testManifestPolicyARN,
+			AttachedPolicyARNs: []string{getExecutionRolePolicyARN("us-west-2"), testManifestPolicyARN},
+		},
+		{
+			RoleName:           "myOtherRole",
+			PolicyARN:          testManifestPolicyARN,
+			AttachedPolicyARNs: []string{testManifestPolicyARN},
+		},
+		// a role which failed before anything was changed is left out
+		{
+			RoleName: "myFailedRole",
+			Err:      errors.New("something went wrong"),
+		},
+	}
+
+	manifest := buildManifest(roleResults, nil, "us-west-2", time.Now().UTC())
+	assert.Equal(t, 2, len(manifest.Roles))
+	assert.Equal(t, testManifestRoleName, manifest.Roles[0].RoleName)
+	assert.Equal(t, "myOtherRole", manifest.Roles[1].RoleName)
+	assert.Equal(t, testManifestPolicyARN, manifest.Roles[1].PolicyARN)
+}
+
+func TestRemoveManifestResources_NewRole(t *testing.T) {
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	manifest := regcredio.ECSRegCredsManifest{
+		Roles: []regcredio.ManifestRole{{
+			RoleName:           testManifestRoleName,
+			Created:            true,
+			PolicyARN:          testManifestPolicyARN,
+			AttachedPolicyARNs: []string{managedPolicyARN, testManifestPolicyARN},
+		}},
+		Secrets: []regcredio.ManifestSecret{{RegistryName: "myreg.test.io", SecretARN: testManifestSecretARN}},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().DetachRolePolicy(managedPolicyARN, testManifestRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().DetachRolePolicy(testManifestPolicyARN, testManifestRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().DeletePolicy(testManifestPolicyARN).Return(nil),
+		mocks.MockIAM.EXPECT().DeleteRole(testManifestRoleName).Return(nil),
+		mocks.MockSM.EXPECT().DeleteSecret(testManifestSecretARN).Return(nil),
+	)
+
+	err := removeManifestResources(manifest, mocks.MockIAM, mocks.MockSM)
+	assert.NoError(t, err, "Unexpected error removing manifest resources")
+}
+
+func TestRemoveManifestResources_ExistingRole(t *testing.T) {
+	manifest := regcredio.ECSRegCredsManifest{
+		Roles: []regcredio.ManifestRole{{
+			RoleName:           testManifestRoleName,
+			PolicyARN:          testManifestPolicyARN,
+			AttachedPolicyARNs: []string{testManifestPolicyARN},
+		}},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		// already detached resources are skipped
+		mocks.MockIAM.EXPECT().DetachRolePolicy(testManifestPolicyARN, testManifestRoleName).Return(awserr.New("NoSuchEntity", "not attached", nil)),
+		mocks.MockIAM.EXPECT().DeletePolicy(testManifestPolicyARN).Return(nil),
+	)
+	mocks.MockIAM.EXPECT().DeleteRole(gomock.Any()).Times(0)
+
+	err := removeManifestResources(manifest, mocks.MockIAM, mocks.MockSM)
+	assert.NoError(t, err, "Unexpected error removing manifest resources")
+}
+
+func TestRemoveManifestResources_InstanceProfile(t *testing.T) {
+	manifest := regcredio.ECSRegCredsManifest{
+		Roles: []regcredio.ManifestRole{{
+			RoleName:  testManifestRoleName,
+			Created:   true,
+			PolicyARN: testManifestPolicyARN,
+			InstanceProfile: &regcredio.ManifestInstanceProfile{
+				Name:      testManifestRoleName,
+				Created:   true,
+				RoleAdded: true,
+			},
+		}},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().DeletePolicy(testManifestPolicyARN).Return(nil),
+		mocks.MockIAM.EXPECT().RemoveRoleFromInstanceProfile(testManifestRoleName, testManifestRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().DeleteInstanceProfile(testManifestRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().DeleteRole(testManifestRoleName).Return(nil),
+	)
+
+	err := removeManifestResources(manifest, mocks.MockIAM, mocks.MockSM)
+	assert.NoError(t, err, "Unexpected error removing manifest resources")
+}
+
+func TestRemoveManifestResources_ExistingInstanceProfile(t *testing.T) {
+	manifest := regcredio.ECSRegCredsManifest{
+		Roles: []regcredio.ManifestRole{{
+			RoleName: testManifestRoleName,
+			InstanceProfile: &regcredio.ManifestInstanceProfile{
+				Name:      testManifestRoleName,
+				RoleAdded: true,
+			},
+		}},
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().RemoveRoleFromInstanceProfile(testManifestRoleName, testManifestRoleName).Return(nil)
+	mocks.MockIAM.EXPECT().DeleteInstanceProfile(gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().DeleteRole(gomock.Any()).Times(0)
+
+	err := removeManifestResources(manifest, mocks.MockIAM, mocks.MockSM)
+	assert.NoError(t, err, "Unexpected error removing manifest resources")
+}
+
+func TestRemoveManifestResources_ErrorOnDeletePolicy(t *testing.T) {
+	manifest := regcredio.ECSRegCredsManifest{
+		Roles: []regcredio.ManifestRole{{
+			RoleName:  testManifestRoleName,
+			Created:   true,
+			PolicyARN: testManifestPolicyARN,
+		}},
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().DeletePolicy(testManifestPolicyARN).Return(errors.New("something went wrong"))
+	mocks.MockIAM.EXPECT().DeleteRole(gomock.Any()).Times(0)
+
+	err := removeManifestResources(manifest, mocks.MockIAM, mocks.MockSM)
+	assert.Error(t, err, "Expected error when deleting policy fails")
+}
+
+func TestRemoveManifestResources_SharedPolicy(t *testing.T) {
+	manifest := regcredio.ECSRegCredsManifest{
+		Roles: []regcredio.ManifestRole{
+			{
+				RoleName:           testManifestRoleName,
+				Created:            true,
+				PolicyARN:          testManifestPolicyARN,
+				AttachedPolicyARNs: []string{testManifestPolicyARN},
+			},
+			{
+				RoleName:           "myOtherRole",
+				PolicyARN:          testManifestPolicyARN,
+				AttachedPolicyARNs: []string{testManifestPolicyARN},
+			},
+		},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().DetachRolePolicy(testManifestPolicyARN, testManifestRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().DetachRolePolicy(testManifestPolicyARN, "myOtherRole").Return(nil),
+		// the policy shared by both roles is only deleted once
+		mocks.MockIAM.EXPECT().DeletePolicy(testManifestPolicyARN).Return(nil),
+		mocks.MockIAM.EXPECT().DeleteRole(testManifestRoleName).Return(nil),
+	)
+
+	err := removeManifestResources(manifest, mocks.MockIAM, mocks.MockSM)
+	assert.NoError(t, err, "Unexpected error removing manifest resources")
+}
@@ -0,0 +1,64 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+)
+
+// duplicateSecretError reports two registries which use the same secret with settings that grant different access,
+// so that they can't be merged into the same policy statements
+func duplicateSecretError(firstRegistry, secondRegistry, secretARN, field string) error {
+	return fmt.Errorf("registries %s and %s use the same secret %s with a different '%s'; give both entries the same settings, or use a separate secret for each", firstRegistry, secondRegistry, secretARN, field)
+}
+
+// inputEntryConflict returns the first field of two input entries using the same secret which differs in a way that
+// changes the access granted, or "" if the entries can be merged. Key IDs and aliases can only be compared once they
+// have been resolved to an ARN, which generateSecretsStatements checks again.
+func inputEntryConflict(first, second regcredio.RegistryCredEntry) string {
+	if first.KmsKeyID != second.KmsKeyID {
+		if first.KmsKeyID == "" || second.KmsKeyID == "" || (isARN(first.KmsKeyID) && isARN(second.KmsKeyID)) {
+			return "kms_key_id"
+		}
+	}
+	if !reflect.DeepEqual(first.Actions, second.Actions) {
+		return "actions"
+	}
+	if first.RotationCompatible != second.RotationCompatible {
+		return "rotation_compatible"
+	}
+	if !reflect.DeepEqual(first.SecretScope, second.SecretScope) {
+		return "secret_scope"
+	}
+	return ""
+}
+
+// grantConflict returns the first field of two grants of the same secret which differs, or "" if the second grant
+// is a duplicate of the first and can be left out of the policy
+func grantConflict(first, second secretGrant) string {
+	switch {
+	case first.keyARN != second.keyARN:
+		return "kms_key_id"
+	case !reflect.DeepEqual(first.secretActions, second.secretActions):
+		return "actions"
+	case !reflect.DeepEqual(first.rotationActions, second.rotationActions):
+		return "rotation_compatible"
+	case first.secretResource != second.secretResource || !reflect.DeepEqual(first.scopeConditions, second.scopeConditions):
+		return "secret_scope"
+	}
+	return ""
+}
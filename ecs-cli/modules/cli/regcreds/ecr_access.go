@@ -0,0 +1,85 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+)
+
+const (
+	ecrRepositoryResourcePrefix = "repository/"
+
+	ecrAuthorizationSid = "ECRAuthorization"
+	ecrPullSid          = "ECRPull"
+)
+
+// ecrGetAuthorizationTokenAction can't be scoped to a repository, so it is always granted on all resources
+const ecrGetAuthorizationTokenAction = "ecr:GetAuthorizationToken"
+
+// ecrPullActions are the actions needed to pull an image from a repository
+var ecrPullActions = []string{
+	"ecr:BatchCheckLayerAvailability",
+	"ecr:GetDownloadUrlForLayer",
+	"ecr:BatchGetImage",
+}
+
+// validateECRRepositories checks that each repository is given as an ECR repository ARN, e.g.
+// arn:aws:ecr:us-west-2:111111111111:repository/my-app. The repository name may contain wildcards.
+func validateECRRepositories(repositories []string) error {
+	for _, repository := range repositories {
+		repositoryARN, err := arn.Parse(repository)
+		if err != nil {
+			return fmt.Errorf("invalid ECR repository ARN '%s' in 'ecr_repositories': %v", repository, err)
+		}
+		if repositoryARN.Service != "ecr" || repositoryARN.Region == "" || repositoryARN.AccountID == "" ||
+			!strings.HasPrefix(repositoryARN.Resource, ecrRepositoryResourcePrefix) || repositoryARN.Resource == ecrRepositoryResourcePrefix {
+			return fmt.Errorf("invalid ECR repository ARN '%s' in 'ecr_repositories'; expected the format arn:<partition>:ecr:<region>:<account>:repository/<name>", repository)
+		}
+	}
+	return nil
+}
+
+// ecrPullRepositories returns the repositories pull access is granted to, which are all repositories if none are given
+func ecrPullRepositories(repositories []string) []string {
+	if len(repositories) == 0 {
+		return []string{"*"}
+	}
+	return repositories
+}
+
+// appendECRPullStatements adds the statements granting pull access to the given ECR repositories (or all
+// repositories, if none are given) to the policy statements
+func appendECRPullStatements(statements []StatementEntry, repositories []string) []StatementEntry {
+	usedSids := make(map[string]bool, len(statements))
+	for _, statement := range statements {
+		usedSids[statement.Sid] = true
+	}
+	return append(statements,
+		StatementEntry{
+			Sid:      uniqueSid(ecrAuthorizationSid, usedSids),
+			Effect:   "Allow",
+			Action:   []string{ecrGetAuthorizationTokenAction},
+			Resource: []string{"*"},
+		},
+		StatementEntry{
+			Sid:      uniqueSid(ecrPullSid, usedSids),
+			Effect:   "Allow",
+			Action:   ecrPullActions,
+			Resource: ecrPullRepositories(repositories),
+		},
+	)
+}
@@ -0,0 +1,60 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateECRRepositories(t *testing.T) {
+	assert.NoError(t, validateECRRepositories(nil))
+	assert.NoError(t, validateECRRepositories([]string{
+		"arn:aws:ecr:us-west-2:111111111111:repository/nginx",
+		"arn:aws:ecr:us-west-2:111111111111:repository/team-a/*",
+	}))
+}
+
+func TestValidateECRRepositories_Errors(t *testing.T) {
+	for _, repository := range []string{
+		"nginx",
+		"arn:aws:s3:::my-bucket",
+		"arn:aws:ecr:us-west-2:111111111111:nginx",
+		"arn:aws:ecr:us-west-2:111111111111:repository/",
+		"arn:aws:ecr::111111111111:repository/nginx",
+	} {
+		assert.Error(t, validateECRRepositories([]string{repository}), "Expected error for repository %s", repository)
+	}
+}
+
+func TestAppendECRPullStatements_AllRepositories(t *testing.T) {
+	statements := appendECRPullStatements(nil, nil)
+	assert.Len(t, statements, 2)
+	assert.Equal(t, []string{ecrGetAuthorizationTokenAction}, statements[0].Action)
+	assert.Equal(t, []string{"*"}, statements[0].Resource)
+	assert.Equal(t, ecrPullActions, statements[1].Action)
+	assert.Equal(t, []string{"*"}, statements[1].Resource)
+}
+
+func TestAppendECRPullStatements_UniqueSids(t *testing.T) {
+	existing := []StatementEntry{{Sid: ecrPullSid, Effect: "Allow", Action: []string{secretsGetValueAction}, Resource: []string{"*"}}}
+	repositories := []string{"arn:aws:ecr:us-west-2:111111111111:repository/nginx"}
+
+	statements := appendECRPullStatements(existing, repositories)
+	assert.Len(t, statements, 3)
+	assert.Equal(t, ecrAuthorizationSid, statements[1].Sid)
+	assert.Equal(t, ecrPullSid+"2", statements[2].Sid)
+	assert.Equal(t, repositories, statements[2].Resource)
+}
@@ -0,0 +1,126 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const insecureTLSHandshakeTimeout = 10 * time.Second
+
+// applyEndpointMap sends the requests of the clients created from the command's session to the endpoints given in
+// the --endpoint-map file. Services which aren't in the map use their usual endpoints.
+func applyEndpointMap(c *cli.Context, commandConfig *config.CommandConfig) error {
+	filename := c.String(flags.EndpointMapFlag)
+	if filename == "" {
+		return nil
+	}
+	endpointMap, err := regcredio.ReadEndpointMapFrom(regcredio.FileStore{}, filename)
+	if err != nil {
+		return err
+	}
+
+	sessionConfig := &aws.Config{EndpointResolver: endpointMapResolver(endpointMap.Endpoints, commandConfig.Session.Config.EndpointResolver)}
+	if httpClient := endpointMapHTTPClient(endpointMap.Endpoints, commandConfig.Session.Config.HTTPClient); httpClient != nil {
+		sessionConfig.HTTPClient = httpClient
+	}
+	for service, endpoint := range endpointMap.Endpoints {
+		log.Debugf("Using endpoint %s for service %s", endpoint.URL, service)
+	}
+	commandConfig.Session = commandConfig.Session.Copy(sessionConfig)
+	return nil
+}
+
+// endpointMapResolver returns the endpoint given in the map for its services, with the signing details of the
+// service's usual endpoint in the region, so that requests are signed as they would be without the map
+func endpointMapResolver(endpointMap map[string]regcredio.EndpointEntry, defaultResolver endpoints.Resolver) endpoints.Resolver {
+	if defaultResolver == nil {
+		defaultResolver = endpoints.DefaultResolver()
+	}
+	return endpoints.ResolverFunc(func(service, region string, opts ...func(*endpoints.Options)) (endpoints.ResolvedEndpoint, error) {
+		endpoint, ok := endpointMap[service]
+		if !ok {
+			return defaultResolver.EndpointFor(service, region, opts...)
+		}
+		resolved, err := defaultResolver.EndpointFor(service, region, opts...)
+		if err != nil {
+			// the region may only be known to the mirror
+			resolved = endpoints.ResolvedEndpoint{SigningRegion: region}
+		}
+		resolved.URL = endpoint.URL
+		return resolved, nil
+	})
+}
+
+// endpointMapHTTPClient returns a client which skips verification of TLS certificates for the hosts of the endpoints
+// which disable it, or nil if none do
+func endpointMapHTTPClient(endpointMap map[string]regcredio.EndpointEntry, baseClient *http.Client) *http.Client {
+	insecureHosts := make(map[string]bool)
+	for service, endpoint := range endpointMap {
+		if !endpoint.InsecureSkipVerify {
+			continue
+		}
+		// URLs were validated when the map was read
+		endpointURL, _ := url.Parse(endpoint.URL)
+		insecureHosts[endpointURL.Host] = true
+		log.Warnf("TLS certificate verification is disabled for the %s endpoint %s", service, endpoint.URL)
+	}
+	if len(insecureHosts) == 0 {
+		return nil
+	}
+
+	client := http.Client{}
+	if baseClient != nil {
+		client = *baseClient
+	}
+	secure := client.Transport
+	if secure == nil {
+		secure = http.DefaultTransport
+	}
+	client.Transport = &endpointTransport{
+		insecureHosts: insecureHosts,
+		secure:        secure,
+		insecure: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSHandshakeTimeout: insecureTLSHandshakeTimeout,
+			TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	return &client
+}
+
+// endpointTransport sends requests to the insecure hosts with the insecure transport, and all others as usual
+type endpointTransport struct {
+	insecureHosts map[string]bool
+	secure        http.RoundTripper
+	insecure      http.RoundTripper
+}
+
+func (t *endpointTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.insecureHosts[req.URL.Host] {
+		return t.insecure.RoundTrip(req)
+	}
+	return t.secure.RoundTrip(req)
+}
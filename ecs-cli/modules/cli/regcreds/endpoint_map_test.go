@@ -0,0 +1,67 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointMapResolver(t *testing.T) {
+	resolver := endpointMapResolver(map[string]regcredio.EndpointEntry{
+		"iam":            {URL: "https://iam.mirror.internal"},
+		"secretsmanager": {URL: "https://secretsmanager.mirror.internal"},
+	}, nil)
+
+	resolved, err := resolver.EndpointFor("iam", "us-west-2")
+	assert.NoError(t, err, "Unexpected error resolving mapped endpoint")
+	assert.Equal(t, "https://iam.mirror.internal", resolved.URL)
+	assert.Equal(t, "us-east-1", resolved.SigningRegion, "Expected the signing region of the usual IAM endpoint")
+
+	resolved, err = resolver.EndpointFor("secretsmanager", "enclave-1")
+	assert.NoError(t, err, "Unexpected error resolving mapped endpoint in an unknown region")
+	assert.Equal(t, "https://secretsmanager.mirror.internal", resolved.URL)
+	assert.Equal(t, "enclave-1", resolved.SigningRegion)
+
+	resolved, err = resolver.EndpointFor("kms", "us-west-2")
+	assert.NoError(t, err, "Unexpected error resolving unmapped endpoint")
+	expected, _ := endpoints.DefaultResolver().EndpointFor("kms", "us-west-2")
+	assert.Equal(t, expected, resolved, "Expected services which aren't mapped to use their usual endpoint")
+}
+
+func TestEndpointMapHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	assert.Nil(t, endpointMapHTTPClient(map[string]regcredio.EndpointEntry{"kms": {URL: server.URL}}, nil), "Expected no client if every endpoint is verified")
+
+	client := endpointMapHTTPClient(map[string]regcredio.EndpointEntry{"kms": {URL: server.URL, InsecureSkipVerify: true}}, nil)
+	if assert.NotNil(t, client) {
+		resp, err := client.Get(server.URL)
+		if assert.NoError(t, err, "Expected the self-signed certificate to be accepted") {
+			resp.Body.Close()
+		}
+	}
+
+	// other hosts are still verified
+	otherServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer otherServer.Close()
+	_, err := client.Get(otherServer.URL)
+	assert.Error(t, err, "Expected the certificate of a host without insecure_skip_verify to be verified")
+}
@@ -0,0 +1,134 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvironmentEnvVar is set to the name of each environment given with '--output-per-env' so that it can be
+// referenced as '${ENV}' in the input file and role names
+const EnvironmentEnvVar = "ENV"
+
+// environment names are used in file names, so path separators and other special characters are not allowed
+var validEnvironmentName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// parseEnvironments returns the comma separated environment names, or nil if none are given
+func parseEnvironments(flagValue string) ([]string, error) {
+	if flagValue == "" {
+		return nil, nil
+	}
+	var environments []string
+	seen := make(map[string]bool)
+	for _, environment := range strings.Split(flagValue, ",") {
+		environment = strings.TrimSpace(environment)
+		if !validEnvironmentName.MatchString(environment) {
+			return nil, fmt.Errorf("invalid environment name '%s' for '--%s'; names may only contain letters, numbers, '.', '_' and '-'", environment, flags.OutputPerEnvFlag)
+		}
+		if seen[environment] {
+			return nil, fmt.Errorf("environment '%s' is given more than once with '--%s'", environment, flags.OutputPerEnvFlag)
+		}
+		seen[environment] = true
+		environments = append(environments, environment)
+	}
+	return environments, nil
+}
+
+// validateEnvironmentOutputFileName checks that a custom output file name template gives each environment its own
+// file, so that the output of one environment does not replace another's
+func validateEnvironmentOutputFileName(fileNameTemplate string, environments []string) error {
+	if fileNameTemplate == "" || len(environments) < 2 {
+		return nil
+	}
+	timestamp := time.Now().UTC()
+	first, err := regcredio.RenderOutputFileName(fileNameTemplate, "", environments[0], true, timestamp)
+	if err != nil {
+		return err
+	}
+	second, err := regcredio.RenderOutputFileName(fileNameTemplate, "", environments[1], true, timestamp)
+	if err != nil {
+		return err
+	}
+	if first == second {
+		return fmt.Errorf("'--%s' must include '{{.Environment}}' when used with '--%s'", flags.OutputFileNameFlag, flags.OutputPerEnvFlag)
+	}
+	return nil
+}
+
+// expandRoleNames expands references to environment variables, e.g. '${ENV}', in role names when an environment is
+// given; otherwise the names are returned as given
+func expandRoleNames(roleNames []string, environment string) ([]string, error) {
+	if environment == "" {
+		return roleNames, nil
+	}
+	expanded := make([]string, len(roleNames))
+	for i, roleName := range roleNames {
+		expandedName, err := regcredio.ExpandEnvVars(roleName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for '--%s'", flags.RoleNameFlag)
+		}
+		expanded[i] = expandedName
+	}
+	return expanded, nil
+}
+
+// expandPolicyName expands references to environment variables in the policy name when an environment is given, as
+// for role names, so that each environment can have its own fixed policy name
+func expandPolicyName(policyName, environment string) (string, error) {
+	if environment == "" {
+		return policyName, nil
+	}
+	expandedName, err := regcredio.ExpandEnvVars(policyName)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid value for '--%s'", flags.PolicyNameFlag)
+	}
+	return expandedName, nil
+}
+
+// environmentManifestFile adds the environment to the manifest file name, e.g. 'manifest.json' becomes
+// 'manifest-staging.json', so that each environment can be removed separately with 'down'
+func environmentManifestFile(manifestFile, environment string) string {
+	if manifestFile == "" || environment == "" {
+		return manifestFile
+	}
+	ext := filepath.Ext(manifestFile)
+	return strings.TrimSuffix(manifestFile, ext) + "-" + environment + ext
+}
+
+// environmentsResult logs the outcome of each environment of a run which continued past failures, and returns an
+// error naming the environments which failed
+func environmentsResult(environments []string, envErrs map[string]error) error {
+	failed := make([]string, 0, len(envErrs))
+	for _, environment := range environments {
+		if err, ok := envErrs[environment]; ok {
+			log.Errorf("Environment %s: failed: %v", environment, err)
+			failed = append(failed, environment)
+		} else {
+			log.Infof("Environment %s: succeeded", environment)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("failed to set up %d of %d environments: %s", len(failed), len(environments), strings.Join(failed, ", "))
+}
@@ -0,0 +1,99 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnvironments(t *testing.T) {
+	environments, err := parseEnvironments("dev, staging,prod")
+	assert.NoError(t, err, "Unexpected error parsing environments")
+	assert.Equal(t, []string{"dev", "staging", "prod"}, environments)
+
+	environments, err = parseEnvironments("")
+	assert.NoError(t, err, "Unexpected error when no environments are given")
+	assert.Nil(t, environments)
+}
+
+func TestParseEnvironments_ErrorCases(t *testing.T) {
+	for _, flagValue := range []string{"dev,,prod", "dev,dev", "../prod", "dev prod"} {
+		_, err := parseEnvironments(flagValue)
+		assert.Error(t, err, "Expected error for '%s'", flagValue)
+	}
+}
+
+func TestValidateEnvironmentOutputFileName(t *testing.T) {
+	environments := []string{"dev", "prod"}
+	assert.NoError(t, validateEnvironmentOutputFileName("", environments), "Expected default template to be valid")
+	assert.NoError(t, validateEnvironmentOutputFileName("{{.Environment}}-creds.yml", environments))
+	assert.NoError(t, validateEnvironmentOutputFileName("creds.yml", []string{"dev"}), "Expected any template to be valid for one environment")
+	assert.Error(t, validateEnvironmentOutputFileName("{{.RoleName}}-creds.yml", environments), "Expected error when environments share a file name")
+}
+
+func TestExpandRoleNames(t *testing.T) {
+	defer restoreTestEnv(EnvironmentEnvVar)()
+	os.Setenv(EnvironmentEnvVar, "staging")
+
+	roleNames, err := expandRoleNames([]string{"web-${ENV}", "worker"}, "staging")
+	assert.NoError(t, err, "Unexpected error expanding role names")
+	assert.Equal(t, []string{"web-staging", "worker"}, roleNames)
+
+	roleNames, err = expandRoleNames([]string{"web-${ENV}"}, "")
+	assert.NoError(t, err, "Unexpected error when no environment is given")
+	assert.Equal(t, []string{"web-${ENV}"}, roleNames, "Expected role names to be unchanged without an environment")
+
+	_, err = expandRoleNames([]string{"web-${UNSET_TEST_ENV_VAR}"}, "staging")
+	assert.Error(t, err, "Expected error when a referenced variable is not set")
+}
+
+func TestExpandPolicyName(t *testing.T) {
+	defer restoreTestEnv(EnvironmentEnvVar)()
+	os.Setenv(EnvironmentEnvVar, "staging")
+
+	policyName, err := expandPolicyName("registry-access-${ENV}", "staging")
+	assert.NoError(t, err, "Unexpected error expanding policy name")
+	assert.Equal(t, "registry-access-staging", policyName)
+
+	policyName, err = expandPolicyName("registry-access-${ENV}", "")
+	assert.NoError(t, err, "Unexpected error when no environment is given")
+	assert.Equal(t, "registry-access-${ENV}", policyName, "Expected policy name to be unchanged without an environment")
+
+	_, err = expandPolicyName("registry-access-${UNSET_TEST_ENV_VAR}", "staging")
+	assert.Error(t, err, "Expected error when the policy name references an unset variable")
+}
+
+func TestEnvironmentManifestFile(t *testing.T) {
+	assert.Equal(t, "out/manifest-staging.json", environmentManifestFile("out/manifest.json", "staging"))
+	assert.Equal(t, "manifest-staging", environmentManifestFile("manifest", "staging"))
+	assert.Equal(t, "manifest.json", environmentManifestFile("manifest.json", ""))
+	assert.Equal(t, "", environmentManifestFile("", "staging"))
+}
+
+func TestEnvironmentsResult(t *testing.T) {
+	environments := []string{"dev", "staging", "prod"}
+
+	assert.NoError(t, environmentsResult(environments, map[string]error{}), "Expected no error when every environment succeeded")
+
+	err := environmentsResult(environments, map[string]error{
+		"prod": errors.New("something went wrong"),
+		"dev":  errors.New("something else went wrong"),
+	})
+	assert.Error(t, err, "Expected error when an environment failed")
+	assert.Contains(t, err.Error(), "2 of 3 environments: dev, prod")
+}
@@ -0,0 +1,172 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+)
+
+// ecsTaskExecutionPolicyResource is the resource of the AWS managed task execution role policy's ARN, in any partition
+const ecsTaskExecutionPolicyResource = "policy/service-role/AmazonECSTaskExecutionRolePolicy"
+
+// policyAccess is what the statements of a policy grant access to, by resource
+type policyAccess struct {
+	secrets      map[string]bool
+	kmsKeys      map[string]bool
+	repositories map[string]bool
+	logs         bool
+}
+
+// explainRole returns a plain-English sentence describing what the role can access, derived from the statements of
+// its registry credentials policy and the policies attached to it, e.g. "Role myRole can read 4 Secrets Manager
+// secrets and decrypt with 2 KMS keys, and has the AWS managed ECS task execution policy."
+func explainRole(result *ExecutionRoleResult) string {
+	access := getPolicyAccess(result.PolicyStatements)
+
+	var grants []string
+	if len(access.secrets) > 0 {
+		grants = append(grants, "read "+countResources(access.secrets, "Secrets Manager secret", "Secrets Manager secrets"))
+	}
+	if len(access.kmsKeys) > 0 {
+		grants = append(grants, "decrypt with "+countResources(access.kmsKeys, "KMS key", "KMS keys"))
+	}
+	if len(access.repositories) > 0 {
+		grants = append(grants, "pull images from "+countResources(access.repositories, "ECR repository", "ECR repositories"))
+	}
+	if access.logs {
+		grants = append(grants, "write to CloudWatch Logs")
+	}
+	policies := describeAttachedPolicies(result)
+
+	sentence := "Role " + result.RoleName
+	switch {
+	case len(grants) > 0 && policies != "":
+		sentence += " can " + joinWords(grants) + ", and has " + policies
+	case len(grants) > 0:
+		sentence += " can " + joinWords(grants)
+	case policies != "":
+		sentence += " has " + policies
+	default:
+		sentence += " has no permissions from this run"
+	}
+	return sentence + "."
+}
+
+// getPolicyAccess collects the secrets, KMS keys and ECR repositories the statements grant access to. A statement on
+// all resources ("*") grants access to all resources of the services of its actions.
+func getPolicyAccess(statements []StatementEntry) policyAccess {
+	access := policyAccess{secrets: make(map[string]bool), kmsKeys: make(map[string]bool), repositories: make(map[string]bool)}
+	for _, statement := range statements {
+		for _, action := range statement.Action {
+			if strings.HasPrefix(action, "logs:") {
+				access.logs = true
+			}
+		}
+		for _, resource := range statement.Resource {
+			if resource == "*" {
+				for _, action := range statement.Action {
+					switch {
+					case strings.HasPrefix(action, "secretsmanager:"):
+						access.secrets[resource] = true
+					case action == kmsDecryptAction:
+						access.kmsKeys[resource] = true
+					case strings.HasPrefix(action, "ecr:") && action != ecrGetAuthorizationTokenAction:
+						access.repositories[resource] = true
+					}
+				}
+				continue
+			}
+			resourceARN, err := arn.Parse(resource)
+			if err != nil {
+				continue
+			}
+			switch resourceARN.Service {
+			case "secretsmanager":
+				access.secrets[resource] = true
+			case "kms":
+				access.kmsKeys[resource] = true
+			case "ecr":
+				access.repositories[resource] = true
+			}
+		}
+	}
+	return access
+}
+
+// describeAttachedPolicies describes the policies attached to the role other than its registry credentials policy, or
+// returns an empty string if there are none
+func describeAttachedPolicies(result *ExecutionRoleResult) string {
+	additional := make(map[string]bool, len(result.AdditionalPolicyARNs))
+	for _, policyARN := range result.AdditionalPolicyARNs {
+		additional[policyARN] = true
+	}
+
+	var descriptions []string
+	for _, policyARN := range result.AttachedPolicyARNs {
+		if policyARN == result.PolicyARN || additional[policyARN] {
+			continue
+		}
+		if strings.HasSuffix(policyARN, ":"+ecsTaskExecutionPolicyResource) {
+			descriptions = append(descriptions, "the AWS managed ECS task execution policy")
+		} else {
+			descriptions = append(descriptions, "the managed policy "+policyARN)
+		}
+	}
+	if len(result.AdditionalPolicyARNs) > 0 {
+		names := make([]string, 0, len(result.AdditionalPolicyARNs))
+		for _, policyARN := range result.AdditionalPolicyARNs {
+			names = append(names, policyARN[strings.LastIndex(policyARN, "/")+1:])
+		}
+		noun := "policy"
+		if len(names) > 1 {
+			noun = "policies"
+		}
+		descriptions = append(descriptions, fmt.Sprintf("the attached %s %s", noun, strings.Join(names, ", ")))
+	}
+	return joinWords(descriptions)
+}
+
+// countResources returns the number of resources with the noun, or "all" for a wildcard resource
+func countResources(resources map[string]bool, noun, plural string) string {
+	if resources["*"] {
+		return "all " + plural
+	}
+	if len(resources) == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %s", len(resources), plural)
+}
+
+// joinWords joins the words as a list in prose, e.g. "a, b and c"
+func joinWords(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+// explainRoles returns the sentence of each role which was set up, in order
+func explainRoles(roleResults []*ExecutionRoleResult) []string {
+	explanations := make([]string, 0, len(roleResults))
+	for _, result := range roleResults {
+		if result.Err != nil {
+			continue
+		}
+		explanations = append(explanations, explainRole(result))
+	}
+	return explanations
+}
@@ -0,0 +1,110 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testExplainPolicyARN  = "arn:aws:iam::111111111111:policy/myPolicy"
+	testExplainManagedARN = "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy"
+)
+
+func TestExplainRole(t *testing.T) {
+	secretStatements := []StatementEntry{
+		{
+			Effect:   "Allow",
+			Action:   []string{"secretsmanager:GetSecretValue"},
+			Resource: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:reg-1", "arn:aws:secretsmanager:us-west-2:111111111111:secret:reg-2"},
+		},
+		{
+			Effect:   "Allow",
+			Action:   []string{kmsDecryptAction, "secretsmanager:GetSecretValue"},
+			Resource: []string{"arn:aws:kms:us-west-2:111111111111:key/key-1", "arn:aws:secretsmanager:us-west-2:111111111111:secret:reg-3"},
+		},
+		{
+			Effect:   "Allow",
+			Action:   []string{kmsDecryptAction},
+			Resource: []string{"arn:aws:kms:us-west-2:111111111111:key/key-1", "arn:aws:kms:us-west-2:111111111111:key/key-2"},
+		},
+	}
+
+	testCases := []struct {
+		description string
+		result      ExecutionRoleResult
+		expected    string
+	}{
+		{
+			"Secrets and managed policy",
+			ExecutionRoleResult{
+				RoleName:           "myRole",
+				PolicyARN:          testExplainPolicyARN,
+				PolicyStatements:   secretStatements,
+				AttachedPolicyARNs: []string{testExplainManagedARN, testExplainPolicyARN},
+			},
+			"Role myRole can read 3 Secrets Manager secrets and decrypt with 2 KMS keys, and has the AWS managed ECS task execution policy.",
+		},
+		{
+			"ECR access and additional policies",
+			ExecutionRoleResult{
+				RoleName:             "myRole",
+				PolicyARN:            testExplainPolicyARN,
+				PolicyStatements:     appendECRPullStatements([]StatementEntry{secretStatements[0]}, nil),
+				AttachedPolicyARNs:   []string{testExplainManagedARN, testExplainPolicyARN, "arn:aws:iam::111111111111:policy/team/extra"},
+				AdditionalPolicyARNs: []string{"arn:aws:iam::111111111111:policy/team/extra"},
+			},
+			"Role myRole can read 2 Secrets Manager secrets and pull images from all ECR repositories, and has the AWS managed ECS task execution policy and the attached policy extra.",
+		},
+		{
+			"Role only",
+			ExecutionRoleResult{RoleName: "myRole", RoleOnly: true, AttachedPolicyARNs: []string{testExplainManagedARN}},
+			"Role myRole has the AWS managed ECS task execution policy.",
+		},
+		{
+			"Minimal managed",
+			ExecutionRoleResult{
+				RoleName:  "myRole",
+				PolicyARN: testExplainPolicyARN,
+				PolicyStatements: []StatementEntry{
+					secretStatements[0],
+					{Effect: "Allow", Action: []string{"logs:CreateLogStream", "logs:PutLogEvents"}, Resource: []string{"*"}},
+				},
+				AttachedPolicyARNs: []string{testExplainPolicyARN},
+			},
+			"Role myRole can read 2 Secrets Manager secrets and write to CloudWatch Logs.",
+		},
+		{
+			"Nothing attached",
+			ExecutionRoleResult{RoleName: "myRole"},
+			"Role myRole has no permissions from this run.",
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.expected, explainRole(&test.result))
+		})
+	}
+}
+
+func TestExplainRoles_SkipsFailedRoles(t *testing.T) {
+	roleResults := []*ExecutionRoleResult{
+		{RoleName: "webRole", AttachedPolicyARNs: []string{testExplainManagedARN}},
+		{RoleName: "logRole", Err: errors.New("failed")},
+	}
+	assert.Equal(t, []string{"Role webRole has the AWS managed ECS task execution policy."}, explainRoles(roleResults))
+}
@@ -0,0 +1,66 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// ExportTrustPolicy prints the trust policy document that 'up' would use for a new task execution role, without
+// making any AWS requests
+func ExportTrustPolicy(c *cli.Context) {
+	requiredTags, err := parseRequiredSessionTags(c.String(flags.RequireSessionTagsFlag))
+	if err != nil {
+		log.Fatal("Error executing 'export-trust-policy': ", err)
+	}
+	accountID := c.String(flags.AccountIDFlag)
+	if accountID != "" {
+		if err = validateAccountID(accountID); err != nil {
+			log.Fatal("Error executing 'export-trust-policy': ", err)
+		}
+	}
+	trustPolicy, err := exportTrustPolicy(regcredio.FileStore{}, c.String(flags.RoleBundleFlag), requiredTags, accountID)
+	if err != nil {
+		log.Fatal("Error executing 'export-trust-policy': ", err)
+	}
+	fmt.Println(trustPolicy)
+}
+
+// exportTrustPolicy returns the trust policy from the role bundle, if one is given, or the default trust policy with
+// any required session tags. Since no AWS requests are made, a trust policy using '{{.AccountID}}' needs the account ID
+// to be given.
+func exportTrustPolicy(store regcredio.Store, bundleFile string, requiredTags map[string]string, accountID string) (string, error) {
+	params := ExecutionRoleParams{RequiredSessionTags: requiredTags}
+	if bundleFile != "" {
+		bundle, err := regcredio.ReadRoleBundleFrom(store, bundleFile)
+		if err != nil {
+			return "", err
+		}
+		trustPolicy, err := expandAccountID(bundle.Role.TrustPolicy, "trust policy of the role bundle", accountID)
+		if err != nil {
+			return "", err
+		}
+		bundle.Role.TrustPolicy = trustPolicy
+		applyRoleBundle(&params, bundle.Role)
+	}
+	if len(requiredTags) > 0 && params.TrustPolicy != "" {
+		return "", fmt.Errorf("'--%s' can't be used with the trust policy of a role bundle", flags.RequireSessionTagsFlag)
+	}
+	return params.trustPolicy(), nil
+}
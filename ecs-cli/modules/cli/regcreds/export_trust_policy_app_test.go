@@ -0,0 +1,71 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportTrustPolicy_Default(t *testing.T) {
+	trustPolicy, err := exportTrustPolicy(regcredio.NewMemoryStore(nil), "", nil, "")
+	assert.NoError(t, err, "Unexpected error exporting default trust policy")
+	assert.Equal(t, assumeRolePolicyDocString, trustPolicy)
+}
+
+func TestExportTrustPolicy_RoleBundle(t *testing.T) {
+	bundleTrustPolicy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":["ecs-tasks.amazonaws.com","ec2.amazonaws.com"]},"Action":"sts:AssumeRole"}]}`
+	store := regcredio.NewMemoryStore(map[string][]byte{
+		"bundle.yml": []byte("version: \"1\"\nrole:\n  trust_policy: '" + bundleTrustPolicy + "'\n  path: /ecs/\n"),
+	})
+
+	trustPolicy, err := exportTrustPolicy(store, "bundle.yml", nil, "")
+	assert.NoError(t, err, "Unexpected error exporting trust policy from role bundle")
+	assert.Equal(t, bundleTrustPolicy, trustPolicy, "Expected the trust policy to be printed exactly as it is used to create the role")
+}
+
+func TestExportTrustPolicy_ErrorOnMissingBundle(t *testing.T) {
+	_, err := exportTrustPolicy(regcredio.NewMemoryStore(nil), "missing.yml", nil, "")
+	assert.Error(t, err, "Expected error when the role bundle does not exist")
+}
+
+func TestExportTrustPolicy_RequiredSessionTags(t *testing.T) {
+	trustPolicy, err := exportTrustPolicy(regcredio.NewMemoryStore(nil), "", map[string]string{"Team": "payments"}, "")
+	assert.NoError(t, err, "Unexpected error exporting trust policy with required session tags")
+	assert.Equal(t, sessionTagTrustPolicy(map[string]string{"Team": "payments"}), trustPolicy)
+}
+
+func TestExportTrustPolicy_ErrorOnRequiredSessionTagsWithRoleBundle(t *testing.T) {
+	store := regcredio.NewMemoryStore(map[string][]byte{
+		"bundle.yml": []byte("version: \"1\"\nrole:\n  trust_policy: '{}'\n"),
+	})
+
+	_, err := exportTrustPolicy(store, "bundle.yml", map[string]string{"Team": "payments"}, "")
+	assert.Error(t, err, "Expected error when session tags are required with a custom trust policy")
+}
+
+func TestExportTrustPolicy_AccountIDTemplate(t *testing.T) {
+	store := regcredio.NewMemoryStore(map[string][]byte{
+		"bundle.yml": []byte("version: \"1\"\nrole:\n  trust_policy: '{\"Statement\":[{\"Principal\":{\"AWS\":\"arn:aws:iam::{{.AccountID}}:root\"}}]}'\n"),
+	})
+
+	trustPolicy, err := exportTrustPolicy(store, "bundle.yml", nil, "111111111111")
+	assert.NoError(t, err, "Unexpected error exporting trust policy with the account ID")
+	assert.Equal(t, `{"Statement":[{"Principal":{"AWS":"arn:aws:iam::111111111111:root"}}]}`, trustPolicy)
+
+	_, err = exportTrustPolicy(store, "bundle.yml", nil, "")
+	assert.Error(t, err, "Expected error when the trust policy needs an account ID and none is given")
+}
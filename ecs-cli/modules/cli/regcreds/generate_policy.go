@@ -14,7 +14,14 @@
 package regcreds
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 
 	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
@@ -22,6 +29,17 @@ import (
 
 const (
 	rolePolicyVersion = "2012-10-17"
+
+	secretsGetValueAction = "secretsmanager:GetSecretValue"
+	kmsDecryptAction      = "kms:Decrypt"
+
+	versionStageConditionKey = "secretsmanager:VersionStage"
+
+	hashSidPrefix     = "Secret"
+	hashSidLength     = 12
+	decryptSidSuffix  = "Decrypt"
+	rotationSidSuffix = "Rotation"
+	sharedKeySid      = "SharedKMSKeyDecrypt"
 )
 
 // PolicyDocument contains the statements that make up an IAM policy
@@ -32,28 +50,141 @@ type PolicyDocument struct {
 
 // StatementEntry contains a set of actions and the resources they apply to
 type StatementEntry struct {
-	Effect   string
-	Action   []string
-	Resource []string
+	Sid       string `json:",omitempty"`
+	Effect    string
+	Action    []string
+	Resource  []string
+	Condition map[string]map[string]string `json:",omitempty"`
+
+	// registryName is the registry the statement grants access to, if any; it is not part of the policy document
+	registryName string
+}
+
+// generateSecretsPolicy returns a policy granting read access to each secret (and decrypt access to its KMS key, if
+// any). Entries which list their own actions are granted exactly those actions on the secret instead, and rotation
+// compatible entries are also granted the rotationActions. If versionStage is non-empty, secret access is restricted
+// to that version stage. Entries with a secret scope are granted access to every secret matching the scope rather
+// than to their own secret ARN. A KMS key used by several entries is granted once, in its own statement. Entries
+// which use the same secret with the same settings are granted it once, and are an error if their settings differ.
+func generateSecretsPolicy(credEntries map[string]regcredio.CredsOutputEntry, versionStage string, kmsClient kmsClient.Client) (string, error) {
+	policyStatements, err := generateSecretsStatements(credEntries, versionStage, kmsClient)
+	if err != nil {
+		return "", err
+	}
+	return marshalPolicyDocument(policyStatements)
 }
 
-func generateSecretsPolicy(credEntries map[string]regcredio.CredsOutputEntry, kmsClient kmsClient.Client) (string, error) {
+// generateSecretsStatements returns the statements of the policy generated by generateSecretsPolicy
+func generateSecretsStatements(credEntries map[string]regcredio.CredsOutputEntry, versionStage string, kmsClient kmsClient.Client) ([]StatementEntry, error) {
 	policyStatements := make([]StatementEntry, 0, len(credEntries))
 
-	for _, entry := range credEntries {
+	// sort registries so that statements (and any Sid suffixes) are stable across runs
+	registryNames := make([]string, 0, len(credEntries))
+	for registryName := range credEntries {
+		registryNames = append(registryNames, registryName)
+	}
+	sort.Strings(registryNames)
+
+	// the entries are validated and their keys looked up first, so that the entries sharing a key are known
+	grants := make([]secretGrant, 0, len(registryNames))
+	keyUses := make(map[string]int)
+	// the first grant of each secret, so that registries sharing a secret are granted it once
+	secretGrants := make(map[string]secretGrant)
+	for _, registryName := range registryNames {
+		entry := credEntries[registryName]
+		if err := validateSecretActions(registryName, entry.Actions); err != nil {
+			return nil, err
+		}
+		secretActions := entrySecretActions(entry)
+		if versionStage != "" && hasSSMAction(secretActions) {
+			return nil, fmt.Errorf("SSM actions for registry %s cannot be restricted to a version stage", registryName)
+		}
+		if err := validateRotationCompatible(registryName, secretActions, entry.RotationCompatible); err != nil {
+			return nil, err
+		}
+		if err := validateSecretScope(registryName, entry.SecretScope, secretActions); err != nil {
+			return nil, err
+		}
+		secretResource := entry.CredentialARN
+		var scopeConditions map[string]string
+		if entry.SecretScope != nil {
+			resource, err := scopedSecretResource(registryName, entry.CredentialARN, *entry.SecretScope)
+			if err != nil {
+				return nil, err
+			}
+			secretResource = resource
+			scopeConditions = secretScopeConditions(*entry.SecretScope)
+		}
 		keyARN := ""
 		if entry.KMSKeyID != "" {
 			validARN, err := kmsClient.GetValidKeyARN(entry.KMSKeyID)
 			if err != nil {
-				return "", err
+				return nil, err
 			}
 			keyARN = validARN
 		}
-		statement := generatePolicyStatement(entry.CredentialARN, keyARN)
-		policyStatements = append(policyStatements, statement)
+		grant := secretGrant{registryName, secretResource, keyARN, scopeConditions, secretActions, entryRotationActions(entry, secretActions)}
+		if first, ok := secretGrants[entry.CredentialARN]; ok && entry.CredentialARN != "" {
+			if field := grantConflict(first, grant); field != "" {
+				return nil, duplicateSecretError(first.registryName, registryName, entry.CredentialARN, field)
+			}
+			// an exact duplicate, granted by the statements of the first registry
+			continue
+		}
+		secretGrants[entry.CredentialARN] = grant
+		if keyARN != "" {
+			keyUses[keyARN]++
+		}
+		grants = append(grants, grant)
 	}
 
-	policyDoc := PolicyDocument{Version: rolePolicyVersion, Statement: policyStatements}
+	usedSids := make(map[string]bool)
+	var sharedKeyARNs []string
+	sharedKeys := make(map[string]bool)
+	for _, grant := range grants {
+		keyARN := grant.keyARN
+		if keyUses[keyARN] > 1 {
+			// granted in a single statement after those of the secrets, so that the key is only listed once
+			if !sharedKeys[keyARN] {
+				sharedKeys[keyARN] = true
+				sharedKeyARNs = append(sharedKeyARNs, keyARN)
+			}
+			keyARN = ""
+		}
+		statements := generatePolicyStatements(grant.secretResource, keyARN, versionStage, grant.scopeConditions, grant.secretActions, grant.rotationActions)
+		baseSid := generateStatementSid(credEntries[grant.registryName])
+		for i := range statements {
+			// statements are returned with the suffix of their Sid
+			statements[i].Sid = uniqueSid(baseSid+statements[i].Sid, usedSids)
+			statements[i].registryName = grant.registryName
+		}
+		policyStatements = append(policyStatements, statements...)
+	}
+	for _, keyARN := range sharedKeyARNs {
+		policyStatements = append(policyStatements, StatementEntry{
+			Sid:      uniqueSid(sharedKeySid, usedSids),
+			Effect:   "Allow",
+			Action:   []string{kmsDecryptAction},
+			Resource: []string{keyARN},
+		})
+	}
+
+	return policyStatements, nil
+}
+
+// secretGrant is the access generateSecretsStatements grants to the secret of a registry
+type secretGrant struct {
+	registryName    string
+	secretResource  string
+	keyARN          string
+	scopeConditions map[string]string
+	secretActions   []string
+	rotationActions []string
+}
+
+// marshalPolicyDocument returns the JSON policy document containing the statements
+func marshalPolicyDocument(statements []StatementEntry) (string, error) {
+	policyDoc := PolicyDocument{Version: rolePolicyVersion, Statement: statements}
 	policyBytes, err := json.Marshal(&policyDoc)
 	if err != nil {
 		return "", err
@@ -62,21 +193,102 @@ func generateSecretsPolicy(credEntries map[string]regcredio.CredsOutputEntry, km
 	return string(policyBytes), nil
 }
 
-func generatePolicyStatement(credARN, kmsKeyARN string) StatementEntry {
-	customDecryptActions := []string{"kms:Decrypt", "secretsmanager:GetSecretValue"}
-	defaultActions := []string{"secretsmanager:GetSecretValue"}
+// generateStatementSid returns a Sid built from the entry's name, which may only contain alphanumeric characters. If
+// the entry has no usable name, a Sid is derived from a hash of the secret ARN.
+func generateStatementSid(entry regcredio.CredsOutputEntry) string {
+	if sid := sanitizeSid(entry.Name); sid != "" {
+		return sid
+	}
+	hash := sha256.Sum256([]byte(entry.CredentialARN))
+	return hashSidPrefix + hex.EncodeToString(hash[:])[:hashSidLength]
+}
+
+// sanitizeSid converts a name like "my-registry.example.com" to "MyRegistryExampleCom"
+func sanitizeSid(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !isSidCharacter(r)
+	})
+
+	var sid strings.Builder
+	for _, word := range words {
+		sid.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return sid.String()
+}
+
+func isSidCharacter(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// uniqueSid appends a numeric suffix to the Sid if it has already been used
+func uniqueSid(sid string, usedSids map[string]bool) string {
+	candidate := sid
+	for i := 2; usedSids[candidate]; i++ {
+		candidate = sid + strconv.Itoa(i)
+	}
+	usedSids[candidate] = true
+
+	return candidate
+}
 
+// generatePolicyStatements returns the statements granting access to a secret, each with only the suffix of its Sid
+// set. The scopeConditions restrict which secrets matching the secret resource may be accessed.
+func generatePolicyStatements(secretResource, kmsKeyARN, versionStage string, scopeConditions map[string]string, secretActions, rotationActions []string) []StatementEntry {
+	if versionStage != "" || len(scopeConditions) > 0 {
+		// the version stage and resource tag condition keys are only present on Secrets Manager requests, so decrypt
+		// access must be granted in its own statement, and the version stage condition key is only present on
+		// requests which read a secret value, so rotation access must be too
+		secretConditions := make(map[string]string, len(scopeConditions)+1)
+		for key, value := range scopeConditions {
+			secretConditions[key] = value
+		}
+		if versionStage != "" {
+			secretConditions[versionStageConditionKey] = versionStage
+		}
+		statements := []StatementEntry{
+			{
+				Effect:    "Allow",
+				Action:    secretActions,
+				Resource:  []string{secretResource},
+				Condition: stringEqualsCondition(secretConditions),
+			},
+		}
+		if kmsKeyARN != "" {
+			statements = append(statements, StatementEntry{
+				Sid:      decryptSidSuffix,
+				Effect:   "Allow",
+				Action:   []string{kmsDecryptAction},
+				Resource: []string{kmsKeyARN},
+			})
+		}
+		if len(rotationActions) > 0 {
+			statements = append(statements, StatementEntry{
+				Sid:       rotationSidSuffix,
+				Effect:    "Allow",
+				Action:    rotationActions,
+				Resource:  []string{secretResource},
+				Condition: stringEqualsCondition(scopeConditions),
+			})
+		}
+		return statements
+	}
+
+	secretActions = append(append([]string{}, secretActions...), rotationActions...)
 	if kmsKeyARN != "" {
-		return StatementEntry{
-			Effect:   "Allow",
-			Action:   customDecryptActions,
-			Resource: []string{kmsKeyARN, credARN},
+		return []StatementEntry{
+			{
+				Effect:   "Allow",
+				Action:   append([]string{kmsDecryptAction}, secretActions...),
+				Resource: []string{kmsKeyARN, secretResource},
+			},
 		}
 	}
 	// TODO: look for unspecified KMS Key on in-region secrets
-	return StatementEntry{
-		Effect:   "Allow",
-		Action:   defaultActions,
-		Resource: []string{credARN},
+	return []StatementEntry{
+		{
+			Effect:   "Allow",
+			Action:   secretActions,
+			Resource: []string{secretResource},
+		},
 	}
 }
@@ -0,0 +1,360 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSecretsPolicy_ExactActions(t *testing.T) {
+	testSecretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret"
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+
+	testCases := []struct {
+		description     string
+		kmsKey          string
+		versionStage    string
+		expectedActions [][]string
+	}{
+		{"No KMS key", "", "", [][]string{{"secretsmanager:GetSecretValue"}}},
+		{"With KMS key", testKeyARN, "", [][]string{{"kms:Decrypt", "secretsmanager:GetSecretValue"}}},
+		{"Version stage, no KMS key", "", "AWSCURRENT", [][]string{{"secretsmanager:GetSecretValue"}}},
+		{"Version stage with KMS key", testKeyARN, "AWSCURRENT", [][]string{{"secretsmanager:GetSecretValue"}, {"kms:Decrypt"}}},
+	}
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			creds := map[string]regcredio.CredsOutputEntry{
+				"myreg.test.io": regcredio.BuildOutputEntry(testSecretARN, test.kmsKey, []string{"web"}),
+			}
+
+			mocks := setupTestController(t)
+			if test.kmsKey != "" {
+				mocks.MockKMS.EXPECT().GetValidKeyARN(test.kmsKey).Return(test.kmsKey, nil)
+			}
+
+			policyString, err := generateSecretsPolicy(creds, test.versionStage, mocks.MockKMS)
+			assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+			policyDoc := parseTestPolicy(t, policyString)
+			assert.Equal(t, len(test.expectedActions), len(policyDoc.Statement))
+			for i, statement := range policyDoc.Statement {
+				assert.Equal(t, "Allow", statement.Effect)
+				assert.Equal(t, test.expectedActions[i], statement.Action, "Expected exact action set")
+			}
+		})
+	}
+}
+
+func TestGenerateSecretsPolicy_WithVersionStage(t *testing.T) {
+	testSecretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret"
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	creds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry(testSecretARN, testKeyARN, []string{"web"}),
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return(testKeyARN, nil)
+
+	policyString, err := generateSecretsPolicy(creds, "AWSCURRENT", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, 2, len(policyDoc.Statement))
+
+	secretStatement := policyDoc.Statement[0]
+	assert.Equal(t, []string{testSecretARN}, secretStatement.Resource)
+	assert.Equal(t, map[string]map[string]string{
+		"StringEquals": {"secretsmanager:VersionStage": "AWSCURRENT"},
+	}, secretStatement.Condition)
+
+	keyStatement := policyDoc.Statement[1]
+	assert.Equal(t, []string{testKeyARN}, keyStatement.Resource)
+	assert.Empty(t, keyStatement.Condition, "Expected no condition on KMS statement")
+}
+
+func TestGenerateSecretsPolicy_SharedKMSKey(t *testing.T) {
+	sharedKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	otherKeyARN := "arn:aws:kms:us-west-2:111111111111:key/12ab-34cd"
+	for _, versionStage := range []string{"", "AWSCURRENT"} {
+		t.Run("version stage '"+versionStage+"'", func(t *testing.T) {
+			creds := map[string]regcredio.CredsOutputEntry{
+				"a.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:a", sharedKeyARN, []string{"web"}),
+				"b.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:b", sharedKeyARN, []string{"web"}),
+				"c.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:c", sharedKeyARN, []string{"web"}),
+				"d.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:d", otherKeyARN, []string{"web"}),
+			}
+
+			mocks := setupTestController(t)
+			mocks.MockKMS.EXPECT().GetValidKeyARN(sharedKeyARN).Return(sharedKeyARN, nil).Times(3)
+			mocks.MockKMS.EXPECT().GetValidKeyARN(otherKeyARN).Return(otherKeyARN, nil)
+
+			policyString, err := generateSecretsPolicy(creds, versionStage, mocks.MockKMS)
+			assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+			policyDoc := parseTestPolicy(t, policyString)
+			var sharedKeyStatements []StatementEntry
+			for _, statement := range policyDoc.Statement {
+				for _, resource := range statement.Resource {
+					if resource == sharedKeyARN {
+						sharedKeyStatements = append(sharedKeyStatements, statement)
+					}
+				}
+			}
+			if assert.Len(t, sharedKeyStatements, 1, "Expected the shared key to be granted by exactly one statement") {
+				assert.Equal(t, StatementEntry{Sid: "SharedKMSKeyDecrypt", Effect: "Allow", Action: []string{"kms:Decrypt"}, Resource: []string{sharedKeyARN}}, sharedKeyStatements[0])
+			}
+			assert.Contains(t, policyString, otherKeyARN, "Expected a key used by one entry to still be granted")
+		})
+	}
+}
+
+func TestGenerateSecretsPolicy_DuplicateSecret(t *testing.T) {
+	secretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:shared"
+	keyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	single := map[string]regcredio.CredsOutputEntry{
+		"a.example.com": regcredio.BuildOutputEntry(secretARN, keyARN, []string{"web"}),
+	}
+	duplicated := map[string]regcredio.CredsOutputEntry{
+		"a.example.com": regcredio.BuildOutputEntry(secretARN, keyARN, []string{"web"}),
+		"b.example.com": regcredio.BuildOutputEntry(secretARN, keyARN, []string{"worker"}),
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(keyARN).Return(keyARN, nil).Times(3)
+
+	expectedPolicy, err := generateSecretsPolicy(single, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+	policyString, err := generateSecretsPolicy(duplicated, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+	assert.Equal(t, expectedPolicy, policyString, "Expected the duplicate entry to be merged into the statements of the first")
+}
+
+func TestGenerateSecretsPolicy_ErrorOnConflictingDuplicateSecret(t *testing.T) {
+	secretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:shared"
+	keyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	creds := map[string]regcredio.CredsOutputEntry{
+		"a.example.com": regcredio.BuildOutputEntry(secretARN, keyARN, []string{"web"}),
+		"b.example.com": regcredio.BuildOutputEntry(secretARN, "alias/otherKey", []string{"worker"}),
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(keyARN).Return(keyARN, nil)
+	mocks.MockKMS.EXPECT().GetValidKeyARN("alias/otherKey").Return("arn:aws:kms:us-west-2:111111111111:key/12ab-34cd", nil)
+
+	_, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.Error(t, err, "Expected error for entries using the same secret with different keys")
+	assert.Contains(t, err.Error(), "a.example.com")
+	assert.Contains(t, err.Error(), "b.example.com")
+	assert.Contains(t, err.Error(), "kms_key_id")
+}
+
+func TestGenerateSecretsPolicy_NoConditionByDefault(t *testing.T) {
+	creds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret", "", []string{"web"}),
+	}
+
+	mocks := setupTestController(t)
+	policyString, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+	assert.NotContains(t, policyString, "Condition")
+}
+
+func TestGenerateSecretsPolicy_Sids(t *testing.T) {
+	namedEntry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:named", "", []string{"web"})
+	namedEntry.Name = "my-registry.example.com (prod)"
+	duplicateNameEntry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:duplicate", "", []string{"log"})
+	duplicateNameEntry.Name = "My Registry Example Com Prod"
+	unnamedEntry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:unnamed", "", []string{"metrics"})
+
+	creds := map[string]regcredio.CredsOutputEntry{
+		"a.example.com": namedEntry,
+		"b.example.com": duplicateNameEntry,
+		"c.example.com": unnamedEntry,
+	}
+
+	mocks := setupTestController(t)
+	policyString, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, 3, len(policyDoc.Statement))
+	assert.Equal(t, "MyRegistryExampleComProd", policyDoc.Statement[0].Sid)
+	assert.Equal(t, "MyRegistryExampleComProd2", policyDoc.Statement[1].Sid, "Expected colliding Sid to be deduplicated")
+	assert.Equal(t, generateStatementSid(unnamedEntry), policyDoc.Statement[2].Sid)
+	assert.Regexp(t, "^Secret[0-9a-f]{12}$", policyDoc.Statement[2].Sid, "Expected hash-based Sid for unnamed entry")
+
+	// generating the policy again should produce identical Sids
+	secondPolicyString, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+	assert.Equal(t, policyString, secondPolicyString, "Expected policy to be deterministic")
+}
+
+func TestGenerateSecretsPolicy_SidsWithVersionStage(t *testing.T) {
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	entry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:named", testKeyARN, []string{"web"})
+	entry.Name = "prod"
+	creds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": entry,
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return(testKeyARN, nil)
+
+	policyString, err := generateSecretsPolicy(creds, "AWSCURRENT", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, "Prod", policyDoc.Statement[0].Sid)
+	assert.Equal(t, "ProdDecrypt", policyDoc.Statement[1].Sid)
+}
+
+func TestGenerateSecretsPolicy_EntryActions(t *testing.T) {
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	ssmEntry := regcredio.BuildOutputEntry("arn:aws:ssm:us-west-2:111111111111:parameter/registry/password", "", []string{"web"})
+	ssmEntry.Actions = []string{"ssm:GetParameters"}
+	encryptedEntry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret", testKeyARN, []string{"log"})
+	encryptedEntry.Actions = []string{"secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"}
+
+	creds := map[string]regcredio.CredsOutputEntry{
+		"a.example.com": ssmEntry,
+		"b.example.com": encryptedEntry,
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return(testKeyARN, nil)
+
+	policyString, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, 2, len(policyDoc.Statement))
+	assert.Equal(t, []string{"ssm:GetParameters"}, policyDoc.Statement[0].Action, "Expected entry actions to be used verbatim")
+	assert.Equal(t, []string{"kms:Decrypt", "secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"}, policyDoc.Statement[1].Action)
+}
+
+func TestGenerateSecretsPolicy_ErrorOnInvalidEntryAction(t *testing.T) {
+	entry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret", "", []string{"web"})
+	entry.Actions = []string{"secretsmanager:*"}
+	creds := map[string]regcredio.CredsOutputEntry{"myreg.test.io": entry}
+
+	mocks := setupTestController(t)
+	_, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.Error(t, err, "Expected error for wildcard action")
+}
+
+func TestGenerateSecretsPolicy_ErrorOnSSMActionWithVersionStage(t *testing.T) {
+	entry := regcredio.BuildOutputEntry("arn:aws:ssm:us-west-2:111111111111:parameter/registry/password", "", []string{"web"})
+	entry.Actions = []string{"ssm:GetParameters"}
+	creds := map[string]regcredio.CredsOutputEntry{"myreg.test.io": entry}
+
+	mocks := setupTestController(t)
+	_, err := generateSecretsPolicy(creds, "AWSCURRENT", mocks.MockKMS)
+	assert.Error(t, err, "Expected error when restricting SSM actions to a version stage")
+}
+
+func TestGenerateSecretsPolicy_RotationCompatible(t *testing.T) {
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	rotatedEntry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:rotated", testKeyARN, []string{"web"})
+	rotatedEntry.Name = "rotated"
+	rotatedEntry.RotationCompatible = true
+	// rotation actions already listed by the entry are not repeated
+	describedEntry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:described", "", []string{"log"})
+	describedEntry.Name = "described"
+	describedEntry.Actions = []string{"secretsmanager:GetSecretValue", "secretsmanager:describesecret"}
+	describedEntry.RotationCompatible = true
+	plainEntry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:plain", "", []string{"metrics"})
+	plainEntry.Name = "plain"
+
+	creds := map[string]regcredio.CredsOutputEntry{
+		"a.example.com": rotatedEntry,
+		"b.example.com": describedEntry,
+		"c.example.com": plainEntry,
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return(testKeyARN, nil)
+
+	policyString, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, 3, len(policyDoc.Statement))
+	assert.Equal(t, []string{"kms:Decrypt", "secretsmanager:GetSecretValue", "secretsmanager:DescribeSecret"}, policyDoc.Statement[0].Action)
+	assert.Equal(t, []string{"secretsmanager:GetSecretValue", "secretsmanager:describesecret"}, policyDoc.Statement[1].Action)
+	assert.Equal(t, []string{"secretsmanager:GetSecretValue"}, policyDoc.Statement[2].Action, "Expected no rotation actions by default")
+}
+
+func TestGenerateSecretsPolicy_RotationCompatibleWithVersionStage(t *testing.T) {
+	testSecretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:rotated"
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	entry := regcredio.BuildOutputEntry(testSecretARN, testKeyARN, []string{"web"})
+	entry.Name = "prod"
+	entry.RotationCompatible = true
+	creds := map[string]regcredio.CredsOutputEntry{"myreg.test.io": entry}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return(testKeyARN, nil)
+
+	policyString, err := generateSecretsPolicy(creds, "AWSCURRENT", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, 3, len(policyDoc.Statement))
+	assert.Equal(t, []string{"secretsmanager:GetSecretValue"}, policyDoc.Statement[0].Action)
+	assert.NotEmpty(t, policyDoc.Statement[0].Condition)
+
+	// DescribeSecret requests have no version stage, so it is granted without the condition
+	rotationStatement := policyDoc.Statement[2]
+	assert.Equal(t, "ProdRotation", rotationStatement.Sid)
+	assert.Equal(t, []string{"secretsmanager:DescribeSecret"}, rotationStatement.Action)
+	assert.Equal(t, []string{testSecretARN}, rotationStatement.Resource)
+	assert.Empty(t, rotationStatement.Condition)
+}
+
+func TestGenerateSecretsPolicy_ErrorOnRotationCompatibleSSMParameter(t *testing.T) {
+	entry := regcredio.BuildOutputEntry("arn:aws:ssm:us-west-2:111111111111:parameter/registry/password", "", []string{"web"})
+	entry.Actions = []string{"ssm:GetParameters"}
+	entry.RotationCompatible = true
+	creds := map[string]regcredio.CredsOutputEntry{"myreg.test.io": entry}
+
+	mocks := setupTestController(t)
+	_, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.Error(t, err, "Expected error when rotation is requested for an SSM parameter")
+}
+
+func TestSanitizeSid(t *testing.T) {
+	testCases := map[string]string{
+		"prod":                    "Prod",
+		"my-registry.example.com": "MyRegistryExampleCom",
+		"Docker Hub #2":           "DockerHub2",
+		"ünïcode":                 "NCode",
+		"--!!--":                  "",
+		"":                        "",
+	}
+	for name, expectedSid := range testCases {
+		assert.Equal(t, expectedSid, sanitizeSid(name), "Unexpected Sid for name '%s'", name)
+	}
+}
+
+func parseTestPolicy(t *testing.T, policyString string) PolicyDocument {
+	policyDoc := PolicyDocument{}
+	err := json.Unmarshal([]byte(policyString), &policyDoc)
+	assert.NoError(t, err, "Unexpected error parsing policy document")
+	assert.Equal(t, rolePolicyVersion, policyDoc.Version)
+	return policyDoc
+}
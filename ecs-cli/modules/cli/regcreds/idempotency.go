@@ -0,0 +1,144 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// IdempotencyKeyTagKey is the role tag recording the idempotency key of the run which set up the role
+	IdempotencyKeyTagKey = "ecs-cli:idempotency-key"
+	// IAM policies cannot be tagged, so the policy of the run is recorded on the role
+	idempotencyFingerprintTagKey = "ecs-cli:idempotency-fingerprint"
+	idempotencyPolicyTagKey      = "ecs-cli:idempotency-policy-arn"
+	// idempotencyTagCount is the number of tags recorded on each role for an idempotency key
+	idempotencyTagCount = 3
+)
+
+// idempotency keys are stored as IAM tag values
+var validIdempotencyKey = regexp.MustCompile(`^[A-Za-z0-9 _.:/=+\-@]{1,256}$`)
+
+// idempotencyConfig is the configuration of a run which must match for a run with the same idempotency key to be
+// treated as a repeat
+type idempotencyConfig struct {
+	Registries           map[string]regcredio.RegistryCredEntry `json:"registries"`
+	RoleNames            []string                               `json:"roleNames"`
+	VersionStage         string                                 `json:"versionStage"`
+	PermissionsBoundary  string                                 `json:"permissionsBoundary"`
+	RoleBundle           regcredio.RoleBundleEntry              `json:"roleBundle"`
+	AdditionalPolicyARNs []string                               `json:"additionalPolicyArns"`
+	AttachOrder          string                                 `json:"attachOrder"`
+	AllowEmpty           bool                                   `json:"allowEmpty"`
+	// fields added later are omitted when unset, so that earlier runs keep their fingerprint
+	IncludeECR          bool                `json:"includeEcr,omitempty"`
+	ECRRepositories     []string            `json:"ecrRepositories,omitempty"`
+	TagCondition        *PolicyTagCondition `json:"tagCondition,omitempty"`
+	PolicyName          string              `json:"policyName,omitempty"`
+	RequiredSessionTags map[string]string   `json:"requiredSessionTags,omitempty"`
+	PolicyNameFromHash  bool                `json:"policyNameFromHash,omitempty"`
+
+	MinimalManaged        bool     `json:"minimalManaged,omitempty"`
+	MinimalManagedActions []string `json:"minimalManagedActions,omitempty"`
+	ManagedPolicyARN      string   `json:"managedPolicyArn,omitempty"`
+	RoleOnly              bool     `json:"roleOnly,omitempty"`
+	SeparateStatements    bool     `json:"separateStatements,omitempty"`
+	MultiRegionKeys       string   `json:"multiRegionKeys,omitempty"`
+}
+
+func validateIdempotencyKey(key string) error {
+	if !validIdempotencyKey.MatchString(key) {
+		return fmt.Errorf("invalid value '%s' for '--%s'; the key must be 1 to 256 letters, numbers, spaces or any of '_.:/=+-@'", key, flags.IdempotencyKeyFlag)
+	}
+	return nil
+}
+
+// idempotencyFingerprint hashes the configuration of a run. Usernames and passwords are left out, so that they are
+// not derivable from the tag, which means a run that only changes credentials is still treated as a repeat.
+func idempotencyFingerprint(config idempotencyConfig) (string, error) {
+	registries := make(map[string]regcredio.RegistryCredEntry, len(config.Registries))
+	for registryName, entry := range config.Registries {
+		entry.Username = ""
+		entry.Password = ""
+		registries[registryName] = entry
+	}
+	config.Registries = registries
+
+	// maps are marshalled with sorted keys, so the same configuration always has the same fingerprint
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(configJSON)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// findIdempotentRun returns the results of an earlier run with the same idempotency key, or nil if there is no
+// completed run with the key for all of the roles. It fails if the key was used with a different configuration.
+func findIdempotentRun(roleNames []string, key, fingerprint string, client iamClient.Client) ([]*ExecutionRoleResult, error) {
+	results := make([]*ExecutionRoleResult, 0, len(roleNames))
+	for _, roleName := range roleNames {
+		tags, err := client.ListRoleTags(roleName)
+		if utils.EntityNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to check the idempotency key of role %s", roleName)
+		}
+		tagValues := make(map[string]string, len(tags))
+		for _, tag := range tags {
+			tagValues[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+
+		policyARN, completed := tagValues[idempotencyPolicyTagKey]
+		if tagValues[IdempotencyKeyTagKey] != key || !completed {
+			return nil, nil
+		}
+		if tagValues[idempotencyFingerprintTagKey] != fingerprint {
+			return nil, fmt.Errorf("idempotency key '%s' was already used for role %s with a different configuration; use a new key to apply the changes", key, roleName)
+		}
+		results = append(results, &ExecutionRoleResult{RoleName: roleName, PolicyARN: policyARN})
+	}
+	return results, nil
+}
+
+// recordIdempotencyKey tags each role with the key, the configuration fingerprint and the policy of the run, once the
+// run has completed
+func recordIdempotencyKey(roleResults []*ExecutionRoleResult, key, fingerprint string, client iamClient.Client) error {
+	for _, result := range roleResults {
+		tags := []*iam.Tag{
+			{Key: aws.String(IdempotencyKeyTagKey), Value: aws.String(key)},
+			{Key: aws.String(idempotencyFingerprintTagKey), Value: aws.String(fingerprint)},
+			{Key: aws.String(idempotencyPolicyTagKey), Value: aws.String(result.PolicyARN)},
+		}
+		if err := client.TagRole(result.RoleName, tags); err != nil {
+			return errors.Wrapf(err, "failed to record the idempotency key on role %s", result.RoleName)
+		}
+	}
+	log.Infof("Recorded idempotency key '%s'", key)
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+)
+
+const testIdempotencyPolicyARN = "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myRole-policy-20190601T120000Z"
+
+func testIdempotencyConfig() idempotencyConfig {
+	return idempotencyConfig{
+		Registries: map[string]regcredio.RegistryCredEntry{
+			"my.example.com": {Username: "user", Password: "pass", ContainerNames: []string{"web"}},
+		},
+		RoleNames: []string{"myRole"},
+	}
+}
+
+func idempotencyTags(key, fingerprint, policyARN string) []*iam.Tag {
+	return []*iam.Tag{
+		{Key: aws.String(IdempotencyKeyTagKey), Value: aws.String(key)},
+		{Key: aws.String(idempotencyFingerprintTagKey), Value: aws.String(fingerprint)},
+		{Key: aws.String(idempotencyPolicyTagKey), Value: aws.String(policyARN)},
+	}
+}
+
+func TestIdempotencyFingerprint(t *testing.T) {
+	config := testIdempotencyConfig()
+	fingerprint, err := idempotencyFingerprint(config)
+	assert.NoError(t, err, "Unexpected error computing fingerprint")
+
+	newPassword := testIdempotencyConfig()
+	newPassword.Registries["my.example.com"] = regcredio.RegistryCredEntry{Username: "user", Password: "changed", ContainerNames: []string{"web"}}
+	samePassword, err := idempotencyFingerprint(newPassword)
+	assert.NoError(t, err, "Unexpected error computing fingerprint")
+	assert.Equal(t, fingerprint, samePassword, "Expected credentials not to change the fingerprint")
+
+	newRole := testIdempotencyConfig()
+	newRole.RoleNames = []string{"otherRole"}
+	otherFingerprint, err := idempotencyFingerprint(newRole)
+	assert.NoError(t, err, "Unexpected error computing fingerprint")
+	assert.NotEqual(t, fingerprint, otherFingerprint, "Expected a different role to change the fingerprint")
+}
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	assert.NoError(t, validateIdempotencyKey("pipeline-1234:attempt/1"))
+	assert.Error(t, validateIdempotencyKey("key,with,commas"))
+	assert.Error(t, validateIdempotencyKey(""))
+}
+
+func TestFindIdempotentRun(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListRoleTags("myRole").Return(idempotencyTags("run-1", "abc", testIdempotencyPolicyARN), nil)
+
+	results, err := findIdempotentRun([]string{"myRole"}, "run-1", "abc", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error finding idempotent run")
+	assert.Len(t, results, 1)
+	assert.Equal(t, "myRole", results[0].RoleName)
+	assert.Equal(t, testIdempotencyPolicyARN, results[0].PolicyARN)
+	assert.False(t, results[0].RoleCreated)
+}
+
+func TestFindIdempotentRun_NoRun(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListRoleTags("newRole").Return(nil, awserr.New("NoSuchEntity", "not found", nil))
+	mocks.MockIAM.EXPECT().ListRoleTags("otherKeyRole").Return(idempotencyTags("run-0", "abc", testIdempotencyPolicyARN), nil)
+
+	results, err := findIdempotentRun([]string{"newRole"}, "run-1", "abc", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error finding idempotent run")
+	assert.Nil(t, results, "Expected no run for a role which does not exist")
+
+	results, err = findIdempotentRun([]string{"otherKeyRole"}, "run-1", "abc", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error finding idempotent run")
+	assert.Nil(t, results, "Expected no run for a role with a different key")
+}
+
+func TestFindIdempotentRun_ErrorForDifferentConfig(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListRoleTags("myRole").Return(idempotencyTags("run-1", "abc", testIdempotencyPolicyARN), nil)
+
+	_, err := findIdempotentRun([]string{"myRole"}, "run-1", "def", mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the key was used with a different configuration")
+}
+
+func TestFindIdempotentRun_ErrorCase(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListRoleTags("myRole").Return(nil, errors.New("something went wrong"))
+
+	_, err := findIdempotentRun([]string{"myRole"}, "run-1", "abc", mocks.MockIAM)
+	assert.Error(t, err, "Expected error when role tags cannot be listed")
+}
+
+func TestRecordIdempotencyKey(t *testing.T) {
+	mocks := setupTestController(t)
+	roleResults := []*ExecutionRoleResult{
+		{RoleName: "webRole", PolicyARN: testIdempotencyPolicyARN},
+		{RoleName: "workerRole", PolicyARN: testIdempotencyPolicyARN},
+	}
+	mocks.MockIAM.EXPECT().TagRole("webRole", idempotencyTags("run-1", "abc", testIdempotencyPolicyARN)).Return(nil)
+	mocks.MockIAM.EXPECT().TagRole("workerRole", idempotencyTags("run-1", "abc", testIdempotencyPolicyARN)).Return(nil)
+
+	assert.NoError(t, recordIdempotencyKey(roleResults, "run-1", "abc", mocks.MockIAM))
+}
@@ -0,0 +1,105 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"time"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Import adopts an existing task execution role and secrets policy, e.g. created by another tool, so that they are
+// managed by the ecs-cli from now on. Only the role's tags are changed.
+func Import(c *cli.Context) {
+	roleName := c.String(flags.ImportRoleFlag)
+	policyARN := c.String(flags.ImportPolicyFlag)
+	manifestFile := c.String(flags.ManifestFlag)
+	if roleName == "" || policyARN == "" || manifestFile == "" {
+		log.Fatalf("Error executing 'import': '--%s', '--%s' and '--%s' must be specified", flags.ImportRoleFlag, flags.ImportPolicyFlag, flags.ManifestFlag)
+	}
+
+	managementTagKey, managementTagValue, err := parseManagementTag(c.String(flags.ManagementTagFlag))
+	if err != nil {
+		log.Fatal("Error executing 'import': ", err)
+	}
+	if managementTagKey == "" {
+		managementTagKey, managementTagValue = DefaultManagementTagKey, DefaultManagementTagValue
+	}
+
+	commandConfig := getNewCommandConfig(c, "", "")
+	client := iamClient.NewIAMClient(commandConfig)
+
+	manifest, err := importResources(roleName, policyARN, managementTagKey, managementTagValue, commandConfig.Region(), client, time.Now().UTC())
+	if err != nil {
+		log.Fatal("Error executing 'import': ", err)
+	}
+	if err = regcredio.WriteManifest(manifest, manifestFile); err != nil {
+		log.Fatal("Error writing manifest: ", err)
+	}
+	log.Infof("Imported role %s and policy %s into manifest %s", roleName, policyARN, manifestFile)
+}
+
+// importResources tags the role as managed by the ecs-cli and returns a manifest listing the role and policy as
+// created by the ecs-cli, so that 'down' removes them. The policy must already be attached to the role.
+func importResources(roleName, policyARN, tagKey, tagValue, region string, client iamClient.Client, importTime time.Time) (regcredio.ECSRegCredsManifest, error) {
+	role, err := client.GetRole(roleName)
+	if err != nil {
+		return regcredio.ECSRegCredsManifest{}, errors.Wrapf(err, "failed to find role %s", roleName)
+	}
+	if _, err = client.GetPolicy(policyARN); err != nil {
+		return regcredio.ECSRegCredsManifest{}, errors.Wrapf(err, "failed to find policy %s", policyARN)
+	}
+
+	attached, err := client.ListAttachedRolePolicies(roleName)
+	if err != nil {
+		return regcredio.ECSRegCredsManifest{}, errors.Wrapf(err, "failed to list policies attached to role %s", roleName)
+	}
+	if !isPolicyAttached(attached, policyARN) {
+		return regcredio.ECSRegCredsManifest{}, fmt.Errorf("policy %s is not attached to role %s", policyARN, roleName)
+	}
+	// every attached policy must be detached before the role can be deleted
+	attachedARNs := make([]string, 0, len(attached))
+	for _, policy := range attached {
+		attachedARNs = append(attachedARNs, aws.StringValue(policy.PolicyArn))
+	}
+
+	tags := []*iam.Tag{{Key: aws.String(tagKey), Value: aws.String(tagValue)}}
+	if err = client.TagRole(roleName, tags); err != nil {
+		return regcredio.ECSRegCredsManifest{}, errors.Wrapf(err, "failed to tag role %s", roleName)
+	}
+	log.Infof("Tagged role %s with %s=%s", roleName, tagKey, tagValue)
+
+	return regcredio.ECSRegCredsManifest{
+		CreatedAt: importTime,
+		Region:    region,
+		Roles: []regcredio.ManifestRole{{
+			RoleName:           roleName,
+			RoleARN:            aws.StringValue(role.Arn),
+			Created:            true,
+			Imported:           true,
+			PolicyARN:          policyARN,
+			AttachedPolicyARNs: attachedARNs,
+			Tags:               map[string]string{tagKey: tagValue},
+		}},
+		Secrets: []regcredio.ManifestSecret{},
+	}, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testImportRoleName  = "externalExecutionRole"
+	testImportRoleARN   = "arn:aws:iam::111111111111:role/externalExecutionRole"
+	testImportPolicyARN = "arn:aws:iam::111111111111:policy/externalRegistryPolicy"
+)
+
+func TestImportResources(t *testing.T) {
+	importTime := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	attached := []*iam.AttachedPolicy{
+		{PolicyArn: aws.String(managedPolicyARN)},
+		{PolicyArn: aws.String(testImportPolicyARN)},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().GetRole(testImportRoleName).Return(&iam.Role{RoleName: aws.String(testImportRoleName), Arn: aws.String(testImportRoleARN)}, nil),
+		mocks.MockIAM.EXPECT().GetPolicy(testImportPolicyARN).Return(&iam.Policy{Arn: aws.String(testImportPolicyARN)}, nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testImportRoleName).Return(attached, nil),
+		mocks.MockIAM.EXPECT().TagRole(testImportRoleName, gomock.Any()).Do(func(_ string, tags []*iam.Tag) {
+			assert.Len(t, tags, 1)
+			assert.Equal(t, DefaultManagementTagKey, aws.StringValue(tags[0].Key))
+			assert.Equal(t, DefaultManagementTagValue, aws.StringValue(tags[0].Value))
+		}).Return(nil),
+	)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(gomock.Any(), gomock.Any()).Times(0)
+
+	manifest, err := importResources(testImportRoleName, testImportPolicyARN, DefaultManagementTagKey, DefaultManagementTagValue, "us-west-2", mocks.MockIAM, importTime)
+	assert.NoError(t, err, "Unexpected error importing resources")
+	assert.Equal(t, "us-west-2", manifest.Region)
+	assert.Equal(t, importTime, manifest.CreatedAt)
+	assert.Empty(t, manifest.Secrets)
+	assert.Len(t, manifest.Roles, 1)
+
+	role := manifest.Roles[0]
+	assert.Equal(t, testImportRoleName, role.RoleName)
+	assert.Equal(t, testImportRoleARN, role.RoleARN)
+	assert.True(t, role.Created, "Expected imported role to be removed by 'down'")
+	assert.True(t, role.Imported)
+	assert.Equal(t, testImportPolicyARN, role.PolicyARN)
+	assert.ElementsMatch(t, []string{managedPolicyARN, testImportPolicyARN}, role.AttachedPolicyARNs)
+}
+
+func TestImportResources_ErrorPolicyNotAttached(t *testing.T) {
+	attached := []*iam.AttachedPolicy{{PolicyArn: aws.String(getExecutionRolePolicyARN("us-west-2"))}}
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole(testImportRoleName).Return(&iam.Role{Arn: aws.String(testImportRoleARN)}, nil)
+	mocks.MockIAM.EXPECT().GetPolicy(testImportPolicyARN).Return(&iam.Policy{Arn: aws.String(testImportPolicyARN)}, nil)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testImportRoleName).Return(attached, nil)
+	mocks.MockIAM.EXPECT().TagRole(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := importResources(testImportRoleName, testImportPolicyARN, DefaultManagementTagKey, DefaultManagementTagValue, "us-west-2", mocks.MockIAM, time.Now())
+	assert.Error(t, err, "Expected error when policy is not attached to the role")
+	assert.Contains(t, err.Error(), "not attached")
+}
+
+func TestImportResources_ErrorRoleNotFound(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole(testImportRoleName).Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "role not found", errors.New("something went wrong")))
+	mocks.MockIAM.EXPECT().GetPolicy(gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().TagRole(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := importResources(testImportRoleName, testImportPolicyARN, DefaultManagementTagKey, DefaultManagementTagValue, "us-west-2", mocks.MockIAM, time.Now())
+	assert.Error(t, err, "Expected error when role does not exist")
+}
@@ -0,0 +1,76 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+)
+
+// instanceProfileResult describes the instance profile wrapping a task execution role
+type instanceProfileResult struct {
+	ARN       string
+	Created   bool
+	RoleAdded bool
+}
+
+// createOrFindInstanceProfile makes sure an instance profile with the same name as the role exists and contains the
+// role, so that running it again for the same role makes no changes. An instance profile can only contain one role,
+// so an existing profile containing a different role is an error.
+func createOrFindInstanceProfile(roleName, path string, client iamClient.Client) (*instanceProfileResult, error) {
+	result := &instanceProfileResult{}
+
+	profile, err := client.CreateInstanceProfile(roleName, path)
+	if err != nil {
+		if !utils.EntityAlreadyExists(err) {
+			return nil, err
+		}
+		if profile, err = client.GetInstanceProfile(roleName); err != nil {
+			return nil, err
+		}
+		log.Infof("Using existing instance profile %s", aws.StringValue(profile.Arn))
+	} else {
+		result.Created = true
+		log.Infof("Created new instance profile %s", aws.StringValue(profile.Arn))
+	}
+	result.ARN = aws.StringValue(profile.Arn)
+
+	if hasInstanceProfileRole(profile, roleName) {
+		return result, nil
+	}
+	if len(profile.Roles) > 0 {
+		return nil, fmt.Errorf("instance profile %s already contains role %s", roleName, aws.StringValue(profile.Roles[0].RoleName))
+	}
+	if err = client.AddRoleToInstanceProfile(roleName, roleName); err != nil {
+		return nil, err
+	}
+	result.RoleAdded = true
+	log.Infof("Added role %s to instance profile %s", roleName, result.ARN)
+
+	return result, nil
+}
+
+func hasInstanceProfileRole(profile *iam.InstanceProfile, roleName string) bool {
+	for _, role := range profile.Roles {
+		if aws.StringValue(role.RoleName) == roleName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testInstanceProfileRoleName = "myTaskExecutionRole"
+	testInstanceProfileARN      = "arn:aws:iam::111111111111:instance-profile/myTaskExecutionRole"
+)
+
+func TestCreateOrFindInstanceProfile_NewProfile(t *testing.T) {
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateInstanceProfile(testInstanceProfileRoleName, "/ecs/").Return(testInstanceProfile(), nil),
+		mocks.MockIAM.EXPECT().AddRoleToInstanceProfile(testInstanceProfileRoleName, testInstanceProfileRoleName).Return(nil),
+	)
+
+	result, err := createOrFindInstanceProfile(testInstanceProfileRoleName, "/ecs/", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error creating instance profile")
+	assert.Equal(t, &instanceProfileResult{ARN: testInstanceProfileARN, Created: true, RoleAdded: true}, result)
+}
+
+func TestCreateOrFindInstanceProfile_ExistingProfileWithRole(t *testing.T) {
+	existingProfile := testInstanceProfile(testInstanceProfileRoleName)
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateInstanceProfile(testInstanceProfileRoleName, "").Return(nil, awserr.New("EntityAlreadyExists", "profile exists", nil)),
+		mocks.MockIAM.EXPECT().GetInstanceProfile(testInstanceProfileRoleName).Return(existingProfile, nil),
+	)
+	mocks.MockIAM.EXPECT().AddRoleToInstanceProfile(gomock.Any(), gomock.Any()).Times(0)
+
+	result, err := createOrFindInstanceProfile(testInstanceProfileRoleName, "", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error finding instance profile")
+	assert.Equal(t, &instanceProfileResult{ARN: testInstanceProfileARN}, result, "Expected no changes to be reported")
+}
+
+func TestCreateOrFindInstanceProfile_ExistingEmptyProfile(t *testing.T) {
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateInstanceProfile(testInstanceProfileRoleName, "").Return(nil, awserr.New("EntityAlreadyExists", "profile exists", nil)),
+		mocks.MockIAM.EXPECT().GetInstanceProfile(testInstanceProfileRoleName).Return(testInstanceProfile(), nil),
+		mocks.MockIAM.EXPECT().AddRoleToInstanceProfile(testInstanceProfileRoleName, testInstanceProfileRoleName).Return(nil),
+	)
+
+	result, err := createOrFindInstanceProfile(testInstanceProfileRoleName, "", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error finding instance profile")
+	assert.Equal(t, &instanceProfileResult{ARN: testInstanceProfileARN, RoleAdded: true}, result)
+}
+
+func TestCreateOrFindInstanceProfile_ErrorOnProfileWithOtherRole(t *testing.T) {
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateInstanceProfile(testInstanceProfileRoleName, "").Return(nil, awserr.New("EntityAlreadyExists", "profile exists", nil)),
+		mocks.MockIAM.EXPECT().GetInstanceProfile(testInstanceProfileRoleName).Return(testInstanceProfile("someOtherRole"), nil),
+	)
+	mocks.MockIAM.EXPECT().AddRoleToInstanceProfile(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := createOrFindInstanceProfile(testInstanceProfileRoleName, "", mocks.MockIAM)
+	assert.Error(t, err, "Expected error when instance profile contains a different role")
+}
+
+func testInstanceProfile(roleNames ...string) *iam.InstanceProfile {
+	profile := &iam.InstanceProfile{
+		Arn:                 aws.String(testInstanceProfileARN),
+		InstanceProfileName: aws.String(testInstanceProfileRoleName),
+	}
+	for _, roleName := range roleNames {
+		profile.Roles = append(profile.Roles, &iam.Role{RoleName: aws.String(roleName)})
+	}
+	return profile
+}
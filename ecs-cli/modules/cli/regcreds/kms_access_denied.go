@@ -0,0 +1,59 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+
+	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	accessDeniedErrorCode          = "AccessDenied"
+	accessDeniedExceptionErrorCode = "AccessDeniedException"
+)
+
+// assumeValidKeyARNClient wraps a KMS client so that key ARNs are trusted as-is when the caller is not allowed to
+// describe the key, instead of failing
+type assumeValidKeyARNClient struct {
+	kmsClient.Client
+}
+
+func newAssumeValidKeyARNClient(client kmsClient.Client) kmsClient.Client {
+	return &assumeValidKeyARNClient{client}
+}
+
+func (c *assumeValidKeyARNClient) GetValidKeyARN(keyID string) (string, error) {
+	keyARN, err := c.Client.GetValidKeyARN(keyID)
+	if err == nil || !isAccessDenied(err) {
+		return keyARN, err
+	}
+	if _, parseErr := arn.Parse(keyID); parseErr != nil {
+		return "", fmt.Errorf("access denied when describing KMS key '%s'; '--%s' requires the key to be given as a full key ARN: %v", keyID, flags.AssumeKMSARNValidFlag, err)
+	}
+	log.Warnf("Access denied when describing KMS key %s; using the ARN as given since '--%s' is set", keyID, flags.AssumeKMSARNValidFlag)
+
+	return keyID, nil
+}
+
+func isAccessDenied(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == accessDeniedExceptionErrorCode || awsErr.Code() == accessDeniedErrorCode
+	}
+	return false
+}
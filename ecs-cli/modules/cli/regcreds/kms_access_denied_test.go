@@ -0,0 +1,69 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssumeValidKeyARNClient_AccessDenied(t *testing.T) {
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return("", awserr.New("AccessDeniedException", "not authorized to perform kms:DescribeKey", nil))
+
+	keyARN, err := newAssumeValidKeyARNClient(mocks.MockKMS).GetValidKeyARN(testKeyARN)
+	assert.NoError(t, err, "Expected access denied error to be ignored for a key ARN")
+	assert.Equal(t, testKeyARN, keyARN)
+}
+
+func TestAssumeValidKeyARNClient_AccessDeniedForKeyAlias(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN("alias/my-key").Return("", awserr.New("AccessDeniedException", "not authorized to perform kms:DescribeKey", nil))
+
+	_, err := newAssumeValidKeyARNClient(mocks.MockKMS).GetValidKeyARN("alias/my-key")
+	assert.Error(t, err, "Expected error when key is not given as an ARN")
+}
+
+func TestAssumeValidKeyARNClient_OtherError(t *testing.T) {
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return("", errors.New("something went wrong"))
+
+	_, err := newAssumeValidKeyARNClient(mocks.MockKMS).GetValidKeyARN(testKeyARN)
+	assert.Error(t, err, "Expected errors other than access denied to be returned")
+}
+
+func TestGenerateSecretsPolicy_AssumeKeyARNValid(t *testing.T) {
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	creds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret", testKeyARN, []string{"web"}),
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return("", awserr.New("AccessDeniedException", "not authorized to perform kms:DescribeKey", nil))
+
+	policyString, err := generateSecretsPolicy(creds, "", newAssumeValidKeyARNClient(mocks.MockKMS))
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, []string{"kms:Decrypt", "secretsmanager:GetSecretValue"}, policyDoc.Statement[0].Action)
+	assert.Contains(t, policyDoc.Statement[0].Resource, testKeyARN)
+}
@@ -0,0 +1,163 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/pkg/errors"
+)
+
+// keyPolicyStatement is a statement of a KMS key policy. Principal and Action may each be a single value or a list.
+type keyPolicyStatement struct {
+	Effect    string
+	Principal json.RawMessage
+	Action    json.RawMessage
+}
+
+// kmsKeyPolicyWarnings checks the key policy of each KMS key used by the registry credentials and returns a warning
+// for each role which the key policy doesn't appear to grant kms:Decrypt, either directly or through its account. The
+// check is best effort: conditions are not evaluated, and keys whose policy can't be read are reported as unchecked.
+func kmsKeyPolicyWarnings(creds map[string]regcredio.CredsOutputEntry, roleARNs []string, client kmsClient.Client) []string {
+	var keyIDs []string
+	seen := make(map[string]bool)
+	for _, entry := range creds {
+		if entry.KMSKeyID != "" && !seen[entry.KMSKeyID] {
+			seen[entry.KMSKeyID] = true
+			keyIDs = append(keyIDs, entry.KMSKeyID)
+		}
+	}
+	sort.Strings(keyIDs)
+
+	var warnings []string
+	for _, keyID := range keyIDs {
+		statements, err := getKeyPolicyStatements(keyID, client)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("Unable to check the key policy of KMS key %s: %v", keyID, err))
+			continue
+		}
+		for _, roleARN := range roleARNs {
+			if !keyPolicyAllowsDecrypt(statements, roleARN) {
+				warnings = append(warnings, fmt.Sprintf("The key policy of KMS key %s does not appear to grant %s to role %s or its account; the role will not be able to decrypt the registry credentials", keyID, kmsDecryptAction, roleARN))
+			}
+		}
+	}
+	return warnings
+}
+
+func getKeyPolicyStatements(keyID string, client kmsClient.Client) ([]keyPolicyStatement, error) {
+	// key policies can't be read through an alias
+	keyARN := keyID
+	if parsedARN, err := arn.Parse(keyID); err != nil || !strings.HasPrefix(parsedARN.Resource, "key/") {
+		keyResult, err := client.DescribeKey(keyID)
+		if err != nil {
+			return nil, err
+		}
+		keyARN = aws.StringValue(keyResult.KeyMetadata.Arn)
+	}
+
+	policy, err := client.GetKeyPolicy(keyARN)
+	if err != nil {
+		return nil, err
+	}
+	document := struct {
+		Statement json.RawMessage
+	}{}
+	if err = json.Unmarshal([]byte(policy), &document); err != nil {
+		return nil, errors.Wrap(err, "failed to parse key policy")
+	}
+	var statements []keyPolicyStatement
+	if err = json.Unmarshal(document.Statement, &statements); err != nil {
+		statement := keyPolicyStatement{}
+		if err = json.Unmarshal(document.Statement, &statement); err != nil {
+			return nil, errors.Wrap(err, "failed to parse key policy statements")
+		}
+		statements = []keyPolicyStatement{statement}
+	}
+	return statements, nil
+}
+
+// keyPolicyAllowsDecrypt reports whether any statement allows kms:Decrypt to everyone, the role's account or the role
+func keyPolicyAllowsDecrypt(statements []keyPolicyStatement, roleARN string) bool {
+	accountID := ""
+	partition := "aws"
+	if parsedARN, err := arn.Parse(roleARN); err == nil {
+		accountID = parsedARN.AccountID
+		partition = parsedARN.Partition
+	}
+	principals := map[string]bool{
+		"*":     true,
+		roleARN: true,
+	}
+	if accountID != "" {
+		principals[accountID] = true
+		principals[fmt.Sprintf("arn:%s:iam::%s:root", partition, accountID)] = true
+	}
+
+	for _, statement := range statements {
+		if statement.Effect != "Allow" || !anyActionMatches(stringOrList(statement.Action), kmsDecryptAction) {
+			continue
+		}
+		for _, principal := range keyPolicyAWSPrincipals(statement.Principal) {
+			if principals[principal] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keyPolicyAWSPrincipals returns the AWS principals of a statement; a principal of "*" is returned as is
+func keyPolicyAWSPrincipals(principal json.RawMessage) []string {
+	var everyone string
+	if err := json.Unmarshal(principal, &everyone); err == nil {
+		return []string{everyone}
+	}
+	principals := map[string]json.RawMessage{}
+	if err := json.Unmarshal(principal, &principals); err != nil {
+		return nil
+	}
+	return stringOrList(principals["AWS"])
+}
+
+func anyActionMatches(actions []string, action string) bool {
+	for _, pattern := range actions {
+		// actions are case insensitive and may use wildcards
+		if matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(action)); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// stringOrList returns the values of a policy element which may be a single string or a list of strings
+func stringOrList(value json.RawMessage) []string {
+	var single string
+	if err := json.Unmarshal(value, &single); err == nil {
+		return []string{single}
+	}
+	var list []string
+	if err := json.Unmarshal(value, &list); err == nil {
+		return list
+	}
+	return nil
+}
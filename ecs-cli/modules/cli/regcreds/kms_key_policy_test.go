@@ -0,0 +1,126 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testKeyPolicyKeyARN  = "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	testKeyPolicyRoleARN = "arn:aws:iam::111111111111:role/myTaskExecutionRole"
+)
+
+func TestKeyPolicyAllowsDecrypt(t *testing.T) {
+	testCases := map[string]struct {
+		policy  string
+		allowed bool
+	}{
+		"account root": {
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"kms:*","Resource":"*"}]}`,
+			allowed: true,
+		},
+		"account ID": {
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":{"AWS":["111111111111"]},"Action":["kms:Decrypt"],"Resource":"*"}]}`,
+			allowed: true,
+		},
+		"role ARN with wildcard action": {
+			policy:  `{"Statement":{"Effect":"Allow","Principal":{"AWS":"` + testKeyPolicyRoleARN + `"},"Action":"kms:Decr*","Resource":"*"}}`,
+			allowed: true,
+		},
+		"everyone": {
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":"*","Action":"kms:decrypt","Resource":"*"}]}`,
+			allowed: true,
+		},
+		"other account": {
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::222222222222:root"},"Action":"kms:*","Resource":"*"}]}`,
+			allowed: false,
+		},
+		"other role": {
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:role/otherRole"},"Action":"kms:Decrypt","Resource":"*"}]}`,
+			allowed: false,
+		},
+		"only encrypt": {
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":["kms:Encrypt","kms:DescribeKey"],"Resource":"*"}]}`,
+			allowed: false,
+		},
+		"deny": {
+			policy:  `{"Statement":[{"Effect":"Deny","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"kms:Decrypt","Resource":"*"}]}`,
+			allowed: false,
+		},
+		"service principal": {
+			policy:  `{"Statement":[{"Effect":"Allow","Principal":{"Service":"secretsmanager.amazonaws.com"},"Action":"kms:Decrypt","Resource":"*"}]}`,
+			allowed: false,
+		},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mocks := setupTestController(t)
+			mocks.MockKMS.EXPECT().GetKeyPolicy(testKeyPolicyKeyARN).Return(testCase.policy, nil)
+
+			statements, err := getKeyPolicyStatements(testKeyPolicyKeyARN, mocks.MockKMS)
+			assert.NoError(t, err, "Unexpected error reading key policy")
+			assert.Equal(t, testCase.allowed, keyPolicyAllowsDecrypt(statements, testKeyPolicyRoleARN))
+		})
+	}
+}
+
+func TestGetKeyPolicyStatements_Alias(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().DescribeKey("alias/registry-creds").Return(&kms.DescribeKeyOutput{KeyMetadata: &kms.KeyMetadata{Arn: aws.String(testKeyPolicyKeyARN)}}, nil)
+	mocks.MockKMS.EXPECT().GetKeyPolicy(testKeyPolicyKeyARN).Return(`{"Statement":[]}`, nil)
+
+	statements, err := getKeyPolicyStatements("alias/registry-creds", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error reading key policy through an alias")
+	assert.Empty(t, statements)
+}
+
+func TestKMSKeyPolicyWarnings(t *testing.T) {
+	otherKeyARN := "arn:aws:kms:us-west-2:111111111111:key/other"
+	creds := map[string]regcredio.CredsOutputEntry{
+		"first.example.com":   regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:first", testKeyPolicyKeyARN, []string{"web"}),
+		"second.example.com":  regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:second", testKeyPolicyKeyARN, []string{"web"}),
+		"third.example.com":   regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:third", otherKeyARN, []string{"web"}),
+		"default.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:default", "", []string{"web"}),
+	}
+
+	mocks := setupTestController(t)
+	// each key is checked once, and secrets without a key are skipped
+	mocks.MockKMS.EXPECT().GetKeyPolicy(testKeyPolicyKeyARN).Return(`{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::222222222222:root"},"Action":"kms:*"}]}`, nil)
+	mocks.MockKMS.EXPECT().GetKeyPolicy(otherKeyARN).Return("", awserr.New("AccessDeniedException", "not authorized to perform kms:GetKeyPolicy", nil))
+
+	warnings := kmsKeyPolicyWarnings(creds, []string{testKeyPolicyRoleARN}, mocks.MockKMS)
+	if assert.Len(t, warnings, 2) {
+		assert.Contains(t, warnings[0], testKeyPolicyKeyARN)
+		assert.Contains(t, warnings[0], testKeyPolicyRoleARN)
+		assert.Contains(t, warnings[1], "Unable to check the key policy of KMS key "+otherKeyARN)
+	}
+}
+
+func TestKMSKeyPolicyWarnings_Allowed(t *testing.T) {
+	creds := map[string]regcredio.CredsOutputEntry{
+		"first.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:first", testKeyPolicyKeyARN, []string{"web"}),
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetKeyPolicy(testKeyPolicyKeyARN).Return(`{"Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111111111111:root"},"Action":"kms:*"}]}`, nil)
+
+	assert.Empty(t, kmsKeyPolicyWarnings(creds, []string{testKeyPolicyRoleARN}, mocks.MockKMS))
+}
@@ -0,0 +1,196 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	// TableOutputFormat prints results as a table
+	TableOutputFormat = "table"
+	// JSONOutputFormat prints all results as a single JSON array
+	JSONOutputFormat = "json"
+	// JSONLinesOutputFormat prints each result as a JSON object on its own line as soon as it is found
+	JSONLinesOutputFormat = "jsonl"
+	// SARIFOutputFormat prints the findings of 'validate' as a SARIF 2.1.0 log, which code scanning tools can import
+	SARIFOutputFormat = "sarif"
+
+	jsonPrefix = ""
+	jsonIndent = "  "
+
+	cellWidthInSpaces         = 20
+	widthBetweenCellsInSpaces = 1
+	cellPaddingInSpaces       = 3
+	paddingCharacter          = ' '
+	noFormatting              = 0
+)
+
+type roleListEntry struct {
+	RoleName   string    `json:"roleName"`
+	RoleARN    string    `json:"roleArn"`
+	CreateDate time.Time `json:"createDate"`
+}
+
+// rolePrinter writes each role as it is found; Flush is called once all roles have been listed
+type rolePrinter interface {
+	Print(entry roleListEntry) error
+	Flush() error
+}
+
+// List prints the task execution roles created by the ecs-cli
+func List(c *cli.Context) {
+	printer, err := newRolePrinter(c.String(flags.Output), os.Stdout)
+	if err != nil {
+		log.Fatal("Error executing 'list': ", err)
+	}
+
+	managementTagKey, managementTagValue, err := parseManagementTag(c.String(flags.ManagementTagFlag))
+	if err != nil {
+		log.Fatal("Error executing 'list': ", err)
+	}
+	if managementTagKey == "" {
+		managementTagKey, managementTagValue = DefaultManagementTagKey, DefaultManagementTagValue
+	}
+
+	commandConfig := getNewCommandConfig(c, "", "")
+	client := iamClient.NewIAMClient(commandConfig)
+
+	if err = listManagedRoles(client, managementTagKey, managementTagValue, printer); err != nil {
+		log.Fatal("Error executing 'list': ", err)
+	}
+}
+
+func newRolePrinter(format string, w io.Writer) (rolePrinter, error) {
+	switch format {
+	case "", TableOutputFormat:
+		return newTableRolePrinter(w), nil
+	case JSONOutputFormat:
+		return &jsonRolePrinter{w: w, entries: []roleListEntry{}}, nil
+	case JSONLinesOutputFormat:
+		return &jsonLinesRolePrinter{encoder: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("invalid value '%s' for '--%s'; valid values are %s, %s and %s", format, flags.Output, TableOutputFormat, JSONOutputFormat, JSONLinesOutputFormat)
+}
+
+// listManagedRoles prints each role with the given management tag as IAM roles are paginated
+func listManagedRoles(client iamClient.Client, tagKey, tagValue string, printer rolePrinter) error {
+	var listErr error
+	err := client.ListRolesPages(func(page *iam.ListRolesOutput, lastPage bool) bool {
+		for _, role := range page.Roles {
+			roleName := aws.StringValue(role.RoleName)
+			tags, err := client.ListRoleTags(roleName)
+			if err != nil {
+				listErr = errors.Wrapf(err, "failed to list tags for role %s", roleName)
+				return false
+			}
+			if !hasTag(tags, tagKey, tagValue) {
+				continue
+			}
+			entry := roleListEntry{
+				RoleName:   roleName,
+				RoleARN:    aws.StringValue(role.Arn),
+				CreateDate: aws.TimeValue(role.CreateDate),
+			}
+			if listErr = printer.Print(entry); listErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if listErr != nil {
+		return listErr
+	}
+
+	return printer.Flush()
+}
+
+func hasTag(tags []*iam.Tag, key, value string) bool {
+	for _, tag := range tags {
+		if aws.StringValue(tag.Key) == key && aws.StringValue(tag.Value) == value {
+			return true
+		}
+	}
+	return false
+}
+
+type tableRolePrinter struct {
+	w *tabwriter.Writer
+}
+
+func newTableRolePrinter(w io.Writer) *tableRolePrinter {
+	tw := new(tabwriter.Writer)
+	tw.Init(w, cellWidthInSpaces, widthBetweenCellsInSpaces, cellPaddingInSpaces, paddingCharacter, noFormatting)
+	fmt.Fprintln(tw, "ROLE NAME\tCREATED\tARN")
+
+	return &tableRolePrinter{w: tw}
+}
+
+func (p *tableRolePrinter) Print(entry roleListEntry) error {
+	_, err := fmt.Fprintf(p.w, "%s\t%s\t%s\n", entry.RoleName, entry.CreateDate.UTC().Format(time.RFC3339), entry.RoleARN)
+	return err
+}
+
+func (p *tableRolePrinter) Flush() error {
+	return p.w.Flush()
+}
+
+// jsonRolePrinter buffers all roles so they can be printed as a single array
+type jsonRolePrinter struct {
+	w       io.Writer
+	entries []roleListEntry
+}
+
+func (p *jsonRolePrinter) Print(entry roleListEntry) error {
+	p.entries = append(p.entries, entry)
+	return nil
+}
+
+func (p *jsonRolePrinter) Flush() error {
+	data, err := json.MarshalIndent(p.entries, jsonPrefix, jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal roles to JSON")
+	}
+	_, err = fmt.Fprintln(p.w, string(data))
+	return err
+}
+
+// jsonLinesRolePrinter writes each role immediately so output can be consumed incrementally
+type jsonLinesRolePrinter struct {
+	encoder *json.Encoder
+}
+
+func (p *jsonLinesRolePrinter) Print(entry roleListEntry) error {
+	return p.encoder.Encode(entry)
+}
+
+func (p *jsonLinesRolePrinter) Flush() error {
+	return nil
+}
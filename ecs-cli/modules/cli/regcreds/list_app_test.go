@@ -0,0 +1,145 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListManagedRoles_JSONLines(t *testing.T) {
+	mocks := setupTestController(t)
+	expectListRoles(mocks, "managedRole1", "unmanagedRole", "managedRole2")
+
+	output := &bytes.Buffer{}
+	printer, err := newRolePrinter(JSONLinesOutputFormat, output)
+	assert.NoError(t, err, "Unexpected error creating printer")
+
+	err = listManagedRoles(mocks.MockIAM, DefaultManagementTagKey, DefaultManagementTagValue, printer)
+	assert.NoError(t, err, "Unexpected error listing roles")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, 2, len(lines), "Expected one line per managed role")
+
+	first := roleListEntry{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "managedRole1", first.RoleName)
+	assert.Equal(t, "arn:aws:iam::111111111111:role/managedRole1", first.RoleARN)
+
+	second := roleListEntry{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "managedRole2", second.RoleName)
+}
+
+func TestListManagedRoles_JSON(t *testing.T) {
+	mocks := setupTestController(t)
+	expectListRoles(mocks, "managedRole1", "unmanagedRole")
+
+	output := &bytes.Buffer{}
+	printer, err := newRolePrinter(JSONOutputFormat, output)
+	assert.NoError(t, err, "Unexpected error creating printer")
+
+	err = listManagedRoles(mocks.MockIAM, DefaultManagementTagKey, DefaultManagementTagValue, printer)
+	assert.NoError(t, err, "Unexpected error listing roles")
+
+	var entries []roleListEntry
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &entries))
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "managedRole1", entries[0].RoleName)
+}
+
+func TestListManagedRoles_JSONNoRoles(t *testing.T) {
+	mocks := setupTestController(t)
+	expectListRoles(mocks, "unmanagedRole")
+
+	output := &bytes.Buffer{}
+	printer, err := newRolePrinter(JSONOutputFormat, output)
+	assert.NoError(t, err, "Unexpected error creating printer")
+
+	err = listManagedRoles(mocks.MockIAM, DefaultManagementTagKey, DefaultManagementTagValue, printer)
+	assert.NoError(t, err, "Unexpected error listing roles")
+	assert.Equal(t, "[]", strings.TrimSpace(output.String()))
+}
+
+func TestListManagedRoles_Table(t *testing.T) {
+	mocks := setupTestController(t)
+	expectListRoles(mocks, "managedRole1")
+
+	output := &bytes.Buffer{}
+	printer, err := newRolePrinter("", output)
+	assert.NoError(t, err, "Unexpected error creating printer")
+
+	err = listManagedRoles(mocks.MockIAM, DefaultManagementTagKey, DefaultManagementTagValue, printer)
+	assert.NoError(t, err, "Unexpected error listing roles")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, 2, len(lines), "Expected header and one row")
+	assert.Contains(t, lines[0], "ROLE NAME")
+	assert.Contains(t, lines[1], "managedRole1")
+}
+
+func TestListManagedRoles_ErrorOnListTags(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListRolesPages(gomock.Any()).Do(func(x interface{}) {
+		fn := x.(func(*iam.ListRolesOutput, bool) bool)
+		fn(&iam.ListRolesOutput{Roles: []*iam.Role{testRole("someRole")}}, true)
+	}).Return(nil)
+	mocks.MockIAM.EXPECT().ListRoleTags("someRole").Return(nil, errors.New("something went wrong"))
+
+	printer, _ := newRolePrinter(JSONLinesOutputFormat, &bytes.Buffer{})
+	err := listManagedRoles(mocks.MockIAM, DefaultManagementTagKey, DefaultManagementTagValue, printer)
+	assert.Error(t, err, "Expected error when listing role tags fails")
+}
+
+func TestNewRolePrinter_ErrorOnInvalidFormat(t *testing.T) {
+	_, err := newRolePrinter("yaml", &bytes.Buffer{})
+	assert.Error(t, err, "Expected error for invalid output format")
+}
+
+// expects a single page per role; roles prefixed with "managed" carry the default management tag
+func expectListRoles(mocks testClients, roleNames ...string) {
+	mocks.MockIAM.EXPECT().ListRolesPages(gomock.Any()).Do(func(x interface{}) {
+		fn := x.(func(*iam.ListRolesOutput, bool) bool)
+		for i, roleName := range roleNames {
+			if !fn(&iam.ListRolesOutput{Roles: []*iam.Role{testRole(roleName)}}, i == len(roleNames)-1) {
+				return
+			}
+		}
+	}).Return(nil)
+
+	for _, roleName := range roleNames {
+		var tags []*iam.Tag
+		if strings.HasPrefix(roleName, "managed") {
+			tags = defaultManagementTags()
+		}
+		mocks.MockIAM.EXPECT().ListRoleTags(roleName).Return(tags, nil)
+	}
+}
+
+func testRole(roleName string) *iam.Role {
+	return &iam.Role{
+		RoleName:   aws.String(roleName),
+		Arn:        aws.String("arn:aws:iam::111111111111:role/" + roleName),
+		CreateDate: aws.Time(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)),
+	}
+}
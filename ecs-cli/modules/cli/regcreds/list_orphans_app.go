@@ -0,0 +1,130 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+type orphanedPolicy struct {
+	PolicyName string
+	PolicyARN  string
+	CreateDate time.Time
+}
+
+// ListOrphans prints the policies generated by the ecs-cli which are not attached to anything, and deletes them if
+// requested
+func ListOrphans(c *cli.Context) {
+	commandConfig := getNewCommandConfig(c, "", "")
+	client := iamClient.NewIAMClient(commandConfig)
+
+	orphans, err := findOrphanedPolicies(client)
+	if err != nil {
+		log.Fatal("Error executing 'list-orphans': ", err)
+	}
+	if len(orphans) == 0 {
+		log.Info("No orphaned policies generated by the ECS CLI were found.")
+		return
+	}
+	if err = printOrphanedPolicies(os.Stdout, orphans); err != nil {
+		log.Fatal("Error executing 'list-orphans': ", err)
+	}
+
+	if c.Bool(flags.DeleteOrphansFlag) {
+		if err = deleteOrphanedPolicies(orphans, client); err != nil {
+			log.Fatal("Error executing 'list-orphans': ", err)
+		}
+	}
+}
+
+// findOrphanedPolicies returns the customer managed policies with the name of a policy generated by the ecs-cli which
+// are not attached to any user, group or role
+func findOrphanedPolicies(client iamClient.Client) ([]orphanedPolicy, error) {
+	var orphans []orphanedPolicy
+	var listErr error
+	err := client.ListPoliciesPages(func(page *iam.ListPoliciesOutput, lastPage bool) bool {
+		for _, policy := range page.Policies {
+			policyName := aws.StringValue(policy.PolicyName)
+			// the attachment count is only used to skip policies which are clearly in use
+			if !isGeneratedPolicyName(policyName) || aws.Int64Value(policy.AttachmentCount) > 0 {
+				continue
+			}
+			policyARN := aws.StringValue(policy.Arn)
+			entities, err := client.ListEntitiesForPolicy(policyARN)
+			if err != nil {
+				listErr = errors.Wrapf(err, "failed to list entities for policy %s", policyARN)
+				return false
+			}
+			if len(entities.PolicyGroups) > 0 || len(entities.PolicyUsers) > 0 || len(entities.PolicyRoles) > 0 {
+				continue
+			}
+			orphans = append(orphans, orphanedPolicy{
+				PolicyName: policyName,
+				PolicyARN:  policyARN,
+				CreateDate: aws.TimeValue(policy.CreateDate),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orphans, listErr
+}
+
+func printOrphanedPolicies(w io.Writer, orphans []orphanedPolicy) error {
+	tw := new(tabwriter.Writer)
+	tw.Init(w, cellWidthInSpaces, widthBetweenCellsInSpaces, cellPaddingInSpaces, paddingCharacter, noFormatting)
+	fmt.Fprintln(tw, "POLICY NAME\tCREATED\tARN")
+	for _, orphan := range orphans {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", orphan.PolicyName, orphan.CreateDate.UTC().Format(time.RFC3339), orphan.PolicyARN)
+	}
+	return tw.Flush()
+}
+
+// deleteOrphanedPolicies deletes each policy, keeping any which have been attached since they were listed. An error
+// is returned if any policy could not be deleted.
+func deleteOrphanedPolicies(orphans []orphanedPolicy, client iamClient.Client) error {
+	failed := 0
+	for _, orphan := range orphans {
+		err := client.DeletePolicy(orphan.PolicyARN)
+		if err == nil {
+			log.Infof("Deleted policy %s", orphan.PolicyARN)
+			continue
+		}
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeDeleteConflictException {
+			log.Infof("Keeping policy %s which has been attached since it was listed", orphan.PolicyARN)
+			continue
+		}
+		log.Errorf("Failed to delete policy %s: %v", orphan.PolicyARN, err)
+		failed++
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d orphaned policies", failed, len(orphans))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testOrphanPolicyName   = "amazon-ecs-cli-setup-myRole-policy-20190601T000000Z"
+	testAttachedPolicyName = "amazon-ecs-cli-setup-myRole-policy-20190602T000000Z"
+	testDetachedPolicyName = "amazon-ecs-cli-setup-otherRole-policy-20190603T000000Z"
+)
+
+func testPolicy(policyName string, attachmentCount int64) *iam.Policy {
+	return &iam.Policy{
+		PolicyName:      aws.String(policyName),
+		Arn:             aws.String("arn:aws:iam::111111111111:policy/" + policyName),
+		AttachmentCount: aws.Int64(attachmentCount),
+		CreateDate:      aws.Time(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)),
+	}
+}
+
+func expectListPolicies(mocks testClients, pages ...[]*iam.Policy) {
+	mocks.MockIAM.EXPECT().ListPoliciesPages(gomock.Any()).Do(func(x interface{}) {
+		fn := x.(func(*iam.ListPoliciesOutput, bool) bool)
+		for i, policies := range pages {
+			if !fn(&iam.ListPoliciesOutput{Policies: policies}, i == len(pages)-1) {
+				return
+			}
+		}
+	}).Return(nil)
+}
+
+func TestFindOrphanedPolicies(t *testing.T) {
+	mocks := setupTestController(t)
+	expectListPolicies(mocks,
+		[]*iam.Policy{testPolicy(testOrphanPolicyName, 0), testPolicy("MyOwnPolicy", 0)},
+		[]*iam.Policy{testPolicy(testAttachedPolicyName, 1), testPolicy(testDetachedPolicyName, 0)},
+	)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListEntitiesForPolicy("arn:aws:iam::111111111111:policy/"+testOrphanPolicyName).Return(&iam.ListEntitiesForPolicyOutput{}, nil),
+		// the attachment count may lag behind, so the entities are always checked
+		mocks.MockIAM.EXPECT().ListEntitiesForPolicy("arn:aws:iam::111111111111:policy/"+testDetachedPolicyName).Return(&iam.ListEntitiesForPolicyOutput{
+			PolicyRoles: []*iam.PolicyRole{{RoleName: aws.String("otherRole")}},
+		}, nil),
+	)
+
+	orphans, err := findOrphanedPolicies(mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error finding orphaned policies")
+	assert.Equal(t, 1, len(orphans), "Expected only the unattached generated policy")
+	assert.Equal(t, testOrphanPolicyName, orphans[0].PolicyName)
+	assert.Equal(t, "arn:aws:iam::111111111111:policy/"+testOrphanPolicyName, orphans[0].PolicyARN)
+
+	output := &bytes.Buffer{}
+	assert.NoError(t, printOrphanedPolicies(output, orphans))
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, 2, len(lines), "Expected a header and one row")
+	assert.Contains(t, lines[1], "2019-06-01T00:00:00Z")
+}
+
+func TestFindOrphanedPolicies_ErrorOnListEntities(t *testing.T) {
+	mocks := setupTestController(t)
+	expectListPolicies(mocks, []*iam.Policy{testPolicy(testOrphanPolicyName, 0), testPolicy(testDetachedPolicyName, 0)})
+	mocks.MockIAM.EXPECT().ListEntitiesForPolicy(gomock.Any()).Return(nil, errors.New("something went wrong")).Times(1)
+
+	_, err := findOrphanedPolicies(mocks.MockIAM)
+	assert.Error(t, err, "Expected error when entities for a policy can't be listed")
+}
+
+func TestDeleteOrphanedPolicies(t *testing.T) {
+	orphans := []orphanedPolicy{
+		{PolicyName: testOrphanPolicyName, PolicyARN: "arn:aws:iam::111111111111:policy/" + testOrphanPolicyName},
+		{PolicyName: testDetachedPolicyName, PolicyARN: "arn:aws:iam::111111111111:policy/" + testDetachedPolicyName},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().DeletePolicy(orphans[0].PolicyARN).Return(nil),
+		mocks.MockIAM.EXPECT().DeletePolicy(orphans[1].PolicyARN).Return(awserr.New(iam.ErrCodeDeleteConflictException, "policy is attached", nil)),
+	)
+
+	err := deleteOrphanedPolicies(orphans, mocks.MockIAM)
+	assert.NoError(t, err, "Expected policies attached since listing to be kept without error")
+}
+
+func TestDeleteOrphanedPolicies_ErrorOnDeleteFailure(t *testing.T) {
+	orphans := []orphanedPolicy{
+		{PolicyName: testOrphanPolicyName, PolicyARN: "arn:aws:iam::111111111111:policy/" + testOrphanPolicyName},
+		{PolicyName: testDetachedPolicyName, PolicyARN: "arn:aws:iam::111111111111:policy/" + testDetachedPolicyName},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().DeletePolicy(orphans[0].PolicyARN).Return(errors.New("something went wrong")),
+		mocks.MockIAM.EXPECT().DeletePolicy(orphans[1].PolicyARN).Return(nil),
+	)
+
+	err := deleteOrphanedPolicies(orphans, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when a policy could not be deleted")
+	assert.Contains(t, err.Error(), "1 of 2")
+}
@@ -0,0 +1,78 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	log "github.com/sirupsen/logrus"
+)
+
+// Destinations of the log output of 'registry-creds up'
+const (
+	LogOutputStderr = "stderr"
+	LogOutputStdout = "stdout"
+	// LogOutputSplit writes info and debug logs to stdout, and warnings and errors to stderr
+	LogOutputSplit = "split"
+)
+
+// setLogOutput routes the output of the logger to stderr (the default, if no mode is given), stdout, or both, split
+// by level. Logs can only be written to stdout if no results are printed there, so that they can still be parsed.
+func setLogOutput(logger *log.Logger, mode string, printsResults bool, stdout, stderr io.Writer) error {
+	switch mode {
+	case "", LogOutputStderr:
+		logger.Out = stderr
+		return nil
+	case LogOutputStdout, LogOutputSplit:
+	default:
+		return fmt.Errorf("invalid value '%s' for '--%s'; valid values are '%s', '%s' and '%s'", mode, flags.LogOutputFlag, LogOutputStderr, LogOutputStdout, LogOutputSplit)
+	}
+	if printsResults {
+		return fmt.Errorf("'--%s %s' can't be used with '--%s', '--%s', '--%s', '--%s', '--%s' or '--%s %s', which print results to stdout", flags.LogOutputFlag, mode, flags.FormatFlag, flags.SummaryOnlyFlag, flags.PrintARNOnlyFlag, flags.PrintPolicyFlag, flags.PrintStepsFlag, flags.EmitTerraformImportFlag, terraformImportStdout)
+	}
+	if mode == LogOutputStdout {
+		logger.Out = stdout
+		return nil
+	}
+	// each entry is written by the hook instead
+	logger.Out = ioutil.Discard
+	logger.Hooks.Add(&splitLogHook{stdout: stdout, stderr: stderr})
+	return nil
+}
+
+// splitLogHook writes each log entry to stdout or stderr, depending on its level
+type splitLogHook struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (hook *splitLogHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (hook *splitLogHook) Fire(entry *log.Entry) error {
+	line, err := entry.String()
+	if err != nil {
+		return err
+	}
+	out := hook.stdout
+	if entry.Level <= log.WarnLevel {
+		out = hook.stderr
+	}
+	_, err = io.WriteString(out, line)
+	return err
+}
@@ -0,0 +1,62 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLogOutput(t *testing.T) {
+	for _, mode := range []string{"", LogOutputStderr, LogOutputStdout} {
+		t.Run("Log output '"+mode+"'", func(t *testing.T) {
+			logger := log.New()
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			assert.NoError(t, setLogOutput(logger, mode, false, stdout, stderr))
+			logger.Warn("a warning")
+
+			if mode == LogOutputStdout {
+				assert.Contains(t, stdout.String(), "a warning")
+				assert.Empty(t, stderr.String())
+			} else {
+				assert.Contains(t, stderr.String(), "a warning")
+				assert.Empty(t, stdout.String())
+			}
+		})
+	}
+}
+
+func TestSetLogOutput_Split(t *testing.T) {
+	logger := log.New()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.NoError(t, setLogOutput(logger, LogOutputSplit, false, stdout, stderr))
+	logger.Info("an info message")
+	logger.Error("an error message")
+
+	assert.Contains(t, stdout.String(), "an info message")
+	assert.NotContains(t, stdout.String(), "an error message")
+	assert.Contains(t, stderr.String(), "an error message")
+	assert.NotContains(t, stderr.String(), "an info message")
+}
+
+func TestSetLogOutput_Errors(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	assert.Error(t, setLogOutput(log.New(), "stdio", false, stdout, stderr), "Expected error for an invalid mode")
+	assert.Error(t, setLogOutput(log.New(), LogOutputStdout, true, stdout, stderr), "Expected error when results are printed to stdout")
+	assert.Error(t, setLogOutput(log.New(), LogOutputSplit, true, stdout, stderr), "Expected error when results are printed to stdout")
+	assert.NoError(t, setLogOutput(log.New(), LogOutputStderr, true, stdout, stderr))
+}
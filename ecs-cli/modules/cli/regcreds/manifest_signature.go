@@ -0,0 +1,139 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"strings"
+
+	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// manifestSigner signs manifests with an asymmetric KMS key, using the first signing algorithm the key supports
+type manifestSigner struct {
+	keyARN    string
+	algorithm string
+	client    kmsClient.Client
+}
+
+// newManifestSigner checks that the key can sign before any resources are created, so that 'up' does not create
+// resources whose manifest it then cannot sign
+func newManifestSigner(keyID string, client kmsClient.Client) (*manifestSigner, error) {
+	output, err := client.DescribeKey(keyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to describe manifest signing key %s", keyID)
+	}
+	metadata := output.KeyMetadata
+	if aws.StringValue(metadata.KeyUsage) != kms.KeyUsageTypeSignVerify {
+		return nil, fmt.Errorf("manifest signing key %s must be an asymmetric key with key usage %s, not %s", keyID, kms.KeyUsageTypeSignVerify, aws.StringValue(metadata.KeyUsage))
+	}
+	for _, algorithm := range aws.StringValueSlice(metadata.SigningAlgorithms) {
+		if _, err := manifestDigest(algorithm, nil); err == nil {
+			return &manifestSigner{
+				keyARN:    aws.StringValue(metadata.Arn),
+				algorithm: algorithm,
+				client:    client,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("manifest signing key %s does not support a known signing algorithm", keyID)
+}
+
+// sign writes the detached signature of the manifest file next to it
+func (s *manifestSigner) sign(store regcredio.Store, manifestFile string) error {
+	rawManifest, err := store.ReadFile(manifestFile)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read manifest %s to sign it", manifestFile)
+	}
+	digest, err := manifestDigest(s.algorithm, rawManifest)
+	if err != nil {
+		return err
+	}
+	signature, err := s.client.Sign(s.keyARN, digest, s.algorithm)
+	if err != nil {
+		return errors.Wrapf(err, "unable to sign manifest %s with key %s", manifestFile, s.keyARN)
+	}
+
+	return regcredio.WriteManifestSignatureTo(store, regcredio.ManifestSignature{
+		KeyID:            s.keyARN,
+		SigningAlgorithm: s.algorithm,
+		Signature:        signature,
+	}, regcredio.ManifestSignatureFile(manifestFile))
+}
+
+// readVerifiedManifest checks the detached signature of the manifest with the given key before parsing it. The key
+// is always the one given by the caller; the key named in the signature file is only used in error messages, so that
+// a replaced manifest cannot be accepted by also replacing its signature.
+func readVerifiedManifest(store regcredio.Store, manifestFile, keyID string, client kmsClient.Client) (*regcredio.ECSRegCredsManifest, error) {
+	rawManifest, err := store.ReadFile(manifestFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading file '%v'", manifestFile)
+	}
+	signatureFile := regcredio.ManifestSignatureFile(manifestFile)
+	signature, err := regcredio.ReadManifestSignatureFrom(store, signatureFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the signature of manifest %s", manifestFile)
+	}
+	digest, err := manifestDigest(signature.SigningAlgorithm, rawManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := client.Verify(keyID, digest, signature.Signature, signature.SigningAlgorithm)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to verify the signature of manifest %s with key %s", manifestFile, keyID)
+	}
+	if !valid {
+		return nil, fmt.Errorf("the signature in %s (signed by %s) is not valid for manifest %s and key %s; the manifest may have been modified, so it will not be used", signatureFile, signature.KeyID, manifestFile, keyID)
+	}
+	log.Infof("Verified the signature of manifest %s with key %s", manifestFile, keyID)
+
+	return regcredio.ParseManifest(rawManifest, manifestFile)
+}
+
+// signingKeyClient returns a KMS client for the region of the signing key if it is given as an ARN, since the key may
+// be in a different region than the resources; otherwise the default client is used
+func signingKeyClient(c *cli.Context, keyID string, defaultClient kmsClient.Client) kmsClient.Client {
+	keyARN, err := arn.Parse(keyID)
+	if err != nil || keyARN.Region == "" {
+		return defaultClient
+	}
+	return kmsClient.NewKMSClient(getNewCommandConfig(c, keyARN.Region, regionSourceSigningKey))
+}
+
+// manifestDigest hashes the manifest with the hash function of the signing algorithm, since KMS is given only the
+// digest and manifests may be larger than the message size KMS accepts
+func manifestDigest(algorithm string, rawManifest []byte) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(algorithm, "_SHA_256"):
+		digest := sha256.Sum256(rawManifest)
+		return digest[:], nil
+	case strings.HasSuffix(algorithm, "_SHA_384"):
+		digest := sha512.Sum384(rawManifest)
+		return digest[:], nil
+	case strings.HasSuffix(algorithm, "_SHA_512"):
+		digest := sha512.Sum512(rawManifest)
+		return digest[:], nil
+	}
+	return nil, fmt.Errorf("unsupported manifest signing algorithm '%s'", algorithm)
+}
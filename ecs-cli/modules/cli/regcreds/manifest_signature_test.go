@@ -0,0 +1,122 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSigningKeyARN = "arn:aws:kms:us-west-2:111111111111:key/signing"
+
+func signingKeyOutput(keyUsage string, algorithms ...string) *kms.DescribeKeyOutput {
+	return &kms.DescribeKeyOutput{
+		KeyMetadata: &kms.KeyMetadata{
+			Arn:               aws.String(testSigningKeyARN),
+			KeyUsage:          aws.String(keyUsage),
+			SigningAlgorithms: aws.StringSlice(algorithms),
+		},
+	}
+}
+
+func TestNewManifestSigner(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().DescribeKey("alias/signing").Return(signingKeyOutput(kms.KeyUsageTypeSignVerify, kms.SigningAlgorithmSpecEcdsaSha384), nil)
+
+	signer, err := newManifestSigner("alias/signing", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error creating manifest signer")
+	assert.Equal(t, testSigningKeyARN, signer.keyARN)
+	assert.Equal(t, kms.SigningAlgorithmSpecEcdsaSha384, signer.algorithm)
+}
+
+func TestNewManifestSigner_ErrorForEncryptionKey(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().DescribeKey("alias/data").Return(signingKeyOutput(kms.KeyUsageTypeEncryptDecrypt), nil)
+
+	_, err := newManifestSigner("alias/data", mocks.MockKMS)
+	assert.Error(t, err, "Expected error for a key which cannot sign")
+}
+
+func TestSignAndVerifyManifest(t *testing.T) {
+	mocks := setupTestController(t)
+	store := regcredio.NewMemoryStore(map[string][]byte{
+		"manifest.json": []byte(`{"version": "2", "region": "us-west-2", "roles": [], "secrets": []}`),
+	})
+	signer := &manifestSigner{keyARN: testSigningKeyARN, algorithm: kms.SigningAlgorithmSpecEcdsaSha256, client: mocks.MockKMS}
+
+	var signedDigest []byte
+	mocks.MockKMS.EXPECT().Sign(testSigningKeyARN, gomock.Any(), kms.SigningAlgorithmSpecEcdsaSha256).Do(func(_ string, digest []byte, _ string) {
+		signedDigest = digest
+	}).Return([]byte("signature"), nil)
+	assert.NoError(t, signer.sign(store, "manifest.json"), "Unexpected error signing manifest")
+	assert.Len(t, signedDigest, 32, "Expected a SHA-256 digest to be signed")
+
+	mocks.MockKMS.EXPECT().Verify("alias/signing", signedDigest, []byte("signature"), kms.SigningAlgorithmSpecEcdsaSha256).Return(true, nil)
+	manifest, err := readVerifiedManifest(store, "manifest.json", "alias/signing", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error verifying manifest")
+	assert.Equal(t, "us-west-2", manifest.Region)
+}
+
+func TestSignAndVerifyManifest_Compressed(t *testing.T) {
+	mocks := setupTestController(t)
+	store := regcredio.NewMemoryStore(nil)
+	assert.NoError(t, regcredio.WriteManifestTo(store, regcredio.ECSRegCredsManifest{Region: "us-west-2"}, "manifest.json.gz"))
+	signer := &manifestSigner{keyARN: testSigningKeyARN, algorithm: kms.SigningAlgorithmSpecEcdsaSha256, client: mocks.MockKMS}
+
+	// the compressed file is signed as written
+	mocks.MockKMS.EXPECT().Sign(testSigningKeyARN, gomock.Any(), kms.SigningAlgorithmSpecEcdsaSha256).Return([]byte("signature"), nil)
+	assert.NoError(t, signer.sign(store, "manifest.json.gz"), "Unexpected error signing compressed manifest")
+	assert.Contains(t, store.FileNames(), "manifest.json.gz.sig")
+
+	mocks.MockKMS.EXPECT().Verify(testSigningKeyARN, gomock.Any(), []byte("signature"), kms.SigningAlgorithmSpecEcdsaSha256).Return(true, nil)
+	manifest, err := readVerifiedManifest(store, "manifest.json.gz", testSigningKeyARN, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error verifying compressed manifest")
+	assert.Equal(t, "us-west-2", manifest.Region)
+}
+
+func TestReadVerifiedManifest_ErrorForModifiedManifest(t *testing.T) {
+	mocks := setupTestController(t)
+	store := regcredio.NewMemoryStore(map[string][]byte{
+		"manifest.json": []byte(`{"version": "2", "region": "us-east-1", "roles": [], "secrets": []}`),
+	})
+	assert.NoError(t, regcredio.WriteManifestSignatureTo(store, regcredio.ManifestSignature{
+		KeyID:            testSigningKeyARN,
+		SigningAlgorithm: kms.SigningAlgorithmSpecEcdsaSha256,
+		Signature:        []byte("signature"),
+	}, "manifest.json.sig"))
+
+	mocks.MockKMS.EXPECT().Verify(testSigningKeyARN, gomock.Any(), []byte("signature"), kms.SigningAlgorithmSpecEcdsaSha256).Return(false, nil)
+	_, err := readVerifiedManifest(store, "manifest.json", testSigningKeyARN, mocks.MockKMS)
+	assert.Error(t, err, "Expected error for a manifest which does not match its signature")
+}
+
+func TestReadVerifiedManifest_ErrorWithoutSignature(t *testing.T) {
+	mocks := setupTestController(t)
+	store := regcredio.NewMemoryStore(map[string][]byte{
+		"manifest.json": []byte(`{"version": "2", "region": "us-west-2", "roles": [], "secrets": []}`),
+	})
+
+	_, err := readVerifiedManifest(store, "manifest.json", testSigningKeyARN, mocks.MockKMS)
+	assert.Error(t, err, "Expected error for a manifest without a signature")
+}
+
+func TestManifestDigest_ErrorForUnknownAlgorithm(t *testing.T) {
+	_, err := manifestDigest("SM2DSA", []byte("{}"))
+	assert.Error(t, err, "Expected error for an unknown signing algorithm")
+}
@@ -0,0 +1,131 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Counters incremented by CreateTaskExecutionRole
+const (
+	MetricRolesCreated      = "regcreds_roles_created_total"
+	MetricPoliciesCreated   = "regcreds_policies_created_total"
+	MetricPolicyAttachments = "regcreds_policy_attachments_total"
+	// MetricFailures is labelled with the category of the step which failed
+	MetricFailures = "regcreds_failures_total"
+
+	metricCategoryLabel = "category"
+)
+
+// Failure categories used for the MetricFailures counter
+const (
+	FailureCategoryRole           = "role"
+	FailureCategoryPolicyDocument = "policy_document"
+	FailureCategoryPolicy         = "policy"
+	FailureCategoryAttachment     = "attachment"
+	// FailureCategoryInstanceProfile is only used if an instance profile is requested
+	FailureCategoryInstanceProfile = "instance_profile"
+	// FailureCategoryPolicyLimit is used if an existing role has no room for the new policies
+	FailureCategoryPolicyLimit = "policy_limit"
+)
+
+// MetricsRecorder receives the counters incremented as resources are created, e.g. so that a long-running service
+// embedding the create flow can expose them. Implementations must be safe for concurrent use.
+type MetricsRecorder interface {
+	AddCounter(name string, labels map[string]string, value float64)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) AddCounter(name string, labels map[string]string, value float64) {}
+
+// CounterRegistry is an in-memory MetricsRecorder which can write its counters in the Prometheus text format
+type CounterRegistry struct {
+	mu       sync.Mutex
+	counters map[string]float64
+}
+
+// NewCounterRegistry creates an empty CounterRegistry
+func NewCounterRegistry() *CounterRegistry {
+	return &CounterRegistry{
+		counters: make(map[string]float64),
+	}
+}
+
+// AddCounter adds the value to the counter with the given name and labels
+func (r *CounterRegistry) AddCounter(name string, labels map[string]string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters[formatSeries(name, labels)] += value
+}
+
+// Value returns the current value of the counter with the given name and labels
+func (r *CounterRegistry) Value(name string, labels map[string]string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counters[formatSeries(name, labels)]
+}
+
+// WritePrometheus writes all counters in the Prometheus text exposition format
+func (r *CounterRegistry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	series := make([]string, 0, len(r.counters))
+	for s := range r.counters {
+		series = append(series, s)
+	}
+	sort.Strings(series)
+
+	lastName := ""
+	for _, s := range series {
+		name := strings.SplitN(s, "{", 2)[0]
+		if name != lastName {
+			if _, err := fmt.Fprintf(w, "# TYPE %s counter\n", name); err != nil {
+				return err
+			}
+			lastName = name
+		}
+		if _, err := fmt.Fprintf(w, "%s %g\n", s, r.counters[s]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatSeries returns the series name in the Prometheus format, e.g. name{key="value"}, with labels sorted by key
+func formatSeries(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", key, labels[key]))
+	}
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+func recordFailure(metrics MetricsRecorder, category string) {
+	metrics.AddCounter(MetricFailures, map[string]string{metricCategoryLabel: category}, 1)
+}
@@ -0,0 +1,98 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterRegistry_WritePrometheus(t *testing.T) {
+	registry := NewCounterRegistry()
+	registry.AddCounter(MetricRolesCreated, nil, 1)
+	registry.AddCounter(MetricRolesCreated, nil, 1)
+	registry.AddCounter(MetricFailures, map[string]string{"category": "role"}, 1)
+	registry.AddCounter(MetricFailures, map[string]string{"category": "attachment"}, 1)
+
+	output := &bytes.Buffer{}
+	err := registry.WritePrometheus(output)
+	assert.NoError(t, err, "Unexpected error writing metrics")
+	assert.Equal(t, `# TYPE regcreds_failures_total counter
+regcreds_failures_total{category="attachment"} 1
+regcreds_failures_total{category="role"} 1
+# TYPE regcreds_roles_created_total counter
+regcreds_roles_created_total 2
+`, output.String())
+}
+
+func TestCreateTaskExecutionRole_RecordsMetrics(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::role/"+testRoleName, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(gomock.Any(), testRoleName).Return(nil, nil).Times(2),
+	)
+
+	registry := NewCounterRegistry()
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"web"}),
+		},
+		RoleName: testRoleName,
+		Region:   "us-west-2",
+		Metrics:  registry,
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating task execution role")
+	assert.Equal(t, float64(1), registry.Value(MetricRolesCreated, nil))
+	assert.Equal(t, float64(1), registry.Value(MetricPoliciesCreated, nil))
+	assert.Equal(t, float64(2), registry.Value(MetricPolicyAttachments, nil))
+	assert.Equal(t, float64(0), registry.Value(MetricFailures, map[string]string{"category": FailureCategoryAttachment}))
+}
+
+func TestCreateTaskExecutionRole_RecordsFailureMetric(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, errors.New("something went wrong")),
+	)
+
+	registry := NewCounterRegistry()
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"web"}),
+		},
+		RoleName: testRoleName,
+		Region:   "us-west-2",
+		Metrics:  registry,
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when creating policy fails")
+	assert.Equal(t, float64(0), registry.Value(MetricRolesCreated, nil), "Expected existing role not to be counted as created")
+	assert.Equal(t, float64(1), registry.Value(MetricFailures, map[string]string{"category": FailureCategoryPolicy}))
+}
@@ -0,0 +1,134 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/aws-sdk-go/aws/arn"
+)
+
+const minimalLogsSid = "CloudWatchLogs"
+
+// DefaultMinimalManagedActions are the actions of the AWS managed task execution role policy, which the new policy
+// grants instead of attaching the managed policy when MinimalManaged is set
+var DefaultMinimalManagedActions = []string{
+	ecrGetAuthorizationTokenAction,
+	"ecr:BatchCheckLayerAvailability",
+	"ecr:GetDownloadUrlForLayer",
+	"ecr:BatchGetImage",
+	"logs:CreateLogStream",
+	"logs:PutLogEvents",
+}
+
+// only the ECR and CloudWatch Logs actions of the managed policy can be replaced
+var minimalManagedActionPattern = regexp.MustCompile(`^(ecr|logs):[A-Za-z*]+$`)
+
+// validateMinimalManagedActions checks that each action is an ECR or CloudWatch Logs action
+func validateMinimalManagedActions(actions []string) error {
+	for _, action := range actions {
+		if !minimalManagedActionPattern.MatchString(action) {
+			return fmt.Errorf("invalid action '%s' for '--%s'; only 'ecr:' and 'logs:' actions can be specified", action, flags.MinimalManagedActionsFlag)
+		}
+	}
+	return nil
+}
+
+// minimalManagedActions returns the actions granted instead of the managed policy, which are the defaults if none are
+// given
+func minimalManagedActions(actions []string) []string {
+	if len(actions) == 0 {
+		return DefaultMinimalManagedActions
+	}
+	return actions
+}
+
+// appendMinimalManagedStatements adds the statements granting the actions of the managed policy to the policy
+// statements. ecr:GetAuthorizationToken can't be scoped and is granted on all resources, other ECR actions on all
+// repositories, and CloudWatch Logs actions on the log groups in the region. If ECR pull access is already granted
+// (with IncludeECR), those actions are left out, and any other ECR actions are granted on the same repositories.
+func appendMinimalManagedStatements(statements []StatementEntry, actions []string, region string, includeECR bool, ecrRepositories []string) []StatementEntry {
+	usedSids := make(map[string]bool, len(statements))
+	for _, statement := range statements {
+		usedSids[statement.Sid] = true
+	}
+	granted := make(map[string]bool)
+	if includeECR {
+		granted[ecrGetAuthorizationTokenAction] = true
+		for _, action := range ecrPullActions {
+			granted[action] = true
+		}
+	}
+
+	var authActions, ecrActions, logsActions []string
+	for _, action := range minimalManagedActions(actions) {
+		if granted[action] {
+			continue
+		}
+		granted[action] = true
+		switch {
+		case action == ecrGetAuthorizationTokenAction:
+			authActions = append(authActions, action)
+		case strings.HasPrefix(action, "ecr:"):
+			ecrActions = append(ecrActions, action)
+		default:
+			logsActions = append(logsActions, action)
+		}
+	}
+
+	if len(authActions) > 0 {
+		statements = append(statements, StatementEntry{
+			Sid:      uniqueSid(ecrAuthorizationSid, usedSids),
+			Effect:   "Allow",
+			Action:   authActions,
+			Resource: []string{"*"},
+		})
+	}
+	if len(ecrActions) > 0 {
+		repositories := []string{"*"}
+		if includeECR {
+			repositories = ecrPullRepositories(ecrRepositories)
+		}
+		statements = append(statements, StatementEntry{
+			Sid:      uniqueSid(ecrPullSid, usedSids),
+			Effect:   "Allow",
+			Action:   ecrActions,
+			Resource: repositories,
+		})
+	}
+	if len(logsActions) > 0 {
+		statements = append(statements, StatementEntry{
+			Sid:      uniqueSid(minimalLogsSid, usedSids),
+			Effect:   "Allow",
+			Action:   logsActions,
+			Resource: []string{logGroupsResource(region)},
+		})
+	}
+	return statements
+}
+
+// logGroupsResource returns the ARN matching all log groups and log streams in the region
+func logGroupsResource(region string) string {
+	return arn.ARN{
+		Partition: utils.GetPartition(region),
+		Service:   "logs",
+		Region:    region,
+		AccountID: "*",
+		Resource:  "log-group:*",
+	}.String()
+}
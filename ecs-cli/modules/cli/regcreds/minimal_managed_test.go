@@ -0,0 +1,94 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendMinimalManagedStatements_Defaults(t *testing.T) {
+	statements := appendMinimalManagedStatements(nil, nil, "us-west-2", false, nil)
+	require.Len(t, statements, 3)
+	assert.Equal(t, StatementEntry{Sid: ecrAuthorizationSid, Effect: "Allow", Action: []string{ecrGetAuthorizationTokenAction}, Resource: []string{"*"}}, statements[0])
+	assert.Equal(t, StatementEntry{Sid: ecrPullSid, Effect: "Allow", Action: ecrPullActions, Resource: []string{"*"}}, statements[1])
+	assert.Equal(t, StatementEntry{Sid: minimalLogsSid, Effect: "Allow", Action: []string{"logs:CreateLogStream", "logs:PutLogEvents"}, Resource: []string{"arn:aws:logs:us-west-2:*:log-group:*"}}, statements[2])
+}
+
+func TestAppendMinimalManagedStatements_Overridden(t *testing.T) {
+	statements := appendMinimalManagedStatements(nil, []string{"logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"}, "cn-north-1", false, nil)
+	require.Len(t, statements, 1)
+	assert.Equal(t, []string{"logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"}, statements[0].Action)
+	assert.Equal(t, []string{"arn:aws-cn:logs:cn-north-1:*:log-group:*"}, statements[0].Resource)
+}
+
+func TestAppendMinimalManagedStatements_IncludeECR(t *testing.T) {
+	repositories := []string{"arn:aws:ecr:us-west-2:111111111111:repository/nginx"}
+	existing := appendECRPullStatements(nil, repositories)
+
+	actions := append([]string{"ecr:DescribeImages"}, DefaultMinimalManagedActions...)
+	statements := appendMinimalManagedStatements(existing, actions, "us-west-2", true, repositories)
+	require.Len(t, statements, 4, "Expected the ECR actions already granted to be left out")
+	assert.Equal(t, StatementEntry{Sid: ecrPullSid + "2", Effect: "Allow", Action: []string{"ecr:DescribeImages"}, Resource: repositories}, statements[2])
+	assert.Equal(t, minimalLogsSid, statements[3].Sid)
+}
+
+func TestValidateMinimalManagedActions(t *testing.T) {
+	assert.NoError(t, validateMinimalManagedActions(DefaultMinimalManagedActions))
+	assert.NoError(t, validateMinimalManagedActions([]string{"logs:*"}))
+	for _, action := range []string{"secretsmanager:GetSecretValue", "ecr", "ecr:", "logs:Put Log Events"} {
+		assert.Error(t, validateMinimalManagedActions([]string{action}), "Expected error for action %s", action)
+	}
+}
+
+func TestCreateTaskExecutionRoles_MinimalManaged(t *testing.T) {
+	testRoleName := "myTaskExecutionRole"
+	testPolicyArn := "arn:aws:iam::111111111111:policy/" + testRoleName + "-policy"
+
+	var policyDoc string
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Do(func(input iam.CreatePolicyInput) {
+			policyDoc = aws.StringValue(input.PolicyDocument)
+		}).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testPolicyArn)}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testPolicyArn, testRoleName).Return(nil, nil),
+	)
+
+	results, err := CreateTaskExecutionRoles(ExecutionRoleParams{
+		RoleNames:      []string{testRoleName},
+		Region:         "us-west-2",
+		AllowEmpty:     true,
+		MinimalManaged: true,
+	}, mocks.MockIAM, mocks.MockKMS)
+	require.NoError(t, err, "Unexpected error when creating task execution role")
+	assert.Equal(t, []string{testPolicyArn}, results[0].AttachedPolicyARNs, "Expected the managed policy not to be attached")
+
+	doc := PolicyDocument{}
+	require.NoError(t, json.Unmarshal([]byte(policyDoc), &doc))
+	var actions []string
+	for _, statement := range doc.Statement {
+		actions = append(actions, statement.Action...)
+	}
+	assert.Equal(t, DefaultMinimalManagedActions, actions)
+
+	entries := buildRoleOutputEntries(results, "")
+	assert.Empty(t, entries[0].ManagedPolicyARN, "Expected no managed policy in the output file")
+}
@@ -0,0 +1,73 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+
+	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/aws-sdk-go/aws/arn"
+)
+
+const (
+	// MultiRegionKeysPrimaryOnly grants kms:Decrypt on a multi-Region key only in the region of the given key ARN
+	MultiRegionKeysPrimaryOnly = "primary-only"
+	// MultiRegionKeysAllReplicas grants kms:Decrypt on a multi-Region key in every region, so that tasks in the regions
+	// of its replicas can decrypt the secret with the replica in their region
+	MultiRegionKeysAllReplicas = "all-replicas"
+)
+
+func validateMultiRegionKeys(mode string) error {
+	switch mode {
+	case "", MultiRegionKeysPrimaryOnly, MultiRegionKeysAllReplicas:
+		return nil
+	}
+	return fmt.Errorf("invalid value '%s' for '--%s'; valid values are %s and %s", mode, flags.MultiRegionKeysFlag, MultiRegionKeysPrimaryOnly, MultiRegionKeysAllReplicas)
+}
+
+// applyMultiRegionKeys replaces the ARNs of multi-Region keys in the statements with an ARN matching the key in any
+// region, if all replicas are granted. Replicas share the key ID of the primary key, so the ARN only differs in its
+// region. The vendored SDK can't list the regions of the replicas, which is why a wildcard region is used.
+func applyMultiRegionKeys(statements []StatementEntry, mode string) []StatementEntry {
+	if mode != MultiRegionKeysAllReplicas {
+		return statements
+	}
+	for i, statement := range statements {
+		var resources []string
+		seen := make(map[string]bool, len(statement.Resource))
+		for _, resource := range statement.Resource {
+			if kmsClient.IsMultiRegionKeyARN(resource) {
+				resource = allReplicasKeyARN(resource)
+			}
+			// replicas of the same key in different regions have the same ARN once the region is replaced
+			if !seen[resource] {
+				seen[resource] = true
+				resources = append(resources, resource)
+			}
+		}
+		statements[i].Resource = resources
+	}
+	return statements
+}
+
+// allReplicasKeyARN returns the ARN of the multi-Region key with a wildcard region
+func allReplicasKeyARN(keyARN string) string {
+	parsed, err := arn.Parse(keyARN)
+	if err != nil {
+		return keyARN
+	}
+	parsed.Region = "*"
+	return parsed.String()
+}
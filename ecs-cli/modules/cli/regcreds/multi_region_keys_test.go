@@ -0,0 +1,84 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testMultiRegionKeyARN   = "arn:aws:kms:us-west-2:111111111111:key/mrk-1234abcd12ab34cd56ef1234567890ab"
+	testSingleRegionKeyARN  = "arn:aws:kms:us-west-2:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+	testAllReplicasKeyARN   = "arn:aws:kms:*:111111111111:key/mrk-1234abcd12ab34cd56ef1234567890ab"
+	testMultiRegionSecretID = "arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-"
+)
+
+func TestValidateMultiRegionKeys(t *testing.T) {
+	assert.NoError(t, validateMultiRegionKeys(""))
+	assert.NoError(t, validateMultiRegionKeys(MultiRegionKeysPrimaryOnly))
+	assert.NoError(t, validateMultiRegionKeys(MultiRegionKeysAllReplicas))
+	assert.Error(t, validateMultiRegionKeys("replicas"))
+}
+
+func TestPolicyStatements_MultiRegionKeys(t *testing.T) {
+	testCases := map[string]struct {
+		mode           string
+		multiRegionKey string
+		singleKey      string
+	}{
+		"default":      {"", testMultiRegionKeyARN, testSingleRegionKeyARN},
+		"primary only": {MultiRegionKeysPrimaryOnly, testMultiRegionKeyARN, testSingleRegionKeyARN},
+		"all replicas": {MultiRegionKeysAllReplicas, testAllReplicasKeyARN, testSingleRegionKeyARN},
+	}
+	for description, tc := range testCases {
+		t.Run(description, func(t *testing.T) {
+			mocks := setupTestController(t)
+			mocks.MockKMS.EXPECT().GetValidKeyARN(testMultiRegionKeyARN).Return(testMultiRegionKeyARN, nil)
+			mocks.MockKMS.EXPECT().GetValidKeyARN(testSingleRegionKeyARN).Return(testSingleRegionKeyARN, nil)
+
+			params := ExecutionRoleParams{
+				CredEntries: map[string]regcredio.CredsOutputEntry{
+					"global.example.com": regcredio.BuildOutputEntry(testMultiRegionSecretID+"global", testMultiRegionKeyARN, nil),
+					"local.example.com":  regcredio.BuildOutputEntry(testMultiRegionSecretID+"local", testSingleRegionKeyARN, nil),
+				},
+				MultiRegionKeys: tc.mode,
+			}
+			statements, err := params.policyStatements(mocks.MockKMS)
+			assert.NoError(t, err, "Unexpected error generating statements")
+
+			var keyResources []string
+			for _, statement := range statements {
+				for _, resource := range statement.Resource {
+					if resource != testMultiRegionSecretID+"global" && resource != testMultiRegionSecretID+"local" {
+						keyResources = append(keyResources, resource)
+					}
+				}
+			}
+			assert.ElementsMatch(t, []string{tc.multiRegionKey, tc.singleKey}, keyResources)
+		})
+	}
+}
+
+func TestApplyMultiRegionKeys_ReplicasInOneStatement(t *testing.T) {
+	replicaARN := "arn:aws:kms:eu-west-1:111111111111:key/mrk-1234abcd12ab34cd56ef1234567890ab"
+	statements := applyMultiRegionKeys([]StatementEntry{{
+		Effect:   "Allow",
+		Action:   []string{kmsDecryptAction},
+		Resource: []string{testMultiRegionKeyARN, replicaARN, testSingleRegionKeyARN},
+	}}, MultiRegionKeysAllReplicas)
+	assert.Equal(t, []string{testAllReplicasKeyARN, testSingleRegionKeyARN}, statements[0].Resource)
+}
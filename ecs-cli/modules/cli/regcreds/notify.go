@@ -0,0 +1,175 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	notifyTimeout         = 10 * time.Second
+	notifyStatusSucceeded = "succeeded"
+	// only the start of an error response is logged
+	notifyMaxResponseBytes = 512
+)
+
+// upNotifier posts the result of a successful 'up' to the --notify-url endpoint
+type upNotifier struct {
+	url      string
+	headers  http.Header
+	required bool
+	client   *http.Client
+}
+
+// upNotification is the JSON body posted to the --notify-url endpoint
+type upNotification struct {
+	Status      string             `json:"status"`
+	Timestamp   string             `json:"timestamp"`
+	Region      string             `json:"region"`
+	Environment string             `json:"environment,omitempty"`
+	Roles       []notificationRole `json:"roles"`
+}
+
+type notificationRole struct {
+	Name               string   `json:"name"`
+	ARN                string   `json:"arn"`
+	Created            bool     `json:"created"`
+	PolicyARN          string   `json:"policyArn,omitempty"`
+	AttachedPolicyARNs []string `json:"attachedPolicyArns,omitempty"`
+}
+
+// newUpNotifier returns the notifier configured by the --notify-* flags, or nil if no URL is given
+func newUpNotifier(c *cli.Context) (*upNotifier, error) {
+	notifyURL := c.String(flags.NotifyURLFlag)
+	if notifyURL == "" {
+		if len(c.StringSlice(flags.NotifyHeaderFlag)) > 0 || c.Bool(flags.NotifyRequiredFlag) {
+			return nil, fmt.Errorf("'--%s' and '--%s' require '--%s'", flags.NotifyHeaderFlag, flags.NotifyRequiredFlag, flags.NotifyURLFlag)
+		}
+		return nil, nil
+	}
+	parsed, err := url.Parse(notifyURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("'--%s' must be an absolute http or https URL, got '%s'", flags.NotifyURLFlag, notifyURL)
+	}
+	headers, err := parseNotifyHeaders(c.StringSlice(flags.NotifyHeaderFlag))
+	if err != nil {
+		return nil, err
+	}
+	return &upNotifier{
+		url:      notifyURL,
+		headers:  headers,
+		required: c.Bool(flags.NotifyRequiredFlag),
+		client:   &http.Client{Timeout: notifyTimeout},
+	}, nil
+}
+
+// parseNotifyHeaders parses headers given as 'Name: value'
+func parseNotifyHeaders(values []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid '--%s' value '%s'; expected 'Name: value'", flags.NotifyHeaderFlag, value)
+		}
+		headers.Add(name, strings.TrimSpace(parts[1]))
+	}
+	return headers, nil
+}
+
+// notify posts the result of the run. A failure is only returned if the notification is required; otherwise it is
+// logged as a warning.
+func (n *upNotifier) notify(roleResults []*ExecutionRoleResult, region, environment string, timestamp time.Time, client iamClient.Client) error {
+	err := n.post(roleResults, region, environment, timestamp, client)
+	if err == nil {
+		log.Infof("Notified %s of the result", n.url)
+		return nil
+	}
+	err = errors.Wrapf(err, "failed to notify %s", n.url)
+	if n.required {
+		return err
+	}
+	log.Warn(err)
+	return nil
+}
+
+func (n *upNotifier) post(roleResults []*ExecutionRoleResult, region, environment string, timestamp time.Time, client iamClient.Client) error {
+	notification, err := buildUpNotification(roleResults, region, environment, timestamp, client)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range n.headers {
+		request.Header[name] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := n.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, notifyMaxResponseBytes))
+		return fmt.Errorf("received status %s: %s", response.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// buildUpNotification describes the roles of the run. The ARN is only known for roles created by the run, so
+// existing roles are looked up.
+func buildUpNotification(roleResults []*ExecutionRoleResult, region, environment string, timestamp time.Time, client iamClient.Client) (*upNotification, error) {
+	roleARNs, err := getRoleARNs(roleResults, client)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]notificationRole, 0, len(roleResults))
+	for i, result := range roleResults {
+		roles = append(roles, notificationRole{
+			Name:               result.RoleName,
+			ARN:                roleARNs[i],
+			Created:            result.RoleCreated,
+			PolicyARN:          result.PolicyARN,
+			AttachedPolicyARNs: result.AttachedPolicyARNs,
+		})
+	}
+	return &upNotification{
+		Status:      notifyStatusSucceeded,
+		Timestamp:   timestamp.UTC().Format(time.RFC3339),
+		Region:      region,
+		Environment: environment,
+		Roles:       roles,
+	}, nil
+}
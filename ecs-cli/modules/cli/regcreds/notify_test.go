@@ -0,0 +1,114 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func TestUpNotifier_PostsResult(t *testing.T) {
+	var received upNotification
+	var authorization, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	notifier, err := newUpNotifier(testNotifyContext(server.URL, []string{"Authorization: Bearer myToken"}, false))
+	require.NoError(t, err)
+	require.NotNil(t, notifier)
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(&iam.Role{Arn: aws.String("arn:aws:iam::111111111111:role/existingRole")}, nil)
+	roleResults := []*ExecutionRoleResult{
+		{RoleName: "newRole", RoleCreated: true, RoleARN: "arn:aws:iam::111111111111:role/newRole", PolicyARN: "arn:aws:iam::111111111111:policy/myPolicy", AttachedPolicyARNs: []string{"arn:aws:iam::111111111111:policy/myPolicy"}},
+		{RoleName: "existingRole", PolicyARN: "arn:aws:iam::111111111111:policy/myPolicy"},
+	}
+	timestamp := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	err = notifier.notify(roleResults, "us-west-2", "dev", timestamp, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error notifying")
+	assert.Equal(t, "Bearer myToken", authorization)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, upNotification{
+		Status:      notifyStatusSucceeded,
+		Timestamp:   "2019-06-01T12:00:00Z",
+		Region:      "us-west-2",
+		Environment: "dev",
+		Roles: []notificationRole{
+			{Name: "newRole", ARN: "arn:aws:iam::111111111111:role/newRole", Created: true, PolicyARN: "arn:aws:iam::111111111111:policy/myPolicy", AttachedPolicyARNs: []string{"arn:aws:iam::111111111111:policy/myPolicy"}},
+			{Name: "existingRole", ARN: "arn:aws:iam::111111111111:role/existingRole", PolicyARN: "arn:aws:iam::111111111111:policy/myPolicy"},
+		},
+	}, received)
+}
+
+func TestUpNotifier_FailureOnlyReturnedIfRequired(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("try again later"))
+	}))
+	defer server.Close()
+
+	mocks := setupTestController(t)
+	notifier, err := newUpNotifier(testNotifyContext(server.URL, nil, false))
+	require.NoError(t, err)
+	assert.NoError(t, notifier.notify(nil, "us-west-2", "", time.Now(), mocks.MockIAM), "Expected failure to only be logged")
+
+	notifier, err = newUpNotifier(testNotifyContext(server.URL, nil, true))
+	require.NoError(t, err)
+	err = notifier.notify(nil, "us-west-2", "", time.Now(), mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the notification is required")
+	assert.Contains(t, err.Error(), "500")
+	assert.Contains(t, err.Error(), "try again later")
+}
+
+func TestNewUpNotifier_ErrorOnInvalidValues(t *testing.T) {
+	_, err := newUpNotifier(testNotifyContext("ftp://example.com/hook", nil, false))
+	assert.Error(t, err, "Expected error for a URL which isn't http or https")
+
+	_, err = newUpNotifier(testNotifyContext("https://example.com/hook", []string{"Authorization"}, false))
+	assert.Error(t, err, "Expected error for a header without a value")
+
+	_, err = newUpNotifier(testNotifyContext("", nil, true))
+	assert.Error(t, err, "Expected error for '--notify-required' without a URL")
+
+	notifier, err := newUpNotifier(testNotifyContext("", nil, false))
+	assert.NoError(t, err)
+	assert.Nil(t, notifier, "Expected no notifier without a URL")
+}
+
+func testNotifyContext(notifyURL string, headers []string, required bool) *cli.Context {
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.String(flags.NotifyURLFlag, notifyURL, "")
+	headerFlag := cli.StringSlice(headers)
+	flagSet.Var(&headerFlag, flags.NotifyHeaderFlag, "")
+	flagSet.Bool(flags.NotifyRequiredFlag, required, "")
+	return cli.NewContext(nil, flagSet, nil)
+}
@@ -0,0 +1,160 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	kmsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	secretsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/secretsmanager"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// findUnchangedRun returns the results of the existing roles if 'up' would make no changes, since no secret would be
+// created or updated and each role already has the policy it would generate, and nil otherwise
+func findUnchangedRun(regCreds map[string]regcredio.RegistryCredEntry, params ExecutionRoleParams, updateAllowed bool, smClient secretsClient.SMClient, iamClient iamClient.Client, kmsClient kmsClient.Client) ([]*ExecutionRoleResult, error) {
+	credEntries, unchanged := existingCredEntries(regCreds, updateAllowed, smClient)
+	if !unchanged {
+		log.Info("A registry secret would be created or updated, so changes are made.")
+		return nil, nil
+	}
+	params.CredEntries = credEntries
+	statements, err := params.policyStatements(kmsClient)
+	if err != nil {
+		return nil, err
+	}
+	if len(statements) == 0 {
+		log.Info("No policy would be generated, so there is no policy to compare and changes are made.")
+		return nil, nil
+	}
+	policyDoc, err := marshalPolicyDocument(statements)
+	if err != nil {
+		return nil, err
+	}
+	results, err := findUnchangedRoles(params, policyDoc, iamClient)
+	if err == nil && results == nil {
+		log.Info("A task execution role doesn't have the new policy, so changes are made.")
+	}
+	return results, err
+}
+
+// existingCredEntries returns the output entries which the new policy would be generated from, if 'up' would use the
+// existing secret of every registry without creating or updating any of them. Otherwise it returns false, since the
+// run makes changes either way.
+func existingCredEntries(regCreds map[string]regcredio.RegistryCredEntry, updateAllowed bool, smClient secretsClient.SMClient) (map[string]regcredio.CredsOutputEntry, bool) {
+	entries := make(map[string]regcredio.CredsOutputEntry, len(regCreds))
+	for registryName, credentialEntry := range regCreds {
+		secretARN := credentialEntry.SecretManagerARN
+		keyID := credentialEntry.KmsKeyID
+		if secretARN == "" {
+			existingSecret, _ := smClient.DescribeSecret(aws.StringValue(generateECSResourceName(registryName)))
+			if existingSecret == nil {
+				return nil, false
+			}
+			secretARN = aws.StringValue(existingSecret.ARN)
+			keyID = aws.StringValue(existingSecret.KmsKeyId)
+		} else if credentialEntry.HasCredPair() && updateAllowed {
+			return nil, false
+		}
+		entry := regcredio.BuildOutputEntry(secretARN, keyID, credentialEntry.ContainerNames)
+		entry.Name = credentialEntry.Name
+		entry.Actions = credentialEntry.Actions
+		entry.RotationCompatible = credentialEntry.RotationCompatible
+		entry.SecretScope = credentialEntry.SecretScope
+		entries[registryName] = entry
+	}
+	return entries, true
+}
+
+// findUnchangedRoles returns the result of each role if all of them are existing roles with the management tag, which
+// already have a policy generated by the ecs-cli attached whose document has the same digest as policyDoc, along with
+// the other policies 'up' attaches. It returns nil if any role would be changed.
+func findUnchangedRoles(params ExecutionRoleParams, policyDoc string, client iamClient.Client) ([]*ExecutionRoleResult, error) {
+	managementTagKey, managementTagValue := params.managementTag()
+	var expectedPolicyARNs []string
+	if managedPolicyARN := params.managedPolicyARN(); managedPolicyARN != "" {
+		expectedPolicyARNs = append(expectedPolicyARNs, managedPolicyARN)
+	}
+	expectedPolicyARNs = append(expectedPolicyARNs, params.AdditionalPolicyARNs...)
+	policyDigest, err := policyDocumentDigest(policyDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	roleNames := params.roleNames()
+	results := make([]*ExecutionRoleResult, 0, len(roleNames))
+	for _, roleName := range roleNames {
+		tags, err := client.ListRoleTags(roleName)
+		if utils.EntityNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list the tags of role %s", roleName)
+		}
+		if !hasTag(tags, managementTagKey, managementTagValue) {
+			return nil, nil
+		}
+
+		attached, err := client.ListAttachedRolePolicies(roleName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list policies attached to role %s", roleName)
+		}
+		attachedARNs := make(map[string]bool, len(attached))
+		for _, policy := range attached {
+			attachedARNs[aws.StringValue(policy.PolicyArn)] = true
+		}
+		for _, policyARN := range expectedPolicyARNs {
+			if !attachedARNs[policyARN] {
+				return nil, nil
+			}
+		}
+
+		policyARN, err := findPolicyWithDigest(attached, policyDigest, params.PolicyName, client)
+		if err != nil || policyARN == "" {
+			return nil, err
+		}
+		results = append(results, &ExecutionRoleResult{
+			RoleName:           roleName,
+			PolicyARN:          policyARN,
+			PolicyName:         policyNameFromARN(policyARN),
+			AttachedPolicyARNs: append([]string{policyARN}, expectedPolicyARNs...),
+		})
+	}
+	return results, nil
+}
+
+// findPolicyWithDigest returns the ARN of the attached policy generated by the ecs-cli (or named policyName, if given)
+// whose document has the given digest, or an empty string if there is none
+func findPolicyWithDigest(attached []*iam.AttachedPolicy, policyDigest, policyName string, client iamClient.Client) (string, error) {
+	for _, policy := range attached {
+		name := aws.StringValue(policy.PolicyName)
+		if !isGeneratedPolicyName(name) && !isContentPolicyName(name) && (policyName == "" || name != policyName) {
+			continue
+		}
+		policyARN := aws.StringValue(policy.PolicyArn)
+		policyDoc, err := client.GetPolicyDocument(policyARN)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get the document of policy %s", policyARN)
+		}
+		// a document which can't be parsed has been changed outside of the ecs-cli, so it never matches
+		if digest, err := policyDocumentDigest(policyDoc); err == nil && digest == policyDigest {
+			return policyARN, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testUnchangedSecretARN = "arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-my.example.com"
+	testUnchangedPolicyARN = "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myRole-policy-20190601T120000Z"
+)
+
+func testUnchangedParams() ExecutionRoleParams {
+	return ExecutionRoleParams{
+		RoleNames: []string{"myRole"},
+		Region:    "us-west-2",
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"my.example.com": regcredio.BuildOutputEntry(testUnchangedSecretARN, "", []string{"web"}),
+		},
+	}
+}
+
+func testUnchangedPolicyDoc(t *testing.T, params ExecutionRoleParams) string {
+	statements, err := params.policyStatements(nil)
+	assert.NoError(t, err, "Unexpected error generating statements")
+	policyDoc, err := marshalPolicyDocument(statements)
+	assert.NoError(t, err, "Unexpected error marshalling policy")
+	return policyDoc
+}
+
+func testGeneratedAttachedPolicies() []*iam.AttachedPolicy {
+	return []*iam.AttachedPolicy{
+		{PolicyArn: aws.String(getExecutionRolePolicyARN("us-west-2")), PolicyName: aws.String("AmazonECSTaskExecutionRolePolicy")},
+		{PolicyArn: aws.String(testUnchangedPolicyARN), PolicyName: aws.String(policyNameFromARN(testUnchangedPolicyARN))},
+	}
+}
+
+func TestFindUnchangedRoles(t *testing.T) {
+	params := testUnchangedParams()
+	policyDoc := testUnchangedPolicyDoc(t, params)
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListRoleTags("myRole").Return(convertToIAMTags(map[string]*string{DefaultManagementTagKey: aws.String(DefaultManagementTagValue)}), nil)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myRole").Return(testGeneratedAttachedPolicies(), nil)
+	// the document is compared regardless of whitespace
+	mocks.MockIAM.EXPECT().GetPolicyDocument(testUnchangedPolicyARN).Return(policyDoc+"\n", nil)
+
+	results, err := findUnchangedRoles(params, policyDoc, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error finding unchanged roles")
+	assert.Len(t, results, 1)
+	assert.Equal(t, "myRole", results[0].RoleName)
+	assert.Equal(t, testUnchangedPolicyARN, results[0].PolicyARN)
+	assert.False(t, results[0].RoleCreated)
+}
+
+func TestFindUnchangedRoles_Changed(t *testing.T) {
+	managedTags := convertToIAMTags(map[string]*string{DefaultManagementTagKey: aws.String(DefaultManagementTagValue)})
+	testCases := map[string]func(mocks testClients){
+		"role not found": func(mocks testClients) {
+			mocks.MockIAM.EXPECT().ListRoleTags("myRole").Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "role not found", nil))
+		},
+		"role not managed": func(mocks testClients) {
+			mocks.MockIAM.EXPECT().ListRoleTags("myRole").Return(nil, nil)
+		},
+		"managed policy not attached": func(mocks testClients) {
+			mocks.MockIAM.EXPECT().ListRoleTags("myRole").Return(managedTags, nil)
+			mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myRole").Return(testGeneratedAttachedPolicies()[1:], nil)
+		},
+		"different document": func(mocks testClients) {
+			mocks.MockIAM.EXPECT().ListRoleTags("myRole").Return(managedTags, nil)
+			mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myRole").Return(testGeneratedAttachedPolicies(), nil)
+			mocks.MockIAM.EXPECT().GetPolicyDocument(testUnchangedPolicyARN).Return(`{"Version":"2012-10-17","Statement":[]}`, nil)
+		},
+	}
+	for description, setup := range testCases {
+		t.Run(description, func(t *testing.T) {
+			params := testUnchangedParams()
+			mocks := setupTestController(t)
+			setup(mocks)
+
+			results, err := findUnchangedRoles(params, testUnchangedPolicyDoc(t, params), mocks.MockIAM)
+			assert.NoError(t, err, "Unexpected error finding unchanged roles")
+			assert.Nil(t, results)
+		})
+	}
+}
+
+func TestExistingCredEntries(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockSM.EXPECT().DescribeSecret("amazon-ecs-cli-setup-other.example.com").Return(&secretsmanager.DescribeSecretOutput{ARN: aws.String("arn:aws:secretsmanager:us-west-2:111111111111:secret:other")}, nil)
+
+	entries, unchanged := existingCredEntries(map[string]regcredio.RegistryCredEntry{
+		"my.example.com":    {SecretManagerARN: testUnchangedSecretARN, Username: "user", Password: "pass", ContainerNames: []string{"web"}},
+		"other.example.com": {Username: "user", Password: "pass", ContainerNames: []string{"log"}},
+	}, false, mocks.MockSM)
+	assert.True(t, unchanged)
+	assert.Equal(t, testUnchangedSecretARN, entries["my.example.com"].CredentialARN)
+	assert.Equal(t, "arn:aws:secretsmanager:us-west-2:111111111111:secret:other", entries["other.example.com"].CredentialARN)
+}
+
+func TestExistingCredEntries_SecretChanged(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockSM.EXPECT().DescribeSecret(gomock.Any()).Return(nil, nil).AnyTimes()
+
+	_, unchanged := existingCredEntries(map[string]regcredio.RegistryCredEntry{
+		"new.example.com": {Username: "user", Password: "pass"},
+	}, false, mocks.MockSM)
+	assert.False(t, unchanged, "Expected a new secret to be a change")
+
+	_, unchanged = existingCredEntries(map[string]regcredio.RegistryCredEntry{
+		"my.example.com": {SecretManagerARN: testUnchangedSecretARN, Username: "user", Password: "pass"},
+	}, true, mocks.MockSM)
+	assert.False(t, unchanged, "Expected an updated secret to be a change")
+}
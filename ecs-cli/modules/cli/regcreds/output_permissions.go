@@ -0,0 +1,35 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+)
+
+// parseOutputPermissions parses the octal file permissions given with '--output-permissions', e.g. '0640'
+func parseOutputPermissions(value string) (os.FileMode, error) {
+	perm, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || perm > uint64(os.ModePerm) {
+		return 0, fmt.Errorf("invalid value '%s' for '--%s'; expected octal file permissions such as '0600'", value, flags.OutputPermissionsFlag)
+	}
+	if perm&0600 != 0600 {
+		// the file is replaced on the next run, and is read again by 'compose' and 'down'
+		return 0, fmt.Errorf("invalid value '%s' for '--%s'; the owner must be able to read and write the files", value, flags.OutputPermissionsFlag)
+	}
+	return os.FileMode(perm), nil
+}
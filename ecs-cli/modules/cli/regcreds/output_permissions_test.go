@@ -0,0 +1,50 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutputPermissions(t *testing.T) {
+	testCases := []struct {
+		value     string
+		expected  os.FileMode
+		expectErr bool
+	}{
+		{"0600", 0600, false},
+		{"640", 0640, false},
+		{"0644", 0644, false},
+		{"0400", 0, true},
+		{"0700", 0700, false},
+		{"1777", 0, true},
+		{"0690", 0, true},
+		{"rw-------", 0, true},
+		{"", 0, true},
+	}
+	for _, test := range testCases {
+		t.Run("Parse '"+test.value+"'", func(t *testing.T) {
+			perm, err := parseOutputPermissions(test.value)
+			if test.expectErr {
+				assert.Error(t, err, "Expected error for invalid file permissions")
+			} else {
+				assert.NoError(t, err, "Unexpected error parsing file permissions")
+				assert.Equal(t, test.expected, perm)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// readOutputTemplate reads the template given with '--output-template', or returns nil if none is given. The output
+// file must be named with '--output-file-name', and not like a registry credentials output file, since 'compose' would
+// otherwise find the rendered file and fail to read it.
+func readOutputTemplate(store regcredio.Store, templateFile, outputDir, outputFileName, roleName, environment string, skipOutput bool) (*template.Template, error) {
+	if templateFile == "" {
+		return nil, nil
+	}
+	if skipOutput {
+		return nil, fmt.Errorf("Only one of '--"+flags.OutputTemplateFlag+"' (value '%s') and '--"+flags.NoOutputFileFlag+"' can be specified but both are present", templateFile)
+	}
+	if outputFileName == "" {
+		return nil, fmt.Errorf("'--%s' requires '--%s', since the rendered file must not be named like a registry credentials output file", flags.OutputTemplateFlag, flags.OutputFileNameFlag)
+	}
+	name, err := regcredio.RenderOutputFileName(outputFileName, roleName, environment, outputDir != "", time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	baseName := strings.TrimSuffix(filepath.Base(name), regcredio.CompressedFileExtension)
+	if matched, _ := filepath.Match(regcredio.ECSCredFileBaseName+"_*.yml", baseName); matched {
+		return nil, fmt.Errorf("output file name '%s' is the name of a registry credentials output file, which 'compose' would read; use another name with '--%s'", name, flags.OutputFileNameFlag)
+	}
+	return regcredio.ReadOutputTemplateFrom(store, templateFile)
+}
+
+// writeOutputTemplate renders the template given with '--output-template' over the results of the run, and writes it
+// to the output file in place of the YAML output. The roles are looked up for their ARNs if they were not created by
+// the run.
+func writeOutputTemplate(store regcredio.Store, tmpl *template.Template, roleEntries []regcredio.RoleOutputEntry, roleResults []*ExecutionRoleResult, creds map[string]regcredio.CredsOutputEntry, secretTags map[string]*string, region string, createTime *time.Time, outputDir, outputFileName, environment string, client iam.Client) error {
+	roles, err := buildSummaryRoles(roleEntries, roleResults, client)
+	if err != nil {
+		return err
+	}
+	tags := make(map[string]string, len(secretTags))
+	for key, value := range secretTags {
+		tags[key] = aws.StringValue(value)
+	}
+
+	createdAt := time.Now().UTC()
+	if createTime != nil {
+		createdAt = *createTime
+	}
+	data := regcredio.NewOutputTemplateData(roles, creds, tags, region, createdAt)
+	return regcredio.WriteOutputTemplateTo(store, tmpl, data, outputDir, outputFileName, environment, createTime)
+}
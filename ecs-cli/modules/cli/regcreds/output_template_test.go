@@ -0,0 +1,56 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOutputTemplate(t *testing.T) {
+	store := regcredio.NewMemoryStore(map[string][]byte{"creds.tfvars.tmpl": []byte(`role_arn = "{{.RoleARN}}"`)})
+
+	tmpl, err := readOutputTemplate(store, "creds.tfvars.tmpl", "", "creds-{{.Environment}}.tfvars", "myRole", "prod", false)
+	assert.NoError(t, err, "Unexpected error reading output template")
+	assert.NotNil(t, tmpl)
+
+	tmpl, err = readOutputTemplate(store, "", "", "", "myRole", "", false)
+	assert.NoError(t, err, "Unexpected error without an output template")
+	assert.Nil(t, tmpl)
+}
+
+func TestReadOutputTemplate_Errors(t *testing.T) {
+	testCases := map[string]struct {
+		outputDir      string
+		outputFileName string
+		skipOutput     bool
+	}{
+		"without output file name": {},
+		"with no output file":      {outputFileName: "creds.tfvars", skipOutput: true},
+		// 'compose' would pick these up as registry credentials output files
+		"default output file name":    {outputFileName: "ecs-registry-creds_{{.Timestamp}}.yml"},
+		"output file name in subdir":  {outputDir: "out", outputFileName: "tf/ecs-registry-creds_{{.RoleName}}.yml"},
+		"compressed output file name": {outputFileName: "ecs-registry-creds_latest.yml.gz"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			store := regcredio.NewMemoryStore(map[string][]byte{"creds.tfvars.tmpl": []byte(`role_arn = "{{.RoleARN}}"`)})
+			_, err := readOutputTemplate(store, "creds.tfvars.tmpl", tc.outputDir, tc.outputFileName, "myRole", "", tc.skipOutput)
+			assert.Error(t, err, "Expected error for output template options")
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	ssmClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/ssm"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ssmParameterPrefix marks a permissions boundary value as the name of an SSM parameter containing the policy ARN
+	ssmParameterPrefix = "ssm:"
+
+	iamServiceName     = "iam"
+	policyResourceType = "policy/"
+)
+
+// boundaryResolver returns the policy ARN for a '--permissions-boundary' value, looking up SSM parameters at most once
+// per invocation
+type boundaryResolver struct {
+	client   ssmClient.Client
+	resolved map[string]string
+}
+
+func newBoundaryResolver(client ssmClient.Client) *boundaryResolver {
+	return &boundaryResolver{
+		client:   client,
+		resolved: make(map[string]string),
+	}
+}
+
+// resolve returns the policy ARN for the given value, which is either a policy ARN or 'ssm:' followed by the name of
+// an SSM parameter whose value is a policy ARN
+func (r *boundaryResolver) resolve(value string) (string, error) {
+	if !isSSMParameterReference(value) {
+		if err := validatePolicyARN(value); err != nil {
+			return "", errors.Wrapf(err, "invalid value for '--%s'", flags.PermissionsBoundaryFlag)
+		}
+		return value, nil
+	}
+
+	if policyARN, ok := r.resolved[value]; ok {
+		return policyARN, nil
+	}
+
+	paramName := strings.TrimPrefix(value, ssmParameterPrefix)
+	if paramName == "" {
+		return "", fmt.Errorf("no SSM parameter name given in '--%s' value '%s'", flags.PermissionsBoundaryFlag, value)
+	}
+	policyARN, err := r.client.GetParameterValue(paramName)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+			return "", fmt.Errorf("SSM parameter '%s' for the permissions boundary does not exist", paramName)
+		}
+		return "", errors.Wrapf(err, "failed to read SSM parameter '%s' for the permissions boundary", paramName)
+	}
+	policyARN = strings.TrimSpace(policyARN)
+	if err = validatePolicyARN(policyARN); err != nil {
+		return "", errors.Wrapf(err, "SSM parameter '%s' does not contain a valid permissions boundary", paramName)
+	}
+	log.Infof("Using permissions boundary %s from SSM parameter %s", policyARN, paramName)
+
+	r.resolved[value] = policyARN
+	return policyARN, nil
+}
+
+func isSSMParameterReference(value string) bool {
+	return strings.HasPrefix(value, ssmParameterPrefix)
+}
+
+func validatePolicyARN(value string) error {
+	parsedARN, err := arn.Parse(value)
+	if err != nil {
+		return fmt.Errorf("'%s' is not an ARN", value)
+	}
+	if parsedARN.Service != iamServiceName || !strings.HasPrefix(parsedARN.Resource, policyResourceType) {
+		return fmt.Errorf("'%s' is not an IAM policy ARN", value)
+	}
+	return nil
+}
+
+// findPermissionsBoundaryProblems checks a '--permissions-boundary' value for 'validate' without making any AWS
+// requests, so SSM parameters are not read. If no region is given, the partition is not checked.
+func findPermissionsBoundaryProblems(value, accountID, region string) []validationFinding {
+	if value == "" {
+		return nil
+	}
+	field := "--" + flags.PermissionsBoundaryFlag
+	boundary, err := expandAccountID(value, "permissions boundary", accountID)
+	if err != nil {
+		return []validationFinding{{Severity: SeverityError, Field: field, Message: err.Error()}}
+	}
+	if isSSMParameterReference(boundary) {
+		return []validationFinding{{Severity: SeverityWarning, Field: field, Message: fmt.Sprintf("SSM parameter '%s' is not read, since no AWS requests are made", strings.TrimPrefix(boundary, ssmParameterPrefix))}}
+	}
+	if err = validatePolicyARN(boundary); err != nil {
+		return []validationFinding{{Severity: SeverityError, Field: field, Message: err.Error()}}
+	}
+	if region != "" {
+		// validatePolicyARN has already parsed the ARN
+		parsedARN, _ := arn.Parse(boundary)
+		if regionPartition := utils.GetPartition(region); parsedARN.Partition != regionPartition {
+			return []validationFinding{{Severity: SeverityError, Field: field, Message: fmt.Sprintf("partition of the permissions boundary (%s) does not match partition '%s' of region %s", parsedARN.Partition, regionPartition, region)}}
+		}
+	}
+	return nil
+}
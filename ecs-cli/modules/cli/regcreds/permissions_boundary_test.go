@@ -0,0 +1,104 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	mock_ssm "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/ssm/mock"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBoundaryARN = "arn:aws:iam::111111111111:policy/boundary"
+
+func TestBoundaryResolver_PolicyARN(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSSM := mock_ssm.NewMockClient(ctrl)
+
+	policyARN, err := newBoundaryResolver(mockSSM).resolve(testBoundaryARN)
+	assert.NoError(t, err, "Unexpected error resolving permissions boundary")
+	assert.Equal(t, testBoundaryARN, policyARN)
+}
+
+func TestBoundaryResolver_SSMParameterIsCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockSSM := mock_ssm.NewMockClient(ctrl)
+	mockSSM.EXPECT().GetParameterValue("/iam/boundary").Return(testBoundaryARN+"\n", nil).Times(1)
+
+	resolver := newBoundaryResolver(mockSSM)
+	for i := 0; i < 2; i++ {
+		policyARN, err := resolver.resolve("ssm:/iam/boundary")
+		assert.NoError(t, err, "Unexpected error resolving permissions boundary")
+		assert.Equal(t, testBoundaryARN, policyARN)
+	}
+}
+
+func TestBoundaryResolver_ErrorCases(t *testing.T) {
+	testCases := []struct {
+		description string
+		value       string
+		setupMock   func(*mock_ssm.MockClient)
+	}{
+		{"Not an ARN", "boundary", nil},
+		{"Not a policy ARN", "arn:aws:iam::111111111111:role/boundary", nil},
+		{"No parameter name", "ssm:", nil},
+		{"Missing parameter", "ssm:/iam/boundary", func(m *mock_ssm.MockClient) {
+			m.EXPECT().GetParameterValue("/iam/boundary").Return("", awserr.New(ssm.ErrCodeParameterNotFound, "not found", nil))
+		}},
+		{"Parameter is not a policy ARN", "ssm:/iam/boundary", func(m *mock_ssm.MockClient) {
+			m.EXPECT().GetParameterValue("/iam/boundary").Return("arn:aws:kms:us-west-2:111111111111:key/abc", nil)
+		}},
+	}
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockSSM := mock_ssm.NewMockClient(ctrl)
+			if test.setupMock != nil {
+				test.setupMock(mockSSM)
+			}
+
+			_, err := newBoundaryResolver(mockSSM).resolve(test.value)
+			assert.Error(t, err, "Expected error resolving permissions boundary")
+		})
+	}
+}
+
+func TestFindPermissionsBoundaryProblems(t *testing.T) {
+	assert.Empty(t, findPermissionsBoundaryProblems("", "", "us-west-2"))
+	assert.Empty(t, findPermissionsBoundaryProblems(testBoundaryARN, "", "us-west-2"))
+	assert.Empty(t, findPermissionsBoundaryProblems("arn:aws:iam::{{.AccountID}}:policy/boundary", "111111111111", "us-west-2"))
+
+	findings := findPermissionsBoundaryProblems("ssm:/iam/boundary-arn", "", "us-west-2")
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, SeverityWarning, findings[0].Severity, "Expected SSM parameters only to be reported as not checked")
+	}
+
+	for _, value := range []string{
+		"arn:aws:iam::{{.AccountID}}:policy/boundary",
+		"arn:aws:s3:::bucket",
+		"arn:aws-cn:iam::111111111111:policy/boundary",
+	} {
+		findings = findPermissionsBoundaryProblems(value, "", "us-west-2")
+		if assert.Len(t, findings, 1, "Expected a finding for '%s'", value) {
+			assert.Equal(t, SeverityError, findings[0].Severity)
+			assert.Equal(t, "--permissions-boundary", findings[0].Field)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultMaxPoliciesPerRole is the default IAM quota of managed policies attached to a role
+const DefaultMaxPoliciesPerRole = 10
+
+const generatedPolicyNameMarker = "-policy-"
+
+// checkPolicyLimit returns an error if attaching a new policy (if newPolicy is set) and the existing policies (i.e. the
+// managed execution role policy and any policies given with --attach-policy) to the role would exceed maxPolicies. If
+// prune is set, the ARNs of the policies previously generated by the ecs-cli (oldest first) which must be detached to
+// make room are returned instead. Nothing is detached yet.
+func checkPolicyLimit(roleName string, existingPolicyARNs []string, newPolicy bool, maxPolicies int, prune bool, client iamClient.Client) ([]string, error) {
+	attached, err := client.ListAttachedRolePolicies(roleName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list policies attached to role %s", roleName)
+	}
+
+	// the new policy is always attached; existing policies only if they aren't already
+	newAttachments := 0
+	if newPolicy {
+		newAttachments++
+	}
+	for _, policyARN := range existingPolicyARNs {
+		if !isPolicyAttached(attached, policyARN) {
+			newAttachments++
+		}
+	}
+	excess := len(attached) + newAttachments - maxPolicies
+	if excess <= 0 {
+		return nil, nil
+	}
+
+	stale, err := stalePolicies(attached, client)
+	if err != nil {
+		return nil, err
+	}
+	if !prune {
+		msg := fmt.Sprintf("role %s has %d attached managed policies and attaching %d more would exceed the limit of %d; detach unused policies from the role", roleName, len(attached), newAttachments, maxPolicies)
+		if len(stale) > 0 {
+			msg += fmt.Sprintf(", or use '--%s' to remove %d stale policies generated by the ECS CLI (%s)", flags.PruneStaleFlag, len(stale), strings.Join(policyNames(stale), ", "))
+		}
+		return nil, errors.New(msg)
+	}
+	if len(stale) < excess {
+		return nil, fmt.Errorf("role %s has %d attached managed policies and attaching %d more would exceed the limit of %d; only %d stale policies generated by the ECS CLI can be removed", roleName, len(attached), newAttachments, maxPolicies, len(stale))
+	}
+
+	var staleARNs []string
+	for _, policy := range stale[:excess] {
+		staleARNs = append(staleARNs, aws.StringValue(policy.Arn))
+	}
+	return staleARNs, nil
+}
+
+// detachStalePolicies detaches the stale policies from the role to make room for the new policies, and returns the
+// ARNs of those it detached. The policy of the run is kept, even if it was generated by an earlier run. If a detachment
+// fails, the policies detached before it are attached again.
+func detachStalePolicies(roleName string, staleARNs []string, policyARN string, client iamClient.Client) ([]string, error) {
+	var detached []string
+	for _, staleARN := range staleARNs {
+		if staleARN == policyARN {
+			continue
+		}
+		if err := client.DetachRolePolicy(staleARN, roleName); err != nil {
+			restoreStalePolicies(roleName, detached, client)
+			return nil, errors.Wrapf(err, "failed to detach stale policy %s from role %s", staleARN, roleName)
+		}
+		log.Infof("Detached stale policy %s from role %s", staleARN, roleName)
+		detached = append(detached, staleARN)
+	}
+	return detached, nil
+}
+
+// restoreStalePolicies attaches the detached stale policies to the role again, since the new policies could not be
+// attached, and returns those which could not be restored. Failures are logged, since the error attaching the new
+// policies is the one returned.
+func restoreStalePolicies(roleName string, detached []string, client iamClient.Client) []string {
+	var notRestored []string
+	for _, policyARN := range detached {
+		if _, err := client.AttachRolePolicy(policyARN, roleName); err != nil {
+			log.Errorf("Failed to attach stale policy %s to role %s again: %v", policyARN, roleName, err)
+			notRestored = append(notRestored, policyARN)
+			continue
+		}
+		log.Infof("Attached stale policy %s to role %s again", policyARN, roleName)
+	}
+	return notRestored
+}
+
+// deleteStalePolicies deletes the stale policies pruned from the roles, once the new policies have been attached. A
+// policy which is still attached to other entities, such as a role which could not be set up, is kept. The first
+// policy which can't be deleted otherwise fails the role it was pruned from, and no further policies are deleted.
+func deleteStalePolicies(results []*ExecutionRoleResult, client iamClient.Client) {
+	deleted := make(map[string]bool)
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		for _, policyARN := range result.PrunedPolicyARNs {
+			if deleted[policyARN] {
+				continue
+			}
+			if err := client.DeletePolicy(policyARN); err != nil {
+				if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeDeleteConflictException {
+					log.Infof("Keeping stale policy %s which is attached to other entities", policyARN)
+					deleted[policyARN] = true
+					continue
+				}
+				result.Err = errors.Wrapf(err, "failed to delete stale policy %s, which was detached from role %s", policyARN, result.RoleName)
+				return
+			}
+			deleted[policyARN] = true
+			log.Infof("Deleted stale policy %s", policyARN)
+		}
+	}
+}
+
+func isPolicyAttached(attached []*iam.AttachedPolicy, policyARN string) bool {
+	for _, policy := range attached {
+		if aws.StringValue(policy.PolicyArn) == policyARN {
+			return true
+		}
+	}
+	return false
+}
+
+// stalePolicies returns the attached policies generated by the ecs-cli which belong to a single run, oldest first.
+// Content addressed policies are left out, since they may be shared with the roles of other runs.
+func stalePolicies(attached []*iam.AttachedPolicy, client iamClient.Client) ([]*iam.Policy, error) {
+	generated, err := generatedPolicies(attached, client)
+	if err != nil {
+		return nil, err
+	}
+	var stale []*iam.Policy
+	for _, policy := range generated {
+		if !isContentPolicyName(aws.StringValue(policy.PolicyName)) {
+			stale = append(stale, policy)
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		return aws.TimeValue(stale[i].CreateDate).Before(aws.TimeValue(stale[j].CreateDate))
+	})
+	return stale, nil
+}
+
+// generatedPolicies returns the attached policies generated by the ecs-cli, in the order they are listed. They are
+// identified by their description rather than their name, since the name may have been given with '--policy-name'.
+func generatedPolicies(attached []*iam.AttachedPolicy, client iamClient.Client) ([]*iam.Policy, error) {
+	var generated []*iam.Policy
+	for _, attachedPolicy := range attached {
+		policyARN := aws.StringValue(attachedPolicy.PolicyArn)
+		// AWS managed policies are never generated
+		if parsedARN, err := arn.Parse(policyARN); err == nil && parsedARN.AccountID == "aws" {
+			continue
+		}
+		policy, err := client.GetPolicy(policyARN)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get policy %s", policyARN)
+		}
+		if strings.HasPrefix(aws.StringValue(policy.Description), policyDescription("")) {
+			generated = append(generated, policy)
+		}
+	}
+	return generated, nil
+}
+
+func isGeneratedPolicyName(policyName string) bool {
+	return strings.HasPrefix(policyName, utils.ECSCLIResourcePrefix) && strings.Contains(policyName, generatedPolicyNameMarker)
+}
+
+func policyNames(policies []*iam.Policy) []string {
+	names := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		names = append(names, aws.StringValue(policy.PolicyName))
+	}
+	return names
+}
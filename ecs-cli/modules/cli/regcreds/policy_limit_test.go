@@ -0,0 +1,291 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam/mock"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const testLimitRoleName = "mySharedRole"
+
+func TestCheckPolicyLimit_WithinLimit(t *testing.T) {
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	// the managed policy is already attached, so only the new policy is added
+	attached := append(testAttachedPolicies(8), &iam.AttachedPolicy{PolicyArn: aws.String(managedPolicyARN), PolicyName: aws.String("AmazonECSTaskExecutionRolePolicy")})
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil)
+
+	pruned, err := checkPolicyLimit(testLimitRoleName, []string{managedPolicyARN}, true, 10, false, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when role has room for the new policy")
+	assert.Empty(t, pruned)
+}
+
+func TestCheckPolicyLimit_ErrorOnLimitExceeded(t *testing.T) {
+	attached := append(testAttachedPolicies(7), testGeneratedPolicy("20190601T000000Z"))
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+	mocks.MockIAM.EXPECT().DetachRolePolicy(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := checkPolicyLimit(testLimitRoleName, []string{getExecutionRolePolicyARN("us-west-2")}, true, 9, false, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when attaching would exceed the limit")
+	assert.Contains(t, err.Error(), "--prune-stale", "Expected stale policies to be suggested for pruning")
+	assert.Contains(t, err.Error(), "amazon-ecs-cli-setup-"+testLimitRoleName+"-policy-20190601T000000Z")
+}
+
+func TestCheckPolicyLimit_CountsAdditionalPolicies(t *testing.T) {
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	xrayPolicyARN := "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+	attached := append(testAttachedPolicies(7), &iam.AttachedPolicy{PolicyArn: aws.String(managedPolicyARN)})
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+
+	_, err := checkPolicyLimit(testLimitRoleName, []string{managedPolicyARN, xrayPolicyARN}, true, 9, false, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the additional policy would exceed the limit")
+}
+
+func TestCheckPolicyLimit_AdditionalPolicyAlreadyAttached(t *testing.T) {
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	xrayPolicyARN := "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+	attached := append(testAttachedPolicies(6), &iam.AttachedPolicy{PolicyArn: aws.String(managedPolicyARN)}, &iam.AttachedPolicy{PolicyArn: aws.String(xrayPolicyARN)})
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil)
+
+	_, err := checkPolicyLimit(testLimitRoleName, []string{managedPolicyARN, xrayPolicyARN}, true, 9, false, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when the additional policy is already attached")
+}
+
+func TestCheckPolicyLimit_PruneStale(t *testing.T) {
+	oldest := testGeneratedPolicy("20190101T000000Z")
+	older := testGeneratedPolicy("20190301T000000Z")
+	newest := testGeneratedPolicy("20190601T000000Z")
+	attached := append(testAttachedPolicies(6), newest, oldest, older)
+
+	mocks := setupTestController(t)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil)
+	// nothing is detached until the new policy is attached
+	mocks.MockIAM.EXPECT().DetachRolePolicy(gomock.Any(), gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().DeletePolicy(gomock.Any()).Times(0)
+
+	stale, err := checkPolicyLimit(testLimitRoleName, []string{getExecutionRolePolicyARN("us-west-2")}, true, 9, true, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when pruning stale policies")
+	assert.Equal(t, []string{aws.StringValue(oldest.PolicyArn), aws.StringValue(older.PolicyArn)}, stale, "Expected oldest generated policies to be pruned")
+}
+
+func TestCreateTaskExecutionRole_PruneStaleAfterAttaching(t *testing.T) {
+	stale := testGeneratedPolicy("20190101T000000Z")
+	staleARN := aws.StringValue(stale.PolicyArn)
+	shared := testGeneratedPolicy("20190301T000000Z")
+	sharedARN := aws.StringValue(shared.PolicyArn)
+	// the managed policy isn't attached yet, so two policies are added
+	attached := append(testAttachedPolicies(8), stale, shared)
+
+	mocks := setupTestController(t)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testFixedPolicyARN)}}, nil),
+		mocks.MockIAM.EXPECT().DetachRolePolicy(staleARN, testLimitRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().DetachRolePolicy(sharedARN, testLimitRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testFixedPolicyARN, testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().DeletePolicy(staleARN).Return(nil),
+		// a policy still attached elsewhere is kept
+		mocks.MockIAM.EXPECT().DeletePolicy(sharedARN).Return(awserr.New(iam.ErrCodeDeleteConflictException, "attached", nil)),
+	)
+
+	roleResult, err := CreateTaskExecutionRole(testPruneParams(), mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when pruning stale policies")
+	assert.Equal(t, []string{staleARN, sharedARN}, roleResult.PrunedPolicyARNs)
+}
+
+func TestCreateTaskExecutionRole_PruneStaleNotBeforePolicyCreated(t *testing.T) {
+	attached := append(testAttachedPolicies(8), testGeneratedPolicy("20190101T000000Z"))
+
+	mocks := setupTestController(t)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, errors.New("something went wrong")),
+	)
+	mocks.MockIAM.EXPECT().DetachRolePolicy(gomock.Any(), gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().DeletePolicy(gomock.Any()).Times(0)
+
+	_, err := CreateTaskExecutionRole(testPruneParams(), mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when the new policy can't be created")
+}
+
+func TestCreateTaskExecutionRole_PruneStaleRestoredOnAttachFailure(t *testing.T) {
+	stale := testGeneratedPolicy("20190101T000000Z")
+	staleARN := aws.StringValue(stale.PolicyArn)
+	attached := append(testAttachedPolicies(8), stale, &iam.AttachedPolicy{PolicyArn: aws.String(getExecutionRolePolicyARN("us-west-2"))})
+
+	mocks := setupTestController(t)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testFixedPolicyARN)}}, nil),
+		mocks.MockIAM.EXPECT().DetachRolePolicy(staleARN, testLimitRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testFixedPolicyARN, testLimitRoleName).Return(nil, errors.New("something went wrong")),
+		// the role keeps the access of the stale policy
+		mocks.MockIAM.EXPECT().AttachRolePolicy(staleARN, testLimitRoleName).Return(nil, nil),
+	)
+	mocks.MockIAM.EXPECT().DeletePolicy(gomock.Any()).Times(0)
+
+	roleResults, err := CreateTaskExecutionRoles(testPruneParams(), mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when the new policy can't be attached")
+	assert.Empty(t, roleResults[0].PrunedPolicyARNs, "Expected the stale policy to be restored")
+}
+
+func TestCreateTaskExecutionRole_ErrorOnDeleteStalePolicy(t *testing.T) {
+	stale := testGeneratedPolicy("20190101T000000Z")
+	staleARN := aws.StringValue(stale.PolicyArn)
+	attached := append(testAttachedPolicies(8), stale, &iam.AttachedPolicy{PolicyArn: aws.String(getExecutionRolePolicyARN("us-west-2"))})
+
+	mocks := setupTestController(t)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testFixedPolicyARN)}}, nil),
+		mocks.MockIAM.EXPECT().DetachRolePolicy(staleARN, testLimitRoleName).Return(nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testFixedPolicyARN, testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().DeletePolicy(staleARN).Return(awserr.New("AccessDenied", "not allowed", nil)),
+	)
+
+	roleResults, err := CreateTaskExecutionRoles(testPruneParams(), mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when a stale policy can't be deleted")
+	assert.Contains(t, err.Error(), staleARN)
+	assert.Equal(t, []string{getExecutionRolePolicyARN("us-west-2"), testFixedPolicyARN}, roleResults[0].AttachedPolicyARNs, "Expected the new policy to stay attached")
+}
+
+func TestCheckPolicyLimit_ErrorOnNotEnoughStale(t *testing.T) {
+	attached := append(testAttachedPolicies(8), testGeneratedPolicy("20190601T000000Z"))
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil)
+	expectPolicyDetails(mocks.MockIAM, attached...)
+	mocks.MockIAM.EXPECT().DetachRolePolicy(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := checkPolicyLimit(testLimitRoleName, []string{getExecutionRolePolicyARN("us-west-2")}, true, 9, true, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when pruning cannot make enough room")
+}
+
+func TestCheckPolicyLimit_RefreshedPolicyNotCounted(t *testing.T) {
+	managedPolicyARN := getExecutionRolePolicyARN("us-west-2")
+	generated := testGeneratedPolicy("20190601T000000Z")
+	// the refreshed policy is already attached, so the role is at the limit but nothing is added
+	attached := append(testAttachedPolicies(7), generated, &iam.AttachedPolicy{PolicyArn: aws.String(managedPolicyARN)})
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return(attached, nil)
+
+	_, err := checkPolicyLimit(testLimitRoleName, []string{managedPolicyARN, aws.StringValue(generated.PolicyArn)}, false, 9, false, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when no new policy is attached")
+}
+
+func TestStalePolicies_IdentifiedByDescription(t *testing.T) {
+	named := &iam.AttachedPolicy{PolicyName: aws.String("payments-registry-access"), PolicyArn: aws.String("arn:aws:iam::111111111111:policy/payments-registry-access")}
+	content := &iam.AttachedPolicy{PolicyName: aws.String("amazon-ecs-cli-setup-sha256-0123"), PolicyArn: aws.String("arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-sha256-0123")}
+	generated := testGeneratedPolicy("20190601T000000Z")
+	attached := append(testAttachedPolicies(1), generated, named, content, &iam.AttachedPolicy{PolicyArn: aws.String(getExecutionRolePolicyARN("us-west-2"))})
+
+	mocks := setupTestController(t)
+	expectPolicyDetails(mocks.MockIAM, testAttachedPolicies(1)[0], generated)
+	// a policy named with --policy-name has the description of a generated policy
+	mocks.MockIAM.EXPECT().GetPolicy(aws.StringValue(named.PolicyArn)).Return(&iam.Policy{
+		Arn:         named.PolicyArn,
+		PolicyName:  named.PolicyName,
+		Description: aws.String(policyDescription(testLimitRoleName)),
+		CreateDate:  aws.Time(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}, nil)
+	mocks.MockIAM.EXPECT().GetPolicy(aws.StringValue(content.PolicyArn)).Return(&iam.Policy{
+		Arn:         content.PolicyArn,
+		PolicyName:  content.PolicyName,
+		Description: aws.String(policyDescription(testLimitRoleName)),
+	}, nil)
+
+	stale, err := stalePolicies(attached, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error finding stale policies")
+	assert.Equal(t, []string{"payments-registry-access", aws.StringValue(generated.PolicyName)}, policyNames(stale), "Expected generated policies other than content addressed ones, oldest first")
+}
+
+func testPruneParams() ExecutionRoleParams {
+	return ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+		},
+		RoleName:           testLimitRoleName,
+		Region:             "us-west-2",
+		MaxPoliciesPerRole: DefaultMaxPoliciesPerRole,
+		PruneStalePolicies: true,
+	}
+}
+
+func testAttachedPolicies(count int) []*iam.AttachedPolicy {
+	var policies []*iam.AttachedPolicy
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("team-policy-%d", i)
+		policies = append(policies, &iam.AttachedPolicy{
+			PolicyName: aws.String(name),
+			PolicyArn:  aws.String("arn:aws:iam::111111111111:policy/" + name),
+		})
+	}
+	return policies
+}
+
+func testGeneratedPolicy(timestamp string) *iam.AttachedPolicy {
+	name := "amazon-ecs-cli-setup-" + testLimitRoleName + "-policy-" + timestamp
+	return &iam.AttachedPolicy{
+		PolicyName: aws.String(name),
+		PolicyArn:  aws.String("arn:aws:iam::111111111111:policy/" + name),
+	}
+}
+
+// expectPolicyDetails answers GetPolicy for the attached policies. Policies named with a creation timestamp have the
+// description of a policy generated by the ECS CLI, and are dated by their timestamp; other policies have none.
+func expectPolicyDetails(mockIAM *mock_iam.MockClient, attached ...*iam.AttachedPolicy) {
+	for _, policy := range attached {
+		details := &iam.Policy{Arn: policy.PolicyArn, PolicyName: policy.PolicyName}
+		if name := aws.StringValue(policy.PolicyName); isGeneratedPolicyName(name) {
+			createDate, _ := time.Parse(regcredio.ECSCredFileTimeFmt, name[strings.LastIndex(name, generatedPolicyNameMarker)+len(generatedPolicyNameMarker):])
+			details.Description = aws.String(policyDescription(testLimitRoleName))
+			details.CreateDate = aws.Time(createDate)
+		}
+		mockIAM.EXPECT().GetPolicy(aws.StringValue(policy.PolicyArn)).Return(details, nil).AnyTimes()
+	}
+}
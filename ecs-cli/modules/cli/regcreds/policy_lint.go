@@ -0,0 +1,146 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	lintRuleWildcardAction    = "wildcard-action"
+	lintRuleWildcardResource  = "wildcard-resource"
+	lintRuleRequiredCondition = "required-condition"
+)
+
+// defaultWildcardResourceActions are the actions which may be granted on '*' if the lint rules don't list any, since
+// they can't be limited to a resource
+var defaultWildcardResourceActions = []string{ecrGetAuthorizationTokenAction}
+
+// lintFinding is a rule violated by a statement of the generated policy
+type lintFinding struct {
+	Sid     string
+	Rule    string
+	Message string
+}
+
+func (finding lintFinding) String() string {
+	return fmt.Sprintf("statement '%s' violates rule %s: %s", finding.Sid, finding.Rule, finding.Message)
+}
+
+// readLintRules returns the rules '--lint' checks the new policy against: those of the rules file, if given, or else
+// the default rules. If linting is not enabled, no rules are returned.
+func readLintRules(store regcredio.Store, lint bool, rulesFile string) (*regcredio.LintRules, error) {
+	if !lint {
+		if rulesFile != "" {
+			return nil, fmt.Errorf("'--%s' requires '--%s'", flags.LintRulesFlag, flags.LintFlag)
+		}
+		return nil, nil
+	}
+	if rulesFile == "" {
+		return &regcredio.LintRules{}, nil
+	}
+	rules, err := regcredio.ReadLintRulesFrom(store, rulesFile)
+	if err != nil {
+		return nil, err
+	}
+	return &rules.Rules, nil
+}
+
+// lintPolicy checks the statements of the generated policy against the lint rules. Every finding is logged as a
+// warning before the error is returned.
+func lintPolicy(statements []StatementEntry, rules regcredio.LintRules) error {
+	findings := findLintFindings(statements, rules)
+	if len(findings) == 0 {
+		log.Infof("The generated policy passed the lint rules (%d statements checked).", len(statements))
+		return nil
+	}
+	for _, finding := range findings {
+		log.Warn(finding)
+	}
+	return fmt.Errorf("the generated policy violates the lint rules (%d finding(s))", len(findings))
+}
+
+// findLintFindings returns the findings of each Allow statement, in statement order. Deny statements only restrict
+// access, so they are not checked.
+func findLintFindings(statements []StatementEntry, rules regcredio.LintRules) []lintFinding {
+	actions := rules.WildcardResourceActions
+	if actions == nil {
+		actions = defaultWildcardResourceActions
+	}
+	wildcardResourceActions := stringSet(actions)
+	allowedWildcardResources := stringSet(rules.AllowedWildcardResources)
+
+	var findings []lintFinding
+	for _, statement := range statements {
+		if statement.Effect != "Allow" {
+			continue
+		}
+		if !rules.AllowWildcardActions {
+			for _, action := range statement.Action {
+				if strings.Contains(action, "*") {
+					findings = append(findings, lintFinding{statement.Sid, lintRuleWildcardAction, fmt.Sprintf("action '%s' contains a wildcard", action)})
+				}
+			}
+		}
+		if !containsAll(wildcardResourceActions, statement.Action) {
+			for _, resource := range statement.Resource {
+				if strings.Contains(resource, "*") && !allowedWildcardResources[resource] {
+					findings = append(findings, lintFinding{statement.Sid, lintRuleWildcardResource, fmt.Sprintf("resource '%s' contains a wildcard and is not in 'allowed_wildcard_resources'", resource)})
+				}
+			}
+		}
+		for _, key := range rules.RequiredConditionKeys {
+			if !hasConditionKey(statement, key) {
+				findings = append(findings, lintFinding{statement.Sid, lintRuleRequiredCondition, fmt.Sprintf("condition key '%s' is required", key)})
+			}
+		}
+	}
+	return findings
+}
+
+// hasConditionKey returns whether the statement's condition has the key with any operator. Condition keys are
+// compared without regard to case, as in IAM.
+func hasConditionKey(statement StatementEntry, key string) bool {
+	for _, keys := range statement.Condition {
+		for conditionKey := range keys {
+			if strings.EqualFold(conditionKey, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsAll returns whether each of the values is in the set
+func containsAll(set map[string]bool, values []string) bool {
+	for _, value := range values {
+		if !set[value] {
+			return false
+		}
+	}
+	return true
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
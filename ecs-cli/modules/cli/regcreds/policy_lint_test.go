@@ -0,0 +1,85 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindLintFindings(t *testing.T) {
+	statements := []StatementEntry{
+		{Sid: "AllowAll", Effect: "Allow", Action: []string{"*:*"}, Resource: []string{"*"}},
+		{Sid: "ECRAuthorization", Effect: "Allow", Action: []string{"ecr:GetAuthorizationToken"}, Resource: []string{"*"}},
+		{Sid: "TeamSecrets", Effect: "Allow", Action: []string{"secretsmanager:GetSecretValue"}, Resource: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:team/*"},
+			Condition: map[string]map[string]string{"StringEquals": {"aws:sourceaccount": "111111111111"}}},
+		{Sid: "DenyUntagged", Effect: "Deny", Action: []string{"*"}, Resource: []string{"*"}},
+	}
+
+	findings := findLintFindings(statements, regcredio.LintRules{RequiredConditionKeys: []string{"aws:SourceAccount"}})
+	assert.Equal(t, []lintFinding{
+		{"AllowAll", lintRuleWildcardAction, "action '*:*' contains a wildcard"},
+		{"AllowAll", lintRuleWildcardResource, "resource '*' contains a wildcard and is not in 'allowed_wildcard_resources'"},
+		{"AllowAll", lintRuleRequiredCondition, "condition key 'aws:SourceAccount' is required"},
+		{"ECRAuthorization", lintRuleRequiredCondition, "condition key 'aws:SourceAccount' is required"},
+		{"TeamSecrets", lintRuleWildcardResource, "resource 'arn:aws:secretsmanager:us-west-2:111111111111:secret:team/*' contains a wildcard and is not in 'allowed_wildcard_resources'"},
+	}, findings)
+
+	findings = findLintFindings(statements[1:], regcredio.LintRules{AllowedWildcardResources: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:team/*"}})
+	assert.Empty(t, findings)
+
+	// listing the wildcard resource actions replaces the defaults
+	findings = findLintFindings(statements[1:2], regcredio.LintRules{WildcardResourceActions: []string{}})
+	assert.Equal(t, []lintFinding{{"ECRAuthorization", lintRuleWildcardResource, "resource '*' contains a wildcard and is not in 'allowed_wildcard_resources'"}}, findings)
+
+	findings = findLintFindings(statements[:1], regcredio.LintRules{AllowWildcardActions: true, AllowedWildcardResources: []string{"*"}})
+	assert.Empty(t, findings)
+}
+
+func TestReadLintRules(t *testing.T) {
+	store := regcredio.NewMemoryStore(map[string][]byte{"lint.yml": []byte("version: \"1\"\nrules:\n  allow_wildcard_actions: true\n")})
+
+	rules, err := readLintRules(store, false, "")
+	assert.NoError(t, err)
+	assert.Nil(t, rules)
+
+	rules, err = readLintRules(store, true, "")
+	assert.NoError(t, err)
+	assert.Equal(t, &regcredio.LintRules{}, rules)
+
+	rules, err = readLintRules(store, true, "lint.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, &regcredio.LintRules{AllowWildcardActions: true}, rules)
+
+	_, err = readLintRules(store, false, "lint.yml")
+	assert.Error(t, err, "Expected error for '--lint-rules' without '--lint'")
+}
+
+func TestCreateTaskExecutionRoles_LintFailure(t *testing.T) {
+	mocks := setupTestController(t)
+	// no IAM calls are expected, since the policy is linted before any changes are made
+
+	testParams := ExecutionRoleParams{
+		RoleName:   "myTaskExecutionRole",
+		Region:     "us-west-2",
+		AllowEmpty: true,
+		IncludeECR: true,
+		LintRules:  &regcredio.LintRules{},
+	}
+
+	_, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error for the ECR pull statement on all repositories")
+}
@@ -0,0 +1,89 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// the name constraints of IAM managed policies
+var validPolicyName = regexp.MustCompile(`^[\w+=,.@-]{1,128}$`)
+
+func validatePolicyName(policyName string) error {
+	if !validPolicyName.MatchString(policyName) {
+		return fmt.Errorf("invalid value '%s' for '--%s'; policy names must be 1 to 128 letters, numbers or any of '_+=,.@-'", policyName, flags.PolicyNameFlag)
+	}
+	return nil
+}
+
+// createOrUpdateNamedPolicy creates the policy with the given name. If a policy with that name already exists, it is
+// given a new version with the document if updates are allowed, and returned as updated; otherwise it is an error.
+func createOrUpdateNamedPolicy(policyName, roleName, policyDoc string, updateAllowed bool, client iamClient.Client) (string, bool, error) {
+	newPolicy, err := createRegistryCredentialsPolicy(aws.String(policyName), roleName, policyDoc, client)
+	if err == nil {
+		return aws.StringValue(newPolicy.Arn), false, nil
+	}
+	if !utils.EntityAlreadyExists(err) {
+		return "", false, err
+	}
+	if !updateAllowed {
+		return "", false, fmt.Errorf("policy %s already exists; use '--%s' to add a new version to it", policyName, flags.UpdateExistingFlag)
+	}
+
+	policyARN, err := findLocalPolicyARN(policyName, client)
+	if err != nil {
+		return "", false, err
+	}
+	log.Infof("Policy %s already exists; adding a new version", policyARN)
+	if err = refreshPolicy(policyARN, policyDoc, client); err != nil {
+		return "", false, err
+	}
+	return policyARN, true, nil
+}
+
+// findLocalPolicyARN returns the ARN of the customer managed policy with the given name
+func findLocalPolicyARN(policyName string, client iamClient.Client) (string, error) {
+	policyARN := ""
+	err := client.ListPoliciesPages(func(page *iam.ListPoliciesOutput, lastPage bool) bool {
+		for _, policy := range page.Policies {
+			if aws.StringValue(policy.PolicyName) == policyName {
+				policyARN = aws.StringValue(policy.Arn)
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find policy %s", policyName)
+	}
+	if policyARN == "" {
+		return "", fmt.Errorf("policy %s already exists but could not be found", policyName)
+	}
+	return policyARN, nil
+}
+
+// policyNameFromARN returns the name of the policy, which follows its path in the ARN
+func policyNameFromARN(policyARN string) string {
+	return policyARN[strings.LastIndex(policyARN, "/")+1:]
+}
@@ -0,0 +1,202 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testFixedPolicyName = "payments-registry-access"
+	testFixedPolicyARN  = "arn:aws:iam::111111111111:policy/" + testFixedPolicyName
+)
+
+func TestValidatePolicyName(t *testing.T) {
+	for _, policyName := range []string{"payments-registry-access", "a", "team_a+b=c,d.e@f", strings.Repeat("p", 128)} {
+		assert.NoError(t, validatePolicyName(policyName), "Unexpected error for policy name %s", policyName)
+	}
+	for _, policyName := range []string{"", "has space", "path/name", "quote'", strings.Repeat("p", 129)} {
+		assert.Error(t, validatePolicyName(policyName), "Expected error for policy name %s", policyName)
+	}
+}
+
+func TestPolicyNameFromARN(t *testing.T) {
+	assert.Equal(t, "myPolicy", policyNameFromARN("arn:aws:iam::111111111111:policy/myPolicy"))
+	assert.Equal(t, "myPolicy", policyNameFromARN("arn:aws:iam::111111111111:policy/team/myPolicy"))
+	assert.Equal(t, "", policyNameFromARN(""))
+}
+
+func TestCreateOrUpdateNamedPolicy_Created(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Do(func(x interface{}) {
+		input := x.(iam.CreatePolicyInput)
+		assert.Equal(t, testFixedPolicyName, aws.StringValue(input.PolicyName), "Expected the given name to be used as is")
+	}).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testFixedPolicyARN)}}, nil)
+
+	policyARN, updated, err := createOrUpdateNamedPolicy(testFixedPolicyName, "myRole", "{}", false, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error creating named policy")
+	assert.Equal(t, testFixedPolicyARN, policyARN)
+	assert.False(t, updated, "Expected new policy not to be reported as updated")
+}
+
+func TestCreateOrUpdateNamedPolicy_ErrorOnExistingWithoutUpdate(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, "policy exists", nil))
+	mocks.MockIAM.EXPECT().CreatePolicyVersion(gomock.Any(), gomock.Any()).Times(0)
+
+	_, _, err := createOrUpdateNamedPolicy(testFixedPolicyName, "myRole", "{}", false, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the policy exists and updates are not allowed")
+	assert.Contains(t, err.Error(), "--update-existing")
+}
+
+func TestCreateOrUpdateNamedPolicy_UpdatesExisting(t *testing.T) {
+	pages := [][]*iam.Policy{
+		{{PolicyName: aws.String("otherPolicy"), Arn: aws.String("arn:aws:iam::111111111111:policy/otherPolicy")}},
+		{{PolicyName: aws.String(testFixedPolicyName), Arn: aws.String(testFixedPolicyARN)}},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, "policy exists", nil)),
+		mocks.MockIAM.EXPECT().ListPoliciesPages(gomock.Any()).Do(func(x interface{}) {
+			fn := x.(func(*iam.ListPoliciesOutput, bool) bool)
+			for i, policies := range pages {
+				if !fn(&iam.ListPoliciesOutput{Policies: policies}, i == len(pages)-1) {
+					return
+				}
+			}
+		}).Return(nil),
+		mocks.MockIAM.EXPECT().ListPolicyVersions(testFixedPolicyARN).Return([]*iam.PolicyVersion{testPolicyVersion("v1", time.Now(), true)}, nil),
+		mocks.MockIAM.EXPECT().CreatePolicyVersion(testFixedPolicyARN, "{}").Return(&iam.PolicyVersion{VersionId: aws.String("v2")}, nil),
+	)
+
+	policyARN, updated, err := createOrUpdateNamedPolicy(testFixedPolicyName, "myRole", "{}", true, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error updating named policy")
+	assert.Equal(t, testFixedPolicyARN, policyARN)
+	assert.True(t, updated, "Expected existing policy to be reported as updated")
+}
+
+func TestCreateOrUpdateNamedPolicy_ErrorOnExistingNotFound(t *testing.T) {
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, "policy exists", nil)),
+		mocks.MockIAM.EXPECT().ListPoliciesPages(gomock.Any()).Return(nil),
+	)
+	mocks.MockIAM.EXPECT().CreatePolicyVersion(gomock.Any(), gomock.Any()).Times(0)
+
+	_, _, err := createOrUpdateNamedPolicy(testFixedPolicyName, "myRole", "{}", true, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the existing policy can't be found")
+}
+
+func TestCreateTaskExecutionRole_UpdateExistingPolicyNotInManifest(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	namedPolicy := &iam.AttachedPolicy{PolicyName: aws.String(testFixedPolicyName), PolicyArn: aws.String(testFixedPolicyARN)}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(nil, awserr.New(iam.ErrCodeEntityAlreadyExistsException, "policy exists", nil)),
+		mocks.MockIAM.EXPECT().ListPoliciesPages(gomock.Any()).Do(func(x interface{}) {
+			x.(func(*iam.ListPoliciesOutput, bool) bool)(&iam.ListPoliciesOutput{Policies: []*iam.Policy{{PolicyName: aws.String(testFixedPolicyName), Arn: aws.String(testFixedPolicyARN)}}}, true)
+		}).Return(nil),
+		mocks.MockIAM.EXPECT().ListPolicyVersions(testFixedPolicyARN).Return([]*iam.PolicyVersion{testPolicyVersion("v1", time.Now(), true)}, nil),
+		mocks.MockIAM.EXPECT().CreatePolicyVersion(testFixedPolicyARN, gomock.Any()).Return(&iam.PolicyVersion{VersionId: aws.String("v2")}, nil),
+		// the role already had the policy attached before the run
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return([]*iam.AttachedPolicy{namedPolicy}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testFixedPolicyARN, testLimitRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:          testCreds,
+		RoleName:             testLimitRoleName,
+		Region:               "us-west-2",
+		PolicyName:           testFixedPolicyName,
+		UpdateExistingPolicy: true,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when updating the existing named policy")
+	assert.True(t, roleResult.PolicyRefreshed, "Expected the named policy to be reported as refreshed")
+
+	// 'down' must neither detach nor delete the policy, which existed before the run
+	manifest := buildManifest([]*ExecutionRoleResult{roleResult}, nil, "us-west-2", time.Now().UTC())
+	assert.Empty(t, manifest.Roles, "Expected no changes to the role to be listed in the manifest")
+}
+
+func TestCreateTaskExecutionRole_PolicyName(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::111111111111:role/"+testLimitRoleName, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Do(func(x interface{}) {
+			input := x.(iam.CreatePolicyInput)
+			assert.Equal(t, testFixedPolicyName, aws.StringValue(input.PolicyName))
+		}).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testFixedPolicyARN)}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testFixedPolicyARN, testLimitRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: testCreds,
+		RoleName:    testLimitRoleName,
+		Region:      "us-west-2",
+		PolicyName:  testFixedPolicyName,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when creating a policy with a fixed name")
+	assert.Equal(t, testFixedPolicyARN, roleResult.PolicyARN)
+	assert.Equal(t, testFixedPolicyName, roleResult.PolicyName, "Expected the policy name to be recorded")
+	assert.False(t, roleResult.PolicyRefreshed)
+	assert.Equal(t, testFixedPolicyName, buildRoleOutputEntries([]*ExecutionRoleResult{roleResult}, getExecutionRolePolicyARN("us-west-2"))[0].PolicyName, "Expected the policy name in the output file")
+}
+
+func TestCreateTaskExecutionRoles_InvalidPolicyOptions(t *testing.T) {
+	testCases := map[string]ExecutionRoleParams{
+		"invalid policy name":           {PolicyName: "has space"},
+		"update existing without name":  {UpdateExistingPolicy: true},
+		"policy name with refresh":      {PolicyName: testFixedPolicyName, RefreshExistingPolicy: true},
+		"policy name longer than limit": {PolicyName: strings.Repeat("p", 129)},
+	}
+	for name, testParams := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mocks := setupTestController(t)
+			mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+
+			testParams.CredEntries = map[string]regcredio.CredsOutputEntry{
+				"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+			}
+			testParams.RoleName = testLimitRoleName
+			testParams.Region = "us-west-2"
+
+			_, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+			assert.Error(t, err, "Expected error for invalid policy options")
+		})
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	log "github.com/sirupsen/logrus"
+)
+
+// printPolicyDocument writes the document of the registry credentials policy the run created, updated or reused, as
+// indented JSON. It is the document submitted to IAM, marshaled again from the statements of the first role, which
+// share the policy.
+func printPolicyDocument(out io.Writer, roleResults []*ExecutionRoleResult) error {
+	if len(roleResults) == 0 || len(roleResults[0].PolicyStatements) == 0 {
+		log.Infof("No policy was created, so nothing is printed for '--%s'.", flags.PrintPolicyFlag)
+		return nil
+	}
+	policyDoc, err := marshalPolicyDocument(roleResults[0].PolicyStatements)
+	if err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err = json.Indent(&indented, []byte(policyDoc), jsonPrefix, jsonIndent); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, indented.String())
+	return err
+}
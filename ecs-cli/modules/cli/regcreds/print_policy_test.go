@@ -0,0 +1,50 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintPolicyDocument(t *testing.T) {
+	statements := []StatementEntry{{
+		Sid:      "MyRegistry",
+		Effect:   "Allow",
+		Action:   []string{"secretsmanager:GetSecretValue"},
+		Resource: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:my-registry"},
+	}}
+	roleResults := []*ExecutionRoleResult{{
+		RoleName:         "myRole",
+		PolicyARN:        "arn:aws:iam::111111111111:policy/myPolicy",
+		PolicyStatements: statements,
+	}}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, printPolicyDocument(out, roleResults))
+	assert.Contains(t, out.String(), "\n  \"Statement\": [", "Expected the document to be indented")
+
+	expected, err := marshalPolicyDocument(statements)
+	assert.NoError(t, err)
+	var compacted bytes.Buffer
+	assert.NoError(t, json.Compact(&compacted, out.Bytes()))
+	assert.Equal(t, expected, compacted.String(), "Expected the submitted document")
+
+	out.Reset()
+	assert.NoError(t, printPolicyDocument(out, []*ExecutionRoleResult{{RoleName: "myRole"}}))
+	assert.Empty(t, out.String(), "Expected nothing to be printed without a policy")
+}
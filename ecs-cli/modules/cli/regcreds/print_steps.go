@@ -0,0 +1,177 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// policyTimestampPlaceholder stands for the creation time in the name of a generated policy, which is only known once
+// the policy is created
+const policyTimestampPlaceholder = "<timestamp>"
+
+// upStepOptions are the options of 'up' which add steps outside of the role setup
+type upStepOptions struct {
+	SkipRole      bool
+	UpdateSecrets bool
+	Tags          map[string]*string
+	SkipOutput    bool
+	OutputDir     string
+	ManifestFile  string
+}
+
+// upSteps returns the steps 'up' would take, in order, with the names of the resources they create or change. No AWS
+// requests are made, so for resources which may already exist both outcomes are given.
+func upSteps(regCreds map[string]regcredio.RegistryCredEntry, params ExecutionRoleParams, options upStepOptions) []string {
+	var steps []string
+	registryNames := make([]string, 0, len(regCreds))
+	for registryName := range regCreds {
+		registryNames = append(registryNames, registryName)
+	}
+	sort.Strings(registryNames)
+	for _, registryName := range registryNames {
+		steps = append(steps, secretStep(registryName, regCreds[registryName], options.UpdateSecrets))
+	}
+
+	if !options.SkipRole {
+		steps = append(steps, roleSteps(len(regCreds), params)...)
+	}
+
+	if len(options.Tags) > 0 && len(regCreds) > 0 {
+		steps = append(steps, fmt.Sprintf("Tag the registry secrets with %s", formatStepTags(options.Tags)))
+	}
+	if options.ManifestFile != "" {
+		steps = append(steps, fmt.Sprintf("Write the manifest %s", options.ManifestFile))
+	}
+	if !options.SkipOutput {
+		outputDir := options.OutputDir
+		if outputDir == "" {
+			outputDir = "the current directory"
+		}
+		steps = append(steps, fmt.Sprintf("Write the registry credentials output file to %s", outputDir))
+	}
+	return steps
+}
+
+func secretStep(registryName string, entry regcredio.RegistryCredEntry, updateSecrets bool) string {
+	if entry.SecretManagerARN == "" {
+		return fmt.Sprintf("Create secret %s for registry %s, or use it if it exists", aws.StringValue(generateECSResourceName(registryName)), registryName)
+	}
+	if entry.HasCredPair() && updateSecrets {
+		return fmt.Sprintf("Update the value of secret %s for registry %s", entry.SecretManagerARN, registryName)
+	}
+	return fmt.Sprintf("Use existing secret %s for registry %s", entry.SecretManagerARN, registryName)
+}
+
+// roleSteps returns the steps of CreateTaskExecutionRoles: the policy document is generated, then each role is created,
+// then the policy, which is attached to each role along with the other policies in the attach order
+func roleSteps(registryCount int, params ExecutionRoleParams) []string {
+	var steps []string
+	roleNames := params.roleNames()
+	hasPolicy := params.createsPolicy(registryCount)
+	if hasPolicy {
+		steps = append(steps, fmt.Sprintf("Generate the registry credentials policy for %d registry secret(s)", registryCount))
+	}
+
+	for _, roleName := range roleNames {
+		step := fmt.Sprintf("Create role %s", roleName)
+		if params.Path != "" {
+			step += fmt.Sprintf(" with path %s", params.Path)
+		}
+		if params.PermissionsBoundary != "" {
+			step += fmt.Sprintf(" and permissions boundary %s", params.PermissionsBoundary)
+		}
+		steps = append(steps, step+", or reuse it if it exists")
+		if params.CreateInstanceProfile {
+			steps = append(steps, fmt.Sprintf("Create instance profile %s, or reuse it if it exists, and add role %s to it", roleName, roleName))
+		}
+	}
+
+	policyName := ""
+	if hasPolicy {
+		policyName = generatedPolicyStepName(roleNames[0], params)
+		steps = append(steps, createPolicyStep(policyName, params))
+	}
+
+	for _, roleName := range roleNames {
+		var attachments []string
+		if managedPolicyARN := params.managedPolicyARN(); managedPolicyARN != "" {
+			attachments = append(attachments, fmt.Sprintf("Attach AWS managed policy %s to role %s", managedPolicyARN, roleName))
+		}
+		if policyName != "" {
+			newPolicy := fmt.Sprintf("Attach policy %s to role %s", policyName, roleName)
+			if params.AttachOrder == AttachOrderGeneratedFirst {
+				attachments = append([]string{newPolicy}, attachments...)
+			} else {
+				attachments = append(attachments, newPolicy)
+			}
+		}
+		for _, policyARN := range params.AdditionalPolicyARNs {
+			attachments = append(attachments, fmt.Sprintf("Attach policy %s to role %s", policyARN, roleName))
+		}
+		steps = append(steps, attachments...)
+	}
+	return steps
+}
+
+// generatedPolicyStepName returns the name the new policy would be created with. The name of a content addressed
+// policy depends on the document, so it is described instead.
+func generatedPolicyStepName(roleName string, params ExecutionRoleParams) string {
+	switch {
+	case params.PolicyName != "":
+		return params.PolicyName
+	case params.PolicyNameFromHash:
+		return aws.StringValue(generateECSResourceName(contentPolicyNameMarker + "<hash of the policy document>"))
+	default:
+		return aws.StringValue(generateECSResourceName(roleName + "-policy-" + policyTimestampPlaceholder))
+	}
+}
+
+func createPolicyStep(policyName string, params ExecutionRoleParams) string {
+	switch {
+	case params.RefreshExistingPolicy:
+		return fmt.Sprintf("Update the policy generated by an earlier run for the existing roles, or create policy %s if there is none", policyName)
+	case params.PolicyName != "" && params.UpdateExistingPolicy:
+		return fmt.Sprintf("Create policy %s, or update it if it exists", policyName)
+	case params.PolicyNameFromHash:
+		return fmt.Sprintf("Create policy %s, or reuse it if it exists", policyName)
+	default:
+		return fmt.Sprintf("Create policy %s", policyName)
+	}
+}
+
+func formatStepTags(tags map[string]*string) string {
+	keys := sortedTagKeys(tags)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+aws.StringValue(tags[key]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// writeSteps writes the steps as a numbered list
+func writeSteps(out io.Writer, steps []string) error {
+	for i, step := range steps {
+		if _, err := fmt.Fprintf(out, "%d. %s\n", i+1, step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpSteps(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{
+		"b.example.com": {SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:b", Username: "user", Password: "pass"},
+		"a.example.com": {Username: "user", Password: "pass"},
+	}
+	params := ExecutionRoleParams{
+		RoleNames:             []string{"roleA", "roleB"},
+		Region:                "us-west-2",
+		CreateInstanceProfile: true,
+		AdditionalPolicyARNs:  []string{"arn:aws:iam::111111111111:policy/extra"},
+		AttachOrder:           AttachOrderGeneratedFirst,
+		PolicyName:            "myPolicy",
+		UpdateExistingPolicy:  true,
+	}
+	options := upStepOptions{
+		UpdateSecrets: true,
+		Tags:          map[string]*string{"Team": aws.String("payments"), "Env": aws.String("dev")},
+		OutputDir:     "out",
+		ManifestFile:  "manifest.json",
+	}
+
+	managed := getExecutionRolePolicyARN("us-west-2")
+	expected := []string{
+		"Create secret amazon-ecs-cli-setup-a.example.com for registry a.example.com, or use it if it exists",
+		"Update the value of secret arn:aws:secretsmanager:us-west-2:111111111111:secret:b for registry b.example.com",
+		"Generate the registry credentials policy for 2 registry secret(s)",
+		"Create role roleA, or reuse it if it exists",
+		"Create instance profile roleA, or reuse it if it exists, and add role roleA to it",
+		"Create role roleB, or reuse it if it exists",
+		"Create instance profile roleB, or reuse it if it exists, and add role roleB to it",
+		"Create policy myPolicy, or update it if it exists",
+		"Attach policy myPolicy to role roleA",
+		"Attach AWS managed policy " + managed + " to role roleA",
+		"Attach policy arn:aws:iam::111111111111:policy/extra to role roleA",
+		"Attach policy myPolicy to role roleB",
+		"Attach AWS managed policy " + managed + " to role roleB",
+		"Attach policy arn:aws:iam::111111111111:policy/extra to role roleB",
+		"Tag the registry secrets with Env=dev,Team=payments",
+		"Write the manifest manifest.json",
+		"Write the registry credentials output file to out",
+	}
+	assert.Equal(t, expected, upSteps(regCreds, params, options))
+}
+
+func TestUpSteps_GeneratedPolicyName(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{
+		"a.example.com": {SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:a"},
+	}
+	params := ExecutionRoleParams{RoleNames: []string{"myRole"}, Region: "us-west-2", Path: "/ecs/", MinimalManaged: true}
+
+	steps := upSteps(regCreds, params, upStepOptions{SkipOutput: true})
+	assert.Equal(t, []string{
+		"Use existing secret arn:aws:secretsmanager:us-west-2:111111111111:secret:a for registry a.example.com",
+		"Generate the registry credentials policy for 1 registry secret(s)",
+		"Create role myRole with path /ecs/, or reuse it if it exists",
+		"Create policy amazon-ecs-cli-setup-myRole-policy-<timestamp>",
+		"Attach policy amazon-ecs-cli-setup-myRole-policy-<timestamp> to role myRole",
+	}, steps, "Expected no managed policy with minimal managed actions")
+
+	params.PolicyNameFromHash = true
+	steps = upSteps(regCreds, params, upStepOptions{SkipOutput: true})
+	assert.Contains(t, steps, "Create policy amazon-ecs-cli-setup-sha256-<hash of the policy document>, or reuse it if it exists")
+}
+
+func TestUpSteps_RoleOnlyAndSkipRole(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{"a.example.com": {Username: "user", Password: "pass"}}
+	params := ExecutionRoleParams{RoleNames: []string{"myRole"}, Region: "us-west-2", RoleOnly: true}
+
+	steps := upSteps(regCreds, params, upStepOptions{SkipOutput: true})
+	assert.Equal(t, []string{
+		"Create secret amazon-ecs-cli-setup-a.example.com for registry a.example.com, or use it if it exists",
+		"Create role myRole, or reuse it if it exists",
+		"Attach AWS managed policy " + getExecutionRolePolicyARN("us-west-2") + " to role myRole",
+	}, steps, "Expected no policy steps with role only")
+
+	steps = upSteps(regCreds, params, upStepOptions{SkipRole: true})
+	assert.Equal(t, []string{
+		"Create secret amazon-ecs-cli-setup-a.example.com for registry a.example.com, or use it if it exists",
+		"Write the registry credentials output file to the current directory",
+	}, steps, "Expected only the secret and output steps without a role")
+}
+
+func TestWriteSteps(t *testing.T) {
+	out := &bytes.Buffer{}
+	assert.NoError(t, writeSteps(out, []string{"Create role myRole", "Create policy myPolicy"}))
+	assert.Equal(t, "1. Create role myRole\n2. Create policy myPolicy\n", out.String())
+}
@@ -0,0 +1,151 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ReconcileTagsAdditive tags new roles only; existing roles keep their tags as they are
+	ReconcileTagsAdditive = "additive"
+	// ReconcileTagsExact also converges the tags the ECS CLI manages on existing roles to the desired tags
+	ReconcileTagsExact = "exact"
+)
+
+const (
+	// managedTagKeysTagKey is the role tag listing the keys of the tags applied with ReconcileTagsExact, which are the
+	// only tags ever removed from a role
+	managedTagKeysTagKey = "ecs-cli:managed-tag-keys"
+	// IAM tag values can't contain commas
+	managedTagKeysSeparator = " "
+	maxTagValueLength       = 256
+)
+
+func validateReconcileTags(mode string) error {
+	switch mode {
+	case "", ReconcileTagsAdditive, ReconcileTagsExact:
+		return nil
+	}
+	return fmt.Errorf("invalid value '%s' for '--%s'; valid values are %s and %s", mode, flags.ReconcileTagsFlag, ReconcileTagsAdditive, ReconcileTagsExact)
+}
+
+// managedTagKeysTag returns the tag recording the keys of the given tags. Keys containing the separator can't be
+// recorded, so they are left out with a warning, and are not removed by later runs.
+func managedTagKeysTag(tags []*iam.Tag) (*iam.Tag, error) {
+	var keys []string
+	for _, tag := range tags {
+		key := aws.StringValue(tag.Key)
+		if strings.Contains(key, managedTagKeysSeparator) {
+			log.Warnf("Tag key '%s' contains a space, so it can't be recorded in '%s'; it won't be removed if it is no longer specified", key, managedTagKeysTagKey)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	value := strings.Join(keys, managedTagKeysSeparator)
+	if len(value) > maxTagValueLength {
+		return nil, fmt.Errorf("the keys of the role tags are too long to record in tag '%s', whose value can't exceed %d characters; specify fewer tags, or use '--%s %s'", managedTagKeysTagKey, maxTagValueLength, flags.ReconcileTagsFlag, ReconcileTagsAdditive)
+	}
+	return &iam.Tag{Key: aws.String(managedTagKeysTagKey), Value: aws.String(value)}, nil
+}
+
+// tagReconciliation lists the keys of the tags set on a role (added, or given another value) and removed from it
+type tagReconciliation struct {
+	SetKeys     []string
+	RemovedKeys []string
+}
+
+// reconcileRoleTags tags the existing role with each desired tag it doesn't already have with the same value, and
+// removes the tags which the managedTagKeysTagKey tag lists as applied by a previous run, but which are no longer
+// desired. Tags not listed there, such as those applied outside of the ECS CLI, are never removed.
+func reconcileRoleTags(roleName string, desired []*iam.Tag, client iamClient.Client) (tagReconciliation, error) {
+	current, err := client.ListRoleTags(roleName)
+	if err != nil {
+		return tagReconciliation{}, errors.Wrapf(err, "failed to list tags of role %s", roleName)
+	}
+	currentValues := make(map[string]string, len(current))
+	for _, tag := range current {
+		currentValues[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	reconciliation := tagReconciliation{}
+	desiredKeys := make(map[string]bool, len(desired))
+	var setTags []*iam.Tag
+	for _, tag := range sortedIAMTags(desired) {
+		key := aws.StringValue(tag.Key)
+		desiredKeys[key] = true
+		if value, ok := currentValues[key]; !ok || value != aws.StringValue(tag.Value) {
+			setTags = append(setTags, tag)
+			reconciliation.SetKeys = append(reconciliation.SetKeys, key)
+		}
+	}
+
+	previousKeys := make(map[string]bool)
+	if value, ok := currentValues[managedTagKeysTagKey]; ok && value != "" {
+		for _, key := range strings.Split(value, managedTagKeysSeparator) {
+			previousKeys[key] = true
+		}
+	}
+	for _, key := range sortedKeys(previousKeys) {
+		if _, tagged := currentValues[key]; tagged && !desiredKeys[key] {
+			reconciliation.RemovedKeys = append(reconciliation.RemovedKeys, key)
+		}
+	}
+
+	if len(setTags) > 0 {
+		if err = client.TagRole(roleName, setTags); err != nil {
+			return tagReconciliation{}, errors.Wrapf(err, "failed to tag role %s", roleName)
+		}
+		log.Infof("Set tags %s on role %s", strings.Join(reconciliation.SetKeys, ", "), roleName)
+	}
+	if len(reconciliation.RemovedKeys) > 0 {
+		if err = client.UntagRole(roleName, reconciliation.RemovedKeys); err != nil {
+			return reconciliation, errors.Wrapf(err, "failed to remove tags from role %s", roleName)
+		}
+		log.Infof("Removed tags %s, which are no longer specified, from role %s", strings.Join(reconciliation.RemovedKeys, ", "), roleName)
+	}
+	return reconciliation, nil
+}
+
+// sortedIAMTags returns the tags in order of key, so that requests are stable
+func sortedIAMTags(tags []*iam.Tag) []*iam.Tag {
+	sorted := append([]*iam.Tag{}, tags...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return aws.StringValue(sorted[i].Key) < aws.StringValue(sorted[j].Key)
+	})
+	return sorted
+}
+
+// tagsMap returns the tags by key, or nil if there are none
+func tagsMap(tags []*iam.Tag) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	tagMap := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		tagMap[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tagMap
+}
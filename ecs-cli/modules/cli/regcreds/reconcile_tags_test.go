@@ -0,0 +1,178 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTag(key, value string) *iam.Tag {
+	return &iam.Tag{Key: aws.String(key), Value: aws.String(value)}
+}
+
+func TestValidateReconcileTags(t *testing.T) {
+	for _, mode := range []string{"", ReconcileTagsAdditive, ReconcileTagsExact} {
+		assert.NoError(t, validateReconcileTags(mode), "Unexpected error for mode '%s'", mode)
+	}
+	assert.Error(t, validateReconcileTags("replace"), "Expected error for unknown mode")
+}
+
+func TestManagedTagKeysTag(t *testing.T) {
+	tag, err := managedTagKeysTag([]*iam.Tag{testTag("Team", "payments"), testTag("ManagedBy", "ecs-cli"), testTag("has space", "x")})
+	assert.NoError(t, err, "Unexpected error recording tag keys")
+	assert.Equal(t, testTag(managedTagKeysTagKey, "ManagedBy Team"), tag, "Expected sorted keys without the key containing a space")
+
+	var tags []*iam.Tag
+	for i := 0; i < 50; i++ {
+		tags = append(tags, testTag(strings.Repeat("k", 5)+string(rune('a'+i%26))+strings.Repeat("x", i), "v"))
+	}
+	_, err = managedTagKeysTag(tags)
+	assert.Error(t, err, "Expected error when the keys exceed the tag value limit")
+}
+
+func TestReconcileRoleTags(t *testing.T) {
+	desired := []*iam.Tag{
+		testTag("Team", "payments"),
+		testTag("ManagedBy", "ecs-cli"),
+		testTag(managedTagKeysTagKey, "ManagedBy Team"),
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListRoleTags(testLimitRoleName).Return([]*iam.Tag{
+			testTag("ManagedBy", "ecs-cli"),
+			testTag("Team", "platform"),
+			testTag("CostCenter", "42"),
+			testTag("Owner", "someone-else"),
+			testTag(IdempotencyKeyTagKey, "build-1"),
+			testTag(managedTagKeysTagKey, "CostCenter ManagedBy Team"),
+		}, nil),
+		mocks.MockIAM.EXPECT().TagRole(testLimitRoleName, []*iam.Tag{
+			testTag("Team", "payments"),
+			testTag(managedTagKeysTagKey, "ManagedBy Team"),
+		}).Return(nil),
+		// Owner and the idempotency tag were not applied by an exact run, so they are kept
+		mocks.MockIAM.EXPECT().UntagRole(testLimitRoleName, []string{"CostCenter"}).Return(nil),
+	)
+
+	reconciliation, err := reconcileRoleTags(testLimitRoleName, desired, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error reconciling tags")
+	assert.Equal(t, tagReconciliation{
+		SetKeys:     []string{"Team", managedTagKeysTagKey},
+		RemovedKeys: []string{"CostCenter"},
+	}, reconciliation)
+}
+
+func TestReconcileRoleTags_NoChanges(t *testing.T) {
+	desired := []*iam.Tag{testTag("ManagedBy", "ecs-cli"), testTag(managedTagKeysTagKey, "ManagedBy")}
+
+	mocks := setupTestController(t)
+	// keys no longer on the role are not removed again
+	mocks.MockIAM.EXPECT().ListRoleTags(testLimitRoleName).Return([]*iam.Tag{
+		testTag("ManagedBy", "ecs-cli"),
+		testTag(managedTagKeysTagKey, "ManagedBy"),
+	}, nil)
+	mocks.MockIAM.EXPECT().TagRole(gomock.Any(), gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().UntagRole(gomock.Any(), gomock.Any()).Times(0)
+
+	reconciliation, err := reconcileRoleTags(testLimitRoleName, desired, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error reconciling tags")
+	assert.Empty(t, reconciliation.SetKeys)
+	assert.Empty(t, reconciliation.RemovedKeys)
+}
+
+func TestReconcileRoleTags_ErrorOnTagRole(t *testing.T) {
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListRoleTags(testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().TagRole(testLimitRoleName, gomock.Any()).Return(awserr.New("AccessDenied", "not authorized", nil)),
+	)
+	mocks.MockIAM.EXPECT().UntagRole(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := reconcileRoleTags(testLimitRoleName, []*iam.Tag{testTag("ManagedBy", "ecs-cli")}, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the role can't be tagged")
+}
+
+func TestCreateTaskExecutionRole_ReconcileTagsExact(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	policyARN := "arn:aws:iam::111111111111:policy/myPolicy"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		// an existing role is found
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().ListRoleTags(testLimitRoleName).Return([]*iam.Tag{testTag(DefaultManagementTagKey, DefaultManagementTagValue)}, nil),
+		mocks.MockIAM.EXPECT().TagRole(testLimitRoleName, []*iam.Tag{
+			testTag("Team", "payments"),
+			testTag(managedTagKeysTagKey, DefaultManagementTagKey+" Team"),
+		}).Return(nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(policyARN)}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(policyARN, testLimitRoleName).Return(nil, nil),
+	)
+	mocks.MockIAM.EXPECT().UntagRole(gomock.Any(), gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:   testCreds,
+		RoleName:      testLimitRoleName,
+		Region:        "us-west-2",
+		Tags:          map[string]*string{"Team": aws.String("payments")},
+		ReconcileTags: ReconcileTagsExact,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error reconciling the tags of an existing role")
+	assert.False(t, roleResult.RoleCreated)
+	assert.Len(t, roleResult.Tags, 3, "Expected the tags of the existing role to be recorded")
+}
+
+func TestCreateTaskExecutionRole_ReconcileTagsWarnOnFailure(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	policyARN := "arn:aws:iam::111111111111:policy/myPolicy"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().ListRoleTags(testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().TagRole(testLimitRoleName, gomock.Any()).Return(awserr.New("AccessDenied", "not authorized", nil)),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(policyARN)}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(policyARN, testLimitRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:    testCreds,
+		RoleName:       testLimitRoleName,
+		Region:         "us-west-2",
+		Tags:           map[string]*string{"Team": aws.String("payments")},
+		ReconcileTags:  ReconcileTagsExact,
+		TagFailureMode: TagFailureWarn,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Expected only a warning when the existing role can't be tagged")
+	assert.Empty(t, roleResult.Tags, "Expected no tags to be recorded for the role")
+}
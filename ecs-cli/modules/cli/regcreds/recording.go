@@ -0,0 +1,68 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws/request"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+type handlersAdder interface {
+	AddTo(handlers *request.Handlers)
+}
+
+// runRecordings are the recorders and replayers of the run by directory. Some commands create more than one session,
+// and their calls must be numbered (or replayed) in a single sequence.
+var runRecordings = make(map[string]handlersAdder)
+
+// applyRecording writes the calls made by the clients created from the command's session to the --record directory,
+// or answers them from the --replay directory instead of AWS
+func applyRecording(c *cli.Context, commandConfig *config.CommandConfig) error {
+	recordDir := c.String(flags.RecordFlag)
+	replayDir := c.String(flags.ReplayFlag)
+	if recordDir != "" && replayDir != "" {
+		return fmt.Errorf("'--%s' can't be used with '--%s'", flags.RecordFlag, flags.ReplayFlag)
+	}
+
+	var adder handlersAdder
+	var err error
+	switch {
+	case recordDir != "":
+		if adder = runRecordings[flags.RecordFlag+":"+recordDir]; adder == nil {
+			if adder, err = clients.NewRecorder(recordDir); err != nil {
+				return err
+			}
+			log.Infof("Recording AWS API calls to %s", recordDir)
+			runRecordings[flags.RecordFlag+":"+recordDir] = adder
+		}
+	case replayDir != "":
+		if adder = runRecordings[flags.ReplayFlag+":"+replayDir]; adder == nil {
+			if adder, err = clients.NewReplayer(replayDir); err != nil {
+				return err
+			}
+			log.Warnf("Replaying AWS API calls from %s; no requests are sent to AWS", replayDir)
+			runRecordings[flags.ReplayFlag+":"+replayDir] = adder
+		}
+	default:
+		return nil
+	}
+	adder.AddTo(&commandConfig.Session.Handlers)
+	return nil
+}
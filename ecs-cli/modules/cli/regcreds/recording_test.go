@@ -0,0 +1,54 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func TestApplyRecording_SharedBySessionsOfARun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-cli-recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.String(flags.RecordFlag, dir, "")
+	context := cli.NewContext(nil, flagSet, nil)
+
+	first := &config.CommandConfig{Session: session.New()}
+	second := &config.CommandConfig{Session: session.New()}
+	require.NoError(t, applyRecording(context, first))
+	assert.NoError(t, applyRecording(context, second), "Expected a second session of the run to share the recorder")
+	assert.Equal(t, 1, first.Session.Handlers.Complete.Len()-session.New().Handlers.Complete.Len())
+	assert.Equal(t, 1, second.Session.Handlers.Complete.Len()-session.New().Handlers.Complete.Len())
+}
+
+func TestApplyRecording_ErrorWithRecordAndReplay(t *testing.T) {
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.String(flags.RecordFlag, "record", "")
+	flagSet.String(flags.ReplayFlag, "replay", "")
+
+	err := applyRecording(cli.NewContext(nil, flagSet, nil), &config.CommandConfig{Session: session.New()})
+	assert.Error(t, err, "Expected error when both --record and --replay are given")
+}
@@ -0,0 +1,97 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxPolicyVersions is the IAM quota of versions kept for a managed policy
+const maxPolicyVersions = 5
+
+// findRefreshablePolicy returns the ARN of the policy generated by the ecs-cli which is attached to the existing roles,
+// or an empty string if none of them has one. If a role has several generated policies, the newest is used. Since the
+// refreshed policy is shared by all roles, it is an error for the roles to have different generated policies.
+func findRefreshablePolicy(results []*ExecutionRoleResult, client iamClient.Client) (string, error) {
+	policyARN := ""
+	policyRole := ""
+	for _, result := range results {
+		if result.Err != nil || result.RoleCreated {
+			continue
+		}
+		attached, err := client.ListAttachedRolePolicies(result.RoleName)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to list policies attached to role %s", result.RoleName)
+		}
+		generated, err := stalePolicies(attached, client)
+		if err != nil {
+			return "", err
+		}
+		if len(generated) == 0 {
+			continue
+		}
+		newest := aws.StringValue(generated[len(generated)-1].Arn)
+		if len(generated) > 1 {
+			log.Warnf("Role %s has %d policies generated by the ECS CLI; only the newest, %s, is refreshed", result.RoleName, len(generated), newest)
+		}
+		if policyARN != "" && newest != policyARN {
+			return "", fmt.Errorf("roles %s and %s have different policies generated by the ECS CLI (%s and %s), so a single policy can't be refreshed for both", policyRole, result.RoleName, policyARN, newest)
+		}
+		policyARN = newest
+		policyRole = result.RoleName
+	}
+	return policyARN, nil
+}
+
+// refreshPolicy sets the content of the policy to policyDoc by creating a new default version. The oldest non-default
+// versions are deleted first so that the policy stays within the IAM limit of versions.
+func refreshPolicy(policyARN, policyDoc string, client iamClient.Client) error {
+	versions, err := client.ListPolicyVersions(policyARN)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list versions of policy %s", policyARN)
+	}
+
+	var oldVersions []*iam.PolicyVersion
+	for _, version := range versions {
+		if !aws.BoolValue(version.IsDefaultVersion) {
+			oldVersions = append(oldVersions, version)
+		}
+	}
+	sort.SliceStable(oldVersions, func(i, j int) bool {
+		return aws.TimeValue(oldVersions[i].CreateDate).Before(aws.TimeValue(oldVersions[j].CreateDate))
+	})
+
+	for excess := len(versions) - maxPolicyVersions + 1; excess > 0 && len(oldVersions) > 0; excess-- {
+		versionID := aws.StringValue(oldVersions[0].VersionId)
+		if err = client.DeletePolicyVersion(policyARN, versionID); err != nil {
+			return errors.Wrapf(err, "failed to delete version %s of policy %s", versionID, policyARN)
+		}
+		log.Infof("Deleted version %s of policy %s", versionID, policyARN)
+		oldVersions = oldVersions[1:]
+	}
+
+	version, err := client.CreatePolicyVersion(policyARN, policyDoc)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a new version of policy %s", policyARN)
+	}
+	log.Infof("Refreshed task execution role policy %s with new default version %s", policyARN, aws.StringValue(version.VersionId))
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindRefreshablePolicy(t *testing.T) {
+	older := testGeneratedPolicy("20190101T000000Z")
+	newest := testGeneratedPolicy("20190601T000000Z")
+	results := []*ExecutionRoleResult{
+		{RoleName: "myNewRole", RoleCreated: true},
+		{RoleName: "myReusedRole"},
+		{RoleName: "myOtherReusedRole"},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myReusedRole").Return(append(testAttachedPolicies(1), newest, older), nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myOtherReusedRole").Return(testAttachedPolicies(2), nil),
+	)
+	expectPolicyDetails(mocks.MockIAM, append(testAttachedPolicies(2), newest, older)...)
+
+	policyARN, err := findRefreshablePolicy(results, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when finding the policy to refresh")
+	assert.Equal(t, aws.StringValue(newest.PolicyArn), policyARN, "Expected the newest generated policy to be refreshed")
+}
+
+func TestFindRefreshablePolicy_NoGeneratedPolicy(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myReusedRole").Return(testAttachedPolicies(2), nil)
+	expectPolicyDetails(mocks.MockIAM, testAttachedPolicies(2)...)
+
+	policyARN, err := findRefreshablePolicy([]*ExecutionRoleResult{{RoleName: "myReusedRole"}}, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when no role has a generated policy")
+	assert.Empty(t, policyARN)
+}
+
+func TestFindRefreshablePolicy_ErrorOnDifferentPolicies(t *testing.T) {
+	results := []*ExecutionRoleResult{{RoleName: "myReusedRole"}, {RoleName: "myOtherReusedRole"}}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myReusedRole").Return([]*iam.AttachedPolicy{testGeneratedPolicy("20190101T000000Z")}, nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies("myOtherReusedRole").Return([]*iam.AttachedPolicy{testGeneratedPolicy("20190601T000000Z")}, nil),
+	)
+	expectPolicyDetails(mocks.MockIAM, testGeneratedPolicy("20190101T000000Z"), testGeneratedPolicy("20190601T000000Z"))
+
+	_, err := findRefreshablePolicy(results, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when roles have different generated policies")
+}
+
+func TestRefreshPolicy_DeletesOldestVersions(t *testing.T) {
+	policyARN := aws.StringValue(testGeneratedPolicy("20190101T000000Z").PolicyArn)
+	created := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	versions := []*iam.PolicyVersion{
+		testPolicyVersion("v5", created.Add(4*time.Hour), true),
+		testPolicyVersion("v2", created.Add(time.Hour), false),
+		testPolicyVersion("v1", created, false),
+		testPolicyVersion("v4", created.Add(3*time.Hour), false),
+		testPolicyVersion("v3", created.Add(2*time.Hour), false),
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListPolicyVersions(policyARN).Return(versions, nil),
+		mocks.MockIAM.EXPECT().DeletePolicyVersion(policyARN, "v1").Return(nil),
+		mocks.MockIAM.EXPECT().CreatePolicyVersion(policyARN, "{}").Return(&iam.PolicyVersion{VersionId: aws.String("v6")}, nil),
+	)
+
+	err := refreshPolicy(policyARN, "{}", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when refreshing policy")
+}
+
+func TestRefreshPolicy_BelowVersionLimit(t *testing.T) {
+	policyARN := aws.StringValue(testGeneratedPolicy("20190101T000000Z").PolicyArn)
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListPolicyVersions(policyARN).Return([]*iam.PolicyVersion{testPolicyVersion("v1", time.Now(), true)}, nil),
+		mocks.MockIAM.EXPECT().CreatePolicyVersion(policyARN, "{}").Return(&iam.PolicyVersion{VersionId: aws.String("v2")}, nil),
+	)
+	mocks.MockIAM.EXPECT().DeletePolicyVersion(gomock.Any(), gomock.Any()).Times(0)
+
+	err := refreshPolicy(policyARN, "{}", mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error when refreshing policy")
+}
+
+func TestCreateTaskExecutionRole_RefreshExistingPolicy(t *testing.T) {
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+	}
+	generated := testGeneratedPolicy("20190101T000000Z")
+	policyARN := aws.StringValue(generated.PolicyArn)
+
+	mocks := setupTestController(t)
+	expectPolicyDetails(mocks.MockIAM, generated)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(expectedCreateRoleInput(testLimitRoleName, defaultManagementTags())).Return("", nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return([]*iam.AttachedPolicy{generated}, nil),
+		mocks.MockIAM.EXPECT().ListPolicyVersions(policyARN).Return([]*iam.PolicyVersion{testPolicyVersion("v1", time.Now(), true)}, nil),
+		mocks.MockIAM.EXPECT().CreatePolicyVersion(policyARN, gomock.Any()).Return(&iam.PolicyVersion{VersionId: aws.String("v2")}, nil),
+		mocks.MockIAM.EXPECT().ListAttachedRolePolicies(testLimitRoleName).Return([]*iam.AttachedPolicy{generated}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(policyARN, testLimitRoleName).Return(nil, nil),
+	)
+	// no additional policy is created
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:           testCreds,
+		RoleName:              testLimitRoleName,
+		Region:                "us-west-2",
+		RefreshExistingPolicy: true,
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when refreshing the existing policy")
+	assert.Equal(t, policyARN, roleResult.PolicyARN)
+	assert.True(t, roleResult.PolicyRefreshed, "Expected policy to be reported as refreshed")
+	assert.True(t, roleResult.PolicyAttachedBefore, "Expected policy to be reported as attached before the run")
+
+	// the refreshed policy, which existed before the run, is left for 'down'
+	manifest := buildManifest([]*ExecutionRoleResult{roleResult}, nil, "us-west-2", time.Now().UTC())
+	assert.Empty(t, manifest.Roles, "Expected no changes to the role to be listed in the manifest")
+}
+
+func TestCreateTaskExecutionRoles_ErrorOnRefreshWithPruneStale(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+		},
+		RoleName:              testLimitRoleName,
+		Region:                "us-west-2",
+		RefreshExistingPolicy: true,
+		PruneStalePolicies:    true,
+	}
+
+	_, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when refreshing and pruning policies together")
+}
+
+func testPolicyVersion(versionID string, createDate time.Time, isDefault bool) *iam.PolicyVersion {
+	return &iam.PolicyVersion{
+		VersionId:        aws.String(versionID),
+		CreateDate:       aws.Time(createDate),
+		IsDefaultVersion: aws.Bool(isDefault),
+	}
+}
@@ -15,18 +15,26 @@ package regcreds
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	orgsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/organizations"
+	s3Client "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/s3"
 	secretsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/secretsmanager"
+	ssmClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/ssm"
+	stsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/sts"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/tagging"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
 	taggingSDK "github.com/aws/aws-sdk-go/service/resourcegroupstaggingapi"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/pkg/errors"
@@ -42,74 +50,747 @@ const (
 func Up(c *cli.Context) {
 	args := c.Args()
 
-	if len(args) != 1 {
+	inputFile := ""
+	if len(c.StringSlice(flags.SecretFlag)) > 0 {
+		if len(args) != 0 {
+			log.Fatalf("Error executing 'up': '--%s' can't be used with a credential file", flags.SecretFlag)
+		}
+	} else if len(args) != 1 {
 		log.Fatal("Exactly 1 credential file is required. Found: ", len(args))
+	} else {
+		inputFile = args[0]
+	}
+
+	if c.Bool(flags.FailFastFlag) && c.Bool(flags.ContinueFlag) {
+		log.Fatalf("Error executing 'up': only one of '--%s' and '--%s' can be specified", flags.FailFastFlag, flags.ContinueFlag)
+	}
+	// results printed to stdout must not be mixed with logs
+	printsResults := c.String(flags.FormatFlag) != "" || c.Bool(flags.SummaryOnlyFlag) || c.Bool(flags.PrintARNOnlyFlag) || c.Bool(flags.PrintPolicyFlag) || c.Bool(flags.PrintStepsFlag) || c.String(flags.EmitTerraformImportFlag) == terraformImportStdout
+	if err := setLogOutput(log.StandardLogger(), c.String(flags.LogOutputFlag), printsResults, os.Stdout, os.Stderr); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+
+	var store regcredio.Store = regcredio.FileStore{}
+	if isS3URL(inputFile) {
+		// the input is read with the same credentials and region as the other clients
+		store = s3InputStore{Store: store, client: s3Client.NewS3Client(getNewCommandConfig(c, "", ""))}
+	}
+	if value := c.String(flags.OutputPermissionsFlag); value != "" {
+		perm, err := parseOutputPermissions(value)
+		if err != nil {
+			log.Fatal("Error executing 'up': ", err)
+		}
+		// applies to the output file and all other files written by the run
+		store = regcredio.WithFilePermissions(store, perm)
+	}
+	environments, err := parseEnvironments(c.String(flags.OutputPerEnvFlag))
+	if err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+	if len(environments) == 0 {
+		if err = upEnvironment(c, store, inputFile, ""); err != nil {
+			log.Fatal("Error executing 'up': ", err)
+		}
+		return
+	}
+	if err = validateEnvironmentOutputFileName(c.String(flags.OutputFileNameFlag), environments); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+
+	// each environment is set up in full, with its own role, policy, output file and manifest, before the next one
+	envErrs := make(map[string]error, len(environments))
+	for _, environment := range environments {
+		log.Infof("Setting up registry credentials for environment %s...", environment)
+		if err = os.Setenv(EnvironmentEnvVar, environment); err != nil {
+			log.Fatal("Error executing 'up': ", err)
+		}
+		err = upEnvironment(c, store, inputFile, environment)
+		if err == nil {
+			continue
+		}
+		if !c.Bool(flags.ContinueFlag) {
+			log.Fatalf("Error executing 'up': environment %s: %v", environment, err)
+		}
+		log.Warnf("Environment %s failed; continuing with the next environment", environment)
+		envErrs[environment] = err
+	}
+	if !c.Bool(flags.ContinueFlag) {
+		return
+	}
+	if err = environmentsResult(environments, envErrs); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+}
+
+// upEnvironment creates the resources for a single run of 'up', reading and writing files with the given store. If an
+// environment is given, it is used to name the output file and manifest, and references to environment variables in
+// role names are expanded. If no input file is given, the registries are given with '--secret' flags.
+func upEnvironment(c *cli.Context, store regcredio.Store, inputFile, environment string) (err error) {
+	startTime := time.Now()
+	summaryOnly := c.Bool(flags.SummaryOnlyFlag)
+	printARNOnly := c.Bool(flags.PrintARNOnlyFlag)
+	if summaryOnly && printARNOnly {
+		return fmt.Errorf("only one of '--%s' and '--%s' can be specified", flags.SummaryOnlyFlag, flags.PrintARNOnlyFlag)
+	}
+	if err = validateUpFormat(c.String(flags.FormatFlag), summaryOnly || printARNOnly); err != nil {
+		return err
+	}
+	if c.Bool(flags.PrintPolicyFlag) && (summaryOnly || printARNOnly || c.String(flags.FormatFlag) != "") {
+		return fmt.Errorf("'--%s' can't be used with '--%s', '--%s' or '--%s', which also print to stdout", flags.PrintPolicyFlag, flags.FormatFlag, flags.SummaryOnlyFlag, flags.PrintARNOnlyFlag)
+	}
+	printSteps := c.Bool(flags.PrintStepsFlag)
+	if printSteps && (summaryOnly || printARNOnly || c.String(flags.FormatFlag) != "" || c.Bool(flags.PrintPolicyFlag) || c.Bool(flags.SimulateFlag)) {
+		return fmt.Errorf("'--%s' can't be used with '--%s', '--%s', '--%s', '--%s' or '--%s'", flags.PrintStepsFlag, flags.FormatFlag, flags.SummaryOnlyFlag, flags.PrintARNOnlyFlag, flags.PrintPolicyFlag, flags.SimulateFlag)
+	}
+	restoreLogOutput := func() {}
+	if summaryOnly || printARNOnly {
+		// logs are only written out if the command fails
+		var flushLogOutput func()
+		restoreLogOutput, flushLogOutput = bufferLogOutput()
+		defer func() {
+			if err != nil {
+				flushLogOutput()
+			}
+		}()
+	}
+
+	var credsInput *regcredio.ECSRegCredsInput
+	if inputFile == "" {
+		credsInput, err = secretFlagsInput(c.StringSlice(flags.SecretFlag))
+		inputFile = secretFlagsSource
+	} else {
+		credsInput, err = regcredio.ReadCredsInputFrom(store, inputFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	// if no region is given, use the region shared by all existing secrets (if any)
+	inferredRegion := ""
+	if config.RecursiveFlagSearch(c, flags.RegionFlag) == "" {
+		inferredRegion = inferRegionFromCreds(credsInput.RegistryCredentials)
 	}
 
 	// create clients
-	commandConfig := getNewCommandConfig(c)
+	commandConfig := getNewCommandConfig(c, inferredRegion, regionSourceCredsInput)
+	var requestIDs *requestIDRecorder
+	if c.Bool(flags.OutputRequestIDsFlag) {
+		requestIDs = newRequestIDRecorder()
+		requestIDs.AddTo(&commandConfig.Session.Handlers)
+	}
 
 	smClient := secretsClient.NewSecretsManagerClient(commandConfig)
 	kmsClient := kms.NewKMSClient(commandConfig)
-	iamClient := iam.NewIAMClient(commandConfig)
+	if c.Bool(flags.AssumeKMSARNValidFlag) {
+		kmsClient = newAssumeValidKeyARNClient(kmsClient)
+	}
+	// tagging a role created by the run is retried while IAM may not find it yet, unless retries are disabled
+	tagWindow := tagRetryWindow
+	if retriesDisabled(c) {
+		tagWindow = 0
+	}
+	iamClient := newTagRetryClient(iam.NewIAMClient(commandConfig), tagWindow)
 
 	// validate provided values before creating any resources
-	credsInput, err := regcredio.ReadCredsInput(args[0])
+
+	region := aws.StringValue(commandConfig.Session.Config.Region)
+
+	roleNames, err := expandRoleNames(c.StringSlice(flags.RoleNameFlag), environment)
 	if err != nil {
-		log.Fatal("Error executing 'up': ", err)
+		return err
+	}
+	skipRole := c.Bool(flags.NoRoleFlag)
+	allowEmpty := c.Bool(flags.AllowEmptyFlag)
+	roleOnly := c.Bool(flags.RoleOnlyFlag)
+	attachRetrySeconds := c.Int(flags.RetryAccessDeniedFlag)
+	if attachRetrySeconds < 0 {
+		return fmt.Errorf("'--%s' must not be negative", flags.RetryAccessDeniedFlag)
+	}
+	if attachRetrySeconds > 0 && retriesDisabled(c) {
+		return fmt.Errorf("'--%s' can't be used with '--%s 0', which disables retries", flags.RetryAccessDeniedFlag, flags.MaxRetriesFlag)
+	}
+	attachRetryValue := ""
+	if attachRetrySeconds > 0 {
+		attachRetryValue = strconv.Itoa(attachRetrySeconds)
+	}
+	concurrency := DefaultConcurrency
+	if c.IsSet(flags.ConcurrencyFlag) {
+		concurrency = c.Int(flags.ConcurrencyFlag)
+		if err = validateConcurrency(concurrency); err != nil {
+			return err
+		}
 	}
 
-	validatedRegCreds, err := validateCredsInput(*credsInput, kmsClient)
+	if c.Bool(flags.StrictARNParsingFlag) {
+		if err = strictARNError(findStrictARNProblems(*credsInput), inputFile); err != nil {
+			return err
+		}
+	}
+
+	validatedRegCreds := make(map[string]regcredio.RegistryCredEntry)
+	if len(credsInput.RegistryCredentials) == 0 && roleOnly {
+		log.Infof("No registry credentials found in %s; only the task execution role will be created.", inputFile)
+	} else if len(credsInput.RegistryCredentials) == 0 && allowEmpty {
+		log.Warnf("No registry credentials found in %s; only the task execution role will be created.", inputFile)
+	} else if len(credsInput.RegistryCredentials) == 0 {
+		return fmt.Errorf("no registry credentials found in %s; add at least one registry under 'registry_credentials', or use '--%s' to create only the task execution role", inputFile, flags.AllowEmptyFlag)
+	} else {
+		validatedRegCreds, err = validateCredsInput(*credsInput, region, kmsClient)
+		if err != nil {
+			return err
+		}
+	}
+	if c.Bool(flags.ValidateSecretsExistFlag) {
+		if err = validateSecretsExist(validatedRegCreds, smClient, ssmClient.NewSSMClient(commandConfig)); err != nil {
+			return err
+		}
+	}
+
+	err = validateRoleDetails(roleNames, skipRole, map[string]string{
+		flags.VersionStageFlag:          c.String(flags.VersionStageFlag),
+		flags.SeparateStatementsFlag:    boolFlagValue(c, flags.SeparateStatementsFlag),
+		flags.PermissionsBoundaryFlag:   c.String(flags.PermissionsBoundaryFlag),
+		flags.RoleBundleFlag:            c.String(flags.RoleBundleFlag),
+		flags.CreateInstanceProfileFlag: boolFlagValue(c, flags.CreateInstanceProfileFlag),
+		flags.PruneStaleFlag:            boolFlagValue(c, flags.PruneStaleFlag),
+		flags.AttachPolicyFlag:          strings.Join(c.StringSlice(flags.AttachPolicyFlag), ","),
+		flags.PrintARNOnlyFlag:          boolFlagValue(c, flags.PrintARNOnlyFlag),
+		flags.PrintPolicyFlag:           boolFlagValue(c, flags.PrintPolicyFlag),
+		flags.ExplainFlag:               boolFlagValue(c, flags.ExplainFlag),
+		flags.CheckPermissionsFlag:      boolFlagValue(c, flags.CheckPermissionsFlag),
+		flags.IAMPathFlag:               c.String(flags.IAMPathFlag),
+		flags.AllowEmptyFlag:            boolFlagValue(c, flags.AllowEmptyFlag),
+		flags.RoleOnlyFlag:              boolFlagValue(c, flags.RoleOnlyFlag),
+		flags.VerifyAccountFlag:         boolFlagValue(c, flags.VerifyAccountFlag),
+		flags.AccountIDFlag:             c.String(flags.AccountIDFlag),
+		flags.EnforceTagPolicyFlag:      boolFlagValue(c, flags.EnforceTagPolicyFlag),
+		flags.LintFlag:                  boolFlagValue(c, flags.LintFlag),
+		flags.LintRulesFlag:             c.String(flags.LintRulesFlag),
+		flags.RetryAccessDeniedFlag:     attachRetryValue,
+		flags.RoleNamePrefixFlag:        c.String(flags.RoleNamePrefixFlag),
+		flags.RoleNameSuffixFlag:        c.String(flags.RoleNameSuffixFlag),
+		flags.IdempotencyKeyFlag:        c.String(flags.IdempotencyKeyFlag),
+		flags.OnlyIfChangedFlag:         boolFlagValue(c, flags.OnlyIfChangedFlag),
+		flags.SaveAsFlag:                c.String(flags.SaveAsFlag),
+		flags.MultiRegionKeysFlag:       c.String(flags.MultiRegionKeysFlag),
+		flags.IncludeECRFlag:            boolFlagValue(c, flags.IncludeECRFlag),
+		flags.RefreshExistingPolicyFlag: boolFlagValue(c, flags.RefreshExistingPolicyFlag),
+		flags.DenyUnlessTagFlag:         c.String(flags.DenyUnlessTagFlag),
+		flags.PolicyNameFlag:            c.String(flags.PolicyNameFlag),
+		flags.UpdateExistingFlag:        boolFlagValue(c, flags.UpdateExistingFlag),
+		flags.PolicyNameFromHashFlag:    boolFlagValue(c, flags.PolicyNameFromHashFlag),
+		flags.ReconcileTagsFlag:         c.String(flags.ReconcileTagsFlag),
+		flags.CheckKMSKeyPolicyFlag:     boolFlagValue(c, flags.CheckKMSKeyPolicyFlag),
+		flags.RequireSessionTagsFlag:    c.String(flags.RequireSessionTagsFlag),
+		flags.SimulateFlag:              boolFlagValue(c, flags.SimulateFlag),
+		flags.EmitSidMapFlag:            c.String(flags.EmitSidMapFlag),
+		flags.EmitCFNTemplateFlag:       c.String(flags.EmitCFNTemplateFlag),
+		flags.MinimalManagedFlag:        boolFlagValue(c, flags.MinimalManagedFlag),
+		flags.ManagedPolicyARNFlag:      c.String(flags.ManagedPolicyARNFlag),
+		flags.SkipPresentManagedFlag:    boolFlagValue(c, flags.SkipPresentManagedFlag),
+		flags.OutputRequestIDsFlag:      boolFlagValue(c, flags.OutputRequestIDsFlag),
+		flags.MinimalManagedActionsFlag: strings.Join(c.StringSlice(flags.MinimalManagedActionsFlag), ","),
+	})
 	if err != nil {
-		log.Fatal("Error executing 'up': ", err)
+		return err
+	}
+	if !skipRole {
+		// the managed policy ARN is built for the partition of the region, which the given ARNs should share
+		for _, warning := range partitionMismatchWarnings(region, upGivenARNs(validatedRegCreds, c.StringSlice(flags.AttachPolicyFlag), c.String(flags.ManagedPolicyARNFlag))) {
+			log.Warn(warning)
+		}
+	}
+	if roleOnly {
+		err = validateRoleOnly(map[string]string{
+			flags.IncludeECRFlag:            boolFlagValue(c, flags.IncludeECRFlag),
+			flags.SeparateStatementsFlag:    boolFlagValue(c, flags.SeparateStatementsFlag),
+			flags.MinimalManagedFlag:        boolFlagValue(c, flags.MinimalManagedFlag),
+			flags.DenyUnlessTagFlag:         c.String(flags.DenyUnlessTagFlag),
+			flags.PolicyNameFlag:            c.String(flags.PolicyNameFlag),
+			flags.PolicyNameFromHashFlag:    boolFlagValue(c, flags.PolicyNameFromHashFlag),
+			flags.RefreshExistingPolicyFlag: boolFlagValue(c, flags.RefreshExistingPolicyFlag),
+			flags.LintFlag:                  boolFlagValue(c, flags.LintFlag),
+			flags.LintRulesFlag:             c.String(flags.LintRulesFlag),
+			flags.SimulateFlag:              boolFlagValue(c, flags.SimulateFlag),
+			flags.EmitSidMapFlag:            c.String(flags.EmitSidMapFlag),
+			flags.CheckKMSKeyPolicyFlag:     boolFlagValue(c, flags.CheckKMSKeyPolicyFlag),
+			flags.OnlyIfChangedFlag:         boolFlagValue(c, flags.OnlyIfChangedFlag),
+			flags.MultiRegionKeysFlag:       c.String(flags.MultiRegionKeysFlag),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	includeECR := c.Bool(flags.IncludeECRFlag)
+	if includeECR {
+		if err = validateECRRepositories(credsInput.ECRRepositories); err != nil {
+			return err
+		}
+	} else if len(credsInput.ECRRepositories) > 0 {
+		log.Warnf("'ecr_repositories' in %s is ignored without '--%s'", inputFile, flags.IncludeECRFlag)
+	}
+	minimalManaged := c.Bool(flags.MinimalManagedFlag)
+	minimalManagedActions := c.StringSlice(flags.MinimalManagedActionsFlag)
+	if len(minimalManagedActions) > 0 && !minimalManaged {
+		return fmt.Errorf("'--%s' requires '--%s'", flags.MinimalManagedActionsFlag, flags.MinimalManagedFlag)
+	}
+	if err = validateMinimalManagedActions(minimalManagedActions); err != nil {
+		return err
+	}
+	tagCondition, err := parsePolicyTagCondition(c.String(flags.DenyUnlessTagFlag))
+	if err != nil {
+		return err
+	}
+	requiredSessionTags, err := parseRequiredSessionTags(c.String(flags.RequireSessionTagsFlag))
+	if err != nil {
+		return err
+	}
+	policyName, err := expandPolicyName(c.String(flags.PolicyNameFlag), environment)
+	if err != nil {
+		return err
+	}
+	if policyName != "" {
+		if err = validatePolicyName(policyName); err != nil {
+			return err
+		}
+	} else if c.Bool(flags.UpdateExistingFlag) {
+		return fmt.Errorf("'--%s' requires '--%s'", flags.UpdateExistingFlag, flags.PolicyNameFlag)
+	}
+	if c.Bool(flags.PolicyNameFromHashFlag) && (policyName != "" || c.Bool(flags.RefreshExistingPolicyFlag)) {
+		return fmt.Errorf("'--%s' can't be used with '--%s' or '--%s'", flags.PolicyNameFromHashFlag, flags.PolicyNameFlag, flags.RefreshExistingPolicyFlag)
+	}
+	// flags override the prefix and suffix from the cluster configuration
+	roleNames, err = applyRoleNameAffixes(roleNames,
+		roleNameAffix(c.String(flags.RoleNamePrefixFlag), commandConfig.RoleNamePrefix),
+		roleNameAffix(c.String(flags.RoleNameSuffixFlag), commandConfig.RoleNameSuffix))
+	if err != nil {
+		return err
+	}
+	attachOrder := c.String(flags.AttachOrderFlag)
+	if err = validateAttachOrder(attachOrder); err != nil {
+		return err
+	}
+	if err = validateReconcileTags(c.String(flags.ReconcileTagsFlag)); err != nil {
+		return err
+	}
+	multiRegionKeys := c.String(flags.MultiRegionKeysFlag)
+	if err = validateMultiRegionKeys(multiRegionKeys); err != nil {
+		return err
+	}
+	if multiRegionKeys == MultiRegionKeysPrimaryOnly {
+		// the default, which keeps the fingerprint of earlier idempotent runs
+		multiRegionKeys = ""
+	}
+	tagFailureMode := c.String(flags.OnTagFailureFlag)
+	if err = validateTagFailureMode(tagFailureMode); err != nil {
+		return err
+	}
+	maxPoliciesPerRole := c.Int(flags.MaxPoliciesPerRoleFlag)
+	if maxPoliciesPerRole < 2 {
+		return fmt.Errorf("'--%s' must be at least 2 to attach the managed task execution role policy and the new policy", flags.MaxPoliciesPerRoleFlag)
 	}
 
-	roleName := c.String(flags.RoleNameFlag)
-	skipRole := c.Bool(flags.NoRoleFlag)
+	saveAs := c.String(flags.SaveAsFlag)
+	var saver roleSaver
+	if saveAs != "" {
+		if err = validateSaveAsKey(saveAs); err != nil {
+			return err
+		}
+		if saver, err = config.NewReadWriter(); err != nil {
+			return err
+		}
+	}
 
-	err = validateRoleDetails(roleName, skipRole)
+	roleBundle := regcredio.RoleBundleEntry{}
+	if bundleFile := c.String(flags.RoleBundleFlag); bundleFile != "" {
+		bundle, err := regcredio.ReadRoleBundleFrom(store, bundleFile)
+		if err != nil {
+			return err
+		}
+		roleBundle = bundle.Role
+	}
+	if iamPath := c.String(flags.IAMPathFlag); iamPath != "" {
+		// an explicit flag value overrides the role bundle
+		if err = validateIAMPath(iamPath); err != nil {
+			return err
+		}
+		roleBundle.Path = iamPath
+	}
+	lintRules, err := readLintRules(store, c.Bool(flags.LintFlag), c.String(flags.LintRulesFlag))
 	if err != nil {
-		log.Fatal("Error executing 'up': ", err)
+		return err
+	}
+	if len(requiredSessionTags) > 0 && roleBundle.TrustPolicy != "" {
+		return fmt.Errorf("'--%s' can't be used with the trust policy of a role bundle", flags.RequireSessionTagsFlag)
+	}
+
+	// an explicit flag value overrides the role bundle
+	boundaryVal := c.String(flags.PermissionsBoundaryFlag)
+	if boundaryVal == "" {
+		boundaryVal = roleBundle.PermissionsBoundary
+	}
+	// the account ID is only looked up if a template needs it and none was given
+	accountID := c.String(flags.AccountIDFlag)
+	if accountID != "" {
+		if err = validateAccountID(accountID); err != nil {
+			return err
+		}
+	} else if usesAccountIDTemplate(boundaryVal, roleBundle.TrustPolicy) {
+		accountID, err = stsClient.NewClient(commandConfig).GetAWSAccountID()
+		if err != nil {
+			return errors.Wrap(err, "unable to get the account of the credentials")
+		}
+	}
+	if boundaryVal, err = expandAccountID(boundaryVal, "permissions boundary", accountID); err != nil {
+		return err
+	}
+	if roleBundle.TrustPolicy, err = expandAccountID(roleBundle.TrustPolicy, "trust policy of the role bundle", accountID); err != nil {
+		return err
+	}
+	permissionsBoundary := ""
+	if boundaryVal != "" {
+		resolver := newBoundaryResolver(ssmClient.NewSSMClient(commandConfig))
+		permissionsBoundary, err = resolver.resolve(boundaryVal)
+		if err != nil {
+			return err
+		}
+	}
+	if !skipRole {
+		// checked before any role is created, so that a rule of the organization doesn't deny it partway through
+		if err = validateBoundaryRules(commandConfig.BoundaryRules, roleBundle.Path, permissionsBoundary); err != nil {
+			return err
+		}
+	}
+
+	// existing roles are checked against the account of the credentials before they are reused
+	expectedAccountID := ""
+	if c.Bool(flags.VerifyAccountFlag) {
+		expectedAccountID, err = stsClient.NewClient(commandConfig).GetAWSAccountID()
+		if err != nil {
+			return errors.Wrap(err, "unable to get the account of the credentials")
+		}
+		if accountID != "" && accountID != expectedAccountID {
+			return fmt.Errorf("'--%s' is %s, but the credentials are for account %s", flags.AccountIDFlag, accountID, expectedAccountID)
+		}
 	}
 
 	outputDir := c.String(flags.OutputDirFlag)
+	outputFileName := c.String(flags.OutputFileNameFlag)
 	skipOutput := c.Bool(flags.NoOutputFileFlag)
 
-	err = validateOutputOptions(outputDir, skipOutput)
+	// the first role names the output file and is used by 'compose'
+	roleName := ""
+	if len(roleNames) > 0 {
+		roleName = roleNames[0]
+	}
+	err = validateOutputOptions(outputDir, outputFileName, roleName, environment, skipOutput)
 	if err != nil {
-		log.Fatal("Error executing 'up': ", err)
+		return err
+	}
+	outputTemplate, err := readOutputTemplate(store, c.String(flags.OutputTemplateFlag), outputDir, outputFileName, roleName, environment, skipOutput)
+	if err != nil {
+		return err
 	}
 
-	// find or create secrets, role
-	updateAllowed := c.Bool(flags.UpdateExistingSecretsFlag)
-
-	credentialOutput, err := getOrCreateRegistryCredentials(validatedRegCreds, smClient, updateAllowed)
+	manifestFile := environmentManifestFile(c.String(flags.ManifestFlag), environment)
+	summaryMarkdownFile := environmentManifestFile(c.String(flags.EmitSummaryMDFlag), environment)
+	sidMapFile := environmentManifestFile(c.String(flags.EmitSidMapFlag), environment)
+	inventoryFile := environmentManifestFile(c.String(flags.EmitInventoryFlag), environment)
+	terraformImportFile := c.String(flags.EmitTerraformImportFlag)
+	if terraformImportFile != terraformImportStdout {
+		terraformImportFile = environmentManifestFile(terraformImportFile, environment)
+	}
+	cfnTemplateFile := environmentManifestFile(c.String(flags.EmitCFNTemplateFlag), environment)
+	if c.Bool(flags.CompressFlag) {
+		// validated before compression so that the template is checked as given
+		outputFileName = regcredio.CompressedFileName(regcredio.OutputFileNameTemplate(outputFileName, environment))
+		if manifestFile != "" {
+			manifestFile = regcredio.CompressedFileName(manifestFile)
+		}
+	}
+	var signer *manifestSigner
+	if signingKey := c.String(flags.ManifestSigningKeyFlag); signingKey != "" {
+		if manifestFile == "" {
+			return fmt.Errorf("'--%s' requires '--%s'", flags.ManifestSigningKeyFlag, flags.ManifestFlag)
+		}
+		signer, err = newManifestSigner(signingKey, signingKeyClient(c, signingKey, kmsClient))
+		if err != nil {
+			return err
+		}
+	}
+	notifier, err := newUpNotifier(c)
 	if err != nil {
-		log.Fatal("Error executing 'up': ", err)
+		return err
+	}
+
+	// the options the new policy document is generated from, for the checks made before any secret is created
+	policyParams := ExecutionRoleParams{
+		VersionStage:    c.String(flags.VersionStageFlag),
+		Region:          region,
+		IncludeECR:      includeECR,
+		ECRRepositories: credsInput.ECRRepositories,
+		TagCondition:    tagCondition,
+
+		ConsolidateStatements: !c.Bool(flags.SeparateStatementsFlag),
+		MultiRegionKeys:       multiRegionKeys,
+
+		MinimalManaged:        minimalManaged,
+		MinimalManagedActions: minimalManagedActions,
+	}
+	if c.Bool(flags.SimulateFlag) {
+		return simulateUp(validatedRegCreds, roleNames, policyParams, permissionsBoundary, region, commandConfig, smClient, iamClient, kmsClient)
+	}
+	if printSteps {
+		// nothing is created or changed, so existing resources are not looked up
+		var secretTags map[string]*string
+		if tagVal := c.String(flags.ResourceTagsFlag); tagVal != "" {
+			if secretTags, err = utils.GetTagsMap(tagVal); err != nil {
+				return err
+			}
+		}
+		stepParams := ExecutionRoleParams{
+			RoleNames:             roleNames,
+			Region:                region,
+			PermissionsBoundary:   permissionsBoundary,
+			CreateInstanceProfile: c.Bool(flags.CreateInstanceProfileFlag),
+			RefreshExistingPolicy: c.Bool(flags.RefreshExistingPolicyFlag),
+			AdditionalPolicyARNs:  c.StringSlice(flags.AttachPolicyFlag),
+			RoleOnly:              roleOnly,
+			AttachOrder:           attachOrder,
+			IncludeECR:            includeECR,
+			ECRRepositories:       credsInput.ECRRepositories,
+			PolicyName:            policyName,
+			UpdateExistingPolicy:  c.Bool(flags.UpdateExistingFlag),
+			PolicyNameFromHash:    c.Bool(flags.PolicyNameFromHashFlag),
+			MinimalManaged:        minimalManaged,
+			ManagedPolicyARN:      c.String(flags.ManagedPolicyARNFlag),
+		}
+		applyRoleBundle(&stepParams, roleBundle)
+		return writeSteps(os.Stdout, upSteps(validatedRegCreds, stepParams, upStepOptions{
+			SkipRole:      skipRole,
+			UpdateSecrets: c.Bool(flags.UpdateExistingSecretsFlag),
+			Tags:          secretTags,
+			SkipOutput:    skipOutput,
+			OutputDir:     outputDir,
+			ManifestFile:  manifestFile,
+		}))
+	}
+
+	// a repeated run with the same idempotency key and configuration makes no changes
+	idempotencyKey := c.String(flags.IdempotencyKeyFlag)
+	fingerprint := ""
+	if idempotencyKey != "" {
+		if err = validateIdempotencyKey(idempotencyKey); err != nil {
+			return err
+		}
+		fingerprint, err = idempotencyFingerprint(idempotencyConfig{
+			Registries:           validatedRegCreds,
+			RoleNames:            roleNames,
+			VersionStage:         c.String(flags.VersionStageFlag),
+			PermissionsBoundary:  permissionsBoundary,
+			RoleBundle:           roleBundle,
+			AdditionalPolicyARNs: c.StringSlice(flags.AttachPolicyFlag),
+			AttachOrder:          attachOrder,
+			AllowEmpty:           allowEmpty,
+			RoleOnly:             roleOnly,
+			IncludeECR:           includeECR,
+			ECRRepositories:      credsInput.ECRRepositories,
+			TagCondition:         tagCondition,
+			PolicyName:           policyName,
+			RequiredSessionTags:  requiredSessionTags,
+			PolicyNameFromHash:   c.Bool(flags.PolicyNameFromHashFlag),
+
+			MinimalManaged:        minimalManaged,
+			MinimalManagedActions: minimalManagedActions,
+			ManagedPolicyARN:      c.String(flags.ManagedPolicyARNFlag),
+			SeparateStatements:    c.Bool(flags.SeparateStatementsFlag),
+			MultiRegionKeys:       multiRegionKeys,
+		})
+		if err != nil {
+			return err
+		}
+		previousResults, err := findIdempotentRun(roleNames, idempotencyKey, fingerprint, iamClient)
+		if err != nil {
+			return err
+		}
+		if previousResults != nil {
+			log.Infof("Idempotency key '%s' was already used by a run with the same configuration; no changes were made.", idempotencyKey)
+			for _, result := range previousResults {
+				log.Infof("Role %s: policy %s attached", result.RoleName, result.PolicyARN)
+			}
+			if saver != nil {
+				if err = saveRoleARN(saver, saveAs, previousResults, iamClient); err != nil {
+					return err
+				}
+			}
+			if notifier != nil {
+				if err = notifier.notify(previousResults, region, environment, time.Now(), iamClient); err != nil {
+					return err
+				}
+			}
+			// no output file is written, so there is no output to print
+			return reportUpResults(c, restoreLogOutput, previousResults, len(validatedRegCreds), nil, region, startTime, iamClient)
+		}
 	}
 
 	var tags map[string]*string
 	if tagVal := c.String(flags.ResourceTagsFlag); tagVal != "" {
 		tags, err = utils.GetTagsMap(tagVal)
 		if err != nil {
-			log.Fatal("Error executing 'up': ", err)
+			return err
+		}
+	}
+
+	managementTagKey, managementTagValue, err := parseManagementTag(c.String(flags.ManagementTagFlag))
+	if err != nil {
+		return err
+	}
+
+	// checked before any secret is created, counting the tags the role setup adds to each role
+	reservedTags := ExecutionRoleParams{ReconcileTags: c.String(flags.ReconcileTagsFlag)}.reservedTagCount()
+	if idempotencyKey != "" {
+		reservedTags += idempotencyTagCount
+	}
+	if !skipRole {
+		if err = validateTagLimits(aws.StringMap(newRoleTags(tags, roleBundle, managementTagKey, managementTagValue)), reservedTags); err != nil {
+			return err
 		}
 	}
 
+	if c.Bool(flags.EnforceTagPolicyFlag) {
+		// checked before any secret or role is created
+		if err = enforceTagPolicy(newRoleTags(tags, roleBundle, managementTagKey, managementTagValue), orgsClient.NewOrganizationsClient(commandConfig)); err != nil {
+			return err
+		}
+	}
+
+	// find or create secrets, role
+	updateAllowed := c.Bool(flags.UpdateExistingSecretsFlag)
+
+	if c.Bool(flags.OnlyIfChangedFlag) {
+		unchangedParams := policyParams
+		unchangedParams.RoleNames = roleNames
+		unchangedParams.ManagementTagKey = managementTagKey
+		unchangedParams.ManagementTagValue = managementTagValue
+		unchangedParams.ManagedPolicyARN = c.String(flags.ManagedPolicyARNFlag)
+		unchangedParams.AdditionalPolicyARNs = c.StringSlice(flags.AttachPolicyFlag)
+		unchangedParams.PolicyName = policyName
+		unchangedResults, err := findUnchangedRun(validatedRegCreds, unchangedParams, updateAllowed, smClient, iamClient, kmsClient)
+		if err != nil {
+			return err
+		}
+		if unchangedResults != nil {
+			log.Info("The task execution roles already have a generated policy with the same document; no changes.")
+			for _, result := range unchangedResults {
+				log.Infof("Role %s: policy %s attached", result.RoleName, result.PolicyARN)
+			}
+			if saver != nil {
+				if err = saveRoleARN(saver, saveAs, unchangedResults, iamClient); err != nil {
+					return err
+				}
+			}
+			if notifier != nil {
+				if err = notifier.notify(unchangedResults, region, environment, time.Now(), iamClient); err != nil {
+					return err
+				}
+			}
+			// no output file is written, so there is no output to print
+			return reportUpResults(c, restoreLogOutput, unchangedResults, len(validatedRegCreds), nil, region, startTime, iamClient)
+		}
+	}
+
+	if c.Bool(flags.CheckPermissionsFlag) {
+		// checked before any secret or role is created, so that the run doesn't stop midway with AccessDenied
+		checkParams := policyParams
+		checkParams.RoleNames = roleNames
+		checkParams.RoleOnly = roleOnly
+		checkParams.PolicyName = policyName
+		checkParams.PolicyNameFromHash = c.Bool(flags.PolicyNameFromHashFlag)
+		applyRoleBundle(&checkParams, roleBundle)
+		if err = checkCallerPermissions(validatedRegCreds, checkParams, stsClient.NewClient(commandConfig), iamClient); err != nil {
+			return err
+		}
+	}
+
+	// with '--strict', the warnings of the checks above stop the run before anything is created
+	if err = strictModeError(c); err != nil {
+		return err
+	}
+
+	credentialOutput, createdSecrets, err := getOrCreateRegistryCredentials(validatedRegCreds, smClient, updateAllowed, concurrency)
+	if err != nil {
+		return err
+	}
+
 	var policyCreateTime *time.Time
+	var roleResults []*ExecutionRoleResult
+	var roleParams ExecutionRoleParams
 	if !skipRole {
-		region := commandConfig.Session.Config.Region
+		roleParams = ExecutionRoleParams{
+			CredEntries:  credentialOutput,
+			RoleNames:    roleNames,
+			Region:       region,
+			Tags:         tags,
+			VersionStage: c.String(flags.VersionStageFlag),
 
-		roleParams := executionRoleParams{
-			CredEntries: credentialOutput,
-			RoleName:    roleName,
-			Region:      *region,
-			Tags:        tags,
+			ManagementTagKey:    managementTagKey,
+			ManagementTagValue:  managementTagValue,
+			PermissionsBoundary: permissionsBoundary,
+
+			CreateInstanceProfile: c.Bool(flags.CreateInstanceProfileFlag),
+			MaxPoliciesPerRole:    maxPoliciesPerRole,
+			PruneStalePolicies:    c.Bool(flags.PruneStaleFlag),
+			RefreshExistingPolicy: c.Bool(flags.RefreshExistingPolicyFlag),
+			AdditionalPolicyARNs:  c.StringSlice(flags.AttachPolicyFlag),
+			AllowEmpty:            allowEmpty,
+			RoleOnly:              roleOnly,
+			AttachOrder:           attachOrder,
+			ExpectedAccountID:     expectedAccountID,
+			IncludeECR:            includeECR,
+			ECRRepositories:       credsInput.ECRRepositories,
+			TagCondition:          tagCondition,
+			PolicyName:            policyName,
+			UpdateExistingPolicy:  c.Bool(flags.UpdateExistingFlag),
+			RequiredSessionTags:   requiredSessionTags,
+			PolicyNameFromHash:    c.Bool(flags.PolicyNameFromHashFlag),
+			ReconcileTags:         c.String(flags.ReconcileTagsFlag),
+			TagFailureMode:        tagFailureMode,
+			FailFast:              c.Bool(flags.FailFastFlag),
+			Concurrency:           concurrency,
+			MinimalManaged:        minimalManaged,
+			MinimalManagedActions: minimalManagedActions,
+			ManagedPolicyARN:      c.String(flags.ManagedPolicyARNFlag),
+			SkipManagedIfPresent:  c.Bool(flags.SkipPresentManagedFlag),
+			LintRules:             lintRules,
+			ConsolidateStatements: !c.Bool(flags.SeparateStatementsFlag),
+			MultiRegionKeys:       multiRegionKeys,
+		}
+		applyRoleBundle(&roleParams, roleBundle)
+		if c.GlobalBool(flags.VerboseFlag) || c.Bool(flags.VerboseFlag) {
+			// written with the logs so that it is also held back by '--summary-only' and '--print-arn-only'
+			roleParams.DebugOutput = log.StandardLogger().Out
 		}
 
-		policyCreateTime, err = createTaskExecutionRole(roleParams, iamClient, kmsClient)
+		roleIAMClient := iamClient
+		if attachRetrySeconds > 0 {
+			roleIAMClient = newAttachRetryClient(iamClient, time.Duration(attachRetrySeconds)*time.Second)
+		}
+		roleResults, err = CreateTaskExecutionRoles(roleParams, roleIAMClient, kmsClient)
 		if err != nil {
-			log.Fatal("Error executing 'up': ", err)
+			// list the resources which were created so that they can still be removed with 'down'
+			writeManifest(store, manifestFile, signer, roleResults, createdSecrets, region)
+			return err
+		}
+		policyCreateTime = &roleResults[0].PolicyCreateTime
+		if idempotencyKey != "" {
+			err = recordIdempotencyKey(roleResults, idempotencyKey, fingerprint, iamClient)
+			if err = handleTagFailure(tagFailureMode, err, "the task execution roles, so a repeated run with the idempotency key will make changes again", []string{IdempotencyKeyTagKey, idempotencyFingerprintTagKey, idempotencyPolicyTagKey}); err != nil {
+				writeManifest(store, manifestFile, signer, roleResults, createdSecrets, region)
+				return err
+			}
+		}
+		if c.Bool(flags.CheckKMSKeyPolicyFlag) {
+			checkKMSKeyPolicies(credentialOutput, roleResults, iamClient, kmsClient)
 		}
 	} else {
 		log.Info("Skipping role creation.")
@@ -119,54 +800,307 @@ func Up(c *cli.Context) {
 		taggingClient := tagging.NewTaggingClient(commandConfig)
 		err = tagRegistryCredentials(credentialOutput, tags, taggingClient)
 		if err != nil {
-			log.Fatal("Failed to tag resources: ", err)
+			err = errors.Wrap(err, "failed to tag resources")
+		}
+		if err = handleTagFailure(tagFailureMode, err, "the registry secrets", sortedTagKeys(tags)); err != nil {
+			return err
 		}
 	}
 
+	writeManifest(store, manifestFile, signer, roleResults, createdSecrets, region)
+
 	// produce output file
-	if !skipOutput {
-		regcredio.GenerateCredsOutput(credentialOutput, roleName, outputDir, policyCreateTime)
+	roleEntries := buildRoleOutputEntries(roleResults, roleParams.managedPolicyARN())
+	if requestIDs != nil {
+		addRequestIDs(roleEntries, roleResults, requestIDs)
+	}
+	// the output file and '--format json' are written from the same output, so that they always agree
+	credsOutput := regcredio.BuildCredsOutput(credentialOutput, roleEntries)
+	if outputTemplate != nil {
+		if err = writeOutputTemplate(store, outputTemplate, roleEntries, roleResults, credentialOutput, tags, region, policyCreateTime, outputDir, outputFileName, environment, iamClient); err != nil {
+			return errors.Wrap(err, "failed to write the output file")
+		}
+	} else if !skipOutput {
+		if err = regcredio.WriteCredsOutputTo(store, credsOutput, outputDir, outputFileName, environment, policyCreateTime); err != nil {
+			return errors.Wrap(err, "failed to write the output file")
+		}
 	} else {
 		log.Info("Skipping generation of registry credentials output file.")
 	}
+	if summaryMarkdownFile != "" {
+		// built from the same entries as the output file so that the two always agree
+		if err = writeSummaryMarkdown(store, summaryMarkdownFile, roleEntries, roleResults, credentialOutput, region, policyCreateTime, iamClient); err != nil {
+			return err
+		}
+	}
+	if inventoryFile != "" {
+		if err = writeComplianceInventory(store, inventoryFile, roleResults, credentialOutput, createdSecrets, tags, region, policyCreateTime, iamClient); err != nil {
+			return err
+		}
+	}
+	if sidMapFile != "" {
+		if err = writeSidMap(store, sidMapFile, roleResults, credentialOutput); err != nil {
+			return err
+		}
+	}
+	if terraformImportFile != "" {
+		if err = writeTerraformImports(store, terraformImportFile, roleResults, credentialOutput, region, policyCreateTime); err != nil {
+			return err
+		}
+	}
+	if cfnTemplateFile != "" {
+		if err = writeCloudFormationTemplate(store, cfnTemplateFile, roleParams, roleResults, policyCreateTime); err != nil {
+			return err
+		}
+	}
+
+	if saver != nil {
+		if err = saveRoleARN(saver, saveAs, roleResults, iamClient); err != nil {
+			return err
+		}
+	}
 
 	log.Info("\nIf your input file contains sensitive information, make sure that you delete it after use.")
-}
 
-func getOrCreateRegistryCredentials(entryMap regcredio.RegistryCreds, smClient secretsClient.SMClient, updateAllowed bool) (map[string]regcredio.CredsOutputEntry, error) {
-	registryResults := make(map[string]regcredio.CredsOutputEntry)
+	if notifier != nil {
+		if err = notifier.notify(roleResults, region, environment, time.Now(), iamClient); err != nil {
+			return err
+		}
+	}
 
-	for registryName, credentialEntry := range entryMap {
-		log.Infof("Processing credentials for registry %s...", registryName)
+	return reportUpResults(c, restoreLogOutput, roleResults, len(credentialOutput), &credsOutput, region, startTime, iamClient)
+}
+
+// validateUpFormat checks the '--format' of 'up', which prints the output to stdout and so can't be combined with the
+// other flags which print to stdout
+func validateUpFormat(format string, printsResults bool) error {
+	if format == "" {
+		return nil
+	}
+	if format != JSONOutputFormat {
+		return fmt.Errorf("invalid value '%s' for '--%s'; the only valid value is '%s'", format, flags.FormatFlag, JSONOutputFormat)
+	}
+	if printsResults {
+		return fmt.Errorf("'--%s %s' can't be used with '--%s' or '--%s', which also print to stdout", flags.FormatFlag, format, flags.SummaryOnlyFlag, flags.PrintARNOnlyFlag)
+	}
+	return nil
+}
 
-		arn := credentialEntry.SecretManagerARN
-		var keyForSecret *string
-		if arn == "" {
-			newSecretARN, key, err := findOrCreateRegistrySecret(registryName, credentialEntry, smClient)
+// reportUpResults prints the run summary, the role ARNs, the output as JSON or the policy document, if requested, once
+// the logs held back for them are no longer needed
+func reportUpResults(c *cli.Context, restoreLogOutput func(), roleResults []*ExecutionRoleResult, secretCount int, credsOutput *regcredio.ECSRegistryCredsOutput, region string, startTime time.Time, iamClient iam.Client) error {
+	if c.String(flags.FormatFlag) == JSONOutputFormat {
+		if credsOutput == nil {
+			log.Warnf("No output was generated, so nothing is printed for '--%s %s'.", flags.FormatFlag, JSONOutputFormat)
+		} else {
+			outputJSON, err := regcredio.FormatCredsOutputJSON(*credsOutput)
 			if err != nil {
-				return nil, err
-			}
-			arn = newSecretARN
-			keyForSecret = &key
-		} else if credentialEntry.HasCredPair() {
-			if err := updateOrWarnForExistingSecret(credentialEntry, updateAllowed, smClient); err != nil {
-				return nil, err
+				return err
 			}
-		} else {
-			log.Infof("Using existing secret %s.", arn)
+			fmt.Println(outputJSON)
 		}
+	}
+	if c.Bool(flags.PrintPolicyFlag) {
+		if err := printPolicyDocument(os.Stdout, roleResults); err != nil {
+			return err
+		}
+	}
+	if c.Bool(flags.SummaryOnlyFlag) {
+		restoreLogOutput()
+		fmt.Println(formatRunSummary(roleResults, secretCount, region, time.Since(startTime)))
+	}
+	if c.Bool(flags.PrintARNOnlyFlag) {
+		roleARNs, err := getRoleARNs(roleResults, iamClient)
+		if err != nil {
+			return err
+		}
+		restoreLogOutput()
+		for _, roleARN := range roleARNs {
+			fmt.Println(roleARN)
+		}
+	}
+	if c.Bool(flags.ExplainFlag) {
+		// logged after the logs of other outputs are restored, so that it isn't discarded with them
+		for _, explanation := range explainRoles(roleResults) {
+			log.Info(explanation)
+		}
+	}
+	return nil
+}
 
-		if keyForSecret == nil {
-			keyForSecret = &credentialEntry.KmsKeyID
+// writeManifest writes the manifest of created resources if a manifest file was given, and signs it if a signer is
+// given
+func writeManifest(store regcredio.Store, manifestFile string, signer *manifestSigner, roleResults []*ExecutionRoleResult, createdSecrets []regcredio.ManifestSecret, region string) {
+	if manifestFile == "" {
+		return
+	}
+	manifest := buildManifest(roleResults, createdSecrets, region, time.Now().UTC())
+	if err := regcredio.WriteManifestTo(store, manifest, manifestFile); err != nil {
+		log.Fatal("Error writing manifest: ", err)
+	}
+	if signer != nil {
+		if err := signer.sign(store, manifestFile); err != nil {
+			log.Fatal("Error signing manifest: ", err)
 		}
-		registryResults[registryName] = regcredio.BuildOutputEntry(arn, *keyForSecret, credentialEntry.ContainerNames)
 	}
+}
 
-	return registryResults, nil
+// simulateUp prints the decision of the IAM policy simulator for each action the new policy would grant each role,
+// without creating or changing any resources. It returns an error if any action would be denied.
+func simulateUp(regCreds map[string]regcredio.RegistryCredEntry, roleNames []string, params ExecutionRoleParams, permissionsBoundary, region string, commandConfig *config.CommandConfig, smClient secretsClient.SMClient, iamClient iam.Client, kmsClient kms.Client) error {
+	accountID, err := stsClient.NewClient(commandConfig).GetAWSAccountID()
+	if err != nil {
+		return errors.Wrap(err, "unable to get the account of the credentials")
+	}
+	params.CredEntries = simulationCredEntries(regCreds, region, accountID, smClient)
+	return simulateNewPolicy(roleNames, params, permissionsBoundary, os.Stdout, iamClient, kmsClient)
 }
 
-// returns the ARN of a new or existing registry secret (and, if applicable, the KMS key associated with that secret)
-func findOrCreateRegistrySecret(registryName string, credEntry regcredio.RegistryCredEntry, smClient secretsClient.SMClient) (string, string, error) {
+// checkKMSKeyPolicies warns about each role which the key policies of the KMS keys don't appear to allow to decrypt the
+// registry credentials. The roles are already set up, so problems are only reported.
+func checkKMSKeyPolicies(creds map[string]regcredio.CredsOutputEntry, roleResults []*ExecutionRoleResult, iamClient iam.Client, kmsClient kms.Client) {
+	roleARNs, err := getRoleARNs(roleResults, iamClient)
+	if err != nil {
+		log.Warn("Unable to check KMS key policies: ", err)
+		return
+	}
+	for _, warning := range kmsKeyPolicyWarnings(creds, roleARNs, kmsClient) {
+		log.Warn(warning)
+	}
+}
+
+// writeSummaryMarkdown writes the markdown summary of the roles and secrets to the given file. The roles are looked up
+// for their ARNs if they were not created by the run.
+func writeSummaryMarkdown(store regcredio.Store, filename string, roleEntries []regcredio.RoleOutputEntry, roleResults []*ExecutionRoleResult, creds map[string]regcredio.CredsOutputEntry, region string, createTime *time.Time, client iam.Client) error {
+	roles, err := buildSummaryRoles(roleEntries, roleResults, client)
+	if err != nil {
+		return err
+	}
+
+	generatedAt := time.Now().UTC()
+	if createTime != nil {
+		generatedAt = *createTime
+	}
+	if err = regcredio.WriteSummaryMarkdownTo(store, filename, roles, creds, region, generatedAt); err != nil {
+		return errors.Wrapf(err, "failed to write summary to %s", filename)
+	}
+	log.Infof("Wrote summary to %s", filename)
+	return nil
+}
+
+// buildSummaryRoles adds the ARN, status and tags of each role to its output file entry. The roles are looked up for
+// their ARNs if they were not created by the run.
+func buildSummaryRoles(roleEntries []regcredio.RoleOutputEntry, roleResults []*ExecutionRoleResult, client iam.Client) ([]regcredio.SummaryRole, error) {
+	roleARNs, err := getRoleARNs(roleResults, client)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]regcredio.SummaryRole, 0, len(roleEntries))
+	for i, roleEntry := range roleEntries {
+		tags := make(map[string]string, len(roleResults[i].Tags))
+		for _, tag := range roleResults[i].Tags {
+			tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+		roles = append(roles, regcredio.SummaryRole{
+			RoleOutputEntry: roleEntry,
+			RoleARN:         roleARNs[i],
+			Created:         roleResults[i].RoleCreated,
+			Tags:            tags,
+		})
+	}
+	return roles, nil
+}
+
+// buildRoleOutputEntries maps each role to the policies attached to it, for the output file. The managedPolicyARN is
+// empty if the managed execution role policy was not attached.
+func buildRoleOutputEntries(roleResults []*ExecutionRoleResult, managedPolicyARN string) []regcredio.RoleOutputEntry {
+	var roles []regcredio.RoleOutputEntry
+	for _, roleResult := range roleResults {
+		roles = append(roles, regcredio.RoleOutputEntry{
+			RoleName:           roleResult.RoleName,
+			PolicyARN:          roleResult.PolicyARN,
+			PolicyName:         roleResult.PolicyName,
+			ManagedPolicyARN:   managedPolicyARN,
+			InstanceProfileARN: roleResult.InstanceProfileARN,
+
+			AdditionalPolicyARNs: roleResult.AdditionalPolicyARNs,
+			ECRRepositories:      roleResult.ECRRepositories,
+			RoleOnly:             roleResult.RoleOnly,
+		})
+	}
+	return roles
+}
+
+// returns the output entry for each registry and the secrets that were newly created. Up to concurrency registries
+// are processed at once; registries which have not been started when one fails are skipped, and the error of the
+// first failed registry (by name) is returned.
+func getOrCreateRegistryCredentials(entryMap regcredio.RegistryCreds, smClient secretsClient.SMClient, updateAllowed bool, concurrency int) (map[string]regcredio.CredsOutputEntry, []regcredio.ManifestSecret, error) {
+	registryNames := make([]string, 0, len(entryMap))
+	for registryName := range entryMap {
+		registryNames = append(registryNames, registryName)
+	}
+	sort.Strings(registryNames)
+
+	outputEntries := make([]regcredio.CredsOutputEntry, len(registryNames))
+	created := make([]bool, len(registryNames))
+	errs := make([]error, len(registryNames))
+	runItems(registryNames, concurrency, true, func(i int) bool {
+		outputEntries[i], created[i], errs[i] = getOrCreateRegistryCredential(registryNames[i], entryMap[registryNames[i]], smClient, updateAllowed)
+		return errs[i] == nil
+	}, func(i int) {})
+
+	registryResults := make(map[string]regcredio.CredsOutputEntry)
+	var createdSecrets []regcredio.ManifestSecret
+	for i, registryName := range registryNames {
+		if errs[i] != nil {
+			return nil, nil, errs[i]
+		}
+		if created[i] {
+			createdSecrets = append(createdSecrets, regcredio.ManifestSecret{RegistryName: registryName, SecretARN: outputEntries[i].CredentialARN})
+		}
+		registryResults[registryName] = outputEntries[i]
+	}
+
+	return registryResults, createdSecrets, nil
+}
+
+// returns the output entry for the registry, and whether its secret was newly created
+func getOrCreateRegistryCredential(registryName string, credentialEntry regcredio.RegistryCredEntry, smClient secretsClient.SMClient, updateAllowed bool) (regcredio.CredsOutputEntry, bool, error) {
+	log.Infof("Processing credentials for registry %s...", registryName)
+
+	arn := credentialEntry.SecretManagerARN
+	created := false
+	var keyForSecret *string
+	if arn == "" {
+		newSecretARN, key, secretCreated, err := findOrCreateRegistrySecret(registryName, credentialEntry, smClient)
+		if err != nil {
+			return regcredio.CredsOutputEntry{}, false, err
+		}
+		arn = newSecretARN
+		keyForSecret = &key
+		created = secretCreated
+	} else if credentialEntry.HasCredPair() {
+		if err := updateOrWarnForExistingSecret(credentialEntry, updateAllowed, smClient); err != nil {
+			return regcredio.CredsOutputEntry{}, false, err
+		}
+	} else {
+		log.Infof("Using existing secret %s.", arn)
+	}
+
+	if keyForSecret == nil {
+		keyForSecret = &credentialEntry.KmsKeyID
+	}
+	outputEntry := regcredio.BuildOutputEntry(arn, *keyForSecret, credentialEntry.ContainerNames)
+	outputEntry.Name = credentialEntry.Name
+	outputEntry.Actions = credentialEntry.Actions
+	outputEntry.RotationCompatible = credentialEntry.RotationCompatible
+	outputEntry.SecretScope = credentialEntry.SecretScope
+	return outputEntry, created, nil
+}
+
+// returns the ARN of a new or existing registry secret (and, if applicable, the KMS key associated with that secret),
+// and whether the secret was created
+func findOrCreateRegistrySecret(registryName string, credEntry regcredio.RegistryCredEntry, smClient secretsClient.SMClient) (string, string, bool, error) {
 
 	secretName := generateECSResourceName(registryName)
 
@@ -175,10 +1109,10 @@ func findOrCreateRegistrySecret(registryName string, credEntry regcredio.Registr
 		log.Infof("Existing credential secret found, using %s", *existingSecret.ARN)
 
 		if existingSecret.KmsKeyId != nil {
-			return *existingSecret.ARN, *existingSecret.KmsKeyId, nil
+			return *existingSecret.ARN, *existingSecret.KmsKeyId, false, nil
 		}
 
-		return *existingSecret.ARN, "", nil
+		return *existingSecret.ARN, "", false, nil
 	}
 
 	secretString := generateSecretString(credEntry.Username, credEntry.Password)
@@ -196,11 +1130,11 @@ func findOrCreateRegistrySecret(registryName string, credEntry regcredio.Registr
 
 	output, err := smClient.CreateSecret(createSecretRequest)
 	if err != nil {
-		return "", "", err
+		return "", "", false, err
 	}
 	log.Infof("New credential secret created: %s", *output.ARN)
 
-	return *output.ARN, kmsKey, nil
+	return *output.ARN, kmsKey, true, nil
 }
 
 func updateOrWarnForExistingSecret(credEntry regcredio.RegistryCredEntry, updateAllowed bool, smClient secretsClient.SMClient) error {
@@ -226,39 +1160,18 @@ func updateOrWarnForExistingSecret(credEntry regcredio.RegistryCredEntry, update
 	return nil
 }
 
-func validateCredsInput(input regcredio.ECSRegCredsInput, kmsClient kms.Client) (map[string]regcredio.RegistryCredEntry, error) {
-	// TODO: validate version?
-
-	inputRegCreds := input.RegistryCredentials
-
-	if len(inputRegCreds) == 0 {
-		return nil, errors.New("provided credentials must contain at least one registry")
-	}
-	if len(inputRegCreds) > maxContainersPerTaskDef {
-		return nil, errors.New("no more than" + string(maxContainersPerTaskDef) + "registry credential entries can be created at one time")
+func validateCredsInput(input regcredio.ECSRegCredsInput, region string, kmsClient kms.Client) (map[string]regcredio.RegistryCredEntry, error) {
+	// offline checks are shared with 'validate'; the first error is returned
+	for _, finding := range findInputProblems(input, region) {
+		if finding.Severity == SeverityWarning {
+			log.Warn(finding.Message)
+			continue
+		}
+		return nil, errors.New(finding.Message)
 	}
 
-	namedContainers := make(map[string]bool)
 	outputRegCreds := make(map[string]regcredio.RegistryCredEntry)
-
-	for registryName, credentialEntry := range inputRegCreds {
-		if !credentialEntry.HasRequiredFields() {
-			return nil, fmt.Errorf("missing required field(s) for registry %s; registry credentials should contain an existing secret ARN or username + password", registryName)
-		}
-		if len(credentialEntry.ContainerNames) > 0 {
-			for _, container := range credentialEntry.ContainerNames {
-				if namedContainers[container] {
-					return nil, fmt.Errorf("container '%s' appears in more than one registry; container names must be unique across given registry credentials", container)
-				}
-				namedContainers[container] = true
-			}
-		}
-		if len(credentialEntry.ContainerNames) == 0 {
-			log.Warnf("No container names given for registry '%s'; output cannot be incorporated into a task definition when running 'compose' command", registryName)
-		}
-		if credentialEntry.SecretManagerARN != "" && !isARN(credentialEntry.SecretManagerARN) {
-			return nil, fmt.Errorf("invalid secrets_manager_arn for registry %s", registryName)
-		}
+	for registryName, credentialEntry := range input.RegistryCredentials {
 		// if key specified as ID or alias, validate & get ARN
 		if credentialEntry.KmsKeyID != "" {
 			keyARN, err := kmsClient.GetValidKeyARN(credentialEntry.KmsKeyID)
@@ -269,11 +1182,8 @@ func validateCredsInput(input regcredio.ECSRegCredsInput, kmsClient kms.Client)
 		}
 		// if both present, validate secret ARN & key are in same region
 		if credentialEntry.SecretManagerARN != "" && credentialEntry.KmsKeyID != "" {
-			secretRegion := strings.Split(credentialEntry.SecretManagerARN, ":")[3]
-			keyRegion := strings.Split(credentialEntry.KmsKeyID, ":")[3]
-
-			if secretRegion != keyRegion {
-				return nil, fmt.Errorf("region of 'secrets_manager_arn'(%s) and 'kms_key_id'(%s) for registry %s do not match; secret and encryption key must be in same region", secretRegion, keyRegion, registryName)
+			if err := validateSameRegion(registryName, credentialEntry.SecretManagerARN, credentialEntry.KmsKeyID); err != nil {
+				return nil, err
 			}
 		}
 		outputRegCreds[registryName] = credentialEntry
@@ -297,36 +1207,168 @@ func getValidKeyARN(keyID string, kmsClient kms.Client) (string, error) {
 	return arn, nil
 }
 
-func validateRoleDetails(roleName string, noRole bool) error {
-	if noRole && roleName != "" {
-		return fmt.Errorf("both role name ('%s') and '--no-role' specified; please specify either a role name or the '--no-role' flag", roleName)
+// roleOptions maps the names of flags which only apply to a task execution role to their values
+func validateRoleDetails(roleNames []string, noRole bool, roleOptions map[string]string) error {
+	if noRole && len(roleNames) > 0 {
+		return fmt.Errorf("both role name ('%s') and '--no-role' specified; please specify either a role name or the '--no-role' flag", strings.Join(roleNames, "', '"))
 	}
-	if !noRole && roleName == "" {
+	if noRole {
+		optionNames := make([]string, 0, len(roleOptions))
+		for name := range roleOptions {
+			optionNames = append(optionNames, name)
+		}
+		sort.Strings(optionNames)
+		for _, name := range optionNames {
+			if roleOptions[name] != "" {
+				return fmt.Errorf("'--%s' cannot be used with '--no-role'; it only applies to a task execution role", name)
+			}
+		}
+	}
+	if !noRole && len(roleNames) == 0 {
 		return errors.New("no value specified for '--role-name'; please specify either a role name or the '--no-role' flag")
 	}
+	seenRoles := make(map[string]bool, len(roleNames))
+	for _, roleName := range roleNames {
+		if roleName == "" {
+			return errors.New("empty value specified for '--role-name'")
+		}
+		// IAM role names are case-insensitive
+		if seenRoles[strings.ToLower(roleName)] {
+			return fmt.Errorf("role '%s' is specified more than once with '--role-name'", roleName)
+		}
+		seenRoles[strings.ToLower(roleName)] = true
+	}
 	return nil
 }
 
-func getNewCommandConfig(c *cli.Context) *config.CommandConfig {
+// validateRoleOnly returns an error if any of the given options of the new policy are set, since no policy is created
+// with '--role-only'
+func validateRoleOnly(policyOptions map[string]string) error {
+	optionNames := make([]string, 0, len(policyOptions))
+	for name := range policyOptions {
+		optionNames = append(optionNames, name)
+	}
+	sort.Strings(optionNames)
+	for _, name := range optionNames {
+		if policyOptions[name] != "" {
+			return fmt.Errorf("'--%s' cannot be used with '--%s'; it only applies to the registry credentials policy", name, flags.RoleOnlyFlag)
+		}
+	}
+	return nil
+}
+
+// boolFlagValue returns "true" if the flag is set and an empty string otherwise, for use with validateRoleDetails
+func boolFlagValue(c *cli.Context, flagName string) string {
+	if c.Bool(flagName) {
+		return "true"
+	}
+	return ""
+}
+
+// if region is non-empty, it overrides the region from flags and config
+func getNewCommandConfig(c *cli.Context, region, regionSource string) *config.CommandConfig {
 	rdwr, err := config.NewReadWriter()
 	if err != nil {
 		log.Fatal("Error executing 'up': ", err)
 	}
-	commandConfig, err := config.NewCommandConfig(c, rdwr)
+	region, err = getRegion(c, rdwr, region, regionSource)
 	if err != nil {
 		log.Fatal("Error executing 'up': ", err)
 	}
+	var commandConfig *config.CommandConfig
+	if region != "" {
+		commandConfig, err = config.NewCommandConfigWithRegion(c, rdwr, region)
+	} else {
+		commandConfig, err = config.NewCommandConfig(c, rdwr)
+	}
+	if err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+	if err = applyCallTimeout(c, commandConfig); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+	if err = applyRetryPolicy(c, commandConfig); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+	if err = applyRecording(c, commandConfig); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+	// applied before credentials are resolved, so that web identity tokens are also exchanged with the mapped STS endpoint
+	if err = applyEndpointMap(c, commandConfig); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+	if err = applyCredentialsSource(c, commandConfig); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
+	if err = applyWebIdentityOverride(c, commandConfig); err != nil {
+		log.Fatal("Error executing 'up': ", err)
+	}
 
 	return commandConfig
 }
 
-func validateOutputOptions(outputDir string, skipOutput bool) error {
+// inferRegionFromCreds returns the region shared by the ARNs of all credential entries. If any entry has no secret
+// ARN (i.e., a new secret will be created) or the entries span multiple regions, no region is inferred.
+func inferRegionFromCreds(creds regcredio.RegistryCreds) string {
+	regions := make(map[string]bool)
+	for _, credEntry := range creds {
+		secretARN, err := arn.Parse(credEntry.SecretManagerARN)
+		if err != nil || secretARN.Region == "" {
+			return ""
+		}
+		regions[secretARN.Region] = true
+
+		if keyARN, err := arn.Parse(credEntry.KmsKeyID); err == nil && keyARN.Region != "" {
+			regions[keyARN.Region] = true
+		}
+	}
+
+	if len(regions) > 1 {
+		log.Warnf("Registry credential ARNs span multiple regions; a region could not be inferred. Use the '--%s' flag to specify the region to use.", flags.RegionFlag)
+		return ""
+	}
+	for region := range regions {
+		log.Infof("Using region %s inferred from registry credential ARNs", region)
+		return region
+	}
+	return ""
+}
+
+func validateOutputOptions(outputDir, outputFileName, roleName, environment string, skipOutput bool) error {
 	if outputDir != "" && skipOutput {
 		return fmt.Errorf("Only one of '--"+flags.OutputDirFlag+"' (value '%s') and '--"+flags.NoOutputFileFlag+"' can be specified but both are present", outputDir)
 	}
+	if outputFileName != "" && skipOutput {
+		return fmt.Errorf("Only one of '--"+flags.OutputFileNameFlag+"' (value '%s') and '--"+flags.NoOutputFileFlag+"' can be specified but both are present", outputFileName)
+	}
+	// render the template now so that an invalid name is reported before any resources are created
+	if _, err := regcredio.RenderOutputFileName(outputFileName, roleName, environment, outputDir != "", time.Now().UTC()); err != nil {
+		return err
+	}
 	return nil
 }
 
+// returns the key and value of the management tag; if no flag value is given, empty strings are returned so the default is used
+func parseManagementTag(flagValue string) (string, string, error) {
+	if flagValue == "" {
+		return "", "", nil
+	}
+	tag, err := utils.GetTagsMap(flagValue)
+	if err != nil {
+		return "", "", err
+	}
+	if len(tag) != 1 {
+		return "", "", fmt.Errorf("exactly one tag must be specified with '--%s'; found %d", flags.ManagementTagFlag, len(tag))
+	}
+	for key, value := range tag {
+		if key == "" {
+			return "", "", fmt.Errorf("tag key specified with '--%s' cannot be empty", flags.ManagementTagFlag)
+		}
+		return key, aws.StringValue(value), nil
+	}
+	return "", "", nil
+}
+
 func tagRegistryCredentials(creds map[string]regcredio.CredsOutputEntry, tags map[string]*string, taggingClient tagging.Client) error {
 	var arns []*string
 
@@ -343,8 +1385,14 @@ func tagRegistryCredentials(creds map[string]regcredio.CredsOutputEntry, tags ma
 		return err
 	}
 
+	// every failed resource is reported, so that no untagged secret goes unnoticed
+	var failures []string
 	for resource, info := range output.FailedResourcesMap {
-		return fmt.Errorf("Failed to tag resource %s; error=%s", resource, *info.ErrorMessage)
+		failures = append(failures, fmt.Sprintf("%s (error=%s)", resource, aws.StringValue(info.ErrorMessage)))
+	}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("Failed to tag %d resource(s): %s", len(failures), strings.Join(failures, "; "))
 	}
 
 	return nil
@@ -22,6 +22,7 @@ import (
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms/mock"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/secretsmanager/mock"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/tagging/mock"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/kms"
@@ -54,8 +55,9 @@ func TestGetOrCreateRegistryCredentials_WithCredPair(t *testing.T) {
 		mocks.MockSM.EXPECT().CreateSecret(expectedCreateInput).Return(&secretsmanager.CreateSecretOutput{ARN: aws.String(responseARN)}, nil),
 	)
 
-	credsOutput, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false)
+	credsOutput, createdSecrets, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false, 1)
 	assert.NoError(t, err, "Expected no error when creating secret with cred pair")
+	assert.Equal(t, []regcredio.ManifestSecret{{RegistryName: testRegistryName, SecretARN: responseARN}}, createdSecrets)
 
 	actualCredEntry := credsOutput[testRegistryName]
 	assert.NotEmpty(t, actualCredEntry)
@@ -87,8 +89,9 @@ func TestGetOrCreateRegistryCredentials_WithCredPairAndKmsKey(t *testing.T) {
 		mocks.MockSM.EXPECT().CreateSecret(expectedCreateInput).Return(&secretsmanager.CreateSecretOutput{ARN: aws.String(responseARN)}, nil),
 	)
 
-	credsOutput, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false)
+	credsOutput, createdSecrets, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false, 1)
 	assert.NoError(t, err, "Expected no error when creating secret with cred pair")
+	assert.Equal(t, []regcredio.ManifestSecret{{RegistryName: testRegistryName, SecretARN: responseARN}}, createdSecrets)
 
 	actualCredEntry := credsOutput[testRegistryName]
 	assert.NotEmpty(t, actualCredEntry)
@@ -110,8 +113,9 @@ func TestGetOrCreateRegistryCredentials_WithCredPairAndExistingFound(t *testing.
 	mocks := setupTestController(t)
 	mocks.MockSM.EXPECT().DescribeSecret(gomock.Any()).Return(&secretsmanager.DescribeSecretOutput{ARN: aws.String(responseARN)}, nil)
 
-	credsOutput, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false)
+	credsOutput, createdSecrets, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false, 1)
 	assert.NoError(t, err, "Expected no error when creating secret with cred pair")
+	assert.Empty(t, createdSecrets, "Expected no secrets to be reported as created")
 
 	actualCredEntry := credsOutput[testRegistryName]
 	assert.NotEmpty(t, actualCredEntry)
@@ -130,8 +134,9 @@ func TestGetOrCreateRegistryCredentials_WithSecretArnOnly(t *testing.T) {
 	mocks := setupTestController(t)
 	mocks.MockSM.EXPECT().DescribeSecret(gomock.Any()).Return(&secretsmanager.DescribeSecretOutput{}, nil)
 
-	credsOutput, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false)
+	credsOutput, createdSecrets, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false, 1)
 	assert.NoError(t, err, "Expected no error when using existing secren ARN")
+	assert.Empty(t, createdSecrets, "Expected no secrets to be reported as created")
 
 	actualCredEntry := credsOutput[testRegistryName]
 	assert.NotEmpty(t, actualCredEntry)
@@ -161,8 +166,9 @@ func TestGetOrCreateRegistryCredentials_WithExistingAndCredsUpdateOk(t *testing.
 	)
 
 	// call with updateAllowed = true
-	credsOutput, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, true)
+	credsOutput, createdSecrets, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, true, 1)
 	assert.NoError(t, err, "Expected no error when updating existing secren ARN")
+	assert.Empty(t, createdSecrets, "Expected no secrets to be reported as created")
 
 	actualCredEntry := credsOutput[testRegistryName]
 	assert.NotEmpty(t, actualCredEntry)
@@ -183,8 +189,9 @@ func TestGetOrCreateRegistryCredentials_WithExistingAndCredsNoUpdate(t *testing.
 	mocks := setupTestController(t)
 	mocks.MockSM.EXPECT().DescribeSecret(gomock.Any()).Return(&secretsmanager.DescribeSecretOutput{}, nil)
 
-	credsOutput, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false)
+	credsOutput, createdSecrets, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false, 1)
 	assert.NoError(t, err, "Expected no error when using existing secren ARN")
+	assert.Empty(t, createdSecrets, "Expected no secrets to be reported as created")
 
 	actualCredEntry := credsOutput[testRegistryName]
 	assert.NotEmpty(t, actualCredEntry)
@@ -202,7 +209,7 @@ func TestGetOrCreateRegistryCredentials_ErrorOnCreate(t *testing.T) {
 		mocks.MockSM.EXPECT().CreateSecret(gomock.Any()).Return(nil, errors.New("something went wrong")),
 	)
 
-	_, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false)
+	_, _, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, false, 1)
 	assert.Error(t, err)
 }
 
@@ -214,7 +221,7 @@ func TestGetOrCreateRegistryCredentials_ErrorOnUpdate(t *testing.T) {
 	mocks := setupTestController(t)
 	mocks.MockSM.EXPECT().PutSecretValue(gomock.Any()).Return(nil, errors.New("something went wrong"))
 
-	_, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, true)
+	_, _, err := getOrCreateRegistryCredentials(testRegistryCreds, mocks.MockSM, true, 1)
 	assert.Error(t, err)
 }
 
@@ -308,6 +315,26 @@ func TestTagRegistryCredentialsFailedResources(t *testing.T) {
 	assert.Error(t, err, "Expected error calling tagRegistryCredentials")
 }
 
+func TestTagRegistryCredentialsReportsAllFailedResources(t *testing.T) {
+	creds := map[string]regcredio.CredsOutputEntry{
+		"a.example.com": {CredentialARN: "arn:aws:secretsmanager:eu-west-1:111111111111:secret:a"},
+		"b.example.com": {CredentialARN: "arn:aws:secretsmanager:eu-west-1:111111111111:secret:b"},
+	}
+	tags := map[string]*string{"Team": aws.String("payments")}
+
+	ctrl := gomock.NewController(t)
+	mockTagging := mock_tagging.NewMockClient(ctrl)
+	mockTagging.EXPECT().TagResources(gomock.Any()).Return(&taggingSDK.TagResourcesOutput{
+		FailedResourcesMap: map[string]*taggingSDK.FailureInfo{
+			"arn:aws:secretsmanager:eu-west-1:111111111111:secret:b": {ErrorMessage: aws.String("throttled")},
+			"arn:aws:secretsmanager:eu-west-1:111111111111:secret:a": {},
+		},
+	}, nil)
+
+	err := tagRegistryCredentials(creds, tags, mockTagging)
+	assert.EqualError(t, err, "Failed to tag 2 resource(s): arn:aws:secretsmanager:eu-west-1:111111111111:secret:a (error=); arn:aws:secretsmanager:eu-west-1:111111111111:secret:b (error=throttled)")
+}
+
 func TestValidateCredsInput_ErrorEmptyCreds(t *testing.T) {
 	emptyCredMap := make(map[string]regcredio.RegistryCredEntry)
 	emptyCredsInput := regcredio.ECSRegCredsInput{
@@ -315,7 +342,7 @@ func TestValidateCredsInput_ErrorEmptyCreds(t *testing.T) {
 		RegistryCredentials: emptyCredMap,
 	}
 
-	_, err := validateCredsInput(emptyCredsInput, nil)
+	_, err := validateCredsInput(emptyCredsInput, "us-west-2", nil)
 	assert.Error(t, err, "Expected empty creds to return error")
 }
 
@@ -329,7 +356,7 @@ func TestValidateCredsInput_ErrorOnMissingReqFields(t *testing.T) {
 		RegistryCredentials: mapWithEmptyCredEntry,
 	}
 
-	_, err := validateCredsInput(testCredsInput, nil)
+	_, err := validateCredsInput(testCredsInput, "us-west-2", nil)
 	assert.Error(t, err, "Expected creds with empty entry to return error")
 }
 
@@ -351,7 +378,7 @@ func TestValidateCredsInput_ErrorOnDuplicateContainers(t *testing.T) {
 		RegistryCredentials: regCreds,
 	}
 
-	_, err := validateCredsInput(testCredsInput, nil)
+	_, err := validateCredsInput(testCredsInput, "us-west-2", nil)
 	assert.Error(t, err, "Expected creds with duplicate containers to return error")
 }
 
@@ -377,7 +404,7 @@ func TestValidateCredsInput_KeyAliasDescribed(t *testing.T) {
 		mocks.MockKMS.EXPECT().DescribeKey("alias/someKey").Return(&kms.DescribeKeyOutput{KeyMetadata: &expectKeyMetadata}, nil),
 	)
 
-	validatedOutput, err := validateCredsInput(testCredsInput, mocks.MockKMS)
+	validatedOutput, err := validateCredsInput(testCredsInput, "us-west-2", mocks.MockKMS)
 	assert.NoError(t, err, "Unexpected error on Describe Key")
 	assert.Equal(t, expectedKeyARN, validatedOutput[testRegName].KmsKeyID)
 }
@@ -396,7 +423,7 @@ func TestValidateCredsInput_NoDescribeOnKeyARN(t *testing.T) {
 
 	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return(testKeyARN, nil)
 
-	validatedOutput, err := validateCredsInput(testCredsInput, mocks.MockKMS)
+	validatedOutput, err := validateCredsInput(testCredsInput, "us-west-2", mocks.MockKMS)
 	assert.NoError(t, err, "Unexpected error when validating reg creds")
 	assert.Equal(t, testKeyARN, validatedOutput["testRegistry"].KmsKeyID)
 }
@@ -416,7 +443,7 @@ func TestValidateCredsInput_ErrorOnDescribeFail(t *testing.T) {
 		mocks.MockKMS.EXPECT().DescribeKey(gomock.Any()).Return(nil, errors.New("something went wrong")),
 	)
 
-	_, err := validateCredsInput(testCredsInput, mocks.MockKMS)
+	_, err := validateCredsInput(testCredsInput, "us-west-2", mocks.MockKMS)
 	assert.Error(t, err, "Expected error when Describe Key fails")
 }
 
@@ -433,10 +460,159 @@ func TestValidateCredsInput_ErrorOnRegionMismatch(t *testing.T) {
 	mocks := setupTestController(t)
 	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return(testKeyARN, nil)
 
-	_, err := validateCredsInput(testCredsInput, mocks.MockKMS)
+	_, err := validateCredsInput(testCredsInput, "us-west-2", mocks.MockKMS)
 	assert.Error(t, err, "Expected error when secret and key regions don't match")
 }
 
+func TestValidateCredsInput_Partitions(t *testing.T) {
+	testCases := []struct {
+		description string
+		secretARN   string
+		region      string
+		expectError bool
+	}{
+		{"aws secret in aws region", "arn:aws:secretsmanager:us-west-2:1234567:secret/some-secret", "us-west-2", false},
+		{"aws-us-gov secret in GovCloud region", "arn:aws-us-gov:secretsmanager:us-gov-west-1:1234567:secret/some-secret", "us-gov-west-1", false},
+		{"aws-cn secret in China region", "arn:aws-cn:secretsmanager:cn-north-1:1234567:secret/some-secret", "cn-north-1", false},
+		{"aws secret in GovCloud region", "arn:aws:secretsmanager:us-west-2:1234567:secret/some-secret", "us-gov-west-1", true},
+		{"aws secret in China region", "arn:aws:secretsmanager:us-west-2:1234567:secret/some-secret", "cn-northwest-1", true},
+		{"aws-cn secret in aws region", "arn:aws-cn:secretsmanager:cn-north-1:1234567:secret/some-secret", "us-east-1", true},
+	}
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			testCredsInput := regcredio.ECSRegCredsInput{
+				Version: "1",
+				RegistryCredentials: map[string]regcredio.RegistryCredEntry{
+					"testRegistry": getTestCredsEntry(test.secretARN, "", "", "", []string{"test"}),
+				},
+			}
+
+			_, err := validateCredsInput(testCredsInput, test.region, nil)
+			if test.expectError {
+				assert.Error(t, err, "Expected error when secret partition doesn't match region")
+				assert.Contains(t, err.Error(), test.region)
+			} else {
+				assert.NoError(t, err, "Unexpected error when secret partition matches region")
+			}
+		})
+	}
+}
+
+func TestValidateRoleDetails(t *testing.T) {
+	assert.NoError(t, validateRoleDetails([]string{"myRole"}, false, map[string]string{flags.RoleBundleFlag: "bundle.yml"}))
+	assert.NoError(t, validateRoleDetails(nil, true, map[string]string{flags.RoleBundleFlag: ""}))
+	assert.Error(t, validateRoleDetails(nil, false, nil), "Expected error when neither role name nor --no-role given")
+	assert.Error(t, validateRoleDetails([]string{"myRole"}, true, nil), "Expected error when both role name and --no-role given")
+	assert.NoError(t, validateRoleDetails([]string{"myRole", "myOtherRole"}, false, nil))
+	assert.Error(t, validateRoleDetails([]string{"myRole", "MYROLE"}, false, nil), "Expected error when a role is given more than once")
+	assert.Error(t, validateRoleDetails([]string{"myRole", ""}, false, nil), "Expected error on empty role name")
+
+	err := validateRoleDetails(nil, true, map[string]string{
+		flags.VersionStageFlag: "",
+		flags.RoleBundleFlag:   "bundle.yml",
+	})
+	assert.Error(t, err, "Expected error when a role option is given with --no-role")
+	assert.Contains(t, err.Error(), flags.RoleBundleFlag)
+}
+
+func TestValidateRoleOnly(t *testing.T) {
+	assert.NoError(t, validateRoleOnly(map[string]string{flags.IncludeECRFlag: "", flags.PolicyNameFlag: ""}))
+
+	err := validateRoleOnly(map[string]string{
+		flags.IncludeECRFlag: "",
+		flags.PolicyNameFlag: "myPolicy",
+	})
+	assert.Error(t, err, "Expected error when a policy option is given with --role-only")
+	assert.Contains(t, err.Error(), flags.PolicyNameFlag)
+}
+
+func TestValidateUpFormat(t *testing.T) {
+	assert.NoError(t, validateUpFormat("", true))
+	assert.NoError(t, validateUpFormat(JSONOutputFormat, false))
+	assert.Error(t, validateUpFormat(TableOutputFormat, false), "Expected error for a format other than json")
+	assert.Error(t, validateUpFormat(JSONOutputFormat, true), "Expected error for json with another flag printing to stdout")
+}
+
+func TestParseManagementTag(t *testing.T) {
+	testCases := []struct {
+		flagValue     string
+		expectedKey   string
+		expectedValue string
+		expectErr     bool
+	}{
+		{"", "", "", false},
+		{"Provisioner=regcreds", "Provisioner", "regcreds", false},
+		{"Provisioner=", "Provisioner", "", false},
+		{"Provisioner", "", "", true},
+		{"a=b,c=d", "", "", true},
+		{"=regcreds", "", "", true},
+	}
+	for _, test := range testCases {
+		t.Run(fmt.Sprintf("Parse management tag '%s'", test.flagValue), func(t *testing.T) {
+			key, value, err := parseManagementTag(test.flagValue)
+			if test.expectErr {
+				assert.Error(t, err, "Expected error parsing management tag")
+			} else {
+				assert.NoError(t, err, "Unexpected error parsing management tag")
+				assert.Equal(t, test.expectedKey, key)
+				assert.Equal(t, test.expectedValue, value)
+			}
+		})
+	}
+}
+
+func TestInferRegionFromCreds(t *testing.T) {
+	testCases := []struct {
+		description    string
+		creds          regcredio.RegistryCreds
+		expectedRegion string
+	}{
+		{
+			"All secrets in one region",
+			regcredio.RegistryCreds{
+				"reg1": getTestCredsEntry("arn:aws:secretsmanager:us-east-2:111111111111:secret:one", "", "", "", nil),
+				"reg2": getTestCredsEntry("arn:aws:secretsmanager:us-east-2:111111111111:secret:two", "", "", "arn:aws:kms:us-east-2:111111111111:key/abc", nil),
+			},
+			"us-east-2",
+		},
+		{
+			"Secrets span multiple regions",
+			regcredio.RegistryCreds{
+				"reg1": getTestCredsEntry("arn:aws:secretsmanager:us-east-2:111111111111:secret:one", "", "", "", nil),
+				"reg2": getTestCredsEntry("arn:aws:secretsmanager:eu-west-1:111111111111:secret:two", "", "", "", nil),
+			},
+			"",
+		},
+		{
+			"Key in a different region",
+			regcredio.RegistryCreds{
+				"reg1": getTestCredsEntry("arn:aws:secretsmanager:us-east-2:111111111111:secret:one", "", "", "arn:aws:kms:us-west-2:111111111111:key/abc", nil),
+			},
+			"",
+		},
+		{
+			"Key alias is ignored",
+			regcredio.RegistryCreds{
+				"reg1": getTestCredsEntry("arn:aws:secretsmanager:us-east-2:111111111111:secret:one", "", "", "alias/myKey", nil),
+			},
+			"us-east-2",
+		},
+		{
+			"New secret without ARN",
+			regcredio.RegistryCreds{
+				"reg1": getTestCredsEntry("arn:aws:secretsmanager:us-east-2:111111111111:secret:one", "", "", "", nil),
+				"reg2": getTestCredsEntry("", "user", "password", "", nil),
+			},
+			"",
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.expectedRegion, inferRegionFromCreds(test.creds))
+		})
+	}
+}
+
 func TestGenerateSecretString(t *testing.T) {
 	type ECSRegistrySecret struct {
 		Username string `json:"username"`
@@ -494,3 +670,25 @@ func setupTestController(t *testing.T) testClients {
 
 	return clients
 }
+
+func TestWriteManifest_Store(t *testing.T) {
+	store := regcredio.NewMemoryStore(nil)
+	roleResults := []*ExecutionRoleResult{{
+		RoleName:           "myTaskExecutionRole",
+		RoleCreated:        true,
+		RoleARN:            "arn:aws:iam::111111111111:role/myTaskExecutionRole",
+		PolicyARN:          testManifestPolicyARN,
+		AttachedPolicyARNs: []string{getExecutionRolePolicyARN("us-west-2"), testManifestPolicyARN},
+	}}
+
+	writeManifest(store, "manifest.json", nil, roleResults, nil, "us-west-2")
+
+	manifest, err := regcredio.ReadManifestFrom(store, "manifest.json")
+	assert.NoError(t, err, "Expected manifest to be written to the store")
+	assert.Equal(t, "us-west-2", manifest.Region)
+	assert.Len(t, manifest.Roles, 1)
+	assert.Equal(t, testManifestPolicyARN, manifest.Roles[0].PolicyARN)
+
+	writeManifest(store, "", nil, roleResults, nil, "us-west-2")
+	assert.Equal(t, []string{"manifest.json"}, store.FileNames(), "Expected no manifest to be written without a file name")
+}
@@ -0,0 +1,103 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"os"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	regionSourceFlag          = "the --" + flags.RegionFlag + " flag"
+	regionSourceCredsInput    = "the registry credential ARNs"
+	regionSourceManifest      = "the manifest"
+	regionSourceSigningKey    = "the manifest signing key ARN"
+	regionSourceClusterConfig = "the ECS CLI cluster configuration"
+	regionSourceAWSProfile    = "the AWS profile"
+)
+
+// resolveRegion returns the region to use and a description of where it came from. Order of resolution:
+//  1. the given region, if any; e.g. read from a manifest, or inferred from the registry credentials when the
+//     --region flag is not given
+//  2. the --region flag
+//  3. the AWS_REGION or AWS_DEFAULT_REGION environment variable
+//  4. the region of the ECS CLI cluster configuration
+//  5. the region of the AWS profile
+//
+// If no region is found, an empty region is returned.
+func resolveRegion(c *cli.Context, rdwr config.ReadWriter, region, regionSource string) (string, string, error) {
+	if region != "" {
+		return region, regionSource, nil
+	}
+	if regionFromFlag := config.RecursiveFlagSearch(c, flags.RegionFlag); regionFromFlag != "" {
+		return regionFromFlag, regionSourceFlag, nil
+	}
+	if regionFromEnv, source := regionFromEnvVars(); regionFromEnv != "" {
+		return regionFromEnv, source, nil
+	}
+
+	ecsConfig, err := rdwr.Get(config.RecursiveFlagSearch(c, flags.ClusterConfigFlag), config.RecursiveFlagSearch(c, flags.ECSProfileFlag))
+	if err != nil {
+		return "", "", errors.Wrap(err, "Error loading config")
+	}
+	if ecsConfig.Region != "" {
+		return ecsConfig.Region, regionSourceClusterConfig, nil
+	}
+
+	awsProfile := config.RecursiveFlagSearch(c, flags.AWSProfileFlag)
+	if awsProfile == "" {
+		awsProfile = ecsConfig.AWSProfile
+	}
+	profileSession, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+		Profile:           awsProfile,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if regionFromProfile := aws.StringValue(profileSession.Config.Region); regionFromProfile != "" {
+		return regionFromProfile, regionSourceAWSProfile, nil
+	}
+
+	return "", "", nil
+}
+
+// regionFromEnvVars returns the region set with AWS_REGION or AWS_DEFAULT_REGION, and the name of the variable
+func regionFromEnvVars() (string, string) {
+	for _, envVar := range []string{flags.AwsRegionEnvVar, flags.AwsDefaultRegionEnvVar} {
+		if regionFromEnv := os.Getenv(envVar); regionFromEnv != "" {
+			return regionFromEnv, "the " + envVar + " environment variable"
+		}
+	}
+	return "", ""
+}
+
+// getRegion resolves the region and logs which source it came from
+func getRegion(c *cli.Context, rdwr config.ReadWriter, region, regionSource string) (string, error) {
+	resolvedRegion, source, err := resolveRegion(c, rdwr, region, regionSource)
+	if err != nil {
+		return "", err
+	}
+	if resolvedRegion != "" {
+		log.Debugf("Using region %s from %s", resolvedRegion, source)
+	}
+	return resolvedRegion, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+type fakeRegionReadWriter struct {
+	config.ReadWriter
+	region string
+}
+
+func (rdwr *fakeRegionReadWriter) Get(clusterConfig, profileConfig string) (*config.LocalConfig, error) {
+	return &config.LocalConfig{Region: rdwr.region}, nil
+}
+
+func TestResolveRegion(t *testing.T) {
+	testCases := []struct {
+		description    string
+		flagRegion     string
+		givenRegion    string
+		envRegion      string
+		envDefault     string
+		configRegion   string
+		awsProfile     string
+		expectedRegion string
+		expectedSource string
+	}{
+		{"flag wins", "us-east-1", "", "eu-west-1", "eu-west-2", "ap-south-1", "customProfile", "us-east-1", regionSourceFlag},
+		{"given region wins", "us-east-1", "ca-central-1", "eu-west-1", "", "", "", "ca-central-1", regionSourceManifest},
+		{"AWS_REGION", "", "", "eu-west-1", "eu-west-2", "ap-south-1", "", "eu-west-1", "the AWS_REGION environment variable"},
+		{"AWS_DEFAULT_REGION", "", "", "", "eu-west-2", "ap-south-1", "", "eu-west-2", "the AWS_DEFAULT_REGION environment variable"},
+		{"cluster config", "", "", "", "", "ap-south-1", "customProfile", "ap-south-1", regionSourceClusterConfig},
+		{"AWS profile", "", "", "", "", "", "customProfile", "us-west-1", regionSourceAWSProfile},
+		{"default AWS profile", "", "", "", "", "", "", "us-west-2", regionSourceAWSProfile},
+	}
+
+	defer restoreTestEnv(flags.AwsRegionEnvVar)()
+	defer restoreTestEnv(flags.AwsDefaultRegionEnvVar)()
+	defer restoreTestEnv("AWS_CONFIG_FILE")()
+	defer restoreTestEnv("AWS_PROFILE")()
+	os.Setenv("AWS_CONFIG_FILE", "../../config/aws_config_example.ini")
+	os.Unsetenv("AWS_PROFILE")
+
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			os.Setenv(flags.AwsRegionEnvVar, test.envRegion)
+			os.Setenv(flags.AwsDefaultRegionEnvVar, test.envDefault)
+
+			flagSet := flag.NewFlagSet("ecs-cli", 0)
+			flagSet.String(flags.RegionFlag, test.flagRegion, "")
+			flagSet.String(flags.AWSProfileFlag, test.awsProfile, "")
+			context := cli.NewContext(nil, flagSet, nil)
+
+			source := ""
+			if test.givenRegion != "" {
+				source = regionSourceManifest
+			}
+			region, regionSource, err := resolveRegion(context, &fakeRegionReadWriter{region: test.configRegion}, test.givenRegion, source)
+			assert.NoError(t, err, "Unexpected error resolving region")
+			assert.Equal(t, test.expectedRegion, region)
+			assert.Equal(t, test.expectedSource, regionSource)
+		})
+	}
+}
+
+// restoreTestEnv returns a function which restores the current value of the environment variable
+func restoreTestEnv(name string) func() {
+	value, ok := os.LookupEnv(name)
+	return func() {
+		if ok {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
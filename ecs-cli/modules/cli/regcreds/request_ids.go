@@ -0,0 +1,106 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"sync"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// requestIDRecorder keeps the AWS request IDs of the successful calls which create roles and policies and attach
+// policies, for '--output-include-request-ids'. Roles are set up concurrently, so it is safe for concurrent use.
+type requestIDRecorder struct {
+	lock sync.Mutex
+	// by role name
+	createRole map[string]string
+	// by policy ARN
+	createPolicy map[string]string
+	// by role name, then policy ARN
+	attachRolePolicy map[string]map[string]string
+}
+
+func newRequestIDRecorder() *requestIDRecorder {
+	return &requestIDRecorder{
+		createRole:       make(map[string]string),
+		createPolicy:     make(map[string]string),
+		attachRolePolicy: make(map[string]map[string]string),
+	}
+}
+
+// AddTo records the request IDs of the calls made with the handlers. Handlers of a session are copied to each client
+// created from it, so it must be added before the IAM client is created.
+func (rec *requestIDRecorder) AddTo(handlers *request.Handlers) {
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "ECSCLIRequestIDHandler",
+		Fn: func(r *request.Request) {
+			if r.Error == nil && r.RequestID != "" {
+				rec.record(r.Params, r.Data, r.RequestID)
+			}
+		},
+	})
+}
+
+func (rec *requestIDRecorder) record(params, data interface{}, requestID string) {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	switch input := params.(type) {
+	case *iam.CreateRoleInput:
+		rec.createRole[aws.StringValue(input.RoleName)] = requestID
+	case *iam.CreatePolicyInput:
+		if output, ok := data.(*iam.CreatePolicyOutput); ok && output.Policy != nil {
+			rec.createPolicy[aws.StringValue(output.Policy.Arn)] = requestID
+		}
+	case *iam.AttachRolePolicyInput:
+		roleName := aws.StringValue(input.RoleName)
+		if rec.attachRolePolicy[roleName] == nil {
+			rec.attachRolePolicy[roleName] = make(map[string]string)
+		}
+		// a retried attachment keeps the request ID of the call which succeeded
+		rec.attachRolePolicy[roleName][aws.StringValue(input.PolicyArn)] = requestID
+	}
+}
+
+// roleRequestIDs returns the request IDs of the calls the run made for the role, or nil if it made none
+func (rec *requestIDRecorder) roleRequestIDs(result *ExecutionRoleResult) *regcredio.RoleRequestIDs {
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	// reused roles and policies have no request IDs, since they weren't created by the run
+	requestIDs := &regcredio.RoleRequestIDs{
+		CreateRole:   rec.createRole[result.RoleName],
+		CreatePolicy: rec.createPolicy[result.PolicyARN],
+	}
+	for _, policyARN := range result.AttachedPolicyARNs {
+		if requestID, ok := rec.attachRolePolicy[result.RoleName][policyARN]; ok {
+			if requestIDs.AttachRolePolicy == nil {
+				requestIDs.AttachRolePolicy = make(map[string]string)
+			}
+			requestIDs.AttachRolePolicy[policyARN] = requestID
+		}
+	}
+	if requestIDs.CreateRole == "" && requestIDs.CreatePolicy == "" && len(requestIDs.AttachRolePolicy) == 0 {
+		return nil
+	}
+	return requestIDs
+}
+
+// addRequestIDs adds the request IDs of each role's calls to its output file entry
+func addRequestIDs(roleEntries []regcredio.RoleOutputEntry, roleResults []*ExecutionRoleResult, rec *requestIDRecorder) {
+	for i := range roleEntries {
+		roleEntries[i].RequestIDs = rec.roleRequestIDs(roleResults[i])
+	}
+}
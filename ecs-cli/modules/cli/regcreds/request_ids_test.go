@@ -0,0 +1,66 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDRecorder(t *testing.T) {
+	policyARN := "arn:aws:iam::111111111111:policy/newRole-policy"
+	managedARN := getExecutionRolePolicyARN("us-west-2")
+
+	rec := newRequestIDRecorder()
+	handlers := request.Handlers{}
+	rec.AddTo(&handlers)
+	complete := func(params, data interface{}, requestID string, err error) {
+		handlers.Complete.Run(&request.Request{Params: params, Data: data, RequestID: requestID, Error: err})
+	}
+	complete(&iam.CreateRoleInput{RoleName: aws.String("newRole")}, &iam.CreateRoleOutput{}, "create-role-id", nil)
+	complete(&iam.CreateRoleInput{RoleName: aws.String("existingRole")}, &iam.CreateRoleOutput{}, "exists-id", errors.New("EntityAlreadyExists"))
+	complete(&iam.CreatePolicyInput{PolicyName: aws.String("newRole-policy")}, &iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(policyARN)}}, "create-policy-id", nil)
+	complete(&iam.AttachRolePolicyInput{RoleName: aws.String("newRole"), PolicyArn: aws.String(managedARN)}, &iam.AttachRolePolicyOutput{}, "attach-managed-id", nil)
+	// an attachment retried after access was denied keeps the ID of the call which succeeded
+	complete(&iam.AttachRolePolicyInput{RoleName: aws.String("newRole"), PolicyArn: aws.String(policyARN)}, &iam.AttachRolePolicyOutput{}, "denied-id", errors.New("AccessDenied"))
+	complete(&iam.AttachRolePolicyInput{RoleName: aws.String("newRole"), PolicyArn: aws.String(policyARN)}, &iam.AttachRolePolicyOutput{}, "attach-policy-id", nil)
+	complete(&iam.GetRoleInput{RoleName: aws.String("existingRole")}, &iam.GetRoleOutput{}, "get-role-id", nil)
+
+	roleResults := []*ExecutionRoleResult{
+		{RoleName: "newRole", RoleCreated: true, PolicyARN: policyARN, AttachedPolicyARNs: []string{managedARN, policyARN}},
+		{RoleName: "existingRole"},
+	}
+	roleEntries := buildRoleOutputEntries(roleResults, managedARN)
+	addRequestIDs(roleEntries, roleResults, rec)
+
+	assert.Equal(t, &regcredio.RoleRequestIDs{
+		CreateRole:   "create-role-id",
+		CreatePolicy: "create-policy-id",
+		AttachRolePolicy: map[string]string{
+			managedARN: "attach-managed-id",
+			policyARN:  "attach-policy-id",
+		},
+	}, roleEntries[0].RequestIDs)
+	assert.Nil(t, roleEntries[1].RequestIDs, "Expected no request IDs for a role the run made no changes to")
+
+	output, err := regcredio.FormatCredsOutputJSON(regcredio.BuildCredsOutput(nil, roleEntries))
+	assert.NoError(t, err, "Unexpected error formatting output")
+	assert.Contains(t, output, `"create_role": "create-role-id"`, "Expected the request IDs in the JSON output")
+}
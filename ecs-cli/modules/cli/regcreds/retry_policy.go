@@ -0,0 +1,166 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// applyRetryPolicy retries the failed requests of the clients created from the command's session as the
+// --retry-policy file says for their error code. Without the file, the SDK's retries are used. Either way, no request
+// is retried more than --max-retries times, if it is given.
+func applyRetryPolicy(c *cli.Context, commandConfig *config.CommandConfig) error {
+	maxRetries, err := maxRetriesFlag(c)
+	if err != nil {
+		return err
+	}
+	filename := c.String(flags.RetryPolicyFlag)
+	if filename == "" && maxRetries < 0 {
+		return nil
+	}
+
+	if maxRetries == 0 {
+		log.Debug("Retries are disabled; failed requests are not retried")
+	}
+	if filename == "" {
+		// each client keeps its own retryer, with the number of retries capped
+		commandConfig.Session = commandConfig.Session.Copy(aws.NewConfig().WithMaxRetries(maxRetries))
+		return nil
+	}
+
+	policy, err := regcredio.ReadRetryPolicyFrom(regcredio.FileStore{}, filename)
+	if err != nil {
+		return err
+	}
+	policyRetryer := newPolicyRetryer(*policy)
+	for code, rule := range policy.ErrorCodes {
+		log.Debugf("Retrying error code %s up to %d time(s)", code, *rule.MaxRetries)
+	}
+	if maxRetries >= 0 && maxRetries < policyRetryer.maxRetries {
+		// the SDK never retries a request more than MaxRetries times, whatever the rule of its error code
+		policyRetryer.maxRetries = maxRetries
+	}
+	// handlers can decide that an error is retryable before the retryer is asked, so the check is enforced for the
+	// policy to also stop those retries
+	sessionConfig := request.WithRetryer(&aws.Config{EnforceShouldRetryCheck: aws.Bool(true)}, policyRetryer)
+	commandConfig.Session = commandConfig.Session.Copy(sessionConfig)
+	return nil
+}
+
+// maxRetriesFlag returns the value of --max-retries, or -1 if it isn't given
+func maxRetriesFlag(c *cli.Context) (int, error) {
+	if !c.IsSet(flags.MaxRetriesFlag) {
+		return -1, nil
+	}
+	maxRetries := c.Int(flags.MaxRetriesFlag)
+	if maxRetries < 0 || maxRetries > regcredio.MaxRetryPolicyRetries {
+		return 0, fmt.Errorf("'--%s' must be between 0 and %d", flags.MaxRetriesFlag, regcredio.MaxRetryPolicyRetries)
+	}
+	return maxRetries, nil
+}
+
+// retriesDisabled returns whether '--max-retries 0' was given, which also disables the ECS CLI's own retries
+func retriesDisabled(c *cli.Context) bool {
+	return c.IsSet(flags.MaxRetriesFlag) && c.Int(flags.MaxRetriesFlag) == 0
+}
+
+// policyRetryer is a request.Retryer which applies the rule of a request's error code, or else the default rule to
+// the errors the SDK would retry
+type policyRetryer struct {
+	rules       map[string]regcredio.RetryRule
+	defaultRule regcredio.RetryRule
+	maxRetries  int
+}
+
+func newPolicyRetryer(policy regcredio.ECSRetryPolicy) policyRetryer {
+	defaultRetries := client.DefaultRetryerMaxNumRetries
+	defaultRule := regcredio.RetryRule{MaxRetries: &defaultRetries}
+	if policy.Default != nil {
+		defaultRule = *policy.Default
+	}
+
+	// the SDK stops once a request has been retried MaxRetries times, so it is the largest of the rules' limits
+	maxRetries := *defaultRule.MaxRetries
+	for _, rule := range policy.ErrorCodes {
+		if *rule.MaxRetries > maxRetries {
+			maxRetries = *rule.MaxRetries
+		}
+	}
+	return policyRetryer{
+		rules:       policy.ErrorCodes,
+		defaultRule: defaultRule,
+		maxRetries:  maxRetries,
+	}
+}
+
+func (retryer policyRetryer) MaxRetries() int {
+	return retryer.maxRetries
+}
+
+func (retryer policyRetryer) ShouldRetry(r *request.Request) bool {
+	rule, listed := retryer.rule(r)
+	if r.RetryCount >= *rule.MaxRetries {
+		return false
+	}
+	if listed {
+		return true
+	}
+	return client.DefaultRetryer{NumMaxRetries: *rule.MaxRetries}.ShouldRetry(r)
+}
+
+func (retryer policyRetryer) RetryRules(r *request.Request) time.Duration {
+	rule, _ := retryer.rule(r)
+	if rule.BaseDelay == 0 {
+		return client.DefaultRetryer{NumMaxRetries: *rule.MaxRetries, MaxRetryDelay: rule.MaxDelay, MaxThrottleDelay: rule.MaxDelay}.RetryRules(r)
+	}
+	return retryDelay(rule, r.RetryCount)
+}
+
+// rule returns the rule of the request's error code and whether the code is listed in the policy
+func (retryer policyRetryer) rule(r *request.Request) (regcredio.RetryRule, bool) {
+	if aerr, ok := r.Error.(awserr.Error); ok {
+		if rule, ok := retryer.rules[aerr.Code()]; ok {
+			return rule, true
+		}
+	}
+	return retryer.defaultRule, false
+}
+
+// retryDelay returns the base delay doubled for each earlier retry, capped by the rule's maximum delay or else the
+// SDK's
+func retryDelay(rule regcredio.RetryRule, retryCount int) time.Duration {
+	maxDelay := rule.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = client.DefaultRetryerMaxRetryDelay
+	}
+	delay := rule.BaseDelay
+	for i := 0; i < retryCount && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,237 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func testRetryPolicy() regcredio.ECSRetryPolicy {
+	return regcredio.ECSRetryPolicy{
+		Version: regcredio.RetryPolicyVersion,
+		Default: &regcredio.RetryRule{MaxRetries: aws.Int(1)},
+		ErrorCodes: map[string]regcredio.RetryRule{
+			"Throttling":     {MaxRetries: aws.Int(8), BaseDelay: 500 * time.Millisecond, MaxDelay: 4 * time.Second},
+			"ServiceFailure": {MaxRetries: aws.Int(3)},
+			"RequestError":   {MaxRetries: aws.Int(0)},
+		},
+	}
+}
+
+func TestPolicyRetryer_MaxRetries(t *testing.T) {
+	assert.Equal(t, 8, newPolicyRetryer(testRetryPolicy()).MaxRetries(), "Expected the largest limit of the rules")
+
+	retryer := newPolicyRetryer(regcredio.ECSRetryPolicy{ErrorCodes: map[string]regcredio.RetryRule{"AccessDenied": {MaxRetries: aws.Int(0)}}})
+	assert.Equal(t, 3, retryer.MaxRetries(), "Expected the SDK's limit without a default rule")
+}
+
+func TestPolicyRetryer_ShouldRetry(t *testing.T) {
+	testCases := map[string]struct {
+		code       string
+		retryable  *bool
+		retryCount int
+		expected   bool
+	}{
+		"listed code within limit":            {code: "Throttling", retryCount: 7, expected: true},
+		"listed code at limit":                {code: "Throttling", retryCount: 8, expected: false},
+		"listed code the SDK doesn't retry":   {code: "ServiceFailure", retryCount: 2, expected: true},
+		"listed code with no retries":         {code: "RequestError", retryable: aws.Bool(true), expected: false},
+		"unlisted code the SDK retries":       {code: "ThrottlingException", expected: true},
+		"unlisted code after default limit":   {code: "ThrottlingException", retryCount: 1, expected: false},
+		"unlisted code the SDK doesn't retry": {code: "AccessDenied", expected: false},
+	}
+	retryer := newPolicyRetryer(testRetryPolicy())
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := &request.Request{Error: awserr.New(test.code, "failed", nil), Retryable: test.retryable, RetryCount: test.retryCount}
+			assert.Equal(t, test.expected, retryer.ShouldRetry(r))
+		})
+	}
+}
+
+func TestPolicyRetryer_RetryRules(t *testing.T) {
+	retryer := newPolicyRetryer(testRetryPolicy())
+
+	expected := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}
+	for retryCount, delay := range expected {
+		r := &request.Request{Error: awserr.New("Throttling", "slow down", nil), RetryCount: retryCount}
+		assert.Equal(t, delay, retryer.RetryRules(r), "Unexpected delay before retry %d", retryCount+1)
+	}
+
+	// without a base delay, the SDK's delays are used
+	r := &request.Request{Error: awserr.New("ServiceFailure", "failed", nil)}
+	delay := retryer.RetryRules(r)
+	assert.True(t, delay > 0 && delay < time.Second, "Expected the SDK's short delay; found %s", delay)
+}
+
+func TestRetryDelay_DefaultMaxDelay(t *testing.T) {
+	rule := regcredio.RetryRule{MaxRetries: aws.Int(20), BaseDelay: time.Second}
+	assert.Equal(t, 5*time.Minute, retryDelay(rule, 20), "Expected the SDK's maximum delay")
+}
+
+// testRetryPolicyContext returns a context with --retry-policy set to a file with the given contents
+func testRetryPolicyContext(t *testing.T, policy string) (*cli.Context, func()) {
+	policyFile, err := ioutil.TempFile("", "retry_policy")
+	assert.NoError(t, err, "Unexpected error creating retry policy file")
+	_, err = policyFile.WriteString(policy)
+	assert.NoError(t, err, "Unexpected error writing retry policy file")
+	policyFile.Close()
+
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.String(flags.RetryPolicyFlag, policyFile.Name(), "")
+	return cli.NewContext(nil, flagSet, nil), func() { os.Remove(policyFile.Name()) }
+}
+
+// testFailingServerConfig returns a command config whose requests go to a server which fails every request with the
+// given status and body, and a counter of the requests the server received
+func testFailingServerConfig(t *testing.T, status int, contentType, body string) (*config.CommandConfig, *int, func()) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(server.URL),
+		DisableSSL:  aws.Bool(true),
+		Credentials: credentials.NewStaticCredentials("AKID", "SKID", ""),
+	})
+	assert.NoError(t, err, "Unexpected error creating session")
+	return &config.CommandConfig{Session: sess}, &requests, server.Close
+}
+
+func TestApplyRetryPolicy_IAMClient(t *testing.T) {
+	body := "<ErrorResponse><Error><Code>ServiceFailure</Code><Message>failed</Message></Error></ErrorResponse>"
+	commandConfig, requests, closeServer := testFailingServerConfig(t, http.StatusInternalServerError, "text/xml", body)
+	defer closeServer()
+	context, removeFile := testRetryPolicyContext(t, "version: \"1\"\nerror_codes:\n  ServiceFailure:\n    max_retries: 1\n    base_delay: 1ms\n")
+	defer removeFile()
+
+	err := applyRetryPolicy(context, commandConfig)
+	assert.NoError(t, err, "Unexpected error applying retry policy")
+
+	_, err = iam.NewIAMClient(commandConfig).GetRole("myRole")
+	assert.Error(t, err, "Expected error from server")
+	assert.Equal(t, 2, *requests, "Expected the failed request to be retried as the policy says")
+}
+
+func TestApplyRetryPolicy_KMSClient(t *testing.T) {
+	body := `{"__type":"KMSInternalException","message":"failed"}`
+	commandConfig, requests, closeServer := testFailingServerConfig(t, http.StatusInternalServerError, "application/x-amz-json-1.1", body)
+	defer closeServer()
+	context, removeFile := testRetryPolicyContext(t, "version: \"1\"\ndefault:\n  max_retries: 0\n")
+	defer removeFile()
+
+	err := applyRetryPolicy(context, commandConfig)
+	assert.NoError(t, err, "Unexpected error applying retry policy")
+
+	_, err = kms.NewKMSClient(commandConfig).DescribeKey("alias/myKey")
+	assert.Error(t, err, "Expected error from server")
+	assert.Equal(t, 1, *requests, "Expected the failed request not to be retried as the policy says")
+}
+
+func TestApplyRetryPolicy_MaxRetriesZero(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(server.URL),
+		DisableSSL:  aws.Bool(true),
+		Credentials: credentials.NewStaticCredentials("AKID", "SKID", ""),
+	})
+	assert.NoError(t, err, "Unexpected error creating session")
+	commandConfig := &config.CommandConfig{Session: sess}
+
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.Int(flags.MaxRetriesFlag, 0, "")
+	flagSet.Set(flags.MaxRetriesFlag, "0")
+	context := cli.NewContext(nil, flagSet, nil)
+
+	err = applyRetryPolicy(context, commandConfig)
+	assert.NoError(t, err, "Unexpected error applying retry policy")
+	assert.True(t, retriesDisabled(context), "Expected retries to be disabled")
+
+	_, err = iam.NewIAMClient(commandConfig).GetRole("myRole")
+	assert.Error(t, err, "Expected error from server")
+	assert.Equal(t, 1, requests, "Expected the failed IAM request not to be retried")
+
+	_, err = kms.NewKMSClient(commandConfig).DescribeKey("alias/myKey")
+	assert.Error(t, err, "Expected error from server")
+	assert.Equal(t, 2, requests, "Expected the failed KMS request not to be retried")
+}
+
+func TestApplyRetryPolicy_MaxRetriesCapsPolicy(t *testing.T) {
+	policyFile, err := ioutil.TempFile("", "retry_policy")
+	assert.NoError(t, err, "Unexpected error creating retry policy file")
+	defer os.Remove(policyFile.Name())
+	_, err = policyFile.WriteString("version: \"1\"\nerror_codes:\n  Throttling:\n    max_retries: 8\n")
+	assert.NoError(t, err, "Unexpected error writing retry policy file")
+	policyFile.Close()
+
+	sess, err := session.NewSession(&aws.Config{Region: aws.String("us-west-2")})
+	assert.NoError(t, err, "Unexpected error creating session")
+	commandConfig := &config.CommandConfig{Session: sess}
+
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.String(flags.RetryPolicyFlag, policyFile.Name(), "")
+	flagSet.Int(flags.MaxRetriesFlag, 0, "")
+	flagSet.Set(flags.MaxRetriesFlag, "2")
+	context := cli.NewContext(nil, flagSet, nil)
+
+	err = applyRetryPolicy(context, commandConfig)
+	assert.NoError(t, err, "Unexpected error applying retry policy")
+	retryer, ok := commandConfig.Session.Config.Retryer.(policyRetryer)
+	assert.True(t, ok, "Expected the retry policy to be used")
+	assert.Equal(t, 2, retryer.MaxRetries(), "Expected the policy's limit to be capped by --max-retries")
+	assert.False(t, retriesDisabled(context))
+}
+
+func TestApplyRetryPolicy_MaxRetriesOutOfRange(t *testing.T) {
+	for _, value := range []string{"-1", "21"} {
+		flagSet := flag.NewFlagSet("ecs-cli", 0)
+		flagSet.Int(flags.MaxRetriesFlag, 0, "")
+		flagSet.Set(flags.MaxRetriesFlag, value)
+		context := cli.NewContext(nil, flagSet, nil)
+
+		err := applyRetryPolicy(context, &config.CommandConfig{})
+		assert.Error(t, err, "Expected error for --max-retries %s", value)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+const arnAccountField = 4
+
+// verifyRoleAccount checks that an existing role is in the expected account. The role is always looked up with the
+// caller's credentials, so a mismatch means that the profile or endpoint is not the one that was intended.
+func verifyRoleAccount(roleName, expectedAccountID string, client iamClient.Client) error {
+	role, err := client.GetRole(roleName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get existing role %s to verify its account", roleName)
+	}
+	roleARN := aws.StringValue(role.Arn)
+	fields := strings.Split(roleARN, ":")
+	if len(fields) <= arnAccountField {
+		return fmt.Errorf("unable to verify the account of existing role %s; unexpected ARN '%s'", roleName, roleARN)
+	}
+	if accountID := fields[arnAccountField]; accountID != expectedAccountID {
+		return fmt.Errorf("existing role %s (%s) is in account %s, but the credentials are for account %s; check the profile, region and endpoint before reusing the role", roleName, roleARN, accountID, expectedAccountID)
+	}
+	return nil
+}
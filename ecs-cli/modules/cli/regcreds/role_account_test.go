@@ -0,0 +1,98 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTaskExecutionRole_ExistingRoleInExpectedAccount(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+	testPolicyArn := aws.String("arn:aws:iam::111111111111:policy/" + testRoleName + "-policy")
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().GetRole(testRoleName).Return(&iam.Role{Arn: aws.String("arn:aws:iam::111111111111:role/" + testRoleName)}, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret", "", []string{"web"}),
+		},
+		RoleName:          testRoleName,
+		Region:            "us-west-2",
+		ExpectedAccountID: "111111111111",
+	}
+
+	roleResult, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when reusing a role in the expected account")
+	assert.False(t, roleResult.RoleCreated, "Expected existing role to be reported as reused")
+}
+
+func TestCreateTaskExecutionRole_ErrorOnExistingRoleInOtherAccount(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().GetRole(testRoleName).Return(&iam.Role{Arn: aws.String("arn:aws:iam::222222222222:role/" + testRoleName)}, nil),
+	)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(gomock.Any(), gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret", "", []string{"web"}),
+		},
+		RoleName:          testRoleName,
+		Region:            "us-west-2",
+		ExpectedAccountID: "111111111111",
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when an existing role is in a different account")
+	assert.Contains(t, err.Error(), "222222222222")
+}
+
+func TestCreateTaskExecutionRole_NoAccountCheckByDefault(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+	testPolicyArn := aws.String("arn:aws:iam::111111111111:policy/" + testRoleName + "-policy")
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil)
+	mocks.MockIAM.EXPECT().GetRole(gomock.Any()).Times(0)
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil)
+	mocks.MockIAM.EXPECT().AttachRolePolicy(gomock.Any(), testRoleName).Return(nil, nil).Times(2)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:test-secret", "", []string{"web"}),
+		},
+		RoleName: testRoleName,
+		Region:   "us-west-2",
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error when reusing a role without an expected account")
+}
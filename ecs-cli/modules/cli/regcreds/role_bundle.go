@@ -0,0 +1,43 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// applyRoleBundle sets the role settings from the bundle which were not given as flags. Tags from the bundle are
+// merged with the tags given as flags, which take precedence for the same key. The permissions boundary is resolved
+// separately, since it may refer to an SSM parameter.
+func applyRoleBundle(params *ExecutionRoleParams, bundle regcredio.RoleBundleEntry) {
+	if params.TrustPolicy == "" {
+		params.TrustPolicy = bundle.TrustPolicy
+	}
+	if params.Path == "" {
+		params.Path = bundle.Path
+	}
+
+	if len(bundle.Tags) == 0 {
+		return
+	}
+	tags := make(map[string]*string, len(bundle.Tags)+len(params.Tags))
+	for key, value := range bundle.Tags {
+		tags[key] = aws.String(value)
+	}
+	for key, value := range params.Tags {
+		tags[key] = value
+	}
+	params.Tags = tags
+}
@@ -0,0 +1,56 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRoleBundle(t *testing.T) {
+	params := ExecutionRoleParams{
+		RoleName: "myRole",
+		Tags: map[string]*string{
+			"team":  aws.String("flag-team"),
+			"stage": aws.String("prod"),
+		},
+	}
+	bundle := regcredio.RoleBundleEntry{
+		TrustPolicy: `{"Statement":[{"Effect":"Allow"}]}`,
+		Path:        "/ecs/",
+		Tags: map[string]string{
+			"team":  "bundle-team",
+			"owner": "platform",
+		},
+	}
+
+	applyRoleBundle(&params, bundle)
+	assert.Equal(t, bundle.TrustPolicy, params.TrustPolicy)
+	assert.Equal(t, "/ecs/", params.Path)
+	assert.Equal(t, map[string]*string{
+		"team":  aws.String("flag-team"),
+		"stage": aws.String("prod"),
+		"owner": aws.String("platform"),
+	}, params.Tags, "Expected flag tags to override bundle tags")
+}
+
+func TestApplyRoleBundle_EmptyBundle(t *testing.T) {
+	params := ExecutionRoleParams{RoleName: "myRole"}
+
+	applyRoleBundle(&params, regcredio.RoleBundleEntry{})
+	assert.Equal(t, ExecutionRoleParams{RoleName: "myRole"}, params)
+}
@@ -0,0 +1,50 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRoleNameLength is the longest name IAM allows for a role
+const maxRoleNameLength = 64
+
+// applyRoleNameAffixes adds the configured prefix and suffix to each role name, and fails before any role is
+// created or looked up if a resulting name is longer than IAM allows
+func applyRoleNameAffixes(roleNames []string, prefix, suffix string) ([]string, error) {
+	if prefix == "" && suffix == "" {
+		return roleNames, nil
+	}
+	effectiveNames := make([]string, len(roleNames))
+	for i, roleName := range roleNames {
+		effectiveName := prefix + roleName + suffix
+		if len(effectiveName) > maxRoleNameLength {
+			return nil, fmt.Errorf("role name '%s' is %d characters long after adding '--%s' ('%s') and '--%s' ('%s'); IAM role names must be at most %d characters", effectiveName, len(effectiveName), flags.RoleNamePrefixFlag, prefix, flags.RoleNameSuffixFlag, suffix, maxRoleNameLength)
+		}
+		log.Infof("Using task execution role name '%s'", effectiveName)
+		effectiveNames[i] = effectiveName
+	}
+	return effectiveNames, nil
+}
+
+// roleNameAffix returns the flag value if it is given, or else the value from the cluster configuration
+func roleNameAffix(flagValue, configValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return configValue
+}
@@ -0,0 +1,52 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyRoleNameAffixes(t *testing.T) {
+	names, err := applyRoleNameAffixes([]string{"myRole", "otherRole"}, "team-a-", "-prod")
+	assert.NoError(t, err, "Unexpected error applying role name affixes")
+	assert.Equal(t, []string{"team-a-myRole-prod", "team-a-otherRole-prod"}, names)
+}
+
+func TestApplyRoleNameAffixesWithoutAffixes(t *testing.T) {
+	names, err := applyRoleNameAffixes([]string{"myRole"}, "", "")
+	assert.NoError(t, err, "Unexpected error applying role name affixes")
+	assert.Equal(t, []string{"myRole"}, names)
+}
+
+func TestApplyRoleNameAffixesAtMaxLength(t *testing.T) {
+	baseName := strings.Repeat("a", maxRoleNameLength-len("team-"))
+	names, err := applyRoleNameAffixes([]string{baseName}, "team-", "")
+	assert.NoError(t, err, "Unexpected error applying role name affixes")
+	assert.Len(t, names[0], maxRoleNameLength)
+}
+
+func TestApplyRoleNameAffixesErrorsWhenTooLong(t *testing.T) {
+	baseName := strings.Repeat("a", maxRoleNameLength-len("team-")+1)
+	_, err := applyRoleNameAffixes([]string{"short", baseName}, "team-", "")
+	assert.Error(t, err, "Expected error when the role name is too long")
+	assert.Contains(t, err.Error(), "65 characters")
+}
+
+func TestRoleNameAffixFlagOverridesConfig(t *testing.T) {
+	assert.Equal(t, "flag-", roleNameAffix("flag-", "config-"))
+	assert.Equal(t, "config-", roleNameAffix("", "config-"))
+}
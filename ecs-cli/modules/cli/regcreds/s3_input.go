@@ -0,0 +1,81 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	s3Client "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/s3"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+const s3URLPrefix = "s3://"
+
+// isS3URL returns whether the input file is given as an s3://bucket/key URL
+func isS3URL(filename string) bool {
+	return strings.HasPrefix(filename, s3URLPrefix)
+}
+
+// parseS3URL returns the bucket and key of an s3://bucket/key URL
+func parseS3URL(url string) (string, string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(url, s3URLPrefix), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %s; expected the format s3://bucket/key", url)
+	}
+	return parts[0], parts[1], nil
+}
+
+// s3InputStore reads s3:// URLs from S3, and reads and writes all other files with the wrapped store
+type s3InputStore struct {
+	regcredio.Store
+	client s3Client.Client
+}
+
+// ReadFile returns the contents of the file or S3 object
+func (s s3InputStore) ReadFile(filename string) ([]byte, error) {
+	if !isS3URL(filename) {
+		return s.Store.ReadFile(filename)
+	}
+	bucket, key, err := parseS3URL(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.client.GetObject(bucket, key)
+	if err != nil {
+		return nil, s3ReadError(filename, bucket, key, err)
+	}
+	return data, nil
+}
+
+// s3ReadError explains the S3 errors which are caused by the bucket, the object or the caller's credentials
+func s3ReadError(url, bucket, key string, err error) error {
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return fmt.Errorf("failed to read %s from S3: %v", url, err)
+	}
+	switch aerr.Code() {
+	case s3Client.ErrCodeAccessDenied:
+		return fmt.Errorf("access denied reading %s; the credentials must be allowed 's3:GetObject' on arn:aws:s3:::%s/%s (and 'kms:Decrypt' if the object is encrypted with a KMS key): %v", url, bucket, key, err)
+	case s3Client.ErrCodeNoSuchBucket:
+		return fmt.Errorf("the bucket of %s does not exist: %v", url, err)
+	case s3Client.ErrCodeNoSuchKey:
+		return fmt.Errorf("%s does not exist: %v", url, err)
+	case s3Client.ErrCodeBucketRegionMismatch:
+		return fmt.Errorf("failed to read %s: %s; use '--%s' to select the bucket's region", url, aerr.Message(), flags.RegionFlag)
+	}
+	return fmt.Errorf("failed to read %s from S3: %v", url, err)
+}
@@ -0,0 +1,102 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	s3Client "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/s3"
+	mock_s3 "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/s3/mock"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testS3CredsInput = `version: "1"
+registry_credentials:
+  myrepo.example.com:
+    secrets_manager_arn: arn:aws:secretsmanager:us-west-2:111111111111:secret:myrepo
+`
+
+func TestParseS3URL(t *testing.T) {
+	bucket, key, err := parseS3URL("s3://my-bucket/teams/payments/cred_input.yml")
+	require.NoError(t, err, "Unexpected error parsing S3 URL")
+	assert.Equal(t, "my-bucket", bucket)
+	assert.Equal(t, "teams/payments/cred_input.yml", key)
+
+	for _, url := range []string{"s3://", "s3://my-bucket", "s3://my-bucket/", "s3:///cred_input.yml"} {
+		_, _, err = parseS3URL(url)
+		assert.Error(t, err, "Expected error parsing %s", url)
+	}
+}
+
+func TestS3InputStore_ReadsCredsInput(t *testing.T) {
+	mockS3 := mock_s3.NewMockClient(gomock.NewController(t))
+	mockS3.EXPECT().GetObject("my-bucket", "cred_input.yml").Return([]byte(testS3CredsInput), nil)
+
+	store := s3InputStore{Store: regcredio.NewMemoryStore(nil), client: mockS3}
+	credsInput, err := regcredio.ReadCredsInputFrom(store, "s3://my-bucket/cred_input.yml")
+	require.NoError(t, err, "Unexpected error reading input from S3")
+	assert.Equal(t, "arn:aws:secretsmanager:us-west-2:111111111111:secret:myrepo", credsInput.RegistryCredentials["myrepo.example.com"].SecretManagerARN)
+}
+
+func TestS3InputStore_UsesWrappedStoreForOtherFiles(t *testing.T) {
+	memoryStore := regcredio.NewMemoryStore(map[string][]byte{"cred_input.yml": []byte(testS3CredsInput)})
+	store := s3InputStore{Store: memoryStore, client: mock_s3.NewMockClient(gomock.NewController(t))}
+
+	data, err := store.ReadFile("cred_input.yml")
+	require.NoError(t, err, "Unexpected error reading local file")
+	assert.Equal(t, testS3CredsInput, string(data))
+
+	require.NoError(t, store.WriteFile("output/creds.json", []byte("{}"), 0600))
+	assert.Equal(t, []string{"cred_input.yml", "output/creds.json"}, memoryStore.FileNames())
+}
+
+func TestS3InputStore_Errors(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected string
+	}{
+		"access denied": {
+			err:      awserr.New(s3Client.ErrCodeAccessDenied, "Access Denied", nil),
+			expected: "access denied reading s3://my-bucket/cred_input.yml; the credentials must be allowed 's3:GetObject' on arn:aws:s3:::my-bucket/cred_input.yml",
+		},
+		"no such bucket": {
+			err:      awserr.New(s3Client.ErrCodeNoSuchBucket, "The specified bucket does not exist", nil),
+			expected: "the bucket of s3://my-bucket/cred_input.yml does not exist",
+		},
+		"no such key": {
+			err:      awserr.New(s3Client.ErrCodeNoSuchKey, "The specified key does not exist.", nil),
+			expected: "s3://my-bucket/cred_input.yml does not exist",
+		},
+		"other region": {
+			err:      awserr.New(s3Client.ErrCodeBucketRegionMismatch, "the bucket is in region eu-west-1, not us-west-2", nil),
+			expected: "failed to read s3://my-bucket/cred_input.yml: the bucket is in region eu-west-1, not us-west-2; use '--region' to select the bucket's region",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			mockS3 := mock_s3.NewMockClient(gomock.NewController(t))
+			mockS3.EXPECT().GetObject("my-bucket", "cred_input.yml").Return(nil, tc.err)
+
+			store := s3InputStore{Store: regcredio.NewMemoryStore(nil), client: mockS3}
+			_, err := regcredio.ReadCredsInputFrom(store, "s3://my-bucket/cred_input.yml")
+			require.Error(t, err, "Expected error reading input from S3")
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
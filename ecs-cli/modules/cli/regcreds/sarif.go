@@ -0,0 +1,264 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/version"
+	"github.com/pkg/errors"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifInputFileRule is the rule of findings about the input file as a whole, such as YAML errors
+	sarifInputFileRule = "input-file"
+)
+
+// sarifLog is the subset of a SARIF 2.1.0 log which 'validate' writes: a single run, with a rule for each input field
+// that has findings
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+}
+
+// printFindingsSARIF writes the findings as a SARIF log. Each finding points at the input file, and at the line of its
+// field if it can be found in the file's content; the rule ID is the name of the field.
+func printFindingsSARIF(findings []validationFinding, inputFile string, content []byte, w io.Writer) error {
+	data, err := json.MarshalIndent(newSARIFLog(findings, inputFile, content), jsonPrefix, jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal findings to SARIF")
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func newSARIFLog(findings []validationFinding, inputFile string, content []byte) sarifLog {
+	uri := strings.TrimPrefix(filepath.ToSlash(inputFile), "./")
+	lines := strings.Split(string(content), "\n")
+
+	ruleIDs := []string{}
+	for _, finding := range findings {
+		ruleIDs = append(ruleIDs, sarifRuleID(finding))
+	}
+	ruleIDs = uniqueSorted(ruleIDs)
+	ruleIndexes := make(map[string]int, len(ruleIDs))
+	rules := make([]sarifRule, 0, len(ruleIDs))
+	for i, ruleID := range ruleIDs {
+		ruleIndexes[ruleID] = i
+		rules = append(rules, sarifRule{ID: ruleID, ShortDescription: sarifMessage{Text: sarifRuleDescription(ruleID)}})
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, finding := range findings {
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}},
+		}
+		if line := findFieldLine(lines, finding.Entry, finding.Field); line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: line}
+		}
+		if name := findingPath(finding); name != "" {
+			location.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: name}}
+		}
+		ruleID := sarifRuleID(finding)
+		results = append(results, sarifResult{
+			RuleID:    ruleID,
+			RuleIndex: ruleIndexes[ruleID],
+			Level:     sarifLevel(finding.Severity),
+			Message:   sarifMessage{Text: finding.Message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	return sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "ecs-cli registry-creds validate",
+				Version:        version.Version,
+				InformationURI: "https://github.com/aws/amazon-ecs-cli",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// sarifRuleID returns the field of the finding without the dashes of a flag name, or the input file rule if the
+// finding has no field
+func sarifRuleID(finding validationFinding) string {
+	if finding.Field == "" {
+		return sarifInputFileRule
+	}
+	return strings.TrimPrefix(finding.Field, "--")
+}
+
+func sarifRuleDescription(ruleID string) string {
+	if ruleID == sarifInputFileRule {
+		return "The credential input file can't be read"
+	}
+	return fmt.Sprintf("Problems with '%s'", ruleID)
+}
+
+// sarifLevel maps the severity of a finding to the SARIF level of the same name
+func sarifLevel(severity string) string {
+	if severity == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// findingPath returns the path of the finding's field in the input file, such as
+// 'registry_credentials.my-registry.example.com.kms_key_id'. Flags are not in the file, so they have no path.
+func findingPath(finding validationFinding) string {
+	if strings.HasPrefix(finding.Field, "--") {
+		return ""
+	}
+	if finding.Entry == "" {
+		return finding.Field
+	}
+	path := "registry_credentials." + finding.Entry
+	if finding.Field != "" {
+		path += "." + finding.Field
+	}
+	return path
+}
+
+// findFieldLine returns the 1-based line of the finding's field in the input file, or of its registry if the field
+// is missing from the entry, or 0 if neither can be found. The file has already been parsed, so keys are only matched
+// by name and indentation rather than parsing the YAML again.
+func findFieldLine(lines []string, entry, field string) int {
+	if strings.HasPrefix(field, "--") {
+		return 0
+	}
+	if entry == "" {
+		if field == "" {
+			return 0
+		}
+		return findKeyLine(lines, 0, len(lines), field)
+	}
+	registriesLine := findKeyLine(lines, 0, len(lines), "registry_credentials")
+	if registriesLine == 0 {
+		return 0
+	}
+	entryLine := findKeyLine(lines, registriesLine, len(lines), entry)
+	if entryLine == 0 || field == "" {
+		return entryLine
+	}
+	// the fields of the entry are the lines indented further than its key
+	entryIndent := indentation(lines[entryLine-1])
+	end := entryLine
+	for end < len(lines) {
+		trimmed := strings.TrimSpace(lines[end])
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") && indentation(lines[end]) <= entryIndent {
+			break
+		}
+		end++
+	}
+	if fieldLine := findKeyLine(lines, entryLine, end, field); fieldLine > 0 {
+		return fieldLine
+	}
+	return entryLine
+}
+
+// findKeyLine returns the 1-based line of the first key with the given name in lines[start:end], or 0 if there is
+// none. Keys can be quoted.
+func findKeyLine(lines []string, start, end int, key string) int {
+	for i := start; i < end; i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		for _, candidate := range []string{key, `"` + key + `"`, "'" + key + "'"} {
+			if strings.HasPrefix(trimmed, candidate+":") {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+func uniqueSorted(values []string) []string {
+	set := stringSet(values)
+	unique := make([]string, 0, len(set))
+	for value := range set {
+		unique = append(unique, value)
+	}
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,111 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sarifTestInput = `version: "1"
+registry_credentials:
+  a.example.com:
+    secrets_manager_arn: arn:aws:secretsmanager:us-west-2:111111111111:secret:a
+    kms_key_id: arn:aws:kms:us-east-1:111111111111:key/123
+    container_names:
+      - web
+  "b.example.com":
+    username: user
+ecr_repositories:
+  - not-an-arn
+`
+
+func TestFindFieldLine(t *testing.T) {
+	lines := strings.Split(sarifTestInput, "\n")
+
+	testCases := map[string]struct {
+		entry    string
+		field    string
+		expected int
+	}{
+		"field of entry":             {"a.example.com", "kms_key_id", 5},
+		"quoted entry":               {"b.example.com", "username", 9},
+		"missing field points entry": {"b.example.com", "secrets_manager_arn", 8},
+		"field of next entry unused": {"a.example.com", "username", 3},
+		"top level field":            {"", "ecr_repositories", 10},
+		"flag":                       {"", "--permissions-boundary", 0},
+		"whole file":                 {"", "", 0},
+		"unknown entry":              {"c.example.com", "username", 0},
+	}
+	for name, test := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, findFieldLine(lines, test.entry, test.field))
+		})
+	}
+}
+
+func TestPrintFindingsSARIF(t *testing.T) {
+	findings := []validationFinding{
+		{Severity: SeverityError, Entry: "a.example.com", Field: "kms_key_id", Message: "regions do not match"},
+		{Severity: SeverityWarning, Entry: "b.example.com", Field: "container_names", Message: "no container names"},
+		{Severity: SeverityError, Field: "--permissions-boundary", Message: "not a policy ARN"},
+	}
+	out := new(bytes.Buffer)
+
+	err := printFindingsSARIF(findings, "./config/cred_input.yml", []byte(sarifTestInput), out)
+	assert.NoError(t, err, "Unexpected error printing SARIF")
+
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &log), "Expected the output to be JSON")
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+
+	var ruleIDs []string
+	for _, rule := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	assert.Equal(t, []string{"container_names", "kms_key_id", "permissions-boundary"}, ruleIDs, "Expected a sorted rule for each field")
+
+	assert.Len(t, run.Results, 3)
+	first := run.Results[0]
+	assert.Equal(t, "kms_key_id", first.RuleID)
+	assert.Equal(t, 1, first.RuleIndex)
+	assert.Equal(t, "error", first.Level)
+	assert.Equal(t, "regions do not match", first.Message.Text)
+	assert.Equal(t, "config/cred_input.yml", first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 5}, first.Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "registry_credentials.a.example.com.kms_key_id", first.Locations[0].LogicalLocations[0].FullyQualifiedName)
+
+	second := run.Results[1]
+	assert.Equal(t, "warning", second.Level)
+	assert.Equal(t, &sarifRegion{StartLine: 8}, second.Locations[0].PhysicalLocation.Region, "Expected a missing field to point at its registry")
+
+	flag := run.Results[2]
+	assert.Equal(t, "permissions-boundary", flag.RuleID)
+	assert.Nil(t, flag.Locations[0].PhysicalLocation.Region, "Expected no line for a flag")
+	assert.Empty(t, flag.Locations[0].LogicalLocations)
+}
+
+func TestPrintFindingsSARIF_NoFindings(t *testing.T) {
+	out := new(bytes.Buffer)
+
+	err := printFindingsSARIF(nil, "cred_input.yml", nil, out)
+	assert.NoError(t, err, "Unexpected error printing SARIF")
+	assert.Contains(t, out.String(), `"results": []`, "Expected an empty list of results")
+}
@@ -0,0 +1,55 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"regexp"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// saved role keys are used as YAML keys in the config file and as the task_execution_role of ECS params files
+var saveAsKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// roleSaver saves the ARN of a role in the ecs-cli config; implemented by config.YAMLReadWriter
+type roleSaver interface {
+	SaveRole(key, roleARN string) error
+}
+
+func validateSaveAsKey(key string) error {
+	if !saveAsKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid value '%s' for '--%s'; the key may contain only letters, digits, '_', '.' and '-'", key, flags.SaveAsFlag)
+	}
+	return nil
+}
+
+// saveRoleARN saves the ARN of the first role, which is also the role used by 'compose', under the key
+func saveRoleARN(saver roleSaver, key string, roleResults []*ExecutionRoleResult, client iamClient.Client) error {
+	if len(roleResults) == 0 {
+		return nil
+	}
+	roleARNs, err := getRoleARNs(roleResults[:1], client)
+	if err != nil {
+		return err
+	}
+	if err = saver.SaveRole(key, roleARNs[0]); err != nil {
+		return errors.Wrapf(err, "failed to save the ARN of role %s as '%s'", roleResults[0].RoleName, key)
+	}
+	log.Infof("Saved the ARN of role %s as '%s' in the ECS CLI config", roleResults[0].RoleName, key)
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRoleSaver struct {
+	saved map[string]string
+	err   error
+}
+
+func (saver *fakeRoleSaver) SaveRole(key, roleARN string) error {
+	if saver.err != nil {
+		return saver.err
+	}
+	saver.saved[key] = roleARN
+	return nil
+}
+
+func TestValidateSaveAsKey(t *testing.T) {
+	assert.NoError(t, validateSaveAsKey("myrole-exec-role"))
+	assert.NoError(t, validateSaveAsKey("team_a.prod-1"))
+	assert.Error(t, validateSaveAsKey("my role"))
+	assert.Error(t, validateSaveAsKey("role:key"))
+}
+
+func TestSaveRoleARN(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(&iam.Role{Arn: aws.String("arn:aws:iam::123456789012:role/existingRole")}, nil)
+
+	saver := &fakeRoleSaver{saved: make(map[string]string)}
+	// only the first role is saved
+	err := saveRoleARN(saver, "exec-role", []*ExecutionRoleResult{{RoleName: "existingRole"}, {RoleName: "otherRole"}}, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error saving role")
+	assert.Equal(t, map[string]string{"exec-role": "arn:aws:iam::123456789012:role/existingRole"}, saver.saved)
+
+	// the ARN of a new role is known without looking it up
+	err = saveRoleARN(saver, "new-role", []*ExecutionRoleResult{{RoleName: "newRole", RoleCreated: true, RoleARN: "arn:aws:iam::123456789012:role/newRole"}}, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error saving role")
+	assert.Equal(t, "arn:aws:iam::123456789012:role/newRole", saver.saved["new-role"])
+}
+
+func TestSaveRoleARN_ErrorSaving(t *testing.T) {
+	mocks := setupTestController(t)
+
+	saver := &fakeRoleSaver{err: errors.New("no cluster configuration")}
+	err := saveRoleARN(saver, "exec-role", []*ExecutionRoleResult{{RoleName: "newRole", RoleARN: "arn:aws:iam::123456789012:role/newRole"}}, mocks.MockIAM)
+	assert.Error(t, err, "Expected error saving role")
+	assert.Contains(t, err.Error(), "failed to save the ARN of role newRole as 'exec-role'")
+}
@@ -0,0 +1,128 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+)
+
+const ssmActionPrefix = "ssm:"
+
+// rotationActions are granted in addition to the secret actions of a rotation compatible entry. While a rotated secret
+// has more than one version, reading the current value may first require its version stages, which are only returned
+// by DescribeSecret.
+var rotationActions = []string{"secretsmanager:DescribeSecret"}
+
+// knownSecretActions are the Secrets Manager and SSM Parameter Store actions which may be given in the 'actions' of a
+// registry entry, keyed by their lower case name since IAM action names are case-insensitive
+var knownSecretActions = newActionSet(
+	"secretsmanager:BatchGetSecretValue",
+	"secretsmanager:CancelRotateSecret",
+	"secretsmanager:CreateSecret",
+	"secretsmanager:DeleteResourcePolicy",
+	"secretsmanager:DeleteSecret",
+	"secretsmanager:DescribeSecret",
+	"secretsmanager:GetRandomPassword",
+	"secretsmanager:GetResourcePolicy",
+	"secretsmanager:GetSecretValue",
+	"secretsmanager:ListSecretVersionIds",
+	"secretsmanager:ListSecrets",
+	"secretsmanager:PutResourcePolicy",
+	"secretsmanager:PutSecretValue",
+	"secretsmanager:RestoreSecret",
+	"secretsmanager:RotateSecret",
+	"secretsmanager:TagResource",
+	"secretsmanager:UntagResource",
+	"secretsmanager:UpdateSecret",
+	"secretsmanager:UpdateSecretVersionStage",
+	"secretsmanager:ValidateResourcePolicy",
+	"ssm:DeleteParameter",
+	"ssm:DeleteParameters",
+	"ssm:DescribeParameters",
+	"ssm:GetParameter",
+	"ssm:GetParameterHistory",
+	"ssm:GetParameters",
+	"ssm:GetParametersByPath",
+	"ssm:LabelParameterVersion",
+	"ssm:PutParameter",
+	"ssm:UnlabelParameterVersion",
+)
+
+func newActionSet(actions ...string) map[string]bool {
+	set := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		set[strings.ToLower(action)] = true
+	}
+	return set
+}
+
+// validateSecretActions checks that each action given for a registry is a Secrets Manager or SSM action. Wildcards are
+// not allowed, so that the generated policy grants exactly the listed actions.
+func validateSecretActions(registryName string, actions []string) error {
+	seen := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		if !knownSecretActions[strings.ToLower(action)] {
+			return fmt.Errorf("invalid action '%s' for registry %s; actions must be Secrets Manager or SSM Parameter Store actions, e.g. %s", action, registryName, secretsGetValueAction)
+		}
+		if seen[strings.ToLower(action)] {
+			return fmt.Errorf("action '%s' is listed more than once for registry %s", action, registryName)
+		}
+		seen[strings.ToLower(action)] = true
+	}
+	return nil
+}
+
+// entrySecretActions returns the actions to grant on the entry's secret, which default to reading its value
+func entrySecretActions(entry regcredio.CredsOutputEntry) []string {
+	if len(entry.Actions) == 0 {
+		return []string{secretsGetValueAction}
+	}
+	return entry.Actions
+}
+
+// validateRotationCompatible checks that rotation compatibility is only requested for Secrets Manager secrets
+func validateRotationCompatible(registryName string, actions []string, rotationCompatible bool) error {
+	if rotationCompatible && hasSSMAction(actions) {
+		return fmt.Errorf("'rotation_compatible' cannot be used with SSM actions for registry %s; only Secrets Manager secrets can be rotated", registryName)
+	}
+	return nil
+}
+
+// entryRotationActions returns the rotation actions not already granted by the entry's secret actions
+func entryRotationActions(entry regcredio.CredsOutputEntry, secretActions []string) []string {
+	if !entry.RotationCompatible {
+		return nil
+	}
+	granted := newActionSet(secretActions...)
+	var missing []string
+	for _, action := range rotationActions {
+		if !granted[strings.ToLower(action)] {
+			missing = append(missing, action)
+		}
+	}
+	return missing
+}
+
+// the version stage condition key is only present on Secrets Manager requests, so it can't restrict SSM actions
+func hasSSMAction(actions []string) bool {
+	for _, action := range actions {
+		if strings.HasPrefix(strings.ToLower(action), ssmActionPrefix) {
+			return true
+		}
+	}
+	return false
+}
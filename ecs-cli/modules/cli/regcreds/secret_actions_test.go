@@ -0,0 +1,50 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSecretActions(t *testing.T) {
+	testCases := []struct {
+		actions     []string
+		expectError bool
+	}{
+		{nil, false},
+		{[]string{"secretsmanager:GetSecretValue"}, false},
+		{[]string{"secretsmanager:BatchGetSecretValue", "ssm:GetParameters"}, false},
+		{[]string{"SecretsManager:getsecretvalue"}, false},
+		{[]string{"secretsmanager:*"}, true},
+		{[]string{"s3:GetObject"}, true},
+		{[]string{"ssm:GetParameters", "ssm:getParameters"}, true},
+		{[]string{""}, true},
+	}
+	for _, test := range testCases {
+		err := validateSecretActions("myreg.test.io", test.actions)
+		if test.expectError {
+			assert.Error(t, err, "Expected error for actions %v", test.actions)
+		} else {
+			assert.NoError(t, err, "Unexpected error for actions %v", test.actions)
+		}
+	}
+}
+
+func TestValidateRotationCompatible(t *testing.T) {
+	assert.NoError(t, validateRotationCompatible("myreg.test.io", nil, true))
+	assert.NoError(t, validateRotationCompatible("myreg.test.io", []string{"ssm:GetParameters"}, false))
+	assert.Error(t, validateRotationCompatible("myreg.test.io", []string{"ssm:GetParameters"}, true), "Expected error when rotation is requested for an SSM parameter")
+}
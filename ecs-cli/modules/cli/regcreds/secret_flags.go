@@ -0,0 +1,51 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/arn"
+)
+
+// secretFlagsSource describes the '--secret' flags in messages which otherwise name the input file
+var secretFlagsSource = fmt.Sprintf("'--%s'", flags.SecretFlag)
+
+// secretFlagsInput returns the input built from '--secret' flags, each of which names a registry and gives the ARN of
+// its existing Secrets Manager secret, as 'name=arn'. The entries are then validated like those of an input file.
+func secretFlagsInput(values []string) (*regcredio.ECSRegCredsInput, error) {
+	registryCreds := make(regcredio.RegistryCreds, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid value '%s' for '--%s'; expected 'name=arn'", value, flags.SecretFlag)
+		}
+		registryName, secretARN := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if registryName == "" || strings.ContainsAny(registryName, " \t") {
+			return nil, fmt.Errorf("invalid registry name '%s' for '--%s'; it must not be empty or contain whitespace", parts[0], flags.SecretFlag)
+		}
+		if _, ok := registryCreds[registryName]; ok {
+			return nil, fmt.Errorf("registry %s is given more than once with '--%s'", registryName, flags.SecretFlag)
+		}
+		parsedARN, err := arn.Parse(secretARN)
+		if err != nil || parsedARN.Service != "secretsmanager" || !strings.HasPrefix(parsedARN.Resource, "secret:") {
+			return nil, fmt.Errorf("invalid secret ARN '%s' for registry %s given with '--%s'; expected the ARN of an existing Secrets Manager secret", secretARN, registryName, flags.SecretFlag)
+		}
+		registryCreds[registryName] = regcredio.RegistryCredEntry{SecretManagerARN: secretARN}
+	}
+	return &regcredio.ECSRegCredsInput{RegistryCredentials: registryCreds}, nil
+}
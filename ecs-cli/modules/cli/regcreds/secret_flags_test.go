@@ -0,0 +1,52 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretFlagsInput(t *testing.T) {
+	input, err := secretFlagsInput([]string{
+		"docker.io=arn:aws:secretsmanager:us-west-2:111111111111:secret:dockerhub-AbCdEf",
+		"quay.io = arn:aws:secretsmanager:us-west-2:111111111111:secret:quay",
+	})
+	assert.NoError(t, err, "Unexpected error building input from '--secret' flags")
+	assert.Equal(t, regcredio.RegistryCreds{
+		"docker.io": {SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:dockerhub-AbCdEf"},
+		"quay.io":   {SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:quay"},
+	}, input.RegistryCredentials)
+}
+
+func TestSecretFlagsInput_Errors(t *testing.T) {
+	testCases := map[string][]string{
+		"no separator":     {"docker.io"},
+		"empty name":       {"=arn:aws:secretsmanager:us-west-2:111111111111:secret:dockerhub"},
+		"empty ARN":        {"docker.io="},
+		"name with space":  {"docker io=arn:aws:secretsmanager:us-west-2:111111111111:secret:dockerhub"},
+		"not an ARN":       {"docker.io=dockerhub"},
+		"SSM parameter":    {"docker.io=arn:aws:ssm:us-west-2:111111111111:parameter/dockerhub"},
+		"duplicate name":   {"docker.io=arn:aws:secretsmanager:us-west-2:111111111111:secret:a", "docker.io=arn:aws:secretsmanager:us-west-2:111111111111:secret:b"},
+		"not a secret ARN": {"docker.io=arn:aws:secretsmanager:us-west-2:111111111111:dockerhub"},
+	}
+	for description, values := range testCases {
+		t.Run(description, func(t *testing.T) {
+			_, err := secretFlagsInput(values)
+			assert.Error(t, err, "Expected error for invalid '--secret' flags")
+		})
+	}
+}
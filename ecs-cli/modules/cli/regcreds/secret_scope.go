@@ -0,0 +1,84 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+)
+
+const (
+	resourceTagConditionKeyPrefix = "secretsmanager:ResourceTag/"
+
+	secretARNFields = 7
+)
+
+// validateSecretScope checks that a secret scope restricts access to a set of Secrets Manager secrets; an empty
+// scope would match every secret in the account
+func validateSecretScope(registryName string, scope *regcredio.SecretScope, actions []string) error {
+	if scope == nil {
+		return nil
+	}
+	if scope.NamePrefix == "" && len(scope.ResourceTags) == 0 {
+		return fmt.Errorf("'secret_scope' for registry %s must specify a 'name_prefix' or 'resource_tags'; an empty scope would grant access to every secret", registryName)
+	}
+	if strings.ContainsAny(scope.NamePrefix, "*?") {
+		return fmt.Errorf("'name_prefix' in 'secret_scope' for registry %s must not contain wildcards; found '%s'", registryName, scope.NamePrefix)
+	}
+	for key, value := range scope.ResourceTags {
+		if key == "" || value == "" {
+			return fmt.Errorf("'resource_tags' in 'secret_scope' for registry %s must not contain an empty key or value", registryName)
+		}
+	}
+	if hasSSMAction(actions) {
+		return fmt.Errorf("'secret_scope' cannot be used with SSM actions for registry %s; only Secrets Manager secrets can be scoped", registryName)
+	}
+	return nil
+}
+
+// scopedSecretResource returns the resource matching every secret whose name starts with the scope's prefix, in the
+// account and region of the entry's own secret. The entry's secret must be matched by the scope, so that containers
+// using it can still read it.
+func scopedSecretResource(registryName, secretARN string, scope regcredio.SecretScope) (string, error) {
+	fields := strings.SplitN(secretARN, ":", secretARNFields)
+	if len(fields) != secretARNFields || fields[2] != "secretsmanager" || fields[5] != "secret" {
+		return "", fmt.Errorf("'secret_scope' for registry %s can only be used with a Secrets Manager secret; found '%s'", registryName, secretARN)
+	}
+	if !strings.HasPrefix(fields[6], scope.NamePrefix) {
+		return "", fmt.Errorf("name of secret %s for registry %s does not start with the 'name_prefix' '%s' of its 'secret_scope'", secretARN, registryName, scope.NamePrefix)
+	}
+	return strings.Join(fields[:secretARNFields-1], ":") + ":" + scope.NamePrefix + "*", nil
+}
+
+// secretScopeConditions returns the condition keys and values matching the scope's resource tags
+func secretScopeConditions(scope regcredio.SecretScope) map[string]string {
+	if len(scope.ResourceTags) == 0 {
+		return nil
+	}
+	conditions := make(map[string]string, len(scope.ResourceTags))
+	for key, value := range scope.ResourceTags {
+		conditions[resourceTagConditionKeyPrefix+key] = value
+	}
+	return conditions
+}
+
+// stringEqualsCondition returns a policy condition requiring each key to equal its value, or nil if there are none
+func stringEqualsCondition(conditions map[string]string) map[string]map[string]string {
+	if len(conditions) == 0 {
+		return nil
+	}
+	return map[string]map[string]string{"StringEquals": conditions}
+}
@@ -0,0 +1,152 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSecretsPolicy_SecretScopeWithTags(t *testing.T) {
+	testKeyARN := "arn:aws:kms:us-west-2:111111111111:key/67yt-756yth"
+	entry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:team-web/dockerhub-AbCdEf", testKeyARN, []string{"web"})
+	entry.Name = "web"
+	entry.SecretScope = &regcredio.SecretScope{
+		NamePrefix:   "team-web/",
+		ResourceTags: map[string]string{"team": "web"},
+	}
+	creds := map[string]regcredio.CredsOutputEntry{"myreg.test.io": entry}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(testKeyARN).Return(testKeyARN, nil)
+
+	policyString, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, 2, len(policyDoc.Statement))
+
+	secretStatement := policyDoc.Statement[0]
+	assert.Equal(t, []string{"secretsmanager:GetSecretValue"}, secretStatement.Action)
+	assert.Equal(t, []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:team-web/*"}, secretStatement.Resource)
+	assert.Equal(t, map[string]map[string]string{
+		"StringEquals": {"secretsmanager:ResourceTag/team": "web"},
+	}, secretStatement.Condition)
+
+	// the resource tag condition key is not present on KMS requests
+	keyStatement := policyDoc.Statement[1]
+	assert.Equal(t, "WebDecrypt", keyStatement.Sid)
+	assert.Equal(t, []string{testKeyARN}, keyStatement.Resource)
+	assert.Empty(t, keyStatement.Condition)
+}
+
+func TestGenerateSecretsPolicy_SecretScopeWithVersionStageAndRotation(t *testing.T) {
+	entry := regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:dockerhub-AbCdEf", "", []string{"web"})
+	entry.Name = "web"
+	entry.RotationCompatible = true
+	entry.SecretScope = &regcredio.SecretScope{ResourceTags: map[string]string{"team": "web", "env": "prod"}}
+	creds := map[string]regcredio.CredsOutputEntry{"myreg.test.io": entry}
+
+	mocks := setupTestController(t)
+	policyString, err := generateSecretsPolicy(creds, "AWSCURRENT", mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error generating secrets policy")
+
+	policyDoc := parseTestPolicy(t, policyString)
+	assert.Equal(t, 2, len(policyDoc.Statement))
+
+	secretStatement := policyDoc.Statement[0]
+	assert.Equal(t, []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:*"}, secretStatement.Resource)
+	assert.Equal(t, map[string]map[string]string{
+		"StringEquals": {
+			"secretsmanager:ResourceTag/team": "web",
+			"secretsmanager:ResourceTag/env":  "prod",
+			"secretsmanager:VersionStage":     "AWSCURRENT",
+		},
+	}, secretStatement.Condition)
+
+	rotationStatement := policyDoc.Statement[1]
+	assert.Equal(t, "WebRotation", rotationStatement.Sid)
+	assert.Equal(t, []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:*"}, rotationStatement.Resource)
+	assert.Equal(t, map[string]map[string]string{
+		"StringEquals": {
+			"secretsmanager:ResourceTag/team": "web",
+			"secretsmanager:ResourceTag/env":  "prod",
+		},
+	}, rotationStatement.Condition, "Expected rotation access to be scoped without the version stage")
+}
+
+func TestGenerateSecretsPolicy_SecretScopeErrors(t *testing.T) {
+	secretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:team-web/dockerhub-AbCdEf"
+
+	testCases := []struct {
+		description string
+		secretARN   string
+		actions     []string
+		scope       regcredio.SecretScope
+	}{
+		{"Empty scope", secretARN, nil, regcredio.SecretScope{}},
+		{"Empty tags", secretARN, nil, regcredio.SecretScope{ResourceTags: map[string]string{}}},
+		{"Empty tag key", secretARN, nil, regcredio.SecretScope{ResourceTags: map[string]string{"": "web"}}},
+		{"Empty tag value", secretARN, nil, regcredio.SecretScope{ResourceTags: map[string]string{"team": ""}}},
+		{"Wildcard in prefix", secretARN, nil, regcredio.SecretScope{NamePrefix: "team-*"}},
+		{"Prefix does not match secret", secretARN, nil, regcredio.SecretScope{NamePrefix: "team-api/"}},
+		{"SSM parameter", "arn:aws:ssm:us-west-2:111111111111:parameter/team-web/password", []string{"ssm:GetParameters"}, regcredio.SecretScope{NamePrefix: "team-web/"}},
+	}
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			entry := regcredio.BuildOutputEntry(test.secretARN, "", []string{"web"})
+			entry.Actions = test.actions
+			scope := test.scope
+			entry.SecretScope = &scope
+			creds := map[string]regcredio.CredsOutputEntry{"myreg.test.io": entry}
+
+			mocks := setupTestController(t)
+			_, err := generateSecretsPolicy(creds, "", mocks.MockKMS)
+			assert.Error(t, err, "Expected error for invalid secret scope")
+		})
+	}
+}
+
+func TestFindInputProblems_SecretScope(t *testing.T) {
+	input := regcredio.ECSRegCredsInput{
+		Version: "1",
+		RegistryCredentials: regcredio.RegistryCreds{
+			"empty.example.com": regcredio.RegistryCredEntry{
+				SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:team-web/dockerhub-AbCdEf",
+				ContainerNames:   []string{"web"},
+				SecretScope:      &regcredio.SecretScope{},
+			},
+			"mismatch.example.com": regcredio.RegistryCredEntry{
+				SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:team-web/quay-AbCdEf",
+				ContainerNames:   []string{"api"},
+				SecretScope:      &regcredio.SecretScope{NamePrefix: "team-api/"},
+			},
+			"valid.example.com": regcredio.RegistryCredEntry{
+				SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:team-web/ecr-AbCdEf",
+				ContainerNames:   []string{"worker"},
+				SecretScope:      &regcredio.SecretScope{NamePrefix: "team-web/", ResourceTags: map[string]string{"team": "web"}},
+			},
+		},
+	}
+
+	findings := findInputProblems(input, "us-west-2")
+	assert.Equal(t, 2, len(findings))
+	for i, registryName := range []string{"empty.example.com", "mismatch.example.com"} {
+		assert.Equal(t, SeverityError, findings[i].Severity)
+		assert.Equal(t, registryName, findings[i].Entry)
+		assert.Equal(t, "secret_scope", findings[i].Field)
+	}
+}
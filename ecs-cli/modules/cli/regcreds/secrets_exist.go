@@ -0,0 +1,89 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	secretsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/secretsmanager"
+	ssmClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/ssm"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	log "github.com/sirupsen/logrus"
+)
+
+// validateSecretsExist checks that the existing secret or parameter of every registry exists, using only calls which
+// read metadata, so that the new policy doesn't grant access to an ARN that would fail when a task starts. Every
+// missing resource is reported in a single error.
+func validateSecretsExist(regCreds map[string]regcredio.RegistryCredEntry, smClient secretsClient.SMClient, paramClient ssmClient.Client) error {
+	registryNames := make([]string, 0, len(regCreds))
+	for registryName := range regCreds {
+		registryNames = append(registryNames, registryName)
+	}
+	sort.Strings(registryNames)
+
+	var problems []string
+	checked := 0
+	for _, registryName := range registryNames {
+		resourceARN := regCreds[registryName].SecretManagerARN
+		if resourceARN == "" {
+			// a new secret will be created
+			continue
+		}
+		parsedARN, err := arn.Parse(resourceARN)
+		if err != nil || (parsedARN.Service != "secretsmanager" && parsedARN.Service != "ssm") {
+			continue
+		}
+		checked++
+		if parsedARN.Service == "ssm" {
+			problems = appendParameterProblem(problems, registryName, resourceARN, paramClient)
+		} else {
+			problems = appendSecretProblem(problems, registryName, resourceARN, smClient)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d of %d existing secrets and parameters could not be found:\n  %s", len(problems), checked, strings.Join(problems, "\n  "))
+	}
+	log.Infof("Found all %d existing secrets and parameters.", checked)
+	return nil
+}
+
+func appendSecretProblem(problems []string, registryName, secretARN string, smClient secretsClient.SMClient) []string {
+	secret, err := smClient.DescribeSecret(secretARN)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == secretsmanager.ErrCodeResourceNotFoundException {
+		return append(problems, fmt.Sprintf("registry %s: secret %s does not exist", registryName, secretARN))
+	}
+	if err != nil {
+		return append(problems, fmt.Sprintf("registry %s: could not check secret %s: %v", registryName, secretARN, err))
+	}
+	if secret.DeletedDate != nil {
+		return append(problems, fmt.Sprintf("registry %s: secret %s is scheduled for deletion", registryName, secretARN))
+	}
+	return problems
+}
+
+func appendParameterProblem(problems []string, registryName, parameterARN string, paramClient ssmClient.Client) []string {
+	exists, err := paramClient.ParameterExists(parameterARN)
+	if err != nil {
+		return append(problems, fmt.Sprintf("registry %s: could not check parameter %s: %v", registryName, parameterARN, err))
+	}
+	if !exists {
+		return append(problems, fmt.Sprintf("registry %s: parameter %s does not exist", registryName, parameterARN))
+	}
+	return problems
+}
@@ -0,0 +1,85 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	mock_ssm "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/ssm/mock"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testExistingSecretARN   = "arn:aws:secretsmanager:us-west-2:111111111111:secret:existing-secret"
+	testMissingSecretARN    = "arn:aws:secretsmanager:us-west-2:111111111111:secret:typo-secret"
+	testDeletedSecretARN    = "arn:aws:secretsmanager:us-west-2:111111111111:secret:deleted-secret"
+	testMissingParameterARN = "arn:aws:ssm:us-west-2:111111111111:parameter/registry/typo"
+)
+
+func TestValidateSecretsExist(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{
+		"existing.example.com": {SecretManagerARN: testExistingSecretARN},
+		"missing.example.com":  {SecretManagerARN: testMissingSecretARN},
+		"deleted.example.com":  {SecretManagerARN: testDeletedSecretARN},
+		"param.example.com":    {SecretManagerARN: testMissingParameterARN},
+		"new.example.com":      {Username: "user", Password: "pass"},
+	}
+
+	mocks := setupTestController(t)
+	mockSSM := mock_ssm.NewMockClient(gomock.NewController(t))
+	mocks.MockSM.EXPECT().DescribeSecret(testExistingSecretARN).Return(&secretsmanager.DescribeSecretOutput{ARN: aws.String(testExistingSecretARN)}, nil)
+	mocks.MockSM.EXPECT().DescribeSecret(testMissingSecretARN).Return(nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil))
+	mocks.MockSM.EXPECT().DescribeSecret(testDeletedSecretARN).Return(&secretsmanager.DescribeSecretOutput{DeletedDate: aws.Time(time.Now())}, nil)
+	mockSSM.EXPECT().ParameterExists(testMissingParameterARN).Return(false, nil)
+
+	err := validateSecretsExist(regCreds, mocks.MockSM, mockSSM)
+	assert.Error(t, err, "Expected error for missing secrets")
+	assert.Contains(t, err.Error(), "3 of 4")
+	assert.Contains(t, err.Error(), "registry deleted.example.com: secret "+testDeletedSecretARN+" is scheduled for deletion")
+	assert.Contains(t, err.Error(), "registry missing.example.com: secret "+testMissingSecretARN+" does not exist")
+	assert.Contains(t, err.Error(), "registry param.example.com: parameter "+testMissingParameterARN+" does not exist")
+	assert.NotContains(t, err.Error(), "existing.example.com")
+}
+
+func TestValidateSecretsExist_ReportsOtherErrors(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{
+		"existing.example.com": {SecretManagerARN: testExistingSecretARN},
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockSM.EXPECT().DescribeSecret(testExistingSecretARN).Return(nil, errors.New("access denied"))
+
+	err := validateSecretsExist(regCreds, mocks.MockSM, mock_ssm.NewMockClient(gomock.NewController(t)))
+	assert.Error(t, err, "Expected error when a secret can't be checked")
+	assert.Contains(t, err.Error(), "could not check secret "+testExistingSecretARN+": access denied")
+}
+
+func TestValidateSecretsExist_AllFound(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{
+		"existing.example.com": {SecretManagerARN: testExistingSecretARN},
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockSM.EXPECT().DescribeSecret(testExistingSecretARN).Return(&secretsmanager.DescribeSecretOutput{ARN: aws.String(testExistingSecretARN)}, nil)
+
+	err := validateSecretsExist(regCreds, mocks.MockSM, mock_ssm.NewMockClient(gomock.NewController(t)))
+	assert.NoError(t, err, "Unexpected error when all secrets exist")
+}
@@ -0,0 +1,93 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const tagSessionAction = "sts:TagSession"
+
+// trustPolicyDocument is the assume role policy document of a new role
+type trustPolicyDocument struct {
+	Version   string
+	Statement []trustPolicyStatement
+}
+
+type trustPolicyStatement struct {
+	Sid       string
+	Effect    string
+	Principal map[string]string
+	Action    []string
+	Condition map[string]map[string]string `json:",omitempty"`
+}
+
+// parseRequiredSessionTags parses the session tags required to assume a new role, given as 'key=value' pairs. An
+// empty value requires the tag to be present with any value.
+func parseRequiredSessionTags(flagValue string) (map[string]string, error) {
+	if flagValue == "" {
+		return nil, nil
+	}
+	tags, err := utils.GetTagsMap(flagValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for '--%s': %v", flags.RequireSessionTagsFlag, err)
+	}
+	requiredTags := make(map[string]string, len(tags))
+	for key, value := range tags {
+		if key == "" {
+			return nil, fmt.Errorf("tag keys specified with '--%s' cannot be empty", flags.RequireSessionTagsFlag)
+		}
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			return nil, fmt.Errorf("tag key '%s' specified with '--%s' cannot start with 'aws:'", key, flags.RequireSessionTagsFlag)
+		}
+		requiredTags[key] = aws.StringValue(value)
+	}
+	return requiredTags, nil
+}
+
+// sessionTagTrustPolicy returns the default trust policy for ECS tasks, also allowing sts:TagSession, with each of
+// the tags required on the request. Both actions carry the conditions, since the session tags are passed when the role
+// is assumed.
+func sessionTagTrustPolicy(requiredTags map[string]string) string {
+	conditions := make(map[string]map[string]string)
+	for key, value := range requiredTags {
+		operator, conditionValue := "StringEquals", value
+		if value == "" {
+			operator, conditionValue = "Null", "false"
+		}
+		if conditions[operator] == nil {
+			conditions[operator] = make(map[string]string)
+		}
+		conditions[operator][requestTagConditionKeyPrefix+key] = conditionValue
+	}
+
+	document := trustPolicyDocument{
+		Version: "2012-10-17",
+		Statement: []trustPolicyStatement{{
+			Effect:    "Allow",
+			Principal: map[string]string{"Service": "ecs-tasks.amazonaws.com"},
+			Action:    []string{"sts:AssumeRole", tagSessionAction},
+			Condition: conditions,
+		}},
+	}
+	// a document of strings always marshals, and maps are marshalled with sorted keys so the document is stable
+	doc, _ := json.Marshal(document)
+	return string(doc)
+}
@@ -0,0 +1,100 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRequiredSessionTags(t *testing.T) {
+	requiredTags, err := parseRequiredSessionTags("Team=payments,Project=")
+	assert.NoError(t, err, "Unexpected error parsing required session tags")
+	assert.Equal(t, map[string]string{"Team": "payments", "Project": ""}, requiredTags)
+
+	requiredTags, err = parseRequiredSessionTags("")
+	assert.NoError(t, err, "Unexpected error when no session tags are given")
+	assert.Nil(t, requiredTags)
+}
+
+func TestParseRequiredSessionTags_ErrorCases(t *testing.T) {
+	for _, flagValue := range []string{"Team", "=payments", "aws:PrincipalTag=x", "Team=payments,"} {
+		_, err := parseRequiredSessionTags(flagValue)
+		assert.Error(t, err, "Expected error for '%s'", flagValue)
+	}
+}
+
+func TestSessionTagTrustPolicy(t *testing.T) {
+	trustPolicy := sessionTagTrustPolicy(map[string]string{"Team": "payments", "Env": "prod", "Project": ""})
+
+	expected := `{"Version":"2012-10-17","Statement":[{"Sid":"","Effect":"Allow","Principal":{"Service":"ecs-tasks.amazonaws.com"},` +
+		`"Action":["sts:AssumeRole","sts:TagSession"],` +
+		`"Condition":{"Null":{"aws:RequestTag/Project":"false"},"StringEquals":{"aws:RequestTag/Env":"prod","aws:RequestTag/Team":"payments"}}}]}`
+	assert.Equal(t, expected, trustPolicy)
+}
+
+func TestTrustPolicy_DefaultUnchangedWithoutSessionTags(t *testing.T) {
+	assert.Equal(t, assumeRolePolicyDocString, ExecutionRoleParams{}.trustPolicy())
+}
+
+func TestCreateTaskExecutionRole_RequiredSessionTags(t *testing.T) {
+	requiredTags := map[string]string{"Team": "payments"}
+	testPolicyARN := "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-" + testLimitRoleName + "-policy"
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Do(func(x interface{}) {
+			input := x.(iam.CreateRoleInput)
+			assert.Equal(t, sessionTagTrustPolicy(requiredTags), aws.StringValue(input.AssumeRolePolicyDocument))
+		}).Return("arn:aws:iam::111111111111:role/"+testLimitRoleName, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: aws.String(testPolicyARN)}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testLimitRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(testPolicyARN, testLimitRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+		},
+		RoleName:            testLimitRoleName,
+		Region:              "us-west-2",
+		RequiredSessionTags: requiredTags,
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error creating role requiring session tags")
+}
+
+func TestCreateTaskExecutionRoles_ErrorOnRequiredSessionTagsWithTrustPolicy(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secret/some-test-arn", "", []string{"test"}),
+		},
+		RoleName:            testLimitRoleName,
+		Region:              "us-west-2",
+		TrustPolicy:         assumeRolePolicyDocString,
+		RequiredSessionTags: map[string]string{"Team": "payments"},
+	}
+
+	_, err := CreateTaskExecutionRoles(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when session tags are required with a custom trust policy")
+}
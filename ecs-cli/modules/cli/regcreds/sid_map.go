@@ -0,0 +1,64 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// writeSidMap writes the mapping of each statement of the new policy to the registry it grants access to. The same
+// policy is attached to every role, so the statements of the first role are used.
+func writeSidMap(store regcredio.Store, filename string, roleResults []*ExecutionRoleResult, creds map[string]regcredio.CredsOutputEntry) error {
+	if len(roleResults) == 0 || len(roleResults[0].PolicyStatements) == 0 {
+		log.Warnf("No policy was generated, so no Sid map is written to %s.", filename)
+		return nil
+	}
+	sidMap := buildSidMap(roleResults[0], creds)
+	if err := regcredio.WriteSidMapTo(store, sidMap, filename); err != nil {
+		return errors.Wrapf(err, "failed to write Sid map to %s", filename)
+	}
+	return nil
+}
+
+func buildSidMap(result *ExecutionRoleResult, creds map[string]regcredio.CredsOutputEntry) regcredio.ECSSidMap {
+	entries := make([]regcredio.SidMapEntry, 0, len(result.PolicyStatements))
+	for _, statement := range result.PolicyStatements {
+		entry := regcredio.SidMapEntry{
+			Sid:       statement.Sid,
+			Effect:    statement.Effect,
+			Actions:   statement.Action,
+			Resources: statement.Resource,
+		}
+		if credEntry, ok := creds[statement.registryName]; ok && statement.registryName != "" {
+			entry.Registry = statement.registryName
+			entry.Name = credEntry.Name
+			entry.SecretARN = credEntry.CredentialARN
+			entry.KMSKeyID = credEntry.KMSKeyID
+		}
+		entries = append(entries, entry)
+	}
+	createdAt := result.PolicyCreateTime
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	return regcredio.ECSSidMap{
+		CreatedAt:  createdAt,
+		PolicyARN:  result.PolicyARN,
+		Statements: entries,
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSidMap(t *testing.T) {
+	secretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-my.example.com-a1b2c3"
+	keyARN := "arn:aws:kms:us-west-2:111111111111:key/12345678-1234-1234-1234-123456789012"
+	creds := map[string]regcredio.CredsOutputEntry{
+		"my.example.com": {Name: "payments registry", CredentialARN: secretARN, KMSKeyID: keyARN},
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockKMS.EXPECT().GetValidKeyARN(keyARN).Return(keyARN, nil)
+	statements, err := generateSecretsStatements(creds, "", mocks.MockKMS)
+	require.NoError(t, err)
+	statements = appendECRPullStatements(statements, nil)
+	createTime := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	roleResults := []*ExecutionRoleResult{{
+		RoleName:         "myTaskExecutionRole",
+		PolicyARN:        "arn:aws:iam::111111111111:policy/myPolicy",
+		PolicyStatements: statements,
+		PolicyCreateTime: createTime,
+	}}
+
+	store := regcredio.NewMemoryStore(nil)
+	err = writeSidMap(store, "sid-map.json", roleResults, creds)
+	require.NoError(t, err, "Unexpected error writing Sid map")
+
+	data, err := store.ReadFile("sid-map.json")
+	require.NoError(t, err)
+	sidMap := regcredio.ECSSidMap{}
+	require.NoError(t, json.Unmarshal(data, &sidMap))
+	assert.Equal(t, "arn:aws:iam::111111111111:policy/myPolicy", sidMap.PolicyARN)
+	assert.Equal(t, createTime, sidMap.CreatedAt)
+	require.Equal(t, len(statements), len(sidMap.Statements))
+
+	assert.Equal(t, regcredio.SidMapEntry{
+		Sid:       "PaymentsRegistry",
+		Registry:  "my.example.com",
+		Name:      "payments registry",
+		SecretARN: secretARN,
+		KMSKeyID:  keyARN,
+		Effect:    "Allow",
+		Actions:   statements[0].Action,
+		Resources: []string{keyARN, secretARN},
+	}, sidMap.Statements[0])
+	for _, entry := range sidMap.Statements[1:] {
+		assert.Empty(t, entry.Registry, "Expected ECR statements to have no registry")
+		assert.NotEmpty(t, entry.Sid)
+	}
+}
+
+func TestWriteSidMap_NoPolicy(t *testing.T) {
+	store := regcredio.NewMemoryStore(nil)
+	err := writeSidMap(store, "sid-map.json", []*ExecutionRoleResult{{RoleName: "myTaskExecutionRole"}}, nil)
+	assert.NoError(t, err, "Unexpected error when no policy was generated")
+	assert.Empty(t, store.FileNames(), "Expected no Sid map without a policy")
+}
@@ -0,0 +1,240 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	secretsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/secretsmanager"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const simulationContextKeyType = "string"
+
+// simulationResult is the decision of the IAM policy simulator for one action of the new policy on one resource
+type simulationResult struct {
+	RoleName string
+	Action   string
+	Resource string
+	Decision string
+	// Detail names what denied the action, if the simulator reports it
+	Detail string
+}
+
+func (result simulationResult) allowed() bool {
+	return result.Decision == iam.PolicyEvaluationDecisionTypeAllowed
+}
+
+// simulationCredEntries returns the output entries which the new policy would be generated from, without creating or
+// updating any secrets. Secrets which 'up' would create don't have an ARN yet, so a placeholder ARN with the name of
+// the new secret is used for them.
+func simulationCredEntries(regCreds map[string]regcredio.RegistryCredEntry, region, accountID string, smClient secretsClient.SMClient) map[string]regcredio.CredsOutputEntry {
+	entries := make(map[string]regcredio.CredsOutputEntry, len(regCreds))
+	for registryName, credentialEntry := range regCreds {
+		secretARN := credentialEntry.SecretManagerARN
+		keyID := credentialEntry.KmsKeyID
+		if secretARN == "" {
+			secretName := aws.StringValue(generateECSResourceName(registryName))
+			if existingSecret, _ := smClient.DescribeSecret(secretName); existingSecret != nil {
+				secretARN = aws.StringValue(existingSecret.ARN)
+				keyID = aws.StringValue(existingSecret.KmsKeyId)
+			} else {
+				secretARN = fmt.Sprintf("arn:%s:secretsmanager:%s:%s:secret:%s", utils.GetPartition(region), region, accountID, secretName)
+			}
+		}
+		entry := regcredio.BuildOutputEntry(secretARN, keyID, credentialEntry.ContainerNames)
+		entry.Name = credentialEntry.Name
+		entry.Actions = credentialEntry.Actions
+		entry.RotationCompatible = credentialEntry.RotationCompatible
+		entry.SecretScope = credentialEntry.SecretScope
+		entries[registryName] = entry
+	}
+	return entries
+}
+
+// simulateNewPolicy simulates the policy generated from the params for each role and writes the decisions to w. It
+// returns an error if any action would be denied, so that 'up' fails without creating or changing any resources.
+func simulateNewPolicy(roleNames []string, params ExecutionRoleParams, permissionsBoundary string, w io.Writer, client iamClient.Client, kmsClient kms.Client) error {
+	statements, err := params.policyStatements(kmsClient)
+	if err != nil {
+		return err
+	}
+	if len(statements) == 0 {
+		log.Info("The new policy would grant no access, so there is nothing to simulate.")
+		return nil
+	}
+
+	results, err := simulatePolicy(roleNames, statements, permissionsBoundary, params.TagCondition, client)
+	if err != nil {
+		return err
+	}
+	denied, err := writeSimulationResults(results, w)
+	if err != nil {
+		return err
+	}
+	if denied > 0 {
+		return fmt.Errorf("the policy simulation found %d denied actions; no resources were created or changed", denied)
+	}
+	log.Info("All actions of the new policy were allowed by the policy simulation; no resources were created or changed.")
+	return nil
+}
+
+// simulatePolicy runs the IAM policy simulator for each action the statements allow, on each of their resources, as
+// each role would be granted them. Existing roles are simulated with their attached policies, permissions boundary
+// and any service control policies, along with the new policy. Roles which don't exist yet are simulated with only the
+// new policy and the permissions boundary they would be created with.
+func simulatePolicy(roleNames []string, statements []StatementEntry, permissionsBoundary string, tagCondition *PolicyTagCondition, client iamClient.Client) ([]simulationResult, error) {
+	policyDoc, err := marshalPolicyDocument(statements)
+	if err != nil {
+		return nil, err
+	}
+
+	boundaryDocs := []*string{}
+	if permissionsBoundary != "" {
+		boundaryDoc, err := client.GetPolicyDocument(permissionsBoundary)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read permissions boundary %s", permissionsBoundary)
+		}
+		boundaryDocs = append(boundaryDocs, aws.String(boundaryDoc))
+	}
+
+	var results []simulationResult
+	for _, roleName := range roleNames {
+		roleARN := ""
+		role, err := client.GetRole(roleName)
+		if err != nil && !isNoSuchEntity(err) {
+			return nil, errors.Wrapf(err, "failed to get role %s", roleName)
+		}
+		if role != nil {
+			roleARN = aws.StringValue(role.Arn)
+		}
+
+		for _, statement := range statements {
+			if statement.Effect != "Allow" {
+				continue
+			}
+			evaluations, err := simulateStatement(roleARN, policyDoc, boundaryDocs, statement, tagCondition, client)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to simulate the new policy for role %s", roleName)
+			}
+			for _, evaluation := range evaluations {
+				results = append(results, simulationResult{
+					RoleName: roleName,
+					Action:   aws.StringValue(evaluation.EvalActionName),
+					Resource: aws.StringValue(evaluation.EvalResourceName),
+					Decision: aws.StringValue(evaluation.EvalDecision),
+					Detail:   simulationDenialDetail(evaluation),
+				})
+			}
+		}
+	}
+	return results, nil
+}
+
+// simulateStatement simulates the actions of the statement on its resources, with a request context which meets the
+// statement's own conditions and the principal tag required by a tag condition, as requests by ECS would
+func simulateStatement(roleARN, policyDoc string, boundaryDocs []*string, statement StatementEntry, tagCondition *PolicyTagCondition, client iamClient.Client) ([]*iam.EvaluationResult, error) {
+	var contextEntries []*iam.ContextEntry
+	for _, conditions := range statement.Condition {
+		for key, value := range conditions {
+			contextEntries = append(contextEntries, simulationContextEntry(key, value))
+		}
+	}
+	sort.SliceStable(contextEntries, func(i, j int) bool {
+		return aws.StringValue(contextEntries[i].ContextKeyName) < aws.StringValue(contextEntries[j].ContextKeyName)
+	})
+	if tagCondition != nil && tagCondition.Type == TagConditionPrincipal {
+		contextEntries = append(contextEntries, simulationContextEntry(principalTagConditionKeyPrefix+tagCondition.Key, tagCondition.Value))
+	}
+
+	if roleARN != "" {
+		return client.SimulatePrincipalPolicy(iam.SimulatePrincipalPolicyInput{
+			PolicySourceArn: aws.String(roleARN),
+			PolicyInputList: []*string{aws.String(policyDoc)},
+			ActionNames:     aws.StringSlice(statement.Action),
+			ResourceArns:    aws.StringSlice(statement.Resource),
+			ContextEntries:  contextEntries,
+		})
+	}
+	input := iam.SimulateCustomPolicyInput{
+		PolicyInputList: []*string{aws.String(policyDoc)},
+		ActionNames:     aws.StringSlice(statement.Action),
+		ResourceArns:    aws.StringSlice(statement.Resource),
+		ContextEntries:  contextEntries,
+	}
+	if len(boundaryDocs) > 0 {
+		input.PermissionsBoundaryPolicyInputList = boundaryDocs
+	}
+	return client.SimulateCustomPolicy(input)
+}
+
+func simulationContextEntry(key, value string) *iam.ContextEntry {
+	return &iam.ContextEntry{
+		ContextKeyName:   aws.String(key),
+		ContextKeyType:   aws.String(simulationContextKeyType),
+		ContextKeyValues: []*string{aws.String(value)},
+	}
+}
+
+// simulationDenialDetail returns what denied a simulated action, if the simulator reports it
+func simulationDenialDetail(evaluation *iam.EvaluationResult) string {
+	if aws.StringValue(evaluation.EvalDecision) == iam.PolicyEvaluationDecisionTypeAllowed {
+		return ""
+	}
+	if detail := evaluation.OrganizationsDecisionDetail; detail != nil && !aws.BoolValue(detail.AllowedByOrganizations) {
+		return "service control policy"
+	}
+	if detail := evaluation.PermissionsBoundaryDecisionDetail; detail != nil && !aws.BoolValue(detail.AllowedByPermissionsBoundary) {
+		return "permissions boundary"
+	}
+	for _, statement := range evaluation.MatchedStatements {
+		if sourcePolicy := aws.StringValue(statement.SourcePolicyId); sourcePolicy != "" {
+			return "statement in " + sourcePolicy
+		}
+	}
+	return ""
+}
+
+// writeSimulationResults writes a table of the simulated decisions and returns the number of denied actions
+func writeSimulationResults(results []simulationResult, w io.Writer) (int, error) {
+	tw := new(tabwriter.Writer)
+	tw.Init(w, cellWidthInSpaces, widthBetweenCellsInSpaces, cellPaddingInSpaces, paddingCharacter, noFormatting)
+	fmt.Fprintln(tw, "ROLE\tACTION\tDECISION\tDENIED BY\tRESOURCE")
+	denied := 0
+	for _, result := range results {
+		if !result.allowed() {
+			denied++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", result.RoleName, result.Action, result.Decision, result.Detail, result.Resource)
+	}
+	return denied, tw.Flush()
+}
+
+func isNoSuchEntity(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == iam.ErrCodeNoSuchEntityException
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testSimulateSecretARN = "arn:aws:secretsmanager:us-west-2:111111111111:secret:existing-AbCdEf"
+	testSimulateRoleARN   = "arn:aws:iam::111111111111:role/" + testLimitRoleName
+)
+
+func TestSimulationCredEntries(t *testing.T) {
+	regCreds := map[string]regcredio.RegistryCredEntry{
+		"given.example.com":    {SecretManagerARN: testSimulateSecretARN, ContainerNames: []string{"web"}},
+		"existing.example.com": {Username: "user", Password: "pass", ContainerNames: []string{"web"}},
+		"new.example.com":      {Username: "user", Password: "pass", KmsKeyID: "arn:aws:kms:us-west-2:111111111111:key/1234"},
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockSM.EXPECT().DescribeSecret("amazon-ecs-cli-setup-existing.example.com").Return(&secretsmanager.DescribeSecretOutput{
+		ARN:      aws.String("arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-existing.example.com-AbCdEf"),
+		KmsKeyId: aws.String("arn:aws:kms:us-west-2:111111111111:key/5678"),
+	}, nil)
+	mocks.MockSM.EXPECT().DescribeSecret("amazon-ecs-cli-setup-new.example.com").Return(nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "not found", nil))
+	mocks.MockSM.EXPECT().CreateSecret(gomock.Any()).Times(0)
+
+	entries := simulationCredEntries(regCreds, "us-west-2", "111111111111", mocks.MockSM)
+	assert.Equal(t, testSimulateSecretARN, entries["given.example.com"].CredentialARN)
+	assert.Equal(t, "arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-existing.example.com-AbCdEf", entries["existing.example.com"].CredentialARN)
+	assert.Equal(t, "arn:aws:kms:us-west-2:111111111111:key/5678", entries["existing.example.com"].KMSKeyID, "Expected the key of the existing secret")
+	assert.Equal(t, "arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-new.example.com", entries["new.example.com"].CredentialARN, "Expected a placeholder ARN for a new secret")
+	assert.Equal(t, "arn:aws:kms:us-west-2:111111111111:key/1234", entries["new.example.com"].KMSKeyID)
+}
+
+func TestSimulatePolicy_ExistingRole(t *testing.T) {
+	statements := []StatementEntry{{
+		Effect:    "Allow",
+		Action:    []string{"secretsmanager:GetSecretValue"},
+		Resource:  []string{testSimulateSecretARN},
+		Condition: map[string]map[string]string{"StringEquals": {versionStageConditionKey: "AWSCURRENT"}},
+	}}
+	tagCondition := &PolicyTagCondition{Type: TagConditionPrincipal, Key: "Team", Value: "payments"}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().GetRole(testLimitRoleName).Return(&iam.Role{Arn: aws.String(testSimulateRoleARN)}, nil),
+		mocks.MockIAM.EXPECT().SimulatePrincipalPolicy(gomock.Any()).Do(func(x interface{}) {
+			input := x.(iam.SimulatePrincipalPolicyInput)
+			assert.Equal(t, testSimulateRoleARN, aws.StringValue(input.PolicySourceArn))
+			assert.Len(t, input.PolicyInputList, 1, "Expected the new policy to be simulated with the role's policies")
+			assert.Equal(t, []string{"secretsmanager:GetSecretValue"}, aws.StringValueSlice(input.ActionNames))
+			assert.Equal(t, []string{testSimulateSecretARN}, aws.StringValueSlice(input.ResourceArns))
+			assert.Equal(t, []*iam.ContextEntry{
+				simulationContextEntry(versionStageConditionKey, "AWSCURRENT"),
+				simulationContextEntry("aws:PrincipalTag/Team", "payments"),
+			}, input.ContextEntries, "Expected the request context to meet the policy's conditions")
+		}).Return([]*iam.EvaluationResult{{
+			EvalActionName:              aws.String("secretsmanager:GetSecretValue"),
+			EvalResourceName:            aws.String(testSimulateSecretARN),
+			EvalDecision:                aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny),
+			OrganizationsDecisionDetail: &iam.OrganizationsDecisionDetail{AllowedByOrganizations: aws.Bool(false)},
+		}}, nil),
+	)
+	mocks.MockIAM.EXPECT().SimulateCustomPolicy(gomock.Any()).Times(0)
+
+	results, err := simulatePolicy([]string{testLimitRoleName}, statements, "", tagCondition, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error simulating policy")
+	assert.Equal(t, []simulationResult{{
+		RoleName: testLimitRoleName,
+		Action:   "secretsmanager:GetSecretValue",
+		Resource: testSimulateSecretARN,
+		Decision: iam.PolicyEvaluationDecisionTypeImplicitDeny,
+		Detail:   "service control policy",
+	}}, results)
+}
+
+func TestSimulatePolicy_NewRoleWithBoundary(t *testing.T) {
+	boundaryARN := "arn:aws:iam::111111111111:policy/boundary"
+	statements := []StatementEntry{
+		{Effect: "Allow", Action: []string{"secretsmanager:GetSecretValue"}, Resource: []string{testSimulateSecretARN}},
+		{Sid: tagConditionSid, Effect: "Deny", Action: []string{"secretsmanager:GetSecretValue"}, Resource: []string{testSimulateSecretARN}},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().GetPolicyDocument(boundaryARN).Return(`{"boundary":true}`, nil),
+		mocks.MockIAM.EXPECT().GetRole(testLimitRoleName).Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "role not found", nil)),
+		// only the allow statement is simulated
+		mocks.MockIAM.EXPECT().SimulateCustomPolicy(gomock.Any()).Do(func(x interface{}) {
+			input := x.(iam.SimulateCustomPolicyInput)
+			assert.Equal(t, []string{`{"boundary":true}`}, aws.StringValueSlice(input.PermissionsBoundaryPolicyInputList))
+			assert.Empty(t, input.ContextEntries)
+		}).Return([]*iam.EvaluationResult{{
+			EvalActionName:   aws.String("secretsmanager:GetSecretValue"),
+			EvalResourceName: aws.String(testSimulateSecretARN),
+			EvalDecision:     aws.String(iam.PolicyEvaluationDecisionTypeAllowed),
+		}}, nil),
+	)
+	mocks.MockIAM.EXPECT().SimulatePrincipalPolicy(gomock.Any()).Times(0)
+
+	results, err := simulatePolicy([]string{testLimitRoleName}, statements, boundaryARN, nil, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error simulating policy for a new role")
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].allowed())
+		assert.Empty(t, results[0].Detail)
+	}
+}
+
+func TestSimulatePolicy_ErrorOnGetRole(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole(testLimitRoleName).Return(nil, awserr.New("AccessDenied", "not authorized", nil))
+
+	statements := []StatementEntry{{Effect: "Allow", Action: []string{"secretsmanager:GetSecretValue"}, Resource: []string{testSimulateSecretARN}}}
+	_, err := simulatePolicy([]string{testLimitRoleName}, statements, "", nil, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the role can't be read")
+}
+
+func TestSimulateNewPolicy_ErrorOnDeniedAction(t *testing.T) {
+	params := ExecutionRoleParams{
+		CredEntries: map[string]regcredio.CredsOutputEntry{
+			"given.example.com": {CredentialARN: testSimulateSecretARN},
+		},
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().GetRole(testLimitRoleName).Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "role not found", nil)),
+		mocks.MockIAM.EXPECT().SimulateCustomPolicy(gomock.Any()).Return([]*iam.EvaluationResult{{
+			EvalActionName:   aws.String("secretsmanager:GetSecretValue"),
+			EvalResourceName: aws.String(testSimulateSecretARN),
+			EvalDecision:     aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny),
+		}}, nil),
+	)
+
+	var out bytes.Buffer
+	err := simulateNewPolicy([]string{testLimitRoleName}, params, "", &out, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when the simulation denies an action")
+	assert.Contains(t, out.String(), iam.PolicyEvaluationDecisionTypeImplicitDeny, "Expected the denied action to be printed")
+}
+
+func TestSimulationDenialDetail(t *testing.T) {
+	assert.Equal(t, "permissions boundary", simulationDenialDetail(&iam.EvaluationResult{
+		EvalDecision:                      aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny),
+		PermissionsBoundaryDecisionDetail: &iam.PermissionsBoundaryDecisionDetail{AllowedByPermissionsBoundary: aws.Bool(false)},
+	}))
+	assert.Equal(t, "statement in mySCP", simulationDenialDetail(&iam.EvaluationResult{
+		EvalDecision:      aws.String(iam.PolicyEvaluationDecisionTypeExplicitDeny),
+		MatchedStatements: []*iam.Statement{{SourcePolicyId: aws.String("mySCP")}},
+	}))
+	assert.Empty(t, simulationDenialDetail(&iam.EvaluationResult{EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeAllowed)}))
+}
+
+func TestWriteSimulationResults(t *testing.T) {
+	results := []simulationResult{
+		{RoleName: "myRole", Action: "secretsmanager:GetSecretValue", Resource: testSimulateSecretARN, Decision: iam.PolicyEvaluationDecisionTypeAllowed},
+		{RoleName: "myRole", Action: "kms:Decrypt", Resource: "arn:aws:kms:us-west-2:111111111111:key/1234", Decision: iam.PolicyEvaluationDecisionTypeImplicitDeny, Detail: "permissions boundary"},
+	}
+
+	out := &bytes.Buffer{}
+	denied, err := writeSimulationResults(results, out)
+	assert.NoError(t, err, "Unexpected error writing simulation results")
+	assert.Equal(t, 1, denied)
+	assert.Contains(t, out.String(), "DECISION")
+	assert.Contains(t, out.String(), "permissions boundary")
+}
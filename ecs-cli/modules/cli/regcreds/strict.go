@@ -0,0 +1,118 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	// retryNoticeField is set on the warnings logged while a call is retried shortly after a role or policy was
+	// created. The call may still succeed, so these warnings aren't counted by '--strict'.
+	retryNoticeField = "retry"
+	// strictModeMetadataKey is the key of the hook of a command run with '--strict' in the metadata of its app
+	strictModeMetadataKey = "strictModeHook"
+)
+
+// strictModeHook records the warnings logged by a command run with '--strict'
+type strictModeHook struct {
+	lock     sync.Mutex
+	warnings []string
+}
+
+// retryNotice returns the entry to log a retry notice with, which '--strict' doesn't count as a warning
+func retryNotice() *log.Entry {
+	return log.WithField(retryNoticeField, true)
+}
+
+func (hook *strictModeHook) Levels() []log.Level {
+	return []log.Level{log.WarnLevel}
+}
+
+func (hook *strictModeHook) Fire(entry *log.Entry) error {
+	if retry, ok := entry.Data[retryNoticeField].(bool); ok && retry {
+		return nil
+	}
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+	hook.warnings = append(hook.warnings, entry.Message)
+	return nil
+}
+
+// err returns an error if any warning was recorded
+func (hook *strictModeHook) err() error {
+	if hook == nil {
+		return nil
+	}
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+	if len(hook.warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d warning(s) treated as errors with '--%s'; the first was: %s", len(hook.warnings), flags.StrictFlag, hook.warnings[0])
+}
+
+// WithStrictMode returns the action of a registry-creds subcommand which, if run with '--strict', fails once it has
+// finished if it logged any warning. Commands which make changes also call strictModeError before making any.
+func WithStrictMode(action func(*cli.Context)) func(*cli.Context) {
+	return func(c *cli.Context) {
+		if !c.Bool(flags.StrictFlag) {
+			action(c)
+			return
+		}
+		hook := &strictModeHook{}
+		if c.App.Metadata == nil {
+			c.App.Metadata = make(map[string]interface{})
+		}
+		c.App.Metadata[strictModeMetadataKey] = hook
+		removeHook := addLogHook(log.StandardLogger(), hook)
+		defer func() {
+			removeHook()
+			delete(c.App.Metadata, strictModeMetadataKey)
+		}()
+
+		action(c)
+		if err := hook.err(); err != nil {
+			log.Fatalf("Error executing '%s': %v", c.Command.Name, err)
+		}
+	}
+}
+
+// addLogHook adds the hook to the logger and returns a function which restores the hooks the logger had before
+func addLogHook(logger *log.Logger, hook log.Hook) func() {
+	previous := logger.Hooks
+	hooks := make(log.LevelHooks, len(previous))
+	for level, levelHooks := range previous {
+		hooks[level] = append([]log.Hook(nil), levelHooks...)
+	}
+	hooks.Add(hook)
+	logger.Hooks = hooks
+	return func() {
+		logger.Hooks = previous
+	}
+}
+
+// strictModeError returns an error if the command was run with '--strict' and has logged a warning so far
+func strictModeError(c *cli.Context) error {
+	if c.App == nil {
+		return nil
+	}
+	hook, _ := c.App.Metadata[strictModeMetadataKey].(*strictModeHook)
+	return hook.err()
+}
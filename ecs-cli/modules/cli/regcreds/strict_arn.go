@@ -0,0 +1,136 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/arn"
+)
+
+// arnPartitions are the AWS partitions which resources in the input can be in
+var arnPartitions = map[string]bool{
+	"aws":        true,
+	"aws-cn":     true,
+	"aws-us-gov": true,
+	"aws-iso":    true,
+	"aws-iso-b":  true,
+}
+
+var (
+	arnRegionPattern  = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-\d+$`)
+	arnAccountPattern = regexp.MustCompile(`^\d{12}$`)
+)
+
+// expectedARN describes the service and resource types an ARN field in the input must have
+type expectedARN struct {
+	service          string
+	resourcePrefixes []string
+}
+
+var (
+	secretARNFormat        = expectedARN{service: "secretsmanager", resourcePrefixes: []string{"secret:"}}
+	kmsKeyARNFormat        = expectedARN{service: "kms", resourcePrefixes: []string{"key/", "alias/"}}
+	ecrRepositoryARNFormat = expectedARN{service: "ecr", resourcePrefixes: []string{ecrRepositoryResourcePrefix}}
+)
+
+// findStrictARNProblems fully parses every ARN in the input and returns a finding for each problem, so that all of
+// them can be fixed at once. KMS keys given as an ID or alias name rather than an ARN are not checked.
+func findStrictARNProblems(input regcredio.ECSRegCredsInput) []validationFinding {
+	findings := []validationFinding{}
+
+	registryNames := make([]string, 0, len(input.RegistryCredentials))
+	for registryName := range input.RegistryCredentials {
+		registryNames = append(registryNames, registryName)
+	}
+	sort.Strings(registryNames)
+
+	for _, registryName := range registryNames {
+		credentialEntry := input.RegistryCredentials[registryName]
+		if credentialEntry.SecretManagerARN != "" {
+			for _, problem := range strictARNProblems(credentialEntry.SecretManagerARN, secretARNFormat) {
+				findings = append(findings, validationFinding{Severity: SeverityError, Entry: registryName, Field: "secrets_manager_arn", Message: problem})
+			}
+		}
+		if strings.HasPrefix(credentialEntry.KmsKeyID, "arn:") {
+			for _, problem := range strictARNProblems(credentialEntry.KmsKeyID, kmsKeyARNFormat) {
+				findings = append(findings, validationFinding{Severity: SeverityError, Entry: registryName, Field: "kms_key_id", Message: problem})
+			}
+		}
+	}
+	for _, repository := range input.ECRRepositories {
+		for _, problem := range strictARNProblems(repository, ecrRepositoryARNFormat) {
+			findings = append(findings, validationFinding{Severity: SeverityError, Field: "ecr_repositories", Message: problem})
+		}
+	}
+	return findings
+}
+
+// strictARNProblems returns every problem with the ARN's partition, service, region, account and resource
+func strictARNProblems(value string, expected expectedARN) []string {
+	parsed, err := arn.Parse(value)
+	if err != nil {
+		return []string{fmt.Sprintf("'%s' is not a valid ARN; expected arn:<partition>:%s:<region>:<account>:<resource>", value, expected.service)}
+	}
+
+	var problems []string
+	if !arnPartitions[parsed.Partition] {
+		problems = append(problems, fmt.Sprintf("ARN '%s' has unknown partition '%s'", value, parsed.Partition))
+	}
+	if parsed.Service != expected.service {
+		problems = append(problems, fmt.Sprintf("ARN '%s' has service '%s', expected '%s'", value, parsed.Service, expected.service))
+	}
+	if !arnRegionPattern.MatchString(parsed.Region) {
+		problems = append(problems, fmt.Sprintf("ARN '%s' has invalid region '%s'", value, parsed.Region))
+	}
+	if !arnAccountPattern.MatchString(parsed.AccountID) {
+		problems = append(problems, fmt.Sprintf("ARN '%s' has invalid account '%s'; expected a 12 digit account ID", value, parsed.AccountID))
+	}
+	if !hasResourceName(parsed.Resource, expected.resourcePrefixes) {
+		problems = append(problems, fmt.Sprintf("ARN '%s' has invalid resource '%s'; expected %s followed by a name", value, parsed.Resource, strings.Join(expected.resourcePrefixes, " or ")))
+	}
+	if strings.ContainsAny(value, " \t\r\n") {
+		problems = append(problems, fmt.Sprintf("ARN '%s' contains whitespace", value))
+	}
+	return problems
+}
+
+func hasResourceName(resource string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(resource, prefix) && len(resource) > len(prefix) && !strings.HasPrefix(resource[len(prefix):], ":") {
+			return true
+		}
+	}
+	return false
+}
+
+// strictARNError returns an error listing every finding, or nil if there are none
+func strictARNError(findings []validationFinding, inputFile string) error {
+	if len(findings) == 0 {
+		return nil
+	}
+	problems := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		problem := finding.Field + ": " + finding.Message
+		if finding.Entry != "" {
+			problem = fmt.Sprintf("registry %s, %s", finding.Entry, problem)
+		}
+		problems = append(problems, problem)
+	}
+	return fmt.Errorf("found %d problem(s) with ARNs in %s: %s", len(findings), inputFile, strings.Join(problems, "; "))
+}
@@ -0,0 +1,109 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindStrictARNProblems_ValidInput(t *testing.T) {
+	input := regcredio.ECSRegCredsInput{
+		RegistryCredentials: regcredio.RegistryCreds{
+			"my.example.com": {
+				SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:my-secret-AbCdEf",
+				KmsKeyID:         "arn:aws:kms:us-west-2:111111111111:key/1234abcd-12ab-34cd-56ef-1234567890ab",
+			},
+			"other.example.com": {
+				SecretManagerARN: "arn:aws-cn:secretsmanager:cn-north-1:111111111111:secret:other-secret",
+				// aliases are resolved later
+				KmsKeyID: "alias/my-key",
+			},
+		},
+		ECRRepositories: []string{"arn:aws:ecr:us-west-2:111111111111:repository/my-app*"},
+	}
+
+	assert.Empty(t, findStrictARNProblems(input), "Expected no problems with well-formed ARNs")
+}
+
+func TestFindStrictARNProblems_ListsAllProblems(t *testing.T) {
+	input := regcredio.ECSRegCredsInput{
+		RegistryCredentials: regcredio.RegistryCreds{
+			"a.example.com": {
+				// missing colon between account and resource type
+				SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111secret:my-secret",
+				KmsKeyID:         "arn:aws:kms:us-west-2:111111111111:my-key",
+			},
+			"b.example.com": {
+				// extra colon, and a typo in the service
+				SecretManagerARN: "arn:aws:secretmanager:us-west-2::111111111111:secret:my-secret",
+			},
+			"c.example.com": {
+				SecretManagerARN: "arn:aws:secretsmanager:us-west-2",
+			},
+		},
+		ECRRepositories: []string{"arn:aws:ecr:uswest2:111111111111:repository/my-app"},
+	}
+
+	findings := findStrictARNProblems(input)
+	var fields []string
+	for _, finding := range findings {
+		assert.Equal(t, SeverityError, finding.Severity)
+		fields = append(fields, finding.Entry+"/"+finding.Field)
+	}
+	assert.Equal(t, []string{
+		"a.example.com/secrets_manager_arn",
+		"a.example.com/secrets_manager_arn",
+		"a.example.com/kms_key_id",
+		"b.example.com/secrets_manager_arn",
+		"b.example.com/secrets_manager_arn",
+		"b.example.com/secrets_manager_arn",
+		"c.example.com/secrets_manager_arn",
+		"/ecr_repositories",
+	}, fields)
+	assert.Contains(t, findings[3].Message, "service 'secretmanager'")
+	assert.Contains(t, findings[4].Message, "invalid account ''")
+	assert.Contains(t, findings[6].Message, "not a valid ARN")
+	assert.Contains(t, findings[7].Message, "invalid region 'uswest2'")
+}
+
+func TestStrictARNProblems(t *testing.T) {
+	testCases := map[string]int{
+		"arn:aws:secretsmanager:us-west-2:111111111111:secret:my-secret":     0,
+		"arn:aws:secretsmanager:us-gov-west-1:111111111111:secret:my-secret": 0,
+		"arn:awz:secretsmanager:us-west-2:111111111111:secret:my-secret":     1,
+		"arn:aws:secretsmanager:us-west-2:11111111111:secret:my-secret":      1,
+		"arn:aws:secretsmanager:us-west-2:111111111111:secret::my-secret":    1,
+		"arn:aws:secretsmanager:us-west-2:111111111111:secret:":              1,
+		"arn:aws:secretsmanager:us-west-2:111111111111:secret:my secret":     1,
+		"arn:aws:kms:us-west-2:111111111111:key/my-key":                      2,
+		"secret:my-secret": 1,
+	}
+	for value, problemCount := range testCases {
+		assert.Len(t, strictARNProblems(value, secretARNFormat), problemCount, "Unexpected problems for '%s'", value)
+	}
+}
+
+func TestStrictARNError(t *testing.T) {
+	assert.NoError(t, strictARNError(nil, "input.yml"))
+
+	err := strictARNError([]validationFinding{
+		{Severity: SeverityError, Entry: "a.example.com", Field: "secrets_manager_arn", Message: "first problem"},
+		{Severity: SeverityError, Field: "ecr_repositories", Message: "second problem"},
+	}, "input.yml")
+	assert.Error(t, err)
+	assert.Equal(t, "found 2 problem(s) with ARNs in input.yml: registry a.example.com, secrets_manager_arn: first problem; ecr_repositories: second problem", err.Error())
+}
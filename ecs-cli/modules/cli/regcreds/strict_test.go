@@ -0,0 +1,79 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestStrictModeHook(t *testing.T) {
+	hook := &strictModeHook{}
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+
+	logger.Info("Creating role myRole")
+	logger.WithField(retryNoticeField, true).Warnf("Role %s was not found shortly after creation while tagging it; retrying in %s...", "myRole", time.Second)
+	assert.NoError(t, hook.err(), "Expected info logs and retry notices not to be counted")
+
+	logger.Warn("Permissions boundary is not applied to existing role myRole")
+	logger.Warn("No policy was generated, so no Sid map is written to sids.json.")
+	err := hook.err()
+	if assert.Error(t, err, "Expected the warnings to be treated as errors") {
+		assert.Contains(t, err.Error(), "2 warning(s)")
+		assert.Contains(t, err.Error(), "Permissions boundary is not applied", "Expected the first warning in the error")
+	}
+}
+
+func TestStrictModeHook_RetryWordingNotMatched(t *testing.T) {
+	hook := &strictModeHook{}
+	logger := log.New()
+	logger.Out = ioutil.Discard
+	logger.AddHook(hook)
+
+	logger.Warn("Throttled; retrying in 1s")
+	assert.Error(t, hook.err(), "Expected a warning without the retry field to be counted")
+}
+
+func TestStrictModeError_NotStrict(t *testing.T) {
+	c := cli.NewContext(cli.NewApp(), flag.NewFlagSet("ecs-cli", 0), nil)
+	log.Warn("Not counted without '--strict'")
+	assert.NoError(t, strictModeError(c))
+	assert.NoError(t, strictModeError(cli.NewContext(nil, flag.NewFlagSet("ecs-cli", 0), nil)))
+}
+
+func TestWithStrictMode_HookKeptOnContext(t *testing.T) {
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.Bool(flags.StrictFlag, true, "")
+	c := cli.NewContext(cli.NewApp(), flagSet, nil)
+	hooks := len(log.StandardLogger().Hooks[log.WarnLevel])
+
+	var errDuringRun error
+	WithStrictMode(func(c *cli.Context) {
+		retryNotice().Warn("Role myRole was not found; retrying")
+		errDuringRun = strictModeError(c)
+	})(c)
+
+	assert.NoError(t, errDuringRun, "Expected the retry notice not to be counted")
+	assert.Len(t, log.StandardLogger().Hooks[log.WarnLevel], hooks, "Expected the hook to be removed once the command finished")
+	assert.NoError(t, strictModeError(c), "Expected no hook once the command finished")
+}
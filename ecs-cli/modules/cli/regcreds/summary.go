@@ -0,0 +1,93 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const noneSummaryValue = "none"
+
+// bufferLogOutput holds log output in memory instead of writing it. If the command exits with a fatal error, the
+// buffered logs are written to the original output so no troubleshooting information is lost. The first returned
+// function discards the buffered logs and restores the original output; the second writes them to the original output
+// first, for a run which fails without exiting.
+func bufferLogOutput() (func(), func()) {
+	logger := log.StandardLogger()
+	originalOutput := logger.Out
+	buffer := &bytes.Buffer{}
+
+	log.SetOutput(buffer)
+	log.RegisterExitHandler(func() {
+		originalOutput.Write(buffer.Bytes())
+	})
+
+	restore := func() {
+		log.SetOutput(originalOutput)
+		buffer.Reset()
+	}
+	flush := func() {
+		originalOutput.Write(buffer.Bytes())
+		restore()
+	}
+	return restore, flush
+}
+
+// getRoleARNs returns the ARN of each role, in order. The ARN is only known for roles created by the run, so existing
+// roles are looked up.
+func getRoleARNs(roleResults []*ExecutionRoleResult, client iamClient.Client) ([]string, error) {
+	roleARNs := make([]string, 0, len(roleResults))
+	for _, roleResult := range roleResults {
+		if roleResult.RoleARN != "" {
+			roleARNs = append(roleARNs, roleResult.RoleARN)
+			continue
+		}
+		role, err := client.GetRole(roleResult.RoleName)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get ARN of role %s", roleResult.RoleName)
+		}
+		roleARNs = append(roleARNs, aws.StringValue(role.Arn))
+	}
+	return roleARNs, nil
+}
+
+// formatRunSummary returns a single line describing the outcome of 'registry-creds up'
+func formatRunSummary(roleResults []*ExecutionRoleResult, secretCount int, region string, elapsed time.Duration) string {
+	roles := noneSummaryValue + " (skipped)"
+	policyARN := noneSummaryValue
+
+	if len(roleResults) > 0 {
+		roleSummaries := make([]string, 0, len(roleResults))
+		for _, roleResult := range roleResults {
+			roleStatus := "reused"
+			if roleResult.RoleCreated {
+				roleStatus = "created"
+			}
+			roleSummaries = append(roleSummaries, fmt.Sprintf("%s (%s)", roleResult.RoleName, roleStatus))
+		}
+		roles = strings.Join(roleSummaries, ",")
+		policyARN = roleResults[0].PolicyARN
+	}
+
+	return fmt.Sprintf("role=%s policy=%s secrets=%d region=%s elapsed=%s",
+		roles, policyARN, secretCount, region, elapsed.Round(time.Millisecond))
+}
@@ -0,0 +1,159 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatRunSummary(t *testing.T) {
+	testCases := []struct {
+		description     string
+		roleResults     []*ExecutionRoleResult
+		expectedSummary string
+	}{
+		{
+			"New role",
+			[]*ExecutionRoleResult{{RoleName: "myRole", RoleCreated: true, PolicyARN: "arn:aws:iam::111111111111:policy/myPolicy"}},
+			"role=myRole (created) policy=arn:aws:iam::111111111111:policy/myPolicy secrets=2 region=us-west-2 elapsed=1.5s",
+		},
+		{
+			"Existing role",
+			[]*ExecutionRoleResult{{RoleName: "myRole", PolicyARN: "arn:aws:iam::111111111111:policy/myPolicy"}},
+			"role=myRole (reused) policy=arn:aws:iam::111111111111:policy/myPolicy secrets=2 region=us-west-2 elapsed=1.5s",
+		},
+		{
+			"Multiple roles",
+			[]*ExecutionRoleResult{
+				{RoleName: "webRole", RoleCreated: true, PolicyARN: "arn:aws:iam::111111111111:policy/myPolicy"},
+				{RoleName: "logRole", PolicyARN: "arn:aws:iam::111111111111:policy/myPolicy"},
+			},
+			"role=webRole (created),logRole (reused) policy=arn:aws:iam::111111111111:policy/myPolicy secrets=2 region=us-west-2 elapsed=1.5s",
+		},
+		{
+			"No role",
+			nil,
+			"role=none (skipped) policy=none secrets=2 region=us-west-2 elapsed=1.5s",
+		},
+	}
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			actualSummary := formatRunSummary(test.roleResults, 2, "us-west-2", 1500*time.Millisecond)
+			assert.Equal(t, test.expectedSummary, actualSummary)
+		})
+	}
+}
+
+func TestBufferLogOutput(t *testing.T) {
+	originalOutput := log.StandardLogger().Out
+	defer log.SetOutput(originalOutput)
+
+	testOutput := &bytes.Buffer{}
+	log.SetOutput(testOutput)
+
+	restoreLogOutput, _ := bufferLogOutput()
+	log.Info("this should be buffered")
+	assert.Empty(t, testOutput.String(), "Expected logs to be buffered")
+
+	restoreLogOutput()
+	log.Info("this should be written")
+	assert.NotContains(t, testOutput.String(), "this should be buffered")
+	assert.Contains(t, testOutput.String(), "this should be written")
+}
+
+func TestGetRoleARNs(t *testing.T) {
+	roleResults := []*ExecutionRoleResult{
+		{RoleName: "newRole", RoleCreated: true, RoleARN: "arn:aws:iam::111111111111:role/newRole"},
+		{RoleName: "existingRole"},
+	}
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(&iam.Role{Arn: aws.String("arn:aws:iam::111111111111:role/existingRole")}, nil)
+
+	roleARNs, err := getRoleARNs(roleResults, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error getting role ARNs")
+	assert.Equal(t, []string{"arn:aws:iam::111111111111:role/newRole", "arn:aws:iam::111111111111:role/existingRole"}, roleARNs)
+}
+
+func TestGetRoleARNs_ErrorOnGetRole(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "role not found", errors.New("something went wrong")))
+
+	_, err := getRoleARNs([]*ExecutionRoleResult{{RoleName: "existingRole"}}, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the role can't be found")
+}
+
+func TestWriteSummaryMarkdown(t *testing.T) {
+	roleResults := []*ExecutionRoleResult{
+		{
+			RoleName:    "newRole",
+			RoleCreated: true,
+			RoleARN:     "arn:aws:iam::111111111111:role/newRole",
+			Tags:        []*iam.Tag{{Key: aws.String("ManagedBy"), Value: aws.String("ecs-cli")}},
+		},
+		{RoleName: "existingRole"},
+	}
+	roleEntries := buildRoleOutputEntries(roleResults, getExecutionRolePolicyARN("us-west-2"))
+	creds := map[string]regcredio.CredsOutputEntry{
+		"myrepo.example.com": {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:myrepo"},
+	}
+	createTime := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(&iam.Role{Arn: aws.String("arn:aws:iam::111111111111:role/existingRole")}, nil)
+
+	store := regcredio.NewMemoryStore(nil)
+	err := writeSummaryMarkdown(store, "summary.md", roleEntries, roleResults, creds, "us-west-2", &createTime, mocks.MockIAM)
+	assert.NoError(t, err, "Unexpected error writing summary")
+
+	data, err := store.ReadFile("summary.md")
+	assert.NoError(t, err, "Expected summary to be written")
+	expectedRoles := []regcredio.SummaryRole{
+		{
+			RoleOutputEntry: roleEntries[0],
+			RoleARN:         "arn:aws:iam::111111111111:role/newRole",
+			Created:         true,
+			Tags:            map[string]string{"ManagedBy": "ecs-cli"},
+		},
+		{
+			RoleOutputEntry: roleEntries[1],
+			RoleARN:         "arn:aws:iam::111111111111:role/existingRole",
+			Tags:            map[string]string{},
+		},
+	}
+	assert.Equal(t, regcredio.FormatSummaryMarkdown(expectedRoles, creds, "us-west-2", createTime), string(data))
+}
+
+func TestWriteSummaryMarkdown_ErrorOnGetRole(t *testing.T) {
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().GetRole("existingRole").Return(nil, awserr.New(iam.ErrCodeNoSuchEntityException, "role not found", errors.New("something went wrong")))
+
+	store := regcredio.NewMemoryStore(nil)
+	roleResults := []*ExecutionRoleResult{{RoleName: "existingRole"}}
+	err := writeSummaryMarkdown(store, "summary.md", buildRoleOutputEntries(roleResults, getExecutionRolePolicyARN("us-west-2")), roleResults, nil, "us-west-2", nil, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the role ARN can't be looked up")
+
+	_, err = store.ReadFile("summary.md")
+	assert.Error(t, err, "Expected no summary to be written")
+}
@@ -0,0 +1,143 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TagConditionPrincipal denies the policy's access to a principal which doesn't have the tag
+	TagConditionPrincipal = "principal"
+	// TagConditionRequest denies the policy's access to a request which carries the tag with a different value
+	TagConditionRequest = "request"
+
+	principalTagConditionKeyPrefix = "aws:PrincipalTag/"
+	requestTagConditionKeyPrefix   = "aws:RequestTag/"
+
+	tagConditionSid = "DenyUnlessTagged"
+)
+
+// PolicyTagCondition adds a statement to the generated policy denying its actions unless the principal or request is
+// tagged with Key=Value
+type PolicyTagCondition struct {
+	Type  string `json:"type"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// parsePolicyTagCondition parses a value like "principal:Team=payments". Returns nil if the value is empty.
+func parsePolicyTagCondition(value string) (*PolicyTagCondition, error) {
+	if value == "" {
+		return nil, nil
+	}
+	invalidErr := fmt.Errorf("invalid value '%s' for '--%s'; expected the format %s:<key>=<value> or %s:<key>=<value>", value, flags.DenyUnlessTagFlag, TagConditionPrincipal, TagConditionRequest)
+
+	conditionType, tag := "", ""
+	if i := strings.Index(value, ":"); i >= 0 {
+		conditionType, tag = value[:i], value[i+1:]
+	}
+	if conditionType != TagConditionPrincipal && conditionType != TagConditionRequest {
+		return nil, invalidErr
+	}
+	i := strings.Index(tag, "=")
+	if i <= 0 || i == len(tag)-1 {
+		return nil, invalidErr
+	}
+	return &PolicyTagCondition{Type: conditionType, Key: tag[:i], Value: tag[i+1:]}, nil
+}
+
+// appendTagConditionStatement adds a statement denying every action granted by the statements, on all of their
+// resources, unless the tag condition is met. Since a deny only removes access, the statement can't grant anything
+// beyond the other statements.
+func appendTagConditionStatement(statements []StatementEntry, condition PolicyTagCondition) []StatementEntry {
+	usedSids := make(map[string]bool, len(statements))
+	actionSet := make(map[string]bool)
+	resourceSet := make(map[string]bool)
+	for _, statement := range statements {
+		usedSids[statement.Sid] = true
+		for _, action := range statement.Action {
+			actionSet[action] = true
+		}
+		for _, resource := range statement.Resource {
+			resourceSet[resource] = true
+		}
+	}
+
+	return append(statements, StatementEntry{
+		Sid:       uniqueSid(tagConditionSid, usedSids),
+		Effect:    "Deny",
+		Action:    sortedKeys(actionSet),
+		Resource:  sortedKeys(resourceSet),
+		Condition: condition.denyCondition(),
+	})
+}
+
+// denyCondition returns the condition under which the deny statement applies. A missing principal tag is denied.
+// Requests to read a secret or decrypt it carry no request tags, so for request tags only a request carrying the tag
+// with another value is denied; requiring the tag would deny all access.
+func (condition PolicyTagCondition) denyCondition() map[string]map[string]string {
+	if condition.Type == TagConditionRequest {
+		conditionKey := requestTagConditionKeyPrefix + condition.Key
+		return map[string]map[string]string{
+			"StringNotEquals": {conditionKey: condition.Value},
+			"Null":            {conditionKey: "false"},
+		}
+	}
+	return map[string]map[string]string{
+		"StringNotEquals": {principalTagConditionKeyPrefix + condition.Key: condition.Value},
+	}
+}
+
+// checkPrincipalTag returns an error if the role doesn't have the tag required by a principal tag condition, since the
+// generated policy would then deny the role the access it grants. A new role has the tags it was created with.
+func checkPrincipalTag(result *ExecutionRoleResult, condition PolicyTagCondition, newRoleTags []*iam.Tag, client iamClient.Client) error {
+	if condition.Type != TagConditionPrincipal {
+		return nil
+	}
+	roleTags := newRoleTags
+	if !result.RoleCreated {
+		tags, err := client.ListRoleTags(result.RoleName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list tags of role %s", result.RoleName)
+		}
+		roleTags = tags
+	}
+	for _, tag := range roleTags {
+		if aws.StringValue(tag.Key) == condition.Key {
+			if aws.StringValue(tag.Value) == condition.Value {
+				return nil
+			}
+			return fmt.Errorf("role %s has tag %s=%s, but '--%s' denies access unless it is %s; the role would be denied access to its registry credentials", result.RoleName, condition.Key, aws.StringValue(tag.Value), flags.DenyUnlessTagFlag, condition.Value)
+		}
+	}
+	return fmt.Errorf("role %s doesn't have tag %s=%s, which '--%s' requires; tag the role (new roles are tagged with '--%s') so that it isn't denied access to its registry credentials", result.RoleName, condition.Key, condition.Value, flags.DenyUnlessTagFlag, flags.ResourceTagsFlag)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,164 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePolicyTagCondition(t *testing.T) {
+	condition, err := parsePolicyTagCondition("")
+	assert.NoError(t, err)
+	assert.Nil(t, condition, "Expected no condition without a flag value")
+
+	condition, err = parsePolicyTagCondition("principal:Team=payments")
+	assert.NoError(t, err, "Unexpected error parsing principal tag condition")
+	assert.Equal(t, PolicyTagCondition{Type: TagConditionPrincipal, Key: "Team", Value: "payments"}, *condition)
+
+	// only the first '=' separates the key from the value, and keys may contain ':'
+	condition, err = parsePolicyTagCondition("request:aws:cloudformation:stack=a=b")
+	assert.NoError(t, err, "Unexpected error parsing request tag condition")
+	assert.Equal(t, PolicyTagCondition{Type: TagConditionRequest, Key: "aws:cloudformation:stack", Value: "a=b"}, *condition)
+
+	for _, value := range []string{"Team=payments", "resource:Team=payments", "principal:Team", "principal:=payments", "principal:Team="} {
+		_, err = parsePolicyTagCondition(value)
+		assert.Error(t, err, "Expected error parsing '%s'", value)
+	}
+}
+
+func TestAppendTagConditionStatement_PrincipalTag(t *testing.T) {
+	statements := []StatementEntry{
+		{Sid: "MyRegistry", Effect: "Allow", Action: []string{secretsGetValueAction}, Resource: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:a"}},
+		{Sid: "MyRegistryDecrypt", Effect: "Allow", Action: []string{kmsDecryptAction}, Resource: []string{"arn:aws:kms:us-west-2:111111111111:key/b"}},
+		{Sid: "OtherRegistry", Effect: "Allow", Action: []string{secretsGetValueAction}, Resource: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:c"}},
+	}
+
+	guarded := appendTagConditionStatement(statements, PolicyTagCondition{Type: TagConditionPrincipal, Key: "Team", Value: "payments"})
+	assert.Equal(t, statements, guarded[:3], "Expected the allow statements to be unchanged")
+	assert.Len(t, guarded, 4)
+	deny := guarded[3]
+	assert.Equal(t, tagConditionSid, deny.Sid)
+	assert.Equal(t, "Deny", deny.Effect)
+	assert.Equal(t, []string{kmsDecryptAction, secretsGetValueAction}, deny.Action)
+	assert.Equal(t, []string{"arn:aws:kms:us-west-2:111111111111:key/b", "arn:aws:secretsmanager:us-west-2:111111111111:secret:a", "arn:aws:secretsmanager:us-west-2:111111111111:secret:c"}, deny.Resource)
+	assert.Equal(t, map[string]map[string]string{"StringNotEquals": {"aws:PrincipalTag/Team": "payments"}}, deny.Condition)
+}
+
+func TestAppendTagConditionStatement_RequestTag(t *testing.T) {
+	statements := appendECRPullStatements(nil, nil)
+
+	guarded := appendTagConditionStatement(statements, PolicyTagCondition{Type: TagConditionRequest, Key: "Team", Value: "payments"})
+	deny := guarded[len(guarded)-1]
+	assert.Equal(t, []string{"*"}, deny.Resource)
+	// requests without the tag, such as reads, are never denied
+	assert.Equal(t, map[string]map[string]string{
+		"StringNotEquals": {"aws:RequestTag/Team": "payments"},
+		"Null":            {"aws:RequestTag/Team": "false"},
+	}, deny.Condition)
+}
+
+func TestAppendTagConditionStatement_UniqueSid(t *testing.T) {
+	statements := []StatementEntry{{Sid: tagConditionSid, Effect: "Allow", Action: []string{secretsGetValueAction}, Resource: []string{"*"}}}
+
+	guarded := appendTagConditionStatement(statements, PolicyTagCondition{Type: TagConditionPrincipal, Key: "Team", Value: "payments"})
+	assert.Equal(t, tagConditionSid+"2", guarded[1].Sid)
+}
+
+func TestCheckPrincipalTag(t *testing.T) {
+	condition := PolicyTagCondition{Type: TagConditionPrincipal, Key: "Team", Value: "payments"}
+	tagged := []*iam.Tag{{Key: aws.String("Team"), Value: aws.String("payments")}}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().ListRoleTags("myReusedRole").Return(tagged, nil),
+		mocks.MockIAM.EXPECT().ListRoleTags("myOtherReusedRole").Return([]*iam.Tag{{Key: aws.String("Team"), Value: aws.String("billing")}}, nil),
+		mocks.MockIAM.EXPECT().ListRoleTags("myUntaggedRole").Return(nil, nil),
+	)
+
+	// a new role has the tags it was created with
+	assert.NoError(t, checkPrincipalTag(&ExecutionRoleResult{RoleName: "myNewRole", RoleCreated: true}, condition, tagged, mocks.MockIAM))
+	assert.Error(t, checkPrincipalTag(&ExecutionRoleResult{RoleName: "myNewRole", RoleCreated: true}, condition, defaultManagementTags(), mocks.MockIAM))
+
+	assert.NoError(t, checkPrincipalTag(&ExecutionRoleResult{RoleName: "myReusedRole"}, condition, nil, mocks.MockIAM))
+	err := checkPrincipalTag(&ExecutionRoleResult{RoleName: "myOtherReusedRole"}, condition, nil, mocks.MockIAM)
+	assert.Error(t, err, "Expected error when the role's tag has a different value")
+	assert.Contains(t, err.Error(), "Team=billing")
+	assert.Error(t, checkPrincipalTag(&ExecutionRoleResult{RoleName: "myUntaggedRole"}, condition, nil, mocks.MockIAM))
+
+	// request tag conditions don't depend on the role's tags
+	assert.NoError(t, checkPrincipalTag(&ExecutionRoleResult{RoleName: "myUntaggedRole"}, PolicyTagCondition{Type: TagConditionRequest, Key: "Team", Value: "payments"}, nil, mocks.MockIAM))
+}
+
+func TestCreateTaskExecutionRole_WithTagCondition(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+	testPolicyArn := aws.String("arn:aws:iam::policy/" + testRoleName + "-policy")
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:a", "", []string{"test"}),
+	}
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::role/"+testRoleName, nil),
+		mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Do(func(input iam.CreatePolicyInput) {
+			var policy PolicyDocument
+			assert.NoError(t, json.Unmarshal([]byte(aws.StringValue(input.PolicyDocument)), &policy))
+			assert.Len(t, policy.Statement, 2)
+			assert.Equal(t, "Deny", policy.Statement[1].Effect)
+		}).Return(&iam.CreatePolicyOutput{Policy: &iam.Policy{Arn: testPolicyArn}}, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(getExecutionRolePolicyARN("us-west-2"), testRoleName).Return(nil, nil),
+		mocks.MockIAM.EXPECT().AttachRolePolicy(*testPolicyArn, testRoleName).Return(nil, nil),
+	)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:  testCreds,
+		RoleName:     testRoleName,
+		Region:       "us-west-2",
+		Tags:         map[string]*string{"Team": aws.String("payments")},
+		TagCondition: &PolicyTagCondition{Type: TagConditionPrincipal, Key: "Team", Value: "payments"},
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.NoError(t, err, "Unexpected error creating role with a tag condition")
+}
+
+func TestCreateTaskExecutionRole_ErrorOnRoleWithoutConditionTag(t *testing.T) {
+	testRoleName := "myNginxProjectRole"
+	testCreds := map[string]regcredio.CredsOutputEntry{
+		"myreg.test.io": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:a", "", []string{"test"}),
+	}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil),
+		mocks.MockIAM.EXPECT().ListRoleTags(testRoleName).Return(defaultManagementTags(), nil),
+	)
+	// the policy would lock the role out, so it isn't created
+	mocks.MockIAM.EXPECT().CreatePolicy(gomock.Any()).Times(0)
+
+	testParams := ExecutionRoleParams{
+		CredEntries:  testCreds,
+		RoleName:     testRoleName,
+		Region:       "us-west-2",
+		TagCondition: &PolicyTagCondition{Type: TagConditionPrincipal, Key: "Team", Value: "payments"},
+	}
+
+	_, err := CreateTaskExecutionRole(testParams, mocks.MockIAM, mocks.MockKMS)
+	assert.Error(t, err, "Expected error when the role doesn't have the required tag")
+}
@@ -0,0 +1,64 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// IAM limits on the tags of a role
+const (
+	maxTagsPerRole  = 50
+	maxTagKeyLength = 128
+)
+
+// validateTagLimits checks the tags a role is created with against the IAM limits on the number of tags and the
+// length of their keys and values, so that every problem is reported at once before any resources are created. The
+// reservedTags are the number of tags the ECS CLI adds to the role afterwards. Existing roles may already have other
+// tags, which are only counted by IAM.
+func validateTagLimits(tags map[string]*string, reservedTags int) error {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	if total := len(tags) + reservedTags; total > maxTagsPerRole {
+		if reservedTags > 0 {
+			problems = append(problems, fmt.Sprintf("%d tags are given and %d are added by the ECS CLI, but a role can have at most %d tags", len(tags), reservedTags, maxTagsPerRole))
+		} else {
+			problems = append(problems, fmt.Sprintf("%d tags are given, but a role can have at most %d tags", len(tags), maxTagsPerRole))
+		}
+	}
+	for _, key := range keys {
+		if key == "" {
+			problems = append(problems, "a tag key is empty")
+		} else if utf8.RuneCountInString(key) > maxTagKeyLength {
+			problems = append(problems, fmt.Sprintf("tag key '%s' is %d characters long, but can be at most %d", key, utf8.RuneCountInString(key), maxTagKeyLength))
+		}
+		if value := aws.StringValue(tags[key]); utf8.RuneCountInString(value) > maxTagValueLength {
+			problems = append(problems, fmt.Sprintf("the value of tag '%s' is %d characters long, but can be at most %d", key, utf8.RuneCountInString(value), maxTagValueLength))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s) with the role tags: %s", len(problems), strings.Join(problems, "; "))
+	}
+	return nil
+}
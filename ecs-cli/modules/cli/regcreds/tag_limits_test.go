@@ -0,0 +1,59 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTagLimits(t *testing.T) {
+	assert.NoError(t, validateTagLimits(nil, 0))
+	assert.NoError(t, validateTagLimits(map[string]*string{
+		strings.Repeat("k", maxTagKeyLength): aws.String(strings.Repeat("v", maxTagValueLength)),
+		"Team":                               aws.String(""),
+	}, 0))
+
+	err := validateTagLimits(map[string]*string{
+		strings.Repeat("k", maxTagKeyLength+1): aws.String("value"),
+		"Team":                                 aws.String(strings.Repeat("v", maxTagValueLength+1)),
+		"":                                     aws.String("value"),
+	}, 0)
+	assert.Error(t, err, "Expected error for tags over the length limits")
+	assert.Contains(t, err.Error(), "found 3 problem(s)", "Expected every problem to be reported")
+	assert.Contains(t, err.Error(), "tag 'Team'")
+
+	// multi-byte characters are counted once
+	assert.NoError(t, validateTagLimits(map[string]*string{"Owner": aws.String(strings.Repeat("é", maxTagValueLength))}, 0))
+}
+
+func TestValidateTagLimits_Count(t *testing.T) {
+	tags := make(map[string]*string, maxTagsPerRole)
+	for i := 0; i < maxTagsPerRole-1; i++ {
+		tags[fmt.Sprintf("key%d", i)] = aws.String("value")
+	}
+	assert.NoError(t, validateTagLimits(tags, 1))
+
+	err := validateTagLimits(tags, 2)
+	assert.Error(t, err, "Expected error when the reserved tags exceed the limit")
+	assert.Contains(t, err.Error(), "added by the ECS CLI")
+
+	tags["key-extra"] = aws.String("value")
+	tags["key-extra-2"] = aws.String("value")
+	assert.Error(t, validateTagLimits(tags, 0), "Expected error for too many tags")
+}
@@ -0,0 +1,144 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	orgsClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/organizations"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// effectiveTagPolicy is the content of an effective tag policy, in which the inheritance operators of the
+// organization's policies have already been applied
+type effectiveTagPolicy struct {
+	Tags map[string]tagPolicyEntry `json:"tags"`
+}
+
+// tagPolicyEntry standardizes a single tag. TagKey is its capitalization, and TagValue, if set, are its allowed values,
+// which may end with '*' to allow any suffix.
+type tagPolicyEntry struct {
+	TagKey      string   `json:"tag_key"`
+	TagValue    []string `json:"tag_value"`
+	EnforcedFor []string `json:"enforced_for"`
+}
+
+// enforceTagPolicy reads the effective tag policy of the account and fails if the tags of new roles don't comply
+// with it. Every problem is logged as a warning before the error is returned. If no tag policy applies to the account,
+// there is nothing to check.
+func enforceTagPolicy(tags map[string]string, client orgsClient.Client) error {
+	content, err := client.DescribeEffectivePolicy(orgsClient.PolicyTypeTagPolicy)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok {
+			switch aerr.Code() {
+			case orgsClient.ErrCodeEffectivePolicyNotFoundException:
+				log.Info("No tag policy applies to the account; the tags of new roles were not checked.")
+				return nil
+			case orgsClient.ErrCodeAWSOrganizationsNotInUseException:
+				return fmt.Errorf("'--%s' was specified, but the account is not a member of an organization", flags.EnforceTagPolicyFlag)
+			case orgsClient.ErrCodeAccessDeniedException:
+				return errors.Wrapf(err, "'--%s' requires the organizations:DescribeEffectivePolicy permission", flags.EnforceTagPolicyFlag)
+			}
+		}
+		return errors.Wrap(err, "failed to read the effective tag policy of the account")
+	}
+
+	policy := effectiveTagPolicy{}
+	if err = json.Unmarshal([]byte(content), &policy); err != nil {
+		return errors.Wrap(err, "failed to parse the effective tag policy of the account")
+	}
+	problems := findTagPolicyProblems(tags, policy)
+	if len(problems) == 0 {
+		log.Infof("The tags of new roles comply with the tag policy of the account (%d tags checked).", len(policy.Tags))
+		return nil
+	}
+	for _, problem := range problems {
+		log.Warn(problem)
+	}
+	return fmt.Errorf("the tags of new roles don't comply with the tag policy of the account (%d problem(s)); no resources were created", len(problems))
+}
+
+// findTagPolicyProblems returns, in order of tag key, each tag of the policy which is missing from the tags,
+// capitalized differently, or has a value the policy doesn't allow. Tags which are not in the policy are allowed.
+func findTagPolicyProblems(tags map[string]string, policy effectiveTagPolicy) []string {
+	policyKeys := make([]string, 0, len(policy.Tags))
+	for policyKey := range policy.Tags {
+		policyKeys = append(policyKeys, policyKey)
+	}
+	sort.Strings(policyKeys)
+
+	var problems []string
+	for _, policyKey := range policyKeys {
+		entry := policy.Tags[policyKey]
+		tagKey := entry.TagKey
+		if tagKey == "" {
+			tagKey = policyKey
+		}
+
+		// tag policies compare keys without regard to case
+		key, value, found := "", "", false
+		for k, v := range tags {
+			if strings.EqualFold(k, tagKey) {
+				key, value, found = k, v, true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("Tag '%s' is required by the tag policy, but is not applied to new roles; specify it with '--%s'", tagKey, flags.ResourceTagsFlag))
+			continue
+		}
+		if key != tagKey {
+			problems = append(problems, fmt.Sprintf("Tag '%s' must be capitalized as '%s' to comply with the tag policy", key, tagKey))
+		}
+		if len(entry.TagValue) > 0 && !tagValueAllowed(value, entry.TagValue) {
+			problems = append(problems, fmt.Sprintf("Value '%s' of tag '%s' is not allowed by the tag policy; allowed values are %s", value, key, strings.Join(entry.TagValue, ", ")))
+		}
+	}
+	return problems
+}
+
+// newRoleTags returns the tags new roles are created with: the tags given as flags merged with those of the role
+// bundle, and the management tag
+func newRoleTags(tags map[string]*string, bundle regcredio.RoleBundleEntry, managementTagKey, managementTagValue string) map[string]string {
+	params := ExecutionRoleParams{Tags: tags, ManagementTagKey: managementTagKey, ManagementTagValue: managementTagValue}
+	applyRoleBundle(&params, bundle)
+	roleTags := make(map[string]string, len(params.Tags)+1)
+	for key, value := range params.Tags {
+		roleTags[key] = aws.StringValue(value)
+	}
+	key, value := params.managementTag()
+	roleTags[key] = value
+	return roleTags
+}
+
+// tagValueAllowed returns whether the value is one of the allowed values, which may end with '*' to match any suffix
+func tagValueAllowed(value string, allowed []string) bool {
+	for _, allowedValue := range allowed {
+		if strings.HasSuffix(allowedValue, "*") && strings.HasPrefix(value, strings.TrimSuffix(allowedValue, "*")) {
+			return true
+		}
+		if value == allowedValue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+
+	mock_organizations "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/organizations/mock"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testEffectiveTagPolicy = `{"tags":{"costcenter":{"tag_key":"CostCenter","tag_value":["100","200*"]},"team":{"tag_key":"Team"}}}`
+
+func TestFindTagPolicyProblems(t *testing.T) {
+	policy := effectiveTagPolicy{Tags: map[string]tagPolicyEntry{
+		"costcenter": {TagKey: "CostCenter", TagValue: []string{"100", "200*"}},
+		"team":       {TagKey: "Team"},
+		"owner":      {},
+	}}
+
+	assert.Empty(t, findTagPolicyProblems(map[string]string{"CostCenter": "200-eu", "Team": "payments", "owner": "me", "Extra": "x"}, policy))
+
+	problems := findTagPolicyProblems(map[string]string{"costcenter": "300"}, policy)
+	assert.Equal(t, []string{
+		"Tag 'costcenter' must be capitalized as 'CostCenter' to comply with the tag policy",
+		"Value '300' of tag 'costcenter' is not allowed by the tag policy; allowed values are 100, 200*",
+		"Tag 'owner' is required by the tag policy, but is not applied to new roles; specify it with '--tags'",
+		"Tag 'Team' is required by the tag policy, but is not applied to new roles; specify it with '--tags'",
+	}, problems)
+}
+
+func TestEnforceTagPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_organizations.NewMockClient(ctrl)
+	client.EXPECT().DescribeEffectivePolicy("TAG_POLICY").Return(testEffectiveTagPolicy, nil).Times(2)
+
+	assert.NoError(t, enforceTagPolicy(map[string]string{"CostCenter": "100", "Team": "payments"}, client))
+	assert.Error(t, enforceTagPolicy(map[string]string{"Team": "payments"}, client), "Expected error when a tag of the policy is missing")
+}
+
+func TestEnforceTagPolicy_Errors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_organizations.NewMockClient(ctrl)
+
+	client.EXPECT().DescribeEffectivePolicy("TAG_POLICY").Return("", awserr.New("EffectivePolicyNotFoundException", "none", nil))
+	assert.NoError(t, enforceTagPolicy(nil, client), "Expected no error when no tag policy applies")
+
+	client.EXPECT().DescribeEffectivePolicy("TAG_POLICY").Return("", awserr.New("AWSOrganizationsNotInUseException", "no org", nil))
+	assert.Error(t, enforceTagPolicy(nil, client), "Expected error when the account is not in an organization")
+
+	client.EXPECT().DescribeEffectivePolicy("TAG_POLICY").Return("not json", nil)
+	assert.Error(t, enforceTagPolicy(nil, client), "Expected error for an invalid policy")
+}
+
+func TestNewRoleTags(t *testing.T) {
+	bundle := regcredio.RoleBundleEntry{Tags: map[string]string{"Team": "platform", "Owner": "me"}}
+	tags := newRoleTags(map[string]*string{"Team": aws.String("payments")}, bundle, "", "")
+	assert.Equal(t, map[string]string{"Team": "payments", "Owner": "me", DefaultManagementTagKey: DefaultManagementTagValue}, tags)
+
+	tags = newRoleTags(nil, regcredio.RoleBundleEntry{}, "provisioner", "regcreds")
+	assert.Equal(t, map[string]string{"provisioner": "regcreds"}, tags)
+}
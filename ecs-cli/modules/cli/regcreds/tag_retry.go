@@ -0,0 +1,139 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	iamClient "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// TagFailureFail makes 'up' fail if tags can't be applied
+	TagFailureFail = "fail"
+	// TagFailureWarn makes 'up' continue with a warning which lists the tags that were not applied
+	TagFailureWarn = "warn"
+)
+
+const (
+	// tagRetryWindow is how long after a role was created tagging it is retried if IAM doesn't find it yet
+	tagRetryWindow = 30 * time.Second
+	// tagRetryInterval is the delay between attempts to tag a role which was not found
+	tagRetryInterval = 2 * time.Second
+)
+
+// tagRetryClient wraps an IAM client so that tagging a role the client created is retried if the role is not found
+// within a short window after its creation. IAM is eventually consistent, so a new role may not be visible to TagRole
+// yet. Roles the client did not create are not retried, since a missing existing role is not expected to appear. It
+// is safe for concurrent use.
+type tagRetryClient struct {
+	iamClient.Client
+	window  time.Duration
+	mu      sync.Mutex
+	created map[string]time.Time
+	now     func() time.Time
+	sleep   func(time.Duration)
+}
+
+func newTagRetryClient(client iamClient.Client, window time.Duration) iamClient.Client {
+	return &tagRetryClient{Client: client, window: window, created: make(map[string]time.Time), now: time.Now, sleep: time.Sleep}
+}
+
+func (c *tagRetryClient) CreateOrFindRole(input iam.CreateRoleInput) (string, error) {
+	roleARN, err := c.Client.CreateOrFindRole(input)
+	if err == nil && roleARN != "" {
+		c.mu.Lock()
+		c.created[aws.StringValue(input.RoleName)] = c.now()
+		c.mu.Unlock()
+	}
+	return roleARN, err
+}
+
+func (c *tagRetryClient) createTime(roleName string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	createTime, ok := c.created[roleName]
+	return createTime, ok
+}
+
+func (c *tagRetryClient) TagRole(roleName string, tags []*iam.Tag) error {
+	retried := false
+	for {
+		err := c.Client.TagRole(roleName, tags)
+		createTime, created := c.createTime(roleName)
+		if err == nil || !isNoSuchEntity(err) || !created {
+			return err
+		}
+		remaining := c.window - c.now().Sub(createTime)
+		if remaining <= 0 {
+			if retried {
+				return errors.Wrapf(err, "role %s was still not found %s after it was created", roleName, c.window)
+			}
+			return err
+		}
+		delay := tagRetryInterval
+		if remaining < delay {
+			delay = remaining
+		}
+		retryNotice().Warnf("Role %s was not found shortly after creation while tagging it; retrying in %s...", roleName, delay)
+		c.sleep(delay)
+		retried = true
+	}
+}
+
+// sortedTagKeys returns the keys of the tags in order
+func sortedTagKeys(tags map[string]*string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func iamTagKeys(tags []*iam.Tag) []string {
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, aws.StringValue(tag.Key))
+	}
+	return keys
+}
+
+// validateTagFailureMode checks the value of '--on-tag-failure'
+func validateTagFailureMode(mode string) error {
+	switch mode {
+	case TagFailureFail, TagFailureWarn:
+		return nil
+	}
+	return fmt.Errorf("invalid value '%s' for '--%s'; valid values are %s and %s", mode, flags.OnTagFailureFlag, TagFailureFail, TagFailureWarn)
+}
+
+// handleTagFailure returns the error if tag failures should fail the command, or else logs a warning that the tags
+// were not applied and returns nil
+func handleTagFailure(mode string, err error, resource string, tagKeys []string) error {
+	if err == nil || mode != TagFailureWarn {
+		return err
+	}
+	log.Warnf("The tags %s were NOT applied to %s: %v", strings.Join(tagKeys, ", "), resource, err)
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTagRoleName = "myRole"
+
+// newTestTagRetryClient returns a client whose clock only advances when it sleeps
+func newTestTagRetryClient(mocks testClients, window time.Duration) (*tagRetryClient, *[]time.Duration) {
+	clock := time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	client := newTagRetryClient(mocks.MockIAM, window).(*tagRetryClient)
+	client.now = func() time.Time { return clock }
+	client.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		clock = clock.Add(d)
+	}
+	return client, &sleeps
+}
+
+func TestTagRetryClient_RetriesNoSuchEntityAfterCreate(t *testing.T) {
+	noSuchEntity := awserr.New(iam.ErrCodeNoSuchEntityException, "The role with name myRole cannot be found.", nil)
+	tags := []*iam.Tag{{Key: aws.String("Team"), Value: aws.String("payments")}}
+
+	mocks := setupTestController(t)
+	gomock.InOrder(
+		mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::111111111111:role/"+testTagRoleName, nil),
+		mocks.MockIAM.EXPECT().TagRole(testTagRoleName, tags).Return(noSuchEntity),
+		mocks.MockIAM.EXPECT().TagRole(testTagRoleName, tags).Return(nil),
+	)
+
+	client, sleeps := newTestTagRetryClient(mocks, 10*time.Second)
+	_, err := client.CreateOrFindRole(iam.CreateRoleInput{RoleName: aws.String(testTagRoleName)})
+	assert.NoError(t, err)
+
+	assert.NoError(t, client.TagRole(testTagRoleName, tags), "Expected tagging to succeed once the new role is visible")
+	assert.Equal(t, []time.Duration{tagRetryInterval}, *sleeps)
+}
+
+func TestTagRetryClient_ErrorAfterWindow(t *testing.T) {
+	noSuchEntity := awserr.New(iam.ErrCodeNoSuchEntityException, "The role with name myRole cannot be found.", nil)
+
+	mocks := setupTestController(t)
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("arn:aws:iam::111111111111:role/"+testTagRoleName, nil)
+	mocks.MockIAM.EXPECT().TagRole(testTagRoleName, gomock.Any()).Return(noSuchEntity).Times(3)
+
+	client, sleeps := newTestTagRetryClient(mocks, 3*time.Second)
+	_, err := client.CreateOrFindRole(iam.CreateRoleInput{RoleName: aws.String(testTagRoleName)})
+	assert.NoError(t, err)
+
+	err = client.TagRole(testTagRoleName, nil)
+	assert.Error(t, err, "Expected the role to still be missing")
+	assert.Contains(t, err.Error(), "still not found")
+	assert.Equal(t, []time.Duration{tagRetryInterval, time.Second}, *sleeps, "Expected the last delay to be capped by the window")
+}
+
+func TestTagRetryClient_NoRetryForExistingRole(t *testing.T) {
+	noSuchEntity := awserr.New(iam.ErrCodeNoSuchEntityException, "The role with name myRole cannot be found.", nil)
+
+	mocks := setupTestController(t)
+	// an existing role is not a new resource
+	mocks.MockIAM.EXPECT().CreateOrFindRole(gomock.Any()).Return("", nil)
+	mocks.MockIAM.EXPECT().TagRole(testTagRoleName, gomock.Any()).Return(noSuchEntity).Times(1)
+
+	client, sleeps := newTestTagRetryClient(mocks, 10*time.Second)
+	_, err := client.CreateOrFindRole(iam.CreateRoleInput{RoleName: aws.String(testTagRoleName)})
+	assert.NoError(t, err)
+
+	assert.Error(t, client.TagRole(testTagRoleName, nil))
+	assert.Empty(t, *sleeps)
+}
+
+func TestHandleTagFailure(t *testing.T) {
+	tagErr := errors.New("access denied")
+
+	assert.Equal(t, tagErr, handleTagFailure(TagFailureFail, tagErr, "role myRole", []string{"Team"}), "Expected the error to fail the command")
+	assert.NoError(t, handleTagFailure(TagFailureWarn, tagErr, "role myRole", []string{"Team"}), "Expected only a warning")
+	assert.NoError(t, handleTagFailure(TagFailureFail, nil, "role myRole", []string{"Team"}))
+
+	assert.NoError(t, validateTagFailureMode(TagFailureWarn))
+	assert.Error(t, validateTagFailureMode("ignore"))
+}
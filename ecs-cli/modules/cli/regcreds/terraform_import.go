@@ -0,0 +1,134 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// terraformImportStdout is the '--emit-terraform-import' value which prints the commands instead of writing a file
+const terraformImportStdout = "-"
+
+// Terraform identifiers may only contain letters, digits, underscores and dashes
+var invalidTerraformNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// writeTerraformImports writes (or, for terraformImportStdout, prints) the 'terraform import' commands for the
+// resources of the run
+func writeTerraformImports(store regcredio.Store, filename string, roleResults []*ExecutionRoleResult, creds map[string]regcredio.CredsOutputEntry, region string, createTime *time.Time) error {
+	imports := buildTerraformImports(roleResults, creds, region)
+	createdAt := time.Now().UTC()
+	if createTime != nil && !createTime.IsZero() {
+		createdAt = *createTime
+	}
+	if filename == terraformImportStdout {
+		fmt.Fprint(os.Stdout, regcredio.FormatTerraformImports(imports, createdAt))
+		return nil
+	}
+	if err := regcredio.WriteTerraformImportsTo(store, filename, imports, createdAt); err != nil {
+		return errors.Wrapf(err, "failed to write terraform import commands to %s", filename)
+	}
+	log.Infof("Wrote terraform import commands to %s", filename)
+	return nil
+}
+
+// buildTerraformImports returns an import for each role, its instance profile and policy attachments, the new policy,
+// and each secret created by the ECS CLI. Secrets given by ARN in the input file are managed elsewhere, so they are
+// left out.
+func buildTerraformImports(roleResults []*ExecutionRoleResult, creds map[string]regcredio.CredsOutputEntry, region string) []regcredio.TerraformImport {
+	usedNames := make(map[string]bool)
+	var imports []regcredio.TerraformImport
+	add := func(resourceType, name, id string) {
+		imports = append(imports, regcredio.TerraformImport{
+			ResourceType: resourceType,
+			Name:         uniqueTerraformName(resourceType, terraformName(name), usedNames),
+			ID:           id,
+		})
+	}
+
+	if len(roleResults) > 0 && roleResults[0].PolicyARN != "" {
+		add("aws_iam_policy", roleResults[0].PolicyName, roleResults[0].PolicyARN)
+	}
+	managedPolicyARN := getExecutionRolePolicyARN(region)
+	for _, result := range roleResults {
+		add("aws_iam_role", result.RoleName, result.RoleName)
+		if result.InstanceProfileARN != "" {
+			// the instance profile has the name of the role
+			add("aws_iam_instance_profile", result.RoleName, result.RoleName)
+		}
+		for _, policyARN := range result.AttachedPolicyARNs {
+			attachmentName := result.RoleName + "_" + policyNameFromARN(policyARN)
+			switch policyARN {
+			case managedPolicyARN:
+				attachmentName = result.RoleName + "_task_execution"
+			case result.PolicyARN:
+				attachmentName = result.RoleName + "_policy"
+			}
+			add("aws_iam_role_policy_attachment", attachmentName, result.RoleName+"/"+policyARN)
+		}
+	}
+
+	registryNames := make([]string, 0, len(creds))
+	for registryName := range creds {
+		registryNames = append(registryNames, registryName)
+	}
+	sort.Strings(registryNames)
+	for _, registryName := range registryNames {
+		secretARN := creds[registryName].CredentialARN
+		if isECSCLISecret(secretARN, registryName) {
+			add("aws_secretsmanager_secret", registryName, secretARN)
+		}
+	}
+	return imports
+}
+
+// isECSCLISecret returns whether the secret has the name the ECS CLI gives the secrets it creates for the registry
+func isECSCLISecret(secretARN, registryName string) bool {
+	parsedARN, err := arn.Parse(secretARN)
+	if err != nil || parsedARN.Service != "secretsmanager" {
+		return false
+	}
+	// Secrets Manager adds a random suffix to the name
+	return strings.HasPrefix(parsedARN.Resource, "secret:"+*generateECSResourceName(registryName)+"-")
+}
+
+// terraformName replaces the characters which are not valid in a Terraform identifier, which must also start with a
+// letter or underscore
+func terraformName(name string) string {
+	name = invalidTerraformNameChars.ReplaceAllString(name, "_")
+	if name == "" || !(name[0] == '_' || (name[0] >= 'A' && name[0] <= 'Z') || (name[0] >= 'a' && name[0] <= 'z')) {
+		name = "_" + name
+	}
+	return name
+}
+
+// uniqueTerraformName returns the name, with a number appended if another resource of the type already has it
+func uniqueTerraformName(resourceType, name string, usedNames map[string]bool) string {
+	candidate := name
+	for i := 2; usedNames[resourceType+"."+candidate]; i++ {
+		candidate = name + "_" + strconv.Itoa(i)
+	}
+	usedNames[resourceType+"."+candidate] = true
+	return candidate
+}
@@ -0,0 +1,84 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTerraformImports(t *testing.T) {
+	policyARN := "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-web-policy-20190601T000000Z"
+	xrayARN := "arn:aws:iam::aws:policy/AWSXRayDaemonWriteAccess"
+	managedARN := getExecutionRolePolicyARN("us-west-2")
+	roleResults := []*ExecutionRoleResult{
+		{
+			RoleName:           "web",
+			PolicyARN:          policyARN,
+			PolicyName:         "amazon-ecs-cli-setup-web-policy-20190601T000000Z",
+			AttachedPolicyARNs: []string{managedARN, policyARN, xrayARN},
+			InstanceProfileARN: "arn:aws:iam::111111111111:instance-profile/web",
+		},
+		{
+			RoleName:           "web.worker",
+			PolicyARN:          policyARN,
+			AttachedPolicyARNs: []string{managedARN, policyARN},
+		},
+	}
+	creds := map[string]regcredio.CredsOutputEntry{
+		"9registry.example.com": regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:amazon-ecs-cli-setup-9registry.example.com-VeDqXm", "", nil),
+		"given.example.com":     regcredio.BuildOutputEntry("arn:aws:secretsmanager:us-west-2:111111111111:secret:team-creds-AbCdEf", "", nil),
+	}
+
+	imports := buildTerraformImports(roleResults, creds, "us-west-2")
+	assert.Equal(t, []regcredio.TerraformImport{
+		{ResourceType: "aws_iam_policy", Name: "amazon-ecs-cli-setup-web-policy-20190601T000000Z", ID: policyARN},
+		{ResourceType: "aws_iam_role", Name: "web", ID: "web"},
+		{ResourceType: "aws_iam_instance_profile", Name: "web", ID: "web"},
+		{ResourceType: "aws_iam_role_policy_attachment", Name: "web_task_execution", ID: "web/" + managedARN},
+		{ResourceType: "aws_iam_role_policy_attachment", Name: "web_policy", ID: "web/" + policyARN},
+		{ResourceType: "aws_iam_role_policy_attachment", Name: "web_AWSXRayDaemonWriteAccess", ID: "web/" + xrayARN},
+		{ResourceType: "aws_iam_role", Name: "web_worker", ID: "web.worker"},
+		{ResourceType: "aws_iam_role_policy_attachment", Name: "web_worker_task_execution", ID: "web.worker/" + managedARN},
+		{ResourceType: "aws_iam_role_policy_attachment", Name: "web_worker_policy", ID: "web.worker/" + policyARN},
+		{ResourceType: "aws_secretsmanager_secret", Name: "_9registry_example_com", ID: creds["9registry.example.com"].CredentialARN},
+	}, imports)
+}
+
+func TestTerraformNames(t *testing.T) {
+	assert.Equal(t, "my-role_prod", terraformName("my-role.prod"))
+	assert.Equal(t, "_1role", terraformName("1role"))
+
+	usedNames := map[string]bool{}
+	assert.Equal(t, "web_prod", uniqueTerraformName("aws_iam_role", terraformName("web.prod"), usedNames))
+	assert.Equal(t, "web_prod_2", uniqueTerraformName("aws_iam_role", terraformName("web_prod"), usedNames))
+	assert.Equal(t, "web_prod", uniqueTerraformName("aws_iam_policy", "web_prod", usedNames), "Expected names to only be unique per resource type")
+}
+
+func TestWriteTerraformImports(t *testing.T) {
+	store := regcredio.NewMemoryStore(nil)
+	roleResults := []*ExecutionRoleResult{{RoleName: "web"}}
+	createTime := time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	err := writeTerraformImports(store, "imports/web.sh", roleResults, nil, "us-west-2", &createTime)
+	require.NoError(t, err, "Unexpected error writing terraform imports")
+	data, err := store.ReadFile("imports/web.sh")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Generated by 'ecs-cli registry-creds up' on 2019-06-01T00:00:00Z.")
+	assert.Contains(t, string(data), "terraform import 'aws_iam_role.web' 'web'\n")
+}
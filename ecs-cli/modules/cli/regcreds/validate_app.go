@@ -0,0 +1,245 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+const (
+	// SeverityError marks a finding which would make 'registry-creds up' fail
+	SeverityError = "error"
+	// SeverityWarning marks a finding which does not stop 'registry-creds up'
+	SeverityWarning = "warning"
+)
+
+// validationFinding is a single problem found in a credential input file. Entry is the registry name, and both Entry
+// and Field are empty for problems with the file as a whole.
+type validationFinding struct {
+	Severity string `json:"severity"`
+	Entry    string `json:"entry"`
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+}
+
+// Validate checks a credential input file without making any AWS requests, and exits with an error if any finding
+// has error severity, or any finding at all with '--strict'
+func Validate(c *cli.Context) {
+	args := c.Args()
+	if len(args) != 1 {
+		log.Fatal("Exactly 1 credential file is required. Found: ", len(args))
+	}
+	format := c.String(flags.FormatFlag)
+	if format != "" && format != TableOutputFormat && format != JSONOutputFormat && format != SARIFOutputFormat {
+		log.Fatalf("Error executing 'validate': invalid value '%s' for '--%s'; valid values are %s, %s and %s", format, flags.FormatFlag, TableOutputFormat, JSONOutputFormat, SARIFOutputFormat)
+	}
+
+	// only local sources are used so that no credentials are needed
+	region := config.RecursiveFlagSearch(c, flags.RegionFlag)
+	if region == "" {
+		region, _ = regionFromEnvVars()
+	}
+	// the account ID can't be looked up without credentials, so templates need it to be given
+	accountID := c.String(flags.AccountIDFlag)
+	if accountID != "" {
+		if err := validateAccountID(accountID); err != nil {
+			log.Fatal("Error executing 'validate': ", err)
+		}
+	}
+
+	var findings []validationFinding
+	credsInput, err := regcredio.ReadCredsInput(args[0])
+	if err != nil {
+		findings = []validationFinding{{Severity: SeverityError, Message: err.Error()}}
+	} else {
+		findings = findInputProblems(*credsInput, region)
+		if c.Bool(flags.StrictARNParsingFlag) {
+			findings = append(findings, findStrictARNProblems(*credsInput)...)
+		}
+	}
+	findings = append(findings, findPermissionsBoundaryProblems(c.String(flags.PermissionsBoundaryFlag), accountID, region)...)
+
+	switch format {
+	case JSONOutputFormat:
+		err = printFindingsJSON(findings, os.Stdout)
+	case SARIFOutputFormat:
+		// the content is only used to find the lines of the fields, so a file which can't be read has none
+		content, _ := ioutil.ReadFile(args[0])
+		err = printFindingsSARIF(findings, args[0], content, os.Stdout)
+	default:
+		err = printFindingsTable(findings, os.Stdout)
+	}
+	if err != nil {
+		log.Fatal("Error executing 'validate': ", err)
+	}
+
+	if errorCount := countFindings(findings, SeverityError); errorCount > 0 {
+		log.Fatalf("Error executing 'validate': found %d error(s) in %s", errorCount, args[0])
+	}
+	if warningCount := countFindings(findings, SeverityWarning); warningCount > 0 && c.Bool(flags.StrictFlag) {
+		log.Fatalf("Error executing 'validate': found %d warning(s) in %s, which are treated as errors with '--%s'", warningCount, args[0], flags.StrictFlag)
+	}
+}
+
+// findInputProblems returns every problem found in the input, in order of registry name. Checks which need AWS
+// requests, such as resolving KMS key aliases, are skipped; if no region is given, partitions are not checked.
+func findInputProblems(input regcredio.ECSRegCredsInput, region string) []validationFinding {
+	findings := []validationFinding{}
+	inputRegCreds := input.RegistryCredentials
+
+	if len(inputRegCreds) == 0 {
+		return append(findings, validationFinding{Severity: SeverityError, Field: "registry_credentials", Message: "provided credentials must contain at least one registry"})
+	}
+	if len(inputRegCreds) > maxContainersPerTaskDef {
+		findings = append(findings, validationFinding{Severity: SeverityError, Field: "registry_credentials", Message: fmt.Sprintf("no more than %d registry credential entries can be created at one time", maxContainersPerTaskDef)})
+	}
+
+	registryNames := make([]string, 0, len(inputRegCreds))
+	for registryName := range inputRegCreds {
+		registryNames = append(registryNames, registryName)
+	}
+	sort.Strings(registryNames)
+
+	namedContainers := make(map[string]bool)
+	// the first registry using each existing secret
+	secretRegistries := make(map[string]string)
+	for _, registryName := range registryNames {
+		credentialEntry := inputRegCreds[registryName]
+		addError := func(field, message string) {
+			findings = append(findings, validationFinding{Severity: SeverityError, Entry: registryName, Field: field, Message: message})
+		}
+
+		if !credentialEntry.HasRequiredFields() {
+			addError("secrets_manager_arn", fmt.Sprintf("missing required field(s) for registry %s; registry credentials should contain an existing secret ARN or username + password", registryName))
+		}
+		for _, container := range credentialEntry.ContainerNames {
+			if namedContainers[container] {
+				addError("container_names", fmt.Sprintf("container '%s' appears in more than one registry; container names must be unique across given registry credentials", container))
+			}
+			namedContainers[container] = true
+		}
+		if len(credentialEntry.ContainerNames) == 0 {
+			findings = append(findings, validationFinding{Severity: SeverityWarning, Entry: registryName, Field: "container_names", Message: fmt.Sprintf("No container names given for registry '%s'; output cannot be incorporated into a task definition when running 'compose' command", registryName)})
+		}
+		if err := validateSecretActions(registryName, credentialEntry.Actions); err != nil {
+			addError("actions", err.Error())
+		}
+		if err := validateRotationCompatible(registryName, credentialEntry.Actions, credentialEntry.RotationCompatible); err != nil {
+			addError("rotation_compatible", err.Error())
+		}
+		if err := validateSecretScope(registryName, credentialEntry.SecretScope, credentialEntry.Actions); err != nil {
+			addError("secret_scope", err.Error())
+		}
+
+		secretARN := credentialEntry.SecretManagerARN
+		if secretARN == "" {
+			continue
+		}
+		if !isARN(secretARN) {
+			addError("secrets_manager_arn", fmt.Sprintf("invalid secrets_manager_arn for registry %s", registryName))
+			continue
+		}
+		if firstRegistry, ok := secretRegistries[secretARN]; ok {
+			if field := inputEntryConflict(inputRegCreds[firstRegistry], credentialEntry); field != "" {
+				addError(field, duplicateSecretError(firstRegistry, registryName, secretARN, field).Error())
+			} else {
+				findings = append(findings, validationFinding{Severity: SeverityWarning, Entry: registryName, Field: "secrets_manager_arn", Message: fmt.Sprintf("Registry '%s' uses the same secret as registry '%s'; the two are granted access in the same policy statements", registryName, firstRegistry)})
+			}
+		} else {
+			secretRegistries[secretARN] = registryName
+		}
+		if credentialEntry.SecretScope != nil {
+			if _, err := scopedSecretResource(registryName, secretARN, *credentialEntry.SecretScope); err != nil {
+				addError("secret_scope", err.Error())
+			}
+		}
+		// the generated policy is only valid for resources in the partition of the region
+		if region != "" {
+			secretPartition := strings.Split(secretARN, ":")[1]
+			regionPartition := utils.GetPartition(region)
+			if secretPartition != regionPartition {
+				addError("secrets_manager_arn", fmt.Sprintf("partition of 'secrets_manager_arn'(%s) for registry %s does not match partition '%s' of region %s", secretPartition, registryName, regionPartition, region))
+			}
+		}
+		// key IDs and aliases can only be compared once they have been resolved to an ARN
+		if isARN(credentialEntry.KmsKeyID) {
+			if err := validateSameRegion(registryName, secretARN, credentialEntry.KmsKeyID); err != nil {
+				addError("kms_key_id", err.Error())
+			}
+		}
+	}
+	if err := validateECRRepositories(input.ECRRepositories); err != nil {
+		findings = append(findings, validationFinding{Severity: SeverityError, Field: "ecr_repositories", Message: err.Error()})
+	}
+	return findings
+}
+
+// validateSameRegion checks that the secret and its encryption key are in the same region
+func validateSameRegion(registryName, secretARN, keyARN string) error {
+	secretRegion := strings.Split(secretARN, ":")[3]
+	keyRegion := strings.Split(keyARN, ":")[3]
+
+	if secretRegion != keyRegion {
+		return fmt.Errorf("region of 'secrets_manager_arn'(%s) and 'kms_key_id'(%s) for registry %s do not match; secret and encryption key must be in same region", secretRegion, keyRegion, registryName)
+	}
+	return nil
+}
+
+func countFindings(findings []validationFinding, severity string) int {
+	count := 0
+	for _, finding := range findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+func printFindingsJSON(findings []validationFinding, w io.Writer) error {
+	data, err := json.MarshalIndent(findings, jsonPrefix, jsonIndent)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal findings to JSON")
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func printFindingsTable(findings []validationFinding, w io.Writer) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No problems found.")
+		return err
+	}
+	tw := new(tabwriter.Writer)
+	tw.Init(w, cellWidthInSpaces, widthBetweenCellsInSpaces, cellPaddingInSpaces, paddingCharacter, noFormatting)
+	fmt.Fprintln(tw, "SEVERITY\tENTRY\tFIELD\tMESSAGE")
+	for _, finding := range findings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", finding.Severity, finding.Entry, finding.Field, finding.Message)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,182 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindInputProblems_NoProblems(t *testing.T) {
+	input := regcredio.ECSRegCredsInput{
+		RegistryCredentials: regcredio.RegistryCreds{
+			"myrepo.someregistry.io": regcredio.RegistryCredEntry{
+				SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:myrepo",
+				KmsKeyID:         "arn:aws:kms:us-west-2:111111111111:key/123",
+				ContainerNames:   []string{"web"},
+			},
+		},
+	}
+
+	findings := findInputProblems(input, "us-west-2")
+	assert.Empty(t, findings, "Expected no findings for a valid input")
+}
+
+func TestFindInputProblems_AllFindingsReported(t *testing.T) {
+	input := regcredio.ECSRegCredsInput{
+		RegistryCredentials: regcredio.RegistryCreds{
+			"b.example.com": regcredio.RegistryCredEntry{
+				Username:       "user",
+				Password:       "pass",
+				ContainerNames: []string{"web"},
+				Actions:        []string{"s3:GetObject"},
+			},
+			"a.example.com": regcredio.RegistryCredEntry{
+				SecretManagerARN: "arn:aws-cn:secretsmanager:cn-north-1:111111111111:secret:a",
+				KmsKeyID:         "arn:aws-cn:kms:cn-northwest-1:111111111111:key/123",
+				ContainerNames:   []string{"web"},
+			},
+			"c.example.com": regcredio.RegistryCredEntry{
+				Username: "user",
+			},
+		},
+	}
+
+	findings := findInputProblems(input, "us-west-2")
+	expected := []struct {
+		severity string
+		entry    string
+		field    string
+	}{
+		{SeverityError, "a.example.com", "secrets_manager_arn"},
+		{SeverityError, "a.example.com", "kms_key_id"},
+		{SeverityError, "b.example.com", "container_names"},
+		{SeverityError, "b.example.com", "actions"},
+		{SeverityError, "c.example.com", "secrets_manager_arn"},
+		{SeverityWarning, "c.example.com", "container_names"},
+	}
+	assert.Len(t, findings, len(expected))
+	for i, finding := range findings {
+		if i >= len(expected) {
+			break
+		}
+		assert.Equal(t, expected[i].severity, finding.Severity, "Unexpected severity for finding %d", i)
+		assert.Equal(t, expected[i].entry, finding.Entry, "Unexpected entry for finding %d", i)
+		assert.Equal(t, expected[i].field, finding.Field, "Unexpected field for finding %d", i)
+		assert.NotEmpty(t, finding.Message)
+	}
+	assert.Equal(t, 5, countFindings(findings, SeverityError))
+	assert.Equal(t, 1, countFindings(findings, SeverityWarning))
+}
+
+func TestFindInputProblems_NoRegionSkipsPartitionCheck(t *testing.T) {
+	input := regcredio.ECSRegCredsInput{
+		RegistryCredentials: regcredio.RegistryCreds{
+			"myrepo.someregistry.io": regcredio.RegistryCredEntry{
+				SecretManagerARN: "arn:aws-cn:secretsmanager:cn-north-1:111111111111:secret:myrepo",
+				KmsKeyID:         "alias/myKey",
+				ContainerNames:   []string{"web"},
+			},
+		},
+	}
+
+	findings := findInputProblems(input, "")
+	assert.Empty(t, findings, "Expected no findings without a region or key ARN")
+}
+
+func TestFindInputProblems_InvalidECRRepository(t *testing.T) {
+	input := regcredio.ECSRegCredsInput{
+		RegistryCredentials: regcredio.RegistryCreds{
+			"myrepo.someregistry.io": regcredio.RegistryCredEntry{
+				SecretManagerARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:myrepo",
+				ContainerNames:   []string{"web"},
+			},
+		},
+		ECRRepositories: []string{"nginx"},
+	}
+
+	findings := findInputProblems(input, "us-west-2")
+	assert.Len(t, findings, 1)
+	assert.Equal(t, "ecr_repositories", findings[0].Field)
+	assert.Equal(t, SeverityError, findings[0].Severity)
+}
+
+func TestFindInputProblems_DuplicateSecret(t *testing.T) {
+	secretARN := "arn:aws:secretsmanager:us-west-2:111111111111:secret:shared"
+	keyARN := "arn:aws:kms:us-west-2:111111111111:key/123"
+	input := regcredio.ECSRegCredsInput{
+		RegistryCredentials: regcredio.RegistryCreds{
+			"a.example.com": regcredio.RegistryCredEntry{SecretManagerARN: secretARN, KmsKeyID: keyARN, ContainerNames: []string{"a"}},
+			// an exact duplicate, which is merged
+			"b.example.com": regcredio.RegistryCredEntry{SecretManagerARN: secretARN, KmsKeyID: keyARN, ContainerNames: []string{"b"}},
+			// an alias may name the same key, so it is only compared once resolved
+			"c.example.com": regcredio.RegistryCredEntry{SecretManagerARN: secretARN, KmsKeyID: "alias/myKey", ContainerNames: []string{"c"}},
+			"d.example.com": regcredio.RegistryCredEntry{SecretManagerARN: secretARN, KmsKeyID: keyARN, ContainerNames: []string{"d"}, Actions: []string{"secretsmanager:GetSecretValue"}},
+			"e.example.com": regcredio.RegistryCredEntry{SecretManagerARN: secretARN, ContainerNames: []string{"e"}},
+		},
+	}
+
+	findings := findInputProblems(input, "us-west-2")
+	expected := []struct {
+		severity string
+		entry    string
+		field    string
+	}{
+		{SeverityWarning, "b.example.com", "secrets_manager_arn"},
+		{SeverityWarning, "c.example.com", "secrets_manager_arn"},
+		{SeverityError, "d.example.com", "actions"},
+		{SeverityError, "e.example.com", "kms_key_id"},
+	}
+	if assert.Len(t, findings, len(expected)) {
+		for i, finding := range findings {
+			assert.Equal(t, expected[i].severity, finding.Severity, "Unexpected severity for finding %d", i)
+			assert.Equal(t, expected[i].entry, finding.Entry, "Unexpected entry for finding %d", i)
+			assert.Equal(t, expected[i].field, finding.Field, "Unexpected field for finding %d", i)
+			assert.Contains(t, finding.Message, "a.example.com", "Expected the finding to name the first registry")
+		}
+	}
+}
+
+func TestFindInputProblems_EmptyCreds(t *testing.T) {
+	findings := findInputProblems(regcredio.ECSRegCredsInput{}, "us-west-2")
+	assert.Len(t, findings, 1)
+	assert.Equal(t, SeverityError, findings[0].Severity)
+	assert.Equal(t, "registry_credentials", findings[0].Field)
+}
+
+func TestPrintFindingsJSON(t *testing.T) {
+	findings := []validationFinding{
+		{Severity: SeverityWarning, Entry: "myrepo.someregistry.io", Field: "container_names", Message: "No container names given"},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, printFindingsJSON(findings, &buf), "Unexpected error printing findings")
+
+	var printed []map[string]string
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &printed), "Expected findings to be a JSON array")
+	assert.Equal(t, []map[string]string{{
+		"severity": SeverityWarning,
+		"entry":    "myrepo.someregistry.io",
+		"field":    "container_names",
+		"message":  "No container names given",
+	}}, printed)
+
+	buf.Reset()
+	assert.NoError(t, printFindingsJSON([]validationFinding{}, &buf))
+	assert.Equal(t, "[]\n", buf.String(), "Expected an empty array when there are no findings")
+}
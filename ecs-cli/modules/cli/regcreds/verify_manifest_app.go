@@ -0,0 +1,40 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// VerifyManifest checks the detached signature of a manifest written by 'registry-creds up', so that a modified
+// manifest can be refused before it is used
+func VerifyManifest(c *cli.Context) {
+	manifestFile := c.String(flags.ManifestFlag)
+	if manifestFile == "" {
+		log.Fatalf("Error executing 'verify-manifest': no value specified for '--%s'", flags.ManifestFlag)
+	}
+	signingKey := c.String(flags.ManifestSigningKeyFlag)
+	if signingKey == "" {
+		log.Fatalf("Error executing 'verify-manifest': no value specified for '--%s'", flags.ManifestSigningKeyFlag)
+	}
+
+	client := signingKeyClient(c, signingKey, kms.NewKMSClient(getNewCommandConfig(c, "", "")))
+	if _, err := readVerifiedManifest(regcredio.FileStore{}, manifestFile, signingKey, client); err != nil {
+		log.Fatal("Error executing 'verify-manifest': ", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const webIdentitySessionNamePrefix = "ecs-cli-registry-creds-"
+
+type webIdentity struct {
+	TokenFile   string
+	RoleARN     string
+	SessionName string
+}
+
+// applyWebIdentityOverride replaces the credentials of the command's session with ones obtained by assuming a role
+// with a web identity token, if either of the web identity flags is given
+func applyWebIdentityOverride(c *cli.Context, commandConfig *config.CommandConfig) error {
+	identity, err := resolveWebIdentity(c.String(flags.WebIdentityTokenFileFlag), c.String(flags.WebIdentityRoleARNFlag))
+	if err != nil || identity == nil {
+		return err
+	}
+
+	creds := stscreds.NewWebIdentityCredentials(commandConfig.Session, identity.RoleARN, identity.SessionName, identity.TokenFile)
+	commandConfig.Session = commandConfig.Session.Copy(&aws.Config{Credentials: creds})
+	return nil
+}
+
+// resolveWebIdentity fills in a value not given with a flag from its environment variable. Returns nil if neither
+// flag is given, in which case the SDK picks up any web identity from the environment by itself.
+func resolveWebIdentity(tokenFile, roleARN string) (*webIdentity, error) {
+	if tokenFile == "" && roleARN == "" {
+		return nil, nil
+	}
+	if tokenFile == "" {
+		tokenFile = os.Getenv(flags.AWSWebIdentityTokenFileEnvVar)
+	}
+	if roleARN == "" {
+		roleARN = os.Getenv(flags.AWSRoleARNEnvVar)
+	}
+
+	if tokenFile == "" {
+		return nil, fmt.Errorf("no web identity token file specified; use '--%s' or set %s", flags.WebIdentityTokenFileFlag, flags.AWSWebIdentityTokenFileEnvVar)
+	}
+	if roleARN == "" {
+		return nil, fmt.Errorf("no role to assume with the web identity token specified; use '--%s' or set %s", flags.WebIdentityRoleARNFlag, flags.AWSRoleARNEnvVar)
+	}
+	if parsedARN, err := arn.Parse(roleARN); err != nil || parsedARN.Service != "iam" {
+		return nil, fmt.Errorf("'%s' is not a valid IAM role ARN", roleARN)
+	}
+	if _, err := os.Stat(tokenFile); err != nil {
+		return nil, errors.Wrap(err, "unable to read web identity token file")
+	}
+
+	sessionName := os.Getenv(flags.AWSRoleSessionNameEnvVar)
+	if sessionName == "" {
+		sessionName = webIdentitySessionNamePrefix + time.Now().UTC().Format(regcredio.ECSCredFileTimeFmt)
+	}
+
+	return &webIdentity{
+		TokenFile:   tokenFile,
+		RoleARN:     roleARN,
+		SessionName: sessionName,
+	}, nil
+}
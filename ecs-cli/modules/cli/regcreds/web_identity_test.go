@@ -0,0 +1,154 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcreds
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const testWebIdentityRoleARN = "arn:aws:iam::111111111111:role/ci-deploy"
+
+func TestResolveWebIdentity(t *testing.T) {
+	tokenFile := writeTestTokenFile(t)
+	defer os.Remove(tokenFile)
+	defer os.Unsetenv(flags.AWSWebIdentityTokenFileEnvVar)
+	defer os.Unsetenv(flags.AWSRoleARNEnvVar)
+	defer os.Unsetenv(flags.AWSRoleSessionNameEnvVar)
+
+	// neither flag given
+	identity, err := resolveWebIdentity("", "")
+	assert.NoError(t, err)
+	assert.Nil(t, identity, "Expected no override without flags")
+
+	identity, err = resolveWebIdentity(tokenFile, testWebIdentityRoleARN)
+	assert.NoError(t, err, "Unexpected error resolving web identity")
+	assert.Equal(t, tokenFile, identity.TokenFile)
+	assert.Equal(t, testWebIdentityRoleARN, identity.RoleARN)
+	assert.Contains(t, identity.SessionName, webIdentitySessionNamePrefix)
+
+	// missing values are taken from the environment
+	os.Setenv(flags.AWSRoleARNEnvVar, testWebIdentityRoleARN)
+	os.Setenv(flags.AWSRoleSessionNameEnvVar, "github-actions")
+	identity, err = resolveWebIdentity(tokenFile, "")
+	assert.NoError(t, err, "Unexpected error resolving web identity")
+	assert.Equal(t, testWebIdentityRoleARN, identity.RoleARN)
+	assert.Equal(t, "github-actions", identity.SessionName)
+
+	os.Setenv(flags.AWSWebIdentityTokenFileEnvVar, tokenFile)
+	identity, err = resolveWebIdentity("", "arn:aws:iam::111111111111:role/other")
+	assert.NoError(t, err, "Unexpected error resolving web identity")
+	assert.Equal(t, tokenFile, identity.TokenFile)
+	assert.Equal(t, "arn:aws:iam::111111111111:role/other", identity.RoleARN, "Expected flag to override environment")
+}
+
+func TestResolveWebIdentity_Errors(t *testing.T) {
+	tokenFile := writeTestTokenFile(t)
+	defer os.Remove(tokenFile)
+	os.Unsetenv(flags.AWSWebIdentityTokenFileEnvVar)
+	os.Unsetenv(flags.AWSRoleARNEnvVar)
+
+	_, err := resolveWebIdentity(tokenFile, "")
+	assert.Error(t, err, "Expected error without a role ARN")
+	_, err = resolveWebIdentity("", testWebIdentityRoleARN)
+	assert.Error(t, err, "Expected error without a token file")
+	_, err = resolveWebIdentity(tokenFile, "ci-deploy")
+	assert.Error(t, err, "Expected error on invalid role ARN")
+	_, err = resolveWebIdentity(tokenFile, "arn:aws:secretsmanager:us-west-2:111111111111:secret:ci-deploy")
+	assert.Error(t, err, "Expected error on non-IAM ARN")
+	_, err = resolveWebIdentity("/missing/token", testWebIdentityRoleARN)
+	assert.Error(t, err, "Expected error on missing token file")
+}
+
+func TestApplyWebIdentityOverride(t *testing.T) {
+	tokenFile := writeTestTokenFile(t)
+	defer os.Remove(tokenFile)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "AssumeRoleWithWebIdentity", r.Form.Get("Action"))
+		assert.Equal(t, testWebIdentityRoleARN, r.Form.Get("RoleArn"))
+		assert.Equal(t, "test-oidc-token", r.Form.Get("WebIdentityToken"))
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+	<AssumeRoleWithWebIdentityResult>
+		<Credentials>
+			<AccessKeyId>webIdentityAKID</AccessKeyId>
+			<SecretAccessKey>webIdentitySKID</SecretAccessKey>
+			<SessionToken>SESSION_TOKEN</SessionToken>
+			<Expiration>%s</Expiration>
+		</Credentials>
+	</AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, time.Now().Add(15*time.Minute).UTC().Format("2006-01-02T15:04:05Z"))
+	}))
+	defer server.Close()
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(server.URL),
+		DisableSSL:  aws.Bool(true),
+		Credentials: credentials.NewStaticCredentials("staticAKID", "staticSKID", ""),
+	})
+	assert.NoError(t, err, "Unexpected error creating session")
+	commandConfig := &config.CommandConfig{Session: sess}
+
+	flagSet := flag.NewFlagSet("ecs-cli", 0)
+	flagSet.String(flags.WebIdentityTokenFileFlag, tokenFile, "")
+	flagSet.String(flags.WebIdentityRoleARNFlag, testWebIdentityRoleARN, "")
+	context := cli.NewContext(nil, flagSet, nil)
+
+	err = applyWebIdentityOverride(context, commandConfig)
+	assert.NoError(t, err, "Unexpected error applying web identity override")
+
+	creds, err := commandConfig.Session.Config.Credentials.Get()
+	assert.NoError(t, err, "Unexpected error getting web identity credentials")
+	assert.Equal(t, "webIdentityAKID", creds.AccessKeyID)
+	assert.Equal(t, "webIdentitySKID", creds.SecretAccessKey)
+}
+
+func TestApplyWebIdentityOverride_NoFlags(t *testing.T) {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Credentials: credentials.NewStaticCredentials("staticAKID", "staticSKID", ""),
+	})
+	assert.NoError(t, err, "Unexpected error creating session")
+	commandConfig := &config.CommandConfig{Session: sess}
+
+	context := cli.NewContext(nil, flag.NewFlagSet("ecs-cli", 0), nil)
+	err = applyWebIdentityOverride(context, commandConfig)
+	assert.NoError(t, err)
+	assert.Equal(t, sess, commandConfig.Session, "Expected session to be unchanged")
+}
+
+func writeTestTokenFile(t *testing.T) string {
+	tokenFile, err := ioutil.TempFile("", "token")
+	assert.NoError(t, err, "Unexpected error creating token file")
+	_, err = tokenFile.WriteString("test-oidc-token")
+	assert.NoError(t, err, "Unexpected error writing token file")
+	assert.NoError(t, tokenFile.Close())
+	return tokenFile.Name()
+}
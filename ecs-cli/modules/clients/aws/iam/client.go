@@ -14,6 +14,8 @@
 package iam
 
 import (
+	"net/url"
+
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils"
@@ -24,10 +26,33 @@ import (
 
 // Client defines methods for interacting with the IAMAPI interface
 type Client interface {
+	AddRoleToInstanceProfile(profileName, roleName string) error
 	AttachRolePolicy(policyArn, roleName string) (*iam.AttachRolePolicyOutput, error)
+	CreateInstanceProfile(profileName, path string) (*iam.InstanceProfile, error)
 	CreateRole(iam.CreateRoleInput) (*iam.CreateRoleOutput, error)
 	CreatePolicy(iam.CreatePolicyInput) (*iam.CreatePolicyOutput, error)
-	CreateOrFindRole(string, string, string, []*iam.Tag) (string, error)
+	CreatePolicyVersion(policyArn, policyDocument string) (*iam.PolicyVersion, error)
+	CreateOrFindRole(iam.CreateRoleInput) (string, error)
+	DeleteInstanceProfile(profileName string) error
+	DeletePolicy(policyArn string) error
+	DeletePolicyVersion(policyArn, versionID string) error
+	DeleteRole(roleName string) error
+	DetachRolePolicy(policyArn, roleName string) error
+	GetInstanceProfile(profileName string) (*iam.InstanceProfile, error)
+	GetPolicy(policyArn string) (*iam.Policy, error)
+	GetPolicyDocument(policyArn string) (string, error)
+	GetRole(roleName string) (*iam.Role, error)
+	ListAttachedRolePolicies(roleName string) ([]*iam.AttachedPolicy, error)
+	ListEntitiesForPolicy(policyArn string) (*iam.ListEntitiesForPolicyOutput, error)
+	ListPoliciesPages(func(*iam.ListPoliciesOutput, bool) bool) error
+	ListPolicyVersions(policyArn string) ([]*iam.PolicyVersion, error)
+	ListRolesPages(func(*iam.ListRolesOutput, bool) bool) error
+	ListRoleTags(roleName string) ([]*iam.Tag, error)
+	RemoveRoleFromInstanceProfile(profileName, roleName string) error
+	SimulateCustomPolicy(iam.SimulateCustomPolicyInput) ([]*iam.EvaluationResult, error)
+	SimulatePrincipalPolicy(iam.SimulatePrincipalPolicyInput) ([]*iam.EvaluationResult, error)
+	TagRole(roleName string, tags []*iam.Tag) error
+	UntagRole(roleName string, tagKeys []string) error
 }
 
 type iamClient struct {
@@ -36,7 +61,7 @@ type iamClient struct {
 
 // NewIAMClient creates an instance of iamClient
 func NewIAMClient(config *config.CommandConfig) Client {
-	client := iam.New(config.Session)
+	client := iam.New(config.Session, clients.FullJitterRetryerConfig(config.Session.Config))
 	client.Handlers.Build.PushBackNamed(clients.CustomUserAgentHandler())
 
 	return newClient(client)
@@ -48,6 +73,16 @@ func newClient(client iamiface.IAMAPI) Client {
 	}
 }
 
+func (c *iamClient) AddRoleToInstanceProfile(profileName, roleName string) error {
+	request := iam.AddRoleToInstanceProfileInput{
+		InstanceProfileName: aws.String(profileName),
+		RoleName:            aws.String(roleName),
+	}
+
+	_, err := c.client.AddRoleToInstanceProfile(&request)
+	return err
+}
+
 func (c *iamClient) AttachRolePolicy(policyArn, roleName string) (*iam.AttachRolePolicyOutput, error) {
 	request := iam.AttachRolePolicyInput{
 		PolicyArn: aws.String(policyArn),
@@ -62,6 +97,23 @@ func (c *iamClient) AttachRolePolicy(policyArn, roleName string) (*iam.AttachRol
 	return output, nil
 }
 
+// CreateInstanceProfile creates an instance profile with no roles; path is optional
+func (c *iamClient) CreateInstanceProfile(profileName, path string) (*iam.InstanceProfile, error) {
+	request := iam.CreateInstanceProfileInput{
+		InstanceProfileName: aws.String(profileName),
+	}
+	if path != "" {
+		request.Path = aws.String(path)
+	}
+
+	output, err := c.client.CreateInstanceProfile(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.InstanceProfile, nil
+}
+
 func (c *iamClient) CreateRole(input iam.CreateRoleInput) (*iam.CreateRoleOutput, error) {
 	output, err := c.client.CreateRole(&input)
 	if err != nil {
@@ -80,16 +132,25 @@ func (c *iamClient) CreatePolicy(input iam.CreatePolicyInput) (*iam.CreatePolicy
 	return output, nil
 }
 
-// CreateOrFindRole returns a new role ARN or an empty string if role already exists
-func (c *iamClient) CreateOrFindRole(roleName, roleDescription, assumeRolePolicyDoc string, tags []*iam.Tag) (string, error) {
-	createRoleRequest := iam.CreateRoleInput{
-		AssumeRolePolicyDocument: aws.String(assumeRolePolicyDoc),
-		Description:              aws.String(roleDescription),
-		RoleName:                 aws.String(roleName),
+// CreatePolicyVersion adds a version with the given document to the policy and makes it the default version
+func (c *iamClient) CreatePolicyVersion(policyArn, policyDocument string) (*iam.PolicyVersion, error) {
+	request := iam.CreatePolicyVersionInput{
+		PolicyArn:      aws.String(policyArn),
+		PolicyDocument: aws.String(policyDocument),
+		SetAsDefault:   aws.Bool(true),
 	}
-	if len(tags) > 0 {
-		createRoleRequest.Tags = tags
+
+	output, err := c.client.CreatePolicyVersion(&request)
+	if err != nil {
+		return nil, err
 	}
+
+	return output.PolicyVersion, nil
+}
+
+// CreateOrFindRole returns a new role ARN or an empty string if role already exists. The input is only used to create
+// new roles; existing roles are not modified.
+func (c *iamClient) CreateOrFindRole(createRoleRequest iam.CreateRoleInput) (string, error) {
 	roleResult, err := c.CreateRole(createRoleRequest)
 	// if err is b/c role already exists, OK to continue
 	if err != nil && !utils.EntityAlreadyExists(err) {
@@ -106,3 +167,262 @@ func (c *iamClient) CreateOrFindRole(roleName, roleDescription, assumeRolePolicy
 
 	return newRoleString, nil
 }
+
+func (c *iamClient) DeleteInstanceProfile(profileName string) error {
+	request := iam.DeleteInstanceProfileInput{
+		InstanceProfileName: aws.String(profileName),
+	}
+
+	_, err := c.client.DeleteInstanceProfile(&request)
+	return err
+}
+
+func (c *iamClient) DeletePolicy(policyArn string) error {
+	request := iam.DeletePolicyInput{
+		PolicyArn: aws.String(policyArn),
+	}
+
+	_, err := c.client.DeletePolicy(&request)
+	return err
+}
+
+// DeletePolicyVersion deletes a version of the policy other than its default version
+func (c *iamClient) DeletePolicyVersion(policyArn, versionID string) error {
+	request := iam.DeletePolicyVersionInput{
+		PolicyArn: aws.String(policyArn),
+		VersionId: aws.String(versionID),
+	}
+
+	_, err := c.client.DeletePolicyVersion(&request)
+	return err
+}
+
+func (c *iamClient) DeleteRole(roleName string) error {
+	request := iam.DeleteRoleInput{
+		RoleName: aws.String(roleName),
+	}
+
+	_, err := c.client.DeleteRole(&request)
+	return err
+}
+
+func (c *iamClient) DetachRolePolicy(policyArn, roleName string) error {
+	request := iam.DetachRolePolicyInput{
+		PolicyArn: aws.String(policyArn),
+		RoleName:  aws.String(roleName),
+	}
+
+	_, err := c.client.DetachRolePolicy(&request)
+	return err
+}
+
+// GetInstanceProfile returns the named instance profile, including the roles added to it
+func (c *iamClient) GetInstanceProfile(profileName string) (*iam.InstanceProfile, error) {
+	request := iam.GetInstanceProfileInput{
+		InstanceProfileName: aws.String(profileName),
+	}
+
+	output, err := c.client.GetInstanceProfile(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.InstanceProfile, nil
+}
+
+func (c *iamClient) GetPolicy(policyArn string) (*iam.Policy, error) {
+	request := iam.GetPolicyInput{
+		PolicyArn: aws.String(policyArn),
+	}
+
+	output, err := c.client.GetPolicy(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Policy, nil
+}
+
+// GetPolicyDocument returns the decoded JSON document of the default version of the given policy
+func (c *iamClient) GetPolicyDocument(policyArn string) (string, error) {
+	policyOutput, err := c.client.GetPolicy(&iam.GetPolicyInput{
+		PolicyArn: aws.String(policyArn),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	versionOutput, err := c.client.GetPolicyVersion(&iam.GetPolicyVersionInput{
+		PolicyArn: aws.String(policyArn),
+		VersionId: policyOutput.Policy.DefaultVersionId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// policy documents are returned URL-encoded
+	return url.QueryUnescape(aws.StringValue(versionOutput.PolicyVersion.Document))
+}
+
+func (c *iamClient) GetRole(roleName string) (*iam.Role, error) {
+	request := iam.GetRoleInput{
+		RoleName: aws.String(roleName),
+	}
+
+	output, err := c.client.GetRole(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Role, nil
+}
+
+// ListAttachedRolePolicies returns the managed policies attached to the given role
+func (c *iamClient) ListAttachedRolePolicies(roleName string) ([]*iam.AttachedPolicy, error) {
+	request := iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String(roleName),
+	}
+
+	var policies []*iam.AttachedPolicy
+	for {
+		output, err := c.client.ListAttachedRolePolicies(&request)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, output.AttachedPolicies...)
+
+		if !aws.BoolValue(output.IsTruncated) {
+			return policies, nil
+		}
+		request.Marker = output.Marker
+	}
+}
+
+// ListPolicyVersions returns the versions of the given policy
+func (c *iamClient) ListPolicyVersions(policyArn string) ([]*iam.PolicyVersion, error) {
+	request := iam.ListPolicyVersionsInput{
+		PolicyArn: aws.String(policyArn),
+	}
+
+	var versions []*iam.PolicyVersion
+	for {
+		output, err := c.client.ListPolicyVersions(&request)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, output.Versions...)
+
+		if !aws.BoolValue(output.IsTruncated) {
+			return versions, nil
+		}
+		request.Marker = output.Marker
+	}
+}
+
+// ListEntitiesForPolicy returns the first page of users, groups and roles the given policy is attached to
+func (c *iamClient) ListEntitiesForPolicy(policyArn string) (*iam.ListEntitiesForPolicyOutput, error) {
+	request := iam.ListEntitiesForPolicyInput{
+		PolicyArn: aws.String(policyArn),
+	}
+	return c.client.ListEntitiesForPolicy(&request)
+}
+
+// ListPoliciesPages calls the given function with each page of customer managed policies in the account until it
+// returns false
+func (c *iamClient) ListPoliciesPages(fn func(*iam.ListPoliciesOutput, bool) bool) error {
+	return c.client.ListPoliciesPages(&iam.ListPoliciesInput{Scope: aws.String(iam.PolicyScopeTypeLocal)}, fn)
+}
+
+// ListRolesPages calls the given function with each page of roles in the account until it returns false
+func (c *iamClient) ListRolesPages(fn func(*iam.ListRolesOutput, bool) bool) error {
+	return c.client.ListRolesPages(&iam.ListRolesInput{}, fn)
+}
+
+// ListRoleTags returns the tags of the given role
+func (c *iamClient) ListRoleTags(roleName string) ([]*iam.Tag, error) {
+	request := iam.ListRoleTagsInput{
+		RoleName: aws.String(roleName),
+	}
+
+	var tags []*iam.Tag
+	for {
+		output, err := c.client.ListRoleTags(&request)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, output.Tags...)
+
+		if !aws.BoolValue(output.IsTruncated) {
+			return tags, nil
+		}
+		request.Marker = output.Marker
+	}
+}
+
+func (c *iamClient) RemoveRoleFromInstanceProfile(profileName, roleName string) error {
+	request := iam.RemoveRoleFromInstanceProfileInput{
+		InstanceProfileName: aws.String(profileName),
+		RoleName:            aws.String(roleName),
+	}
+
+	_, err := c.client.RemoveRoleFromInstanceProfile(&request)
+	return err
+}
+
+// SimulateCustomPolicy returns the decision of the IAM policy simulator for each action and resource of the input,
+// evaluated against the given policies only
+func (c *iamClient) SimulateCustomPolicy(input iam.SimulateCustomPolicyInput) ([]*iam.EvaluationResult, error) {
+	var results []*iam.EvaluationResult
+	for {
+		output, err := c.client.SimulateCustomPolicy(&input)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, output.EvaluationResults...)
+
+		if !aws.BoolValue(output.IsTruncated) {
+			return results, nil
+		}
+		input.Marker = output.Marker
+	}
+}
+
+// SimulatePrincipalPolicy returns the decision of the IAM policy simulator for each action and resource of the input,
+// evaluated against the policies of the principal along with any given policies
+func (c *iamClient) SimulatePrincipalPolicy(input iam.SimulatePrincipalPolicyInput) ([]*iam.EvaluationResult, error) {
+	var results []*iam.EvaluationResult
+	for {
+		output, err := c.client.SimulatePrincipalPolicy(&input)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, output.EvaluationResults...)
+
+		if !aws.BoolValue(output.IsTruncated) {
+			return results, nil
+		}
+		input.Marker = output.Marker
+	}
+}
+
+// TagRole adds the given tags to the role, replacing the values of any existing tags with the same keys
+func (c *iamClient) TagRole(roleName string, tags []*iam.Tag) error {
+	request := iam.TagRoleInput{
+		RoleName: aws.String(roleName),
+		Tags:     tags,
+	}
+
+	_, err := c.client.TagRole(&request)
+	return err
+}
+
+// UntagRole removes the tags with the given keys from the role; keys the role isn't tagged with are ignored
+func (c *iamClient) UntagRole(roleName string, tagKeys []string) error {
+	request := iam.UntagRoleInput{
+		RoleName: aws.String(roleName),
+		TagKeys:  aws.StringSlice(tagKeys),
+	}
+
+	_, err := c.client.UntagRole(&request)
+	return err
+}
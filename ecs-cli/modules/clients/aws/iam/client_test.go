@@ -19,6 +19,7 @@ import (
 
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/iam/mock/sdk"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -50,6 +51,173 @@ func TestAttachRolePolicy_ErrorCase(t *testing.T) {
 	assert.Error(t, err, "Unexpected error when Attaching Role Policy")
 }
 
+func TestAddRoleToInstanceProfile(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.AddRoleToInstanceProfileInput{
+		InstanceProfileName: aws.String(testRoleName),
+		RoleName:            aws.String(testRoleName),
+	}
+	mockIAM.EXPECT().AddRoleToInstanceProfile(&expectedInput).Return(&iam.AddRoleToInstanceProfileOutput{}, nil)
+
+	err := client.AddRoleToInstanceProfile(testRoleName, testRoleName)
+	assert.NoError(t, err, "Expected no error when adding role to instance profile")
+}
+
+func TestCreateInstanceProfile(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.CreateInstanceProfileInput{
+		InstanceProfileName: aws.String(testRoleName),
+		Path:                aws.String("/ecs/"),
+	}
+	expectedProfile := &iam.InstanceProfile{
+		Arn:                 aws.String("arn:aws:iam::111111111111:instance-profile/ecs/" + testRoleName),
+		InstanceProfileName: aws.String(testRoleName),
+	}
+	mockIAM.EXPECT().CreateInstanceProfile(&expectedInput).Return(&iam.CreateInstanceProfileOutput{InstanceProfile: expectedProfile}, nil)
+
+	profile, err := client.CreateInstanceProfile(testRoleName, "/ecs/")
+	assert.NoError(t, err, "Unexpected error when creating instance profile")
+	assert.Equal(t, expectedProfile, profile)
+}
+
+func TestCreateInstanceProfile_ErrorCase(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	mockIAM.EXPECT().CreateInstanceProfile(&iam.CreateInstanceProfileInput{InstanceProfileName: aws.String(testRoleName)}).Return(nil, errors.New("something went wrong"))
+
+	_, err := client.CreateInstanceProfile(testRoleName, "")
+	assert.Error(t, err, "Expected error when creating instance profile")
+}
+
+func TestGetInstanceProfile(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedProfile := &iam.InstanceProfile{
+		InstanceProfileName: aws.String(testRoleName),
+		Roles:               []*iam.Role{{RoleName: aws.String(testRoleName)}},
+	}
+	mockIAM.EXPECT().GetInstanceProfile(&iam.GetInstanceProfileInput{InstanceProfileName: aws.String(testRoleName)}).Return(&iam.GetInstanceProfileOutput{InstanceProfile: expectedProfile}, nil)
+
+	profile, err := client.GetInstanceProfile(testRoleName)
+	assert.NoError(t, err, "Unexpected error when getting instance profile")
+	assert.Equal(t, expectedProfile, profile)
+}
+
+func TestRemoveRoleFromInstanceProfile(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.RemoveRoleFromInstanceProfileInput{
+		InstanceProfileName: aws.String(testRoleName),
+		RoleName:            aws.String(testRoleName),
+	}
+	mockIAM.EXPECT().RemoveRoleFromInstanceProfile(&expectedInput).Return(&iam.RemoveRoleFromInstanceProfileOutput{}, nil)
+
+	err := client.RemoveRoleFromInstanceProfile(testRoleName, testRoleName)
+	assert.NoError(t, err, "Expected no error when removing role from instance profile")
+}
+
+func TestDeleteInstanceProfile(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	mockIAM.EXPECT().DeleteInstanceProfile(&iam.DeleteInstanceProfileInput{InstanceProfileName: aws.String(testRoleName)}).Return(&iam.DeleteInstanceProfileOutput{}, nil)
+
+	err := client.DeleteInstanceProfile(testRoleName)
+	assert.NoError(t, err, "Expected no error when deleting instance profile")
+}
+
+func TestDetachRolePolicy(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.DetachRolePolicyInput{
+		PolicyArn: aws.String(testPolicyArn),
+		RoleName:  aws.String(testRoleName),
+	}
+	mockIAM.EXPECT().DetachRolePolicy(&expectedInput).Return(&iam.DetachRolePolicyOutput{}, nil)
+
+	err := client.DetachRolePolicy(testPolicyArn, testRoleName)
+	assert.NoError(t, err, "Expected no error when Detaching Role Policy")
+}
+
+func TestDeletePolicy(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.DeletePolicyInput{
+		PolicyArn: aws.String(testPolicyArn),
+	}
+	mockIAM.EXPECT().DeletePolicy(&expectedInput).Return(&iam.DeletePolicyOutput{}, nil)
+
+	err := client.DeletePolicy(testPolicyArn)
+	assert.NoError(t, err, "Expected no error when Deleting Policy")
+}
+
+func TestCreatePolicyVersion(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.CreatePolicyVersionInput{
+		PolicyArn:      aws.String(testPolicyArn),
+		PolicyDocument: aws.String("{}"),
+		SetAsDefault:   aws.Bool(true),
+	}
+	mockIAM.EXPECT().CreatePolicyVersion(&expectedInput).Return(&iam.CreatePolicyVersionOutput{PolicyVersion: &iam.PolicyVersion{VersionId: aws.String("v2")}}, nil)
+
+	version, err := client.CreatePolicyVersion(testPolicyArn, "{}")
+	assert.NoError(t, err, "Expected no error when creating policy version")
+	assert.Equal(t, "v2", aws.StringValue(version.VersionId))
+}
+
+func TestDeletePolicyVersion(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.DeletePolicyVersionInput{
+		PolicyArn: aws.String(testPolicyArn),
+		VersionId: aws.String("v1"),
+	}
+	mockIAM.EXPECT().DeletePolicyVersion(&expectedInput).Return(&iam.DeletePolicyVersionOutput{}, nil)
+
+	err := client.DeletePolicyVersion(testPolicyArn, "v1")
+	assert.NoError(t, err, "Expected no error when deleting policy version")
+}
+
+func TestListPolicyVersions(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	gomock.InOrder(
+		mockIAM.EXPECT().ListPolicyVersions(&iam.ListPolicyVersionsInput{PolicyArn: aws.String(testPolicyArn)}).Return(&iam.ListPolicyVersionsOutput{
+			Versions:    []*iam.PolicyVersion{{VersionId: aws.String("v1")}},
+			IsTruncated: aws.Bool(true),
+			Marker:      aws.String("nextPage"),
+		}, nil),
+		mockIAM.EXPECT().ListPolicyVersions(&iam.ListPolicyVersionsInput{PolicyArn: aws.String(testPolicyArn), Marker: aws.String("nextPage")}).Return(&iam.ListPolicyVersionsOutput{
+			Versions: []*iam.PolicyVersion{{VersionId: aws.String("v2")}},
+		}, nil),
+	)
+
+	versions, err := client.ListPolicyVersions(testPolicyArn)
+	assert.NoError(t, err, "Expected no error when listing policy versions")
+	assert.Len(t, versions, 2)
+}
+
+func TestDeleteRole(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.DeleteRoleInput{
+		RoleName: aws.String(testRoleName),
+	}
+	mockIAM.EXPECT().DeleteRole(&expectedInput).Return(&iam.DeleteRoleOutput{}, nil)
+
+	err := client.DeleteRole(testRoleName)
+	assert.NoError(t, err, "Expected no error when Deleting Role")
+}
+
+func TestDeleteRole_ErrorCase(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	mockIAM.EXPECT().DeleteRole(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	err := client.DeleteRole(testRoleName)
+	assert.Error(t, err, "Expected error when Deleting Role")
+}
+
 func TestCreateRole(t *testing.T) {
 	mockIAM, client := setupTestController(t)
 
@@ -84,6 +252,30 @@ func TestCreateRole_ErrorCase(t *testing.T) {
 	assert.Error(t, err, "Expected error when Creating Role")
 }
 
+func TestCreateOrFindRole(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	expectedInput := iam.CreateRoleInput{
+		RoleName:                 aws.String(testRoleName),
+		AssumeRolePolicyDocument: aws.String("{}"),
+		PermissionsBoundary:      aws.String("arn:aws:iam::111111111111:policy/boundary"),
+	}
+	mockIAM.EXPECT().CreateRole(&expectedInput).Return(&iam.CreateRoleOutput{Role: &iam.Role{Arn: aws.String("arn:" + testRoleName)}}, nil)
+
+	roleARN, err := client.CreateOrFindRole(expectedInput)
+	assert.NoError(t, err, "Unexpected error when Creating Role")
+	assert.Equal(t, "arn:"+testRoleName, roleARN)
+}
+
+func TestCreateOrFindRole_RoleExists(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	mockIAM.EXPECT().CreateRole(gomock.Any()).Return(nil, awserr.New("EntityAlreadyExists", "role exists", nil))
+
+	roleARN, err := client.CreateOrFindRole(iam.CreateRoleInput{RoleName: aws.String(testRoleName)})
+	assert.NoError(t, err, "Expected no error when role already exists")
+	assert.Empty(t, roleARN)
+}
+
 func TestCreatePolicy(t *testing.T) {
 	mockIAM, client := setupTestController(t)
 
@@ -117,6 +309,146 @@ func TestCreatePolicy_ErrorCase(t *testing.T) {
 	assert.Error(t, err, "Expected error when Creating Policy")
 }
 
+func TestGetPolicyDocument(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	mockIAM.EXPECT().GetPolicy(&iam.GetPolicyInput{PolicyArn: aws.String(testPolicyArn)}).Return(&iam.GetPolicyOutput{
+		Policy: &iam.Policy{Arn: aws.String(testPolicyArn), DefaultVersionId: aws.String("v2")},
+	}, nil)
+	mockIAM.EXPECT().GetPolicyVersion(&iam.GetPolicyVersionInput{PolicyArn: aws.String(testPolicyArn), VersionId: aws.String("v2")}).Return(&iam.GetPolicyVersionOutput{
+		PolicyVersion: &iam.PolicyVersion{Document: aws.String("%7B%22Version%22%3A%222012-10-17%22%7D")},
+	}, nil)
+
+	document, err := client.GetPolicyDocument(testPolicyArn)
+	assert.NoError(t, err, "Unexpected error when getting policy document")
+	assert.Equal(t, `{"Version":"2012-10-17"}`, document)
+}
+
+func TestGetPolicyDocument_ErrorCase(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	mockIAM.EXPECT().GetPolicy(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	_, err := client.GetPolicyDocument(testPolicyArn)
+	assert.Error(t, err, "Expected error when getting policy document")
+}
+
+func TestListAttachedRolePolicies(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	firstPolicy := &iam.AttachedPolicy{PolicyArn: aws.String(testPolicyArn), PolicyName: aws.String("SomePolicy")}
+	secondPolicy := &iam.AttachedPolicy{PolicyArn: aws.String("arn:aws:iam:policy/OtherPolicy"), PolicyName: aws.String("OtherPolicy")}
+
+	gomock.InOrder(
+		mockIAM.EXPECT().ListAttachedRolePolicies(&iam.ListAttachedRolePoliciesInput{RoleName: aws.String(testRoleName)}).Return(&iam.ListAttachedRolePoliciesOutput{
+			AttachedPolicies: []*iam.AttachedPolicy{firstPolicy},
+			IsTruncated:      aws.Bool(true),
+			Marker:           aws.String("nextPage"),
+		}, nil),
+		mockIAM.EXPECT().ListAttachedRolePolicies(&iam.ListAttachedRolePoliciesInput{RoleName: aws.String(testRoleName), Marker: aws.String("nextPage")}).Return(&iam.ListAttachedRolePoliciesOutput{
+			AttachedPolicies: []*iam.AttachedPolicy{secondPolicy},
+			IsTruncated:      aws.Bool(false),
+		}, nil),
+	)
+
+	policies, err := client.ListAttachedRolePolicies(testRoleName)
+	assert.NoError(t, err, "Unexpected error when listing attached role policies")
+	assert.Equal(t, []*iam.AttachedPolicy{firstPolicy, secondPolicy}, policies)
+}
+
+func TestListRoleTags(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	firstTag := &iam.Tag{Key: aws.String("ManagedBy"), Value: aws.String("ecs-cli")}
+	secondTag := &iam.Tag{Key: aws.String("Owner"), Value: aws.String("Platform")}
+
+	gomock.InOrder(
+		mockIAM.EXPECT().ListRoleTags(&iam.ListRoleTagsInput{RoleName: aws.String(testRoleName)}).Return(&iam.ListRoleTagsOutput{
+			Tags:        []*iam.Tag{firstTag},
+			IsTruncated: aws.Bool(true),
+			Marker:      aws.String("nextPage"),
+		}, nil),
+		mockIAM.EXPECT().ListRoleTags(&iam.ListRoleTagsInput{RoleName: aws.String(testRoleName), Marker: aws.String("nextPage")}).Return(&iam.ListRoleTagsOutput{
+			Tags:        []*iam.Tag{secondTag},
+			IsTruncated: aws.Bool(false),
+		}, nil),
+	)
+
+	tags, err := client.ListRoleTags(testRoleName)
+	assert.NoError(t, err, "Unexpected error when listing role tags")
+	assert.Equal(t, []*iam.Tag{firstTag, secondTag}, tags)
+}
+
+func TestListRoleTags_ErrorCase(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	mockIAM.EXPECT().ListRoleTags(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	_, err := client.ListRoleTags(testRoleName)
+	assert.Error(t, err, "Expected error when listing role tags")
+}
+
+func TestGetPolicy(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	testPolicy := &iam.Policy{Arn: aws.String(testPolicyArn)}
+	mockIAM.EXPECT().GetPolicy(&iam.GetPolicyInput{PolicyArn: aws.String(testPolicyArn)}).Return(&iam.GetPolicyOutput{Policy: testPolicy}, nil)
+
+	policy, err := client.GetPolicy(testPolicyArn)
+	assert.NoError(t, err, "Unexpected error when getting policy")
+	assert.Equal(t, testPolicy, policy)
+}
+
+func TestGetRole(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	testRole := &iam.Role{RoleName: aws.String(testRoleName)}
+	mockIAM.EXPECT().GetRole(&iam.GetRoleInput{RoleName: aws.String(testRoleName)}).Return(&iam.GetRoleOutput{Role: testRole}, nil)
+
+	role, err := client.GetRole(testRoleName)
+	assert.NoError(t, err, "Unexpected error when getting role")
+	assert.Equal(t, testRole, role)
+}
+
+func TestGetRole_ErrorCase(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	mockIAM.EXPECT().GetRole(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	_, err := client.GetRole(testRoleName)
+	assert.Error(t, err, "Expected error when getting role")
+}
+
+func TestListEntitiesForPolicy(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	output := &iam.ListEntitiesForPolicyOutput{PolicyRoles: []*iam.PolicyRole{{RoleName: aws.String(testRoleName)}}}
+	mockIAM.EXPECT().ListEntitiesForPolicy(&iam.ListEntitiesForPolicyInput{PolicyArn: aws.String(testPolicyArn)}).Return(output, nil)
+
+	entities, err := client.ListEntitiesForPolicy(testPolicyArn)
+	assert.NoError(t, err, "Unexpected error when listing entities for policy")
+	assert.Equal(t, output, entities)
+}
+
+func TestListPoliciesPages(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	mockIAM.EXPECT().ListPoliciesPages(&iam.ListPoliciesInput{Scope: aws.String(iam.PolicyScopeTypeLocal)}, gomock.Any()).Return(nil)
+
+	err := client.ListPoliciesPages(func(*iam.ListPoliciesOutput, bool) bool { return true })
+	assert.NoError(t, err, "Unexpected error when listing policies")
+}
+
+func TestTagRole(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	tags := []*iam.Tag{{Key: aws.String("ManagedBy"), Value: aws.String("ecs-cli")}}
+	mockIAM.EXPECT().TagRole(&iam.TagRoleInput{RoleName: aws.String(testRoleName), Tags: tags}).Return(&iam.TagRoleOutput{}, nil)
+
+	err := client.TagRole(testRoleName, tags)
+	assert.NoError(t, err, "Unexpected error when tagging role")
+}
+
+func TestUntagRole(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+	mockIAM.EXPECT().UntagRole(&iam.UntagRoleInput{RoleName: aws.String(testRoleName), TagKeys: aws.StringSlice([]string{"Team"})}).Return(&iam.UntagRoleOutput{}, nil)
+
+	err := client.UntagRole(testRoleName, []string{"Team"})
+	assert.NoError(t, err, "Unexpected error when untagging role")
+}
+
 func setupTestController(t *testing.T) (*mock_iamiface.MockIAMAPI, Client) {
 	ctrl := gomock.NewController(t)
 	mockIAM := mock_iamiface.NewMockIAMAPI(ctrl)
@@ -124,3 +456,53 @@ func setupTestController(t *testing.T) (*mock_iamiface.MockIAMAPI, Client) {
 
 	return mockIAM, client
 }
+
+func TestSimulateCustomPolicy(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	input := iam.SimulateCustomPolicyInput{
+		PolicyInputList: aws.StringSlice([]string{"{}"}),
+		ActionNames:     aws.StringSlice([]string{"secretsmanager:GetSecretValue"}),
+	}
+	nextInput := input
+	nextInput.Marker = aws.String("nextPage")
+	gomock.InOrder(
+		mockIAM.EXPECT().SimulateCustomPolicy(&input).Return(&iam.SimulatePolicyResponse{
+			EvaluationResults: []*iam.EvaluationResult{{EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeAllowed)}},
+			IsTruncated:       aws.Bool(true),
+			Marker:            aws.String("nextPage"),
+		}, nil),
+		mockIAM.EXPECT().SimulateCustomPolicy(&nextInput).Return(&iam.SimulatePolicyResponse{
+			EvaluationResults: []*iam.EvaluationResult{{EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeImplicitDeny)}},
+		}, nil),
+	)
+
+	results, err := client.SimulateCustomPolicy(input)
+	assert.NoError(t, err, "Expected no error when simulating custom policy")
+	assert.Len(t, results, 2)
+}
+
+func TestSimulatePrincipalPolicy(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	input := iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String("arn:aws:iam::111111111111:role/" + testRoleName),
+		ActionNames:     aws.StringSlice([]string{"secretsmanager:GetSecretValue"}),
+	}
+	mockIAM.EXPECT().SimulatePrincipalPolicy(&input).Return(&iam.SimulatePolicyResponse{
+		EvaluationResults: []*iam.EvaluationResult{{EvalDecision: aws.String(iam.PolicyEvaluationDecisionTypeAllowed)}},
+	}, nil)
+
+	results, err := client.SimulatePrincipalPolicy(input)
+	assert.NoError(t, err, "Expected no error when simulating principal policy")
+	assert.Len(t, results, 1)
+}
+
+func TestSimulatePrincipalPolicy_ErrorCase(t *testing.T) {
+	mockIAM, client := setupTestController(t)
+
+	mockIAM.EXPECT().SimulatePrincipalPolicy(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	_, err := client.SimulatePrincipalPolicy(iam.SimulatePrincipalPolicyInput{})
+	assert.Error(t, err, "Expected error when simulating principal policy")
+}
@@ -47,6 +47,18 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 	return m.recorder
 }
 
+// AddRoleToInstanceProfile mocks base method
+func (m *MockClient) AddRoleToInstanceProfile(arg0, arg1 string) error {
+	ret := m.ctrl.Call(m, "AddRoleToInstanceProfile", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRoleToInstanceProfile indicates an expected call of AddRoleToInstanceProfile
+func (mr *MockClientMockRecorder) AddRoleToInstanceProfile(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRoleToInstanceProfile", reflect.TypeOf((*MockClient)(nil).AddRoleToInstanceProfile), arg0, arg1)
+}
+
 // AttachRolePolicy mocks base method
 func (m *MockClient) AttachRolePolicy(arg0, arg1 string) (*iam.AttachRolePolicyOutput, error) {
 	ret := m.ctrl.Call(m, "AttachRolePolicy", arg0, arg1)
@@ -60,17 +72,30 @@ func (mr *MockClientMockRecorder) AttachRolePolicy(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachRolePolicy", reflect.TypeOf((*MockClient)(nil).AttachRolePolicy), arg0, arg1)
 }
 
+// CreateInstanceProfile mocks base method
+func (m *MockClient) CreateInstanceProfile(arg0, arg1 string) (*iam.InstanceProfile, error) {
+	ret := m.ctrl.Call(m, "CreateInstanceProfile", arg0, arg1)
+	ret0, _ := ret[0].(*iam.InstanceProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInstanceProfile indicates an expected call of CreateInstanceProfile
+func (mr *MockClientMockRecorder) CreateInstanceProfile(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInstanceProfile", reflect.TypeOf((*MockClient)(nil).CreateInstanceProfile), arg0, arg1)
+}
+
 // CreateOrFindRole mocks base method
-func (m *MockClient) CreateOrFindRole(arg0, arg1, arg2 string, arg3 []*iam.Tag) (string, error) {
-	ret := m.ctrl.Call(m, "CreateOrFindRole", arg0, arg1, arg2, arg3)
+func (m *MockClient) CreateOrFindRole(arg0 iam.CreateRoleInput) (string, error) {
+	ret := m.ctrl.Call(m, "CreateOrFindRole", arg0)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateOrFindRole indicates an expected call of CreateOrFindRole
-func (mr *MockClientMockRecorder) CreateOrFindRole(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrFindRole", reflect.TypeOf((*MockClient)(nil).CreateOrFindRole), arg0, arg1, arg2, arg3)
+func (mr *MockClientMockRecorder) CreateOrFindRole(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrFindRole", reflect.TypeOf((*MockClient)(nil).CreateOrFindRole), arg0)
 }
 
 // CreatePolicy mocks base method
@@ -86,6 +111,19 @@ func (mr *MockClientMockRecorder) CreatePolicy(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePolicy", reflect.TypeOf((*MockClient)(nil).CreatePolicy), arg0)
 }
 
+// CreatePolicyVersion mocks base method
+func (m *MockClient) CreatePolicyVersion(arg0, arg1 string) (*iam.PolicyVersion, error) {
+	ret := m.ctrl.Call(m, "CreatePolicyVersion", arg0, arg1)
+	ret0, _ := ret[0].(*iam.PolicyVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePolicyVersion indicates an expected call of CreatePolicyVersion
+func (mr *MockClientMockRecorder) CreatePolicyVersion(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePolicyVersion", reflect.TypeOf((*MockClient)(nil).CreatePolicyVersion), arg0, arg1)
+}
+
 // CreateRole mocks base method
 func (m *MockClient) CreateRole(arg0 iam.CreateRoleInput) (*iam.CreateRoleOutput, error) {
 	ret := m.ctrl.Call(m, "CreateRole", arg0)
@@ -98,3 +136,253 @@ func (m *MockClient) CreateRole(arg0 iam.CreateRoleInput) (*iam.CreateRoleOutput
 func (mr *MockClientMockRecorder) CreateRole(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRole", reflect.TypeOf((*MockClient)(nil).CreateRole), arg0)
 }
+
+// DeleteInstanceProfile mocks base method
+func (m *MockClient) DeleteInstanceProfile(arg0 string) error {
+	ret := m.ctrl.Call(m, "DeleteInstanceProfile", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteInstanceProfile indicates an expected call of DeleteInstanceProfile
+func (mr *MockClientMockRecorder) DeleteInstanceProfile(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteInstanceProfile", reflect.TypeOf((*MockClient)(nil).DeleteInstanceProfile), arg0)
+}
+
+// DeletePolicy mocks base method
+func (m *MockClient) DeletePolicy(arg0 string) error {
+	ret := m.ctrl.Call(m, "DeletePolicy", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePolicy indicates an expected call of DeletePolicy
+func (mr *MockClientMockRecorder) DeletePolicy(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePolicy", reflect.TypeOf((*MockClient)(nil).DeletePolicy), arg0)
+}
+
+// DeletePolicyVersion mocks base method
+func (m *MockClient) DeletePolicyVersion(arg0, arg1 string) error {
+	ret := m.ctrl.Call(m, "DeletePolicyVersion", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePolicyVersion indicates an expected call of DeletePolicyVersion
+func (mr *MockClientMockRecorder) DeletePolicyVersion(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePolicyVersion", reflect.TypeOf((*MockClient)(nil).DeletePolicyVersion), arg0, arg1)
+}
+
+// DeleteRole mocks base method
+func (m *MockClient) DeleteRole(arg0 string) error {
+	ret := m.ctrl.Call(m, "DeleteRole", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRole indicates an expected call of DeleteRole
+func (mr *MockClientMockRecorder) DeleteRole(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRole", reflect.TypeOf((*MockClient)(nil).DeleteRole), arg0)
+}
+
+// DetachRolePolicy mocks base method
+func (m *MockClient) DetachRolePolicy(arg0, arg1 string) error {
+	ret := m.ctrl.Call(m, "DetachRolePolicy", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DetachRolePolicy indicates an expected call of DetachRolePolicy
+func (mr *MockClientMockRecorder) DetachRolePolicy(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachRolePolicy", reflect.TypeOf((*MockClient)(nil).DetachRolePolicy), arg0, arg1)
+}
+
+// GetInstanceProfile mocks base method
+func (m *MockClient) GetInstanceProfile(arg0 string) (*iam.InstanceProfile, error) {
+	ret := m.ctrl.Call(m, "GetInstanceProfile", arg0)
+	ret0, _ := ret[0].(*iam.InstanceProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstanceProfile indicates an expected call of GetInstanceProfile
+func (mr *MockClientMockRecorder) GetInstanceProfile(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceProfile", reflect.TypeOf((*MockClient)(nil).GetInstanceProfile), arg0)
+}
+
+// GetPolicy mocks base method
+func (m *MockClient) GetPolicy(arg0 string) (*iam.Policy, error) {
+	ret := m.ctrl.Call(m, "GetPolicy", arg0)
+	ret0, _ := ret[0].(*iam.Policy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPolicy indicates an expected call of GetPolicy
+func (mr *MockClientMockRecorder) GetPolicy(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicy", reflect.TypeOf((*MockClient)(nil).GetPolicy), arg0)
+}
+
+// GetPolicyDocument mocks base method
+func (m *MockClient) GetPolicyDocument(arg0 string) (string, error) {
+	ret := m.ctrl.Call(m, "GetPolicyDocument", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPolicyDocument indicates an expected call of GetPolicyDocument
+func (mr *MockClientMockRecorder) GetPolicyDocument(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPolicyDocument", reflect.TypeOf((*MockClient)(nil).GetPolicyDocument), arg0)
+}
+
+// GetRole mocks base method
+func (m *MockClient) GetRole(arg0 string) (*iam.Role, error) {
+	ret := m.ctrl.Call(m, "GetRole", arg0)
+	ret0, _ := ret[0].(*iam.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRole indicates an expected call of GetRole
+func (mr *MockClientMockRecorder) GetRole(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRole", reflect.TypeOf((*MockClient)(nil).GetRole), arg0)
+}
+
+// ListAttachedRolePolicies mocks base method
+func (m *MockClient) ListAttachedRolePolicies(arg0 string) ([]*iam.AttachedPolicy, error) {
+	ret := m.ctrl.Call(m, "ListAttachedRolePolicies", arg0)
+	ret0, _ := ret[0].([]*iam.AttachedPolicy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAttachedRolePolicies indicates an expected call of ListAttachedRolePolicies
+func (mr *MockClientMockRecorder) ListAttachedRolePolicies(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAttachedRolePolicies", reflect.TypeOf((*MockClient)(nil).ListAttachedRolePolicies), arg0)
+}
+
+// ListEntitiesForPolicy mocks base method
+func (m *MockClient) ListEntitiesForPolicy(arg0 string) (*iam.ListEntitiesForPolicyOutput, error) {
+	ret := m.ctrl.Call(m, "ListEntitiesForPolicy", arg0)
+	ret0, _ := ret[0].(*iam.ListEntitiesForPolicyOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntitiesForPolicy indicates an expected call of ListEntitiesForPolicy
+func (mr *MockClientMockRecorder) ListEntitiesForPolicy(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntitiesForPolicy", reflect.TypeOf((*MockClient)(nil).ListEntitiesForPolicy), arg0)
+}
+
+// ListPoliciesPages mocks base method
+func (m *MockClient) ListPoliciesPages(arg0 func(*iam.ListPoliciesOutput, bool) bool) error {
+	ret := m.ctrl.Call(m, "ListPoliciesPages", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListPoliciesPages indicates an expected call of ListPoliciesPages
+func (mr *MockClientMockRecorder) ListPoliciesPages(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPoliciesPages", reflect.TypeOf((*MockClient)(nil).ListPoliciesPages), arg0)
+}
+
+// ListPolicyVersions mocks base method
+func (m *MockClient) ListPolicyVersions(arg0 string) ([]*iam.PolicyVersion, error) {
+	ret := m.ctrl.Call(m, "ListPolicyVersions", arg0)
+	ret0, _ := ret[0].([]*iam.PolicyVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPolicyVersions indicates an expected call of ListPolicyVersions
+func (mr *MockClientMockRecorder) ListPolicyVersions(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPolicyVersions", reflect.TypeOf((*MockClient)(nil).ListPolicyVersions), arg0)
+}
+
+// ListRoleTags mocks base method
+func (m *MockClient) ListRoleTags(arg0 string) ([]*iam.Tag, error) {
+	ret := m.ctrl.Call(m, "ListRoleTags", arg0)
+	ret0, _ := ret[0].([]*iam.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoleTags indicates an expected call of ListRoleTags
+func (mr *MockClientMockRecorder) ListRoleTags(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoleTags", reflect.TypeOf((*MockClient)(nil).ListRoleTags), arg0)
+}
+
+// ListRolesPages mocks base method
+func (m *MockClient) ListRolesPages(arg0 func(*iam.ListRolesOutput, bool) bool) error {
+	ret := m.ctrl.Call(m, "ListRolesPages", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListRolesPages indicates an expected call of ListRolesPages
+func (mr *MockClientMockRecorder) ListRolesPages(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRolesPages", reflect.TypeOf((*MockClient)(nil).ListRolesPages), arg0)
+}
+
+// RemoveRoleFromInstanceProfile mocks base method
+func (m *MockClient) RemoveRoleFromInstanceProfile(arg0, arg1 string) error {
+	ret := m.ctrl.Call(m, "RemoveRoleFromInstanceProfile", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRoleFromInstanceProfile indicates an expected call of RemoveRoleFromInstanceProfile
+func (mr *MockClientMockRecorder) RemoveRoleFromInstanceProfile(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRoleFromInstanceProfile", reflect.TypeOf((*MockClient)(nil).RemoveRoleFromInstanceProfile), arg0, arg1)
+}
+
+// SimulateCustomPolicy mocks base method
+func (m *MockClient) SimulateCustomPolicy(arg0 iam.SimulateCustomPolicyInput) ([]*iam.EvaluationResult, error) {
+	ret := m.ctrl.Call(m, "SimulateCustomPolicy", arg0)
+	ret0, _ := ret[0].([]*iam.EvaluationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SimulateCustomPolicy indicates an expected call of SimulateCustomPolicy
+func (mr *MockClientMockRecorder) SimulateCustomPolicy(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SimulateCustomPolicy", reflect.TypeOf((*MockClient)(nil).SimulateCustomPolicy), arg0)
+}
+
+// SimulatePrincipalPolicy mocks base method
+func (m *MockClient) SimulatePrincipalPolicy(arg0 iam.SimulatePrincipalPolicyInput) ([]*iam.EvaluationResult, error) {
+	ret := m.ctrl.Call(m, "SimulatePrincipalPolicy", arg0)
+	ret0, _ := ret[0].([]*iam.EvaluationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SimulatePrincipalPolicy indicates an expected call of SimulatePrincipalPolicy
+func (mr *MockClientMockRecorder) SimulatePrincipalPolicy(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SimulatePrincipalPolicy", reflect.TypeOf((*MockClient)(nil).SimulatePrincipalPolicy), arg0)
+}
+
+// TagRole mocks base method
+func (m *MockClient) TagRole(arg0 string, arg1 []*iam.Tag) error {
+	ret := m.ctrl.Call(m, "TagRole", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TagRole indicates an expected call of TagRole
+func (mr *MockClientMockRecorder) TagRole(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TagRole", reflect.TypeOf((*MockClient)(nil).TagRole), arg0, arg1)
+}
+
+// UntagRole mocks base method
+func (m *MockClient) UntagRole(arg0 string, arg1 []string) error {
+	ret := m.ctrl.Call(m, "UntagRole", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UntagRole indicates an expected call of UntagRole
+func (mr *MockClientMockRecorder) UntagRole(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UntagRole", reflect.TypeOf((*MockClient)(nil).UntagRole), arg0, arg1)
+}
@@ -14,18 +14,31 @@
 package kms
 
 import (
+	"strings"
+
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
 )
 
+const defaultKeyPolicyName = "default"
+
+// the key ID of a multi-Region key starts with this prefix, and is shared by all of its replicas. The vendored SDK
+// doesn't return the MultiRegion field of the key metadata, so the key ID is all that identifies these keys.
+const multiRegionKeyIDPrefix = "mrk-"
+
 // Client defines methods for interacting with KMS
 type Client interface {
 	DescribeKey(keyID string) (*kms.DescribeKeyOutput, error)
+	GetKeyPolicy(keyID string) (string, error)
 	GetValidKeyARN(keyID string) (string, error)
+	ListAliases(keyID string) ([]string, error)
+	Sign(keyID string, digest []byte, signingAlgorithm string) ([]byte, error)
+	Verify(keyID string, digest, signature []byte, signingAlgorithm string) (bool, error)
 }
 
 type kmsClient struct {
@@ -34,7 +47,7 @@ type kmsClient struct {
 
 // NewKMSClient creates an instance of a kmsClient
 func NewKMSClient(config *config.CommandConfig) Client {
-	client := kms.New(config.Session)
+	client := kms.New(config.Session, clients.FullJitterRetryerConfig(config.Session.Config))
 	client.Handlers.Build.PushBackNamed(clients.CustomUserAgentHandler())
 
 	return newClient(client)
@@ -59,6 +72,21 @@ func (c *kmsClient) DescribeKey(keyID string) (*kms.DescribeKeyOutput, error) {
 	return output, nil
 }
 
+// GetKeyPolicy returns the policy document of the key; 'default' is the only policy name a key can have
+func (c *kmsClient) GetKeyPolicy(keyID string) (string, error) {
+	request := kms.GetKeyPolicyInput{
+		KeyId:      aws.String(keyID),
+		PolicyName: aws.String(defaultKeyPolicyName),
+	}
+
+	output, err := c.client.GetKeyPolicy(&request)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.Policy), nil
+}
+
 func (c *kmsClient) GetValidKeyARN(keyID string) (string, error) {
 	ARNString := ""
 
@@ -76,3 +104,71 @@ func (c *kmsClient) GetValidKeyARN(keyID string) (string, error) {
 	}
 	return ARNString, nil
 }
+
+// ListAliases returns the names of the aliases that refer to the given key
+func (c *kmsClient) ListAliases(keyID string) ([]string, error) {
+	request := kms.ListAliasesInput{
+		KeyId: aws.String(keyID),
+	}
+
+	var aliases []string
+	err := c.client.ListAliasesPages(&request, func(page *kms.ListAliasesOutput, lastPage bool) bool {
+		for _, alias := range page.Aliases {
+			aliases = append(aliases, aws.StringValue(alias.AliasName))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return aliases, nil
+}
+
+// Sign signs the digest of a message with an asymmetric key
+func (c *kmsClient) Sign(keyID string, digest []byte, signingAlgorithm string) ([]byte, error) {
+	request := kms.SignInput{
+		KeyId:            aws.String(keyID),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(signingAlgorithm),
+	}
+
+	output, err := c.client.Sign(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Signature, nil
+}
+
+// Verify checks the signature of the digest of a message. An invalid signature is reported as false, not an error.
+func (c *kmsClient) Verify(keyID string, digest, signature []byte, signingAlgorithm string) (bool, error) {
+	request := kms.VerifyInput{
+		KeyId:            aws.String(keyID),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		Signature:        signature,
+		SigningAlgorithm: aws.String(signingAlgorithm),
+	}
+
+	output, err := c.client.Verify(&request)
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == kms.ErrCodeKMSInvalidSignatureException {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return aws.BoolValue(output.SignatureValid), nil
+}
+
+// IsMultiRegionKeyARN returns whether the key ARN, as returned by GetValidKeyARN, is the ARN of a multi-Region key or
+// one of its replicas
+func IsMultiRegionKeyARN(keyARN string) bool {
+	parsed, err := arn.Parse(keyARN)
+	if err != nil || parsed.Service != kms.ServiceName {
+		return false
+	}
+	return strings.HasPrefix(parsed.Resource, "key/"+multiRegionKeyIDPrefix)
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/kms/mock/sdk"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/kms"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -53,6 +54,99 @@ func TestDescribeKey_ErrorCase(t *testing.T) {
 	assert.Error(t, err, "Expected error when Describing Key")
 }
 
+func TestGetKeyPolicy(t *testing.T) {
+	mockKMS, client := setupTestController(t)
+
+	testKeyID := "r6utfygh-677u-8765ytg00000"
+	mockKMS.EXPECT().GetKeyPolicy(&kms.GetKeyPolicyInput{
+		KeyId:      aws.String(testKeyID),
+		PolicyName: aws.String("default"),
+	}).Return(&kms.GetKeyPolicyOutput{Policy: aws.String(`{"Version":"2012-10-17"}`)}, nil)
+
+	policy, err := client.GetKeyPolicy(testKeyID)
+	assert.NoError(t, err, "Unexpected error when getting key policy")
+	assert.Equal(t, `{"Version":"2012-10-17"}`, policy)
+}
+
+func TestGetKeyPolicy_ErrorCase(t *testing.T) {
+	mockKMS, client := setupTestController(t)
+
+	mockKMS.EXPECT().GetKeyPolicy(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	_, err := client.GetKeyPolicy("r6utfygh-677u-8765ytg00000")
+	assert.Error(t, err, "Expected error when getting key policy")
+}
+
+func TestListAliases(t *testing.T) {
+	mockKMS, client := setupTestController(t)
+
+	testKeyID := "r6utfygh-677u-8765ytg00000"
+	mockKMS.EXPECT().ListAliasesPages(&kms.ListAliasesInput{KeyId: aws.String(testKeyID)}, gomock.Any()).Do(func(_ interface{}, x interface{}) {
+		fn := x.(func(*kms.ListAliasesOutput, bool) bool)
+		fn(&kms.ListAliasesOutput{Aliases: []*kms.AliasListEntry{{AliasName: aws.String("alias/registry-creds")}}}, false)
+		fn(&kms.ListAliasesOutput{Aliases: []*kms.AliasListEntry{{AliasName: aws.String("alias/shared")}}}, true)
+	}).Return(nil)
+
+	aliases, err := client.ListAliases(testKeyID)
+	assert.NoError(t, err, "Unexpected error when listing aliases")
+	assert.Equal(t, []string{"alias/registry-creds", "alias/shared"}, aliases)
+}
+
+func TestListAliases_ErrorCase(t *testing.T) {
+	mockKMS, client := setupTestController(t)
+
+	mockKMS.EXPECT().ListAliasesPages(gomock.Any(), gomock.Any()).Return(errors.New("something went wrong"))
+
+	_, err := client.ListAliases("r6utfygh-677u-8765ytg00000")
+	assert.Error(t, err, "Expected error when listing aliases")
+}
+
+func TestSign(t *testing.T) {
+	mockKMS, client := setupTestController(t)
+
+	testKeyID := "r6utfygh-677u-8765ytg00000"
+	digest := []byte("digest")
+	mockKMS.EXPECT().Sign(&kms.SignInput{
+		KeyId:            aws.String(testKeyID),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(kms.SigningAlgorithmSpecEcdsaSha256),
+	}).Return(&kms.SignOutput{Signature: []byte("signature")}, nil)
+
+	signature, err := client.Sign(testKeyID, digest, kms.SigningAlgorithmSpecEcdsaSha256)
+	assert.NoError(t, err, "Unexpected error when signing")
+	assert.Equal(t, []byte("signature"), signature)
+}
+
+func TestVerify(t *testing.T) {
+	mockKMS, client := setupTestController(t)
+
+	mockKMS.EXPECT().Verify(gomock.Any()).Return(&kms.VerifyOutput{SignatureValid: aws.Bool(true)}, nil)
+
+	valid, err := client.Verify("r6utfygh-677u-8765ytg00000", []byte("digest"), []byte("signature"), kms.SigningAlgorithmSpecEcdsaSha256)
+	assert.NoError(t, err, "Unexpected error when verifying")
+	assert.True(t, valid, "Expected signature to be valid")
+}
+
+func TestVerify_InvalidSignature(t *testing.T) {
+	mockKMS, client := setupTestController(t)
+
+	mockKMS.EXPECT().Verify(gomock.Any()).Return(nil, awserr.New(kms.ErrCodeKMSInvalidSignatureException, "invalid", nil))
+
+	valid, err := client.Verify("r6utfygh-677u-8765ytg00000", []byte("digest"), []byte("signature"), kms.SigningAlgorithmSpecEcdsaSha256)
+	assert.NoError(t, err, "Expected an invalid signature not to be an error")
+	assert.False(t, valid, "Expected signature to be invalid")
+}
+
+func TestVerify_ErrorCase(t *testing.T) {
+	mockKMS, client := setupTestController(t)
+
+	mockKMS.EXPECT().Verify(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	_, err := client.Verify("r6utfygh-677u-8765ytg00000", []byte("digest"), []byte("signature"), kms.SigningAlgorithmSpecEcdsaSha256)
+	assert.Error(t, err, "Expected error when verifying")
+}
+
 func setupTestController(t *testing.T) (*mock_kmsiface.MockKMSAPI, Client) {
 	ctrl := gomock.NewController(t)
 	mockKMS := mock_kmsiface.NewMockKMSAPI(ctrl)
@@ -60,3 +154,10 @@ func setupTestController(t *testing.T) (*mock_kmsiface.MockKMSAPI, Client) {
 
 	return mockKMS, client
 }
+
+func TestIsMultiRegionKeyARN(t *testing.T) {
+	assert.True(t, IsMultiRegionKeyARN("arn:aws:kms:us-west-2:111122223333:key/mrk-1234abcd12ab34cd56ef1234567890ab"))
+	assert.False(t, IsMultiRegionKeyARN("arn:aws:kms:us-west-2:111122223333:key/1234abcd-12ab-34cd-56ef-1234567890ab"))
+	assert.False(t, IsMultiRegionKeyARN("arn:aws:kms:us-west-2:111122223333:alias/mrk-alias"))
+	assert.False(t, IsMultiRegionKeyARN("mrk-1234abcd12ab34cd56ef1234567890ab"))
+}
@@ -60,6 +60,19 @@ func (mr *MockClientMockRecorder) DescribeKey(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeKey", reflect.TypeOf((*MockClient)(nil).DescribeKey), arg0)
 }
 
+// GetKeyPolicy mocks base method
+func (m *MockClient) GetKeyPolicy(arg0 string) (string, error) {
+	ret := m.ctrl.Call(m, "GetKeyPolicy", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeyPolicy indicates an expected call of GetKeyPolicy
+func (mr *MockClientMockRecorder) GetKeyPolicy(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyPolicy", reflect.TypeOf((*MockClient)(nil).GetKeyPolicy), arg0)
+}
+
 // GetValidKeyARN mocks base method
 func (m *MockClient) GetValidKeyARN(arg0 string) (string, error) {
 	ret := m.ctrl.Call(m, "GetValidKeyARN", arg0)
@@ -72,3 +85,42 @@ func (m *MockClient) GetValidKeyARN(arg0 string) (string, error) {
 func (mr *MockClientMockRecorder) GetValidKeyARN(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetValidKeyARN", reflect.TypeOf((*MockClient)(nil).GetValidKeyARN), arg0)
 }
+
+// ListAliases mocks base method
+func (m *MockClient) ListAliases(arg0 string) ([]string, error) {
+	ret := m.ctrl.Call(m, "ListAliases", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAliases indicates an expected call of ListAliases
+func (mr *MockClientMockRecorder) ListAliases(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAliases", reflect.TypeOf((*MockClient)(nil).ListAliases), arg0)
+}
+
+// Sign mocks base method
+func (m *MockClient) Sign(arg0 string, arg1 []byte, arg2 string) ([]byte, error) {
+	ret := m.ctrl.Call(m, "Sign", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sign indicates an expected call of Sign
+func (mr *MockClientMockRecorder) Sign(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sign", reflect.TypeOf((*MockClient)(nil).Sign), arg0, arg1, arg2)
+}
+
+// Verify mocks base method
+func (m *MockClient) Verify(arg0 string, arg1, arg2 []byte, arg3 string) (bool, error) {
+	ret := m.ctrl.Call(m, "Verify", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify
+func (mr *MockClientMockRecorder) Verify(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockClient)(nil).Verify), arg0, arg1, arg2, arg3)
+}
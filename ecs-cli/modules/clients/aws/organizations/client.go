@@ -0,0 +1,118 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package organizations
+
+import (
+	"net/http"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+// The SDK version vendored by the ECS CLI does not include the Organizations service client, so this package
+// implements the one operation the ECS CLI needs on top of the SDK's generic client.
+const (
+	serviceName  = "organizations"
+	apiVersion   = "2016-11-28"
+	targetPrefix = "AWSOrganizationsV20161128"
+	jsonVersion  = "1.1"
+
+	// PolicyTypeTagPolicy is the type of the organization's tag policies
+	PolicyTypeTagPolicy = "TAG_POLICY"
+
+	// ErrCodeAWSOrganizationsNotInUseException is returned when the account is not a member of an organization
+	ErrCodeAWSOrganizationsNotInUseException = "AWSOrganizationsNotInUseException"
+	// ErrCodeEffectivePolicyNotFoundException is returned when no policy of the type applies to the account
+	ErrCodeEffectivePolicyNotFoundException = "EffectivePolicyNotFoundException"
+	// ErrCodeAccessDeniedException is returned when the credentials are not allowed to read the effective policy
+	ErrCodeAccessDeniedException = "AccessDeniedException"
+)
+
+// Client defines methods for reading the policies of an organization
+type Client interface {
+	DescribeEffectivePolicy(policyType string) (string, error)
+}
+
+type organizationsClient struct {
+	client *client.Client
+}
+
+type describeEffectivePolicyInput struct {
+	PolicyType *string
+}
+
+type describeEffectivePolicyOutput struct {
+	EffectivePolicy *effectivePolicy
+}
+
+type effectivePolicy struct {
+	PolicyContent *string
+	PolicyType    *string
+	TargetId      *string
+}
+
+// NewOrganizationsClient creates an instance of an organizationsClient. It uses the session of the command config, so
+// credentials and endpoint overrides are resolved like those of the other clients; Organizations has a single global
+// endpoint in each partition.
+func NewOrganizationsClient(config *config.CommandConfig) Client {
+	cfg := config.Session.ClientConfig(serviceName)
+	signingName := cfg.SigningName
+	if signingName == "" {
+		signingName = serviceName
+	}
+	c := client.New(*cfg.Config, metadata.ClientInfo{
+		ServiceName:   serviceName,
+		ServiceID:     "Organizations",
+		APIVersion:    apiVersion,
+		PartitionID:   cfg.PartitionID,
+		Endpoint:      cfg.Endpoint,
+		SigningName:   signingName,
+		SigningRegion: cfg.SigningRegion,
+		JSONVersion:   jsonVersion,
+		TargetPrefix:  targetPrefix,
+	}, cfg.Handlers)
+
+	c.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	c.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	c.Handlers.Build.PushBackNamed(clients.CustomUserAgentHandler())
+	c.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	c.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	c.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+
+	return &organizationsClient{client: c}
+}
+
+// DescribeEffectivePolicy returns the JSON content of the effective policy of the given type for the caller's account
+func (c *organizationsClient) DescribeEffectivePolicy(policyType string) (string, error) {
+	op := &request.Operation{
+		Name:       "DescribeEffectivePolicy",
+		HTTPMethod: http.MethodPost,
+		HTTPPath:   "/",
+	}
+	output := &describeEffectivePolicyOutput{}
+	req := c.client.NewRequest(op, &describeEffectivePolicyInput{PolicyType: aws.String(policyType)}, output)
+	if err := req.Send(); err != nil {
+		return "", err
+	}
+	if output.EffectivePolicy == nil {
+		return "", nil
+	}
+	return aws.StringValue(output.EffectivePolicy.PolicyContent), nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package organizations
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeEffectivePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "AWSOrganizationsV20161128.DescribeEffectivePolicy", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "application/x-amz-json-1.1", r.Header.Get("Content-Type"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/organizations/aws4_request")
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"PolicyType":"TAG_POLICY"}`, string(body))
+		w.Write([]byte(`{"EffectivePolicy":{"PolicyContent":"{\"tags\":{}}","PolicyType":"TAG_POLICY","TargetId":"111111111111"}}`))
+	}))
+	defer server.Close()
+
+	content, err := newTestClient(server.URL).DescribeEffectivePolicy(PolicyTypeTagPolicy)
+	require.NoError(t, err, "Unexpected error describing effective policy")
+	assert.Equal(t, `{"tags":{}}`, content)
+}
+
+func TestDescribeEffectivePolicy_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amzn-Requestid", "request-1")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"EffectivePolicyNotFoundException","Message":"No effective policy found"}`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).DescribeEffectivePolicy(PolicyTypeTagPolicy)
+	require.Error(t, err, "Expected error describing effective policy")
+	reqErr, ok := err.(awserr.RequestFailure)
+	require.True(t, ok, "Expected a request failure")
+	assert.Equal(t, ErrCodeEffectivePolicyNotFoundException, reqErr.Code())
+	assert.Equal(t, "request-1", reqErr.RequestID())
+}
+
+func newTestClient(endpoint string) Client {
+	sess := session.Must(session.NewSession(&aws.Config{
+		// Organizations is signed for us-east-1 regardless of the region
+		Region:      aws.String("us-east-1"),
+		Endpoint:    aws.String(endpoint),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	return NewOrganizationsClient(&config.CommandConfig{Session: sess})
+}
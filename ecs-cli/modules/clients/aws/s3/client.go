@@ -0,0 +1,198 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/rest"
+)
+
+// The SDK version vendored by the ECS CLI does not include the S3 service client, so this package implements the one
+// operation the ECS CLI needs on top of the SDK's generic client.
+const (
+	serviceName = "s3"
+	apiVersion  = "2006-03-01"
+
+	// MaxObjectSize is the largest object GetObject reads; input files are expected to be small documents
+	MaxObjectSize = 10 * 1024 * 1024
+
+	// ErrCodeAccessDenied is returned when the credentials are not allowed to read the object
+	ErrCodeAccessDenied = "AccessDenied"
+	// ErrCodeNoSuchBucket is returned when the bucket does not exist
+	ErrCodeNoSuchBucket = "NoSuchBucket"
+	// ErrCodeNoSuchKey is returned when the bucket has no object with the key
+	ErrCodeNoSuchKey = "NoSuchKey"
+	// ErrCodeBucketRegionMismatch is returned when the bucket is in another region than the client
+	ErrCodeBucketRegionMismatch = "BucketRegionMismatch"
+	// ErrCodeObjectTooLarge is returned when the object is larger than MaxObjectSize
+	ErrCodeObjectTooLarge = "ObjectTooLarge"
+
+	bucketRegionHeader = "X-Amz-Bucket-Region"
+	requestIDHeader    = "X-Amz-Request-Id"
+)
+
+// buckets with these names can be addressed as a subdomain of the endpoint; names with dots would not match the
+// endpoint's TLS certificate, so they use path style addressing
+var virtualHostBucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+
+// Client defines methods for reading objects from S3
+type Client interface {
+	GetObject(bucket, key string) ([]byte, error)
+}
+
+type s3Client struct {
+	client *client.Client
+}
+
+type getObjectInput struct {
+	Bucket string
+	Key    string
+}
+
+type getObjectOutput struct {
+	Body []byte
+}
+
+// s3Error is the error document returned by S3
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+	Region  string `xml:"Region"`
+}
+
+// NewS3Client creates an instance of an s3Client. It uses the session of the command config, so credentials, region
+// and endpoint overrides are resolved like those of the other clients.
+func NewS3Client(config *config.CommandConfig) Client {
+	cfg := config.Session.ClientConfig(serviceName)
+	signingName := cfg.SigningName
+	if signingName == "" {
+		signingName = serviceName
+	}
+	c := client.New(*cfg.Config, metadata.ClientInfo{
+		ServiceName:   serviceName,
+		ServiceID:     "S3",
+		APIVersion:    apiVersion,
+		PartitionID:   cfg.PartitionID,
+		Endpoint:      cfg.Endpoint,
+		SigningName:   signingName,
+		SigningRegion: cfg.SigningRegion,
+	}, cfg.Handlers)
+
+	c.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	c.Handlers.Build.PushBackNamed(clients.CustomUserAgentHandler())
+	c.Handlers.Unmarshal.PushBackNamed(request.NamedHandler{Name: "ecscli.s3.Unmarshal", Fn: unmarshalBody})
+	c.Handlers.UnmarshalError.PushBackNamed(request.NamedHandler{Name: "ecscli.s3.UnmarshalError", Fn: unmarshalError})
+
+	return &s3Client{client: c}
+}
+
+// GetObject returns the contents of the object
+func (c *s3Client) GetObject(bucket, key string) ([]byte, error) {
+	op := &request.Operation{
+		Name:       "GetObject",
+		HTTPMethod: http.MethodGet,
+		HTTPPath:   "/",
+	}
+	output := &getObjectOutput{}
+	req := c.client.NewRequest(op, &getObjectInput{Bucket: bucket, Key: key}, output)
+	setObjectURL(req, bucket, key)
+
+	if err := req.Send(); err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+// setObjectURL sets the path, and the host for virtual hosted style requests, of the object's URL
+func setObjectURL(req *request.Request, bucket, key string) {
+	u := req.HTTPRequest.URL
+	objectPath := "/" + key
+	if aws.BoolValue(req.Config.S3ForcePathStyle) || !virtualHostBucketName.MatchString(bucket) {
+		objectPath = "/" + bucket + objectPath
+	} else {
+		u.Host = bucket + "." + u.Host
+	}
+	u.Path = objectPath
+	u.RawPath = rest.EscapePath(objectPath, false)
+}
+
+func unmarshalBody(r *request.Request) {
+	defer r.HTTPResponse.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(r.HTTPResponse.Body, MaxObjectSize+1))
+	if err != nil {
+		r.Error = awserr.NewRequestFailure(awserr.New(request.ErrCodeSerialization, "failed to read object", err),
+			r.HTTPResponse.StatusCode, r.RequestID)
+		return
+	}
+	if len(body) > MaxObjectSize {
+		r.Error = awserr.NewRequestFailure(awserr.New(ErrCodeObjectTooLarge,
+			fmt.Sprintf("the object is larger than %d bytes", MaxObjectSize), nil), r.HTTPResponse.StatusCode, r.RequestID)
+		return
+	}
+	r.Data.(*getObjectOutput).Body = body
+}
+
+// unmarshalError parses the error document of a failed request. Unlike the query protocol's error handler, it accepts
+// the <Error> root element used by S3, and falls back to the status code for responses without a body.
+func unmarshalError(r *request.Request) {
+	defer r.HTTPResponse.Body.Close()
+	r.RequestID = r.HTTPResponse.Header.Get(requestIDHeader)
+	statusCode := r.HTTPResponse.StatusCode
+
+	body, _ := ioutil.ReadAll(io.LimitReader(r.HTTPResponse.Body, 64*1024))
+	errDoc := s3Error{}
+	if len(bytes.TrimSpace(body)) == 0 || xml.Unmarshal(body, &errDoc) != nil || errDoc.Code == "" {
+		errDoc.Code = statusCodeError(statusCode)
+		errDoc.Message = http.StatusText(statusCode)
+	}
+
+	region := r.HTTPResponse.Header.Get(bucketRegionHeader)
+	if region == "" {
+		region = errDoc.Region
+	}
+	var err awserr.Error = awserr.New(errDoc.Code, errDoc.Message, nil)
+	if region != "" && region != r.ClientInfo.SigningRegion {
+		err = awserr.New(ErrCodeBucketRegionMismatch,
+			fmt.Sprintf("the bucket is in region %s, not %s", region, r.ClientInfo.SigningRegion), err)
+	}
+	r.Error = awserr.NewRequestFailure(err, statusCode, r.RequestID)
+}
+
+// statusCodeError returns the error code S3 uses for a status code, for responses which have no error document
+func statusCodeError(statusCode int) string {
+	switch statusCode {
+	case http.StatusForbidden:
+		return ErrCodeAccessDenied
+	case http.StatusNotFound:
+		return ErrCodeNoSuchKey
+	case http.StatusMovedPermanently:
+		return "PermanentRedirect"
+	}
+	return http.StatusText(statusCode)
+}
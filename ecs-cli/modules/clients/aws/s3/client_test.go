@@ -0,0 +1,190 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package s3
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetObject(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/my-bucket/creds/cred input.yml", r.URL.Path)
+		assert.Equal(t, "/my-bucket/creds/cred%20input.yml", r.URL.EscapedPath())
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-west-2/s3/aws4_request")
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+		w.Write([]byte("version: \"1\"\n"))
+	}))
+	defer server.Close()
+
+	data, err := newTestClient(server.URL).GetObject("my-bucket", "creds/cred input.yml")
+	require.NoError(t, err, "Unexpected error when getting object")
+	assert.Equal(t, "version: \"1\"\n", string(data))
+}
+
+func TestGetObject_ErrorDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(requestIDHeader, "request-1")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message><Key>creds.yml</Key></Error>`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).GetObject("my-bucket", "creds.yml")
+	require.Error(t, err, "Expected error when getting object")
+	reqErr, ok := err.(awserr.RequestFailure)
+	require.True(t, ok, "Expected a request failure")
+	assert.Equal(t, ErrCodeNoSuchKey, reqErr.Code())
+	assert.Equal(t, "The specified key does not exist.", reqErr.Message())
+	assert.Equal(t, http.StatusNotFound, reqErr.StatusCode())
+	assert.Equal(t, "request-1", reqErr.RequestID())
+}
+
+func TestGetObject_ErrorWithoutBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).GetObject("my-bucket", "creds.yml")
+	require.Error(t, err, "Expected error when getting object")
+	assert.Equal(t, ErrCodeAccessDenied, err.(awserr.Error).Code())
+}
+
+func TestGetObject_BucketInOtherRegion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(bucketRegionHeader, "eu-west-1")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`<Error><Code>AuthorizationHeaderMalformed</Code><Message>the region 'us-west-2' is wrong; expecting 'eu-west-1'</Message><Region>eu-west-1</Region></Error>`))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).GetObject("my-bucket", "creds.yml")
+	require.Error(t, err, "Expected error when getting object")
+	aerr := err.(awserr.Error)
+	assert.Equal(t, ErrCodeBucketRegionMismatch, aerr.Code())
+	assert.Equal(t, "the bucket is in region eu-west-1, not us-west-2", aerr.Message())
+	assert.Equal(t, "AuthorizationHeaderMalformed", aerr.OrigErr().(awserr.Error).Code())
+}
+
+func TestGetObject_TooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", MaxObjectSize+1)))
+	}))
+	defer server.Close()
+
+	_, err := newTestClient(server.URL).GetObject("my-bucket", "creds.yml")
+	require.Error(t, err, "Expected error when getting object")
+	assert.Equal(t, ErrCodeObjectTooLarge, err.(awserr.Error).Code())
+}
+
+func TestGetObject_RecordingRedactsBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("version: \"1\"\nregistry_credentials:\n  my-registry:\n    username: myUser\n    password: hunter2\n"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "ecs-cli-s3-recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	recorder, err := clients.NewRecorder(dir)
+	require.NoError(t, err)
+
+	sess := newTestSession(server.URL)
+	recorder.AddTo(&sess.Handlers)
+	data, err := NewS3Client(&config.CommandConfig{Session: sess}).GetObject("my-bucket", "creds.yml")
+	require.NoError(t, err, "Unexpected error when getting object")
+	assert.Contains(t, string(data), "hunter2", "Expected the object to be returned unredacted")
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	content, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"Body": "REDACTED"`)
+	assert.NotContains(t, string(content), "hunter2")
+	assert.Contains(t, string(content), `"Key": "creds.yml"`)
+}
+
+func TestSetObjectURL(t *testing.T) {
+	testCases := map[string]struct {
+		bucket       string
+		forcePath    bool
+		expectedHost string
+		expectedPath string
+	}{
+		"virtual hosted style": {
+			bucket:       "my-bucket",
+			expectedHost: "my-bucket.s3.us-west-2.amazonaws.com",
+			expectedPath: "/creds.yml",
+		},
+		"bucket name with dots": {
+			bucket:       "my.bucket",
+			expectedHost: "s3.us-west-2.amazonaws.com",
+			expectedPath: "/my.bucket/creds.yml",
+		},
+		"path style forced": {
+			bucket:       "my-bucket",
+			forcePath:    true,
+			expectedHost: "s3.us-west-2.amazonaws.com",
+			expectedPath: "/my-bucket/creds.yml",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			sess := session.Must(session.NewSession(&aws.Config{
+				Region:           aws.String("us-west-2"),
+				Credentials:      credentials.NewStaticCredentials("id", "secret", ""),
+				S3ForcePathStyle: aws.Bool(tc.forcePath),
+			}))
+			c := NewS3Client(&config.CommandConfig{Session: sess}).(*s3Client)
+			req := c.client.NewRequest(&request.Operation{Name: "GetObject", HTTPMethod: http.MethodGet, HTTPPath: "/"}, &getObjectInput{}, &getObjectOutput{})
+
+			setObjectURL(req, tc.bucket, "creds.yml")
+			assert.Equal(t, tc.expectedHost, req.HTTPRequest.URL.Host)
+			assert.Equal(t, tc.expectedPath, req.HTTPRequest.URL.Path)
+		})
+	}
+}
+
+func newTestClient(endpoint string) Client {
+	return NewS3Client(&config.CommandConfig{Session: newTestSession(endpoint)})
+}
+
+func newTestSession(endpoint string) *session.Session {
+	return session.Must(session.NewSession(&aws.Config{
+		Region:           aws.String("us-west-2"),
+		Endpoint:         aws.String(endpoint),
+		Credentials:      credentials.NewStaticCredentials("id", "secret", ""),
+		S3ForcePathStyle: aws.Bool(true),
+		MaxRetries:       aws.Int(0),
+	}))
+}
@@ -23,6 +23,7 @@ import (
 // SMClient defines methods for interacting with the SecretsManagerAPI interface
 type SMClient interface {
 	CreateSecret(secretsmanager.CreateSecretInput) (*secretsmanager.CreateSecretOutput, error)
+	DeleteSecret(secretID string) error
 	DescribeSecret(secretID string) (*secretsmanager.DescribeSecretOutput, error)
 	ListSecrets(*string) (*secretsmanager.ListSecretsOutput, error)
 	PutSecretValue(input secretsmanager.PutSecretValueInput) (*secretsmanager.PutSecretValueOutput, error)
@@ -56,6 +57,15 @@ func (c *secretsManagerClient) CreateSecret(input secretsmanager.CreateSecretInp
 	return output, nil
 }
 
+// DeleteSecret schedules the secret for deletion after the default recovery window
+func (c *secretsManagerClient) DeleteSecret(secretID string) error {
+	request := secretsmanager.DeleteSecretInput{}
+	request.SetSecretId(secretID)
+
+	_, err := c.client.DeleteSecret(&request)
+	return err
+}
+
 func (c *secretsManagerClient) DescribeSecret(secretID string) (*secretsmanager.DescribeSecretOutput, error) {
 	request := secretsmanager.DescribeSecretInput{}
 	request.SetSecretId(secretID)
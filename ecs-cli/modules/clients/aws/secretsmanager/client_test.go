@@ -76,6 +76,25 @@ func TestDescribeSecretErrorCase(t *testing.T) {
 	assert.Error(t, err, "Expected error when Describing Secret")
 }
 
+func TestDeleteSecret(t *testing.T) {
+	mockSM, client := setupTestController(t)
+
+	testSecretName := "some-secret-1"
+	mockSM.EXPECT().DeleteSecret(&secretsmanager.DeleteSecretInput{SecretId: aws.String(testSecretName)}).Return(&secretsmanager.DeleteSecretOutput{}, nil)
+
+	err := client.DeleteSecret(testSecretName)
+	assert.NoError(t, err, "Expected no error when Deleting Secret")
+}
+
+func TestDeleteSecretErrorCase(t *testing.T) {
+	mockSM, client := setupTestController(t)
+
+	mockSM.EXPECT().DeleteSecret(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	err := client.DeleteSecret("fake-secret-name")
+	assert.Error(t, err, "Expected error when Deleting Secret")
+}
+
 func TestListSecrets(t *testing.T) {
 	mockSM, client := setupTestController(t)
 
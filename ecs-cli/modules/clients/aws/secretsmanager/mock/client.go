@@ -60,6 +60,18 @@ func (mr *MockSMClientMockRecorder) CreateSecret(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSecret", reflect.TypeOf((*MockSMClient)(nil).CreateSecret), arg0)
 }
 
+// DeleteSecret mocks base method
+func (m *MockSMClient) DeleteSecret(arg0 string) error {
+	ret := m.ctrl.Call(m, "DeleteSecret", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSecret indicates an expected call of DeleteSecret
+func (mr *MockSMClientMockRecorder) DeleteSecret(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSecret", reflect.TypeOf((*MockSMClient)(nil).DeleteSecret), arg0)
+}
+
 // DescribeSecret mocks base method
 func (m *MockSMClient) DescribeSecret(arg0 string) (*secretsmanager.DescribeSecretOutput, error) {
 	ret := m.ctrl.Call(m, "DescribeSecret", arg0)
@@ -0,0 +1,80 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ssm
+
+import (
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// Client defines methods for interacting with the SSMAPI interface
+type Client interface {
+	GetParameterValue(name string) (string, error)
+	ParameterExists(name string) (bool, error)
+}
+
+type ssmClient struct {
+	client ssmiface.SSMAPI
+}
+
+// NewSSMClient creates an instance of an ssmClient
+func NewSSMClient(config *config.CommandConfig) Client {
+	client := ssm.New(config.Session)
+	client.Handlers.Build.PushBackNamed(clients.CustomUserAgentHandler())
+
+	return newClient(client)
+}
+
+func newClient(client ssmiface.SSMAPI) Client {
+	return &ssmClient{
+		client: client,
+	}
+}
+
+// GetParameterValue returns the decrypted value of the named parameter
+func (c *ssmClient) GetParameterValue(name string) (string, error) {
+	request := ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	}
+
+	output, err := c.client.GetParameter(&request)
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(output.Parameter.Value), nil
+}
+
+// ParameterExists returns whether the named parameter exists. The parameter is read without decryption and its value
+// is discarded.
+func (c *ssmClient) ParameterExists(name string) (bool, error) {
+	request := ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(false),
+	}
+
+	_, err := c.client.GetParameter(&request)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ssm
+
+import (
+	"errors"
+	"testing"
+
+	mock_ssmiface "github.com/aws/amazon-ecs-cli/ecs-cli/modules/clients/aws/amimetadata/mock/sdk"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetParameterValue(t *testing.T) {
+	mockSSM, client := setupTestController(t)
+
+	expectedInput := ssm.GetParameterInput{
+		Name:           aws.String("/iam/boundary"),
+		WithDecryption: aws.Bool(true),
+	}
+	mockSSM.EXPECT().GetParameter(&expectedInput).Return(&ssm.GetParameterOutput{
+		Parameter: &ssm.Parameter{
+			Value: aws.String("arn:aws:iam::111111111111:policy/boundary"),
+		},
+	}, nil)
+
+	value, err := client.GetParameterValue("/iam/boundary")
+	assert.NoError(t, err, "Unexpected error when getting parameter")
+	assert.Equal(t, "arn:aws:iam::111111111111:policy/boundary", value)
+}
+
+func TestGetParameterValue_ErrorCase(t *testing.T) {
+	mockSSM, client := setupTestController(t)
+	mockSSM.EXPECT().GetParameter(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+	_, err := client.GetParameterValue("/iam/boundary")
+	assert.Error(t, err, "Expected error when getting parameter")
+}
+
+func TestParameterExists(t *testing.T) {
+	mockSSM, client := setupTestController(t)
+
+	expectedInput := ssm.GetParameterInput{
+		Name:           aws.String("/registry/creds"),
+		WithDecryption: aws.Bool(false),
+	}
+	gomock.InOrder(
+		mockSSM.EXPECT().GetParameter(&expectedInput).Return(&ssm.GetParameterOutput{Parameter: &ssm.Parameter{}}, nil),
+		mockSSM.EXPECT().GetParameter(&expectedInput).Return(nil, awserr.New(ssm.ErrCodeParameterNotFound, "not found", nil)),
+		mockSSM.EXPECT().GetParameter(&expectedInput).Return(nil, errors.New("something went wrong")),
+	)
+
+	exists, err := client.ParameterExists("/registry/creds")
+	assert.NoError(t, err, "Unexpected error checking parameter")
+	assert.True(t, exists)
+
+	exists, err = client.ParameterExists("/registry/creds")
+	assert.NoError(t, err, "Expected no error for a missing parameter")
+	assert.False(t, exists)
+
+	_, err = client.ParameterExists("/registry/creds")
+	assert.Error(t, err, "Expected error when checking parameter")
+}
+
+func setupTestController(t *testing.T) (*mock_ssmiface.MockSSMAPI, Client) {
+	ctrl := gomock.NewController(t)
+	mockSSM := mock_ssmiface.NewMockSSMAPI(ctrl)
+	client := newClient(mockSSM)
+
+	return mockSSM, client
+}
@@ -26,6 +26,7 @@ import (
 // Client sts interface
 type Client interface {
 	GetAWSAccountID() (string, error)
+	GetCallerARN() (string, error)
 }
 
 // stsClient implements Client
@@ -58,3 +59,12 @@ func (c *stsClient) GetAWSAccountID() (string, error) {
 	}
 	return aws.StringValue(resp.Account), nil
 }
+
+// GetCallerARN returns the ARN of the caller, which is an assumed role session ARN for role credentials
+func (c *stsClient) GetCallerARN() (string, error) {
+	resp, err := c.client.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.StringValue(resp.Arn), nil
+}
@@ -52,6 +52,22 @@ func TestGetAWSAccountIDErrorCase(t *testing.T) {
 
 }
 
+func TestGetCallerARN(t *testing.T) {
+	mockSts, client, ctrl := setupTestController(t)
+	defer ctrl.Finish()
+
+	expectedARN := "arn:aws:sts::123456789:assumed-role/deploy/session"
+
+	mockSts.EXPECT().GetCallerIdentity(gomock.Any()).Return(&sts.GetCallerIdentityOutput{
+		Account: aws.String("123456789"),
+		Arn:     aws.String(expectedARN),
+	}, nil)
+
+	callerARN, err := client.GetCallerARN()
+	assert.NoError(t, err, "GetCallerARN")
+	assert.Equal(t, expectedARN, callerARN, "Expected caller ARN to match")
+}
+
 func setupTestController(t *testing.T) (*mock_stsiface.MockSTSAPI, Client, *gomock.Controller) {
 	ctrl := gomock.NewController(t)
 	mockSts := mock_stsiface.NewMockSTSAPI(ctrl)
@@ -58,3 +58,16 @@ func (m *MockClient) GetAWSAccountID() (string, error) {
 func (mr *MockClientMockRecorder) GetAWSAccountID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAWSAccountID", reflect.TypeOf((*MockClient)(nil).GetAWSAccountID))
 }
+
+// GetCallerARN mocks base method
+func (m *MockClient) GetCallerARN() (string, error) {
+	ret := m.ctrl.Call(m, "GetCallerARN")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCallerARN indicates an expected call of GetCallerARN
+func (mr *MockClientMockRecorder) GetCallerARN() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCallerARN", reflect.TypeOf((*MockClient)(nil).GetCallerARN))
+}
@@ -0,0 +1,62 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clients
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// CallTimeoutErrorCode is the code of the error returned when an API call doesn't complete within its timeout
+const CallTimeoutErrorCode = "CallTimeout"
+
+type callTimeoutCancelKey struct{}
+
+// AddCallTimeout limits each API call made with the handlers, including its retries, to the timeout. A call which
+// times out fails with an error naming the service and operation. Handlers of a session are copied to each client
+// created from it, so this applies to every call made by those clients.
+func AddCallTimeout(handlers *request.Handlers, timeout time.Duration) {
+	handlers.Validate.PushFrontNamed(request.NamedHandler{
+		Name: "ECSCLICallTimeoutHandler",
+		Fn: func(r *request.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			r.SetContext(context.WithValue(ctx, callTimeoutCancelKey{}, cancel))
+		},
+	})
+	// runs after the SDK has decided not to retry, so that the error returned by the call can be replaced
+	handlers.AfterRetry.PushBackNamed(request.NamedHandler{
+		Name: "ECSCLICallTimeoutErrorHandler",
+		Fn: func(r *request.Request) {
+			if r.Error == nil || r.Context().Err() != context.DeadlineExceeded {
+				return
+			}
+			if aerr, ok := r.Error.(awserr.Error); ok && aerr.Code() == CallTimeoutErrorCode {
+				return
+			}
+			r.Error = awserr.New(CallTimeoutErrorCode, fmt.Sprintf("%s %s call did not complete within %s", r.ClientInfo.ServiceName, r.Operation.Name, timeout), r.Error)
+		},
+	})
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "ECSCLICallTimeoutCancelHandler",
+		Fn: func(r *request.Request) {
+			if cancel, ok := r.Context().Value(callTimeoutCancelKey{}).(context.CancelFunc); ok {
+				cancel()
+			}
+		},
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddCallTimeout_ErrorOnTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client := iam.New(testCallTimeoutSession(t, server.URL, 50*time.Millisecond))
+	start := time.Now()
+	_, err := client.AttachRolePolicy(&iam.AttachRolePolicyInput{PolicyArn: aws.String("arn:aws:iam::aws:policy/test"), RoleName: aws.String("myRole")})
+	assert.True(t, time.Since(start) < 400*time.Millisecond, "Expected the call to stop at its timeout")
+
+	aerr, ok := err.(awserr.Error)
+	assert.True(t, ok, "Expected an AWS error, got %v", err)
+	assert.Equal(t, CallTimeoutErrorCode, aerr.Code())
+	assert.Contains(t, aerr.Message(), "iam AttachRolePolicy")
+	assert.Contains(t, aerr.Message(), "50ms")
+}
+
+func TestAddCallTimeout_EachCallHasItsOwnTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(`<AttachRolePolicyResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/"><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></AttachRolePolicyResponse>`))
+	}))
+	defer server.Close()
+
+	// together, the calls take longer than the timeout of one call
+	client := iam.New(testCallTimeoutSession(t, server.URL, 300*time.Millisecond))
+	for i := 0; i < 4; i++ {
+		_, err := client.AttachRolePolicy(&iam.AttachRolePolicyInput{PolicyArn: aws.String("arn:aws:iam::aws:policy/test"), RoleName: aws.String("myRole")})
+		assert.NoError(t, err, "Unexpected error on call %d", i)
+	}
+}
+
+func testCallTimeoutSession(t *testing.T, endpoint string, timeout time.Duration) *session.Session {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(endpoint),
+		DisableSSL:  aws.Bool(true),
+		Credentials: credentials.NewStaticCredentials("AKID", "SKID", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	assert.NoError(t, err, "Unexpected error creating session")
+	AddCallTimeout(&sess.Handlers, timeout)
+	return sess
+}
@@ -0,0 +1,315 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/pkg/errors"
+)
+
+const (
+	// RedactedValue replaces credentials and secret values in recordings. It is also valid base64, so that redacted
+	// binary fields can be replayed.
+	RedactedValue = "REDACTED"
+	// ReplayErrorCode is the code of the error returned when a call doesn't match the next call of a recording
+	ReplayErrorCode = "ReplayMismatch"
+
+	recordingExtension = ".json"
+	recordingFileMode  = 0600
+	recordingDirMode   = 0700
+)
+
+// redactedFields are the fields of requests and responses whose values are replaced in recordings, at any depth
+var redactedFields = map[string]bool{
+	"AccessKeyId":      true,
+	"Password":         true,
+	"Plaintext":        true,
+	"SecretAccessKey":  true,
+	"SecretBinary":     true,
+	"SecretString":     true,
+	"SessionToken":     true,
+	"WebIdentityToken": true,
+}
+
+// responseRedactors replace the secret values in the responses of particular operations, by service and operation.
+// Their secrets are in fields which can't be redacted by name alone, such as the contents of an input file read from
+// S3, or the value of an SSM parameter.
+var responseRedactors = map[string]func(fields map[string]interface{}){
+	"s3.GetObject":            redactBody,
+	"ssm.GetParameter":        redactSecureParameters,
+	"ssm.GetParameters":       redactSecureParameters,
+	"ssm.GetParametersByPath": redactSecureParameters,
+}
+
+// recordedCall is the content of a recording file
+type recordedCall struct {
+	Service   string          `json:"service"`
+	Operation string          `json:"operation"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	Output    json.RawMessage `json:"output,omitempty"`
+	Error     *recordedError  `json:"error,omitempty"`
+}
+
+type recordedError struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	RequestID  string `json:"requestId,omitempty"`
+}
+
+// Recorder writes each API call made with the handlers it is added to, with its response or error, to a file in a
+// directory. Credentials and secret values are redacted, and no HTTP headers are written.
+type Recorder struct {
+	dir   string
+	lock  sync.Mutex
+	calls int
+}
+
+// NewRecorder returns a recorder that writes to dir, which is created if needed. It is an error if dir already
+// contains a recording, since replaying it would then mix the calls of two runs.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, recordingDirMode); err != nil {
+		return nil, errors.Wrapf(err, "could not create recording directory %s", dir)
+	}
+	files, err := recordingFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		return nil, fmt.Errorf("recording directory %s already contains a recording", dir)
+	}
+	return &Recorder{dir: dir}, nil
+}
+
+// AddTo records the calls made with the handlers. Handlers of a session are copied to each client created from it.
+func (rec *Recorder) AddTo(handlers *request.Handlers) {
+	handlers.Complete.PushBackNamed(request.NamedHandler{
+		Name: "ECSCLIRecordHandler",
+		Fn: func(r *request.Request) {
+			// calls which failed validation were never sent, and fail the same way when replayed
+			if r.AttemptTime.IsZero() {
+				return
+			}
+			if err := rec.record(r); err != nil && r.Error == nil {
+				r.Error = err
+			}
+		},
+	})
+}
+
+func (rec *Recorder) record(r *request.Request) error {
+	call := recordedCall{
+		Service:   r.ClientInfo.ServiceName,
+		Operation: r.Operation.Name,
+	}
+	var err error
+	if call.Input, err = redactedJSON(r.Params, nil); err != nil {
+		return errors.Wrapf(err, "could not record %s %s", call.Service, call.Operation)
+	}
+	if r.Error != nil {
+		call.Error = newRecordedError(r.Error)
+	} else if call.Output, err = redactedJSON(r.Data, responseRedactors[call.Service+"."+call.Operation]); err != nil {
+		return errors.Wrapf(err, "could not record %s %s", call.Service, call.Operation)
+	}
+	content, err := json.MarshalIndent(call, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "could not record %s %s", call.Service, call.Operation)
+	}
+
+	rec.lock.Lock()
+	defer rec.lock.Unlock()
+	rec.calls++
+	filename := filepath.Join(rec.dir, fmt.Sprintf("%04d-%s-%s%s", rec.calls, call.Service, call.Operation, recordingExtension))
+	return ioutil.WriteFile(filename, append(content, '\n'), recordingFileMode)
+}
+
+func newRecordedError(err error) *recordedError {
+	recorded := &recordedError{Message: err.Error()}
+	if aerr, ok := err.(awserr.Error); ok {
+		recorded.Code = aerr.Code()
+		recorded.Message = aerr.Message()
+	}
+	if rerr, ok := err.(awserr.RequestFailure); ok {
+		recorded.StatusCode = rerr.StatusCode()
+		recorded.RequestID = rerr.RequestID()
+	}
+	return recorded
+}
+
+// redactedJSON returns the JSON of the value, with the values of the redacted fields replaced, and the operation's
+// redactor, if any, applied
+func redactedJSON(value interface{}, redactor func(fields map[string]interface{})) (json.RawMessage, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields interface{}
+	if err = json.Unmarshal(content, &fields); err != nil {
+		return nil, err
+	}
+	if object, ok := fields.(map[string]interface{}); ok && redactor != nil {
+		redactor(object)
+	}
+	return json.Marshal(redact(fields))
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if redactedFields[key] && field != nil {
+				v[key] = RedactedValue
+			} else {
+				v[key] = redact(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+// redactBody redacts the contents of an S3 object, which is base64 encoded in the JSON of the response
+func redactBody(fields map[string]interface{}) {
+	if fields["Body"] != nil {
+		fields["Body"] = RedactedValue
+	}
+}
+
+// redactSecureParameters redacts the values of the SecureString parameters in an SSM response. Other parameters are
+// kept, so that calls using their values, such as a permissions boundary ARN, can be replayed.
+func redactSecureParameters(fields map[string]interface{}) {
+	parameters, _ := fields["Parameters"].([]interface{})
+	if parameter := fields["Parameter"]; parameter != nil {
+		parameters = append(parameters, parameter)
+	}
+	for _, item := range parameters {
+		parameter, ok := item.(map[string]interface{})
+		if ok && parameter["Type"] == ssm.ParameterTypeSecureString && parameter["Value"] != nil {
+			parameter["Value"] = RedactedValue
+		}
+	}
+}
+
+// Replayer answers the API calls made with the handlers it is added to from a recording, in the order they were
+// recorded, instead of sending them to AWS. Requests are not signed, so no credentials are needed.
+type Replayer struct {
+	dir   string
+	files []string
+	lock  sync.Mutex
+	next  int
+}
+
+// NewReplayer returns a replayer of the recording in dir
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := recordingFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("replay directory %s does not contain a recording", dir)
+	}
+	return &Replayer{dir: dir, files: files}, nil
+}
+
+// AddTo replays the calls made with the handlers. Handlers of a session are copied to each client created from it.
+func (rep *Replayer) AddTo(handlers *request.Handlers) {
+	handlers.Build.PushBackNamed(request.NamedHandler{
+		Name: "ECSCLIReplayHandler",
+		Fn: func(r *request.Request) {
+			r.Handlers.Sign.Clear()
+			r.Handlers.Send.Clear()
+			r.Handlers.Send.PushBack(rep.replay)
+			r.Handlers.UnmarshalMeta.Clear()
+			r.Handlers.ValidateResponse.Clear()
+			r.Handlers.Unmarshal.Clear()
+			r.Handlers.UnmarshalError.Clear()
+		},
+	})
+}
+
+func (rep *Replayer) replay(r *request.Request) {
+	// a replayed error is returned as it was recorded, without retries
+	r.Retryable = aws.Bool(false)
+	r.HTTPResponse = &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(&bytes.Buffer{})}
+
+	call, filename, err := rep.nextCall()
+	if err != nil {
+		r.Error = awserr.New(ReplayErrorCode, fmt.Sprintf("could not replay %s %s", r.ClientInfo.ServiceName, r.Operation.Name), err)
+		return
+	}
+	if call.Service != r.ClientInfo.ServiceName || call.Operation != r.Operation.Name {
+		r.Error = awserr.New(ReplayErrorCode, fmt.Sprintf("%s %s was called, but %s recorded %s %s", r.ClientInfo.ServiceName, r.Operation.Name, filename, call.Service, call.Operation), nil)
+		return
+	}
+	if call.Error != nil {
+		r.Error = awserr.New(call.Error.Code, call.Error.Message, nil)
+		if call.Error.StatusCode != 0 {
+			r.HTTPResponse.StatusCode = call.Error.StatusCode
+			r.RequestID = call.Error.RequestID
+			r.Error = awserr.NewRequestFailure(r.Error.(awserr.Error), call.Error.StatusCode, call.Error.RequestID)
+		}
+		return
+	}
+	if len(call.Output) > 0 && r.DataFilled() {
+		if err := json.Unmarshal(call.Output, r.Data); err != nil {
+			r.Error = awserr.New(ReplayErrorCode, fmt.Sprintf("could not read the response in %s", filename), err)
+		}
+	}
+}
+
+func (rep *Replayer) nextCall() (*recordedCall, string, error) {
+	rep.lock.Lock()
+	defer rep.lock.Unlock()
+	if rep.next >= len(rep.files) {
+		return nil, "", fmt.Errorf("all %d calls of the recording in %s have been replayed", len(rep.files), rep.dir)
+	}
+	filename := rep.files[rep.next]
+	rep.next++
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, filename, err
+	}
+	call := &recordedCall{}
+	if err = json.Unmarshal(content, call); err != nil {
+		return nil, filename, errors.Wrapf(err, "could not read %s", filename)
+	}
+	return call, filename, nil
+}
+
+// recordingFiles returns the files of the recording in dir, in the order the calls were made
+func recordingFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+recordingExtension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
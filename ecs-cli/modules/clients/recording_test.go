@@ -0,0 +1,203 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clients
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRecordingGetRoleResponse = `<GetRoleResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/"><GetRoleResult><Role><RoleName>myRole</RoleName><Arn>arn:aws:iam::123456789012:role/myRole</Arn></Role></GetRoleResult><ResponseMetadata><RequestId>1</RequestId></ResponseMetadata></GetRoleResponse>`
+	testRecordingNoSuchEntity    = `<ErrorResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/"><Error><Type>Sender</Type><Code>NoSuchEntity</Code><Message>The role with name otherRole cannot be found.</Message></Error><RequestId>2</RequestId></ErrorResponse>`
+)
+
+func TestRecorder_RedactsSecretValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ARN":"arn:aws:secretsmanager:us-west-2:123456789012:secret:mySecret","Name":"mySecret"}`))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "ecs-cli-recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	recorder, err := NewRecorder(dir)
+	require.NoError(t, err)
+
+	sess := testRecordingSession(t, server.URL)
+	recorder.AddTo(&sess.Handlers)
+	_, err = secretsmanager.New(sess).CreateSecret(&secretsmanager.CreateSecretInput{
+		Name:         aws.String("mySecret"),
+		SecretString: aws.String(`{"username":"myUser","password":"hunter2"}`),
+	})
+	require.NoError(t, err)
+
+	files, err := recordingFiles(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "0001-secretsmanager-CreateSecret.json", filepath.Base(files[0]))
+	content, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "hunter2")
+	assert.NotContains(t, string(content), "myUser")
+	assert.Contains(t, string(content), `"SecretString": "REDACTED"`)
+	assert.Contains(t, string(content), "arn:aws:secretsmanager:us-west-2:123456789012:secret:mySecret")
+}
+
+func TestRecorder_RedactsSecureParameterValues(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Parameters":[{"Name":"myPassword","Type":"SecureString","Value":"hunter2"},{"Name":"myBoundary","Type":"String","Value":"arn:aws:iam::123456789012:policy/myBoundary"}]}`))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "ecs-cli-recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	recorder, err := NewRecorder(dir)
+	require.NoError(t, err)
+
+	sess := testRecordingSession(t, server.URL)
+	recorder.AddTo(&sess.Handlers)
+	_, err = ssm.New(sess).GetParameters(&ssm.GetParametersInput{
+		Names:          aws.StringSlice([]string{"myPassword", "myBoundary"}),
+		WithDecryption: aws.Bool(true),
+	})
+	require.NoError(t, err)
+
+	files, err := recordingFiles(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	content, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "hunter2")
+	assert.Contains(t, string(content), `"Value": "REDACTED"`)
+	assert.Contains(t, string(content), "arn:aws:iam::123456789012:policy/myBoundary", "Expected String parameter values to be kept")
+}
+
+func TestReplayer_ReplaysRecording(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("RoleName") != "myRole" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(testRecordingNoSuchEntity))
+			return
+		}
+		w.Write([]byte(testRecordingGetRoleResponse))
+	}))
+
+	dir, err := ioutil.TempDir("", "ecs-cli-recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	recorder, err := NewRecorder(dir)
+	require.NoError(t, err)
+	sess := testRecordingSession(t, server.URL)
+	recorder.AddTo(&sess.Handlers)
+	testRecordingGetRoles(t, iam.New(sess))
+	server.Close()
+
+	replayer, err := NewReplayer(dir)
+	require.NoError(t, err)
+	// no credentials are needed, and the closed server is never called
+	replaySess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(server.URL),
+		Credentials: credentials.AnonymousCredentials,
+	})
+	require.NoError(t, err)
+	replayer.AddTo(&replaySess.Handlers)
+	testRecordingGetRoles(t, iam.New(replaySess))
+
+	_, err = iam.New(replaySess).GetRole(&iam.GetRoleInput{RoleName: aws.String("myRole")})
+	aerr, ok := err.(awserr.Error)
+	require.True(t, ok, "Expected an AWS error, got %v", err)
+	assert.Equal(t, ReplayErrorCode, aerr.Code())
+}
+
+func TestReplayer_ErrorOnDifferentCall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-cli-recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "0001-iam-GetRole.json"), []byte(`{"service":"iam","operation":"GetRole","output":{}}`), 0600)
+	require.NoError(t, err)
+
+	replayer, err := NewReplayer(dir)
+	require.NoError(t, err)
+	sess := testRecordingSession(t, "http://localhost")
+	replayer.AddTo(&sess.Handlers)
+	_, err = iam.New(sess).DeleteRole(&iam.DeleteRoleInput{RoleName: aws.String("myRole")})
+
+	aerr, ok := err.(awserr.Error)
+	require.True(t, ok, "Expected an AWS error, got %v", err)
+	assert.Equal(t, ReplayErrorCode, aerr.Code())
+	assert.Contains(t, aerr.Message(), "iam DeleteRole was called")
+	assert.Contains(t, aerr.Message(), "iam GetRole")
+}
+
+func TestNewRecorder_ErrorIfDirectoryHasRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-cli-recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "0001-iam-GetRole.json"), []byte(`{}`), 0600)
+	require.NoError(t, err)
+
+	_, err = NewRecorder(dir)
+	assert.Error(t, err, "Expected error for a directory with a recording")
+}
+
+func TestNewReplayer_ErrorIfDirectoryHasNoRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ecs-cli-recording")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = NewReplayer(dir)
+	assert.Error(t, err, "Expected error for a directory without a recording")
+}
+
+func testRecordingGetRoles(t *testing.T, client *iam.IAM) {
+	output, err := client.GetRole(&iam.GetRoleInput{RoleName: aws.String("myRole")})
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/myRole", aws.StringValue(output.Role.Arn))
+
+	_, err = client.GetRole(&iam.GetRoleInput{RoleName: aws.String("otherRole")})
+	rerr, ok := err.(awserr.RequestFailure)
+	require.True(t, ok, "Expected a request failure, got %v", err)
+	assert.Equal(t, iam.ErrCodeNoSuchEntityException, rerr.Code())
+	assert.Equal(t, http.StatusNotFound, rerr.StatusCode())
+	assert.Equal(t, "2", rerr.RequestID())
+}
+
+func testRecordingSession(t *testing.T, endpoint string) *session.Session {
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String("us-west-2"),
+		Endpoint:    aws.String(endpoint),
+		Credentials: credentials.NewStaticCredentials("AKID", "SKID", ""),
+		MaxRetries:  aws.Int(0),
+	})
+	require.NoError(t, err)
+	return sess
+}
@@ -0,0 +1,97 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clients
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// maxRetryDelay caps the delay before any retry so that the CLI does not appear to hang
+	maxRetryDelay = 20 * time.Second
+	// maxBackoffShift keeps the exponential ceiling from overflowing on large retry counts
+	maxBackoffShift = 30
+)
+
+// FullJitterRetryer retries the same requests as the SDK's default retryer, but waits a random delay between zero and
+// an exponentially increasing ceiling ("full jitter"). Clients which are throttled at the same time, e.g. CI jobs
+// started together, then spread out their retries instead of retrying in lockstep.
+type FullJitterRetryer struct {
+	client.DefaultRetryer
+	// randInt63n returns a random number in [0, n); rand.Int63n is used if unset
+	randInt63n func(n int64) int64
+}
+
+// NewFullJitterRetryer returns a FullJitterRetryer with the SDK's default number of retries and minimum delays
+func NewFullJitterRetryer() *FullJitterRetryer {
+	return &FullJitterRetryer{
+		DefaultRetryer: client.DefaultRetryer{
+			NumMaxRetries:    client.DefaultRetryerMaxNumRetries,
+			MinRetryDelay:    client.DefaultRetryerMinRetryDelay,
+			MinThrottleDelay: client.DefaultRetryerMinThrottleDelay,
+			MaxRetryDelay:    maxRetryDelay,
+			MaxThrottleDelay: maxRetryDelay,
+		},
+	}
+}
+
+// FullJitterRetryerConfig returns an aws.Config which makes a client of a session with the given config retry with a
+// FullJitterRetryer, up to the session's MaxRetries if it is set. A retryer already set on the session, e.g. from a
+// retry policy, is kept instead.
+func FullJitterRetryerConfig(sessionConfig *aws.Config) *aws.Config {
+	if sessionConfig != nil && sessionConfig.Retryer != nil {
+		return aws.NewConfig()
+	}
+	retryer := NewFullJitterRetryer()
+	if sessionConfig != nil && sessionConfig.MaxRetries != nil && *sessionConfig.MaxRetries != aws.UseServiceDefaultRetries {
+		retryer.NumMaxRetries = *sessionConfig.MaxRetries
+	}
+	return request.WithRetryer(aws.NewConfig(), retryer)
+}
+
+// RetryRules returns a random delay of up to the smaller of min delay * 2^retry count and the max delay, where throttled
+// requests use the throttle delays
+func (r *FullJitterRetryer) RetryRules(req *request.Request) time.Duration {
+	ceiling := r.backoffCeiling(req.RetryCount, req.IsErrorThrottle())
+	if ceiling <= 0 {
+		return 0
+	}
+
+	randInt63n := r.randInt63n
+	if randInt63n == nil {
+		randInt63n = rand.Int63n
+	}
+	return time.Duration(randInt63n(int64(ceiling) + 1))
+}
+
+func (r *FullJitterRetryer) backoffCeiling(retryCount int, throttled bool) time.Duration {
+	minDelay, maxDelay := r.MinRetryDelay, r.MaxRetryDelay
+	if throttled {
+		minDelay, maxDelay = r.MinThrottleDelay, r.MaxThrottleDelay
+	}
+
+	if retryCount > maxBackoffShift {
+		retryCount = maxBackoffShift
+	}
+	ceiling := minDelay << uint(retryCount)
+	if ceiling > maxDelay || ceiling < minDelay {
+		return maxDelay
+	}
+	return ceiling
+}
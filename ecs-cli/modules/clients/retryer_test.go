@@ -0,0 +1,100 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package clients
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullJitterRetryer_DelaysWithinJitteredBounds(t *testing.T) {
+	retryer := NewFullJitterRetryer()
+	req := &request.Request{Error: awserr.New("InternalFailure", "something went wrong", errors.New("something went wrong"))}
+
+	for retryCount := 0; retryCount < 12; retryCount++ {
+		req.RetryCount = retryCount
+		ceiling := retryer.MinRetryDelay << uint(retryCount)
+		if ceiling > retryer.MaxRetryDelay {
+			ceiling = retryer.MaxRetryDelay
+		}
+		for i := 0; i < 50; i++ {
+			delay := retryer.RetryRules(req)
+			assert.True(t, delay >= 0 && delay <= ceiling, "Expected delay %v for retry %d to be within [0, %v]", delay, retryCount, ceiling)
+		}
+	}
+}
+
+func TestFullJitterRetryer_ThrottleDelays(t *testing.T) {
+	retryer := NewFullJitterRetryer()
+	// always pick the largest possible delay
+	retryer.randInt63n = func(n int64) int64 { return n - 1 }
+	req := &request.Request{Error: awserr.New("Throttling", "Rate exceeded", errors.New("Rate exceeded"))}
+
+	var delays []time.Duration
+	for retryCount := 0; retryCount < 8; retryCount++ {
+		req.RetryCount = retryCount
+		delays = append(delays, retryer.RetryRules(req))
+	}
+	assert.Equal(t, []time.Duration{
+		500 * time.Millisecond,
+		1 * time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		8 * time.Second,
+		16 * time.Second,
+		20 * time.Second,
+		20 * time.Second,
+	}, delays)
+}
+
+func TestFullJitterRetryer_SmallestDelay(t *testing.T) {
+	retryer := NewFullJitterRetryer()
+	retryer.randInt63n = func(n int64) int64 { return 0 }
+	req := &request.Request{RetryCount: 5, Error: awserr.New("Throttling", "Rate exceeded", errors.New("Rate exceeded"))}
+
+	assert.Equal(t, time.Duration(0), retryer.RetryRules(req), "Expected full jitter to allow retrying immediately")
+}
+
+func TestFullJitterRetryer_LargeRetryCount(t *testing.T) {
+	retryer := NewFullJitterRetryer()
+	assert.Equal(t, maxRetryDelay, retryer.backoffCeiling(100, false), "Expected the ceiling to be capped without overflowing")
+	assert.Equal(t, maxRetryDelay, retryer.backoffCeiling(100, true))
+}
+
+func TestFullJitterRetryerConfig(t *testing.T) {
+	_, ok := FullJitterRetryerConfig(aws.NewConfig()).Retryer.(*FullJitterRetryer)
+	assert.True(t, ok, "Expected a full jitter retryer")
+
+	sessionConfig := request.WithRetryer(aws.NewConfig(), client.DefaultRetryer{NumMaxRetries: 1})
+	assert.Nil(t, FullJitterRetryerConfig(sessionConfig).Retryer, "Expected the retryer of the session to be kept")
+}
+
+func TestFullJitterRetryerConfig_SessionMaxRetries(t *testing.T) {
+	retryer, ok := FullJitterRetryerConfig(aws.NewConfig().WithMaxRetries(0)).Retryer.(*FullJitterRetryer)
+	if assert.True(t, ok, "Expected a full jitter retryer") {
+		assert.Equal(t, 0, retryer.MaxRetries(), "Expected the session's limit")
+	}
+
+	retryer, ok = FullJitterRetryerConfig(aws.NewConfig().WithMaxRetries(aws.UseServiceDefaultRetries)).Retryer.(*FullJitterRetryer)
+	if assert.True(t, ok, "Expected a full jitter retryer") {
+		assert.Equal(t, client.DefaultRetryerMaxNumRetries, retryer.MaxRetries(), "Expected the SDK's default limit")
+	}
+}
@@ -198,5 +198,17 @@ func configureFlags() []cli.Flag {
 				"[Optional] Specifies the type of tasks that you would like to run. Options: EC2 or FARGATE. Defaults to empty string if none provided.",
 			),
 		},
+		cli.StringFlag{
+			Name: flags.RoleNamePrefixFlag,
+			Usage: fmt.Sprintf(
+				"[Optional] Specifies the prefix added to task execution role names given to ecs-cli registry-creds up. Format <prefix><role-name><suffix>.",
+			),
+		},
+		cli.StringFlag{
+			Name: flags.RoleNameSuffixFlag,
+			Usage: fmt.Sprintf(
+				"[Optional] Specifies the suffix added to task execution role names given to ecs-cli registry-creds up. Format <prefix><role-name><suffix>.",
+			),
+		},
 	}
 }
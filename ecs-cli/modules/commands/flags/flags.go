@@ -48,6 +48,11 @@ const (
 	AWSAccessKeyEnvVar      = "AWS_ACCESS_KEY_ID"
 	AWSSecretKeyEnvVar      = "AWS_SECRET_ACCESS_KEY"
 
+	// Web identity credentials, e.g. from an OIDC provider
+	AWSWebIdentityTokenFileEnvVar = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	AWSRoleARNEnvVar              = "AWS_ROLE_ARN"
+	AWSRoleSessionNameEnvVar      = "AWS_ROLE_SESSION_NAME"
+
 	// logs
 	TaskIDFlag         = "task-id"
 	TaskDefinitionFlag = "task-def"
@@ -141,6 +146,90 @@ const (
 	NoRoleFlag                = "no-role"
 	NoOutputFileFlag          = "no-output-file"
 	OutputDirFlag             = "output-dir"
+	OutputFileNameFlag        = "output-file-name"
+	OutputTemplateFlag        = "output-template"
+	OutputRequestIDsFlag      = "output-include-request-ids"
+	OutputPermissionsFlag     = "output-permissions"
+	VersionStageFlag          = "version-stage"
+	SeparateStatementsFlag    = "separate-statements"
+	SummaryOnlyFlag           = "summary-only"
+	ManagementTagFlag         = "management-tag"
+	PermissionsBoundaryFlag   = "permissions-boundary"
+	AssumeKMSARNValidFlag     = "assume-kms-arn-valid"
+	ManifestFlag              = "manifest"
+	CreateInstanceProfileFlag = "create-instance-profile"
+	RoleBundleFlag            = "role-bundle"
+	WebIdentityTokenFileFlag  = "web-identity-token-file"
+	WebIdentityRoleARNFlag    = "web-identity-role-arn"
+	CredentialsSourceFlag     = "credentials-source"
+	TimeoutPerCallFlag        = "timeout-per-call"
+	CompressFlag              = "compress"
+	StrictARNParsingFlag      = "strict-arn-parsing"
+	StrictFlag                = "strict"
+	EmitSummaryMDFlag         = "emit-summary-md"
+	PolicyNameFlag            = "policy-name"
+	UpdateExistingFlag        = "update-existing"
+	PolicyNameFromHashFlag    = "policy-name-from-hash"
+	ReconcileTagsFlag         = "reconcile-tags"
+	OnTagFailureFlag          = "on-tag-failure"
+	EndpointMapFlag           = "endpoint-map"
+	RetryPolicyFlag           = "retry-policy"
+	RecordFlag                = "record"
+	ReplayFlag                = "replay"
+	FailFastFlag              = "fail-fast"
+	ContinueFlag              = "continue"
+	NotifyURLFlag             = "notify-url"
+	NotifyHeaderFlag          = "notify-header"
+	NotifyRequiredFlag        = "notify-required"
+	ValidateSecretsExistFlag  = "validate-secrets-exist"
+	EmitSidMapFlag            = "emit-sid-map"
+	EmitTerraformImportFlag   = "emit-terraform-import"
+	EmitCFNTemplateFlag       = "emit-cfn-template"
+	SecretFlag                = "secret"
+	ManagedPolicyARNFlag      = "managed-policy-arn"
+	CheckKMSKeyPolicyFlag     = "check-kms-key-policy"
+	RequireSessionTagsFlag    = "require-session-tags"
+	SimulateFlag              = "simulate"
+	MaxPoliciesPerRoleFlag    = "max-policies-per-role"
+	PruneStaleFlag            = "prune-stale"
+	ImportRoleFlag            = "role"
+	ImportPolicyFlag          = "policy"
+	OutputPerEnvFlag          = "output-per-env"
+	FormatFlag                = "format"
+	LogOutputFlag             = "log-output"
+	AttachPolicyFlag          = "attach-policy"
+	PrintARNOnlyFlag          = "print-arn-only"
+	PrintPolicyFlag           = "print-policy"
+	PrintStepsFlag            = "print-steps"
+	AllowEmptyFlag            = "allow-empty"
+	RoleOnlyFlag              = "role-only"
+	VerifyAccountFlag         = "verify-account"
+	AccountIDFlag             = "account-id"
+	EnforceTagPolicyFlag      = "enforce-tag-policy"
+	LintFlag                  = "lint"
+	LintRulesFlag             = "lint-rules"
+	RetryAccessDeniedFlag     = "retry-on-access-denied-after-create"
+	AttachOrderFlag           = "attach-order"
+	SkipPresentManagedFlag    = "no-managed-policy-if-present"
+	DeleteOrphansFlag         = "delete"
+	RoleNamePrefixFlag        = "role-name-prefix"
+	RoleNameSuffixFlag        = "role-name-suffix"
+	ManifestSigningKeyFlag    = "manifest-signing-key"
+	IdempotencyKeyFlag        = "idempotency-key"
+	OnlyIfChangedFlag         = "only-if-changed"
+	SaveAsFlag                = "save-as"
+	MultiRegionKeysFlag       = "multi-region-keys"
+	MaxRetriesFlag            = "max-retries"
+	ExplainFlag               = "explain"
+	CheckPermissionsFlag      = "check-permissions"
+	IAMPathFlag               = "iam-path"
+	EmitInventoryFlag         = "emit-compliance-inventory"
+	RefreshExistingPolicyFlag = "refresh-existing-policy"
+	DenyUnlessTagFlag         = "deny-unless-tag"
+	IncludeECRFlag            = "include-ecr"
+	MinimalManagedFlag        = "minimal-managed"
+	MinimalManagedActionsFlag = "minimal-managed-actions"
+	ConcurrencyFlag           = "concurrency"
 
 	DesiredTaskStatus = "desired-status"
 
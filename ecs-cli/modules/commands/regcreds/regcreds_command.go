@@ -15,10 +15,13 @@
 package regcredsCommand
 
 import (
+	"strings"
+
 	ecscli "github.com/aws/amazon-ecs-cli/ecs-cli/modules"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/cli/regcreds"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/flags"
 	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/commands/usage"
+	"github.com/aws/amazon-ecs-cli/ecs-cli/modules/utils/regcredio"
 	"github.com/urfave/cli"
 )
 
@@ -31,6 +34,16 @@ func RegistryCredsCommand() cli.Command {
 		Flags:  flags.OptionalRegionAndProfileFlags(),
 		Subcommands: []cli.Command{
 			upCommand(),
+			listCommand(),
+			downCommand(),
+			describeCommand(),
+			importCommand(),
+			validateCommand(),
+			exportTrustPolicyCommand(),
+			listOrphansCommand(),
+			verifyManifestCommand(),
+			diffManifestCommand(),
+			checkCommand(),
 		},
 	}
 }
@@ -39,12 +52,345 @@ func upCommand() cli.Command {
 	return cli.Command{
 		Name:         "up",
 		Usage:        usage.RegistryCredsUp,
-		Action:       regcreds.Up,
-		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), regcredsUpFlags()),
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.Up),
+		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), credentialsFlags(), callTimeoutFlags(), endpointMapFlags(), retryPolicyFlags(), recordingFlags(), flags.DebugFlag(), strictFlags(), regcredsUpFlags()),
 		OnUsageError: flags.UsageErrorFactory("up"),
 	}
 }
 
+func listCommand() cli.Command {
+	return cli.Command{
+		Name:         "list",
+		Usage:        usage.RegistryCredsList,
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.List),
+		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), credentialsFlags(), callTimeoutFlags(), endpointMapFlags(), retryPolicyFlags(), recordingFlags(), flags.DebugFlag(), strictFlags(), regcredsListFlags()),
+		OnUsageError: flags.UsageErrorFactory("list"),
+	}
+}
+
+func downCommand() cli.Command {
+	return cli.Command{
+		Name:         "down",
+		Usage:        usage.RegistryCredsDown,
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.Down),
+		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), credentialsFlags(), callTimeoutFlags(), endpointMapFlags(), retryPolicyFlags(), recordingFlags(), flags.DebugFlag(), strictFlags(), regcredsDownFlags()),
+		OnUsageError: flags.UsageErrorFactory("down"),
+	}
+}
+
+func describeCommand() cli.Command {
+	return cli.Command{
+		Name:         "describe",
+		Usage:        usage.RegistryCredsDescribe,
+		ArgsUsage:    "ROLE_NAME",
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.Describe),
+		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), credentialsFlags(), callTimeoutFlags(), endpointMapFlags(), retryPolicyFlags(), recordingFlags(), flags.DebugFlag(), strictFlags()),
+		OnUsageError: flags.UsageErrorFactory("describe"),
+	}
+}
+
+func importCommand() cli.Command {
+	return cli.Command{
+		Name:         "import",
+		Usage:        usage.RegistryCredsImport,
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.Import),
+		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), credentialsFlags(), callTimeoutFlags(), endpointMapFlags(), retryPolicyFlags(), recordingFlags(), flags.DebugFlag(), strictFlags(), regcredsImportFlags()),
+		OnUsageError: flags.UsageErrorFactory("import"),
+	}
+}
+
+func validateCommand() cli.Command {
+	return cli.Command{
+		Name:         "validate",
+		Usage:        usage.RegistryCredsValidate,
+		ArgsUsage:    "INPUT_FILE",
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.Validate),
+		Flags:        flags.AppendFlags(flags.OptRegionFlag(), flags.DebugFlag(), strictFlags(), regcredsValidateFlags()),
+		OnUsageError: flags.UsageErrorFactory("validate"),
+	}
+}
+
+func exportTrustPolicyCommand() cli.Command {
+	return cli.Command{
+		Name:         "export-trust-policy",
+		Usage:        usage.RegistryCredsExportTrustPolicy,
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.ExportTrustPolicy),
+		Flags:        flags.AppendFlags(flags.DebugFlag(), strictFlags(), regcredsExportTrustPolicyFlags()),
+		OnUsageError: flags.UsageErrorFactory("export-trust-policy"),
+	}
+}
+
+func listOrphansCommand() cli.Command {
+	return cli.Command{
+		Name:         "list-orphans",
+		Usage:        usage.RegistryCredsListOrphans,
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.ListOrphans),
+		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), credentialsFlags(), callTimeoutFlags(), endpointMapFlags(), retryPolicyFlags(), recordingFlags(), flags.DebugFlag(), strictFlags(), regcredsListOrphansFlags()),
+		OnUsageError: flags.UsageErrorFactory("list-orphans"),
+	}
+}
+
+func verifyManifestCommand() cli.Command {
+	return cli.Command{
+		Name:         "verify-manifest",
+		Usage:        usage.RegistryCredsVerifyManifest,
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.VerifyManifest),
+		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), credentialsFlags(), callTimeoutFlags(), endpointMapFlags(), retryPolicyFlags(), recordingFlags(), flags.DebugFlag(), strictFlags(), regcredsVerifyManifestFlags()),
+		OnUsageError: flags.UsageErrorFactory("verify-manifest"),
+	}
+}
+
+func diffManifestCommand() cli.Command {
+	return cli.Command{
+		Name:         "diff-manifest",
+		Usage:        usage.RegistryCredsDiffManifest,
+		ArgsUsage:    "OLD_FILE NEW_FILE",
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.DiffManifest),
+		Flags:        flags.AppendFlags(flags.DebugFlag(), strictFlags(), regcredsDiffManifestFlags()),
+		OnUsageError: flags.UsageErrorFactory("diff-manifest"),
+	}
+}
+
+func checkCommand() cli.Command {
+	return cli.Command{
+		Name:         "check",
+		Usage:        usage.RegistryCredsCheck,
+		Before:       ecscli.BeforeApp,
+		Action:       regcreds.WithStrictMode(regcreds.Check),
+		Flags:        flags.AppendFlags(flags.OptionalRegionAndProfileFlags(), credentialsFlags(), callTimeoutFlags(), endpointMapFlags(), retryPolicyFlags(), recordingFlags(), flags.DebugFlag(), strictFlags(), regcredsCheckFlags()),
+		OnUsageError: flags.UsageErrorFactory("check"),
+	}
+}
+
+func callTimeoutFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.IntFlag{
+			Name:  flags.TimeoutPerCallFlag,
+			Value: regcreds.DefaultTimeoutPerCallSeconds,
+			Usage: "[Optional] The number of seconds a single AWS API call, including its retries, may take before the command fails with an error naming the call. Set to 0 for no limit.",
+		},
+	}
+}
+
+func endpointMapFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.EndpointMapFlag,
+			Usage: "[Optional] A YAML file mapping the services " + strings.Join(regcredio.EndpointMapServices, ", ") + " to custom endpoint URLs, e.g. those of an internal mirror. Each endpoint can disable verification of its TLS certificate with 'insecure_skip_verify'. Services which aren't in the file use their usual endpoints.",
+		},
+	}
+}
+
+func retryPolicyFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.RetryPolicyFlag,
+			Usage: "[Optional] A YAML file mapping AWS error codes (e.g. 'Throttling') to the number of retries and the backoff of failed requests, with a 'default' rule for the other errors the SDK retries. Without the file, the SDK's retries are used.",
+		},
+		cli.IntFlag{
+			Name:  flags.MaxRetriesFlag,
+			Usage: "[Optional] The most times a failed AWS request is retried, which also caps the rules of '--" + flags.RetryPolicyFlag + "'. With 0, no request is retried, including the ECS CLI's own retries of tagging a new role, so failures surface immediately. Without the flag, the SDK's retries are used.",
+		},
+	}
+}
+
+func recordingFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.RecordFlag,
+			Usage: "[Optional] Write each IAM, KMS and Secrets Manager API call, with its response or error, to a file in this directory, e.g. to reproduce a failure with --" + flags.ReplayFlag + ". Credentials and secret values are redacted. The directory must not already contain a recording.",
+		},
+		cli.StringFlag{
+			Name:  flags.ReplayFlag,
+			Usage: "[Optional] Answer the IAM, KMS and Secrets Manager API calls from a recording made with --" + flags.RecordFlag + ", in the order they were recorded, instead of sending them to AWS. Fails if the command makes a call other than the next recorded one.",
+		},
+	}
+}
+
+func strictFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:  flags.StrictFlag,
+			Usage: "[Optional] If specified, warnings are treated as errors: the command fails if it logs any warning. 'up' stops before creating any resources if a warning is logged while checking its input and options.",
+		},
+	}
+}
+
+func credentialsFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.WebIdentityTokenFileFlag,
+			Usage: "[Optional] The path of a file containing a web identity (OIDC) token, used to assume the role given with --" + flags.WebIdentityRoleARNFlag + ". Defaults to the value of " + flags.AWSWebIdentityTokenFileEnvVar + ".",
+		},
+		cli.StringFlag{
+			Name:  flags.WebIdentityRoleARNFlag,
+			Usage: "[Optional] The ARN of the role to assume with the web identity token. Defaults to the value of " + flags.AWSRoleARNEnvVar + ".",
+		},
+		cli.StringFlag{
+			Name:  flags.CredentialsSourceFlag,
+			Usage: "[Optional] Use credentials from only this source, and fail if it has none instead of trying the next one: " + strings.Join(regcreds.CredentialsSources, ", ") + ". 'env' uses the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, 'profile' the keys of the --" + flags.AWSProfileFlag + " profile (or default profile) in the shared credentials file, 'instance' the role of the EC2 instance, and 'chain' resolves credentials as usual. The source used is logged. Can't be used with a web identity, except with 'chain'.",
+		},
+	}
+}
+
+func regcredsDownFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.ManifestFlag,
+			Usage: "The manifest file written by 'registry-creds up --" + flags.ManifestFlag + "'. Only the resources listed in the manifest are removed.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManifestSigningKeyFlag,
+			Usage: "[Optional] The KMS key the manifest was signed with. If specified, no resources are removed unless the manifest matches its signature.",
+		},
+	}
+}
+
+func regcredsImportFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.ImportRoleFlag,
+			Usage: "The name of the existing task execution role to import.",
+		},
+		cli.StringFlag{
+			Name:  flags.ImportPolicyFlag,
+			Usage: "The ARN of the existing registry credentials policy to import. The policy must already be attached to the role and is not modified.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManifestFlag,
+			Usage: "The file to write a JSON manifest of the imported resources to, for use with 'registry-creds down'.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManagementTagFlag,
+			Usage: "[Optional] The tag (in the format key=value) added to the role to identify it as created by the ECS CLI. (default: \"" + regcreds.DefaultManagementTagKey + "=" + regcreds.DefaultManagementTagValue + "\")",
+		},
+	}
+}
+
+func strictARNParsingFlag() cli.Flag {
+	return cli.BoolFlag{
+		Name:  flags.StrictARNParsingFlag,
+		Usage: "[Optional] If specified, every ARN in the input file is fully parsed, and its partition, service, region, account and resource type are checked. All problems found are listed, and no resources are created if there are any.",
+	}
+}
+
+func regcredsValidateFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.FormatFlag,
+			Value: regcreds.TableOutputFormat,
+			Usage: "[Optional] The output format of the findings. Valid values are 'table', 'json' (an array of findings with their severity, entry, field and message) and 'sarif' (a SARIF 2.1.0 log for code scanning tools, with the line of each field in the input file).",
+		},
+		strictARNParsingFlag(),
+		cli.StringFlag{
+			Name:  flags.PermissionsBoundaryFlag,
+			Usage: "[Optional] The permissions boundary given to 'registry-creds up', which is checked to be an IAM policy ARN. SSM parameters are not read.",
+		},
+		accountIDFlag(),
+	}
+}
+
+func regcredsDiffManifestFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.FormatFlag,
+			Value: regcreds.TableOutputFormat,
+			Usage: "[Optional] The output format of the differences. Valid values are 'table' and 'json' (an object with the changed 'roles' and 'secrets', which are empty if the files don't differ).",
+		},
+	}
+}
+
+func regcredsCheckFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.ManifestFlag,
+			Usage: "The manifest file written by 'registry-creds up --" + flags.ManifestFlag + "'. The roles, policies, attachments, tags and instance profiles it lists are compared with those in the account.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManifestSigningKeyFlag,
+			Usage: "[Optional] The KMS key the manifest was signed with. If specified, nothing is checked unless the manifest matches its signature.",
+		},
+		cli.StringFlag{
+			Name:  flags.FormatFlag,
+			Value: regcreds.TableOutputFormat,
+			Usage: "[Optional] The output format of the changes found. Valid values are 'table' and 'json' (an array, which is empty if nothing changed).",
+		},
+	}
+}
+
+// requireSessionTagsFlag is shared by 'up' and 'export-trust-policy', so that the exported trust policy matches
+func requireSessionTagsFlag() cli.Flag {
+	return cli.StringFlag{
+		Name:  flags.RequireSessionTagsFlag,
+		Usage: "[Optional] Session tags required to assume a new role, for attribute-based access control. Specify as a comma separated list of key value pairs (e.g. 'Team=payments,Project='); an empty value requires the tag with any value. The default trust policy also allows 'sts:TagSession', and both actions require the tags. Can't be used with the trust policy of a role bundle.",
+	}
+}
+
+// accountIDFlag is shared by 'up' and the commands which make no AWS requests, which can't look up the account
+func accountIDFlag() cli.Flag {
+	return cli.StringFlag{
+		Name:  flags.AccountIDFlag,
+		Usage: "[Optional] The 12 digit account ID which replaces '" + regcreds.AccountIDTemplate + "' in the permissions boundary and in the trust policy of a role bundle. 'registry-creds up' uses the account of the credentials if not specified; commands which make no AWS requests need it to be given.",
+	}
+}
+
+func regcredsExportTrustPolicyFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.RoleBundleFlag,
+			Usage: "[Optional] A YAML file declaring the trust policy of the role, as given to 'registry-creds up'. If not specified, the default trust policy for ECS tasks is printed.",
+		},
+		requireSessionTagsFlag(),
+		accountIDFlag(),
+	}
+}
+
+func regcredsListOrphansFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name:  flags.DeleteOrphansFlag,
+			Usage: "[Optional] If specified, the listed policies are deleted. Policies which have been attached to an entity since they were listed are kept.",
+		},
+	}
+}
+
+func regcredsVerifyManifestFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.ManifestFlag,
+			Usage: "The manifest file written by 'registry-creds up --" + flags.ManifestFlag + "'. The signature is read from the same file name with '.sig' appended.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManifestSigningKeyFlag,
+			Usage: "The KMS key the manifest was signed with.",
+		},
+	}
+}
+
+func regcredsListFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  flags.Output,
+			Value: regcreds.TableOutputFormat,
+			Usage: "[Optional] The output format. Valid values are 'table', 'json' (a single array) and 'jsonl' (one JSON object per line, printed as roles are found).",
+		},
+		cli.StringFlag{
+			Name:  flags.ManagementTagFlag,
+			Usage: "[Optional] The tag (in the format key=value) identifying IAM Roles created by the ECS CLI. (default: \"" + regcreds.DefaultManagementTagKey + "=" + regcreds.DefaultManagementTagValue + "\")",
+		},
+	}
+}
+
 func regcredsUpFlags() []cli.Flag {
 	return []cli.Flag{
 		cli.BoolFlag{
@@ -52,8 +398,28 @@ func regcredsUpFlags() []cli.Flag {
 			Usage: "[Optional] Specifies whether existing secrets should be updated with new credential values.",
 		},
 		cli.StringFlag{
+			Name:  flags.FormatFlag,
+			Usage: "[Optional] If set to 'json', the content of the output file is also printed to stdout as JSON once all resources are set up, with the same fields and values as the file. Logs are written to stderr, so stdout contains only the JSON. Can't be used with '--" + flags.SummaryOnlyFlag + "' or '--" + flags.PrintARNOnlyFlag + "'.",
+		},
+		cli.StringFlag{
+			Name:  flags.LogOutputFlag,
+			Usage: "[Optional] Where logs are written: '" + regcreds.LogOutputStderr + "' (the default), '" + regcreds.LogOutputStdout + "', or '" + regcreds.LogOutputSplit + "' to write info and debug logs to stdout and warnings and errors to stderr. Logs can only be written to stdout if no results are printed there.",
+		},
+		cli.StringSliceFlag{
+			Name:  flags.SecretFlag,
+			Usage: "[Optional] A registry and the ARN of its existing Secrets Manager secret, as 'name=arn', used instead of a credential file. Can be repeated. The secrets are granted with the default actions, and can't be combined with a credential file.",
+		},
+		cli.StringSliceFlag{
 			Name:  flags.RoleNameFlag,
-			Usage: "The name to use for the new task execution role. If the role already exists, new policies will be attached to the existing role.",
+			Usage: "The name to use for the new task execution role. If the role already exists, new policies will be attached to the existing role. Specify the flag more than once to attach a single new policy to several roles.",
+		},
+		cli.StringFlag{
+			Name:  flags.RoleNamePrefixFlag,
+			Usage: "[Optional] A prefix added to each role name given with --" + flags.RoleNameFlag + ". Overrides the role_name_prefix of the cluster configuration.",
+		},
+		cli.StringFlag{
+			Name:  flags.RoleNameSuffixFlag,
+			Usage: "[Optional] A suffix added to each role name given with --" + flags.RoleNameFlag + ". Overrides the role_name_suffix of the cluster configuration.",
 		},
 		cli.BoolFlag{
 			Name:  flags.NoRoleFlag,
@@ -67,9 +433,268 @@ func regcredsUpFlags() []cli.Flag {
 			Name:  flags.OutputDirFlag,
 			Usage: "[Optional] The directory where the output file should be created. If none specified, file will be created in the current working directory.",
 		},
+		cli.StringFlag{
+			Name:  flags.OutputFileNameFlag,
+			Usage: "[Optional] A template for the name of the output file; '{{.RoleName}}' and '{{.Timestamp}}' are replaced with the role name and creation time (e.g. '{{.RoleName}}-creds.yml'). Path separators are only allowed with '--" + flags.OutputDirFlag + "'. (default: \"" + regcredio.DefaultOutputFileNameTemplate + "\")",
+		},
+		cli.BoolFlag{
+			Name:  flags.OutputRequestIDsFlag,
+			Usage: "[Optional] If specified, the output file and '--format json' output include the AWS request IDs of the CreateRole, CreatePolicy and AttachRolePolicy calls made for each task execution role, for finding them in CloudTrail.",
+		},
+		cli.StringFlag{
+			Name:  flags.OutputTemplateFlag,
+			Usage: "[Optional] A file containing a Go template which is rendered over the results of the command (e.g. '{{.RoleARN}}', '{{range $registry, $entry := .Entries}}') to produce the output file, instead of the YAML format used by 'compose'. The template is checked before any resources are created.",
+		},
+		cli.StringFlag{
+			Name:  flags.OutputPermissionsFlag,
+			Usage: "[Optional] The octal permissions of the output file and the other files written by the command, such as the manifest (default: \"0600\"). The owner must be able to read and write the files. On Windows, the access control list of the files is inherited from their directory.",
+		},
+		cli.StringFlag{
+			Name:  flags.OutputPerEnvFlag,
+			Usage: "[Optional] A comma separated list of environments (e.g. 'dev,staging,prod'). The command is run once per environment with " + regcreds.EnvironmentEnvVar + " set to its name, so that '${" + regcreds.EnvironmentEnvVar + "}' in the input file and '--" + flags.RoleNameFlag + "' selects its values, and a separate output file and manifest are written for each environment.",
+		},
+		cli.BoolFlag{
+			Name:  flags.FailFastFlag,
+			Usage: "[Optional] Stop at the first failure. With several values of '--" + flags.RoleNameFlag + "', no further roles are set up and no policy is created once a role fails; by default, the other roles are still set up. Environments given with '--" + flags.OutputPerEnvFlag + "' always stop at the first failure unless '--" + flags.ContinueFlag + "' is given.",
+		},
+		cli.BoolFlag{
+			Name:  flags.ContinueFlag,
+			Usage: "[Optional] With '--" + flags.OutputPerEnvFlag + "', set up the remaining environments after one fails, then log which environments succeeded and which failed. The command exits with an error if any failed. Roles given with '--" + flags.RoleNameFlag + "' are always all set up unless '--" + flags.FailFastFlag + "' is given.",
+		},
+		cli.StringFlag{
+			Name:  flags.NotifyURLFlag,
+			Usage: "[Optional] After the command succeeds, POST its result as JSON to this http or https URL: the region, the time, and each role with its ARN and attached policy ARNs.",
+		},
+		cli.StringSliceFlag{
+			Name:  flags.NotifyHeaderFlag,
+			Usage: "[Optional] A header to send with the notification, as 'Name: value' (e.g. 'Authorization: Bearer <token>'). Can be repeated.",
+		},
+		cli.BoolFlag{
+			Name:  flags.NotifyRequiredFlag,
+			Usage: "[Optional] Fail the command if the notification can't be sent or isn't accepted with a 2xx status. By default, the failure is logged as a warning.",
+		},
+		cli.BoolFlag{
+			Name:  flags.ValidateSecretsExistFlag,
+			Usage: "[Optional] Before making any changes, check that every existing secret or parameter given with 'secrets_manager_arn' in the input file exists, and report all that don't. Secrets are checked with DescribeSecret and parameters with GetParameter, without decryption, so 'secretsmanager:DescribeSecret' and 'ssm:GetParameter' permissions are needed.",
+		},
 		cli.StringFlag{
 			Name:  flags.ResourceTagsFlag,
 			Usage: "[Optional] The AWS Resource tags to add to the Secrets Manager secrets and new IAM Role. Existing IAM Roles cannot be tagged.",
 		},
+		cli.BoolFlag{
+			Name:  flags.EnforceTagPolicyFlag,
+			Usage: "[Optional] If specified, the tags of new IAM Roles, including the management tag and the tags of a role bundle, are checked against the effective tag policy of the account's organization before any resources are created. Each tag key of the policy must be applied, with the policy's capitalization and one of its allowed values. Requires 'organizations:DescribeEffectivePolicy'.",
+		},
+		cli.BoolFlag{
+			Name:  flags.LintFlag,
+			Usage: "[Optional] If specified, the statements of the new policy are checked against lint rules before any IAM changes are made, and the command fails if any rule is violated. By default, wildcard actions and resources containing '*' (other than for 'ecr:GetAuthorizationToken') are not allowed.",
+		},
+		cli.StringFlag{
+			Name:  flags.LintRulesFlag,
+			Usage: "[Optional] The YAML file of lint rules used with '--" + flags.LintFlag + "', which can allow wildcard actions or specific wildcard resources and require condition keys in each statement.",
+		},
+		cli.StringFlag{
+			Name:  flags.VersionStageFlag,
+			Usage: "[Optional] Restricts the task execution role to reading only the specified version stage (e.g. AWSCURRENT) of each secret.",
+		},
+		cli.BoolFlag{
+			Name:  flags.SeparateStatementsFlag,
+			Usage: "[Optional] If specified, each registry is granted access in its own policy statements. By default, if all secrets and KMS keys are in the same account and region, the statements with the same actions and conditions are combined into one listing all of their resources.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManagementTagFlag,
+			Usage: "[Optional] The tag (in the format key=value) added to new IAM Roles to identify them as created by the ECS CLI. (default: \"" + regcreds.DefaultManagementTagKey + "=" + regcreds.DefaultManagementTagValue + "\")",
+		},
+		cli.StringFlag{
+			Name:  flags.PermissionsBoundaryFlag,
+			Usage: "[Optional] The ARN of the IAM policy to set as the permissions boundary of a new task execution role. To read the ARN from SSM Parameter Store, specify 'ssm:' followed by the parameter name (e.g. ssm:/iam/boundary-arn). '" + regcreds.AccountIDTemplate + "' is replaced with the account ID (e.g. arn:aws:iam::" + regcreds.AccountIDTemplate + ":policy/boundary).",
+		},
+		cli.StringFlag{
+			Name:  flags.IAMPathFlag,
+			Usage: "[Optional] The IAM path of a new task execution role (e.g. /teams/payments/), which must begin and end with '/'. Overrides the path of a role bundle. If the ECS CLI config has 'boundary_rules', the permissions boundary required for the path is checked before any role is created.",
+		},
+		cli.StringFlag{
+			Name:  flags.RoleBundleFlag,
+			Usage: "[Optional] A YAML file declaring the trust policy, permissions boundary, tags and path of a new task execution role. Values given with other flags override the bundle.",
+		},
+		accountIDFlag(),
+		cli.BoolFlag{
+			Name:  flags.CreateInstanceProfileFlag,
+			Usage: "[Optional] If specified, an instance profile with the same name as the task execution role is created (if it does not already exist) and the role is added to it, for use with the EC2 launch type.",
+		},
+		cli.StringSliceFlag{
+			Name:  flags.AttachPolicyFlag,
+			Usage: "[Optional] The ARN of an existing managed policy (e.g. for X-Ray or ECR) to attach to the task execution role after the new policy. Specify the flag more than once to attach several policies.",
+		},
+		cli.StringFlag{
+			Name:  flags.AttachOrderFlag,
+			Value: regcreds.AttachOrderManagedFirst,
+			Usage: "[Optional] The order in which the AWS managed task execution role policy and the new policy are attached to the task execution role. Valid values are '" + regcreds.AttachOrderManagedFirst + "' and '" + regcreds.AttachOrderGeneratedFirst + "'. The order has no effect on permissions, but some policy tools expect a particular order.",
+		},
+		cli.BoolFlag{
+			Name:  flags.SkipPresentManagedFlag,
+			Usage: "[Optional] If specified, the AWS managed task execution role policy is not attached to an existing task execution role which already has it, or the policy given with '--" + flags.ManagedPolicyARNFlag + "', attached. The new policy and any policies given with '--" + flags.AttachPolicyFlag + "' are still attached.",
+		},
+		cli.IntFlag{
+			Name:  flags.MaxPoliciesPerRoleFlag,
+			Value: regcreds.DefaultMaxPoliciesPerRole,
+			Usage: "[Optional] The number of managed policies which may be attached to an existing task execution role. The command fails before creating the new policy if attaching it would exceed this limit.",
+		},
+		cli.IntFlag{
+			Name:  flags.RetryAccessDeniedFlag,
+			Usage: "[Optional] The number of seconds after creating the task execution role or policy during which attaching a policy is retried if access is denied, since new IAM resources can take a few seconds to become usable. Access denied errors after this window are not retried, since they usually mean missing permissions. Defaults to 0 (no retries).",
+		},
+		cli.IntFlag{
+			Name:  flags.ConcurrencyFlag,
+			Value: regcreds.DefaultConcurrency,
+			Usage: "[Optional] The number of registries, and then task execution roles, which are set up at once. Results and output files are in the same order as with the default of 1, but log lines of different roles may be interleaved. With '--" + flags.FailFastFlag + "', the roles already being set up when one fails are completed.",
+		},
+		cli.BoolFlag{
+			Name:  flags.PruneStaleFlag,
+			Usage: "[Optional] If specified, policies previously generated by the ECS CLI are detached (oldest first) from an existing task execution role to stay within '--" + flags.MaxPoliciesPerRoleFlag + "', and deleted if they are no longer attached to anything.",
+		},
+		cli.StringFlag{
+			Name:  flags.DenyUnlessTagFlag,
+			Usage: "[Optional] Add a statement to the new policy denying its access unless a tag condition is met, as a safety net. Specify as " + regcreds.TagConditionPrincipal + ":<key>=<value> to deny access to roles without the tag (each task execution role must have it), or " + regcreds.TagConditionRequest + ":<key>=<value> to deny requests which carry the tag with a different value.",
+		},
+		cli.BoolFlag{
+			Name:  flags.RefreshExistingPolicyFlag,
+			Usage: "[Optional] If specified, the policy previously generated by the ECS CLI for an existing task execution role is given a new version with the updated permissions, instead of attaching another policy. The oldest versions are deleted to stay within the IAM limit of 5. Can't be used with '--" + flags.PruneStaleFlag + "'.",
+		},
+		cli.StringFlag{
+			Name:  flags.PolicyNameFlag,
+			Usage: "[Optional] The exact name of the new policy, instead of the generated '<role>-policy-<timestamp>' name. Up to 128 letters, numbers or any of '_+=,.@-'. Fails if a policy with the name exists, unless '--" + flags.UpdateExistingFlag + "' is specified.",
+		},
+		cli.BoolFlag{
+			Name:  flags.UpdateExistingFlag,
+			Usage: "[Optional] If specified with '--" + flags.PolicyNameFlag + "', a policy which already has the name is given a new version with the updated permissions. The oldest versions are deleted to stay within the IAM limit of 5.",
+		},
+		cli.StringFlag{
+			Name:  flags.ReconcileTagsFlag,
+			Usage: "[Optional] How the tags of existing task execution roles are updated. With '" + regcreds.ReconcileTagsAdditive + "' (the default), existing roles keep their tags. With '" + regcreds.ReconcileTagsExact + "', existing roles are given the tags of new roles, and tags applied this way by earlier runs which are no longer specified are removed; other tags are never removed. Requires 'iam:ListRoleTags', 'iam:TagRole' and 'iam:UntagRole'.",
+		},
+		cli.StringFlag{
+			Name:  flags.OnTagFailureFlag,
+			Value: regcreds.TagFailureFail,
+			Usage: "[Optional] What happens if tags can't be applied to the registry secrets or existing roles, or the idempotency key can't be recorded. With '" + regcreds.TagFailureFail + "' (the default), the command fails. With '" + regcreds.TagFailureWarn + "', a warning lists the tags which were not applied and the command continues.",
+		},
+		cli.BoolFlag{
+			Name:  flags.PolicyNameFromHashFlag,
+			Usage: "[Optional] If specified, the new policy is named after a hash of its document, and an existing policy with that name is reused instead of creating an identical policy. Can't be used with '--" + flags.PolicyNameFlag + "' or '--" + flags.RefreshExistingPolicyFlag + "'.",
+		},
+		requireSessionTagsFlag(),
+		cli.BoolFlag{
+			Name:  flags.SimulateFlag,
+			Usage: "[Optional] If specified, no resources are created or changed. Instead, the IAM policy simulator is run for each action the new policy would grant each task execution role, and the decision for each action and resource is printed. Existing roles are simulated with their attached policies, permissions boundary and service control policies. Fails if any action is denied. Requires 'iam:SimulatePrincipalPolicy' and 'iam:SimulateCustomPolicy'.",
+		},
+		cli.BoolFlag{
+			Name:  flags.CheckKMSKeyPolicyFlag,
+			Usage: "[Optional] If specified, the key policy of each KMS key in the input file is read once the task execution roles are set up, and a warning is printed for each role which the key policy doesn't appear to allow 'kms:Decrypt', directly or through its account. Requires 'kms:GetKeyPolicy'.",
+		},
+		cli.StringFlag{
+			Name:  flags.MultiRegionKeysFlag,
+			Usage: "[Optional] How kms:Decrypt is granted on a multi-Region KMS key (a key ID starting with 'mrk-'). With 'primary-only' (the default), only the key in the region of its ARN is granted. With 'all-replicas', the key is granted in any region, so that tasks in the regions of its replicas can also decrypt the secret. Valid values: primary-only, all-replicas.",
+		},
+		cli.BoolFlag{
+			Name:  flags.AssumeKMSARNValidFlag,
+			Usage: "[Optional] If specified, KMS key ARNs are used as given when access to describe the key is denied, instead of failing.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManifestFlag,
+			Usage: "[Optional] The file to write a JSON manifest of the resources created by this command to, for use with 'registry-creds down'.",
+		},
+		strictARNParsingFlag(),
+		cli.BoolFlag{
+			Name:  flags.CompressFlag,
+			Usage: "[Optional] If specified, the output file and manifest are written gzip compressed, with '" + regcredio.CompressedFileExtension + "' appended to their names. Compressed files are read without any extra flags.",
+		},
+		cli.StringFlag{
+			Name:  flags.EmitSummaryMDFlag,
+			Usage: "[Optional] The file to write a markdown summary of the run to: the roles with their ARNs and tags, and the secrets and KMS keys they grant access to. With '--" + flags.OutputPerEnvFlag + "', each environment's name is added to the file name as for '--" + flags.ManifestFlag + "'.",
+		},
+		cli.StringFlag{
+			Name:  flags.EmitInventoryFlag,
+			Usage: "[Optional] The file to write a JSON compliance inventory of the run to: the roles with their tags and attached policies, the registry credentials policy with its grants, and the secrets, each with whether the run created, updated or reused it. With '--" + flags.OutputPerEnvFlag + "', each environment's name is added to the file name as for '--" + flags.ManifestFlag + "'.",
+		},
+		cli.StringFlag{
+			Name:  flags.EmitSidMapFlag,
+			Usage: "[Optional] The file to write a JSON map of the statements of the new policy to: each statement's Sid, actions and resources, with the registry, name, secret and KMS key from the input file that it grants access to. With '--" + flags.OutputPerEnvFlag + "', each environment's name is added to the file name as for '--" + flags.ManifestFlag + "'.",
+		},
+		cli.StringFlag{
+			Name:  flags.EmitTerraformImportFlag,
+			Usage: "[Optional] The file to write a shell script of 'terraform import' commands to, one for each task execution role, instance profile, policy attachment, new policy and secret created by the ECS CLI, or '-' to print them. With '--" + flags.OutputPerEnvFlag + "', each environment's name is added to the file name as for '--" + flags.ManifestFlag + "'.",
+		},
+		cli.StringFlag{
+			Name:  flags.EmitCFNTemplateFlag,
+			Usage: "[Optional] The file to write a CloudFormation template of the task execution roles, instance profiles and policy created by the ECS CLI to, with each resource's actual name and properties, so that they can be imported into a stack. The resources to import are written next to it to '<name>" + "-resources-to-import.json'. With '--" + flags.OutputPerEnvFlag + "', each environment's name is added to the file name as for '--" + flags.ManifestFlag + "'.",
+		},
+		cli.BoolFlag{
+			Name:  flags.IncludeECRFlag,
+			Usage: "[Optional] If specified, the new policy also grants pull access to Amazon ECR: to the repositories listed under 'ecr_repositories' in the input file, or to all repositories if none are listed.",
+		},
+		cli.BoolFlag{
+			Name:  flags.MinimalManagedFlag,
+			Usage: "[Optional] If specified, the AWS managed task execution role policy is not attached. Instead, the new policy grants only the ECR and CloudWatch Logs actions tasks need: " + strings.Join(regcreds.DefaultMinimalManagedActions, ", ") + ". CloudWatch Logs actions are granted on the log groups in the region.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManagedPolicyARNFlag,
+			Usage: "[Optional] The ARN of the managed policy attached to each role in place of the AWS managed task execution role policy of the region (arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy in the 'aws' partition), e.g. if it has been renamed. The policy is checked to exist before any resources are created. Can't be used with '--" + flags.MinimalManagedFlag + "'.",
+		},
+		cli.StringSliceFlag{
+			Name:  flags.MinimalManagedActionsFlag,
+			Usage: "[Optional] With '--" + flags.MinimalManagedFlag + "', the complete set of ECR and CloudWatch Logs actions to grant instead of the defaults, e.g. to also grant 'logs:CreateLogGroup'. Specify the flag once per action.",
+		},
+		cli.StringFlag{
+			Name:  flags.IdempotencyKeyFlag,
+			Usage: "[Optional] A key identifying this run, recorded as a tag on each task execution role. If a later run with the same key finds the roles set up by a completed run with the same configuration, it makes no changes and reports the existing roles and policy. A different configuration with the same key is an error.",
+		},
+		cli.StringFlag{
+			Name:  flags.SaveAsFlag,
+			Usage: "[Optional] A key to save the ARN of the task execution role (the first, if several are given) under in the ECS CLI config file, replacing any role saved with the key. Compose commands use the saved role when the task_execution_role of the ECS params file is the key. A cluster configuration must exist.",
+		},
+		cli.BoolFlag{
+			Name:  flags.OnlyIfChangedFlag,
+			Usage: "[Optional] If specified, no changes are made when every task execution role already exists with the management tag and has a policy generated by the ECS CLI with the same document as the new policy attached, along with the other policies it would attach. The documents are compared by their hash, and the existing roles and policy are reported. A run which would create or update a secret, or create a role, makes its changes as usual.",
+		},
+		cli.StringFlag{
+			Name:  flags.ManifestSigningKeyFlag,
+			Usage: "[Optional] The asymmetric KMS key (with key usage SIGN_VERIFY) to sign the manifest with. The signature is written to the manifest file name with '.sig' appended, and is checked by 'registry-creds verify-manifest' and 'registry-creds down --" + flags.ManifestSigningKeyFlag + "'.",
+		},
+		cli.BoolFlag{
+			Name:  flags.PrintARNOnlyFlag,
+			Usage: "[Optional] If specified, only the ARN of each task execution role is printed on success, one per line, e.g. for use in scripts. Full logs are still printed if the command fails.",
+		},
+		cli.BoolFlag{
+			Name:  flags.PrintPolicyFlag,
+			Usage: "[Optional] If specified, the document of the registry credentials policy is printed to stdout as indented JSON on success, exactly as it was submitted to IAM. Can't be used with '--" + flags.FormatFlag + "', '--" + flags.SummaryOnlyFlag + "' or '--" + flags.PrintARNOnlyFlag + "'.",
+		},
+		cli.BoolFlag{
+			Name:  flags.PrintStepsFlag,
+			Usage: "[Optional] If specified, the steps the command would take are printed to stdout as a numbered list, with the names of the secrets, roles and policies, and nothing is created or changed. Existing resources are not looked up, so steps which may find an existing resource give both outcomes.",
+		},
+		cli.BoolFlag{
+			Name:  flags.VerifyAccountFlag,
+			Usage: "[Optional] If specified, the account of the credentials is looked up with STS, and the command fails if an existing task execution role is in a different account instead of reusing it.",
+		},
+		cli.BoolFlag{
+			Name:  flags.AllowEmptyFlag,
+			Usage: "[Optional] If specified, an input file without registry credentials creates only the task execution role with the managed task execution role policy (and any policies given with --" + flags.AttachPolicyFlag + "), instead of failing. No secrets or registry credentials policy are created.",
+		},
+		cli.BoolFlag{
+			Name:  flags.RoleOnlyFlag,
+			Usage: "[Optional] If specified, the task execution role is created with only the managed task execution role policy (and any policies given with --" + flags.AttachPolicyFlag + ") attached. The secrets are still created, but no policy granting access to them is created. The input file may have no registry credentials.",
+		},
+		cli.BoolFlag{
+			Name:  flags.SummaryOnlyFlag,
+			Usage: "[Optional] If specified, only a single summary line is printed on success. Full logs are still printed if the command fails.",
+		},
+		cli.BoolFlag{
+			Name:  flags.CheckPermissionsFlag,
+			Usage: "[Optional] If specified, the IAM policy simulator checks that the caller is allowed to create and tag the new roles, create the policy, attach policies to the roles and describe the KMS keys, before any secret or role is created. The missing permissions are listed and nothing is changed. Requires 'iam:SimulatePrincipalPolicy' and 'sts:GetCallerIdentity'.",
+		},
+		cli.BoolFlag{
+			Name:  flags.ExplainFlag,
+			Usage: "[Optional] If specified, a plain-English summary of what each role can access is logged on success, e.g. the number of secrets it can read, the KMS keys it can decrypt with and the policies attached to it.",
+		},
 	}
 }
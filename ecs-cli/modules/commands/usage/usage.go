@@ -98,6 +98,16 @@ const (
 
 // Regcreds
 const (
-	RegistryCreds   = "Facilitates the creation and use of private registry credentials within ECS."
-	RegistryCredsUp = "Uses a YAML input file to generate AWS Secrets Manager secrets and an IAM Task Execution Role for use in an ECS Task Definition."
+	RegistryCreds                  = "Facilitates the creation and use of private registry credentials within ECS."
+	RegistryCredsUp                = "Uses a YAML input file to generate AWS Secrets Manager secrets and an IAM Task Execution Role for use in an ECS Task Definition."
+	RegistryCredsList              = "Lists the IAM Task Execution Roles created by the ECS CLI."
+	RegistryCredsDown              = "Removes the resources listed in a manifest written by 'registry-creds up'."
+	RegistryCredsDescribe          = "Prints the secrets and KMS keys an IAM Task Execution Role created by the ECS CLI can access."
+	RegistryCredsImport            = "Tags an existing IAM Task Execution Role as created by the ECS CLI and writes a manifest for it, without changing its policies."
+	RegistryCredsValidate          = "Checks a registry credentials input file for problems without making any AWS requests."
+	RegistryCredsExportTrustPolicy = "Prints the trust policy document 'registry-creds up' uses for a new IAM Task Execution Role without making any AWS requests."
+	RegistryCredsListOrphans       = "Lists the IAM Policies generated by the ECS CLI which are no longer attached to any user, group or role, and optionally deletes them."
+	RegistryCredsVerifyManifest    = "Checks the signature of a manifest written by 'registry-creds up --manifest-signing-key'."
+	RegistryCredsDiffManifest      = "Prints the differences in roles, policies and secrets between two manifests or two output files written by 'registry-creds up', without making any AWS requests."
+	RegistryCredsCheck             = "Checks that the resources listed in a manifest written by 'registry-creds up' have not been changed or removed since, and exits with an error if they have. No changes are made."
 )
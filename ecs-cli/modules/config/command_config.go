@@ -36,6 +36,12 @@ type CommandConfig struct {
 	ComposeProjectNamePrefix string // Deprecated; remains for backwards compatibility
 	CFNStackName             string
 	LaunchType               string
+	RoleNamePrefix           string
+	RoleNameSuffix           string
+	// SavedRoles are the ARNs of the roles saved in the config, by key
+	SavedRoles map[string]string
+	// BoundaryRules are the permissions boundaries required of roles, by IAM path prefix
+	BoundaryRules map[string]string
 }
 
 func (c *CommandConfig) Region() string {
@@ -92,6 +98,10 @@ func NewCommandConfig(context *cli.Context, rdwr ReadWriter) (*CommandConfig, er
 		ComposeProjectNamePrefix: ecsConfig.ComposeProjectNamePrefix, // deprecated; remains for backwards compatibility
 		CFNStackName:             ecsConfig.CFNStackName,
 		LaunchType:               ecsConfig.DefaultLaunchType,
+		RoleNamePrefix:           ecsConfig.RoleNamePrefix,
+		RoleNameSuffix:           ecsConfig.RoleNameSuffix,
+		SavedRoles:               ecsConfig.SavedRoles,
+		BoundaryRules:            ecsConfig.BoundaryRules,
 	}, nil
 }
 
@@ -135,5 +145,9 @@ func NewCommandConfigWithRegion(context *cli.Context, rdwr ReadWriter, region st
 		ComposeProjectNamePrefix: ecsConfig.ComposeProjectNamePrefix, // deprecated; remains for backwards compatibility
 		CFNStackName:             ecsConfig.CFNStackName,
 		LaunchType:               ecsConfig.DefaultLaunchType,
+		RoleNamePrefix:           ecsConfig.RoleNamePrefix,
+		RoleNameSuffix:           ecsConfig.RoleNameSuffix,
+		SavedRoles:               ecsConfig.SavedRoles,
+		BoundaryRules:            ecsConfig.BoundaryRules,
 	}, nil
 }
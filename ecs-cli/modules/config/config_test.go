@@ -16,6 +16,7 @@ package config
 import (
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -370,6 +371,51 @@ func TestCredentialsWhenUsingAssumeRoleEnvVar(t *testing.T) {
 	testCredentialsInSessionWithConfig(t, context, ecsConfig, startingConfig, assumeRoleAccessKey, assumeRoleSecretKey)
 }
 
+func TestCredentialsWhenUsingWebIdentityEnvVar(t *testing.T) {
+	// defaults
+	ecsConfig := NewLocalConfig(clusterName)
+	ecsConfig.Region = region
+
+	tokenFile, err := ioutil.TempFile("", "token")
+	assert.NoError(t, err, "Unexpected error creating token file")
+	defer os.Remove(tokenFile.Name())
+	tokenFile.WriteString("oidc-token")
+	tokenFile.Close()
+
+	// set variables for test
+	// web identity from the environment takes precedence over the ECS config
+	ecsConfig.AWSAccessKey = awsAccessKey
+	ecsConfig.AWSSecretKey = awsSecretKey
+	os.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile.Name())
+	os.Setenv("AWS_ROLE_ARN", "arn:aws:iam::111111111111:role/ci")
+	defer os.Clearenv()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const respMsg = `
+	<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+		<AssumeRoleWithWebIdentityResult>
+			<Credentials>
+				<AccessKeyId>` + assumeRoleAccessKey + `</AccessKeyId>
+				<SecretAccessKey>` + assumeRoleSecretKey + `</SecretAccessKey>
+				<SessionToken>SESSION_TOKEN</SessionToken>
+				<Expiration>%s</Expiration>
+			</Credentials>
+		</AssumeRoleWithWebIdentityResult>
+	</AssumeRoleWithWebIdentityResponse>
+	`
+		w.Write([]byte(fmt.Sprintf(respMsg, time.Now().Add(15*time.Minute).Format("2006-01-02T15:04:05Z"))))
+	}))
+	defer server.Close()
+
+	startingConfig := aws.Config{}
+	startingConfig.Endpoint = aws.String(server.URL)
+	startingConfig.DisableSSL = aws.Bool(true)
+
+	// invoke test and verify
+	context := cli.NewContext(nil, flag.NewFlagSet("ecs-cli-up", 0), nil)
+	testCredentialsInSessionWithConfig(t, context, ecsConfig, &startingConfig, assumeRoleAccessKey, assumeRoleSecretKey)
+}
+
 func assumeRoleTestHelper() *aws.Config {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		const respMsg = `
@@ -54,6 +54,10 @@ type LocalConfig struct {
 	CFNStackName             string
 	CFNStackNamePrefix       string // Deprecated; remains for backwards compatibility
 	DefaultLaunchType        string
+	RoleNamePrefix           string
+	RoleNameSuffix           string
+	SavedRoles               map[string]string
+	BoundaryRules            map[string]string
 }
 
 // Profile is a simple struct for storing a single AWS profile config
@@ -70,6 +74,8 @@ type Cluster struct {
 	ComposeServiceNamePrefix string `yaml:"compose-service-name-prefix,omitempty"`
 	CFNStackName             string `yaml:"cfn-stack-name,omitempty"`
 	DefaultLaunchType        string `yaml:"default_launch_type"`
+	RoleNamePrefix           string `yaml:"role_name_prefix,omitempty"`
+	RoleNameSuffix           string `yaml:"role_name_suffix,omitempty"`
 }
 
 // ClusterConfig is the top level struct representing the cluster config file
@@ -77,6 +83,11 @@ type ClusterConfig struct {
 	Version  string
 	Default  string             `yaml:"default"`
 	Clusters map[string]Cluster `yaml:"clusters"`
+	// Roles are the ARNs of task execution roles saved by 'registry-creds up --save-as', by key
+	Roles map[string]string `yaml:"roles,omitempty"`
+	// BoundaryRules map IAM path prefixes to the permissions boundary which 'registry-creds up' requires of roles
+	// created at those paths
+	BoundaryRules map[string]string `yaml:"boundary_rules,omitempty"`
 }
 
 // ProfileConfig is the top level struct representing the Credentials file
@@ -113,6 +124,7 @@ func NewLocalConfig(cluster string) *LocalConfig {
 //   a) ECS_PROFILE
 //   b) AWS_PROFILE
 //   c) AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, Optional: AWS_SESSION_TOKEN
+//   d) AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN, Optional: AWS_ROLE_SESSION_NAME
 //  3) ECS Config - attempts to fetch the credentials from the default ECS Profile
 //  4) Default AWS Profile - attempts to use credentials (aws_access_key_id, aws_secret_access_key) or assume_role (role_arn, source_profile) from AWS profile name
 //    a) AWS_DEFAULT_PROFILE environment variable (defaults to 'default')
@@ -154,7 +166,7 @@ func (cfg *LocalConfig) toAWSSessionWithConfig(context *cli.Context, svcConfig *
 		keyID, secretKey := unsetEnvVars()
 		defer resetEnvVars(keyID, secretKey)
 		return sessionFromECSConfig(cfg, region, svcConfig)
-	} else if hasEnvVars(context) {
+	} else if hasEnvVars(context) || hasWebIdentityEnvVars() {
 		return sessionFromProfile("", region, svcConfig)
 	} else if isDefaultECSProfileCase(cfg) {
 		return sessionFromECSConfig(cfg, region, svcConfig)
@@ -212,6 +224,11 @@ func hasEnvVars(context *cli.Context) bool {
 	return (os.Getenv(flags.AWSSecretKeyEnvVar) != "" && os.Getenv(flags.AWSAccessKeyEnvVar) != "")
 }
 
+// hasWebIdentityEnvVars is true if a web identity token is provided in the environment, e.g. by an OIDC provider in CI
+func hasWebIdentityEnvVars() bool {
+	return os.Getenv(flags.AWSWebIdentityTokenFileEnvVar) != ""
+}
+
 func isDefaultECSProfileCase(cfg *LocalConfig) bool {
 	return (cfg.AWSAccessKey != "" || cfg.AWSSecretKey != "" || cfg.AWSProfile != "")
 }
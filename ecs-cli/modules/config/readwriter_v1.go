@@ -129,6 +129,10 @@ func readClusterConfig(path string, clusterConfigKey string, localConfig *LocalC
 	localConfig.ComposeServiceNamePrefix = cluster.ComposeServiceNamePrefix
 	localConfig.CFNStackName = cluster.CFNStackName
 	localConfig.DefaultLaunchType = cluster.DefaultLaunchType
+	localConfig.RoleNamePrefix = cluster.RoleNamePrefix
+	localConfig.RoleNameSuffix = cluster.RoleNameSuffix
+	localConfig.SavedRoles = config.Roles
+	localConfig.BoundaryRules = config.BoundaryRules
 	// Fields must be explicitly set as empty because the iniReadWriter will set them to default
 	localConfig.ComposeProjectNamePrefix = ""
 	localConfig.CFNStackNamePrefix = ""
@@ -270,6 +274,24 @@ func (rdwr *YAMLReadWriter) SaveCluster(configName string, cluster *Cluster) err
 	return rdwr.saveConfig(path, config)
 }
 
+// SaveRole saves the ARN of a role under the given key, replacing any role saved with the key. The cluster config
+// file must already exist.
+func (rdwr *YAMLReadWriter) SaveRole(key, roleARN string) error {
+	path := ConfigFilePath(rdwr.destination)
+	config, err := ReadClusterFile(path)
+	if err != nil {
+		return errors.Wrap(err, "a cluster configuration is needed to save a role; configure one with 'ecs-cli configure'")
+	}
+
+	if config.Roles == nil {
+		config.Roles = make(map[string]string)
+	}
+	config.Roles[key] = roleARN
+
+	// save the modified config
+	return rdwr.saveConfig(path, config)
+}
+
 // SetDefaultProfile updates which set of credentials is defined as default
 func (rdwr *YAMLReadWriter) SetDefaultProfile(configName string) error {
 	path := credentialsFilePath(rdwr.destination)
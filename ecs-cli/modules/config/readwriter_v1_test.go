@@ -308,6 +308,100 @@ clusters:
 	assert.Equal(t, LaunchTypeEC2, config.DefaultLaunchType)
 }
 
+func TestReadClusterConfigFileWithRoleNameAffixes(t *testing.T) {
+	configContents := `default: prod_config
+clusters:
+  prod_config:
+    cluster: cli-demo-prod
+    region: us-east-2
+    role_name_prefix: team-a-
+    role_name_suffix: -prod
+`
+
+	dest, err := newMockDestination()
+	assert.NoError(t, err, "Error creating mock config destination")
+
+	err = os.MkdirAll(dest.Path, *dest.Mode)
+	assert.NoError(t, err, "Could not create config directory")
+
+	defer os.RemoveAll(dest.Path)
+
+	err = ioutil.WriteFile(dest.Path+"/"+clusterConfigFileName, []byte(configContents), *dest.Mode)
+	assert.NoError(t, err)
+
+	parser := setupParser(t, dest, false)
+
+	config, err := parser.Get("", "")
+	assert.NoError(t, err, "Error reading config")
+	assert.Equal(t, "team-a-", config.RoleNamePrefix, "RoleNamePrefix should be present.")
+	assert.Equal(t, "-prod", config.RoleNameSuffix, "RoleNameSuffix should be present.")
+}
+
+func TestReadClusterConfigFileWithBoundaryRules(t *testing.T) {
+	configContents := `default: prod_config
+clusters:
+  prod_config:
+    cluster: cli-demo-prod
+    region: us-east-2
+boundary_rules:
+  /teams/payments/: arn:aws:iam::123456789012:policy/payments-boundary
+`
+
+	dest, err := newMockDestination()
+	assert.NoError(t, err, "Error creating mock config destination")
+
+	err = os.MkdirAll(dest.Path, *dest.Mode)
+	assert.NoError(t, err, "Could not create config directory")
+
+	defer os.RemoveAll(dest.Path)
+
+	err = ioutil.WriteFile(dest.Path+"/"+clusterConfigFileName, []byte(configContents), *dest.Mode)
+	assert.NoError(t, err)
+
+	parser := setupParser(t, dest, false)
+
+	config, err := parser.Get("", "")
+	assert.NoError(t, err, "Error reading config")
+	assert.Equal(t, map[string]string{"/teams/payments/": "arn:aws:iam::123456789012:policy/payments-boundary"}, config.BoundaryRules, "BoundaryRules should be present.")
+}
+
+func TestSaveRole(t *testing.T) {
+	configContents := `default: prod_config
+clusters:
+  prod_config:
+    cluster: cli-demo-prod
+    region: us-east-2
+`
+
+	dest, err := newMockDestination()
+	assert.NoError(t, err, "Error creating mock config destination")
+
+	err = os.MkdirAll(dest.Path, *dest.Mode)
+	assert.NoError(t, err, "Could not create config directory")
+
+	defer os.RemoveAll(dest.Path)
+
+	parser := setupParser(t, dest, false)
+	err = parser.SaveRole("myrole-exec-role", "arn:aws:iam::123456789012:role/myRole")
+	assert.Error(t, err, "Expected error saving a role without a cluster configuration")
+
+	err = ioutil.WriteFile(dest.Path+"/"+clusterConfigFileName, []byte(configContents), *dest.Mode)
+	assert.NoError(t, err)
+
+	err = parser.SaveRole("myrole-exec-role", "arn:aws:iam::123456789012:role/myRole")
+	assert.NoError(t, err, "Error saving role")
+	err = parser.SaveRole("other-role", "arn:aws:iam::123456789012:role/otherRole")
+	assert.NoError(t, err, "Error saving role")
+
+	config, err := parser.Get("", "")
+	assert.NoError(t, err, "Error reading config")
+	assert.Equal(t, "cli-demo-prod", config.Cluster, "Cluster should be unchanged.")
+	assert.Equal(t, map[string]string{
+		"myrole-exec-role": "arn:aws:iam::123456789012:role/myRole",
+		"other-role":       "arn:aws:iam::123456789012:role/otherRole",
+	}, config.SavedRoles)
+}
+
 func TestOverwriteINIConfigFile(t *testing.T) {
 	configContents := `[ecs]
 cluster = very-long-cluster-name
@@ -45,6 +45,8 @@ type ConvertTaskDefParams struct {
 	ContainerConfigs       []adapter.ContainerConfig
 	ECSParams              *ECSParams
 	ECSRegistryCreds       *regcredio.ECSRegistryCredsOutput
+	// SavedRoles are the role ARNs saved in the ecs-cli config by key, which task_execution_role may refer to
+	SavedRoles map[string]string
 }
 
 // ConvertToTaskDefinition transforms the yaml configs to its ecs equivalent (task definition)
@@ -99,6 +101,12 @@ func ConvertToTaskDefinition(params ConvertTaskDefParams) (*ecs.TaskDefinition,
 	}
 
 	executionRoleArn := taskDefParams.executionRoleArn
+	if savedRoleArn, ok := params.SavedRoles[executionRoleArn]; ok {
+		log.WithFields(log.Fields{
+			"option name": "task_execution_role",
+		}).Infof("Using the role saved in the ecs-cli config as %s: %s", executionRoleArn, savedRoleArn)
+		executionRoleArn = savedRoleArn
+	}
 
 	placementConstraints := convertToTaskDefinitionConstraints(params.ECSParams)
 
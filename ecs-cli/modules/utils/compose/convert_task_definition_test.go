@@ -1626,6 +1626,29 @@ registry_credential_outputs:
 	assert.Equal(t, "arn:aws:secretsmanager::secret:amazon-ecs-cli-setup-my.example.registry.net", aws.StringValue(mysqlContainer.RepositoryCredentials.CredentialsParameter))
 }
 
+func TestConvertToTaskDefinitionWithSavedRole(t *testing.T) {
+	containerConfigs := testContainerConfigs([]string{"mysql"})
+	ecsParams := &ECSParams{TaskDefinition: EcsTaskDef{ExecutionRole: "myrole-exec-role"}}
+	savedRoleARN := "arn:aws:iam::123456789012:role/myTaskExecutionRole"
+
+	testParams := ConvertTaskDefParams{
+		TaskDefName:      projectName,
+		Volumes:          &adapter.Volumes{},
+		ContainerConfigs: containerConfigs,
+		ECSParams:        ecsParams,
+		SavedRoles:       map[string]string{"myrole-exec-role": savedRoleARN},
+	}
+	taskDefinition, err := ConvertToTaskDefinition(testParams)
+	assert.NoError(t, err, "Unexpected error when converting task definition")
+	assert.Equal(t, savedRoleARN, aws.StringValue(taskDefinition.ExecutionRoleArn))
+
+	// a role which isn't a saved key is used as given
+	testParams.SavedRoles = map[string]string{"other-key": savedRoleARN}
+	taskDefinition, err = ConvertToTaskDefinition(testParams)
+	assert.NoError(t, err, "Unexpected error when converting task definition")
+	assert.Equal(t, "myrole-exec-role", aws.StringValue(taskDefinition.ExecutionRoleArn))
+}
+
 func TestConvertToTaskDefinitionWithECSRegistryCreds_EmptyContainerCredMap(t *testing.T) {
 	containerConfigs := testContainerConfigs([]string{"mysql", "wordpress"})
 	credsNoContainersFileString := `version: "1"
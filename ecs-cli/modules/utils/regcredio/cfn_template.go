@@ -0,0 +1,77 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// CloudFormationTemplateVersion is the template format version of the templates written with '--emit-cfn-template'
+	CloudFormationTemplateVersion = "2010-09-09"
+
+	// CloudFormationDeletionPolicyRetain is the deletion policy CloudFormation requires of imported resources
+	CloudFormationDeletionPolicyRetain = "Retain"
+
+	cloudFormationImportFileSuffix = "-resources-to-import.json"
+)
+
+// CloudFormationTemplate is a template of the resources created by 'registry-creds up', to import them into a stack
+type CloudFormationTemplate struct {
+	AWSTemplateFormatVersion string
+	Description              string
+	Resources                map[string]CloudFormationResource
+}
+
+// CloudFormationResource is a resource of a CloudFormationTemplate
+type CloudFormationResource struct {
+	Type           string
+	DeletionPolicy string
+	Properties     map[string]interface{}
+}
+
+// CloudFormationResourceToImport identifies an existing resource for a CloudFormation IMPORT change set
+type CloudFormationResourceToImport struct {
+	ResourceType       string
+	LogicalResourceID  string `json:"LogicalResourceId"`
+	ResourceIdentifier map[string]string
+}
+
+// CloudFormationImportFileName returns the name of the file listing the resources to import with the template
+func CloudFormationImportFileName(templateFileName string) string {
+	return strings.TrimSuffix(templateFileName, filepath.Ext(templateFileName)) + cloudFormationImportFileSuffix
+}
+
+// WriteCloudFormationTemplateTo writes the template to the store, and the resources to import to the file named by
+// CloudFormationImportFileName, in the format expected by 'aws cloudformation create-change-set --resources-to-import'
+func WriteCloudFormationTemplateTo(store Store, filename string, template CloudFormationTemplate, resources []CloudFormationResourceToImport) error {
+	templateBytes, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = store.WriteFile(filename, append(templateBytes, '\n'), manifestFilePermissions); err != nil {
+		return err
+	}
+
+	if resources == nil {
+		resources = []CloudFormationResourceToImport{}
+	}
+	resourcesBytes, err := json.MarshalIndent(resources, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(CloudFormationImportFileName(filename), append(resourcesBytes, '\n'), manifestFilePermissions)
+}
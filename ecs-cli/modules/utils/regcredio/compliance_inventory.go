@@ -0,0 +1,102 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// ComplianceInventoryVersion is the version of the compliance inventory format. Fields may be added within a version,
+// but are never renamed or removed.
+const ComplianceInventoryVersion = "1"
+
+// the resource types of the inventory, named as in AWS Config and CloudFormation
+const (
+	ComplianceResourceTypeRole   = "AWS::IAM::Role"
+	ComplianceResourceTypePolicy = "AWS::IAM::ManagedPolicy"
+	ComplianceResourceTypeSecret = "AWS::SecretsManager::Secret"
+)
+
+// the changes a run made to a resource of the inventory
+const (
+	ComplianceChangeCreated = "created"
+	ComplianceChangeUpdated = "updated"
+	ComplianceChangeReused  = "reused"
+)
+
+// ECSComplianceInventory is the inventory of the resources set up by a "registry-creds up" run, for compliance tools
+// which ingest provisioning events
+type ECSComplianceInventory struct {
+	Version     string               `json:"version"`
+	GeneratedAt time.Time            `json:"generatedAt"`
+	Source      string               `json:"source"`
+	Region      string               `json:"region"`
+	Resources   []ComplianceResource `json:"resources"`
+}
+
+// ComplianceResource is a role, policy or secret used by the run. Roles list the policies attached to them, and
+// policies list the grants of their document.
+type ComplianceResource struct {
+	ResourceType string `json:"resourceType"`
+	ResourceARN  string `json:"resourceArn"`
+	ResourceName string `json:"resourceName"`
+	// Change is one of ComplianceChangeCreated, ComplianceChangeUpdated or ComplianceChangeReused
+	Change string `json:"change"`
+	// Tags are the tags the run applied to the resource
+	Tags             map[string]string `json:"tags"`
+	AttachedPolicies []string          `json:"attachedPolicies,omitempty"`
+	Grants           []ComplianceGrant `json:"grants,omitempty"`
+}
+
+// ComplianceGrant is a statement of a policy document
+type ComplianceGrant struct {
+	Sid       string                       `json:"sid,omitempty"`
+	Effect    string                       `json:"effect"`
+	Actions   []string                     `json:"actions"`
+	Resources []string                     `json:"resources"`
+	Condition map[string]map[string]string `json:"condition,omitempty"`
+}
+
+// FormatComplianceInventory returns the inventory as indented JSON. Resources are sorted by type and ARN, so that the
+// same resources always give the same document.
+func FormatComplianceInventory(inventory ECSComplianceInventory) ([]byte, error) {
+	inventory.Version = ComplianceInventoryVersion
+	if inventory.Resources == nil {
+		inventory.Resources = []ComplianceResource{}
+	}
+	for i := range inventory.Resources {
+		if inventory.Resources[i].Tags == nil {
+			inventory.Resources[i].Tags = map[string]string{}
+		}
+	}
+	sort.SliceStable(inventory.Resources, func(i, j int) bool {
+		left, right := inventory.Resources[i], inventory.Resources[j]
+		if left.ResourceType != right.ResourceType {
+			return left.ResourceType < right.ResourceType
+		}
+		return left.ResourceARN < right.ResourceARN
+	})
+	return json.MarshalIndent(inventory, "", "  ")
+}
+
+// WriteComplianceInventoryTo writes the inventory to the given file as JSON
+func WriteComplianceInventoryTo(store Store, inventory ECSComplianceInventory, filename string) error {
+	inventoryBytes, err := FormatComplianceInventory(inventory)
+	if err != nil {
+		return err
+	}
+	return store.WriteFile(filename, inventoryBytes, manifestFilePermissions)
+}
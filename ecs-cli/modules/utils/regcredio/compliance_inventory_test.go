@@ -0,0 +1,56 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteComplianceInventoryTo(t *testing.T) {
+	inventory := ECSComplianceInventory{
+		GeneratedAt: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		Source:      "ecs-cli registry-creds up",
+		Region:      "us-west-2",
+		Resources: []ComplianceResource{
+			{ResourceType: ComplianceResourceTypeSecret, ResourceARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:b", ResourceName: "b.example.com", Change: ComplianceChangeReused},
+			{ResourceType: ComplianceResourceTypeRole, ResourceARN: "arn:aws:iam::111111111111:role/myRole", ResourceName: "myRole", Change: ComplianceChangeCreated, Tags: map[string]string{"Team": "payments"}},
+			{ResourceType: ComplianceResourceTypeSecret, ResourceARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:a", ResourceName: "a.example.com", Change: ComplianceChangeCreated},
+		},
+	}
+	store := NewMemoryStore(nil)
+
+	err := WriteComplianceInventoryTo(store, inventory, "inventory.json")
+	assert.NoError(t, err, "Unexpected error writing compliance inventory")
+
+	data, err := store.ReadFile("inventory.json")
+	assert.NoError(t, err, "Expected the inventory to be written to the store")
+	var written ECSComplianceInventory
+	assert.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, ComplianceInventoryVersion, written.Version)
+	assert.Len(t, written.Resources, 3)
+	assert.Equal(t, "arn:aws:iam::111111111111:role/myRole", written.Resources[0].ResourceARN, "Expected resources sorted by type")
+	assert.Equal(t, "arn:aws:secretsmanager:us-west-2:111111111111:secret:a", written.Resources[1].ResourceARN, "Expected resources of a type sorted by ARN")
+	assert.Equal(t, map[string]string{}, written.Resources[1].Tags)
+	assert.Contains(t, string(data), `"tags": {}`, "Expected empty tags to be written as an object")
+}
+
+func TestFormatComplianceInventory_NoResources(t *testing.T) {
+	data, err := FormatComplianceInventory(ECSComplianceInventory{Region: "us-west-2"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"resources": []`)
+}
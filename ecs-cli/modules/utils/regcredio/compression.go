@@ -0,0 +1,75 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CompressedFileExtension is appended to the names of output and manifest files which are written gzip compressed
+const CompressedFileExtension = ".gz"
+
+// gzipMagic are the first bytes of gzip compressed data
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// CompressedFileName returns the name of the compressed file, e.g. 'manifest.json.gz'
+func CompressedFileName(filename string) string {
+	if isCompressedFileName(filename) {
+		return filename
+	}
+	return filename + CompressedFileExtension
+}
+
+func isCompressedFileName(filename string) bool {
+	return strings.HasSuffix(filename, CompressedFileExtension)
+}
+
+// compressForFile compresses the data if the file name ends with CompressedFileExtension
+func compressForFile(filename string, data []byte) ([]byte, error) {
+	if !isCompressedFileName(filename) {
+		return data, nil
+	}
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(data); err != nil {
+		return nil, errors.Wrapf(err, "Error compressing file '%v'", filename)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrapf(err, "Error compressing file '%v'", filename)
+	}
+	return compressed.Bytes(), nil
+}
+
+// decompress returns the data decompressed if it is gzip compressed, whatever the name of the file, and otherwise
+// returns it unchanged
+func decompress(filename string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error decompressing file '%v'", filename)
+	}
+	defer reader.Close()
+	decompressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error decompressing file '%v'", filename)
+	}
+	return decompressed, nil
+}
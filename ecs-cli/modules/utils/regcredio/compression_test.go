@@ -0,0 +1,91 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressedFileName(t *testing.T) {
+	assert.Equal(t, "manifest.json.gz", CompressedFileName("manifest.json"))
+	assert.Equal(t, "manifest.json.gz", CompressedFileName("manifest.json.gz"), "Expected extension not to be added twice")
+}
+
+func TestWriteManifestTo_Compressed(t *testing.T) {
+	store := NewMemoryStore(nil)
+	manifest := ECSRegCredsManifest{Region: "us-west-2", Roles: []ManifestRole{{RoleName: "myRole"}}}
+	assert.NoError(t, WriteManifestTo(store, manifest, "manifest.json.gz"), "Unexpected error writing compressed manifest")
+
+	data, err := store.ReadFile("manifest.json.gz")
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, gzipMagic), "Expected manifest to be gzip compressed")
+
+	readManifest, err := ReadManifestFrom(store, "manifest.json.gz")
+	assert.NoError(t, err, "Unexpected error reading compressed manifest")
+	assert.Equal(t, "us-west-2", readManifest.Region)
+	assert.Equal(t, "myRole", readManifest.Roles[0].RoleName)
+}
+
+func TestParseManifest_DecompressesWhateverTheFileName(t *testing.T) {
+	compressed, err := compressForFile("manifest.gz", []byte(`{"version": "2", "region": "us-west-2", "roles": [], "secrets": []}`))
+	assert.NoError(t, err)
+
+	manifest, err := ParseManifest(compressed, "manifest.json")
+	assert.NoError(t, err, "Unexpected error parsing compressed manifest")
+	assert.Equal(t, "us-west-2", manifest.Region)
+}
+
+func TestParseManifest_ErrorOnCorruptCompressedData(t *testing.T) {
+	_, err := ParseManifest(append(append([]byte{}, gzipMagic...), []byte("not gzip")...), "manifest.json.gz")
+	assert.Error(t, err, "Expected error on corrupt compressed manifest")
+}
+
+func TestGenerateCredsOutputTo_Compressed(t *testing.T) {
+	store := NewMemoryStore(nil)
+	testCreds := map[string]CredsOutputEntry{
+		"my.example.net": BuildOutputEntry("arn:aws:secretsmanager:secret/test", "", []string{"web"}),
+	}
+	createTime := time.Date(2019, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	err := GenerateCredsOutputTo(store, testCreds, nil, "/creds", CompressedFileName(OutputFileNameTemplate("", "")), "", &createTime)
+	assert.NoError(t, err, "Unexpected error when generating compressed creds output")
+
+	data, err := store.ReadFile("/creds/ecs-registry-creds_20190601T123000Z.yml.gz")
+	assert.NoError(t, err, "Expected compressed output file to be written to the store")
+	assert.True(t, bytes.HasPrefix(data, gzipMagic), "Expected output file to be gzip compressed")
+
+	// compressed output files are read transparently
+	tmpfile, err := ioutil.TempFile("", "ecs-registry-creds")
+	assert.NoError(t, err, "Unexpected error creating test file")
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, tmpfile.Close())
+
+	output, err := ReadCredsOutput(tmpfile.Name())
+	assert.NoError(t, err, "Unexpected error reading compressed creds output")
+	assert.Equal(t, "arn:aws:secretsmanager:secret/test", output.CredentialResources.ContainerCredentials["my.example.net"].CredentialARN)
+}
+
+func TestOutputFileNameTemplate(t *testing.T) {
+	assert.Equal(t, DefaultOutputFileNameTemplate, OutputFileNameTemplate("", ""))
+	assert.Equal(t, DefaultEnvironmentOutputFileNameTemplate, OutputFileNameTemplate("", "staging"))
+	assert.Equal(t, "{{.RoleName}}.yml", OutputFileNameTemplate("{{.RoleName}}.yml", "staging"))
+}
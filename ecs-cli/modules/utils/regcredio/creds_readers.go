@@ -14,9 +14,14 @@
 package regcredio
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
@@ -25,10 +30,44 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// CredsInputSchemaVersion is the latest schema version of the credential input file read by 'registry-creds'. Files
+// of older versions are migrated to it when they are read.
+const CredsInputSchemaVersion = "1"
+
+// RoleBundleVersion is the version of the role bundle format read by 'registry-creds up'
+const RoleBundleVersion = "1"
+
+// EndpointMapVersion is the version of the endpoint map format read by 'registry-creds'
+const EndpointMapVersion = "1"
+
+// LintRulesVersion is the version of the lint rules format read by 'registry-creds up'
+const LintRulesVersion = "1"
+
+// RetryPolicyVersion is the version of the retry policy format read by 'registry-creds'
+const RetryPolicyVersion = "1"
+
+// MaxRetryPolicyRetries is the largest 'max_retries' of a retry policy rule, so that a typo can't retry a request
+// for hours
+const MaxRetryPolicyRetries = 20
+
+// EndpointMapServices are the endpoint IDs of the services which can be given custom endpoints in an endpoint map
+var EndpointMapServices = []string{"iam", "kms", "secretsmanager", "sts"}
+
+const manifestVersionSingleRole = "1"
+
+// manifestV1 contains the fields of version 1 manifests which were replaced in later versions
+type manifestV1 struct {
+	Role *ManifestRole `json:"role"`
+}
+
 // ReadCredsInput parses 'registry-creds up' input into an ECSRegCredsInput struct
 func ReadCredsInput(filename string) (*ECSRegCredsInput, error) {
+	return ReadCredsInputFrom(FileStore{}, filename)
+}
 
-	rawCredsInput, err := ioutil.ReadFile(filename)
+// ReadCredsInputFrom reads the credential input file from the store
+func ReadCredsInputFrom(store Store, filename string) (*ECSRegCredsInput, error) {
+	rawCredsInput, err := store.ReadFile(filename)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
 	}
@@ -37,18 +76,304 @@ func ReadCredsInput(filename string) (*ECSRegCredsInput, error) {
 	if err = yaml.Unmarshal([]byte(rawCredsInput), &credsInput); err != nil {
 		return nil, errors.Wrapf(err, "Error unmarshalling yaml data from credential input file: %s", filename)
 	}
+	if err = migrateCredsInput(credsInput, filename); err != nil {
+		return nil, err
+	}
 
 	expandedCredsInput := RegistryCreds{}
 	for regName, credEntry := range credsInput.RegistryCredentials {
-		expandedCredEntry := expandCredEntry(credEntry)
+		expandedCredEntry, err := expandCredEntry(credEntry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error expanding environment variables for registry %s in credential input file: %s", regName, filename)
+		}
 		expandedCredsInput[regName] = expandedCredEntry
 	}
 
 	credsInput.RegistryCredentials = expandedCredsInput
 
+	for i, repository := range credsInput.ECRRepositories {
+		expandedRepository, err := expandEnvVars(repository)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error expanding environment variables for 'ecr_repositories' in credential input file: %s", filename)
+		}
+		credsInput.ECRRepositories[i] = expandedRepository
+	}
+
 	return credsInput, nil
 }
 
+// migrateCredsInput checks the schema version of the input and updates inputs of older versions to the latest one,
+// so that a field is never read with the meaning of another version. The version was given with 'version' before
+// 'schema_version' was added, and files which give neither are read as version 1, as they always have been.
+func migrateCredsInput(credsInput *ECSRegCredsInput, filename string) error {
+	schemaVersion := credsInput.SchemaVersion
+	if schemaVersion == "" {
+		schemaVersion = credsInput.Version
+	} else if credsInput.Version != "" && credsInput.Version != schemaVersion {
+		return fmt.Errorf("'version' (%s) and 'schema_version' (%s) in credential input file %s don't match; remove 'version', which is the older name of 'schema_version'", credsInput.Version, schemaVersion, filename)
+	}
+
+	switch schemaVersion {
+	case "", CredsInputSchemaVersion:
+	default:
+		return fmt.Errorf("unsupported schema version '%s' in credential input file %s; this version of the ECS CLI reads schema version %s or older, so upgrade the ECS CLI to read the file", schemaVersion, filename, CredsInputSchemaVersion)
+	}
+
+	credsInput.SchemaVersion = CredsInputSchemaVersion
+	credsInput.Version = CredsInputSchemaVersion
+	return nil
+}
+
+// ReadManifest parses a manifest written by 'registry-creds up' into an ECSRegCredsManifest struct
+func ReadManifest(filename string) (*ECSRegCredsManifest, error) {
+	return ReadManifestFrom(FileStore{}, filename)
+}
+
+// ReadManifestFrom reads the manifest from the store
+func ReadManifestFrom(store Store, filename string) (*ECSRegCredsManifest, error) {
+	rawManifest, err := store.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
+	}
+	return ParseManifest(rawManifest, filename)
+}
+
+// ParseManifest parses the contents of a manifest file, so that the exact contents whose signature was checked can
+// be used. Compressed contents are decompressed first.
+func ParseManifest(rawManifest []byte, filename string) (*ECSRegCredsManifest, error) {
+	rawManifest, err := decompress(filename, rawManifest)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &ECSRegCredsManifest{}
+	if err := json.Unmarshal(rawManifest, manifest); err != nil {
+		return nil, errors.Wrapf(err, "Error unmarshalling JSON data from manifest file: %s", filename)
+	}
+	switch manifest.Version {
+	case ECSRegCredsManifestVersion:
+	case manifestVersionSingleRole:
+		// version 1 manifests list at most one role
+		legacyManifest := manifestV1{}
+		if err := json.Unmarshal(rawManifest, &legacyManifest); err != nil {
+			return nil, errors.Wrapf(err, "Error unmarshalling JSON data from manifest file: %s", filename)
+		}
+		if legacyManifest.Role != nil {
+			manifest.Roles = []ManifestRole{*legacyManifest.Role}
+		}
+		manifest.Version = ECSRegCredsManifestVersion
+	default:
+		return nil, fmt.Errorf("unsupported manifest version '%s' in file %s", manifest.Version, filename)
+	}
+
+	return manifest, nil
+}
+
+// ReadManifestSignatureFrom reads the detached signature of a manifest from the store
+func ReadManifestSignatureFrom(store Store, filename string) (*ManifestSignature, error) {
+	rawSignature, err := store.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
+	}
+
+	signature := &ManifestSignature{}
+	if err = json.Unmarshal(rawSignature, signature); err != nil {
+		return nil, errors.Wrapf(err, "Error unmarshalling JSON data from manifest signature file: %s", filename)
+	}
+	if signature.Version != ManifestSignatureVersion {
+		return nil, fmt.Errorf("unsupported manifest signature version '%s' in file %s", signature.Version, filename)
+	}
+	if len(signature.Signature) == 0 || signature.SigningAlgorithm == "" {
+		return nil, fmt.Errorf("manifest signature file %s does not contain a signature", filename)
+	}
+
+	return signature, nil
+}
+
+// ReadRoleBundle parses a role bundle file into an ECSRoleBundle struct and validates its structure
+func ReadRoleBundle(filename string) (*ECSRoleBundle, error) {
+	return ReadRoleBundleFrom(FileStore{}, filename)
+}
+
+// ReadRoleBundleFrom reads the role bundle file from the store
+func ReadRoleBundleFrom(store Store, filename string) (*ECSRoleBundle, error) {
+	rawBundle, err := store.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
+	}
+
+	bundle := &ECSRoleBundle{}
+	if err = yaml.UnmarshalStrict(rawBundle, bundle); err != nil {
+		return nil, errors.Wrapf(err, "Error unmarshalling yaml data from role bundle file: %s", filename)
+	}
+	if err = validateRoleBundle(bundle); err != nil {
+		return nil, errors.Wrapf(err, "invalid role bundle file %s", filename)
+	}
+
+	return bundle, nil
+}
+
+func validateRoleBundle(bundle *ECSRoleBundle) error {
+	if bundle.Version != RoleBundleVersion {
+		return fmt.Errorf("unsupported version '%s'; supported version is '%s'", bundle.Version, RoleBundleVersion)
+	}
+
+	role := bundle.Role
+	if role.TrustPolicy != "" {
+		trustPolicy := struct {
+			Statement []interface{}
+		}{}
+		if err := json.Unmarshal([]byte(role.TrustPolicy), &trustPolicy); err != nil {
+			return errors.Wrap(err, "'trust_policy' is not a valid JSON policy document")
+		}
+		if len(trustPolicy.Statement) == 0 {
+			return errors.New("'trust_policy' must contain at least one statement")
+		}
+	}
+	if role.Path != "" && (!strings.HasPrefix(role.Path, "/") || !strings.HasSuffix(role.Path, "/")) {
+		return fmt.Errorf("'path' must begin and end with '/'; found '%s'", role.Path)
+	}
+	for key := range role.Tags {
+		if key == "" {
+			return errors.New("'tags' must not contain an empty key")
+		}
+	}
+	return nil
+}
+
+// ReadEndpointMapFrom reads an endpoint map file from the store and validates its endpoints
+func ReadEndpointMapFrom(store Store, filename string) (*ECSEndpointMap, error) {
+	rawEndpointMap, err := store.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
+	}
+
+	endpointMap := &ECSEndpointMap{}
+	if err = yaml.UnmarshalStrict(rawEndpointMap, endpointMap); err != nil {
+		return nil, errors.Wrapf(err, "Error unmarshalling yaml data from endpoint map file: %s", filename)
+	}
+	if err = validateEndpointMap(endpointMap); err != nil {
+		return nil, errors.Wrapf(err, "invalid endpoint map file %s", filename)
+	}
+
+	return endpointMap, nil
+}
+
+func validateEndpointMap(endpointMap *ECSEndpointMap) error {
+	if endpointMap.Version != EndpointMapVersion {
+		return fmt.Errorf("unsupported version '%s'; supported version is '%s'", endpointMap.Version, EndpointMapVersion)
+	}
+	if len(endpointMap.Endpoints) == 0 {
+		return errors.New("'endpoints' must contain at least one endpoint")
+	}
+	for service, endpoint := range endpointMap.Endpoints {
+		if !isEndpointMapService(service) {
+			return fmt.Errorf("unsupported service '%s' in 'endpoints'; supported services are %s", service, strings.Join(EndpointMapServices, ", "))
+		}
+		endpointURL, err := url.Parse(endpoint.URL)
+		if err != nil {
+			return errors.Wrapf(err, "invalid 'url' for service %s", service)
+		}
+		if (endpointURL.Scheme != "https" && endpointURL.Scheme != "http") || endpointURL.Host == "" {
+			return fmt.Errorf("'url' for service %s must be an absolute http or https URL; found '%s'", service, endpoint.URL)
+		}
+		if endpointURL.RawQuery != "" || endpointURL.Fragment != "" {
+			return fmt.Errorf("'url' for service %s must not have a query or fragment; found '%s'", service, endpoint.URL)
+		}
+		if endpoint.InsecureSkipVerify && endpointURL.Scheme != "https" {
+			return fmt.Errorf("'insecure_skip_verify' for service %s only applies to https endpoints", service)
+		}
+	}
+	return nil
+}
+
+func isEndpointMapService(service string) bool {
+	for _, supported := range EndpointMapServices {
+		if service == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadLintRulesFrom reads the lint rules file from the store
+func ReadLintRulesFrom(store Store, filename string) (*ECSLintRules, error) {
+	rawRules, err := store.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
+	}
+
+	rules := &ECSLintRules{}
+	if err = yaml.UnmarshalStrict(rawRules, rules); err != nil {
+		return nil, errors.Wrapf(err, "Error unmarshalling yaml data from lint rules file: %s", filename)
+	}
+	if rules.Version != LintRulesVersion {
+		return nil, fmt.Errorf("invalid lint rules file %s: unsupported version '%s'; supported version is '%s'", filename, rules.Version, LintRulesVersion)
+	}
+	for _, key := range rules.Rules.RequiredConditionKeys {
+		if key == "" {
+			return nil, fmt.Errorf("invalid lint rules file %s: 'required_condition_keys' must not contain an empty key", filename)
+		}
+	}
+	return rules, nil
+}
+
+// ReadRetryPolicyFrom reads a retry policy file from the store and validates its rules
+func ReadRetryPolicyFrom(store Store, filename string) (*ECSRetryPolicy, error) {
+	rawPolicy, err := store.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
+	}
+
+	policy := &ECSRetryPolicy{}
+	if err = yaml.UnmarshalStrict(rawPolicy, policy); err != nil {
+		return nil, errors.Wrapf(err, "Error unmarshalling yaml data from retry policy file: %s", filename)
+	}
+	if err = validateRetryPolicy(policy); err != nil {
+		return nil, errors.Wrapf(err, "invalid retry policy file %s", filename)
+	}
+
+	return policy, nil
+}
+
+func validateRetryPolicy(policy *ECSRetryPolicy) error {
+	if policy.Version != RetryPolicyVersion {
+		return fmt.Errorf("unsupported version '%s'; supported version is '%s'", policy.Version, RetryPolicyVersion)
+	}
+	if policy.Default == nil && len(policy.ErrorCodes) == 0 {
+		return errors.New("'default' or 'error_codes' must be given")
+	}
+	if policy.Default != nil {
+		if err := validateRetryRule(*policy.Default); err != nil {
+			return errors.Wrap(err, "invalid 'default'")
+		}
+	}
+	for code, rule := range policy.ErrorCodes {
+		if code == "" {
+			return errors.New("'error_codes' must not contain an empty code")
+		}
+		if err := validateRetryRule(rule); err != nil {
+			return errors.Wrapf(err, "invalid rule for error code %s", code)
+		}
+	}
+	return nil
+}
+
+func validateRetryRule(rule RetryRule) error {
+	if rule.MaxRetries == nil {
+		return errors.New("'max_retries' is required")
+	}
+	if *rule.MaxRetries < 0 || *rule.MaxRetries > MaxRetryPolicyRetries {
+		return fmt.Errorf("'max_retries' must be between 0 and %d; found %d", MaxRetryPolicyRetries, *rule.MaxRetries)
+	}
+	if rule.BaseDelay < 0 || rule.MaxDelay < 0 {
+		return errors.New("'base_delay' and 'max_delay' must not be negative")
+	}
+	if rule.MaxDelay > 0 && rule.BaseDelay > rule.MaxDelay {
+		return fmt.Errorf("'base_delay' (%s) must not be longer than 'max_delay' (%s)", rule.BaseDelay, rule.MaxDelay)
+	}
+	return nil
+}
+
 // ReadCredsOutput parses an ECS creds output file into an RegistryCredsOutput struct
 // TODO: use this to parse reg creds used with "compose" cmd
 func ReadCredsOutput(filename string) (*ECSRegistryCredsOutput, error) {
@@ -69,6 +394,14 @@ func ReadCredsOutput(filename string) (*ECSRegistryCredsOutput, error) {
 	if err != nil {
 		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
 	}
+	return parseCredsOutput(rawCredsOutput, filename)
+}
+
+func parseCredsOutput(rawCredsOutput []byte, filename string) (*ECSRegistryCredsOutput, error) {
+	rawCredsOutput, err := decompress(filename, rawCredsOutput)
+	if err != nil {
+		return nil, err
+	}
 
 	credsOutput := &ECSRegistryCredsOutput{}
 	if err = yaml.Unmarshal([]byte(rawCredsOutput), &credsOutput); err != nil {
@@ -78,17 +411,40 @@ func ReadCredsOutput(filename string) (*ECSRegistryCredsOutput, error) {
 	return credsOutput, nil
 }
 
-// FindLatestRegCredsOutputFile returns the newest ecs-registry-creds file in the current working directory
-func FindLatestRegCredsOutputFile(targetDir string) (string, error) {
-	searchPattern := ECSCredFileBaseName + "_*.yml"
-
-	// if targetDir defined, search there instead of current working directory
-	if targetDir != "" {
-		searchPattern = targetDir + string(os.PathSeparator) + searchPattern
+// ReadManifestOrCredsOutputFrom reads a file written by 'registry-creds up' which is either a manifest or an output
+// file from the store. Manifests are JSON objects and output files are YAML, so the file is read as a manifest if its
+// contents (once decompressed) start with '{'. Exactly one of the results is set if there is no error.
+func ReadManifestOrCredsOutputFrom(store Store, filename string) (*ECSRegCredsManifest, *ECSRegistryCredsOutput, error) {
+	rawFile, err := store.ReadFile(filename)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Error reading file '%v'", filename)
 	}
-	files, err := filepath.Glob(searchPattern)
+	contents, err := decompress(filename, rawFile)
 	if err != nil {
-		return "", err
+		return nil, nil, err
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(contents), []byte("{")) {
+		manifest, err := ParseManifest(contents, filename)
+		return manifest, nil, err
+	}
+	credsOutput, err := parseCredsOutput(contents, filename)
+	return nil, credsOutput, err
+}
+
+// FindLatestRegCredsOutputFile returns the newest ecs-registry-creds file in the current working directory; compressed
+// files are included
+func FindLatestRegCredsOutputFile(targetDir string) (string, error) {
+	var files []string
+	for _, searchPattern := range []string{ECSCredFileBaseName + "_*.yml", ECSCredFileBaseName + "_*.yml" + CompressedFileExtension} {
+		// if targetDir defined, search there instead of current working directory
+		if targetDir != "" {
+			searchPattern = targetDir + string(os.PathSeparator) + searchPattern
+		}
+		matches, err := filepath.Glob(searchPattern)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, matches...)
 	}
 
 	latestFileName := ""
@@ -106,7 +462,7 @@ func FindLatestRegCredsOutputFile(targetDir string) (string, error) {
 }
 
 func getTimeFromCredOutputFile(filename string) time.Time {
-	dateString := strings.TrimSuffix(strings.Split(filename, "_")[1], ".yml")
+	dateString := strings.TrimSuffix(strings.TrimSuffix(strings.Split(filename, "_")[1], CompressedFileExtension), ".yml")
 	outputTime, _ := time.Parse(ECSCredFileTimeFmt, dateString)
 
 	// if dateString can't be parsed, return empty Time struct
@@ -114,28 +470,86 @@ func getTimeFromCredOutputFile(filename string) time.Time {
 }
 
 // expandCredEntry checks if individual fields are env vars and if so, retrieves & sets that value
-func expandCredEntry(credEntry RegistryCredEntry) RegistryCredEntry {
-	expandedSecretARN := getValueOrEnvVar(credEntry.SecretManagerARN)
-	expandedUsername := getValueOrEnvVar(credEntry.Username)
-	expandedPassword := getValueOrEnvVar(credEntry.Password)
-	expandedKmsKeyID := getValueOrEnvVar(credEntry.KmsKeyID)
-	//TODO: look for env vars in container names?
-
-	expandedCredEntry := RegistryCredEntry{
-		SecretManagerARN: expandedSecretARN,
-		Username:         expandedUsername,
-		Password:         expandedPassword,
-		KmsKeyID:         expandedKmsKeyID,
-		ContainerNames:   credEntry.ContainerNames,
-	}
-	return expandedCredEntry
-}
-
-// selectively runs ExpandEnv() to avoid indescriminant replacement of substrings with '$'
-// e.g., password='c00l$tuff2018' -> return same; password='${MY_PASSWORD}' -> return env value.
-func getValueOrEnvVar(s string) string {
-	if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") {
-		return os.ExpandEnv(s)
-	}
-	return s
+func expandCredEntry(credEntry RegistryCredEntry) (RegistryCredEntry, error) {
+	expanded := RegistryCredEntry{RotationCompatible: credEntry.RotationCompatible, SecretScope: credEntry.SecretScope}
+	fields := []struct {
+		name  string
+		value string
+		dest  *string
+	}{
+		{"name", credEntry.Name, &expanded.Name},
+		{"secrets_manager_arn", credEntry.SecretManagerARN, &expanded.SecretManagerARN},
+		{"username", credEntry.Username, &expanded.Username},
+		{"password", credEntry.Password, &expanded.Password},
+		{"kms_key_id", credEntry.KmsKeyID, &expanded.KmsKeyID},
+	}
+	for _, field := range fields {
+		value, err := expandEnvVars(field.value)
+		if err != nil {
+			return RegistryCredEntry{}, errors.Wrapf(err, "invalid value for '%s'", field.name)
+		}
+		*field.dest = value
+	}
+
+	var err error
+	if expanded.ContainerNames, err = expandEnvVarsInList(credEntry.ContainerNames); err != nil {
+		return RegistryCredEntry{}, errors.Wrap(err, "invalid value for 'container_names'")
+	}
+	if expanded.Actions, err = expandEnvVarsInList(credEntry.Actions); err != nil {
+		return RegistryCredEntry{}, errors.Wrap(err, "invalid value for 'actions'")
+	}
+
+	return expanded, nil
+}
+
+func expandEnvVarsInList(values []string) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	expanded := make([]string, len(values))
+	for i, value := range values {
+		expandedValue, err := expandEnvVars(value)
+		if err != nil {
+			return nil, err
+		}
+		expanded[i] = expandedValue
+	}
+	return expanded, nil
+}
+
+// envVarReference matches '${VAR}' and '${VAR:-default}', optionally escaped with a leading '$'
+var envVarReference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ExpandEnvVars expands environment variable references in a value given outside of the input file, e.g. a role
+// name, in the same way as the values in the input file
+func ExpandEnvVars(s string) (string, error) {
+	return expandEnvVars(s)
+}
+
+// expandEnvVars replaces each '${VAR}' reference with the value of the environment variable, which must be set, and
+// each '${VAR:-default}' reference with the value of the variable or the default if it is unset or empty. Only the
+// braced format is expanded to avoid indiscriminate replacement of substrings with '$'; e.g. password='c00l$tuff2018'
+// is returned unchanged. '$${VAR}' is returned as the literal '${VAR}'.
+func expandEnvVars(s string) (string, error) {
+	var expandErr error
+	expanded := envVarReference.ReplaceAllStringFunc(s, func(reference string) string {
+		if strings.HasPrefix(reference, "$$") {
+			return reference[1:]
+		}
+		match := envVarReference.FindStringSubmatch(reference)
+		name, hasDefault, defaultValue := match[1], match[2] != "", match[3]
+
+		value, isSet := os.LookupEnv(name)
+		if hasDefault && value == "" {
+			return defaultValue
+		}
+		if !isSet && expandErr == nil {
+			expandErr = fmt.Errorf("environment variable '%s' is not set", name)
+		}
+		return value
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
 }
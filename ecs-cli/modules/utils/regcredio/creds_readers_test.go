@@ -17,6 +17,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -25,6 +26,7 @@ func TestReadCredsInput(t *testing.T) {
 	credsInputString := `version: 1
 registry_credentials:
   registry.io:
+    name: Registry IO pull
     username: some_user_name
     password: myl337p4$$w0rd!<bz*
     kms_key_id: aws:arn:kms:key/iuytre-jhgfd
@@ -54,6 +56,7 @@ registry_credentials:
 
 	firstRegResult := credsResult.RegistryCredentials["registry.io"]
 	assert.NotEmpty(t, firstRegResult)
+	assert.Equal(t, "Registry IO pull", firstRegResult.Name)
 	assert.Equal(t, "some_user_name", firstRegResult.Username)
 	assert.Equal(t, "myl337p4$$w0rd!<bz*", firstRegResult.Password)
 	assert.Equal(t, "aws:arn:kms:key/iuytre-jhgfd", firstRegResult.KmsKeyID)
@@ -61,6 +64,7 @@ registry_credentials:
 
 	otherRegResult := credsResult.RegistryCredentials["other-registry.net"]
 	assert.NotEmpty(t, otherRegResult)
+	assert.Empty(t, otherRegResult.Name)
 	assert.Equal(t, "aws:arn:secretsmanager:secret/repocreds-776ytg", otherRegResult.SecretManagerARN)
 	assert.Equal(t, 1, len(otherRegResult.ContainerNames))
 }
@@ -125,6 +129,76 @@ registry_credentials:
 	assert.Equal(t, 1, len(credEntry.ContainerNames))
 }
 
+func TestReadCredsInputWithEmbeddedEnvVarsAndDefaults(t *testing.T) {
+	os.Setenv("TEST_ACCOUNT_ID", "111111111111")
+	os.Setenv("TEST_EMPTY_STAGE", "")
+	os.Unsetenv("TEST_UNSET_REGION")
+	defer func() {
+		os.Unsetenv("TEST_ACCOUNT_ID")
+		os.Unsetenv("TEST_EMPTY_STAGE")
+	}()
+
+	inputFile := writeTestFile(t, `version: 1
+registry_credentials:
+  myrepo.someregistry.io:
+    name: registry-${TEST_EMPTY_STAGE:-dev}
+    secrets_manager_arn: arn:aws:secretsmanager:${TEST_UNSET_REGION:-us-west-2}:${TEST_ACCOUNT_ID}:secret:regsecret
+    password: c00l$${TEST_ACCOUNT_ID}$tuff
+    actions:
+      - $${TEST_ACCOUNT_ID}
+    rotation_compatible: true
+    container_names:
+      - web-${TEST_ACCOUNT_ID}`)
+	defer os.Remove(inputFile)
+
+	credsResult, err := ReadCredsInput(inputFile)
+	assert.NoError(t, err, "Unexpected error reading file")
+
+	credEntry := credsResult.RegistryCredentials["myrepo.someregistry.io"]
+	assert.Equal(t, "registry-dev", credEntry.Name, "Expected default for empty variable")
+	assert.Equal(t, "arn:aws:secretsmanager:us-west-2:111111111111:secret:regsecret", credEntry.SecretManagerARN)
+	assert.Equal(t, "c00l${TEST_ACCOUNT_ID}$tuff", credEntry.Password, "Expected escaped reference to be kept literally")
+	assert.Equal(t, []string{"${TEST_ACCOUNT_ID}"}, credEntry.Actions)
+	assert.True(t, credEntry.RotationCompatible)
+	assert.Equal(t, []string{"web-111111111111"}, credEntry.ContainerNames)
+}
+
+func TestReadCredsInputWithECRRepositories(t *testing.T) {
+	os.Setenv("TEST_ACCOUNT_ID", "111111111111")
+	defer os.Unsetenv("TEST_ACCOUNT_ID")
+
+	inputFile := writeTestFile(t, `version: 1
+registry_credentials:
+  myrepo.someregistry.io:
+    secrets_manager_arn: arn:aws:secretsmanager:us-west-2:111111111111:secret:regsecret
+    container_names:
+      - test
+ecr_repositories:
+  - arn:aws:ecr:us-west-2:${TEST_ACCOUNT_ID}:repository/nginx`)
+	defer os.Remove(inputFile)
+
+	credsResult, err := ReadCredsInput(inputFile)
+	assert.NoError(t, err, "Unexpected error reading file")
+	assert.Equal(t, []string{"arn:aws:ecr:us-west-2:111111111111:repository/nginx"}, credsResult.ECRRepositories)
+}
+
+func TestReadCredsInput_ErrorOnUnsetEnvVar(t *testing.T) {
+	os.Unsetenv("TEST_UNSET_ACCOUNT_ID")
+
+	inputFile := writeTestFile(t, `version: 1
+registry_credentials:
+  myrepo.someregistry.io:
+    secrets_manager_arn: arn:aws:secretsmanager:us-west-2:${TEST_UNSET_ACCOUNT_ID}:secret:regsecret
+    container_names:
+      - test`)
+	defer os.Remove(inputFile)
+
+	_, err := ReadCredsInput(inputFile)
+	assert.Error(t, err, "Expected error when a referenced environment variable is unset")
+	assert.Contains(t, err.Error(), "TEST_UNSET_ACCOUNT_ID")
+	assert.Contains(t, err.Error(), "secrets_manager_arn")
+}
+
 func TestReadCredsInput_ErrorFileNotFound(t *testing.T) {
 	var fakeFileName = "/missingFile"
 	_, err := ReadCredsInput(fakeFileName)
@@ -152,6 +226,54 @@ registry_credentials:
 	assert.Error(t, err, "Expected error on bad file YAML")
 }
 
+func TestReadCredsInputFrom_SchemaVersions(t *testing.T) {
+	entries := "registry_credentials:\n  registry.io:\n    secrets_manager_arn: arn:aws:secretsmanager:secret/creds\n    container_names:\n      - web"
+	testCases := map[string]string{
+		"no version":             entries,
+		"legacy version":         "version: \"1\"\n" + entries,
+		"schema version":         "schema_version: \"1\"\n" + entries,
+		"both versions matching": "version: \"1\"\nschema_version: \"1\"\n" + entries,
+	}
+	for description, input := range testCases {
+		t.Run(description, func(t *testing.T) {
+			store := NewMemoryStore(map[string][]byte{"creds.yml": []byte(input)})
+			credsInput, err := ReadCredsInputFrom(store, "creds.yml")
+			assert.NoError(t, err, "Unexpected error reading credential input")
+			assert.Equal(t, CredsInputSchemaVersion, credsInput.SchemaVersion)
+			assert.Equal(t, CredsInputSchemaVersion, credsInput.Version)
+			assert.Equal(t, "arn:aws:secretsmanager:secret/creds", credsInput.RegistryCredentials["registry.io"].SecretManagerARN)
+		})
+	}
+}
+
+func TestReadCredsInputFrom_ErrorOnUnsupportedSchemaVersion(t *testing.T) {
+	testCases := map[string]struct {
+		input    string
+		errorMsg string
+	}{
+		"future schema version": {
+			input:    "schema_version: \"2\"\nregistry_credentials: {}",
+			errorMsg: "unsupported schema version '2' in credential input file creds.yml",
+		},
+		"future legacy version": {
+			input:    "version: \"3\"\nregistry_credentials: {}",
+			errorMsg: "unsupported schema version '3' in credential input file creds.yml",
+		},
+		"versions don't match": {
+			input:    "version: \"1\"\nschema_version: \"2\"\nregistry_credentials: {}",
+			errorMsg: "'version' (1) and 'schema_version' (2) in credential input file creds.yml don't match",
+		},
+	}
+	for description, tc := range testCases {
+		t.Run(description, func(t *testing.T) {
+			store := NewMemoryStore(map[string][]byte{"creds.yml": []byte(tc.input)})
+			_, err := ReadCredsInputFrom(store, "creds.yml")
+			assert.Error(t, err, "Expected error reading credential input")
+			assert.Contains(t, err.Error(), tc.errorMsg)
+		})
+	}
+}
+
 func TestReadCredsOutput(t *testing.T) {
 	credsOutputString := `version: "1"
 registry_credential_outputs:
@@ -226,6 +348,224 @@ func TestReadCredsOutput_ErrorFileNotFound(t *testing.T) {
 	assert.Error(t, err, "Expected error on missing file")
 }
 
+func TestReadManifest_ErrorOnUnsupportedVersion(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "test")
+	assert.NoError(t, err, "Unexpected error in creating test file")
+	defer os.Remove(tmpfile.Name())
+
+	_, err = tmpfile.Write([]byte(`{"version":"99","region":"us-west-2","secrets":[]}`))
+	assert.NoError(t, err, "Unexpected error writing file")
+	err = tmpfile.Close()
+	assert.NoError(t, err, "Unexpected error closing file")
+
+	_, err = ReadManifest(tmpfile.Name())
+	assert.Error(t, err, "Expected error on unsupported manifest version")
+}
+
+func TestReadManifest_SingleRoleVersion(t *testing.T) {
+	manifestFile := writeTestFile(t, `{
+	"version": "1",
+	"region": "us-west-2",
+	"role": {"roleName": "myTestCredsRole", "created": true, "policyArn": "arn:aws:iam::111111111111:policy/myTestCredsRole-policy"},
+	"secrets": []
+}`)
+	defer os.Remove(manifestFile)
+
+	manifest, err := ReadManifest(manifestFile)
+	assert.NoError(t, err, "Unexpected error reading single role manifest")
+	assert.Equal(t, ECSRegCredsManifestVersion, manifest.Version)
+	assert.Equal(t, []ManifestRole{{
+		RoleName:  "myTestCredsRole",
+		Created:   true,
+		PolicyARN: "arn:aws:iam::111111111111:policy/myTestCredsRole-policy",
+	}}, manifest.Roles)
+}
+
+func TestReadManifest_ErrorFileNotFound(t *testing.T) {
+	_, err := ReadManifest("/missingFile")
+	assert.Error(t, err, "Expected error on missing file")
+}
+
+func TestReadManifestOrCredsOutputFrom(t *testing.T) {
+	compressedManifest, err := compressForFile("manifest.json.gz", []byte(`{"version":"2","region":"us-west-2","roles":[{"roleName":"myTestCredsRole"}],"secrets":[]}`))
+	assert.NoError(t, err, "Unexpected error compressing manifest")
+	store := NewMemoryStore(map[string][]byte{
+		"manifest.json.gz": compressedManifest,
+		"output.yml":       []byte("version: \"1\"\nregistry_credential_outputs:\n  task_execution_role: myTestCredsRole\n"),
+	})
+
+	manifest, credsOutput, err := ReadManifestOrCredsOutputFrom(store, "manifest.json.gz")
+	assert.NoError(t, err, "Unexpected error reading manifest")
+	assert.Nil(t, credsOutput)
+	if assert.NotNil(t, manifest) {
+		assert.Equal(t, "myTestCredsRole", manifest.Roles[0].RoleName)
+	}
+
+	manifest, credsOutput, err = ReadManifestOrCredsOutputFrom(store, "output.yml")
+	assert.NoError(t, err, "Unexpected error reading output file")
+	assert.Nil(t, manifest)
+	if assert.NotNil(t, credsOutput) {
+		assert.Equal(t, "myTestCredsRole", credsOutput.CredentialResources.TaskExecutionRole)
+	}
+
+	_, _, err = ReadManifestOrCredsOutputFrom(store, "missing.json")
+	assert.Error(t, err, "Expected error on missing file")
+}
+
+func TestReadRoleBundle(t *testing.T) {
+	bundleString := `version: "1"
+role:
+  path: /ecs/
+  permissions_boundary: arn:aws:iam::111111111111:policy/boundary
+  trust_policy: |
+    {"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"ecs-tasks.amazonaws.com"},"Action":"sts:AssumeRole"}]}
+  tags:
+    team: platform`
+
+	bundleFile := writeTestFile(t, bundleString)
+	defer os.Remove(bundleFile)
+
+	bundle, err := ReadRoleBundle(bundleFile)
+	assert.NoError(t, err, "Unexpected error reading role bundle")
+	assert.Equal(t, "/ecs/", bundle.Role.Path)
+	assert.Equal(t, "arn:aws:iam::111111111111:policy/boundary", bundle.Role.PermissionsBoundary)
+	assert.Contains(t, bundle.Role.TrustPolicy, "ecs-tasks.amazonaws.com")
+	assert.Equal(t, map[string]string{"team": "platform"}, bundle.Role.Tags)
+}
+
+func TestReadRoleBundle_Errors(t *testing.T) {
+	testCases := map[string]string{
+		"unknown field":       "version: \"1\"\nrole:\n  description: my role",
+		"unsupported version": "version: \"2\"\nrole:\n  path: /ecs/",
+		"invalid trust JSON":  "version: \"1\"\nrole:\n  trust_policy: '{not json'",
+		"empty trust policy":  "version: \"1\"\nrole:\n  trust_policy: '{\"Version\":\"2012-10-17\"}'",
+		"invalid path":        "version: \"1\"\nrole:\n  path: ecs",
+	}
+	for description, bundleString := range testCases {
+		t.Run(description, func(t *testing.T) {
+			bundleFile := writeTestFile(t, bundleString)
+			defer os.Remove(bundleFile)
+
+			_, err := ReadRoleBundle(bundleFile)
+			assert.Error(t, err, "Expected error reading invalid role bundle")
+		})
+	}
+}
+
+func TestReadEndpointMapFrom(t *testing.T) {
+	store := NewMemoryStore(map[string][]byte{"endpoints.yml": []byte(`version: "1"
+endpoints:
+  iam:
+    url: https://iam.mirror.internal
+  secretsmanager:
+    url: https://secretsmanager.mirror.internal:8443/
+    insecure_skip_verify: true
+`)})
+
+	endpointMap, err := ReadEndpointMapFrom(store, "endpoints.yml")
+	assert.NoError(t, err, "Unexpected error reading endpoint map")
+	assert.Equal(t, map[string]EndpointEntry{
+		"iam":            {URL: "https://iam.mirror.internal"},
+		"secretsmanager": {URL: "https://secretsmanager.mirror.internal:8443/", InsecureSkipVerify: true},
+	}, endpointMap.Endpoints)
+}
+
+func TestReadEndpointMapFrom_Errors(t *testing.T) {
+	testCases := map[string]string{
+		"unknown field":          "version: \"1\"\nendpoints:\n  iam:\n    url: https://iam.internal\n    region: us-west-2",
+		"unsupported version":    "version: \"2\"\nendpoints:\n  iam:\n    url: https://iam.internal",
+		"no endpoints":           "version: \"1\"",
+		"unsupported service":    "version: \"1\"\nendpoints:\n  s3:\n    url: https://s3.internal",
+		"relative url":           "version: \"1\"\nendpoints:\n  kms:\n    url: kms.internal",
+		"unsupported scheme":     "version: \"1\"\nendpoints:\n  kms:\n    url: ftp://kms.internal",
+		"url with query":         "version: \"1\"\nendpoints:\n  kms:\n    url: https://kms.internal/?a=b",
+		"insecure http endpoint": "version: \"1\"\nendpoints:\n  sts:\n    url: http://sts.internal\n    insecure_skip_verify: true",
+	}
+	for description, endpointMap := range testCases {
+		t.Run(description, func(t *testing.T) {
+			store := NewMemoryStore(map[string][]byte{"endpoints.yml": []byte(endpointMap)})
+			_, err := ReadEndpointMapFrom(store, "endpoints.yml")
+			assert.Error(t, err, "Expected error reading invalid endpoint map")
+		})
+	}
+}
+
+func TestReadRetryPolicyFrom(t *testing.T) {
+	store := NewMemoryStore(map[string][]byte{"retry.yml": []byte(`version: "1"
+default:
+  max_retries: 2
+error_codes:
+  Throttling:
+    max_retries: 8
+    base_delay: 500ms
+    max_delay: 20s
+  AccessDenied:
+    max_retries: 0
+`)})
+
+	policy, err := ReadRetryPolicyFrom(store, "retry.yml")
+	assert.NoError(t, err, "Unexpected error reading retry policy")
+	assert.Equal(t, 2, *policy.Default.MaxRetries)
+	assert.Equal(t, 8, *policy.ErrorCodes["Throttling"].MaxRetries)
+	assert.Equal(t, 500*time.Millisecond, policy.ErrorCodes["Throttling"].BaseDelay)
+	assert.Equal(t, 20*time.Second, policy.ErrorCodes["Throttling"].MaxDelay)
+	assert.Equal(t, 0, *policy.ErrorCodes["AccessDenied"].MaxRetries)
+}
+
+func TestReadRetryPolicyFrom_Errors(t *testing.T) {
+	testCases := map[string]string{
+		"unknown field":        "version: \"1\"\ndefault:\n  max_retries: 2\n  jitter: true",
+		"unsupported version":  "version: \"2\"\ndefault:\n  max_retries: 2",
+		"no rules":             "version: \"1\"",
+		"missing max retries":  "version: \"1\"\nerror_codes:\n  Throttling:\n    base_delay: 1s",
+		"negative max retries": "version: \"1\"\ndefault:\n  max_retries: -1",
+		"too many retries":     "version: \"1\"\ndefault:\n  max_retries: 100",
+		"invalid delay":        "version: \"1\"\ndefault:\n  max_retries: 2\n  base_delay: soon",
+		"base longer than max": "version: \"1\"\ndefault:\n  max_retries: 2\n  base_delay: 10s\n  max_delay: 1s",
+		"empty error code":     "version: \"1\"\nerror_codes:\n  \"\":\n    max_retries: 1",
+	}
+	for description, policy := range testCases {
+		t.Run(description, func(t *testing.T) {
+			store := NewMemoryStore(map[string][]byte{"retry.yml": []byte(policy)})
+			_, err := ReadRetryPolicyFrom(store, "retry.yml")
+			assert.Error(t, err, "Expected error reading invalid retry policy")
+		})
+	}
+}
+
+func TestReadLintRulesFrom(t *testing.T) {
+	store := NewMemoryStore(map[string][]byte{"lint.yml": []byte(`version: "1"
+rules:
+  allowed_wildcard_resources:
+    - arn:aws:secretsmanager:us-west-2:111111111111:secret:team/*
+  required_condition_keys:
+    - aws:SourceAccount
+`)})
+
+	rules, err := ReadLintRulesFrom(store, "lint.yml")
+	assert.NoError(t, err, "Unexpected error reading lint rules")
+	assert.Equal(t, LintRules{
+		AllowedWildcardResources: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:team/*"},
+		RequiredConditionKeys:    []string{"aws:SourceAccount"},
+	}, rules.Rules)
+}
+
+func TestReadLintRulesFrom_Errors(t *testing.T) {
+	testCases := map[string]string{
+		"unknown field":       "version: \"1\"\nrules:\n  allow_star: true",
+		"unsupported version": "version: \"2\"\nrules:\n  allow_wildcard_actions: true",
+		"empty required key":  "version: \"1\"\nrules:\n  required_condition_keys:\n    - \"\"",
+		"invalid yaml":        "version: [",
+	}
+	for description, rules := range testCases {
+		t.Run(description, func(t *testing.T) {
+			store := NewMemoryStore(map[string][]byte{"lint.yml": []byte(rules)})
+			_, err := ReadLintRulesFrom(store, "lint.yml")
+			assert.Error(t, err, "Expected error reading invalid lint rules")
+		})
+	}
+}
+
 func TestFindLatestRegCredsOutputFile(t *testing.T) {
 	testCases := []struct {
 		description    string
@@ -234,6 +574,7 @@ func TestFindLatestRegCredsOutputFile(t *testing.T) {
 	}{
 		{"Find latest of 3 valid output files", []string{"ecs-registry-creds_20171117T125102Z.yml", "ecs-registry-creds_20181012T215145Z.yml", "ecs-registry-creds_20181017T125102Z.yml"}, "ecs-registry-creds_20181017T125102Z.yml"},
 		{"Find latest valid file out of mixed valid/invalid output files", []string{"ecs-registry-creds_3.yml", "ecs-registry-creds_20181013T125105Z.yml"}, "ecs-registry-creds_20181013T125105Z.yml"},
+		{"Find latest of compressed and uncompressed output files", []string{"ecs-registry-creds_20181012T215145Z.yml", "ecs-registry-creds_20181017T125102Z.yml.gz"}, "ecs-registry-creds_20181017T125102Z.yml.gz"},
 		{"Return no file if no valid file found", []string{"ecs-registry-creds_3.yml", "ecs-registry-creds_TEST.yml"}, ""},
 		{"Return no file if no files found", []string{}, ""},
 	}
@@ -261,3 +602,16 @@ func TestFindLatestRegCredsOutputFile(t *testing.T) {
 		})
 	}
 }
+
+// writeTestFile writes the content to a new temp file; callers are responsible for removing it
+func writeTestFile(t *testing.T, content string) string {
+	tmpfile, err := ioutil.TempFile("", "test")
+	assert.NoError(t, err, "Unexpected error in creating test file")
+
+	_, err = tmpfile.Write([]byte(content))
+	assert.NoError(t, err, "Unexpected error writing file")
+	err = tmpfile.Close()
+	assert.NoError(t, err, "Unexpected error closing file")
+
+	return tmpfile.Name()
+}
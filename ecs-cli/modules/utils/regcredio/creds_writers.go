@@ -14,10 +14,15 @@
 package regcredio
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
 	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
@@ -27,23 +32,111 @@ const (
 	ECSCredFileTimeFmt = "20060102T150405Z"
 	// ECSCredFileBaseName is the base name of any private registry cred file produced or read by the ecs-cli
 	ECSCredFileBaseName = "ecs-registry-creds"
+	// DefaultOutputFileNameTemplate produces the timestamped output file name used when no template is given
+	DefaultOutputFileNameTemplate = ECSCredFileBaseName + "_{{.Timestamp}}.yml"
+	// DefaultEnvironmentOutputFileNameTemplate is used instead of the default template when output is written per
+	// environment; the timestamp stays first so that the latest file can still be found by its name
+	DefaultEnvironmentOutputFileNameTemplate = ECSCredFileBaseName + "_{{.Timestamp}}_{{.Environment}}.yml"
+	// ECSRegCredsManifestVersion is the version of the manifest format written by 'registry-creds up'
+	ECSRegCredsManifestVersion = "2"
+	// ManifestSignatureVersion is the version of the manifest signature format
+	ManifestSignatureVersion = "1"
+	// SidMapVersion is the version of the Sid map format written with '--emit-sid-map'
+	SidMapVersion = "1"
+
+	// the files list ARNs of credentials, so by default they are only readable by their owner
+	manifestFilePermissions = 0600
+	outputFilePermissions   = 0600
+	outputDirPermissions    = 0755
+
+	// both separators are rejected regardless of platform so that templates behave the same everywhere
+	outputFileNameSeparators = "/\\"
 )
 
-// GenerateCredsOutput marshals credential output JSON into YAML and outputs it to a file
-func GenerateCredsOutput(creds map[string]CredsOutputEntry, roleName, outputDir string, policyCreatTime *time.Time) error {
+// OutputFileNameData contains the values available to an output file name template
+type OutputFileNameData struct {
+	RoleName  string
+	Timestamp string
+	// Environment is only set when output is written per environment
+	Environment string
+}
+
+// GenerateCredsOutput marshals credential output JSON into YAML and outputs it to a file. Roles and environment are
+// optional, and if no file name template is given the default timestamped name is used. A file name ending with
+// CompressedFileExtension is written gzip compressed.
+func GenerateCredsOutput(creds map[string]CredsOutputEntry, roles []RoleOutputEntry, outputDir, fileNameTemplate, environment string, policyCreatTime *time.Time) error {
+	return GenerateCredsOutputTo(FileStore{}, creds, roles, outputDir, fileNameTemplate, environment, policyCreatTime)
+}
+
+// GenerateCredsOutputTo writes the output file to the store
+func GenerateCredsOutputTo(store Store, creds map[string]CredsOutputEntry, roles []RoleOutputEntry, outputDir, fileNameTemplate, environment string, policyCreatTime *time.Time) error {
+	return WriteCredsOutputTo(store, BuildCredsOutput(creds, roles), outputDir, fileNameTemplate, environment, policyCreatTime)
+}
+
+// BuildCredsOutput returns the contents of the output file for the registry credentials and roles
+func BuildCredsOutput(creds map[string]CredsOutputEntry, roles []RoleOutputEntry) ECSRegistryCredsOutput {
 	outputResources := CredResources{
 		ContainerCredentials: creds,
-		TaskExecutionRole:    roleName,
+		TaskExecutionRoles:   roles,
+	}
+	if len(roles) > 0 {
+		outputResources.TaskExecutionRole = roles[0].RoleName
+		outputResources.InstanceProfileARN = roles[0].InstanceProfileARN
 	}
-	regOutput := ECSRegistryCredsOutput{
+	return ECSRegistryCredsOutput{
 		Version:             "1",
 		CredentialResources: outputResources,
 	}
+}
+
+// FormatCredsOutputJSON returns the output as indented JSON. It is converted from the YAML of the output file, so that
+// the two have the same fields and values.
+func FormatCredsOutputJSON(regOutput ECSRegistryCredsOutput) (string, error) {
+	credBytes, err := yaml.Marshal(regOutput)
+	if err != nil {
+		return "", err
+	}
+	var document interface{}
+	if err = yaml.Unmarshal(credBytes, &document); err != nil {
+		return "", err
+	}
+	jsonBytes, err := json.MarshalIndent(jsonCompatible(document), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// jsonCompatible converts the maps of a YAML document, which may have keys of any type, to maps with string keys
+func jsonCompatible(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			converted[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range typed {
+			typed[i] = jsonCompatible(item)
+		}
+	}
+	return value
+}
+
+// WriteCredsOutputTo writes the output to a file in the store, named by the file name template (or the default
+// template) after its first role
+func WriteCredsOutputTo(store Store, regOutput ECSRegistryCredsOutput, outputDir, fileNameTemplate, environment string, policyCreatTime *time.Time) error {
 	credBytes, err := yaml.Marshal(regOutput)
 	if err != nil {
 		return err
 	}
+	return writeOutputFile(store, credBytes, regOutput.CredentialResources.TaskExecutionRole, outputDir, fileNameTemplate, environment, policyCreatTime)
+}
 
+// writeOutputFile writes the content of the output file to the store, named by the file name template (or the default
+// template) after the role
+func writeOutputFile(store Store, content []byte, roleName, outputDir, fileNameTemplate, environment string, policyCreatTime *time.Time) error {
 	outputFileDir := outputDir
 	if outputFileDir == "" {
 		wdir, err := os.Getwd()
@@ -57,21 +150,123 @@ func GenerateCredsOutput(creds map[string]CredsOutputEntry, roleName, outputDir
 	if policyCreatTime != nil {
 		timeStamp = *policyCreatTime
 	}
-	timestampedSuffix := fmt.Sprintf("_%s.yml", timeStamp.Format(ECSCredFileTimeFmt))
+	fileName, err := RenderOutputFileName(fileNameTemplate, roleName, environment, outputDir != "", timeStamp)
+	if err != nil {
+		return err
+	}
+
+	outputFilePath := filepath.Join(outputFileDir, fileName)
+	if content, err = compressForFile(outputFilePath, content); err != nil {
+		return err
+	}
+	log.Info("Writing registry credential output to new file " + outputFilePath)
+	return store.WriteFile(outputFilePath, content, outputFilePermissions)
+}
+
+// OutputFileNameTemplate returns the given output file name template, or the default template if none is given
+func OutputFileNameTemplate(fileNameTemplate, environment string) string {
+	if fileNameTemplate != "" {
+		return fileNameTemplate
+	}
+	if environment != "" {
+		return DefaultEnvironmentOutputFileNameTemplate
+	}
+	return DefaultOutputFileNameTemplate
+}
 
-	file, err := os.Create(outputFileDir + string(os.PathSeparator) + ECSCredFileBaseName + timestampedSuffix)
+// RenderOutputFileName returns the output file name produced by the template. The name may only contain path
+// separators if an output directory was given, and must stay within that directory.
+func RenderOutputFileName(fileNameTemplate, roleName, environment string, hasOutputDir bool, timestamp time.Time) (string, error) {
+	fileNameTemplate = OutputFileNameTemplate(fileNameTemplate, environment)
+	tmpl, err := template.New("output-file-name").Option("missingkey=error").Parse(fileNameTemplate)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid output file name template")
+	}
+
+	var fileName strings.Builder
+	data := OutputFileNameData{
+		RoleName:    roleName,
+		Timestamp:   timestamp.Format(ECSCredFileTimeFmt),
+		Environment: environment,
+	}
+	if err = tmpl.Execute(&fileName, data); err != nil {
+		return "", errors.Wrap(err, "invalid output file name template")
+	}
+
+	name := fileName.String()
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("output file name template '%s' produces an empty file name", fileNameTemplate)
+	}
+	if strings.ContainsAny(name, outputFileNameSeparators) {
+		if !hasOutputDir {
+			return "", fmt.Errorf("output file name '%s' contains a path separator; use an output directory to write the file to another directory", name)
+		}
+		cleanName := filepath.Clean(name)
+		if filepath.IsAbs(cleanName) || cleanName == ".." || strings.HasPrefix(cleanName, ".."+string(os.PathSeparator)) {
+			return "", fmt.Errorf("output file name '%s' must be within the output directory", name)
+		}
+	}
+
+	return name, nil
+}
+
+// WriteManifest writes the manifest of created resources as JSON to the given file, replacing any existing file. A
+// file name ending with CompressedFileExtension is written gzip compressed.
+func WriteManifest(manifest ECSRegCredsManifest, filename string) error {
+	return WriteManifestTo(FileStore{}, manifest, filename)
+}
+
+// WriteManifestTo writes the manifest to the store
+func WriteManifestTo(store Store, manifest ECSRegCredsManifest, filename string) error {
+	manifest.Version = ECSRegCredsManifestVersion
+	if manifest.Roles == nil {
+		manifest.Roles = []ManifestRole{}
+	}
+	if manifest.Secrets == nil {
+		manifest.Secrets = []ManifestSecret{}
+	}
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if manifestBytes, err = compressForFile(filename, manifestBytes); err != nil {
+		return err
+	}
+
+	log.Info("Writing manifest of created resources to file " + filename)
+	return store.WriteFile(filename, manifestBytes, manifestFilePermissions)
+}
+
+// ManifestSignatureFile returns the name of the detached signature file of a manifest, e.g. 'manifest.json.sig'
+func ManifestSignatureFile(manifestFile string) string {
+	return manifestFile + ".sig"
+}
+
+// WriteManifestSignatureTo writes the detached signature of a manifest to the store
+func WriteManifestSignatureTo(store Store, signature ManifestSignature, filename string) error {
+	signature.Version = ManifestSignatureVersion
+	signatureBytes, err := json.MarshalIndent(signature, "", "  ")
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	log.Info("Writing registry credential output to new file " + file.Name())
-	_, err = file.Write(credBytes)
+	log.Info("Writing manifest signature to file " + filename)
+	return store.WriteFile(filename, signatureBytes, manifestFilePermissions)
+}
+
+// WriteSidMapTo writes the Sid map of a generated policy to the store
+func WriteSidMapTo(store Store, sidMap ECSSidMap, filename string) error {
+	sidMap.Version = SidMapVersion
+	if sidMap.Statements == nil {
+		sidMap.Statements = []SidMapEntry{}
+	}
+	sidMapBytes, err := json.MarshalIndent(sidMap, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return nil
+	log.Info("Writing policy Sid map to file " + filename)
+	return store.WriteFile(filename, sidMapBytes, manifestFilePermissions)
 }
 
 // BuildOutputEntry returns a CredsOutputEntry with the provided parameters
@@ -14,6 +14,7 @@
 package regcredio
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -31,6 +32,20 @@ func TestGenerateCredsOutput(t *testing.T) {
 	defer os.RemoveAll(testOutputDir)
 
 	testRoleName := "myTestCredsRole"
+	testInstanceProfileARN := "arn:aws:iam::111111111111:instance-profile/myTestCredsRole"
+	testRoles := []RoleOutputEntry{
+		{
+			RoleName:           testRoleName,
+			PolicyARN:          "arn:aws:iam::111111111111:policy/myTestCredsRole-policy",
+			ManagedPolicyARN:   "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy",
+			InstanceProfileARN: testInstanceProfileARN,
+		},
+		{
+			RoleName:         "myOtherTestCredsRole",
+			PolicyARN:        "arn:aws:iam::111111111111:policy/myTestCredsRole-policy",
+			ManagedPolicyARN: "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy",
+		},
+	}
 	testCreds := make(map[string]CredsOutputEntry)
 	testReg1 := "my.example.net"
 	testReg2 := "example.io"
@@ -40,7 +55,7 @@ func TestGenerateCredsOutput(t *testing.T) {
 
 	// generate file
 	currTime := time.Now().UTC()
-	err = GenerateCredsOutput(testCreds, testRoleName, testOutputDir, &currTime)
+	err = GenerateCredsOutput(testCreds, testRoles, testOutputDir, "", "", &currTime)
 	assert.NoError(t, err, "Unexpected error when generating creds output")
 
 	// assert output file was produced, is legible
@@ -56,4 +71,128 @@ func TestGenerateCredsOutput(t *testing.T) {
 	assert.Equal(t, testCreds[testReg1], actualRegCreds.ContainerCredentials[testReg1])
 	assert.Equal(t, testCreds[testReg2], actualRegCreds.ContainerCredentials[testReg2])
 	assert.Equal(t, actualRegCreds.TaskExecutionRole, testRoleName)
+	assert.Equal(t, testInstanceProfileARN, actualRegCreds.InstanceProfileARN)
+	assert.Equal(t, testRoles, actualRegCreds.TaskExecutionRoles)
+}
+
+func TestGenerateCredsOutput_FileNameTemplate(t *testing.T) {
+	testOutputDir, err := ioutil.TempDir("", "test")
+	assert.NoError(t, err, "Unexpected error creating temp directory")
+	defer os.RemoveAll(testOutputDir)
+
+	testCreds := map[string]CredsOutputEntry{
+		"my.example.net": BuildOutputEntry("arn:aws:secretsmanager:secret/test", "", []string{"web"}),
+	}
+	err = GenerateCredsOutput(testCreds, []RoleOutputEntry{{RoleName: "myTestCredsRole"}}, testOutputDir, "creds/{{.RoleName}}-creds.yml", "", nil)
+	assert.NoError(t, err, "Unexpected error when generating creds output")
+
+	actualCredsOutput, err := ReadCredsOutput(filepath.Join(testOutputDir, "creds", "myTestCredsRole-creds.yml"))
+	assert.NoError(t, err, "Expected output file to be written to the rendered name")
+	assert.Equal(t, "myTestCredsRole", actualCredsOutput.CredentialResources.TaskExecutionRole)
+}
+
+func TestRenderOutputFileName(t *testing.T) {
+	timestamp := time.Date(2019, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		template     string
+		hasOutputDir bool
+		expectedName string
+		expectError  bool
+	}{
+		{"", false, "ecs-registry-creds_20190601T123000Z.yml", false},
+		{"{{.RoleName}}-creds.json", false, "myrole-creds.json", false},
+		{"{{.RoleName}}_{{.Timestamp}}.yml", false, "myrole_20190601T123000Z.yml", false},
+		{"creds/{{.RoleName}}.yml", true, "creds/myrole.yml", false},
+		{"creds/{{.RoleName}}.yml", false, "", true},
+		{`creds\{{.RoleName}}.yml`, false, "", true},
+		{"../{{.RoleName}}.yml", true, "", true},
+		{"/tmp/{{.RoleName}}.yml", true, "", true},
+		{"{{.Unknown}}.yml", false, "", true},
+		{"{{.RoleName", false, "", true},
+		{"{{.RoleName}}", false, "myrole", false},
+	}
+	for _, test := range testCases {
+		name, err := RenderOutputFileName(test.template, "myrole", "", test.hasOutputDir, timestamp)
+		if test.expectError {
+			assert.Error(t, err, "Expected error for template '%s'", test.template)
+			continue
+		}
+		assert.NoError(t, err, "Unexpected error for template '%s'", test.template)
+		assert.Equal(t, test.expectedName, name)
+	}
+
+	_, err := RenderOutputFileName("{{.RoleName}}", "", "", false, timestamp)
+	assert.Error(t, err, "Expected error when the rendered name is empty")
+}
+
+func TestRenderOutputFileName_Environment(t *testing.T) {
+	timestamp := time.Date(2019, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	name, err := RenderOutputFileName("", "myrole", "staging", false, timestamp)
+	assert.NoError(t, err, "Unexpected error for default template with environment")
+	assert.Equal(t, "ecs-registry-creds_20190601T123000Z_staging.yml", name)
+	assert.Equal(t, timestamp, getTimeFromCredOutputFile(name), "Expected timestamp to be read from the environment file name")
+
+	name, err = RenderOutputFileName("{{.Environment}}/{{.RoleName}}.yml", "myrole", "staging", true, timestamp)
+	assert.NoError(t, err, "Unexpected error for template with environment")
+	assert.Equal(t, "staging/myrole.yml", name)
+}
+
+func TestWriteManifest(t *testing.T) {
+	testOutputDir, err := ioutil.TempDir("", "test")
+	assert.NoError(t, err, "Unexpected error creating temp directory")
+	defer os.RemoveAll(testOutputDir)
+
+	manifestFile := filepath.Join(testOutputDir, "manifest.json")
+	testManifest := ECSRegCredsManifest{
+		CreatedAt: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC),
+		Region:    "us-west-2",
+		Roles: []ManifestRole{{
+			RoleName:           "myTestCredsRole",
+			RoleARN:            "arn:aws:iam::111111111111:role/myTestCredsRole",
+			Created:            true,
+			PolicyARN:          "arn:aws:iam::111111111111:policy/myTestCredsRole-policy",
+			AttachedPolicyARNs: []string{"arn:aws:iam::111111111111:policy/myTestCredsRole-policy"},
+		}},
+		Secrets: []ManifestSecret{
+			{RegistryName: "my.example.net", SecretARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:test"},
+		},
+	}
+
+	err = WriteManifest(testManifest, manifestFile)
+	assert.NoError(t, err, "Unexpected error when writing manifest")
+
+	actualManifest, err := ReadManifest(manifestFile)
+	assert.NoError(t, err, "Unexpected error reading manifest")
+	testManifest.Version = ECSRegCredsManifestVersion
+	assert.Equal(t, testManifest, *actualManifest)
+}
+
+func TestWriteSidMapTo(t *testing.T) {
+	store := NewMemoryStore(nil)
+	testSidMap := ECSSidMap{
+		CreatedAt: time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC),
+		PolicyARN: "arn:aws:iam::111111111111:policy/myTestCredsRole-policy",
+		Statements: []SidMapEntry{{
+			Sid:       "MyRegistry",
+			Registry:  "my.example.net",
+			Name:      "my registry",
+			SecretARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:test",
+			Effect:    "Allow",
+			Actions:   []string{"secretsmanager:GetSecretValue"},
+			Resources: []string{"arn:aws:secretsmanager:us-west-2:111111111111:secret:test"},
+		}},
+	}
+
+	err := WriteSidMapTo(store, testSidMap, "sid-map.json")
+	assert.NoError(t, err, "Unexpected error when writing Sid map")
+
+	data, err := store.ReadFile("sid-map.json")
+	assert.NoError(t, err, "Unexpected error reading Sid map")
+	actualSidMap := ECSSidMap{}
+	assert.NoError(t, json.Unmarshal(data, &actualSidMap))
+	testSidMap.Version = SidMapVersion
+	assert.Equal(t, testSidMap, actualSidMap)
+	assert.NotContains(t, string(data), "kmsKeyId", "Expected unset fields to be omitted")
 }
@@ -0,0 +1,79 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+//go:build windows
+// +build windows
+
+package regcredio
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// the vendored golang.org/x/sys/windows has no wrappers for these
+var (
+	modadvapi32 = windows.NewLazySystemDLL("advapi32.dll")
+
+	procConvertStringSecurityDescriptorToSecurityDescriptorW = modadvapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+	procSetFileSecurityW                                     = modadvapi32.NewProc("SetFileSecurityW")
+)
+
+const (
+	sddlRevision1           = 1
+	daclSecurityInformation = 0x4
+)
+
+// restrictFileAccess replaces the access control list of the file with one which only grants the current user access,
+// if the permissions give no access to the group or others. On Windows, the permission bits only control whether the
+// file is read-only, so the file would otherwise keep the access control list inherited from its directory.
+func restrictFileAccess(filename string, perm os.FileMode) error {
+	if perm&0077 != 0 {
+		return nil
+	}
+	token, err := windows.OpenCurrentProcessToken()
+	if err != nil {
+		return err
+	}
+	defer token.Close()
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return err
+	}
+	sid, err := user.User.Sid.String()
+	if err != nil {
+		return err
+	}
+
+	// a protected DACL, so that nothing is inherited from the directory, granting full access to the user only
+	sddl, err := windows.UTF16PtrFromString("D:P(A;;FA;;;" + sid + ")")
+	if err != nil {
+		return err
+	}
+	var descriptor uintptr
+	if r, _, err := procConvertStringSecurityDescriptorToSecurityDescriptorW.Call(uintptr(unsafe.Pointer(sddl)), sddlRevision1, uintptr(unsafe.Pointer(&descriptor)), 0); r == 0 {
+		return &os.PathError{Op: "ConvertStringSecurityDescriptorToSecurityDescriptor", Path: filename, Err: err}
+	}
+	defer windows.LocalFree(windows.Handle(descriptor))
+
+	name, err := windows.UTF16PtrFromString(filename)
+	if err != nil {
+		return err
+	}
+	if r, _, err := procSetFileSecurityW.Call(uintptr(unsafe.Pointer(name)), daclSecurityInformation, descriptor); r == 0 {
+		return &os.PathError{Op: "SetFileSecurity", Path: filename, Err: err}
+	}
+	return nil
+}
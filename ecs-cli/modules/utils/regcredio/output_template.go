@@ -0,0 +1,135 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OutputTemplateData contains the values available to an output template given with '--output-template'
+type OutputTemplateData struct {
+	// RoleARN is the ARN of the first role, which names the output file; it is empty if no role was set up
+	RoleARN string
+	Roles   []SummaryRole
+	// Entries are the registry credentials, by registry name
+	Entries map[string]CredsOutputEntry
+	// Tags are the tags given with '--tags', which were applied to the registry secrets
+	Tags   map[string]string
+	Region string
+	// Timestamp is the creation time of the new policies (or of the output, if none was created), in RFC 3339 format
+	Timestamp string
+}
+
+// outputTemplateFuncs are the functions available to an output template, in addition to the text/template builtins
+var outputTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(value interface{}) (string, error) {
+		jsonBytes, err := json.Marshal(value)
+		return string(jsonBytes), err
+	},
+}
+
+// NewOutputTemplateData returns the data of an output template. The first role, if any, names the output file.
+func NewOutputTemplateData(roles []SummaryRole, creds map[string]CredsOutputEntry, tags map[string]string, region string, createdAt time.Time) OutputTemplateData {
+	data := OutputTemplateData{
+		Roles:     roles,
+		Entries:   creds,
+		Tags:      tags,
+		Region:    region,
+		Timestamp: createdAt.UTC().Format(time.RFC3339),
+	}
+	if len(roles) > 0 {
+		data.RoleARN = roles[0].RoleARN
+	}
+	return data
+}
+
+// ReadOutputTemplateFrom reads and parses an output template from the store. The template is also rendered with
+// placeholder values, so that references to fields which don't exist are reported before any resources are created.
+func ReadOutputTemplateFrom(store Store, filename string) (*template.Template, error) {
+	rawTemplate, err := store.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading file '%v'", filename)
+	}
+
+	// a registry missing from Entries renders as an empty entry, so that the placeholder values can't fail on it
+	tmpl, err := template.New(filename).Option("missingkey=zero").Funcs(outputTemplateFuncs).Parse(string(rawTemplate))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid output template %s", filename)
+	}
+	if err = tmpl.Execute(ioutil.Discard, placeholderOutputTemplateData()); err != nil {
+		return nil, errors.Wrapf(err, "invalid output template %s", filename)
+	}
+	return tmpl, nil
+}
+
+// placeholderOutputTemplateData returns output template data with a value in every field, so that rendering it
+// evaluates the body of each range and if action
+func placeholderOutputTemplateData() OutputTemplateData {
+	role := SummaryRole{
+		RoleOutputEntry: RoleOutputEntry{
+			RoleName:             "role",
+			PolicyARN:            "policy",
+			PolicyName:           "policy",
+			ManagedPolicyARN:     "policy",
+			InstanceProfileARN:   "instance-profile",
+			AdditionalPolicyARNs: []string{"policy"},
+			ECRRepositories:      []string{"repository"},
+			RoleOnly:             true,
+		},
+		RoleARN: "role",
+		Created: true,
+		Tags:    map[string]string{"key": "value"},
+	}
+	entry := CredsOutputEntry{
+		Name:               "registry",
+		CredentialARN:      "secret",
+		KMSKeyID:           "key",
+		ContainerNames:     []string{"container"},
+		Actions:            []string{"action"},
+		RotationCompatible: true,
+		SecretScope:        &SecretScope{NamePrefix: "prefix", ResourceTags: map[string]string{"key": "value"}},
+	}
+	return NewOutputTemplateData([]SummaryRole{role}, map[string]CredsOutputEntry{"registry": entry}, map[string]string{"key": "value"}, "region", time.Time{})
+}
+
+// FormatOutputTemplate renders the output template with the given data
+func FormatOutputTemplate(tmpl *template.Template, data OutputTemplateData) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, errors.Wrap(err, "failed to render the output template")
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteOutputTemplateTo renders the output template and writes it to the output file in the store, named like the
+// file written by WriteCredsOutputTo
+func WriteOutputTemplateTo(store Store, tmpl *template.Template, data OutputTemplateData, outputDir, fileNameTemplate, environment string, policyCreatTime *time.Time) error {
+	content, err := FormatOutputTemplate(tmpl, data)
+	if err != nil {
+		return err
+	}
+	roleName := ""
+	if len(data.Roles) > 0 {
+		roleName = data.Roles[0].RoleName
+	}
+	return writeOutputFile(store, content, roleName, outputDir, fileNameTemplate, environment, policyCreatTime)
+}
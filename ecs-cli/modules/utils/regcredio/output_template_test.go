@@ -0,0 +1,79 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteOutputTemplateTo(t *testing.T) {
+	templateText := `role={{.RoleARN}}
+{{range $registry, $entry := .Entries}}{{$registry}}={{$entry.CredentialARN}} containers={{join $entry.ContainerNames ","}}
+{{end}}policies={{range .Roles}}{{json .AdditionalPolicyARNs}}{{end}}
+team={{.Tags.Team}} region={{.Region}} at={{.Timestamp}}
+`
+	store := NewMemoryStore(map[string][]byte{"template.txt": []byte(templateText)})
+	tmpl, err := ReadOutputTemplateFrom(store, "template.txt")
+	assert.NoError(t, err, "Unexpected error reading output template")
+
+	roles := []SummaryRole{{
+		RoleOutputEntry: RoleOutputEntry{
+			RoleName:             "myRole",
+			AdditionalPolicyARNs: []string{"arn:aws:iam::111111111111:policy/extra"},
+		},
+		RoleARN: "arn:aws:iam::111111111111:role/myRole",
+	}}
+	creds := map[string]CredsOutputEntry{
+		"second.example.com": {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:second", ContainerNames: []string{"web"}},
+		"first.example.com":  {CredentialARN: "arn:aws:secretsmanager:us-west-2:111111111111:secret:first", ContainerNames: []string{"web", "worker"}},
+	}
+	createdAt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := NewOutputTemplateData(roles, creds, map[string]string{"Team": "payments"}, "us-west-2", createdAt)
+
+	err = WriteOutputTemplateTo(store, tmpl, data, "out", "{{.RoleName}}.txt", "", &createdAt)
+	assert.NoError(t, err, "Unexpected error writing output template")
+	content, err := store.ReadFile("out/myRole.txt")
+	assert.NoError(t, err, "Expected the output file to be named after the first role")
+	expected := "role=arn:aws:iam::111111111111:role/myRole\n" +
+		"first.example.com=arn:aws:secretsmanager:us-west-2:111111111111:secret:first containers=web,worker\n" +
+		"second.example.com=arn:aws:secretsmanager:us-west-2:111111111111:secret:second containers=web\n" +
+		"policies=[\"arn:aws:iam::111111111111:policy/extra\"]\n" +
+		"team=payments region=us-west-2 at=2019-01-01T00:00:00Z\n"
+	assert.Equal(t, expected, string(content))
+}
+
+func TestReadOutputTemplateFrom_Errors(t *testing.T) {
+	store := NewMemoryStore(map[string][]byte{
+		"unclosed.txt":      []byte("{{.RoleARN"),
+		"unknown-field.txt": []byte("{{range .Roles}}{{.PolicyArn}}{{end}}"),
+		"unknown-func.txt":  []byte("{{upper .RoleARN}}"),
+		"missing-key.txt":   []byte("{{(index .Entries \"my.example.com\").CredentialARN}}{{.Tags.Team}}"),
+	})
+
+	_, err := ReadOutputTemplateFrom(store, "missing.txt")
+	assert.Error(t, err, "Expected error for a missing template file")
+	_, err = ReadOutputTemplateFrom(store, "unclosed.txt")
+	assert.Error(t, err, "Expected error for a template which doesn't parse")
+	_, err = ReadOutputTemplateFrom(store, "unknown-field.txt")
+	assert.Error(t, err, "Expected error for a field which doesn't exist, even inside a range")
+	_, err = ReadOutputTemplateFrom(store, "unknown-func.txt")
+	assert.Error(t, err, "Expected error for a function which doesn't exist")
+
+	// registries and tags depend on the input, so a missing key isn't an error
+	_, err = ReadOutputTemplateFrom(store, "missing-key.txt")
+	assert.NoError(t, err, "Unexpected error for a key which isn't in the placeholder values")
+}
@@ -0,0 +1,140 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Store reads and writes the input, output and manifest files of the registry-creds commands
+type Store interface {
+	ReadFile(filename string) ([]byte, error)
+	// WriteFile replaces the file with the given data, creating any missing parent directories
+	WriteFile(filename string, data []byte, perm os.FileMode) error
+}
+
+// FileStore is a Store backed by the local filesystem
+type FileStore struct{}
+
+// ReadFile reads the named file
+func (FileStore) ReadFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
+// WriteFile writes the named file, creating its parent directories if needed. The data is written to a temporary file
+// in the same directory which is then renamed, so that readers never see a partially written file, even if the
+// process is killed. On Windows, a file whose permissions only allow access by its owner is given an access control
+// list which only grants the current user access.
+func (FileStore) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, outputDirPermissions); err != nil {
+		return err
+	}
+
+	// the temporary file is hidden so that it isn't picked up as an output file
+	tempName := filepath.Join(dir, "."+filepath.Base(filename)+".tmp-"+strconv.Itoa(os.Getpid())+"-"+strconv.FormatInt(time.Now().UnixNano(), 10))
+	tempFile, err := os.OpenFile(tempName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	// access is restricted before anything is written, and is kept when the file is renamed
+	err = restrictFileAccess(tempName, perm)
+	if err == nil {
+		_, err = tempFile.Write(data)
+	}
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempName, filename)
+	}
+	if err != nil {
+		os.Remove(tempName)
+	}
+	return err
+}
+
+// WithFilePermissions returns a Store which writes files to the given store with the given permissions, instead of the
+// default permissions of each file. The umask still applies. On Windows, permissions which only allow access by the
+// owner give the file an access control list which only grants the current user access; otherwise the file keeps the
+// access control list inherited from its directory.
+func WithFilePermissions(store Store, perm os.FileMode) Store {
+	return permissionsStore{Store: store, perm: perm}
+}
+
+type permissionsStore struct {
+	Store
+	perm os.FileMode
+}
+
+// WriteFile writes the file with the permissions of the store
+func (s permissionsStore) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return s.Store.WriteFile(filename, data, s.perm)
+}
+
+// MemoryStore is a Store which keeps files in memory, so that the registry-creds commands can be tested without
+// filesystem access
+type MemoryStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemoryStore returns a MemoryStore containing the given files, keyed by name
+func NewMemoryStore(files map[string][]byte) *MemoryStore {
+	store := &MemoryStore{files: make(map[string][]byte, len(files))}
+	for name, data := range files {
+		store.files[filepath.Clean(name)] = data
+	}
+	return store
+}
+
+// ReadFile returns the contents of the named file, or an error satisfying os.IsNotExist if there is none
+func (s *MemoryStore) ReadFile(filename string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[filepath.Clean(filename)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: filename, Err: os.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// WriteFile stores a copy of the data under the file name; permissions are ignored
+func (s *MemoryStore) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[filepath.Clean(filename)] = append([]byte(nil), data...)
+	return nil
+}
+
+// FileNames returns the names of all files in the store, sorted
+func (s *MemoryStore) FileNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.files))
+	for name := range s.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
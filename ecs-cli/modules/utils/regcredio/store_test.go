@@ -0,0 +1,218 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore(map[string][]byte{"input.yml": []byte("version: \"1\"")})
+
+	data, err := store.ReadFile("./input.yml")
+	assert.NoError(t, err, "Unexpected error reading file from memory store")
+	assert.Equal(t, "version: \"1\"", string(data))
+
+	_, err = store.ReadFile("missing.yml")
+	assert.True(t, os.IsNotExist(err), "Expected not exist error for missing file")
+
+	assert.NoError(t, store.WriteFile("out/manifest.json", []byte("{}"), manifestFilePermissions))
+	assert.Equal(t, []string{"input.yml", filepath.Join("out", "manifest.json")}, store.FileNames())
+}
+
+func TestFileStore_WriteFileReplacesFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "regcreds-store")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "out", "manifest.json")
+	store := FileStore{}
+	assert.NoError(t, store.WriteFile(filename, []byte(`{"version":"1"}`), manifestFilePermissions))
+	assert.NoError(t, store.WriteFile(filename, []byte(`{"version":"2"}`), manifestFilePermissions))
+
+	data, err := store.ReadFile(filename)
+	assert.NoError(t, err, "Unexpected error reading written file")
+	assert.Equal(t, `{"version":"2"}`, string(data))
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "out"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(files), "Expected no temporary files to be left behind")
+}
+
+func TestWithFilePermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on Windows")
+	}
+	dir, err := ioutil.TempDir("", "regcreds-store")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(dir)
+
+	defaultFile := filepath.Join(dir, "default.yml")
+	assert.NoError(t, FileStore{}.WriteFile(defaultFile, []byte("version: \"1\""), outputFilePermissions))
+	info, err := os.Stat(defaultFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "Expected the output file to be readable only by its owner by default")
+
+	store := WithFilePermissions(FileStore{}, 0400)
+	filename := filepath.Join(dir, "out", "output.yml")
+	assert.NoError(t, store.WriteFile(filename, []byte("version: \"1\""), outputFilePermissions))
+	info, err = os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0400), info.Mode().Perm())
+
+	data, err := store.ReadFile(filename)
+	assert.NoError(t, err, "Unexpected error reading written file")
+	assert.Equal(t, "version: \"1\"", string(data))
+}
+
+func TestFileStore_WriteFileKeepsExistingFileOnError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "regcreds-store")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(dir)
+
+	// a directory can't be replaced by a file, so the rename fails
+	filename := filepath.Join(dir, "manifest.json")
+	assert.NoError(t, os.Mkdir(filename, outputDirPermissions))
+
+	err = FileStore{}.WriteFile(filename, []byte("{}"), manifestFilePermissions)
+	assert.Error(t, err, "Expected error when the file can't be replaced")
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(files), "Expected the temporary file to be removed")
+	assert.True(t, files[0].IsDir(), "Expected the existing entry to be kept")
+}
+
+func TestGenerateCredsOutputTo_MemoryStore(t *testing.T) {
+	store := NewMemoryStore(nil)
+	testCreds := map[string]CredsOutputEntry{
+		"my.example.net": BuildOutputEntry("arn:aws:secretsmanager:secret/test", "arn:aws:kms:key/test-546yrtgf", []string{"web"}),
+	}
+	testRoles := []RoleOutputEntry{{
+		RoleName:         "myTestCredsRole",
+		PolicyARN:        "arn:aws:iam::111111111111:policy/myTestCredsRole-policy",
+		ManagedPolicyARN: "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy",
+	}}
+	createTime := time.Date(2019, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	err := GenerateCredsOutputTo(store, testCreds, testRoles, "/creds", "", "", &createTime)
+	assert.NoError(t, err, "Unexpected error when generating creds output")
+
+	data, err := store.ReadFile("/creds/ecs-registry-creds_20190601T123000Z.yml")
+	assert.NoError(t, err, "Expected output file to be written to the store")
+	assert.Equal(t, `version: "1"
+registry_credential_outputs:
+  task_execution_role: myTestCredsRole
+  task_execution_roles:
+  - role_name: myTestCredsRole
+    policy_arn: arn:aws:iam::111111111111:policy/myTestCredsRole-policy
+    managed_policy_arn: arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy
+  container_credentials:
+    my.example.net:
+      credentials_parameter: arn:aws:secretsmanager:secret/test
+      kms_key_id: arn:aws:kms:key/test-546yrtgf
+      container_names:
+      - web
+`, string(data))
+}
+
+func TestFormatCredsOutputJSON(t *testing.T) {
+	testCreds := map[string]CredsOutputEntry{
+		"my.example.net": BuildOutputEntry("arn:aws:secretsmanager:secret/test", "", nil),
+	}
+	testRoles := []RoleOutputEntry{{RoleName: "myTestCredsRole", PolicyARN: "arn:aws:iam::111111111111:policy/myTestCredsRole-policy"}}
+
+	outputJSON, err := FormatCredsOutputJSON(BuildCredsOutput(testCreds, testRoles))
+	assert.NoError(t, err, "Unexpected error formatting creds output")
+	assert.Equal(t, `{
+  "registry_credential_outputs": {
+    "container_credentials": {
+      "my.example.net": {
+        "container_names": [],
+        "credentials_parameter": "arn:aws:secretsmanager:secret/test"
+      }
+    },
+    "task_execution_role": "myTestCredsRole",
+    "task_execution_roles": [
+      {
+        "policy_arn": "arn:aws:iam::111111111111:policy/myTestCredsRole-policy",
+        "role_name": "myTestCredsRole"
+      }
+    ]
+  },
+  "version": "1"
+}`, outputJSON)
+}
+
+func TestReadCredsInputFrom_MemoryStore(t *testing.T) {
+	store := NewMemoryStore(map[string][]byte{"input.yml": []byte(`version: "1"
+registry_credentials:
+  my.example.net:
+    secrets_manager_arn: arn:aws:secretsmanager:us-west-2:111111111111:secret:test
+    container_names:
+    - web
+`)})
+
+	credsInput, err := ReadCredsInputFrom(store, "input.yml")
+	assert.NoError(t, err, "Unexpected error reading input from memory store")
+	assert.Equal(t, "arn:aws:secretsmanager:us-west-2:111111111111:secret:test", credsInput.RegistryCredentials["my.example.net"].SecretManagerARN)
+}
+
+func TestWriteManifestTo_MemoryStore(t *testing.T) {
+	store := NewMemoryStore(nil)
+	manifest := ECSRegCredsManifest{
+		CreatedAt: time.Date(2019, 6, 1, 12, 30, 0, 0, time.UTC),
+		Region:    "us-west-2",
+	}
+
+	assert.NoError(t, WriteManifestTo(store, manifest, "manifest.json"))
+	readManifest, err := ReadManifestFrom(store, "manifest.json")
+	assert.NoError(t, err, "Unexpected error reading manifest from memory store")
+	assert.Equal(t, ECSRegCredsManifestVersion, readManifest.Version)
+	assert.Equal(t, "us-west-2", readManifest.Region)
+	assert.Empty(t, readManifest.Roles)
+}
+
+func TestWriteManifestSignatureTo_MemoryStore(t *testing.T) {
+	store := NewMemoryStore(nil)
+	signature := ManifestSignature{
+		KeyID:            "arn:aws:kms:us-west-2:111111111111:key/signing",
+		SigningAlgorithm: "ECDSA_SHA_256",
+		Signature:        []byte("signature"),
+	}
+
+	assert.NoError(t, WriteManifestSignatureTo(store, signature, ManifestSignatureFile("manifest.json")))
+	assert.Equal(t, []string{"manifest.json.sig"}, store.FileNames())
+	readSignature, err := ReadManifestSignatureFrom(store, "manifest.json.sig")
+	assert.NoError(t, err, "Unexpected error reading manifest signature from memory store")
+	assert.Equal(t, ManifestSignatureVersion, readSignature.Version)
+	assert.Equal(t, signature.KeyID, readSignature.KeyID)
+	assert.Equal(t, []byte("signature"), readSignature.Signature)
+}
+
+func TestReadManifestSignatureFrom_ErrorWithoutSignature(t *testing.T) {
+	store := NewMemoryStore(map[string][]byte{
+		"manifest.json.sig": []byte(`{"version": "1", "keyId": "arn:aws:kms:us-west-2:111111111111:key/signing"}`),
+	})
+
+	_, err := ReadManifestSignatureFrom(store, "manifest.json.sig")
+	assert.Error(t, err, "Expected error for a signature file without a signature")
+}
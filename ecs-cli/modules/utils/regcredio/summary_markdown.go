@@ -0,0 +1,144 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultSecretsManagerKey describes the key of secrets which were not given a KMS key
+const defaultSecretsManagerKey = "aws/secretsmanager (default)"
+
+// SummaryRole is a role in the markdown summary: its output file entry along with what the output file doesn't record
+type SummaryRole struct {
+	RoleOutputEntry
+	RoleARN string
+	Created bool
+	// Tags are the tags applied to the role by the run
+	Tags map[string]string
+}
+
+// WriteSummaryMarkdownTo writes a markdown summary of the roles and registry credentials of a run, for use in runbooks
+func WriteSummaryMarkdownTo(store Store, filename string, roles []SummaryRole, creds map[string]CredsOutputEntry, region string, createdAt time.Time) error {
+	return store.WriteFile(filename, []byte(FormatSummaryMarkdown(roles, creds, region, createdAt)), manifestFilePermissions)
+}
+
+// FormatSummaryMarkdown returns the markdown summary written by WriteSummaryMarkdownTo
+func FormatSummaryMarkdown(roles []SummaryRole, creds map[string]CredsOutputEntry, region string, createdAt time.Time) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "# Registry credentials\n\n")
+	fmt.Fprintf(buf, "Generated by `ecs-cli registry-creds up` in region `%s` on %s.\n", region, createdAt.UTC().Format(time.RFC3339))
+
+	fmt.Fprintf(buf, "\n## Task execution roles\n")
+	if len(roles) == 0 {
+		fmt.Fprintf(buf, "\nNo task execution role was set up.\n")
+	}
+	for _, role := range roles {
+		status := "reused"
+		if role.Created {
+			status = "created"
+		}
+		fmt.Fprintf(buf, "\n### %s\n\n", role.RoleName)
+		fmt.Fprintf(buf, "* ARN: %s\n", markdownCode(role.RoleARN))
+		fmt.Fprintf(buf, "* Status: %s\n", status)
+		if role.PolicyARN != "" {
+			fmt.Fprintf(buf, "* Generated policy: %s\n", markdownCode(role.PolicyARN))
+		}
+		if role.ManagedPolicyARN != "" {
+			fmt.Fprintf(buf, "* Managed policy: %s\n", markdownCode(role.ManagedPolicyARN))
+		}
+		for _, policyARN := range role.AdditionalPolicyARNs {
+			fmt.Fprintf(buf, "* Additional policy: %s\n", markdownCode(policyARN))
+		}
+		if role.InstanceProfileARN != "" {
+			fmt.Fprintf(buf, "* Instance profile: %s\n", markdownCode(role.InstanceProfileARN))
+		}
+		for _, repository := range role.ECRRepositories {
+			fmt.Fprintf(buf, "* ECR repository: %s\n", markdownCode(repository))
+		}
+		fmt.Fprintf(buf, "* Tags: %s\n", formatSummaryTags(role))
+	}
+
+	registries := make([]string, 0, len(creds))
+	for registry := range creds {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+
+	fmt.Fprintf(buf, "\n## Secrets\n\n")
+	if len(registries) == 0 {
+		fmt.Fprintf(buf, "No registry credentials were given.\n")
+	} else {
+		fmt.Fprintf(buf, "| Registry | Secret | KMS key | Containers |\n")
+		fmt.Fprintf(buf, "| --- | --- | --- | --- |\n")
+	}
+	keyRegistries := make(map[string][]string)
+	for _, registry := range registries {
+		entry := creds[registry]
+		key := entry.KMSKeyID
+		if key == "" {
+			key = defaultSecretsManagerKey
+		}
+		keyRegistries[key] = append(keyRegistries[key], registry)
+		fmt.Fprintf(buf, "| %s | %s | %s | %s |\n", markdownCell(registry), markdownCell(entry.CredentialARN), markdownCell(key), markdownCell(strings.Join(entry.ContainerNames, ", ")))
+	}
+
+	if len(keyRegistries) > 0 {
+		keys := make([]string, 0, len(keyRegistries))
+		for key := range keyRegistries {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintf(buf, "\n## KMS keys\n\n")
+		for _, key := range keys {
+			fmt.Fprintf(buf, "* %s: %s\n", markdownCode(key), strings.Join(keyRegistries[key], ", "))
+		}
+	}
+
+	return buf.String()
+}
+
+func formatSummaryTags(role SummaryRole) string {
+	if len(role.Tags) == 0 {
+		if !role.Created {
+			return "none applied (existing role)"
+		}
+		return "none"
+	}
+	keys := make([]string, 0, len(role.Tags))
+	for key := range role.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tags := make([]string, 0, len(keys))
+	for _, key := range keys {
+		tags = append(tags, markdownCode(key+"="+role.Tags[key]))
+	}
+	return strings.Join(tags, ", ")
+}
+
+func markdownCode(value string) string {
+	return "`" + strings.Replace(value, "`", "'", -1) + "`"
+}
+
+// markdownCell escapes the value for a table cell, where '|' would end the cell
+func markdownCell(value string) string {
+	return strings.Replace(value, "|", "\\|", -1)
+}
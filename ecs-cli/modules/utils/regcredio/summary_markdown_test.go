@@ -0,0 +1,106 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatSummaryMarkdown(t *testing.T) {
+	roles := []SummaryRole{
+		{
+			RoleOutputEntry: RoleOutputEntry{
+				RoleName:             "newRole",
+				PolicyARN:            "arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-newRole-policy-20190101T000000Z",
+				ManagedPolicyARN:     "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy",
+				AdditionalPolicyARNs: []string{"arn:aws:iam::111111111111:policy/extra"},
+			},
+			RoleARN: "arn:aws:iam::111111111111:role/newRole",
+			Created: true,
+			Tags:    map[string]string{"Team": "payments", "ManagedBy": "ecs-cli"},
+		},
+		{
+			RoleOutputEntry: RoleOutputEntry{
+				RoleName:         "existingRole",
+				ManagedPolicyARN: "arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy",
+			},
+			RoleARN: "arn:aws:iam::111111111111:role/existingRole",
+		},
+	}
+	creds := map[string]CredsOutputEntry{
+		"second.example.com": {
+			CredentialARN:  "arn:aws:secretsmanager:us-west-2:111111111111:secret:second",
+			ContainerNames: []string{"web"},
+		},
+		"first.example.com": {
+			CredentialARN:  "arn:aws:secretsmanager:us-west-2:111111111111:secret:first",
+			KMSKeyID:       "arn:aws:kms:us-west-2:111111111111:key/1234",
+			ContainerNames: []string{"web", "worker"},
+		},
+	}
+	createdAt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	expected := "# Registry credentials\n\n" +
+		"Generated by `ecs-cli registry-creds up` in region `us-west-2` on 2019-01-01T00:00:00Z.\n\n" +
+		"## Task execution roles\n\n" +
+		"### newRole\n\n" +
+		"* ARN: `arn:aws:iam::111111111111:role/newRole`\n" +
+		"* Status: created\n" +
+		"* Generated policy: `arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-newRole-policy-20190101T000000Z`\n" +
+		"* Managed policy: `arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy`\n" +
+		"* Additional policy: `arn:aws:iam::111111111111:policy/extra`\n" +
+		"* Tags: `ManagedBy=ecs-cli`, `Team=payments`\n\n" +
+		"### existingRole\n\n" +
+		"* ARN: `arn:aws:iam::111111111111:role/existingRole`\n" +
+		"* Status: reused\n" +
+		"* Managed policy: `arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy`\n" +
+		"* Tags: none applied (existing role)\n\n" +
+		"## Secrets\n\n" +
+		"| Registry | Secret | KMS key | Containers |\n" +
+		"| --- | --- | --- | --- |\n" +
+		"| first.example.com | arn:aws:secretsmanager:us-west-2:111111111111:secret:first | arn:aws:kms:us-west-2:111111111111:key/1234 | web, worker |\n" +
+		"| second.example.com | arn:aws:secretsmanager:us-west-2:111111111111:secret:second | aws/secretsmanager (default) | web |\n\n" +
+		"## KMS keys\n\n" +
+		"* `arn:aws:kms:us-west-2:111111111111:key/1234`: first.example.com\n" +
+		"* `aws/secretsmanager (default)`: second.example.com\n"
+
+	assert.Equal(t, expected, FormatSummaryMarkdown(roles, creds, "us-west-2", createdAt))
+}
+
+func TestFormatSummaryMarkdown_NoRolesOrCredentials(t *testing.T) {
+	summary := FormatSummaryMarkdown(nil, nil, "us-east-1", time.Now())
+
+	assert.Contains(t, summary, "No task execution role was set up.")
+	assert.Contains(t, summary, "No registry credentials were given.")
+	assert.NotContains(t, summary, "## KMS keys")
+}
+
+func TestWriteSummaryMarkdownTo(t *testing.T) {
+	store := NewMemoryStore(nil)
+	createdAt := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	creds := map[string]CredsOutputEntry{
+		"my|registry": {CredentialARN: "arn:aws:secretsmanager:us-east-1:111111111111:secret:mine"},
+	}
+
+	err := WriteSummaryMarkdownTo(store, "out/summary.md", nil, creds, "us-east-1", createdAt)
+	assert.NoError(t, err, "Unexpected error writing summary")
+
+	data, err := store.ReadFile("out/summary.md")
+	assert.NoError(t, err, "Expected summary to be written")
+	assert.Equal(t, FormatSummaryMarkdown(nil, creds, "us-east-1", createdAt), string(data))
+	assert.Contains(t, string(data), "| my\\|registry |", "Expected '|' to be escaped in table cells")
+}
@@ -0,0 +1,60 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TerraformImport is a resource set up by 'registry-creds up', with the address and ID to import it into Terraform with
+type TerraformImport struct {
+	// ResourceType is the type of the Terraform AWS provider resource, e.g. aws_iam_role
+	ResourceType string
+	// Name is the resource name in the address; it must be a valid Terraform identifier
+	Name string
+	// ID is the import ID the resource type expects, e.g. the role name for aws_iam_role
+	ID string
+}
+
+// Address returns the resource address of the import
+func (i TerraformImport) Address() string {
+	return i.ResourceType + "." + i.Name
+}
+
+// WriteTerraformImportsTo writes the script of 'terraform import' commands to the store
+func WriteTerraformImportsTo(store Store, filename string, imports []TerraformImport, createdAt time.Time) error {
+	return store.WriteFile(filename, []byte(FormatTerraformImports(imports, createdAt)), manifestFilePermissions)
+}
+
+// FormatTerraformImports returns a shell script running 'terraform import' for each resource, in order. The addresses
+// and IDs are quoted, so that the script can be run as is.
+func FormatTerraformImports(imports []TerraformImport, createdAt time.Time) string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "#!/bin/sh\n")
+	fmt.Fprintf(buf, "# Generated by 'ecs-cli registry-creds up' on %s.\n", createdAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(buf, "# Add a resource block for each address to your Terraform configuration before running these commands.\n")
+	fmt.Fprintf(buf, "set -e\n\n")
+	for _, i := range imports {
+		fmt.Fprintf(buf, "terraform import %s %s\n", shellQuote(i.Address()), shellQuote(i.ID))
+	}
+	return buf.String()
+}
+
+// shellQuote quotes the value for a POSIX shell
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,45 @@
+// Copyright 2015-2019 Amazon.com, Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package regcredio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTerraformImportsTo(t *testing.T) {
+	imports := []TerraformImport{
+		{ResourceType: "aws_iam_role", Name: "myTaskExecutionRole", ID: "myTaskExecutionRole"},
+		{ResourceType: "aws_iam_role_policy_attachment", Name: "myTaskExecutionRole_policy", ID: "myTaskExecutionRole/arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myTaskExecutionRole-policy-20190601T000000Z"},
+		{ResourceType: "aws_secretsmanager_secret", Name: "o_brien_example_com", ID: "arn:aws:secretsmanager:us-west-2:111111111111:secret:o'brien"},
+	}
+	store := NewMemoryStore(nil)
+
+	err := WriteTerraformImportsTo(store, "imports.sh", imports, time.Date(2019, time.June, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err, "Unexpected error writing terraform imports")
+	data, err := store.ReadFile("imports.sh")
+	require.NoError(t, err)
+	assert.Equal(t, `#!/bin/sh
+# Generated by 'ecs-cli registry-creds up' on 2019-06-01T00:00:00Z.
+# Add a resource block for each address to your Terraform configuration before running these commands.
+set -e
+
+terraform import 'aws_iam_role.myTaskExecutionRole' 'myTaskExecutionRole'
+terraform import 'aws_iam_role_policy_attachment.myTaskExecutionRole_policy' 'myTaskExecutionRole/arn:aws:iam::111111111111:policy/amazon-ecs-cli-setup-myTaskExecutionRole-policy-20190601T000000Z'
+terraform import 'aws_secretsmanager_secret.o_brien_example_com' 'arn:aws:secretsmanager:us-west-2:111111111111:secret:o'\''brien'
+`, string(data))
+}
@@ -13,12 +13,19 @@
 
 package regcredio
 
+import "time"
+
 /* ----------------- INPUT types ----------------- */
 
 // ECSRegCredsInput contains registry cred entries for creation and/or use in a task execution role
 type ECSRegCredsInput struct {
+	// SchemaVersion is the version of the input format the file was written for; see CredsInputSchemaVersion
+	SchemaVersion string `yaml:"schema_version"`
+	// Version is the name the schema version was given before 'schema_version' was added, and is still read
 	Version             string
 	RegistryCredentials RegistryCreds `yaml:"registry_credentials"`
+	// ECRRepositories are the ARNs of the ECR repositories pull access is limited to with '--include-ecr'
+	ECRRepositories []string `yaml:"ecr_repositories"`
 }
 
 // RegistryCreds is a map of registry names to RegCredEntry structs
@@ -26,11 +33,25 @@ type RegistryCreds map[string]RegistryCredEntry
 
 // RegistryCredEntry contains info needed to create an AWS Secrets Manager secret and match it to an ECS container(s)
 type RegistryCredEntry struct {
+	Name             string   `yaml:"name"`
 	SecretManagerARN string   `yaml:"secrets_manager_arn"`
 	Username         string   `yaml:"username"`
 	Password         string   `yaml:"password"`
 	KmsKeyID         string   `yaml:"kms_key_id"`
 	ContainerNames   []string `yaml:"container_names"`
+	Actions          []string `yaml:"actions"`
+	// RotationCompatible grants the additional access needed to read a secret which has automatic rotation enabled
+	RotationCompatible bool `yaml:"rotation_compatible"`
+	// SecretScope grants access to every secret matching the scope instead of only the entry's own secret
+	SecretScope *SecretScope `yaml:"secret_scope"`
+}
+
+// SecretScope matches the Secrets Manager secrets a registry entry grants access to by naming convention
+type SecretScope struct {
+	// NamePrefix restricts access to secrets whose name starts with the prefix
+	NamePrefix string `yaml:"name_prefix,omitempty"`
+	// ResourceTags restricts access to secrets with all of the given tags
+	ResourceTags map[string]string `yaml:"resource_tags,omitempty"`
 }
 
 // HasRequiredFields indicates whether the entry has the fields required to create or use registry credentials
@@ -54,12 +75,210 @@ type ECSRegistryCredsOutput struct {
 // CredResources contains the credential resources generated by "registry-creds up"
 type CredResources struct {
 	TaskExecutionRole    string                      `yaml:"task_execution_role"`
+	InstanceProfileARN   string                      `yaml:"instance_profile_arn,omitempty"`
+	TaskExecutionRoles   []RoleOutputEntry           `yaml:"task_execution_roles,omitempty"`
 	ContainerCredentials map[string]CredsOutputEntry `yaml:"container_credentials"`
 }
 
+// RoleOutputEntry maps a task execution role to the policies attached to it. TaskExecutionRole and
+// InstanceProfileARN always describe the first role, so files with several roles can still be used by 'compose'.
+type RoleOutputEntry struct {
+	RoleName           string `yaml:"role_name"`
+	PolicyARN          string `yaml:"policy_arn"`
+	PolicyName         string `yaml:"policy_name,omitempty"`
+	ManagedPolicyARN   string `yaml:"managed_policy_arn,omitempty"`
+	InstanceProfileARN string `yaml:"instance_profile_arn,omitempty"`
+	// AdditionalPolicyARNs are the existing policies attached with '--attach-policy'
+	AdditionalPolicyARNs []string `yaml:"additional_policy_arns,omitempty"`
+	// ECRRepositories are the repositories the policy grants pull access to with '--include-ecr'; '*' means all
+	ECRRepositories []string `yaml:"ecr_repositories,omitempty"`
+	// RoleOnly indicates that the role was set up with '--role-only', so no policy granting access to the registry
+	// credentials was created and PolicyARN is empty
+	RoleOnly bool `yaml:"role_only,omitempty"`
+	// RequestIDs are only set with '--output-include-request-ids'
+	RequestIDs *RoleRequestIDs `yaml:"request_ids,omitempty"`
+}
+
+// RoleRequestIDs are the AWS request IDs of the calls made to set up a role, for finding them in CloudTrail
+type RoleRequestIDs struct {
+	CreateRole   string `yaml:"create_role,omitempty"`
+	CreatePolicy string `yaml:"create_policy,omitempty"`
+	// AttachRolePolicy maps each policy attached by the run to the request ID of its attachment
+	AttachRolePolicy map[string]string `yaml:"attach_role_policy,omitempty"`
+}
+
 // CredsOutputEntry contains the credential ARN, key, and associated container names for a single registry
 type CredsOutputEntry struct {
+	Name           string   `yaml:"name,omitempty"`
 	CredentialARN  string   `yaml:"credentials_parameter"` //TODO: rename 'CredentialARN' to 'CredentialsParam' ?
 	KMSKeyID       string   `yaml:"kms_key_id,omitempty"`
 	ContainerNames []string `yaml:"container_names"`
+	Actions        []string `yaml:"actions,omitempty"`
+	// RotationCompatible is copied from the input entry
+	RotationCompatible bool `yaml:"rotation_compatible,omitempty"`
+	// SecretScope is copied from the input entry
+	SecretScope *SecretScope `yaml:"secret_scope,omitempty"`
+}
+
+/* ----------------- MANIFEST types ----------------- */
+
+// ECSRegCredsManifest lists the resources created by a single "registry-creds up" run so that exactly those
+// resources, and none that the run merely reused, can later be removed
+type ECSRegCredsManifest struct {
+	Version   string           `json:"version"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Region    string           `json:"region"`
+	Roles     []ManifestRole   `json:"roles"`
+	Secrets   []ManifestSecret `json:"secrets"`
+}
+
+// ManifestRole describes a task execution role used by a run and the changes made to it
+type ManifestRole struct {
+	RoleName string `json:"roleName"`
+	// RoleARN is only known for roles created by the run
+	RoleARN string `json:"roleArn,omitempty"`
+	// Created indicates whether the role was created by the run; existing roles are never deleted
+	Created bool `json:"created"`
+	// Imported indicates that the role and policy were adopted with 'registry-creds import' rather than created by a run
+	Imported bool `json:"imported,omitempty"`
+	// PolicyARN is the registry credentials policy created by the run, which is shared by all roles of the run
+	PolicyARN string `json:"policyArn"`
+	// AttachedPolicyARNs are the policies the run attached to the role
+	AttachedPolicyARNs []string `json:"attachedPolicyArns"`
+	// PolicyDocumentSHA256 is the digest of the document of PolicyARN, so that 'registry-creds check' can tell if the
+	// policy was changed; manifests written before it was recorded don't have it
+	PolicyDocumentSHA256 string `json:"policyDocumentSha256,omitempty"`
+	// Tags are the tags the run applied to the role
+	Tags map[string]string `json:"tags,omitempty"`
+	// InstanceProfile is only set if the run created an instance profile or added the role to one
+	InstanceProfile *ManifestInstanceProfile `json:"instanceProfile,omitempty"`
+}
+
+// ManifestInstanceProfile describes the instance profile wrapping the role and the changes made to it
+type ManifestInstanceProfile struct {
+	Name string `json:"name"`
+	ARN  string `json:"arn"`
+	// Created indicates whether the instance profile was created by the run; existing profiles are never deleted
+	Created bool `json:"created"`
+	// RoleAdded indicates whether the run added the role to the instance profile
+	RoleAdded bool `json:"roleAdded"`
+}
+
+// ManifestSecret describes a secret created by the run; existing secrets used or updated by the run are not listed
+type ManifestSecret struct {
+	RegistryName string `json:"registryName"`
+	SecretARN    string `json:"secretArn"`
+}
+
+// ManifestSignature is the detached signature of a manifest, written next to the manifest by 'registry-creds up'
+type ManifestSignature struct {
+	Version string `json:"version"`
+	// KeyID is the ARN of the asymmetric KMS key which signed the manifest
+	KeyID            string `json:"keyId"`
+	SigningAlgorithm string `json:"signingAlgorithm"`
+	// Signature signs the digest of the manifest file contents, and is written base64 encoded
+	Signature []byte `json:"signature"`
+}
+
+/* ----------------- ROLE BUNDLE types ----------------- */
+
+// ECSRoleBundle declares the configuration of a new task execution role in a single file
+type ECSRoleBundle struct {
+	Version string
+	Role    RoleBundleEntry `yaml:"role"`
+}
+
+// RoleBundleEntry contains the settings applied when creating a task execution role
+type RoleBundleEntry struct {
+	TrustPolicy         string            `yaml:"trust_policy"`
+	PermissionsBoundary string            `yaml:"permissions_boundary"`
+	Path                string            `yaml:"path"`
+	Tags                map[string]string `yaml:"tags"`
+}
+
+/* ----------------- SID MAP types ----------------- */
+
+// ECSSidMap maps each statement of the policy generated by 'registry-creds up' to the registry credentials it grants
+// access to, so that statements can be traced back to the input file
+type ECSSidMap struct {
+	Version    string        `json:"version"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	PolicyARN  string        `json:"policyArn"`
+	Statements []SidMapEntry `json:"statements"`
+}
+
+// SidMapEntry describes a single statement of a generated policy
+type SidMapEntry struct {
+	Sid string `json:"sid"`
+	// Registry, Name, SecretARN and KMSKeyID are only set for statements granting access to registry credentials;
+	// Name is the name given to the registry in the input file, if any
+	Registry  string   `json:"registry,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	SecretARN string   `json:"secretArn,omitempty"`
+	KMSKeyID  string   `json:"kmsKeyId,omitempty"`
+	Effect    string   `json:"effect"`
+	Actions   []string `json:"actions"`
+	Resources []string `json:"resources"`
+}
+
+/* ----------------- ENDPOINT MAP types ----------------- */
+
+// ECSEndpointMap sets custom endpoints for the AWS services used by 'registry-creds', e.g. those of an internal mirror
+type ECSEndpointMap struct {
+	Version string
+	// Endpoints maps the endpoint ID of a service (e.g. 'secretsmanager') to its endpoint
+	Endpoints map[string]EndpointEntry `yaml:"endpoints"`
+}
+
+// EndpointEntry is the custom endpoint of a single service
+type EndpointEntry struct {
+	URL string `yaml:"url"`
+	// InsecureSkipVerify disables verification of the TLS certificate of the endpoint
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+/* ----------------- LINT RULES types ----------------- */
+
+// ECSLintRules configures the checks 'registry-creds up --lint' runs on the generated policy before it is created
+type ECSLintRules struct {
+	Version string
+	Rules   LintRules `yaml:"rules"`
+}
+
+// LintRules are the least-privilege rules of the generated policy's Allow statements
+type LintRules struct {
+	// AllowWildcardActions allows actions containing '*', e.g. '*:*' or 'secretsmanager:Get*'
+	AllowWildcardActions bool `yaml:"allow_wildcard_actions"`
+	// AllowedWildcardResources are the resources containing '*' which may be granted, e.g. the resources of a
+	// 'secret_scope'
+	AllowedWildcardResources []string `yaml:"allowed_wildcard_resources"`
+	// WildcardResourceActions are the actions which may be granted on resources containing '*', since they can't be
+	// limited to a resource; if not set, the linter's defaults are used
+	WildcardResourceActions []string `yaml:"wildcard_resource_actions"`
+	// RequiredConditionKeys must each be in the condition of every Allow statement, with any operator
+	RequiredConditionKeys []string `yaml:"required_condition_keys"`
+}
+
+/* ----------------- RETRY POLICY types ----------------- */
+
+// ECSRetryPolicy configures how the AWS clients of 'registry-creds' retry failed requests, by error code
+type ECSRetryPolicy struct {
+	Version string
+	// Default applies to the errors the SDK retries whose code isn't in ErrorCodes; if not set, the SDK's defaults are
+	// used
+	Default *RetryRule `yaml:"default"`
+	// ErrorCodes maps an error code (e.g. 'Throttling') to its rule. Listed codes are retried as their rule says, even
+	// if the SDK would not retry them.
+	ErrorCodes map[string]RetryRule `yaml:"error_codes"`
+}
+
+// RetryRule is the retry behavior of a set of errors. The delay before each retry starts at BaseDelay and doubles
+// with every retry, up to MaxDelay.
+type RetryRule struct {
+	// MaxRetries is the number of retries after the first attempt; 0 disables retries
+	MaxRetries *int `yaml:"max_retries"`
+	// BaseDelay is the delay before the first retry; if not set, the SDK's delays are used
+	BaseDelay time.Duration `yaml:"base_delay"`
+	// MaxDelay caps the delay before each retry; if not set, the SDK's maximum of 5 minutes is used
+	MaxDelay time.Duration `yaml:"max_delay"`
 }
@@ -62,6 +62,14 @@ func EntityAlreadyExists(err error) bool {
 	return false
 }
 
+// EntityNotFound returns true if an error indicates that the AWS resource does not exist
+func EntityNotFound(err error) bool {
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == "NoSuchEntity" || awsErr.Code() == "ResourceNotFoundException"
+	}
+	return false
+}
+
 // ParseTags parses AWS Resource tags from the flag value
 // users specify tags in this format: key1=value1,key2=value2,key3=value3
 func ParseTags(flagValue string, tags []*ecs.Tag) ([]*ecs.Tag, error) {